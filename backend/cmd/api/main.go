@@ -1,17 +1,28 @@
 package main
 
 import (
+	"errors"
 	"flag"
+	"fmt"
+	"os"
 
 	"go.uber.org/zap"
 
 	"github.com/cloudhut/common/flagext"
 	"github.com/cloudhut/kowl/backend/pkg/api"
+	"github.com/cloudhut/kowl/backend/pkg/kafka"
 )
 
 func main() {
 	startupLogger := zap.NewExample()
 
+	// The validate-config subcommand only loads and validates the config without connecting to any external service
+	validateOnly := false
+	if len(os.Args) > 1 && os.Args[1] == "validate-config" {
+		validateOnly = true
+		os.Args = append(os.Args[:1], os.Args[2:]...)
+	}
+
 	cfg := &api.Config{}
 	cfg.SetDefaults()
 	flagext.RegisterFlags(cfg)
@@ -27,6 +38,9 @@ func main() {
 		startupLogger.Fatal("failed to load yaml config", zap.Error(err))
 	}
 	err = cfg.Validate()
+	if validateOnly {
+		os.Exit(printValidationResult(err))
+	}
 	if err != nil {
 		startupLogger.Fatal("failed to validate config", zap.Error(err))
 	}
@@ -34,3 +48,23 @@ func main() {
 	a := api.New(cfg)
 	a.Start()
 }
+
+// printValidationResult prints every validation problem on a separate line and returns the exit code
+func printValidationResult(err error) int {
+	if err == nil {
+		fmt.Println("config is valid")
+		return 0
+	}
+
+	var validationErrs kafka.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		fmt.Printf("config is invalid, found %d problems:\n", len(validationErrs))
+		for _, validationErr := range validationErrs {
+			fmt.Printf("  - %v\n", validationErr)
+		}
+		return 1
+	}
+
+	fmt.Printf("config is invalid: %v\n", err)
+	return 1
+}
@@ -1,10 +1,16 @@
 package api
 
 import (
+	"net/http"
+	"sync"
+
 	"github.com/cloudhut/common/logging"
 	"github.com/cloudhut/common/rest"
+	"github.com/cloudhut/kowl/backend/pkg/audit"
+	"github.com/cloudhut/kowl/backend/pkg/connect"
 	"github.com/cloudhut/kowl/backend/pkg/git"
 	"github.com/cloudhut/kowl/backend/pkg/kafka"
+	"github.com/cloudhut/kowl/backend/pkg/oidc"
 	"github.com/cloudhut/kowl/backend/pkg/owl"
 	"go.uber.org/zap"
 )
@@ -13,11 +19,35 @@ import (
 type API struct {
 	Cfg *Config
 
-	Logger   *zap.Logger
+	Logger *zap.Logger
+
+	// KafkaSvc and OwlSvc are the services of the default cluster. Handlers must use the services of the requested
+	// cluster instead, see API.kafkaSvc and API.owlSvc.
 	KafkaSvc *kafka.Service
 	OwlSvc   *owl.Service
 	GitSvc   *git.Service
 
+	// ConnectSvc monitors the configured Kafka Connect clusters, which are shared by all Kafka clusters
+	ConnectSvc *connect.Service
+
+	// OIDCSvc authenticates the users if the OIDC login is enabled
+	OIDCSvc *oidc.Service
+
+	// clusters are all served clusters by name, clusterNames is the configured order of the clusters. The first
+	// cluster is the default cluster.
+	clusters     map[string]*cluster
+	clusterNames []string
+
+	// consumeRateLimiter limits the consume requests per principal, it is nil if rate limiting is disabled
+	consumeRateLimiter *consumeRateLimiter
+
+	// topicFilter hides and rejects topics which are not allowed by the topics config. It applies to all clusters alike,
+	// as do the RBAC hooks.
+	topicFilter *topicFilter
+
+	// auditLogger records mutating operations, it is nil if the audit log is disabled
+	auditLogger *audit.Logger
+
 	Hooks *Hooks // Hooks to add additional functionality from the outside at different places (used by Kafka Owl Business)
 
 	version versionInfo
@@ -46,32 +76,109 @@ func New(cfg *Config) *API {
 		)
 	}
 
-	kafkaSvc, err := kafka.NewService(cfg.Kafka, logger, cfg.MetricsNamespace)
+	gitSvc, err := git.NewService(cfg.Git, logger)
 	if err != nil {
-		logger.Fatal("failed to create kafka service", zap.Error(err))
+		logger.Fatal("failed to create git service", zap.Error(err))
 	}
 
-	gitSvc, err := git.NewService(cfg.Git, logger)
+	connectSvc, err := connect.NewService(cfg.Connect, logger)
 	if err != nil {
-		logger.Fatal("failed to create git service", zap.Error(err))
+		logger.Fatal("failed to create kafka connect service", zap.Error(err))
+	}
+
+	oidcSvc, err := oidc.NewService(cfg.OIDC, ensurePrefixFormat(cfg.REST.BasePath), logger)
+	if err != nil {
+		logger.Fatal("failed to create oidc service", zap.Error(err))
+	}
+
+	clusterCfgs := cfg.ClusterConfigs()
+
+	// sarama logs via a process global logger, hence its level is taken from the default cluster
+	err = kafka.SetSaramaLogger(logger, clusterCfgs[0].Kafka.LogLevel)
+	if err != nil {
+		logger.Fatal("failed to create sarama logger", zap.Error(err))
+	}
+
+	clusters := make(map[string]*cluster, len(clusterCfgs))
+	clusterNames := make([]string, len(clusterCfgs))
+	for i, clusterCfg := range clusterCfgs {
+		clusterLogger := logger
+		if len(clusterCfgs) > 1 {
+			clusterLogger = logger.With(zap.String("cluster", clusterCfg.Name))
+		}
+
+		kafkaSvc, err := kafka.NewService(clusterCfg.Kafka, clusterLogger, clusterCfg.Name, cfg.MetricsNamespace)
+		if err != nil {
+			logger.Fatal("failed to create kafka service", zap.String("cluster", clusterCfg.Name), zap.Error(err))
+		}
+
+		clusters[clusterCfg.Name] = &cluster{
+			Name:     clusterCfg.Name,
+			Cfg:      clusterCfg.Kafka,
+			KafkaSvc: kafkaSvc,
+			OwlSvc:   owl.NewService(clusterLogger, kafkaSvc, gitSvc),
+		}
+		clusterNames[i] = clusterCfg.Name
+	}
+	defaultCluster := clusters[clusterNames[0]]
+
+	var rateLimiter *consumeRateLimiter
+	if cfg.ConsumeRateLimit.Enabled {
+		rateLimiter = newConsumeRateLimiter(cfg.ConsumeRateLimit)
+	}
+
+	topicFilter, err := newTopicFilter(cfg.Topics)
+	if err != nil {
+		logger.Fatal("failed to create topic filter", zap.Error(err))
+	}
+
+	var auditLogger *audit.Logger
+	if cfg.Audit.Enabled {
+		sink, err := newAuditSink(cfg.Audit, defaultCluster)
+		if err != nil {
+			logger.Fatal("failed to create audit log sink", zap.Error(err))
+		}
+		auditLogger = audit.NewLogger(cfg.Audit, sink, logger)
+	}
+
+	hooks := newDefaultHooks()
+	if cfg.RBAC.Enabled {
+		authorizer, err := newAuthorizer(cfg.RBAC)
+		if err != nil {
+			logger.Fatal("failed to create rbac authorizer", zap.Error(err))
+		}
+		hooks.Owl = newRBACHooks(authorizer)
 	}
 
 	return &API{
-		Cfg:      cfg,
-		Logger:   logger,
-		KafkaSvc: kafkaSvc,
-		OwlSvc:   owl.NewService(logger, kafkaSvc, gitSvc),
-		GitSvc:   gitSvc,
-		Hooks:    newDefaultHooks(),
-		version:  version,
+		Cfg:                cfg,
+		Logger:             logger,
+		KafkaSvc:           defaultCluster.KafkaSvc,
+		OwlSvc:             defaultCluster.OwlSvc,
+		GitSvc:             gitSvc,
+		ConnectSvc:         connectSvc,
+		OIDCSvc:            oidcSvc,
+		clusters:           clusters,
+		clusterNames:       clusterNames,
+		consumeRateLimiter: rateLimiter,
+		topicFilter:        topicFilter,
+		auditLogger:        auditLogger,
+		Hooks:              hooks,
+		version:            version,
 	}
 }
 
 // Start the API server and block
 func (api *API) Start() {
-	api.KafkaSvc.RegisterMetrics()
-	api.KafkaSvc.Start()
+	for _, name := range api.clusterNames {
+		kafkaSvc := api.clusters[name].KafkaSvc
+		if api.Cfg.EnableMetrics {
+			kafkaSvc.RegisterMetrics()
+		}
+		kafkaSvc.Start()
+	}
 
+	// The owl services share the git service, hence the topic documentation sync must only be started once
 	err := api.OwlSvc.Start()
 	if err != nil {
 		api.Logger.Fatal("failed to start owl service", zap.Error(err))
@@ -79,8 +186,52 @@ func (api *API) Start() {
 
 	// Server
 	server := rest.NewServer(&api.Cfg.REST, api.Logger, api.routes())
+	shutdownDone := api.shutdownOnServerShutdown(server.Server)
 	err = server.Start()
 	if err != nil {
 		api.Logger.Fatal("REST Server returned an error", zap.Error(err))
 	}
+	<-shutdownDone
+}
+
+// shutdownOnServerShutdown drains the Kafka services as soon as the server received a shutdown signal. The server
+// waits for the in-flight HTTP requests only up to its graceful shutdown timeout, hence the consume and produce
+// requests must be cancelled by the (shorter) drain timeout meanwhile. Websockets are not awaited by the server at
+// all. The returned channel is closed once the Kafka services have been shut down.
+func (api *API) shutdownOnServerShutdown(server *http.Server) <-chan struct{} {
+	done := make(chan struct{})
+	server.RegisterOnShutdown(func() {
+		defer close(done)
+		api.shutdown()
+	})
+	return done
+}
+
+// shutdown drains and closes the Kafka services of all clusters concurrently, so that the drain timeouts don't add
+// up
+func (api *API) shutdown() {
+	wg := sync.WaitGroup{}
+	for _, name := range api.clusterNames {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			err := api.clusters[name].KafkaSvc.Shutdown()
+			if err != nil {
+				api.Logger.Warn("failed to shut down kafka service gracefully", zap.String("cluster", name), zap.Error(err))
+			}
+		}(name)
+	}
+	wg.Wait()
+}
+
+// newAuditSink creates the configured audit log sink, the kafka sink produces to the default cluster
+func newAuditSink(cfg audit.Config, defaultCluster *cluster) (audit.Sink, error) {
+	switch cfg.Sink {
+	case audit.SinkFile:
+		return audit.NewFileSink(cfg.File.Path)
+	case audit.SinkKafka:
+		return audit.NewKafkaSink(defaultCluster.KafkaSvc, cfg.Kafka.Topic), nil
+	default:
+		return audit.NewStdoutSink(), nil
+	}
 }
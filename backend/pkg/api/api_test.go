@@ -0,0 +1,77 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/cloudhut/kowl/backend/pkg/kafka"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestShutdownOnServerShutdown_CancelsOperationsAfterDrainTimeout(t *testing.T) {
+	broker := sarama.NewMockBroker(t, 1)
+	defer broker.Close()
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).
+			SetBroker(broker.Addr(), broker.BrokerID()).
+			SetController(broker.BrokerID()),
+	})
+
+	cfg := kafka.Config{}
+	cfg.SetDefaults()
+	cfg.Brokers = []string{broker.Addr()}
+	cfg.ClusterVersion = "1.0.0"
+	cfg.ShutdownDrainTimeout = 50 * time.Millisecond
+	kafkaSvc, err := kafka.NewService(cfg, zap.NewNop(), "dev", "kowl")
+	require.NoError(t, err)
+	api := &API{
+		Logger:       zap.NewNop(),
+		clusters:     map[string]*cluster{"dev": {Name: "dev", KafkaSvc: kafkaSvc}},
+		clusterNames: []string{"dev"},
+	}
+
+	// The handler's tracked operation outlives the drain timeout, it only returns once it has been cancelled
+	started := make(chan struct{})
+	cancelled := make(chan error, 1)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, done, err := kafkaSvc.TrackOperation(r.Context())
+		if err != nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		defer done()
+		close(started)
+		<-ctx.Done()
+		cancelled <- ctx.Err()
+	}))
+	shutdownDone := api.shutdownOnServerShutdown(server.Config)
+	server.Start()
+	defer server.Close()
+
+	go func() {
+		res, err := server.Client().Get(server.URL)
+		if err == nil {
+			res.Body.Close()
+		}
+	}()
+	<-started
+
+	// The graceful shutdown timeout of the server is much longer than the drain timeout, the in-flight request must
+	// be cancelled by the drain timeout nevertheless
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	startedShutdown := time.Now()
+	require.NoError(t, server.Config.Shutdown(ctx))
+	assert.Equal(t, context.Canceled, <-cancelled)
+	<-shutdownDone
+	assert.Less(t, int64(time.Since(startedShutdown)), int64(5*time.Second))
+
+	_, _, err = kafkaSvc.TrackOperation(context.Background())
+	assert.Equal(t, kafka.ErrShuttingDown, err)
+}
@@ -0,0 +1,149 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cloudhut/kowl/backend/pkg/audit"
+	"github.com/go-chi/chi"
+)
+
+const (
+	// maxAuditedBodySize is the max size of a JSON request body which is written to the audit log, the parameters of
+	// larger requests are omitted
+	maxAuditedBodySize = 64 * 1024
+
+	// maxAuditedErrorSize is the max size of an error response which is read to write its message to the audit log
+	maxAuditedErrorSize = 4 * 1024
+
+	// auditWriteTimeout is the timeout for writing an entry to the sink, it is independent of the request context so
+	// that entries are written even if the requester has disconnected
+	auditWriteTimeout = 10 * time.Second
+)
+
+// auditResponseWriter records the status code and the beginning of error responses
+type auditResponseWriter struct {
+	http.ResponseWriter
+	statusCode int
+	errorBody  bytes.Buffer
+}
+
+func (w *auditResponseWriter) WriteHeader(statusCode int) {
+	if w.statusCode == 0 {
+		w.statusCode = statusCode
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *auditResponseWriter) Write(b []byte) (int, error) {
+	if w.statusCode == 0 {
+		w.statusCode = http.StatusOK
+	}
+	if remaining := maxAuditedErrorSize - w.errorBody.Len(); w.statusCode >= 400 && remaining > 0 {
+		if len(b) < remaining {
+			remaining = len(b)
+		}
+		w.errorBody.Write(b[:remaining])
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *auditResponseWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// audit is a middleware which writes an entry to the audit log for each request of a mutating operation. The entry
+// contains the requester, the resource from the url parameters, the (redacted) request parameters and the result.
+func (api *API) audit(action string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if api.auditLogger == nil {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			entry := &audit.Entry{
+				Timestamp:  start,
+				Action:     action,
+				Principal:  "anonymous",
+				RemoteAddr: r.RemoteAddr,
+				Cluster:    api.cluster(r).Name,
+				Resource:   make(map[string]string),
+			}
+			if user, ok := UserFromContext(r.Context()); ok {
+				entry.Principal = user.Name
+			}
+			if routeCtx := chi.RouteContext(r.Context()); routeCtx != nil {
+				for i, key := range routeCtx.URLParams.Keys {
+					entry.Resource[key] = routeCtx.URLParams.Values[i]
+				}
+			}
+			entry.Parameters = api.auditParameters(r)
+
+			recorder := &auditResponseWriter{ResponseWriter: w}
+			next.ServeHTTP(recorder, r)
+
+			if recorder.statusCode == 0 {
+				recorder.statusCode = http.StatusOK
+			}
+			entry.Result = audit.Result{
+				Success:    recorder.statusCode < 400,
+				StatusCode: recorder.statusCode,
+				DurationMs: time.Since(start).Milliseconds(),
+			}
+			if !entry.Result.Success {
+				var restErr struct {
+					Message string `json:"message"`
+				}
+				if json.Unmarshal(recorder.errorBody.Bytes(), &restErr) == nil {
+					entry.Result.Error = restErr.Message
+				}
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), auditWriteTimeout)
+			defer cancel()
+			api.auditLogger.Log(ctx, entry)
+		})
+	}
+}
+
+// auditParameters returns the redacted query parameters and JSON body of the request. The body is restored so that
+// it can still be read by the handler.
+func (api *API) auditParameters(r *http.Request) map[string]interface{} {
+	params := make(map[string]interface{})
+	if len(r.URL.Query()) > 0 {
+		query := make(map[string]interface{}, len(r.URL.Query()))
+		for name, values := range r.URL.Query() {
+			query[name] = strings.Join(values, ",")
+		}
+		params["query"] = audit.Redact(query, api.auditLogger.RedactFields())
+	}
+
+	// Uploads such as message imports are not written to the audit log
+	if r.Body == nil || r.Body == http.NoBody || strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/") {
+		return params
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, maxAuditedBodySize+1))
+	r.Body = ioutil.NopCloser(io.MultiReader(bytes.NewReader(body), r.Body))
+	if err != nil || len(body) > maxAuditedBodySize {
+		params["bodyOmitted"] = true
+		return params
+	}
+
+	var decoded interface{}
+	if err := json.Unmarshal(body, &decoded); err != nil {
+		params["bodyOmitted"] = true
+		return params
+	}
+	params["body"] = audit.Redact(decoded, api.auditLogger.RedactFields())
+
+	return params
+}
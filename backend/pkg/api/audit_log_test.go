@@ -0,0 +1,78 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cloudhut/common/rest"
+	"github.com/cloudhut/kowl/backend/pkg/audit"
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type memoryAuditSink struct {
+	entries []audit.Entry
+}
+
+func (s *memoryAuditSink) Write(_ context.Context, encoded []byte) error {
+	var entry audit.Entry
+	err := json.Unmarshal(encoded, &entry)
+	s.entries = append(s.entries, entry)
+	return err
+}
+
+func TestAuditMiddleware(t *testing.T) {
+	cfg := audit.Config{}
+	cfg.SetDefaults()
+	sink := &memoryAuditSink{}
+	api := &API{
+		Logger:       zap.NewNop(),
+		clusters:     map[string]*cluster{"dev": {Name: "dev"}},
+		clusterNames: []string{"dev"},
+		auditLogger:  audit.NewLogger(cfg, sink, zap.NewNop()),
+	}
+
+	router := chi.NewRouter()
+	router.With(api.audit("topic.alterConfig")).Patch("/api/topics/{topicName}/config", func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]interface{}
+		require.NoError(t, rest.Decode(r, &req), "handler must still be able to read the body")
+		if chi.URLParam(r, "topicName") == "locked" {
+			rest.SendRESTError(w, r, api.Logger, &rest.Error{Status: http.StatusForbidden, Message: "You don't have permissions"})
+			return
+		}
+		rest.SendResponse(w, r, api.Logger, http.StatusOK, struct{}{})
+	})
+
+	request := func(topicName string) {
+		body := `{"configs":[{"key":"retention.ms","value":"1000"}],"saslPassword":"secret"}`
+		r := httptest.NewRequest(http.MethodPatch, "/api/topics/"+topicName+"/config", strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+		r = r.WithContext(ContextWithUser(r.Context(), User{Name: "alice"}))
+		router.ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	request("orders")
+	require.Len(t, sink.entries, 1)
+	entry := sink.entries[0]
+	assert.Equal(t, "topic.alterConfig", entry.Action)
+	assert.Equal(t, "alice", entry.Principal)
+	assert.Equal(t, "dev", entry.Cluster)
+	assert.Equal(t, map[string]string{"topicName": "orders"}, entry.Resource)
+	assert.True(t, entry.Result.Success)
+	body := entry.Parameters.(map[string]interface{})["body"].(map[string]interface{})
+	assert.Equal(t, "[REDACTED]", body["saslPassword"])
+	assert.NotNil(t, body["configs"])
+
+	request("locked")
+	require.Len(t, sink.entries, 2)
+	entry = sink.entries[1]
+	assert.False(t, entry.Result.Success)
+	assert.Equal(t, http.StatusForbidden, entry.Result.StatusCode)
+	assert.Equal(t, "You don't have permissions", entry.Result.Error)
+}
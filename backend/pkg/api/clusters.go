@@ -0,0 +1,79 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/cloudhut/common/rest"
+	"github.com/cloudhut/kowl/backend/pkg/kafka"
+	"github.com/cloudhut/kowl/backend/pkg/owl"
+	"github.com/go-chi/chi"
+)
+
+// cluster holds the services of one of the served Kafka clusters. Each cluster has its own clients and caches.
+type cluster struct {
+	Name     string
+	Cfg      kafka.Config
+	KafkaSvc *kafka.Service
+	OwlSvc   *owl.Service
+}
+
+// clusterKey is the context key of the cluster which has been selected by the request path
+type clusterKey struct{}
+
+// selectCluster is a middleware which selects the cluster by the clusterName url parameter. All handlers which are
+// called afterwards use the services of this cluster.
+func (api *API) selectCluster(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		clusterName := chi.URLParam(r, "clusterName")
+		c, ok := api.clusters[clusterName]
+		if !ok {
+			restErr := &rest.Error{
+				Err:      fmt.Errorf("the requested cluster '%v' does not exist", clusterName),
+				Status:   http.StatusNotFound,
+				Message:  fmt.Sprintf("Cluster '%v' does not exist", clusterName),
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, api.Logger, restErr)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), clusterKey{}, c)))
+	})
+}
+
+// cluster returns the cluster which has been selected by the request path, or the default cluster for requests
+// without a cluster prefix
+func (api *API) cluster(r *http.Request) *cluster {
+	if c, ok := r.Context().Value(clusterKey{}).(*cluster); ok {
+		return c
+	}
+
+	return api.clusters[api.clusterNames[0]]
+}
+
+// kafkaSvc returns the Kafka service of the requested cluster
+func (api *API) kafkaSvc(r *http.Request) *kafka.Service {
+	return api.cluster(r).KafkaSvc
+}
+
+// owlSvc returns the Owl service of the requested cluster
+func (api *API) owlSvc(r *http.Request) *owl.Service {
+	return api.cluster(r).OwlSvc
+}
+
+func (api *API) handleGetClusters() http.HandlerFunc {
+	type response struct {
+		Clusters       []string `json:"clusters"`
+		DefaultCluster string   `json:"defaultCluster"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		res := response{
+			Clusters:       api.clusterNames,
+			DefaultCluster: api.clusterNames[0],
+		}
+		rest.SendResponse(w, r, api.Logger, http.StatusOK, res)
+	}
+}
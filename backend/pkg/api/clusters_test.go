@@ -0,0 +1,70 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestSelectCluster(t *testing.T) {
+	api := &API{
+		Logger: zap.NewNop(),
+		clusters: map[string]*cluster{
+			"dev":  {Name: "dev"},
+			"prod": {Name: "prod"},
+		},
+		clusterNames: []string{"dev", "prod"},
+	}
+
+	var selected string
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		selected = api.cluster(r).Name
+	}
+	router := chi.NewRouter()
+	router.Get("/api/topics", handler)
+	router.Route("/api/clusters/{clusterName}", func(r chi.Router) {
+		r.Use(api.selectCluster)
+		r.Get("/topics", handler)
+	})
+
+	request := func(path string) int {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, request("/api/topics"))
+	assert.Equal(t, "dev", selected)
+	assert.Equal(t, http.StatusOK, request("/api/clusters/prod/topics"))
+	assert.Equal(t, "prod", selected)
+	assert.Equal(t, http.StatusNotFound, request("/api/clusters/staging/topics"))
+}
+
+func TestSelectCluster_ConnectClusterParam(t *testing.T) {
+	api := &API{
+		Logger:       zap.NewNop(),
+		clusters:     map[string]*cluster{"prod": {Name: "prod"}},
+		clusterNames: []string{"prod"},
+	}
+
+	// The Kafka Connect cluster is selected by its own parameter, which must not shadow the Kafka cluster
+	var selected, connectCluster string
+	router := chi.NewRouter()
+	router.Route("/api/clusters/{clusterName}", func(r chi.Router) {
+		r.Use(api.selectCluster)
+		r.Get("/connect/clusters/{connectClusterName}/connectors/{connector}", func(w http.ResponseWriter, r *http.Request) {
+			selected = api.cluster(r).Name
+			connectCluster = chi.URLParam(r, "connectClusterName")
+		})
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/clusters/prod/connect/clusters/main/connectors/sink", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "prod", selected)
+	assert.Equal(t, "main", connectCluster)
+}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"github.com/cloudhut/kowl/backend/pkg/git"
@@ -8,7 +9,10 @@ import (
 
 	"github.com/cloudhut/common/logging"
 	"github.com/cloudhut/common/rest"
+	"github.com/cloudhut/kowl/backend/pkg/audit"
+	"github.com/cloudhut/kowl/backend/pkg/connect"
 	"github.com/cloudhut/kowl/backend/pkg/kafka"
+	"github.com/cloudhut/kowl/backend/pkg/oidc"
 	"gopkg.in/yaml.v2"
 )
 
@@ -19,10 +23,49 @@ type Config struct {
 	ServeFrontend    bool   `yaml:"serveFrontend"` // useful for local development where we want the frontend from 'npm run start'
 	FrontendPath     string `yaml:"frontendPath"`  // path to frontend files (index.html), set to './build' by default
 
-	Git    git.Config     `yaml:"git"`
-	REST   rest.Config    `yaml:"server"`
-	Kafka  kafka.Config   `yaml:"kafka"`
-	Logger logging.Config `yaml:"logger"`
+	// EnableTopicOperations allows requests which modify topics, consumer groups, ACLs or connectors, such as altering
+	// topic configs or resetting consumer group offsets. It is disabled by default so that Kowl is read-only unless
+	// configured otherwise.
+	EnableTopicOperations bool `yaml:"enableTopicOperations"`
+
+	// EnableProduce allows producing messages to topics, it is disabled by default as well
+	EnableProduce bool `yaml:"enableProduce"`
+
+	// MessageImportMaxBytes is the maximum size of an uploaded message export, which shall be imported into a topic
+	MessageImportMaxBytes int64 `yaml:"messageImportMaxBytes"`
+
+	// EnableMetrics exposes the prometheus metrics of the HTTP server and the Kafka client at /metrics and
+	// /admin/metrics. It is enabled by default.
+	EnableMetrics bool `yaml:"enableMetrics"`
+
+	ConsumeRateLimit ConsumeRateLimitConfig `yaml:"consumeRateLimit"`
+
+	// MessageSearch bounds the time that listing and searching messages may take
+	MessageSearch MessageSearchConfig `yaml:"messageSearch"`
+
+	// Topics restricts which topics are listed and can be accessed, internal topics are hidden by default. It applies
+	// to the topics of all clusters.
+	Topics TopicsConfig `yaml:"topics"`
+
+	// RBAC authorizes requesters by their roles, it is disabled by default. Roles grant their permissions on all
+	// clusters.
+	RBAC RBACConfig `yaml:"rbac"`
+
+	// OIDC requires users to log in via OpenID Connect, the authenticated users are authorized by the RBAC config
+	OIDC oidc.Config `yaml:"oidc"`
+
+	// Audit writes an audit log entry for each mutating operation
+	Audit audit.Config `yaml:"audit"`
+
+	Git     git.Config     `yaml:"git"`
+	REST    rest.Config    `yaml:"server"`
+	Kafka   kafka.Config   `yaml:"kafka"`
+	Connect connect.Config `yaml:"connect"`
+	Logger  logging.Config `yaml:"logger"`
+
+	// Clusters are the configs of multiple Kafka clusters which are served by this instance. The kafka config block
+	// must not be configured if clusters are set, the first cluster is the default cluster.
+	Clusters []ClusterConfig `yaml:"clusters"`
 }
 
 // RegisterFlags for all (sub)configs
@@ -32,26 +75,51 @@ func (c *Config) RegisterFlags(f *flag.FlagSet) {
 	// Package flags for sensitive input like passwords
 	c.Kafka.RegisterFlags(f)
 	c.Git.RegisterFlags(f)
+	c.OIDC.RegisterFlags(f)
 }
 
-// Validate all root and child config structs
+// Validate all root and child config structs. Every sub config is validated, if more than one check fails a
+// kafka.ValidationErrors error listing every problem is returned.
 func (c *Config) Validate() error {
+	errs := kafka.ValidationErrors{}
+
 	err := c.Logger.Set(c.Logger.LogLevelInput) // Parses LogLevel
-	if err != nil {
-		return fmt.Errorf("failed to validate loglevel input: %w", err)
-	}
+	addValidationErrors(&errs, "failed to validate loglevel input", err)
 
-	err = c.Kafka.Validate()
-	if err != nil {
-		return fmt.Errorf("failed to validate Kafka config: %w", err)
+	errs.Add(c.validateClusters())
+
+	addValidationErrors(&errs, "failed to validate Git config", c.Git.Validate())
+
+	if c.MessageImportMaxBytes <= 0 {
+		errs.Add(fmt.Errorf("messageImportMaxBytes must be greater than 0"))
 	}
 
-	err = c.Git.Validate()
-	if err != nil {
-		return fmt.Errorf("failed to validate Git config: %w", err)
+	addValidationErrors(&errs, "failed to validate Kafka Connect config", c.Connect.Validate())
+	addValidationErrors(&errs, "failed to validate consume rate limit config", c.ConsumeRateLimit.Validate())
+	addValidationErrors(&errs, "failed to validate message search config", c.MessageSearch.Validate())
+	addValidationErrors(&errs, "failed to validate topics config", c.Topics.Validate())
+	addValidationErrors(&errs, "failed to validate rbac config", c.RBAC.Validate())
+	addValidationErrors(&errs, "failed to validate oidc config", c.OIDC.Validate())
+	addValidationErrors(&errs, "failed to validate audit config", c.Audit.Validate())
+
+	return errs.ErrOrNil()
+}
+
+// addValidationErrors prefixes the given error with the context and adds it to errs. If the error lists multiple
+// problems, each of them is prefixed and added on its own.
+func addValidationErrors(errs *kafka.ValidationErrors, context string, err error) {
+	if err == nil {
+		return
 	}
 
-	return nil
+	var nested kafka.ValidationErrors
+	if errors.As(err, &nested) {
+		for _, nestedErr := range nested {
+			errs.Add(fmt.Errorf("%v: %w", context, nestedErr))
+		}
+		return
+	}
+	errs.Add(fmt.Errorf("%v: %w", context, err))
 }
 
 // SetDefaults for all root and child config structs
@@ -59,20 +127,33 @@ func (c *Config) SetDefaults() {
 	c.ServeFrontend = true
 	c.FrontendPath = "./build"
 	c.MetricsNamespace = "kowl"
+	c.EnableMetrics = true
+	c.MessageImportMaxBytes = 10 * 1024 * 1024 // 10 MiB
 
 	c.Logger.SetDefaults()
 	c.REST.SetDefaults()
 	c.Kafka.SetDefaults()
 	c.Git.SetDefaults()
+	c.Connect.SetDefaults()
+	c.ConsumeRateLimit.SetDefaults()
+	c.MessageSearch.SetDefaults()
+	c.OIDC.SetDefaults()
+	c.Audit.SetDefaults()
 }
 
-// LoadConfig read YAML-formatted config from filename into cfg.
+// LoadConfig read YAML-formatted config from filename into cfg. Environment variable references such as ${VAR} or
+// ${VAR:-default} in config values are resolved before the config is parsed.
 func LoadConfig(filename string, cfg *Config) error {
 	buf, err := ioutil.ReadFile(filename)
 	if err != nil {
 		return fmt.Errorf("error reading config file: %w", err)
 	}
 
+	buf, err = expandEnvInYAML(buf)
+	if err != nil {
+		return fmt.Errorf("error expanding environment variables in config file: %w", err)
+	}
+
 	err = yaml.UnmarshalStrict(buf, cfg)
 	if err != nil {
 		return fmt.Errorf("error parsing config file: %w", err)
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+
+	"github.com/cloudhut/kowl/backend/pkg/kafka"
+)
+
+// defaultClusterName is the name of the cluster which is configured via the kafka config block if no clusters have
+// been configured
+const defaultClusterName = "default"
+
+// clusterNamePattern restricts cluster names to characters which can be used in URL paths without escaping
+var clusterNamePattern = regexp.MustCompile(`^[a-zA-Z0-9._-]+$`)
+
+// ClusterConfig is the config of one of multiple Kafka clusters which are served by a single Kowl instance. The API
+// of a cluster is served at /api/clusters/{name}/...
+type ClusterConfig struct {
+	Name  string       `yaml:"name"`
+	Kafka kafka.Config `yaml:"kafka"`
+}
+
+// UnmarshalYAML sets the defaults of the Kafka config before the cluster config is parsed, because the defaults of
+// list elements can't be set upfront
+func (c *ClusterConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	c.Kafka.SetDefaults()
+
+	type clusterConfig ClusterConfig
+	return unmarshal((*clusterConfig)(c))
+}
+
+// ClusterConfigs returns the configs of all clusters. If no clusters have been configured, the kafka config block is
+// returned as the only cluster.
+func (c *Config) ClusterConfigs() []ClusterConfig {
+	if len(c.Clusters) == 0 {
+		return []ClusterConfig{{Name: defaultClusterName, Kafka: c.Kafka}}
+	}
+
+	return c.Clusters
+}
+
+// validateClusters validates the cluster configs, either the kafka config block or the clusters list must be
+// configured. The problems of all clusters are reported.
+func (c *Config) validateClusters() error {
+	errs := kafka.ValidationErrors{}
+	if c.Clusters == nil {
+		addValidationErrors(&errs, "failed to validate Kafka config", c.Kafka.Validate())
+		return errs.ErrOrNil()
+	}
+
+	if len(c.Clusters) == 0 {
+		return fmt.Errorf("at least one cluster must be configured")
+	}
+	if c.isKafkaConfigured() {
+		return fmt.Errorf("either the kafka config or clusters can be configured, but not both. The --kafka.* flags " +
+			"only apply to the kafka config, set the secrets of each cluster in its config instead")
+	}
+
+	names := make(map[string]bool, len(c.Clusters))
+	for i, cluster := range c.Clusters {
+		if !clusterNamePattern.MatchString(cluster.Name) {
+			errs.Add(fmt.Errorf("cluster name '%v' at index %d is invalid, it must only contain letters, digits, '.', '_' and '-'", cluster.Name, i))
+		} else if names[cluster.Name] {
+			errs.Add(fmt.Errorf("cluster name '%v' is not unique", cluster.Name))
+		}
+		names[cluster.Name] = true
+
+		context := fmt.Sprintf("failed to validate Kafka config of cluster '%v'", cluster.Name)
+		addValidationErrors(&errs, context, cluster.Kafka.Validate())
+	}
+
+	return errs.ErrOrNil()
+}
+
+// isKafkaConfigured returns true if any setting of the kafka config block, including the secrets which are set via
+// flags, deviates from the defaults
+func (c *Config) isKafkaConfigured() bool {
+	defaults := kafka.Config{}
+	defaults.SetDefaults()
+
+	return !reflect.DeepEqual(c.Kafka, defaults) && !reflect.DeepEqual(c.Kafka, kafka.Config{})
+}
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+func TestClusterConfigs(t *testing.T) {
+	cfg := Config{}
+	cfg.SetDefaults()
+	cfg.Kafka.Brokers = []string{"localhost:9092"}
+	require.NoError(t, cfg.validateClusters())
+	clusters := cfg.ClusterConfigs()
+	require.Len(t, clusters, 1)
+	assert.Equal(t, defaultClusterName, clusters[0].Name)
+
+	// The Kafka defaults must be applied to every cluster
+	cfg = Config{}
+	cfg.SetDefaults()
+	err := yaml.UnmarshalStrict([]byte(`
+clusters:
+  - name: dev
+    kafka:
+      brokers: ["dev:9092"]
+  - name: prod
+    kafka:
+      brokers: ["prod:9092"]
+      clientId: kowl-prod
+`), &cfg)
+	require.NoError(t, err)
+	require.NoError(t, cfg.validateClusters())
+	clusters = cfg.ClusterConfigs()
+	require.Len(t, clusters, 2)
+	assert.Equal(t, "kowl", clusters[0].Kafka.ClientID)
+	assert.Equal(t, "kowl-prod", clusters[1].Kafka.ClientID)
+	assert.Equal(t, 15*time.Second, clusters[1].Kafka.DialTimeout)
+}
+
+func TestValidateClusters(t *testing.T) {
+	newConfig := func(names ...string) *Config {
+		cfg := &Config{Clusters: []ClusterConfig{}}
+		for _, name := range names {
+			cluster := ClusterConfig{Name: name}
+			cluster.Kafka.SetDefaults()
+			cluster.Kafka.Brokers = []string{name + ":9092"}
+			cfg.Clusters = append(cfg.Clusters, cluster)
+		}
+		return cfg
+	}
+
+	assert.NoError(t, newConfig("dev", "prod").validateClusters())
+	assert.EqualError(t, newConfig().validateClusters(), "at least one cluster must be configured")
+	assert.EqualError(t, newConfig("dev", "dev").validateClusters(), "cluster name 'dev' is not unique")
+	assert.Error(t, newConfig("dev/prod").validateClusters())
+	assert.Error(t, newConfig("").validateClusters())
+
+	cfg := newConfig("dev")
+	cfg.Kafka.Brokers = []string{"localhost:9092"}
+	assert.Error(t, cfg.validateClusters())
+
+	// Secret flags only bind to the kafka config, hence they would be silently ignored by the clusters
+	cfg = newConfig("dev")
+	cfg.Kafka.SetDefaults()
+	require.NoError(t, cfg.validateClusters())
+	cfg.Kafka.SASL.Password = "set-via-flag"
+	assert.Error(t, cfg.validateClusters())
+}
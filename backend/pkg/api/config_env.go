@@ -0,0 +1,100 @@
+package api
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// envVarPattern matches ${VAR} and ${VAR:-default}
+var envVarPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// expandEnvInYAML resolves environment variable references in all scalar values of the given YAML document. Only
+// values are expanded, so that keys and comments are left untouched. Unquoted values are resolved again after the
+// expansion, so that e.g. "enabled: ${FLAG}" or "port: ${PORT}" are decoded as bool or int, whereas quoted values
+// remain strings.
+func expandEnvInYAML(buf []byte) ([]byte, error) {
+	var doc yaml.Node
+	err := yaml.Unmarshal(buf, &doc)
+	if err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		return buf, nil
+	}
+
+	err = expandEnvInNode(&doc)
+	if err != nil {
+		return nil, err
+	}
+
+	return yaml.Marshal(&doc)
+}
+
+func expandEnvInNode(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		expanded, err := expandEnv(node.Value)
+		if err != nil {
+			return err
+		}
+		if expanded != node.Value {
+			node.Value = expanded
+			if node.Style == 0 {
+				// Let the tag of plain scalars be resolved from the expanded value
+				node.Tag = ""
+			}
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			err := expandEnvInNode(node.Content[i+1])
+			if err != nil {
+				return fmt.Errorf("%v: %w", node.Content[i].Value, err)
+			}
+		}
+	case yaml.DocumentNode, yaml.SequenceNode:
+		for i, child := range node.Content {
+			err := expandEnvInNode(child)
+			if err != nil {
+				if node.Kind == yaml.SequenceNode {
+					return fmt.Errorf("[%d]: %w", i, err)
+				}
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// expandEnv replaces all ${VAR} and ${VAR:-default} references in s. Like in a shell the default is used if the
+// variable is unset or empty. An error is returned if a variable is unset and no default has been specified.
+func expandEnv(s string) (string, error) {
+	if !strings.Contains(s, "${") {
+		return s, nil
+	}
+
+	var missing []string
+	expanded := envVarPattern.ReplaceAllStringFunc(s, func(match string) string {
+		groups := envVarPattern.FindStringSubmatch(match)
+		name, hasDefault, defaultValue := groups[1], groups[2] != "", groups[3]
+
+		value, isSet := os.LookupEnv(name)
+		if hasDefault && value == "" {
+			return defaultValue
+		}
+		if !isSet {
+			missing = append(missing, name)
+		}
+		return value
+	})
+
+	if len(missing) > 0 {
+		return "", fmt.Errorf("referenced environment variable(s) '%v' not set and no default value given", strings.Join(missing, "', '"))
+	}
+
+	return expanded, nil
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandEnv(t *testing.T) {
+	os.Setenv("KOWL_TEST_PASSWORD", "secret")
+	os.Setenv("KOWL_TEST_EMPTY", "")
+	defer os.Unsetenv("KOWL_TEST_PASSWORD")
+	defer os.Unsetenv("KOWL_TEST_EMPTY")
+
+	tt := []struct {
+		input    string
+		expected string
+	}{
+		{"plain", "plain"},
+		{"${KOWL_TEST_PASSWORD}", "secret"},
+		{"prefix-${KOWL_TEST_PASSWORD}-suffix", "prefix-secret-suffix"},
+		{"${KOWL_TEST_UNSET:-fallback}", "fallback"},
+		{"${KOWL_TEST_EMPTY:-fallback}", "fallback"},
+		{"${KOWL_TEST_UNSET:-}", ""},
+		{"${KOWL_TEST_EMPTY}", ""},
+	}
+
+	for _, table := range tt {
+		actual, err := expandEnv(table.input)
+		require.NoError(t, err, table.input)
+		assert.Equal(t, table.expected, actual, table.input)
+	}
+
+	_, err := expandEnv("${KOWL_TEST_UNSET}")
+	assert.Error(t, err)
+}
+
+func TestLoadConfig_ExpandsEnv(t *testing.T) {
+	os.Setenv("KOWL_TEST_PASSWORD", "secret")
+	os.Setenv("KOWL_TEST_PORT", "8081")
+	defer os.Unsetenv("KOWL_TEST_PASSWORD")
+	defer os.Unsetenv("KOWL_TEST_PORT")
+
+	f, err := ioutil.TempFile("", "kowl-config-*.yaml")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+
+	_, err = f.WriteString(`
+# ${THIS_IS_A_COMMENT}
+kafka:
+  brokers: ["${KOWL_TEST_BROKER:-localhost:9092}"]
+  sasl:
+    enabled: ${KOWL_TEST_SASL_ENABLED:-true}
+    password: ${KOWL_TEST_PASSWORD}
+  dialTimeout: ${KOWL_TEST_DIAL_TIMEOUT:-20s}
+  clientId: "${KOWL_TEST_PORT}"
+server:
+  listenPort: ${KOWL_TEST_PORT}
+`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	cfg := Config{}
+	cfg.SetDefaults()
+	err = LoadConfig(f.Name(), &cfg)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"localhost:9092"}, cfg.Kafka.Brokers)
+	assert.Equal(t, "secret", cfg.Kafka.SASL.Password)
+	assert.True(t, cfg.Kafka.SASL.Enabled)
+	assert.Equal(t, 20*time.Second, cfg.Kafka.DialTimeout)
+	assert.Equal(t, 8081, cfg.REST.HTTPListenPort)
+	assert.Equal(t, "8081", cfg.Kafka.ClientID, "quoted values remain strings")
+}
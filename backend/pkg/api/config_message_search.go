@@ -0,0 +1,46 @@
+package api
+
+import (
+	"fmt"
+	"time"
+)
+
+// MessageSearchConfig bounds the time that listing and searching messages may take, so that a search across a large
+// topic doesn't block the UI indefinitely. Once the budget has been exhausted, the messages which have been found so
+// far are returned as partial result along with the offsets that have been reached in each partition.
+type MessageSearchConfig struct {
+	// DefaultMaxDuration is the budget of requests which don't specify a max duration
+	DefaultMaxDuration time.Duration `yaml:"defaultMaxDuration"`
+
+	// MaxDuration is the highest budget a request may ask for
+	MaxDuration time.Duration `yaml:"maxDuration"`
+}
+
+// SetDefaults for the message search config
+func (c *MessageSearchConfig) SetDefaults() {
+	c.DefaultMaxDuration = 5 * time.Minute
+	c.MaxDuration = 30 * time.Minute
+}
+
+// Validate the message search config
+func (c *MessageSearchConfig) Validate() error {
+	if c.DefaultMaxDuration <= 0 {
+		return fmt.Errorf("defaultMaxDuration must be a positive duration")
+	}
+	if c.MaxDuration < c.DefaultMaxDuration {
+		return fmt.Errorf("maxDuration must not be lower than defaultMaxDuration")
+	}
+
+	return nil
+}
+
+// maxDuration returns the budget of a request, which may ask for a specific duration up to the configured limit
+func (c *MessageSearchConfig) maxDuration(requested time.Duration) (time.Duration, error) {
+	if requested == 0 {
+		return c.DefaultMaxDuration, nil
+	}
+	if requested > c.MaxDuration {
+		return 0, fmt.Errorf("maxDurationMs must not exceed %d", c.MaxDuration.Milliseconds())
+	}
+	return requested, nil
+}
@@ -0,0 +1,29 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessageSearchConfig_MaxDuration(t *testing.T) {
+	cfg := MessageSearchConfig{}
+	cfg.SetDefaults()
+	require.NoError(t, cfg.Validate())
+
+	maxDuration, err := cfg.maxDuration(0)
+	require.NoError(t, err)
+	assert.Equal(t, cfg.DefaultMaxDuration, maxDuration, "requests without a max duration use the default budget")
+
+	maxDuration, err = cfg.maxDuration(90 * time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, 90*time.Second, maxDuration)
+
+	_, err = cfg.maxDuration(cfg.MaxDuration + time.Second)
+	assert.Error(t, err)
+
+	cfg.MaxDuration = time.Minute
+	assert.Error(t, cfg.Validate(), "the limit must not be lower than the default")
+}
@@ -0,0 +1,110 @@
+package api
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// ConsumeRateLimitConfig limits how many consume requests (listing, searching and tailing messages) each principal
+// may send and how many bytes it may consume. A principal is identified by the ConsumeRateLimitPrincipal hook, which
+// returns the name of the authenticated user or otherwise the client's ip address by default.
+type ConsumeRateLimitConfig struct {
+	Enabled bool `yaml:"enabled"`
+
+	// ConsumeRateLimit is the limit which applies to all principals which don't have a specific limit
+	ConsumeRateLimit `yaml:",inline"`
+
+	// Principals contains limits for specific principals which override the global limit
+	Principals map[string]ConsumeRateLimit `yaml:"principals"`
+
+	// IdleTimeout is the duration after which the limiter state of principals which haven't consumed is discarded
+	IdleTimeout time.Duration `yaml:"idleTimeout"`
+
+	// MaxPrincipals is the max number of principals whose limiter state is kept. Once it has been reached, the state
+	// of the principal which has been seen least recently is discarded.
+	MaxPrincipals int `yaml:"maxPrincipals"`
+
+	// TrustedProxies are the ip addresses or CIDR ranges of reverse proxies whose forwarded client addresses
+	// (X-Forwarded-For, X-Real-IP) are used to identify unauthenticated clients. The forwarded addresses of all other
+	// clients are ignored, because they could be set to anything.
+	TrustedProxies []string `yaml:"trustedProxies"`
+}
+
+// ConsumeRateLimit is a token bucket limit for requests and consumed bytes. Zero values disable the respective limit.
+type ConsumeRateLimit struct {
+	RequestsPerSecond float64 `yaml:"requestsPerSecond"`
+	RequestsBurst     int     `yaml:"requestsBurst"`
+	BytesPerSecond    float64 `yaml:"bytesPerSecond"`
+	BytesBurst        int     `yaml:"bytesBurst"`
+}
+
+// SetDefaults for the consume rate limit config
+func (c *ConsumeRateLimitConfig) SetDefaults() {
+	c.IdleTimeout = 10 * time.Minute
+	c.MaxPrincipals = 10000
+}
+
+// Validate the consume rate limit config
+func (c *ConsumeRateLimitConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+
+	if c.IdleTimeout <= 0 {
+		return fmt.Errorf("idleTimeout must be a positive duration")
+	}
+	if c.MaxPrincipals <= 0 {
+		return fmt.Errorf("maxPrincipals must be greater than 0")
+	}
+	if _, err := parseTrustedProxies(c.TrustedProxies); err != nil {
+		return err
+	}
+	if err := c.ConsumeRateLimit.validate(); err != nil {
+		return err
+	}
+	for principal, limit := range c.Principals {
+		if err := limit.validate(); err != nil {
+			return fmt.Errorf("invalid limit for principal '%v': %w", principal, err)
+		}
+	}
+
+	return nil
+}
+
+func (l *ConsumeRateLimit) validate() error {
+	if l.RequestsPerSecond < 0 || l.RequestsBurst < 0 || l.BytesPerSecond < 0 || l.BytesBurst < 0 {
+		return fmt.Errorf("rate limits must not be negative")
+	}
+	if l.RequestsPerSecond > 0 && l.RequestsBurst == 0 {
+		return fmt.Errorf("requestsBurst must be at least 1 if requestsPerSecond is set")
+	}
+	if l.BytesPerSecond > 0 && l.BytesBurst == 0 {
+		return fmt.Errorf("bytesBurst must be at least 1 if bytesPerSecond is set")
+	}
+
+	return nil
+}
+
+// parseTrustedProxies parses the trusted proxies, which are given as ip addresses or CIDR ranges
+func parseTrustedProxies(proxies []string) ([]*net.IPNet, error) {
+	networks := make([]*net.IPNet, len(proxies))
+	for i, proxy := range proxies {
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return nil, fmt.Errorf("trusted proxy '%v' is neither an ip address nor a CIDR range", proxy)
+			}
+			networks[i] = &net.IPNet{IP: ip, Mask: net.CIDRMask(len(ip)*8, len(ip)*8)}
+			continue
+		}
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("trusted proxy '%v' is neither an ip address nor a CIDR range", proxy)
+		}
+		networks[i] = network
+	}
+
+	return networks, nil
+}
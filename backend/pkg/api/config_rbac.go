@@ -0,0 +1,102 @@
+package api
+
+import (
+	"fmt"
+)
+
+// Operations which can be granted by a role permission. Admin includes all other operations.
+const (
+	rbacOperationView    = "view"
+	rbacOperationConsume = "consume"
+	rbacOperationProduce = "produce"
+	rbacOperationAdmin   = "admin"
+)
+
+// Names of the roles which are always defined
+const (
+	rbacRoleAdmin  = "admin"
+	rbacRoleViewer = "viewer"
+)
+
+// RBACConfig configures the role based access control. Roles grant operations on topics, consumer groups and
+// cluster-wide resources, role bindings assign roles to users and groups. The identity of the requester must be
+// injected into the request context (see ContextWithUser) by an authentication middleware, requests without an
+// identity are rejected if RBAC is enabled.
+type RBACConfig struct {
+	Enabled bool `yaml:"enabled"`
+
+	// Roles are custom roles in addition to the built-in roles admin (all operations on all resources) and viewer
+	// (view on all resources)
+	Roles        []RoleConfig        `yaml:"roles"`
+	RoleBindings []RoleBindingConfig `yaml:"roleBindings"`
+}
+
+// RoleConfig is a named set of permissions
+type RoleConfig struct {
+	Name        string                 `yaml:"name"`
+	Permissions []RolePermissionConfig `yaml:"permissions"`
+}
+
+// RolePermissionConfig grants the operations (view, consume, produce or admin) on all topics and consumer groups
+// matching at least one of the regular expressions. The expressions must match the whole name. Cluster grants the operations on cluster-wide resources such as
+// broker configs and metrics, ACLs, quotas, schema registry subjects and Kafka connect clusters.
+type RolePermissionConfig struct {
+	Operations     []string `yaml:"operations"`
+	Topics         []string `yaml:"topics"`
+	ConsumerGroups []string `yaml:"consumerGroups"`
+	Cluster        bool     `yaml:"cluster"`
+}
+
+// RoleBindingConfig assigns a role to users and groups of the requester identity
+type RoleBindingConfig struct {
+	Role   string   `yaml:"role"`
+	Users  []string `yaml:"users"`
+	Groups []string `yaml:"groups"`
+}
+
+// Validate the RBAC config
+func (c *RBACConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+
+	_, err := newAuthorizer(*c)
+	return err
+}
+
+func (c *RolePermissionConfig) validate() error {
+	if len(c.Operations) == 0 {
+		return fmt.Errorf("at least one operation is required")
+	}
+	for _, operation := range c.Operations {
+		switch operation {
+		case rbacOperationView, rbacOperationConsume, rbacOperationProduce, rbacOperationAdmin:
+		default:
+			return fmt.Errorf("unknown operation '%v', must be one of view, consume, produce or admin", operation)
+		}
+	}
+	if len(c.Topics) == 0 && len(c.ConsumerGroups) == 0 && !c.Cluster {
+		return fmt.Errorf("a permission must select topics, consumer groups or the cluster")
+	}
+
+	return nil
+}
+
+// builtInRoles returns the roles which are always defined
+func builtInRoles() []RoleConfig {
+	all := []string{".*"}
+	return []RoleConfig{
+		{
+			Name: rbacRoleAdmin,
+			Permissions: []RolePermissionConfig{
+				{Operations: []string{rbacOperationAdmin}, Topics: all, ConsumerGroups: all, Cluster: true},
+			},
+		},
+		{
+			Name: rbacRoleViewer,
+			Permissions: []RolePermissionConfig{
+				{Operations: []string{rbacOperationView}, Topics: all, ConsumerGroups: all, Cluster: true},
+			},
+		},
+	}
+}
@@ -0,0 +1,44 @@
+package api
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/cloudhut/kowl/backend/pkg/kafka"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_Validate_AggregatesErrors(t *testing.T) {
+	cfg := Config{}
+	cfg.SetDefaults()
+	cfg.Kafka.ClusterVersion = "2.6"
+	cfg.MessageImportMaxBytes = 0
+	cfg.RBAC = testRBACConfig()
+	cfg.RBAC.Roles[0].Permissions[0].Operations = []string{"delete"}
+
+	err := cfg.Validate()
+	var validationErrs kafka.ValidationErrors
+	require.True(t, errors.As(err, &validationErrs))
+	// Kafka brokers and cluster version, message import limit and rbac operations
+	assert.Len(t, validationErrs, 4, err.Error())
+
+	var versionErr *kafka.ErrKafkaVersion
+	assert.True(t, errors.As(err, &versionErr))
+}
+
+func TestConfig_Validate_AggregatesClusterErrors(t *testing.T) {
+	cfg := Config{}
+	cfg.SetDefaults()
+	cfg.Clusters = []ClusterConfig{{Name: "dev"}, {Name: "prod"}}
+	for i := range cfg.Clusters {
+		cfg.Clusters[i].Kafka.SetDefaults()
+	}
+
+	err := cfg.Validate()
+	var validationErrs kafka.ValidationErrors
+	require.True(t, errors.As(err, &validationErrs))
+	require.Len(t, validationErrs, 2, err.Error())
+	assert.Contains(t, validationErrs[0].Error(), "cluster 'dev'")
+	assert.Contains(t, validationErrs[1].Error(), "cluster 'prod'")
+}
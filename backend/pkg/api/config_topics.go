@@ -0,0 +1,36 @@
+package api
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// TopicsConfig restricts which topics are served by Kowl. Both lists contain regular expressions which must match the
+// whole topic name (e.g. "orders-.*" rather than "orders-"). If the allow list is set, only topics matching at least one of its expressions are
+// served. Topics matching any expression of the deny list are never served. Internal topics (whose names start with
+// an underscore) are hidden unless they match an expression of the allow list.
+type TopicsConfig struct {
+	AllowList []string `yaml:"allowList"`
+	DenyList  []string `yaml:"denyList"`
+}
+
+// Validate the topics config
+func (c *TopicsConfig) Validate() error {
+	_, err := newTopicFilter(*c)
+	return err
+}
+
+// compileRegexps compiles all expressions of the given list, the name of the list is part of the error message. The
+// expressions are anchored, so that they must match the whole name rather than a substring of it.
+func compileRegexps(listName string, expressions []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, len(expressions))
+	for i, expression := range expressions {
+		r, err := regexp.Compile("^(?:" + expression + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex '%v' in %v: %w", expression, listName, err)
+		}
+		compiled[i] = r
+	}
+
+	return compiled, nil
+}
@@ -1,15 +1,20 @@
 package api
 
 import (
+	"errors"
 	"fmt"
-	"github.com/Shopify/sarama"
-	"github.com/gorilla/schema"
 	"net/http"
+	"strings"
 
+	"github.com/Shopify/sarama"
 	"github.com/cloudhut/common/rest"
+	"github.com/cloudhut/kowl/backend/pkg/kafka"
 	"github.com/cloudhut/kowl/backend/pkg/owl"
+	"github.com/gorilla/schema"
+	"go.uber.org/zap"
 )
 
+// getAclsOverviewRequest contains the ACL filters. Enum filters which are not set (0) match any value.
 type getAclsOverviewRequest struct {
 	// The resource type.
 	ResourceType int `schema:"resourceType"`
@@ -33,6 +38,22 @@ type getAclsOverviewRequest struct {
 	PermissionType int `schema:"permissionType"`
 }
 
+// SetDefaults sets all enum filters which have not been set to "any"
+func (g *getAclsOverviewRequest) SetDefaults() {
+	if g.ResourceType == 0 {
+		g.ResourceType = int(sarama.AclResourceAny)
+	}
+	if g.ResourcePatternTypeFilter == 0 {
+		g.ResourcePatternTypeFilter = int(sarama.AclPatternAny)
+	}
+	if g.Operation == 0 {
+		g.Operation = int(sarama.AclOperationAny)
+	}
+	if g.PermissionType == 0 {
+		g.PermissionType = int(sarama.AclPermissionAny)
+	}
+}
+
 func (g *getAclsOverviewRequest) OK() error {
 	if g.ResourceType < 1 || g.ResourceType > int(sarama.AclResourceTransactionalID) {
 		return fmt.Errorf("resourceType filter is out of bounds")
@@ -89,6 +110,7 @@ func (api *API) handleGetACLsOverview() http.HandlerFunc {
 		}
 
 		// Validate parsed request
+		req.SetDefaults()
 		err = req.OK()
 		if err != nil {
 			restErr := &rest.Error{
@@ -117,8 +139,17 @@ func (api *API) handleGetACLsOverview() http.HandlerFunc {
 			return
 		}
 
-		aclResources, err := api.OwlSvc.ListAllACLs(req.ToSaramaFilter())
+		aclResources, err := api.owlSvc(r).ListAllACLs(r.Context(), req.ToSaramaFilter())
 		if err != nil {
+			if errors.Is(err, kafka.ErrACLsNotSupported) {
+				rest.SendRESTError(w, r, api.Logger, &rest.Error{
+					Err:      err,
+					Status:   http.StatusNotImplemented,
+					Message:  "Listing ACLs is not supported by the Kafka cluster. Either the brokers do not support the DescribeAcls API or no authorizer is configured",
+					IsSilent: true,
+				})
+				return
+			}
 			restErr := &rest.Error{
 				Err:      err,
 				Status:   http.StatusInternalServerError,
@@ -130,8 +161,150 @@ func (api *API) handleGetACLsOverview() http.HandlerFunc {
 		}
 
 		res := response{
-			AclResources: aclResources,
+			AclResources: api.topicFilter.filterAclResources(aclResources),
 		}
 		rest.SendResponse(w, r, api.Logger, http.StatusOK, res)
 	}
 }
+
+type createACLRequest struct {
+	owl.AclBinding
+}
+
+func (c *createACLRequest) OK() error {
+	_, _, err := c.AclBinding.ToSarama()
+	return err
+}
+
+type deleteACLsRequest struct {
+	owl.AclBindingFilter
+}
+
+func (d *deleteACLsRequest) OK() error {
+	_, err := d.AclBindingFilter.ToSarama()
+	return err
+}
+
+// checkACLOperation returns an error if operations are disabled or if the requester is not allowed to manage ACLs
+func (api *API) checkACLOperation(isAllowed bool, restErr *rest.Error) *rest.Error {
+	if !api.Cfg.EnableTopicOperations {
+		return &rest.Error{
+			Err:      fmt.Errorf("topic operations are disabled"),
+			Status:   http.StatusForbidden,
+			Message:  "Operations are disabled, set 'enableTopicOperations' to true in order to manage ACLs",
+			IsSilent: false,
+		}
+	}
+	if restErr != nil {
+		return restErr
+	}
+	if !isAllowed {
+		return &rest.Error{
+			Err:      fmt.Errorf("requester is not allowed to manage ACLs"),
+			Status:   http.StatusForbidden,
+			Message:  "You are not allowed to manage ACLs",
+			IsSilent: false,
+		}
+	}
+
+	return nil
+}
+
+// aclOperationErrorStatus returns the HTTP status code for errors which are returned while managing ACLs
+func aclOperationErrorStatus(err error) int {
+	if errors.Is(err, kafka.ErrACLsNotSupported) {
+		return http.StatusNotImplemented
+	}
+	if errors.Is(err, kafka.ErrInvalidACL) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+func (api *API) handleCreateACL() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if restErr := api.checkACLOperation(api.Hooks.Owl.CanCreateACL(r.Context())); restErr != nil {
+			rest.SendRESTError(w, r, api.Logger, restErr)
+			return
+		}
+
+		var req createACLRequest
+		err := rest.Decode(r, &req)
+		if err != nil {
+			restErr := &rest.Error{
+				Err:      err,
+				Status:   http.StatusBadRequest,
+				Message:  fmt.Sprintf("Failed to parse request: %v", err.Error()),
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, api.Logger, restErr)
+			return
+		}
+
+		// ACLs of topics which are not served can neither be listed nor created
+		if strings.EqualFold(req.ResourceType, aclResourceTypeTopic) && req.ResourceName != aclWildcardResourceName &&
+			!api.topicFilter.isAllowed(req.ResourceName) {
+			rest.SendRESTError(w, r, api.Logger, newTopicNotAllowedError(req.ResourceName))
+			return
+		}
+
+		err = api.owlSvc(r).CreateACL(r.Context(), req.AclBinding)
+		if err != nil {
+			restErr := &rest.Error{
+				Err:      err,
+				Status:   aclOperationErrorStatus(err),
+				Message:  fmt.Sprintf("Could not create ACL: %v", err.Error()),
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, api.Logger, restErr)
+			return
+		}
+		api.Logger.Info("created ACL",
+			zap.String("resource_name", req.ResourceName),
+			zap.String("principal", req.Principal),
+			zap.String("operation", req.Operation))
+
+		rest.SendResponse(w, r, api.Logger, http.StatusCreated, req.AclBinding)
+	}
+}
+
+func (api *API) handleDeleteACLs() http.HandlerFunc {
+	type response struct {
+		DeletedCount int `json:"deletedCount"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if restErr := api.checkACLOperation(api.Hooks.Owl.CanDeleteACL(r.Context())); restErr != nil {
+			rest.SendRESTError(w, r, api.Logger, restErr)
+			return
+		}
+
+		var req deleteACLsRequest
+		err := rest.Decode(r, &req)
+		if err != nil {
+			restErr := &rest.Error{
+				Err:      err,
+				Status:   http.StatusBadRequest,
+				Message:  fmt.Sprintf("Failed to parse request: %v", err.Error()),
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, api.Logger, restErr)
+			return
+		}
+
+		deletedCount, err := api.owlSvc(r).DeleteACLs(r.Context(), req.AclBindingFilter)
+		if err != nil {
+			restErr := &rest.Error{
+				Err:      err,
+				Status:   aclOperationErrorStatus(err),
+				Message:  fmt.Sprintf("Could not delete ACLs: %v", err.Error()),
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, api.Logger, restErr)
+			return
+		}
+		api.Logger.Info("deleted ACLs", zap.Int("deleted_count", deletedCount))
+
+		rest.SendResponse(w, r, api.Logger, http.StatusOK, response{DeletedCount: deletedCount})
+	}
+}
@@ -0,0 +1,116 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/cloudhut/common/rest"
+	"github.com/cloudhut/kowl/backend/pkg/oidc"
+	"go.uber.org/zap"
+)
+
+// authenticate is a middleware which rejects requests without a valid OIDC session and injects the logged in user
+// into the request context, so that it can be authorized by the RBAC hooks
+func (api *API) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		session, err := api.OIDCSvc.Authenticate(w, r)
+		if err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, oidc.ErrNotAuthenticated) {
+				status = http.StatusUnauthorized
+			}
+			rest.SendRESTError(w, r, api.Logger, &rest.Error{
+				Err:      err,
+				Status:   status,
+				Message:  "You must be logged in to access this resource",
+				IsSilent: status == http.StatusUnauthorized,
+			})
+			return
+		}
+
+		ctx := ContextWithUser(r.Context(), User{Name: session.Username, Groups: session.Groups})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// handleLogin redirects the user to the OpenID provider. The user is sent back to the path of the returnTo query
+// parameter after the login.
+func (api *API) handleLogin() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authURL, err := api.OIDCSvc.StartLogin(w, r, r.URL.Query().Get("returnTo"))
+		if err != nil {
+			rest.SendRESTError(w, r, api.Logger, &rest.Error{
+				Err:      err,
+				Status:   http.StatusBadGateway,
+				Message:  "Could not start the login, the identity provider is not available",
+				IsSilent: false,
+			})
+			return
+		}
+
+		http.Redirect(w, r, authURL, http.StatusFound)
+	}
+}
+
+// handleLoginCallback completes the login after the user has been redirected back by the OpenID provider
+func (api *API) handleLoginCallback() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		session, returnTo, err := api.OIDCSvc.FinishLogin(w, r)
+		if err != nil {
+			status := http.StatusUnauthorized
+			if errors.Is(err, oidc.ErrInvalidLoginState) {
+				status = http.StatusBadRequest
+			}
+			rest.SendRESTError(w, r, api.Logger, &rest.Error{
+				Err:      err,
+				Status:   status,
+				Message:  fmt.Sprintf("Login failed: %v", err.Error()),
+				IsSilent: false,
+			})
+			return
+		}
+
+		api.Logger.Info("user logged in", zap.String("username", session.Username), zap.Strings("groups", session.Groups))
+		http.Redirect(w, r, returnTo, http.StatusFound)
+	}
+}
+
+// handleLogout ends the session and redirects the user to the OpenID provider to end the session there as well
+func (api *API) handleLogout() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		redirectURL := api.OIDCSvc.Logout(w, r)
+		if redirectURL == "" {
+			redirectURL = "/"
+		}
+
+		http.Redirect(w, r, redirectURL, http.StatusFound)
+	}
+}
+
+// handleGetCurrentUser returns the identity of the logged in user, which is used by the frontend to show the user
+func (api *API) handleGetCurrentUser() http.HandlerFunc {
+	type response struct {
+		Username string   `json:"username"`
+		Groups   []string `json:"groups"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := UserFromContext(r.Context())
+		if !ok {
+			rest.SendRESTError(w, r, api.Logger, &rest.Error{
+				Err:      fmt.Errorf("request has no authenticated user"),
+				Status:   http.StatusNotFound,
+				Message:  "No user is logged in",
+				IsSilent: true,
+			})
+			return
+		}
+
+		groups := user.Groups
+		if groups == nil {
+			groups = []string{}
+		}
+		rest.SendResponse(w, r, api.Logger, http.StatusOK, response{Username: user.Name, Groups: groups})
+	}
+}
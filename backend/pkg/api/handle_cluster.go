@@ -1,10 +1,16 @@
 package api
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/cloudhut/common/rest"
+	"github.com/cloudhut/kowl/backend/pkg/kafka"
 	"github.com/cloudhut/kowl/backend/pkg/owl"
+	"github.com/go-chi/chi"
 )
 
 func (api *API) handleDescribeCluster() http.HandlerFunc {
@@ -13,7 +19,13 @@ func (api *API) handleDescribeCluster() http.HandlerFunc {
 	}
 
 	return func(w http.ResponseWriter, r *http.Request) {
-		clusterInfo, err := api.OwlSvc.GetClusterInfo(r.Context())
+		restErr := api.checkCanViewCluster(r)
+		if restErr != nil {
+			rest.SendRESTError(w, r, api.Logger, restErr)
+			return
+		}
+
+		clusterInfo, err := api.owlSvc(r).GetClusterInfo(r.Context())
 		if err != nil {
 			restErr := &rest.Error{
 				Err:      err,
@@ -32,13 +44,56 @@ func (api *API) handleDescribeCluster() http.HandlerFunc {
 	}
 }
 
+// handleGetClusterHealth returns the offline, under-replicated and leader imbalanced partitions of the cluster. Only
+// the topics which the requester can see are checked and counted.
+func (api *API) handleGetClusterHealth() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var visibilityErr *rest.Error
+		isTopicVisible := func(topicName string) (bool, error) {
+			if !api.topicFilter.isAllowed(topicName) {
+				return false, nil
+			}
+			canSee, restErr := api.Hooks.Owl.CanSeeTopic(r.Context(), topicName)
+			if restErr != nil {
+				visibilityErr = restErr
+				return false, fmt.Errorf("failed to check the permissions of topic '%v': %v", topicName, restErr.Message)
+			}
+			return canSee, nil
+		}
+
+		health, err := api.owlSvc(r).GetClusterHealth(r.Context(), isTopicVisible)
+		if visibilityErr != nil {
+			rest.SendRESTError(w, r, api.Logger, visibilityErr)
+			return
+		}
+		if err != nil {
+			restErr := &rest.Error{
+				Err:      err,
+				Status:   http.StatusInternalServerError,
+				Message:  "Could not check the cluster health",
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, api.Logger, restErr)
+			return
+		}
+
+		rest.SendResponse(w, r, api.Logger, http.StatusOK, health)
+	}
+}
+
 func (api *API) handleClusterConfig() http.HandlerFunc {
 	type response struct {
 		ClusterConfig owl.ClusterConfig `json:"clusterConfig"`
 	}
 
 	return func(w http.ResponseWriter, r *http.Request) {
-		clusterConfig, err := api.OwlSvc.GetClusterConfig(r.Context())
+		restErr := api.checkCanViewCluster(r)
+		if restErr != nil {
+			rest.SendRESTError(w, r, api.Logger, restErr)
+			return
+		}
+
+		clusterConfig, err := api.owlSvc(r).GetClusterConfig(r.Context())
 		if err != nil {
 			restErr := &rest.Error{
 				Err:      err,
@@ -56,3 +111,235 @@ func (api *API) handleClusterConfig() http.HandlerFunc {
 		rest.SendResponse(w, r, api.Logger, http.StatusOK, response)
 	}
 }
+
+func (api *API) handleGetAPIVersions() http.HandlerFunc {
+	type response struct {
+		APIVersions *owl.APIVersions `json:"apiVersions"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		restErr := api.checkCanViewCluster(r)
+		if restErr != nil {
+			rest.SendRESTError(w, r, api.Logger, restErr)
+			return
+		}
+
+		forceRefresh := r.URL.Query().Get("refresh") == "true"
+		apiVersions, err := api.owlSvc(r).GetAPIVersions(forceRefresh)
+		if err != nil {
+			restErr := &rest.Error{
+				Err:      err,
+				Status:   http.StatusInternalServerError,
+				Message:  "Could not describe the supported api versions",
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, api.Logger, restErr)
+			return
+		}
+
+		response := response{
+			APIVersions: apiVersions,
+		}
+		rest.SendResponse(w, r, api.Logger, http.StatusOK, response)
+	}
+}
+
+// handleGetBrokerConfig returns all config entries of a single broker
+func (api *API) handleGetBrokerConfig() http.HandlerFunc {
+	type response struct {
+		BrokerConfig *owl.BrokerConfig `json:"brokerConfig"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		restErr := api.checkCanViewCluster(r)
+		if restErr != nil {
+			rest.SendRESTError(w, r, api.Logger, restErr)
+			return
+		}
+
+		brokerID, err := strconv.ParseInt(chi.URLParam(r, "brokerID"), 10, 32)
+		if err != nil {
+			restErr := &rest.Error{
+				Err:      err,
+				Status:   http.StatusBadRequest,
+				Message:  "Broker ID must be a valid int32",
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, api.Logger, restErr)
+			return
+		}
+
+		brokerConfig, reqErr := api.owlSvc(r).GetBrokerConfig(r.Context(), int32(brokerID))
+		if reqErr != nil {
+			restErr := &rest.Error{
+				Err:      errors.New(reqErr.ErrorMessage),
+				Status:   http.StatusInternalServerError,
+				Message:  fmt.Sprintf("Could not describe config for broker %v: %v", brokerID, reqErr.ErrorMessage),
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, api.Logger, restErr)
+			return
+		}
+
+		response := response{
+			BrokerConfig: brokerConfig,
+		}
+		rest.SendResponse(w, r, api.Logger, http.StatusOK, response)
+	}
+}
+
+// handleGetBrokerMetrics returns the throughput and request metrics of Kowl's connections to a single broker
+func (api *API) handleGetBrokerMetrics() http.HandlerFunc {
+	type response struct {
+		BrokerMetrics *kafka.BrokerMetrics `json:"brokerMetrics"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		restErr := api.checkCanViewCluster(r)
+		if restErr != nil {
+			rest.SendRESTError(w, r, api.Logger, restErr)
+			return
+		}
+
+		brokerID, err := strconv.ParseInt(chi.URLParam(r, "brokerID"), 10, 32)
+		if err != nil {
+			rest.SendRESTError(w, r, api.Logger, &rest.Error{
+				Err:      err,
+				Status:   http.StatusBadRequest,
+				Message:  "Broker ID must be a valid int32",
+				IsSilent: false,
+			})
+			return
+		}
+
+		brokerMetrics, err := api.kafkaSvc(r).GetBrokerMetrics(r.Context(), int32(brokerID))
+		if err != nil {
+			status := http.StatusServiceUnavailable
+			if errors.Is(err, kafka.ErrBrokerNotFound) {
+				status = http.StatusNotFound
+			}
+			rest.SendRESTError(w, r, api.Logger, &rest.Error{
+				Err:      err,
+				Status:   status,
+				Message:  fmt.Sprintf("Could not get metrics of broker %v: %v", brokerID, err.Error()),
+				IsSilent: false,
+			})
+			return
+		}
+
+		rest.SendResponse(w, r, api.Logger, http.StatusOK, &response{BrokerMetrics: brokerMetrics})
+	}
+}
+
+// handleInvalidateTopicMetadataCache discards the cached topic metadata, so that it is fetched from the cluster on the
+// next request
+func (api *API) handleInvalidateTopicMetadataCache() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		restErr := api.checkCanRefreshClusterMetadata(r)
+		if restErr != nil {
+			rest.SendRESTError(w, r, api.Logger, restErr)
+			return
+		}
+
+		api.kafkaSvc(r).InvalidateTopicMetadataCache()
+		rest.SendResponse(w, r, api.Logger, http.StatusOK, struct{}{})
+	}
+}
+
+// handleRefreshClusterMetadata forces a refresh of the cluster metadata and discards all cached metadata, e.g. after
+// brokers have been added or topics have been recreated
+func (api *API) handleRefreshClusterMetadata() http.HandlerFunc {
+	type response struct {
+		BrokerCount int       `json:"brokerCount"`
+		TopicCount  int       `json:"topicCount"`
+		RefreshedAt time.Time `json:"refreshedAt"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		restErr := api.checkCanRefreshClusterMetadata(r)
+		if restErr != nil {
+			rest.SendRESTError(w, r, api.Logger, restErr)
+			return
+		}
+
+		refresh, err := api.kafkaSvc(r).RefreshClusterMetadata(r.Context())
+		if err != nil {
+			rest.SendRESTError(w, r, api.Logger, &rest.Error{
+				Err:      err,
+				Status:   http.StatusInternalServerError,
+				Message:  fmt.Sprintf("Could not refresh cluster metadata: %v", err.Error()),
+				IsSilent: false,
+			})
+			return
+		}
+
+		rest.SendResponse(w, r, api.Logger, http.StatusOK, &response{
+			BrokerCount: refresh.BrokerCount,
+			TopicCount:  refresh.TopicCount,
+			RefreshedAt: refresh.RefreshedAt,
+		})
+	}
+}
+
+// checkCanViewCluster returns an error if the requester is not allowed to view cluster-wide resources such as broker
+// configs and metrics or schema registry subjects
+func (api *API) checkCanViewCluster(r *http.Request) *rest.Error {
+	canView, restErr := api.Hooks.Owl.CanViewCluster(r.Context())
+	if restErr != nil {
+		return restErr
+	}
+	if !canView {
+		return &rest.Error{
+			Err:      fmt.Errorf("requester has no permissions to view the cluster"),
+			Status:   http.StatusForbidden,
+			Message:  "You don't have permissions to view the cluster",
+			IsSilent: false,
+		}
+	}
+
+	return nil
+}
+
+// checkCanRefreshClusterMetadata returns an error if the requester is not allowed to discard the cached metadata
+func (api *API) checkCanRefreshClusterMetadata(r *http.Request) *rest.Error {
+	canRefresh, restErr := api.Hooks.Owl.CanRefreshClusterMetadata(r.Context())
+	if restErr != nil {
+		return restErr
+	}
+	if !canRefresh {
+		return &rest.Error{
+			Err:      fmt.Errorf("requester has no permissions to refresh the cluster metadata"),
+			Status:   http.StatusForbidden,
+			Message:  "You don't have permissions to refresh the cluster metadata",
+			IsSilent: false,
+		}
+	}
+
+	return nil
+}
+
+// handleGetClusterOverview returns an at-a-glance summary of the cluster. Sub-stats which could not be collected are
+// listed in the response, which is flagged as partial in that case. Topics which are not served are not counted.
+func (api *API) handleGetClusterOverview() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		restErr := api.checkCanViewCluster(r)
+		if restErr != nil {
+			rest.SendRESTError(w, r, api.Logger, restErr)
+			return
+		}
+
+		overview, err := api.owlSvc(r).GetClusterOverview(r.Context(), api.topicFilter.isAllowed)
+		if err != nil {
+			restErr := &rest.Error{
+				Err:      err,
+				Status:   http.StatusInternalServerError,
+				Message:  fmt.Sprintf("Could not get the cluster overview: %v", err.Error()),
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, api.Logger, restErr)
+			return
+		}
+
+		rest.SendResponse(w, r, api.Logger, http.StatusOK, overview)
+	}
+}
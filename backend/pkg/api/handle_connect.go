@@ -0,0 +1,217 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/cloudhut/common/rest"
+	"github.com/cloudhut/kowl/backend/pkg/connect"
+	"github.com/go-chi/chi"
+	"go.uber.org/zap"
+)
+
+// handleGetConnectors returns the connectors of all Kafka Connect clusters which the requester is allowed to view
+func (api *API) handleGetConnectors() http.HandlerFunc {
+	type response struct {
+		Clusters     []connect.ClusterConnectors `json:"clusters"`
+		IsConfigured bool                        `json:"isConfigured"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !api.ConnectSvc.IsEnabled() {
+			rest.SendResponse(w, r, api.Logger, http.StatusOK, &response{
+				Clusters:     nil,
+				IsConfigured: false,
+			})
+			return
+		}
+
+		clusterNames := make([]string, 0, len(api.ConnectSvc.ClusterNames()))
+		for _, clusterName := range api.ConnectSvc.ClusterNames() {
+			canView, restErr := api.Hooks.Owl.CanViewConnectCluster(r.Context(), clusterName)
+			if restErr != nil {
+				rest.SendRESTError(w, r, api.Logger, restErr)
+				return
+			}
+			if canView {
+				clusterNames = append(clusterNames, clusterName)
+			}
+		}
+
+		clusters, err := api.ConnectSvc.ListConnectors(r.Context(), clusterNames)
+		if err != nil {
+			rest.SendRESTError(w, r, api.Logger, &rest.Error{
+				Err:      err,
+				Status:   http.StatusInternalServerError,
+				Message:  fmt.Sprintf("Could not list connectors: %v", err.Error()),
+				IsSilent: false,
+			})
+			return
+		}
+
+		rest.SendResponse(w, r, api.Logger, http.StatusOK, &response{
+			Clusters:     clusters,
+			IsConfigured: true,
+		})
+	}
+}
+
+// handleGetConnector returns the state, tasks and config of a single connector
+func (api *API) handleGetConnector() http.HandlerFunc {
+	type response struct {
+		Connector    *connect.ConnectorDetails `json:"connector"`
+		IsConfigured bool                      `json:"isConfigured"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		clusterName := chi.URLParam(r, "connectClusterName")
+		connectorName := chi.URLParam(r, "connector")
+		logger := api.Logger.With(zap.String("connect_cluster", clusterName), zap.String("connector", connectorName))
+
+		if !api.ConnectSvc.IsEnabled() {
+			rest.SendResponse(w, r, logger, http.StatusOK, &response{
+				Connector:    nil,
+				IsConfigured: false,
+			})
+			return
+		}
+
+		canView, restErr := api.Hooks.Owl.CanViewConnectCluster(r.Context(), clusterName)
+		if restErr != nil {
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+		if !canView {
+			rest.SendRESTError(w, r, logger, &rest.Error{
+				Err:      fmt.Errorf("requester has no permissions to view the requested kafka connect cluster"),
+				Status:   http.StatusForbidden,
+				Message:  "You don't have permissions to view that Kafka Connect cluster",
+				IsSilent: false,
+			})
+			return
+		}
+
+		connector, err := api.ConnectSvc.GetConnector(r.Context(), clusterName, connectorName)
+		if err != nil {
+			rest.SendRESTError(w, r, logger, &rest.Error{
+				Err:      err,
+				Status:   connectErrorStatus(err),
+				Message:  fmt.Sprintf("Could not get connector: %v", err.Error()),
+				IsSilent: false,
+			})
+			return
+		}
+
+		rest.SendResponse(w, r, logger, http.StatusOK, &response{
+			Connector:    connector,
+			IsConfigured: true,
+		})
+	}
+}
+
+// connectorAction runs an action against a connector and returns its status after the action
+type connectorAction func(r *http.Request, clusterName string, connectorName string) (*connect.ConnectorDetails, error)
+
+// handleConnectorAction runs the given action against the requested connector, if operations are enabled and the
+// requester is allowed to edit the Kafka Connect cluster
+func (api *API) handleConnectorAction(actionName string, action connectorAction) http.HandlerFunc {
+	type response struct {
+		Connector *connect.ConnectorDetails `json:"connector"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		clusterName := chi.URLParam(r, "connectClusterName")
+		connectorName := chi.URLParam(r, "connector")
+		logger := api.Logger.With(zap.String("connect_cluster", clusterName), zap.String("connector", connectorName))
+
+		if !api.Cfg.EnableTopicOperations {
+			rest.SendRESTError(w, r, logger, &rest.Error{
+				Err:      fmt.Errorf("topic operations are disabled"),
+				Status:   http.StatusForbidden,
+				Message:  fmt.Sprintf("Operations are disabled, set 'enableTopicOperations' to true in order to %v connectors", actionName),
+				IsSilent: false,
+			})
+			return
+		}
+		canEdit, restErr := api.Hooks.Owl.CanEditConnectCluster(r.Context(), clusterName)
+		if restErr != nil {
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+		if !canEdit {
+			rest.SendRESTError(w, r, logger, &rest.Error{
+				Err:      fmt.Errorf("requester has no permissions to edit the requested kafka connect cluster"),
+				Status:   http.StatusForbidden,
+				Message:  "You don't have permissions to edit connectors of that Kafka Connect cluster",
+				IsSilent: false,
+			})
+			return
+		}
+
+		connector, err := action(r, clusterName, connectorName)
+		if err != nil {
+			rest.SendRESTError(w, r, logger, &rest.Error{
+				Err:      err,
+				Status:   connectErrorStatus(err),
+				Message:  fmt.Sprintf("Could not %v connector: %v", actionName, err.Error()),
+				IsSilent: false,
+			})
+			return
+		}
+		logger.Info("ran connector action", zap.String("action", actionName))
+
+		rest.SendResponse(w, r, logger, http.StatusOK, &response{Connector: connector})
+	}
+}
+
+func (api *API) handleRestartConnector() http.HandlerFunc {
+	return api.handleConnectorAction("restart", func(r *http.Request, clusterName string, connectorName string) (*connect.ConnectorDetails, error) {
+		return api.ConnectSvc.RestartConnector(r.Context(), clusterName, connectorName)
+	})
+}
+
+func (api *API) handleRestartConnectorTask() http.HandlerFunc {
+	return api.handleConnectorAction("restart", func(r *http.Request, clusterName string, connectorName string) (*connect.ConnectorDetails, error) {
+		taskID, err := strconv.Atoi(chi.URLParam(r, "taskID"))
+		if err != nil || taskID < 0 {
+			return nil, errInvalidTaskID
+		}
+		return api.ConnectSvc.RestartTask(r.Context(), clusterName, connectorName, taskID)
+	})
+}
+
+func (api *API) handlePauseConnector() http.HandlerFunc {
+	return api.handleConnectorAction("pause", func(r *http.Request, clusterName string, connectorName string) (*connect.ConnectorDetails, error) {
+		return api.ConnectSvc.PauseConnector(r.Context(), clusterName, connectorName)
+	})
+}
+
+func (api *API) handleResumeConnector() http.HandlerFunc {
+	return api.handleConnectorAction("resume", func(r *http.Request, clusterName string, connectorName string) (*connect.ConnectorDetails, error) {
+		return api.ConnectSvc.ResumeConnector(r.Context(), clusterName, connectorName)
+	})
+}
+
+var errInvalidTaskID = errors.New("task id must be a non-negative number")
+
+// connectErrorStatus returns the HTTP status code for errors which are returned by the Kafka Connect service
+func connectErrorStatus(err error) int {
+	var connectErr *connect.RestError
+	switch {
+	case errors.Is(err, errInvalidTaskID):
+		return http.StatusBadRequest
+	case errors.Is(err, connect.ErrClusterNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, connect.ErrNotConfigured):
+		return http.StatusNotImplemented
+	case errors.As(err, &connectErr) && connectErr.ErrorCode == http.StatusNotFound:
+		return http.StatusNotFound
+	case errors.As(err, &connectErr) && connectErr.ErrorCode == http.StatusConflict:
+		// Kafka Connect rejects requests while the workers are rebalancing
+		return http.StatusConflict
+	default:
+		return http.StatusServiceUnavailable
+	}
+}
@@ -1,10 +1,17 @@
 package api
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"sort"
+	"time"
 
 	"github.com/cloudhut/common/rest"
+	"github.com/cloudhut/kowl/backend/pkg/kafka"
 	"github.com/cloudhut/kowl/backend/pkg/owl"
+	"github.com/go-chi/chi"
+	"go.uber.org/zap"
 )
 
 // GetConsumerGroupsResponse represents the data which is returned for listing topics
@@ -14,7 +21,7 @@ type GetConsumerGroupsResponse struct {
 
 func (api *API) handleGetConsumerGroups() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		describedGroups, err := api.OwlSvc.GetConsumerGroupsOverview(r.Context())
+		describedGroups, err := api.owlSvc(r).GetConsumerGroupsOverview(r.Context())
 		if err != nil {
 			restErr := &rest.Error{
 				Err:      err,
@@ -34,9 +41,11 @@ func (api *API) handleGetConsumerGroups() http.HandlerFunc {
 				return
 			}
 
-			if canSee {
-				visibleGroups = append(visibleGroups, group)
+			if !canSee {
+				continue
 			}
+			api.topicFilter.filterGroupOverview(group)
+			visibleGroups = append(visibleGroups, group)
 
 			// Attach allowed actions for each topic
 			group.AllowedActions, restErr = api.Hooks.Owl.AllowedConsumerGroupActions(r.Context(), group.GroupID)
@@ -47,8 +56,518 @@ func (api *API) handleGetConsumerGroups() http.HandlerFunc {
 		}
 
 		response := GetConsumerGroupsResponse{
-			ConsumerGroups: describedGroups,
+			ConsumerGroups: visibleGroups,
 		}
 		rest.SendResponse(w, r, api.Logger, http.StatusOK, response)
 	}
 }
+
+type resetConsumerGroupOffsetsRequest struct {
+	Topics []struct {
+		TopicName    string  `json:"topicName"`
+		PartitionIDs []int32 `json:"partitionIds"` // Empty for all partitions
+	} `json:"topics"`
+	Strategy  kafka.OffsetResetStrategy `json:"strategy"`
+	Offset    int64                     `json:"offset"`
+	Timestamp string                    `json:"timestamp"` // RFC3339, only for the timestamp strategy
+	Force     bool                      `json:"force"`
+}
+
+func (r *resetConsumerGroupOffsetsRequest) OK() error {
+	if len(r.Topics) == 0 {
+		return fmt.Errorf("at least one topic must be given")
+	}
+	for _, topic := range r.Topics {
+		if topic.TopicName == "" {
+			return fmt.Errorf("topic name is required")
+		}
+	}
+
+	switch r.Strategy {
+	case kafka.OffsetResetEarliest, kafka.OffsetResetLatest, kafka.OffsetResetOffset:
+	case kafka.OffsetResetTimestamp:
+		if _, err := time.Parse(time.RFC3339, r.Timestamp); err != nil {
+			return fmt.Errorf("timestamp must be given in RFC3339 format: %w", err)
+		}
+	default:
+		return fmt.Errorf("strategy must be one of: earliest, latest, offset, timestamp")
+	}
+
+	return nil
+}
+
+// handleResetConsumerGroupOffsets resets the committed offsets of a consumer group to the earliest, latest, a specific
+// offset or the offset at a timestamp.
+func (api *API) handleResetConsumerGroupOffsets() http.HandlerFunc {
+	type response struct {
+		GroupID string                          `json:"groupId"`
+		Offsets []owl.ConsumerGroupOffsetChange `json:"offsets"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		groupID := chi.URLParam(r, "groupId")
+		logger := api.Logger.With(zap.String("group_id", groupID))
+
+		if !api.Cfg.EnableTopicOperations {
+			restErr := &rest.Error{
+				Err:      fmt.Errorf("topic operations are disabled"),
+				Status:   http.StatusForbidden,
+				Message:  "Operations are disabled, set 'enableTopicOperations' to true in order to reset consumer group offsets",
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+
+		// Check if logged in user is allowed to edit the given consumer group
+		canEdit, restErr := api.Hooks.Owl.CanEditConsumerGroup(r.Context(), groupID)
+		if restErr != nil {
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+		if !canEdit {
+			restErr := &rest.Error{
+				Err:      fmt.Errorf("requester has no permissions to edit the requested consumer group"),
+				Status:   http.StatusForbidden,
+				Message:  "You don't have permissions to edit that consumer group",
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+
+		var req resetConsumerGroupOffsetsRequest
+		err := rest.Decode(r, &req)
+		if err != nil {
+			restErr := &rest.Error{
+				Err:      err,
+				Status:   http.StatusBadRequest,
+				Message:  fmt.Sprintf("Failed to parse request: %v", err.Error()),
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+
+		for _, topic := range req.Topics {
+			if !api.topicFilter.isAllowed(topic.TopicName) {
+				rest.SendRESTError(w, r, logger, newTopicNotAllowedError(topic.TopicName))
+				return
+			}
+		}
+
+		timestamp, _ := time.Parse(time.RFC3339, req.Timestamp) // Error has been checked in validation function
+		resetReq := kafka.ResetConsumerGroupOffsetsRequest{
+			GroupID:   groupID,
+			Topics:    make(map[string][]int32, len(req.Topics)),
+			Strategy:  req.Strategy,
+			Offset:    req.Offset,
+			Timestamp: timestamp,
+			Force:     req.Force,
+		}
+		for _, topic := range req.Topics {
+			resetReq.Topics[topic.TopicName] = append(resetReq.Topics[topic.TopicName], topic.PartitionIDs...)
+		}
+
+		offsets, err := api.owlSvc(r).ResetConsumerGroupOffsets(r.Context(), resetReq)
+		if err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, kafka.ErrConsumerGroupActive) {
+				status = http.StatusConflict
+			}
+			restErr := &rest.Error{
+				Err:      err,
+				Status:   status,
+				Message:  fmt.Sprintf("Could not reset consumer group offsets: %v", err.Error()),
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+
+		res := response{
+			GroupID: groupID,
+			Offsets: offsets,
+		}
+		rest.SendResponse(w, r, logger, http.StatusOK, res)
+	}
+}
+
+type copyConsumerGroupOffsetsRequest struct {
+	DestinationGroupID string   `json:"destinationGroupId"`
+	Topics             []string `json:"topics"` // Empty for all topics with committed offsets
+	OffsetDelta        int64    `json:"offsetDelta"`
+}
+
+func (c *copyConsumerGroupOffsetsRequest) OK() error {
+	if c.DestinationGroupID == "" {
+		return fmt.Errorf("destination group id is required")
+	}
+	for _, topic := range c.Topics {
+		if topic == "" {
+			return fmt.Errorf("topic name must not be empty")
+		}
+	}
+
+	return nil
+}
+
+// handleCopyConsumerGroupOffsets commits the committed offsets of a consumer group for another (inactive) consumer
+// group, optionally shifted by an offset delta.
+func (api *API) handleCopyConsumerGroupOffsets() http.HandlerFunc {
+	type response struct {
+		SourceGroupID      string                          `json:"sourceGroupId"`
+		DestinationGroupID string                          `json:"destinationGroupId"`
+		Offsets            []owl.CopiedConsumerGroupOffset `json:"offsets"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		groupID := chi.URLParam(r, "groupId")
+		logger := api.Logger.With(zap.String("group_id", groupID))
+
+		if !api.Cfg.EnableTopicOperations {
+			restErr := &rest.Error{
+				Err:      fmt.Errorf("topic operations are disabled"),
+				Status:   http.StatusForbidden,
+				Message:  "Operations are disabled, set 'enableTopicOperations' to true in order to copy consumer group offsets",
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+
+		var req copyConsumerGroupOffsetsRequest
+		err := rest.Decode(r, &req)
+		if err != nil {
+			restErr := &rest.Error{
+				Err:      err,
+				Status:   http.StatusBadRequest,
+				Message:  fmt.Sprintf("Failed to parse request: %v", err.Error()),
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+		logger = logger.With(zap.String("destination_group_id", req.DestinationGroupID))
+		if req.DestinationGroupID == groupID {
+			restErr := &rest.Error{
+				Err:      fmt.Errorf("source and destination group are the same"),
+				Status:   http.StatusBadRequest,
+				Message:  "The destination group must be different from the source group",
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+
+		for _, topicName := range req.Topics {
+			if !api.topicFilter.isAllowed(topicName) {
+				rest.SendRESTError(w, r, logger, newTopicNotAllowedError(topicName))
+				return
+			}
+		}
+
+		// The requester must be allowed to see the source group and to edit the destination group
+		canSee, restErr := api.Hooks.Owl.CanSeeConsumerGroup(r.Context(), groupID)
+		if restErr != nil {
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+		if !canSee {
+			restErr := &rest.Error{
+				Err:      fmt.Errorf("requester has no permissions to view the requested consumer group"),
+				Status:   http.StatusForbidden,
+				Message:  "You don't have permissions to view that consumer group",
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+		canEdit, restErr := api.Hooks.Owl.CanEditConsumerGroup(r.Context(), req.DestinationGroupID)
+		if restErr != nil {
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+		if !canEdit {
+			restErr := &rest.Error{
+				Err:      fmt.Errorf("requester has no permissions to edit the destination consumer group"),
+				Status:   http.StatusForbidden,
+				Message:  "You don't have permissions to edit the destination consumer group",
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+
+		copyReq := kafka.CopyConsumerGroupOffsetsRequest{
+			SourceGroupID:      groupID,
+			DestinationGroupID: req.DestinationGroupID,
+			Topics:             req.Topics,
+			IsTopicAllowed:     api.topicFilter.isAllowed,
+			OffsetDelta:        req.OffsetDelta,
+		}
+		offsets, err := api.owlSvc(r).CopyConsumerGroupOffsets(r.Context(), copyReq)
+		if err != nil {
+			status := http.StatusInternalServerError
+			switch {
+			case errors.Is(err, kafka.ErrConsumerGroupActive):
+				status = http.StatusConflict
+			case errors.Is(err, kafka.ErrNoCommittedOffsets):
+				status = http.StatusNotFound
+			}
+			restErr := &rest.Error{
+				Err:      err,
+				Status:   status,
+				Message:  fmt.Sprintf("Could not copy consumer group offsets: %v", err.Error()),
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+
+		res := response{
+			SourceGroupID:      groupID,
+			DestinationGroupID: req.DestinationGroupID,
+			Offsets:            offsets,
+		}
+		rest.SendResponse(w, r, logger, http.StatusOK, res)
+	}
+}
+
+// handleGetConsumerGroupLag returns the lag of a single consumer group for each partition, aggregated per topic and in
+// total.
+func (api *API) handleGetConsumerGroupLag() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		groupID := chi.URLParam(r, "groupId")
+		logger := api.Logger.With(zap.String("group_id", groupID))
+
+		canSee, restErr := api.Hooks.Owl.CanSeeConsumerGroup(r.Context(), groupID)
+		if restErr != nil {
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+		if !canSee {
+			restErr := &rest.Error{
+				Err:      fmt.Errorf("requester has no permissions to view the requested consumer group"),
+				Status:   http.StatusForbidden,
+				Message:  "You don't have permissions to view that consumer group",
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+
+		lag, err := api.owlSvc(r).GetConsumerGroupLagDetails(r.Context(), groupID)
+		if err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, owl.ErrConsumerGroupNotFound) {
+				status = http.StatusNotFound
+			}
+			restErr := &rest.Error{
+				Err:      err,
+				Status:   status,
+				Message:  fmt.Sprintf("Could not get consumer group lag: %v", err.Error()),
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+
+		api.topicFilter.filterGroupLagDetails(lag)
+		rest.SendResponse(w, r, logger, http.StatusOK, lag)
+	}
+}
+
+// handleGetConsumerGroupMembers returns the members of a single consumer group along with their assigned partitions
+func (api *API) handleGetConsumerGroupMembers() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		groupID := chi.URLParam(r, "groupId")
+		logger := api.Logger.With(zap.String("group_id", groupID))
+
+		canSee, restErr := api.Hooks.Owl.CanSeeConsumerGroup(r.Context(), groupID)
+		if restErr != nil {
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+		if !canSee {
+			restErr := &rest.Error{
+				Err:      fmt.Errorf("requester has no permissions to view the requested consumer group"),
+				Status:   http.StatusForbidden,
+				Message:  "You don't have permissions to view that consumer group",
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+
+		members, err := api.owlSvc(r).GetConsumerGroupMembers(r.Context(), groupID)
+		if err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, owl.ErrConsumerGroupNotFound) {
+				status = http.StatusNotFound
+			}
+			restErr := &rest.Error{
+				Err:      err,
+				Status:   status,
+				Message:  fmt.Sprintf("Could not get consumer group members: %v", err.Error()),
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+
+		api.topicFilter.filterGroupMembers(members)
+		rest.SendResponse(w, r, logger, http.StatusOK, members)
+	}
+}
+
+// errDeleteConsumerGroupsDisabled is returned by all consumer group deletions if topic operations are disabled
+var errDeleteConsumerGroupsDisabled = &rest.Error{
+	Err:      fmt.Errorf("topic operations are disabled"),
+	Status:   http.StatusForbidden,
+	Message:  "Operations are disabled, set 'enableTopicOperations' to true in order to delete consumer groups",
+	IsSilent: false,
+}
+
+// checkDeleteConsumerGroup returns an error if the requester is not allowed to delete the given consumer group
+func (api *API) checkDeleteConsumerGroup(r *http.Request, groupID string) *rest.Error {
+	canDelete, restErr := api.Hooks.Owl.CanDeleteConsumerGroup(r.Context(), groupID)
+	if restErr != nil {
+		return restErr
+	}
+	if !canDelete {
+		return &rest.Error{
+			Err:      fmt.Errorf("requester has no permissions to delete the requested consumer group"),
+			Status:   http.StatusForbidden,
+			Message:  "You don't have permissions to delete that consumer group",
+			IsSilent: false,
+		}
+	}
+
+	return nil
+}
+
+// handleDeleteConsumerGroup deletes a single consumer group along with its committed offsets. Groups with active
+// members can not be deleted.
+func (api *API) handleDeleteConsumerGroup() http.HandlerFunc {
+	type response struct {
+		GroupID string `json:"groupId"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		groupID := chi.URLParam(r, "groupId")
+		logger := api.Logger.With(zap.String("group_id", groupID))
+
+		if !api.Cfg.EnableTopicOperations {
+			rest.SendRESTError(w, r, logger, errDeleteConsumerGroupsDisabled)
+			return
+		}
+		restErr := api.checkDeleteConsumerGroup(r, groupID)
+		if restErr != nil {
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+
+		err := api.owlSvc(r).DeleteConsumerGroup(r.Context(), groupID)
+		if err != nil {
+			status := http.StatusInternalServerError
+			switch {
+			case errors.Is(err, kafka.ErrConsumerGroupActive):
+				status = http.StatusConflict
+			case errors.Is(err, kafka.ErrConsumerGroupNotFound):
+				status = http.StatusNotFound
+			}
+			restErr := &rest.Error{
+				Err:      err,
+				Status:   status,
+				Message:  fmt.Sprintf("Could not delete consumer group: %v", err.Error()),
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+
+		rest.SendResponse(w, r, logger, http.StatusOK, response{GroupID: groupID})
+	}
+}
+
+type deleteConsumerGroupsRequest struct {
+	GroupIDs []string `json:"groupIds"`
+}
+
+func (d *deleteConsumerGroupsRequest) OK() error {
+	if len(d.GroupIDs) == 0 {
+		return fmt.Errorf("at least one consumer group must be given")
+	}
+
+	seen := make(map[string]bool, len(d.GroupIDs))
+	for _, groupID := range d.GroupIDs {
+		if groupID == "" {
+			return fmt.Errorf("group id must not be empty")
+		}
+		if seen[groupID] {
+			return fmt.Errorf("consumer group '%v' is listed more than once", groupID)
+		}
+		seen[groupID] = true
+	}
+
+	return nil
+}
+
+// handleDeleteConsumerGroups deletes multiple consumer groups and reports the result of each group. Groups which can
+// not be deleted (e. g. because they are still active) are reported along with the error, they don't fail the request.
+func (api *API) handleDeleteConsumerGroups() http.HandlerFunc {
+	type response struct {
+		Groups []owl.DeletedConsumerGroup `json:"groups"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !api.Cfg.EnableTopicOperations {
+			rest.SendRESTError(w, r, api.Logger, errDeleteConsumerGroupsDisabled)
+			return
+		}
+
+		var req deleteConsumerGroupsRequest
+		err := rest.Decode(r, &req)
+		if err != nil {
+			restErr := &rest.Error{
+				Err:      err,
+				Status:   http.StatusBadRequest,
+				Message:  fmt.Sprintf("Failed to parse request: %v", err.Error()),
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, api.Logger, restErr)
+			return
+		}
+
+		// Groups which the requester is not allowed to delete are reported, but not passed on to Kafka
+		allowed := make([]string, 0, len(req.GroupIDs))
+		groups := make([]owl.DeletedConsumerGroup, 0, len(req.GroupIDs))
+		for _, groupID := range req.GroupIDs {
+			restErr := api.checkDeleteConsumerGroup(r, groupID)
+			if restErr != nil {
+				groups = append(groups, owl.DeletedConsumerGroup{GroupID: groupID, Error: restErr.Message})
+				continue
+			}
+			allowed = append(allowed, groupID)
+		}
+
+		if len(allowed) > 0 {
+			deleted, err := api.owlSvc(r).DeleteConsumerGroups(r.Context(), allowed)
+			if err != nil {
+				restErr := &rest.Error{
+					Err:      err,
+					Status:   http.StatusInternalServerError,
+					Message:  fmt.Sprintf("Could not delete consumer groups: %v", err.Error()),
+					IsSilent: false,
+				}
+				rest.SendRESTError(w, r, api.Logger, restErr)
+				return
+			}
+			groups = append(groups, deleted...)
+		}
+		sort.Slice(groups, func(i, j int) bool { return groups[i].GroupID < groups[j].GroupID })
+
+		rest.SendResponse(w, r, api.Logger, http.StatusOK, response{Groups: groups})
+	}
+}
@@ -0,0 +1,217 @@
+package api
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/cloudhut/common/rest"
+	"github.com/cloudhut/kowl/backend/pkg/kafka"
+	"github.com/go-chi/chi"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultExportLimit = 1000
+	maxExportLimit     = 1000000
+
+	// exportFlushInterval is the number of messages after which the export is flushed to the client
+	exportFlushInterval = 100
+
+	// exportErrorTrailer is the HTTP trailer which carries the error of an export which has been aborted after the
+	// response has been started. It is empty if the export is complete.
+	exportErrorTrailer = "X-Export-Error"
+)
+
+// exportMessagesRequest are the parsed query parameters of an export request
+type exportMessagesRequest struct {
+	kafka.ExportMessagesRequest
+	Format  string
+	Columns []string
+}
+
+// parseExportMessagesRequest parses the query parameters of an export request:
+// ?format=ndjson|json|csv&columns=key,value&partitions=0,1&startOffset=0&endOffset=100
+// &startTime=2020-11-20T14:32:00Z&endTime=2020-11-21T14:32:00Z&limit=1000&raw=false
+func parseExportMessagesRequest(r *http.Request, topicName string) (*exportMessagesRequest, error) {
+	query := r.URL.Query()
+	req := &exportMessagesRequest{
+		ExportMessagesRequest: kafka.ExportMessagesRequest{
+			TopicName:   topicName,
+			StartOffset: -1,
+			EndOffset:   -1,
+			Limit:       defaultExportLimit,
+		},
+		Format: query.Get("format"),
+	}
+
+	var err error
+	req.PartitionIDs, err = parsePartitionIDs(query.Get("partitions"))
+	if err != nil {
+		return nil, err
+	}
+	req.Columns, err = parseExportColumns(query.Get("columns"))
+	if err != nil {
+		return nil, err
+	}
+
+	for param, offset := range map[string]*int64{"startOffset": &req.StartOffset, "endOffset": &req.EndOffset} {
+		if value := query.Get(param); value != "" {
+			*offset, err = strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%v must be an offset, but '%v' is not a number", param, value)
+			}
+		}
+	}
+	for param, timestamp := range map[string]*time.Time{"startTime": &req.StartTime, "endTime": &req.EndTime} {
+		if value := query.Get(param); value != "" {
+			*timestamp, err = time.Parse(time.RFC3339, value)
+			if err != nil {
+				return nil, fmt.Errorf("%v must be given in RFC3339 format, e.g. 2020-11-20T14:32:00Z", param)
+			}
+		}
+	}
+	if !req.StartTime.IsZero() && !req.EndTime.IsZero() && req.EndTime.Before(req.StartTime) {
+		return nil, fmt.Errorf("endTime must not be before startTime")
+	}
+
+	if value := query.Get("limit"); value != "" {
+		req.Limit, err = strconv.Atoi(value)
+		if err != nil || req.Limit <= 0 || req.Limit > maxExportLimit {
+			return nil, fmt.Errorf("limit must be a number between 1 and %v", maxExportLimit)
+		}
+	}
+	if value := query.Get("raw"); value != "" {
+		req.Raw, err = strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("raw must be either true or false")
+		}
+	}
+
+	return req, nil
+}
+
+// handleExportMessages streams the messages of the requested range as NDJSON, JSON array or CSV download. The
+// response is streamed while the messages are consumed, hence errors which occur after the first message has been
+// written can't change the status code anymore. The truncated export ends with an error record (JSON formats) and the
+// error is sent in the exportErrorTrailer. The duration of an export is limited by server.writeTimeout.
+func (api *API) handleExportMessages() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		topicName := chi.URLParam(r, "topicName")
+		logger := api.Logger.With(zap.String("topic_name", topicName))
+
+		req, err := parseExportMessagesRequest(r, topicName)
+		if err != nil {
+			restErr := &rest.Error{
+				Err:      err,
+				Status:   http.StatusBadRequest,
+				Message:  err.Error(),
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+
+		restErr := api.checkCanViewTopicMessages(r, topicName)
+		if restErr != nil {
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+
+		buffered := bufio.NewWriter(w)
+		writer, contentType, err := newMessageExportWriter(req.Format, req.Columns, buffered)
+		if err != nil {
+			restErr := &rest.Error{
+				Err:      err,
+				Status:   http.StatusBadRequest,
+				Message:  err.Error(),
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+
+		flush := func() error {
+			if err := writer.Flush(); err != nil {
+				return err
+			}
+			if err := buffered.Flush(); err != nil {
+				return err
+			}
+			if flusher, ok := w.(http.Flusher); ok {
+				flusher.Flush()
+			}
+			return nil
+		}
+
+		// The response headers are sent along with the first message, so that errors which occur before can still
+		// be returned as error response
+		isStarted := false
+		written := 0
+		consumedBytes := int64(0)
+		_, err = api.owlSvc(r).ExportMessages(r.Context(), req.ExportMessagesRequest, func(m *kafka.ExportedMessage) error {
+			if !isStarted {
+				isStarted = true
+				setExportHeaders(w, topicName, req.Format, contentType)
+			}
+			consumedBytes += int64(m.Size)
+
+			err := writer.WriteMessage(m)
+			if err != nil {
+				return err
+			}
+			written++
+			if written%exportFlushInterval == 0 {
+				return flush()
+			}
+			return nil
+		})
+		api.chargeConsumedBytes(r, consumedBytes)
+		if err != nil && !isStarted {
+			restErr := &rest.Error{
+				Err:      err,
+				Status:   consumeErrorStatus(err),
+				Message:  fmt.Sprintf("Could not export messages: %v", err.Error()),
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+		if err != nil {
+			logger.Warn("message export has been aborted", zap.Int("exported_messages", written), zap.Error(err))
+			w.Header().Set(exportErrorTrailer, err.Error())
+			abortErr := writer.Abort(err)
+			if abortErr == nil {
+				abortErr = flush()
+			}
+			if abortErr != nil {
+				logger.Warn("failed to finish aborted message export", zap.Error(abortErr))
+			}
+			return
+		}
+
+		if !isStarted {
+			setExportHeaders(w, topicName, req.Format, contentType)
+		}
+		err = writer.Close()
+		if err == nil {
+			err = flush()
+		}
+		if err != nil {
+			logger.Warn("failed to finish message export", zap.Error(err))
+		}
+	}
+}
+
+// setExportHeaders sets the response headers, which let browsers download the export as file
+func setExportHeaders(w http.ResponseWriter, topicName string, format string, contentType string) {
+	if format == "" {
+		format = exportFormatNDJSON
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", topicName+"."+format))
+	w.Header().Set("Trailer", exportErrorTrailer)
+	w.WriteHeader(http.StatusOK)
+}
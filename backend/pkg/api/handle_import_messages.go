@@ -0,0 +1,111 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"github.com/cloudhut/common/rest"
+	"github.com/cloudhut/kowl/backend/pkg/owl"
+	"github.com/go-chi/chi"
+	"go.uber.org/zap"
+)
+
+// parseImportMessagesRequest parses the query parameters of an import request:
+// ?encoding=base64|decoded&preserveKeys=true&preserveHeaders=true&dryRun=false
+func parseImportMessagesRequest(r *http.Request, topicName string) (*owl.ImportMessagesRequest, error) {
+	query := r.URL.Query()
+	req := &owl.ImportMessagesRequest{
+		TopicName:       topicName,
+		Encoding:        owl.ImportEncoding(query.Get("encoding")),
+		PreserveKeys:    true,
+		PreserveHeaders: true,
+	}
+	switch req.Encoding {
+	case "", owl.ImportEncodingBase64, owl.ImportEncodingDecoded:
+	default:
+		return nil, fmt.Errorf("encoding '%v' is invalid, it must be one of: %v, %v", req.Encoding,
+			owl.ImportEncodingBase64, owl.ImportEncodingDecoded)
+	}
+
+	for param, value := range map[string]*bool{"preserveKeys": &req.PreserveKeys, "preserveHeaders": &req.PreserveHeaders, "dryRun": &req.DryRun} {
+		if query.Get(param) == "" {
+			continue
+		}
+		var err error
+		*value, err = strconv.ParseBool(query.Get(param))
+		if err != nil {
+			return nil, fmt.Errorf("%v must be either true or false", param)
+		}
+	}
+
+	return req, nil
+}
+
+// handleImportMessages produces the records of an uploaded NDJSON message export to a topic. The export is sent as
+// request body and must not be larger than messageImportMaxBytes.
+func (api *API) handleImportMessages() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		topicName := chi.URLParam(r, "topicName")
+		logger := api.Logger.With(zap.String("topic_name", topicName))
+
+		restErr := api.checkCanProduceToTopic(r, topicName)
+		if restErr != nil {
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+
+		req, err := parseImportMessagesRequest(r, topicName)
+		if err != nil {
+			restErr := &rest.Error{
+				Err:      err,
+				Status:   http.StatusBadRequest,
+				Message:  err.Error(),
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+
+		// One more byte than allowed is read, so that too large uploads can be told apart
+		body, err := ioutil.ReadAll(io.LimitReader(r.Body, api.Cfg.MessageImportMaxBytes+1))
+		if err != nil {
+			restErr := &rest.Error{
+				Err:      err,
+				Status:   http.StatusBadRequest,
+				Message:  fmt.Sprintf("Failed to read uploaded messages: %v", err.Error()),
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+		if int64(len(body)) > api.Cfg.MessageImportMaxBytes {
+			restErr := &rest.Error{
+				Err:      fmt.Errorf("uploaded messages exceed the max size of %v bytes", api.Cfg.MessageImportMaxBytes),
+				Status:   http.StatusRequestEntityTooLarge,
+				Message:  fmt.Sprintf("The uploaded messages must not be larger than %v bytes", api.Cfg.MessageImportMaxBytes),
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+		req.Records = bytes.NewReader(body)
+
+		res, err := api.owlSvc(r).ImportMessages(r.Context(), *req)
+		if err != nil {
+			restErr := &rest.Error{
+				Err:      err,
+				Status:   produceErrorStatus(err),
+				Message:  fmt.Sprintf("Could not import messages: %v", err.Error()),
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+
+		rest.SendResponse(w, r, logger, http.StatusOK, res)
+	}
+}
@@ -0,0 +1,173 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/cloudhut/common/rest"
+	"github.com/cloudhut/kowl/backend/pkg/kafka"
+	"go.uber.org/zap"
+)
+
+type reassignPartitionsRequest struct {
+	Topics []struct {
+		TopicName  string `json:"topicName"`
+		Partitions []struct {
+			PartitionID int32   `json:"partitionId"`
+			Replicas    []int32 `json:"replicas"`
+		} `json:"partitions"`
+	} `json:"topics"`
+}
+
+func (r *reassignPartitionsRequest) OK() error {
+	if len(r.Topics) == 0 {
+		return fmt.Errorf("at least one topic must be given")
+	}
+	for _, topic := range r.Topics {
+		if topic.TopicName == "" {
+			return fmt.Errorf("topic name is required")
+		}
+		if len(topic.Partitions) == 0 {
+			return fmt.Errorf("at least one partition of topic '%v' must be given", topic.TopicName)
+		}
+	}
+
+	return nil
+}
+
+// reassignmentErrorStatus returns the HTTP status code for errors which are returned by partition reassignments
+func reassignmentErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, kafka.ErrInvalidReassignment):
+		return http.StatusBadRequest
+	case errors.Is(err, kafka.ErrPartitionReassignmentsNotSupported):
+		return http.StatusNotImplemented
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// handleReassignPartitions submits a reassignment of partition replicas to other brokers
+func (api *API) handleReassignPartitions() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !api.Cfg.EnableTopicOperations {
+			restErr := &rest.Error{
+				Err:      fmt.Errorf("topic operations are disabled"),
+				Status:   http.StatusForbidden,
+				Message:  "Topic operations are disabled, set 'enableTopicOperations' to true in order to reassign partitions",
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, api.Logger, restErr)
+			return
+		}
+
+		var req reassignPartitionsRequest
+		err := rest.Decode(r, &req)
+		if err != nil {
+			restErr := &rest.Error{
+				Err:      err,
+				Status:   http.StatusBadRequest,
+				Message:  fmt.Sprintf("Failed to parse request: %v", err.Error()),
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, api.Logger, restErr)
+			return
+		}
+
+		reassignments := make(map[string][]kafka.PartitionReassignment, len(req.Topics))
+		for _, topic := range req.Topics {
+			if !api.topicFilter.isAllowed(topic.TopicName) {
+				rest.SendRESTError(w, r, api.Logger, newTopicNotAllowedError(topic.TopicName))
+				return
+			}
+			// Check if logged in user is allowed to change the partitions of each topic
+			canEdit, restErr := api.Hooks.Owl.CanEditTopicPartitions(r.Context(), topic.TopicName)
+			if restErr != nil {
+				rest.SendRESTError(w, r, api.Logger, restErr)
+				return
+			}
+			if !canEdit {
+				restErr := &rest.Error{
+					Err:      fmt.Errorf("requester has no permissions to edit partitions of topic '%v'", topic.TopicName),
+					Status:   http.StatusForbidden,
+					Message:  fmt.Sprintf("You don't have permissions to edit the partitions of topic '%v'", topic.TopicName),
+					IsSilent: false,
+				}
+				rest.SendRESTError(w, r, api.Logger, restErr)
+				return
+			}
+
+			for _, p := range topic.Partitions {
+				reassignments[topic.TopicName] = append(reassignments[topic.TopicName], kafka.PartitionReassignment{
+					PartitionID: p.PartitionID,
+					Replicas:    p.Replicas,
+				})
+			}
+		}
+
+		progress, err := api.owlSvc(r).ReassignPartitions(r.Context(), reassignments)
+		if err != nil {
+			restErr := &rest.Error{
+				Err:      err,
+				Status:   reassignmentErrorStatus(err),
+				Message:  fmt.Sprintf("Could not reassign partitions: %v", err.Error()),
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, api.Logger, restErr)
+			return
+		}
+		api.Logger.Info("submitted partition reassignment", zap.Int("topics", len(reassignments)))
+
+		rest.SendResponse(w, r, api.Logger, http.StatusOK, progress)
+	}
+}
+
+// handleGetPartitionReassignments returns the progress of all ongoing partition reassignments. The topics can be
+// limited by passing a comma separated list of topic names via the 'topicNames' query parameter.
+func (api *API) handleGetPartitionReassignments() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var topicNames []string
+		if param := r.URL.Query().Get("topicNames"); param != "" {
+			topicNames = strings.Split(param, ",")
+		}
+
+		for _, topicName := range topicNames {
+			if !api.topicFilter.isAllowed(topicName) {
+				rest.SendRESTError(w, r, api.Logger, newTopicNotAllowedError(topicName))
+				return
+			}
+			canView, restErr := api.Hooks.Owl.CanViewTopicPartitions(r.Context(), topicName)
+			if restErr != nil {
+				rest.SendRESTError(w, r, api.Logger, restErr)
+				return
+			}
+			if !canView {
+				restErr := &rest.Error{
+					Err:      fmt.Errorf("requester has no permissions to view partitions of topic '%v'", topicName),
+					Status:   http.StatusForbidden,
+					Message:  fmt.Sprintf("You don't have permissions to view the partitions of topic '%v'", topicName),
+					IsSilent: false,
+				}
+				rest.SendRESTError(w, r, api.Logger, restErr)
+				return
+			}
+		}
+
+		progress, err := api.owlSvc(r).ListPartitionReassignments(r.Context(), topicNames)
+		if err != nil {
+			restErr := &rest.Error{
+				Err:      err,
+				Status:   reassignmentErrorStatus(err),
+				Message:  fmt.Sprintf("Could not list partition reassignments: %v", err.Error()),
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, api.Logger, restErr)
+			return
+		}
+
+		api.topicFilter.filterReassignments(progress)
+		rest.SendResponse(w, r, api.Logger, http.StatusOK, progress)
+	}
+}
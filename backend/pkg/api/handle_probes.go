@@ -1,8 +1,12 @@
 package api
 
 import (
+	"context"
 	"github.com/cloudhut/common/rest"
+	"github.com/cloudhut/kowl/backend/pkg/kafka"
+	"go.uber.org/zap"
 	"net/http"
+	"time"
 )
 
 func (api *API) handleLivenessProbe() http.HandlerFunc {
@@ -26,11 +30,17 @@ func (api *API) handleStartupProbe() http.HandlerFunc {
 	}
 
 	return func(w http.ResponseWriter, r *http.Request) {
-		// Check Kafka connectivity
-		isKafkaOK := false
-		err := api.KafkaSvc.IsHealthy()
-		if err == nil {
-			isKafkaOK = true
+		// Check Kafka connectivity of all clusters by opening a new connection to one of the seed brokers
+		ctx, cancel := context.WithTimeout(r.Context(), 6*time.Second)
+		defer cancel()
+
+		isKafkaOK := true
+		for _, name := range api.clusterNames {
+			err := kafka.PingCluster(ctx, &api.clusters[name].Cfg)
+			if err != nil {
+				isKafkaOK = false
+				api.Logger.Warn("startup probe failed to ping Kafka cluster", zap.String("cluster", name), zap.Error(err))
+			}
 		}
 
 		res := &response{
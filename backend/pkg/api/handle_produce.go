@@ -0,0 +1,133 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/cloudhut/common/rest"
+	"github.com/cloudhut/kowl/backend/pkg/kafka"
+	"github.com/cloudhut/kowl/backend/pkg/owl"
+	"github.com/go-chi/chi"
+	"go.uber.org/zap"
+)
+
+type produceMessageRequest struct {
+	PartitionID *int32              `json:"partitionId"` // Optional, the key's hash determines the partition otherwise
+	Key         *string             `json:"key"`
+	KeyEncoding owl.PayloadEncoding `json:"keyEncoding"`
+	Headers     map[string]string   `json:"headers"`
+	Value       string              `json:"value"`
+	Encoding    owl.PayloadEncoding `json:"encoding"`    // raw (default), json or base64
+	Compression string              `json:"compression"` // Optional, overrides the configured producer compression
+}
+
+func (p *produceMessageRequest) OK() error {
+	if p.PartitionID != nil && *p.PartitionID < 0 {
+		return fmt.Errorf("partitionId must not be negative")
+	}
+	for _, encoding := range []owl.PayloadEncoding{p.Encoding, p.KeyEncoding} {
+		switch encoding {
+		case "", owl.PayloadEncodingRaw, owl.PayloadEncodingJSON, owl.PayloadEncodingBase64:
+		default:
+			return fmt.Errorf("encoding '%v' is invalid, it must be one of: raw, json, base64", encoding)
+		}
+	}
+
+	return nil
+}
+
+// handleProduceMessage produces a single message to a topic and returns the partition and offset it has been
+// written to
+func (api *API) handleProduceMessage() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		topicName := chi.URLParam(r, "topicName")
+		logger := api.Logger.With(zap.String("topic_name", topicName))
+
+		restErr := api.checkCanProduceToTopic(r, topicName)
+		if restErr != nil {
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+
+		var req produceMessageRequest
+		err := rest.Decode(r, &req)
+		if err != nil {
+			restErr := &rest.Error{
+				Err:      err,
+				Status:   http.StatusBadRequest,
+				Message:  fmt.Sprintf("Failed to parse request: %v", err.Error()),
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+
+		res, err := api.owlSvc(r).ProduceMessage(r.Context(), owl.ProduceMessageRequest{
+			TopicName:   topicName,
+			PartitionID: req.PartitionID,
+			Key:         req.Key,
+			KeyEncoding: req.KeyEncoding,
+			Headers:     req.Headers,
+			Value:       req.Value,
+			Encoding:    req.Encoding,
+			Compression: req.Compression,
+		})
+		if err != nil {
+			restErr := &rest.Error{
+				Err:      err,
+				Status:   produceErrorStatus(err),
+				Message:  fmt.Sprintf("Could not produce message: %v", err.Error()),
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+
+		rest.SendResponse(w, r, logger, http.StatusOK, res)
+	}
+}
+
+// checkCanProduceToTopic returns an error if producing is disabled or the requester must not produce messages to the
+// given topic
+func (api *API) checkCanProduceToTopic(r *http.Request, topicName string) *rest.Error {
+	if !api.Cfg.EnableProduce {
+		return &rest.Error{
+			Err:      fmt.Errorf("producing messages is disabled"),
+			Status:   http.StatusForbidden,
+			Message:  "Producing messages is disabled, set 'enableProduce' to true in order to produce messages",
+			IsSilent: false,
+		}
+	}
+
+	// Check if logged in user is allowed to produce messages to the given topic
+	canProduce, restErr := api.Hooks.Owl.CanProduceToTopic(r.Context(), topicName)
+	if restErr != nil {
+		return restErr
+	}
+	if !canProduce {
+		return &rest.Error{
+			Err:      fmt.Errorf("requester has no permissions to produce messages to the requested topic"),
+			Status:   http.StatusForbidden,
+			Message:  "You don't have permissions to produce messages to that topic",
+			IsSilent: false,
+		}
+	}
+
+	return nil
+}
+
+// produceErrorStatus returns the HTTP status code for errors which occurred while producing messages
+func produceErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, kafka.ErrTopicNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, kafka.ErrInvalidPartition), errors.Is(err, owl.ErrInvalidPayload),
+		errors.Is(err, kafka.ErrInvalidCompression):
+		return http.StatusBadRequest
+	case errors.Is(err, kafka.ErrShuttingDown):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,182 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/Shopify/sarama"
+	"github.com/cloudhut/common/rest"
+	"github.com/cloudhut/kowl/backend/pkg/kafka"
+	"github.com/cloudhut/kowl/backend/pkg/owl"
+	"github.com/gorilla/schema"
+)
+
+// getQuotasRequest filters the described quotas. An empty entity type matches all entity types.
+type getQuotasRequest struct {
+	EntityType string `schema:"entityType"`
+	Name       string `schema:"name"`
+}
+
+func (g *getQuotasRequest) OK() error {
+	if g.Name != "" && g.EntityType == "" {
+		return fmt.Errorf("entityType is required when filtering by name")
+	}
+
+	return nil
+}
+
+// quotaErrorStatus returns the HTTP status code for errors which are returned while describing or altering quotas
+func quotaErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, kafka.ErrClientQuotasNotSupported):
+		return http.StatusNotImplemented
+	case errors.Is(err, kafka.ErrInvalidQuota):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func (api *API) handleGetQuotas() http.HandlerFunc {
+	type response struct {
+		Quotas []owl.ClientQuota `json:"quotas"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Parse request from url parameters
+		decoder := schema.NewDecoder()
+		req := &getQuotasRequest{}
+		err := decoder.Decode(req, r.URL.Query())
+		if err != nil {
+			restErr := &rest.Error{
+				Err:      err,
+				Status:   http.StatusBadRequest,
+				Message:  "Failed to parse request parameters",
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, api.Logger, restErr)
+			return
+		}
+		err = req.OK()
+		if err != nil {
+			restErr := &rest.Error{
+				Err:      err,
+				Status:   http.StatusBadRequest,
+				Message:  fmt.Sprintf("Failed to validate request parameters: %v", err.Error()),
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, api.Logger, restErr)
+			return
+		}
+
+		// Check if logged in user is allowed to list quotas
+		isAllowed, restErr := api.Hooks.Owl.CanListQuotas(r.Context())
+		if restErr != nil {
+			rest.SendRESTError(w, r, api.Logger, restErr)
+			return
+		}
+		if !isAllowed {
+			rest.SendRESTError(w, r, api.Logger, &rest.Error{
+				Err:      fmt.Errorf("requester is not allowed to list quotas"),
+				Status:   http.StatusForbidden,
+				Message:  "You are not allowed to list quotas",
+				IsSilent: true,
+			})
+			return
+		}
+
+		quotas, err := api.owlSvc(r).DescribeClientQuotas(r.Context(), req.EntityType, req.Name)
+		if err != nil {
+			restErr := &rest.Error{
+				Err:      err,
+				Status:   quotaErrorStatus(err),
+				Message:  fmt.Sprintf("Could not describe quotas: %v", err.Error()),
+				IsSilent: errors.Is(err, kafka.ErrClientQuotasNotSupported),
+			}
+			rest.SendRESTError(w, r, api.Logger, restErr)
+			return
+		}
+
+		rest.SendResponse(w, r, api.Logger, http.StatusOK, response{Quotas: quotas})
+	}
+}
+
+type patchQuotasRequest struct {
+	Entity []owl.QuotaEntity `json:"entity"`
+
+	// Quotas maps quota keys (e. g. producer_byte_rate) to their new values. A null value removes the quota.
+	Quotas map[string]*float64 `json:"quotas"`
+}
+
+func (p *patchQuotasRequest) OK() error {
+	if len(p.Quotas) == 0 {
+		return fmt.Errorf("at least one quota must be given")
+	}
+
+	entity := make([]kafka.QuotaEntity, len(p.Entity))
+	for i, e := range p.Entity {
+		entity[i] = kafka.QuotaEntity{EntityType: sarama.QuotaEntityType(e.EntityType), Name: e.Name, Default: e.IsDefault}
+	}
+	return kafka.ValidateQuotaEntity(entity)
+}
+
+// handlePatchQuotas sets or removes the given quotas of a single entity. All other quotas of the entity remain
+// untouched.
+func (api *API) handlePatchQuotas() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !api.Cfg.EnableTopicOperations {
+			restErr := &rest.Error{
+				Err:      fmt.Errorf("topic operations are disabled"),
+				Status:   http.StatusForbidden,
+				Message:  "Operations are disabled, set 'enableTopicOperations' to true in order to alter quotas",
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, api.Logger, restErr)
+			return
+		}
+
+		// Check if logged in user is allowed to alter quotas
+		isAllowed, restErr := api.Hooks.Owl.CanAlterQuotas(r.Context())
+		if restErr != nil {
+			rest.SendRESTError(w, r, api.Logger, restErr)
+			return
+		}
+		if !isAllowed {
+			rest.SendRESTError(w, r, api.Logger, &rest.Error{
+				Err:      fmt.Errorf("requester is not allowed to alter quotas"),
+				Status:   http.StatusForbidden,
+				Message:  "You are not allowed to alter quotas",
+				IsSilent: false,
+			})
+			return
+		}
+
+		var req patchQuotasRequest
+		err := rest.Decode(r, &req)
+		if err != nil {
+			restErr := &rest.Error{
+				Err:      err,
+				Status:   http.StatusBadRequest,
+				Message:  fmt.Sprintf("Failed to parse request: %v", err.Error()),
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, api.Logger, restErr)
+			return
+		}
+
+		err = api.owlSvc(r).AlterClientQuotas(r.Context(), req.Entity, req.Quotas)
+		if err != nil {
+			restErr := &rest.Error{
+				Err:      err,
+				Status:   quotaErrorStatus(err),
+				Message:  fmt.Sprintf("Could not alter quotas: %v", err.Error()),
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, api.Logger, restErr)
+			return
+		}
+
+		rest.SendResponse(w, r, api.Logger, http.StatusOK, req)
+	}
+}
@@ -14,7 +14,13 @@ func (api *API) handleGetSchemaOverview() http.HandlerFunc {
 	}
 
 	return func(w http.ResponseWriter, r *http.Request) {
-		overview, err := api.OwlSvc.GetSchemaOverview(r.Context())
+		restErr := api.checkCanViewCluster(r)
+		if restErr != nil {
+			rest.SendRESTError(w, r, api.Logger, restErr)
+			return
+		}
+
+		overview, err := api.owlSvc(r).GetSchemaOverview(r.Context())
 		if err != nil {
 			if err == owl.ErrSchemaRegistryNotConfigured {
 				rest.SendResponse(w, r, api.Logger, http.StatusOK, &response{
@@ -47,9 +53,15 @@ func (api *API) handleGetSchemaDetails() http.HandlerFunc {
 	}
 
 	return func(w http.ResponseWriter, r *http.Request) {
+		restErr := api.checkCanViewCluster(r)
+		if restErr != nil {
+			rest.SendRESTError(w, r, api.Logger, restErr)
+			return
+		}
+
 		subject := chi.URLParam(r, "subject")
 		version := chi.URLParam(r, "version")
-		schemaDetails, err := api.OwlSvc.GetSchemaDetails(r.Context(), subject, version)
+		schemaDetails, err := api.owlSvc(r).GetSchemaDetails(r.Context(), subject, version)
 		if err != nil {
 			if err == owl.ErrSchemaRegistryNotConfigured {
 				rest.SendResponse(w, r, api.Logger, http.StatusOK, &response{
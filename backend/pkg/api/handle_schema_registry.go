@@ -0,0 +1,352 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/cloudhut/common/rest"
+	"github.com/cloudhut/kowl/backend/pkg/owl"
+	"github.com/cloudhut/kowl/backend/pkg/schema"
+	"github.com/go-chi/chi"
+	"go.uber.org/zap"
+)
+
+// parseDeletedParam parses the ?deleted=true query parameter, which includes soft deleted subjects and versions
+func parseDeletedParam(r *http.Request) (bool, *rest.Error) {
+	value := r.URL.Query().Get("deleted")
+	if value == "" {
+		return false, nil
+	}
+	deleted, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, &rest.Error{
+			Err:      fmt.Errorf("failed to parse deleted query param: %w", err),
+			Status:   http.StatusBadRequest,
+			Message:  "deleted must be either true or false",
+			IsSilent: false,
+		}
+	}
+	return deleted, nil
+}
+
+// schemaRegistryErrorStatus returns the HTTP status code for errors which are returned while browsing the schema
+// registry
+func schemaRegistryErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, owl.ErrInvalidSchemaVersion), errors.Is(err, owl.ErrInvalidCompatibilityLevel),
+		schema.IsInvalidInput(err):
+		return http.StatusBadRequest
+	case schema.IsNotFound(err):
+		return http.StatusNotFound
+	case errors.Is(err, owl.ErrSchemaRegistryNotConfigured):
+		return http.StatusNotImplemented
+	default:
+		return http.StatusServiceUnavailable
+	}
+}
+
+func (api *API) handleGetSchemaRegistrySubjects() http.HandlerFunc {
+	type response struct {
+		Subjects     []owl.SchemaRegistrySubject `json:"subjects"`
+		IsConfigured bool                        `json:"isConfigured"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		restErr := api.checkCanViewCluster(r)
+		if restErr != nil {
+			rest.SendRESTError(w, r, api.Logger, restErr)
+			return
+		}
+
+		deleted, restErr := parseDeletedParam(r)
+		if restErr != nil {
+			rest.SendRESTError(w, r, api.Logger, restErr)
+			return
+		}
+
+		subjects, err := api.owlSvc(r).ListSchemaRegistrySubjects(r.Context(), deleted)
+		if err != nil {
+			if err == owl.ErrSchemaRegistryNotConfigured {
+				rest.SendResponse(w, r, api.Logger, http.StatusOK, &response{
+					Subjects:     nil,
+					IsConfigured: false,
+				})
+				return
+			}
+
+			rest.SendRESTError(w, r, api.Logger, &rest.Error{
+				Err:      err,
+				Status:   schemaRegistryErrorStatus(err),
+				Message:  fmt.Sprintf("Could not list schema registry subjects: %v", err.Error()),
+				IsSilent: false,
+			})
+			return
+		}
+
+		rest.SendResponse(w, r, api.Logger, http.StatusOK, &response{
+			Subjects:     subjects,
+			IsConfigured: true,
+		})
+	}
+}
+
+func (api *API) handleGetSchemaRegistrySubjectVersions() http.HandlerFunc {
+	type response struct {
+		Subject      string                             `json:"subject"`
+		Versions     []owl.SchemaRegistrySubjectVersion `json:"versions"`
+		IsConfigured bool                               `json:"isConfigured"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		restErr := api.checkCanViewCluster(r)
+		if restErr != nil {
+			rest.SendRESTError(w, r, api.Logger, restErr)
+			return
+		}
+
+		subject := chi.URLParam(r, "subject")
+		deleted, restErr := parseDeletedParam(r)
+		if restErr != nil {
+			rest.SendRESTError(w, r, api.Logger, restErr)
+			return
+		}
+
+		versions, err := api.owlSvc(r).ListSchemaRegistrySubjectVersions(r.Context(), subject, deleted)
+		if err != nil {
+			if err == owl.ErrSchemaRegistryNotConfigured {
+				rest.SendResponse(w, r, api.Logger, http.StatusOK, &response{
+					Subject:      subject,
+					Versions:     nil,
+					IsConfigured: false,
+				})
+				return
+			}
+
+			rest.SendRESTError(w, r, api.Logger, &rest.Error{
+				Err:      err,
+				Status:   schemaRegistryErrorStatus(err),
+				Message:  fmt.Sprintf("Could not list versions of subject: %v", err.Error()),
+				IsSilent: false,
+			})
+			return
+		}
+
+		rest.SendResponse(w, r, api.Logger, http.StatusOK, &response{
+			Subject:      subject,
+			Versions:     versions,
+			IsConfigured: true,
+		})
+	}
+}
+
+// handleGetSchemaRegistrySchema returns a single version of a subject, the version is either a number or "latest"
+func (api *API) handleGetSchemaRegistrySchema() http.HandlerFunc {
+	type response struct {
+		Schema       *owl.SchemaRegistrySchema `json:"schema"`
+		IsConfigured bool                      `json:"isConfigured"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		restErr := api.checkCanViewCluster(r)
+		if restErr != nil {
+			rest.SendRESTError(w, r, api.Logger, restErr)
+			return
+		}
+
+		subject := chi.URLParam(r, "subject")
+		version := chi.URLParam(r, "version")
+		deleted, restErr := parseDeletedParam(r)
+		if restErr != nil {
+			rest.SendRESTError(w, r, api.Logger, restErr)
+			return
+		}
+
+		res, err := api.owlSvc(r).GetSchemaRegistrySchema(r.Context(), subject, version, deleted)
+		if err != nil {
+			if err == owl.ErrSchemaRegistryNotConfigured {
+				rest.SendResponse(w, r, api.Logger, http.StatusOK, &response{
+					Schema:       nil,
+					IsConfigured: false,
+				})
+				return
+			}
+
+			rest.SendRESTError(w, r, api.Logger, &rest.Error{
+				Err:      err,
+				Status:   schemaRegistryErrorStatus(err),
+				Message:  fmt.Sprintf("Could not get schema: %v", err.Error()),
+				IsSilent: false,
+			})
+			return
+		}
+
+		rest.SendResponse(w, r, api.Logger, http.StatusOK, &response{
+			Schema:       res,
+			IsConfigured: true,
+		})
+	}
+}
+
+type checkSchemaCompatibilityRequest struct {
+	Schema     string                   `json:"schema"`
+	SchemaType string                   `json:"schemaType"` // AVRO (default), PROTOBUF or JSON
+	References []schema.SchemaReference `json:"references"`
+}
+
+func (c *checkSchemaCompatibilityRequest) OK() error {
+	if c.Schema == "" {
+		return fmt.Errorf("schema must be set")
+	}
+	switch c.SchemaType {
+	case "", schema.SchemaTypeAvro, schema.SchemaTypeProtobuf, schema.SchemaTypeJSON:
+	default:
+		return fmt.Errorf("schemaType '%v' is invalid, it must be one of: %v, %v, %v", c.SchemaType,
+			schema.SchemaTypeAvro, schema.SchemaTypeProtobuf, schema.SchemaTypeJSON)
+	}
+	return nil
+}
+
+// handleCheckSchemaCompatibility checks whether the candidate schema in the request body is compatible with the
+// latest version of the subject
+func (api *API) handleCheckSchemaCompatibility() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		restErr := api.checkCanViewCluster(r)
+		if restErr != nil {
+			rest.SendRESTError(w, r, api.Logger, restErr)
+			return
+		}
+
+		subject := chi.URLParam(r, "subject")
+
+		var req checkSchemaCompatibilityRequest
+		err := rest.Decode(r, &req)
+		if err != nil {
+			rest.SendRESTError(w, r, api.Logger, &rest.Error{
+				Err:      err,
+				Status:   http.StatusBadRequest,
+				Message:  fmt.Sprintf("Failed to parse request: %v", err.Error()),
+				IsSilent: false,
+			})
+			return
+		}
+		// The registry assumes Avro if the schema type is not set, hence Avro is not sent for older registries
+		schemaType := req.SchemaType
+		if schemaType == schema.SchemaTypeAvro {
+			schemaType = ""
+		}
+
+		res, err := api.owlSvc(r).CheckSchemaCompatibility(r.Context(), subject, schema.CompatibilityCheckRequest{
+			Schema:     req.Schema,
+			SchemaType: schemaType,
+			References: req.References,
+		})
+		if err != nil {
+			rest.SendRESTError(w, r, api.Logger, &rest.Error{
+				Err:      err,
+				Status:   schemaRegistryErrorStatus(err),
+				Message:  fmt.Sprintf("Could not check schema compatibility: %v", err.Error()),
+				IsSilent: false,
+			})
+			return
+		}
+
+		rest.SendResponse(w, r, api.Logger, http.StatusOK, res)
+	}
+}
+
+func (api *API) handleGetSubjectCompatibility() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		restErr := api.checkCanViewCluster(r)
+		if restErr != nil {
+			rest.SendRESTError(w, r, api.Logger, restErr)
+			return
+		}
+
+		subject := chi.URLParam(r, "subject")
+
+		res, err := api.owlSvc(r).GetSubjectCompatibility(r.Context(), subject)
+		if err != nil {
+			rest.SendRESTError(w, r, api.Logger, &rest.Error{
+				Err:      err,
+				Status:   schemaRegistryErrorStatus(err),
+				Message:  fmt.Sprintf("Could not get subject compatibility level: %v", err.Error()),
+				IsSilent: false,
+			})
+			return
+		}
+
+		rest.SendResponse(w, r, api.Logger, http.StatusOK, res)
+	}
+}
+
+type setSubjectCompatibilityRequest struct {
+	// CompatibilityLevel is the new compatibility level, DEFAULT resets the subject to the global compatibility level
+	CompatibilityLevel string `json:"compatibilityLevel"`
+}
+
+func (s *setSubjectCompatibilityRequest) OK() error {
+	if s.CompatibilityLevel == "" {
+		return fmt.Errorf("compatibilityLevel must be set")
+	}
+	return nil
+}
+
+// handleSetSubjectCompatibility sets the compatibility level of a subject, if operations are enabled
+func (api *API) handleSetSubjectCompatibility() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		subject := chi.URLParam(r, "subject")
+		logger := api.Logger.With(zap.String("subject", subject))
+
+		if !api.Cfg.EnableTopicOperations {
+			rest.SendRESTError(w, r, logger, &rest.Error{
+				Err:      fmt.Errorf("topic operations are disabled"),
+				Status:   http.StatusForbidden,
+				Message:  "Operations are disabled, set 'enableTopicOperations' to true in order to change compatibility levels",
+				IsSilent: false,
+			})
+			return
+		}
+		canEdit, restErr := api.Hooks.Owl.CanEditSchemaSubject(r.Context(), subject)
+		if restErr != nil {
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+		if !canEdit {
+			rest.SendRESTError(w, r, logger, &rest.Error{
+				Err:      fmt.Errorf("requester has no permissions to edit the requested subject"),
+				Status:   http.StatusForbidden,
+				Message:  "You don't have permissions to edit that subject",
+				IsSilent: false,
+			})
+			return
+		}
+
+		var req setSubjectCompatibilityRequest
+		err := rest.Decode(r, &req)
+		if err != nil {
+			rest.SendRESTError(w, r, logger, &rest.Error{
+				Err:      err,
+				Status:   http.StatusBadRequest,
+				Message:  fmt.Sprintf("Failed to parse request: %v", err.Error()),
+				IsSilent: false,
+			})
+			return
+		}
+
+		res, err := api.owlSvc(r).SetSubjectCompatibility(r.Context(), subject, req.CompatibilityLevel)
+		if err != nil {
+			rest.SendRESTError(w, r, logger, &rest.Error{
+				Err:      err,
+				Status:   schemaRegistryErrorStatus(err),
+				Message:  fmt.Sprintf("Could not set subject compatibility level: %v", err.Error()),
+				IsSilent: false,
+			})
+			return
+		}
+		logger.Info("changed subject compatibility level", zap.String("compatibility_level", res.CompatibilityLevel))
+
+		rest.SendResponse(w, r, logger, http.StatusOK, res)
+	}
+}
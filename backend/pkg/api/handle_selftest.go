@@ -0,0 +1,96 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/cloudhut/common/rest"
+	"go.uber.org/zap"
+)
+
+// handleSelfTest produces a message to the configured test topic (or a temporary topic) and consumes it back, so that
+// the connectivity and the permissions of Kowl can be verified end to end. The response contains the timings of each
+// phase, if a phase has failed it is returned with status 503.
+//
+// It is served at /api/admin/self-test rather than /admin/self-test, because the routes below /admin are private
+// routes without authentication, RBAC and audit log, which must not be able to produce messages or create topics.
+func (api *API) handleSelfTest() http.HandlerFunc {
+	type phase struct {
+		Name       string  `json:"name"`
+		DurationMs float64 `json:"durationMs"`
+		Error      string  `json:"error,omitempty"`
+	}
+	type response struct {
+		Success            bool    `json:"success"`
+		TopicName          string  `json:"topicName"`
+		IsTemporaryTopic   bool    `json:"isTemporaryTopic"`
+		Phases             []phase `json:"phases"`
+		RoundTripLatencyMs float64 `json:"roundTripLatencyMs"`
+	}
+	toMs := func(d time.Duration) float64 {
+		return float64(d) / float64(time.Millisecond)
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !api.Cfg.EnableTopicOperations {
+			restErr := &rest.Error{
+				Err:      fmt.Errorf("topic operations are disabled"),
+				Status:   http.StatusForbidden,
+				Message:  "Topic operations are disabled, set 'enableTopicOperations' to true in order to run the self test",
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, api.Logger, restErr)
+			return
+		}
+
+		canRun, restErr := api.Hooks.Owl.CanRunSelfTest(r.Context())
+		if restErr != nil {
+			rest.SendRESTError(w, r, api.Logger, restErr)
+			return
+		}
+		if !canRun {
+			restErr := &rest.Error{
+				Err:      fmt.Errorf("requester has no permissions to run the self test"),
+				Status:   http.StatusForbidden,
+				Message:  "You don't have permissions to run the self test",
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, api.Logger, restErr)
+			return
+		}
+
+		res, err := api.kafkaSvc(r).RunSelfTest(r.Context())
+		if err != nil {
+			restErr := &rest.Error{
+				Err:      err,
+				Status:   produceErrorStatus(err),
+				Message:  fmt.Sprintf("Could not run the self test: %v", err.Error()),
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, api.Logger, restErr)
+			return
+		}
+
+		phases := make([]phase, len(res.Phases))
+		for i, p := range res.Phases {
+			phases[i] = phase{Name: p.Name, DurationMs: toMs(p.Duration)}
+			if p.Err != nil {
+				phases[i].Error = p.Err.Error()
+			}
+		}
+		status := http.StatusOK
+		if !res.Succeeded() {
+			status = http.StatusServiceUnavailable
+			api.Logger.Warn("self test has failed", zap.String("topic_name", res.TopicName), zap.Any("phases", phases))
+		}
+
+		rest.SendResponse(w, r, api.Logger, status, &response{
+			Success:            res.Succeeded(),
+			TopicName:          res.TopicName,
+			IsTemporaryTopic:   res.IsTemporaryTopic,
+			Phases:             phases,
+			RoundTripLatencyMs: toMs(res.RoundTripLatency),
+		})
+	}
+}
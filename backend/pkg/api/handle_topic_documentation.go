@@ -1,11 +1,13 @@
 package api
 
 import (
+	"fmt"
+	"net/http"
+
 	"github.com/cloudhut/common/rest"
 	"github.com/cloudhut/kowl/backend/pkg/owl"
 	"github.com/go-chi/chi"
 	"go.uber.org/zap"
-	"net/http"
 )
 
 // handleGetTopicDocumentation returns the respective topic documentation from the git repository
@@ -19,7 +21,23 @@ func (api *API) handleGetTopicDocumentation() http.HandlerFunc {
 		topicName := chi.URLParam(r, "topicName")
 		logger := api.Logger.With(zap.String("topic_name", topicName))
 
-		doc, err := api.OwlSvc.GetTopicDocumentation(topicName)
+		canSee, restErr := api.Hooks.Owl.CanSeeTopic(r.Context(), topicName)
+		if restErr != nil {
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+		if !canSee {
+			restErr := &rest.Error{
+				Err:      fmt.Errorf("requester has no permissions to view the documentation of the requested topic"),
+				Status:   http.StatusForbidden,
+				Message:  "You don't have permissions to view the documentation of that topic",
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+
+		doc, err := api.owlSvc(r).GetTopicDocumentation(topicName)
 		if err != nil {
 			restErr := &rest.Error{
 				Err:      err,
@@ -3,12 +3,19 @@ package api
 import (
 	"context"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/cloudhut/kowl/backend/pkg/kafka"
 	"github.com/cloudhut/kowl/backend/pkg/owl"
+	"github.com/go-chi/chi"
+	"go.uber.org/zap"
 
 	"github.com/cloudhut/common/rest"
 )
@@ -24,8 +31,21 @@ type ListMessagesRequest struct {
 	TopicName             string `json:"topicName"`
 	StartOffset           int64  `json:"startOffset"` // -1 for recent (newest - results), -2 for oldest offset, -3 for newest
 	PartitionID           int32  `json:"partitionId"` // -1 for all partition ids
+	Partitions            string `json:"partitions"`  // Comma separated partition ids or "all", takes precedence over partitionId
 	MaxResults            uint16 `json:"maxResults"`
 	FilterInterpreterCode string `json:"filterInterpreterCode"` // Base64 encoded code
+
+	// FilterHeaderKey only returns messages which have a header with this key. If FilterHeaderValue is set as well,
+	// the header's raw value must be equal to it.
+	FilterHeaderKey   string  `json:"filterHeaderKey"`
+	FilterHeaderValue *string `json:"filterHeaderValue"`
+
+	// DeserializeHeaders decodes the header values like keys and values, defaults to true
+	DeserializeHeaders *bool `json:"deserializeHeaders"`
+
+	// MaxDurationMs bounds the total consumption time, the configured default is used if zero. Once it has been
+	// exceeded, the request completes with the messages found so far and the offsets reached per partition.
+	MaxDurationMs int64 `json:"maxDurationMs"`
 }
 
 func (l *ListMessagesRequest) OK() error {
@@ -41,6 +61,10 @@ func (l *ListMessagesRequest) OK() error {
 		return fmt.Errorf("partitionID is smaller than -1")
 	}
 
+	if _, err := parsePartitionIDs(l.Partitions); err != nil {
+		return err
+	}
+
 	if l.MaxResults <= 0 || l.MaxResults > 500 {
 		return fmt.Errorf("max results must be between 1 and 500")
 	}
@@ -49,9 +73,27 @@ func (l *ListMessagesRequest) OK() error {
 		return fmt.Errorf("failed to decode interpreter code %w", err)
 	}
 
+	if l.FilterHeaderValue != nil && l.FilterHeaderKey == "" {
+		return fmt.Errorf("filterHeaderValue requires a filterHeaderKey")
+	}
+
+	if l.MaxDurationMs < 0 {
+		return fmt.Errorf("maxDurationMs must not be negative")
+	}
+
 	return nil
 }
 
+// HasFilter returns true if the request uses any of the message filters
+func (l *ListMessagesRequest) HasFilter() bool {
+	return len(l.FilterInterpreterCode) > 0 || l.FilterHeaderKey != ""
+}
+
+// isEnabledOrDefault returns the value of an optional boolean request parameter, which defaults to true
+func isEnabledOrDefault(b *bool) bool {
+	return b == nil || *b
+}
+
 func (l *ListMessagesRequest) DecodeInterpreterCode() (string, error) {
 	code, err := base64.StdEncoding.DecodeString(l.FilterInterpreterCode)
 	if err != nil {
@@ -62,9 +104,31 @@ func (l *ListMessagesRequest) DecodeInterpreterCode() (string, error) {
 }
 
 func (api *API) handleGetMessages() http.HandlerFunc {
+	handleGetNewestMessages := api.handleGetNewestMessages()
+	handleGetMessagesFromTimestamp := api.handleGetMessagesFromTimestamp()
+	handleGetCompactLatestMessages := api.handleGetCompactLatestMessages()
+	handleGetOffsetRangeMessages := api.handleGetOffsetRangeMessages()
+
 	return func(w http.ResponseWriter, r *http.Request) {
 		logger := api.Logger
 
+		// Plain HTTP requests which fetch the newest messages, messages from a timestamp, the latest value per key or an
+		// offset range do not require a websocket connection
+		switch r.URL.Query().Get("mode") {
+		case "newest":
+			handleGetNewestMessages(w, r)
+			return
+		case "timestamp":
+			handleGetMessagesFromTimestamp(w, r)
+			return
+		case "compact-latest":
+			handleGetCompactLatestMessages(w, r)
+			return
+		case "range":
+			handleGetOffsetRangeMessages(w, r)
+			return
+		}
+
 		ctx, cancel := context.WithCancel(r.Context())
 		defer cancel()
 
@@ -106,6 +170,12 @@ func (api *API) handleGetMessages() http.HandlerFunc {
 			return
 		}
 
+		// The topic of the request may differ from the topic in the url, which has been checked by the middleware
+		if !api.topicFilter.isAllowed(req.TopicName) {
+			sendError("This topic is not served by this Kowl instance")
+			return
+		}
+
 		// Check if logged in user is allowed to list messages for the given request
 		canViewMessages, restErr := api.Hooks.Owl.CanViewTopicMessages(r.Context(), req.TopicName)
 		if restErr != nil {
@@ -117,7 +187,7 @@ func (api *API) handleGetMessages() http.HandlerFunc {
 			return
 		}
 
-		if len(req.FilterInterpreterCode) > 0 {
+		if req.HasFilter() {
 			canUseMessageSearchFilters, restErr := api.Hooks.Owl.CanUseMessageSearchFilters(r.Context(), req.TopicName)
 			if restErr != nil {
 				sendError(restErr.Message)
@@ -130,23 +200,35 @@ func (api *API) handleGetMessages() http.HandlerFunc {
 		}
 
 		interpreterCode, _ := req.DecodeInterpreterCode() // Error has been checked in validation function
+		partitionIDs, _ := parsePartitionIDs(req.Partitions)
+		maxDuration, err := api.Cfg.MessageSearch.maxDuration(time.Duration(req.MaxDurationMs) * time.Millisecond)
+		if err != nil {
+			sendError(fmt.Sprintf("Failed to validate list message request: %v", err))
+			return
+		}
 
 		// Request messages from kafka and return them once we got all the messages or the context is done
 		listReq := owl.ListMessageRequest{
 			TopicName:             req.TopicName,
 			PartitionID:           req.PartitionID,
+			PartitionIDs:          partitionIDs,
 			StartOffset:           req.StartOffset,
 			MessageCount:          req.MaxResults,
 			FilterInterpreterCode: interpreterCode,
+			HeaderFilter:          kafka.HeaderFilter{Key: req.FilterHeaderKey, Value: req.FilterHeaderValue},
+			DeserializeHeaders:    isEnabledOrDefault(req.DeserializeHeaders),
+			MaxDuration:           maxDuration,
 		}
 		api.Hooks.Owl.PrintListMessagesAuditLog(r, &listReq)
 
-		// Use 30min duration if we want to search a whole topic or forward messages as they arrive
-		duration := 18 * time.Second
-		if listReq.FilterInterpreterCode != "" || listReq.StartOffset == owl.StartOffsetNewest {
-			duration = 30 * time.Minute
+		// Requests which neither search a whole topic nor forward messages as they arrive should complete quickly
+		isSearch := listReq.FilterInterpreterCode != "" || !listReq.HeaderFilter.IsEmpty() || listReq.StartOffset == owl.StartOffsetNewest
+		if !isSearch && listReq.MaxDuration > listMessagesMaxDuration {
+			listReq.MaxDuration = listMessagesMaxDuration
 		}
-		childCtx, cancel := context.WithTimeout(ctx, duration)
+		// The budget completes the request with a partial result, the timeout is only hit if that's not possible
+		// (e.g. because the partition consumers could not be created in time)
+		childCtx, cancel := context.WithTimeout(ctx, listReq.MaxDuration+listMessagesGracePeriod)
 		defer cancel()
 
 		progress := &progressReporter{
@@ -160,9 +242,628 @@ func (api *API) handleGetMessages() http.HandlerFunc {
 		}
 		progress.Start()
 
-		err = api.OwlSvc.ListMessages(childCtx, listReq, progress)
+		err = api.owlSvc(r).ListMessages(childCtx, listReq, progress)
 		if err != nil {
 			progress.OnError(err.Error())
 		}
+		progress.statsMutex.RLock()
+		api.chargeConsumedBytes(r, progress.bytesConsumed)
+		progress.statsMutex.RUnlock()
+	}
+}
+
+// TailMessagesRequest is the first message a client sends on the live tail websocket connection
+type TailMessagesRequest struct {
+	Partitions           string `json:"partitions"` // Comma separated partition ids or "all"
+	MaxMessagesPerSecond int    `json:"maxMessagesPerSecond"`
+	DeserializeHeaders   *bool  `json:"deserializeHeaders"` // Defaults to true
+}
+
+func (t *TailMessagesRequest) OK() error {
+	if _, err := parsePartitionIDs(t.Partitions); err != nil {
+		return err
+	}
+
+	if t.MaxMessagesPerSecond < 0 {
+		return fmt.Errorf("maxMessagesPerSecond must not be negative")
+	}
+
+	return nil
+}
+
+// listMessagesMaxDuration is the max duration of requests which neither use a filter nor consume the newest messages
+const listMessagesMaxDuration = 18 * time.Second
+
+// listMessagesGracePeriod is the time a request may take in addition to its budget, before it's cancelled
+const listMessagesGracePeriod = 10 * time.Second
+
+// handleTailMessages streams new messages of a topic via websocket, starting at the high watermark, until the client
+// disconnects
+func (api *API) handleTailMessages() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		topicName := chi.URLParam(r, "topicName")
+		logger := api.Logger.With(zap.String("topic_name", topicName))
+
+		ctx, cancel := context.WithCancel(r.Context())
+		defer cancel()
+
+		wsClient := websocketClient{
+			Ctx:        ctx,
+			Cancel:     cancel,
+			Logger:     logger,
+			Connection: nil,
+			Mutex:      &sync.RWMutex{},
+		}
+		restErr := wsClient.upgrade(w, r)
+		if restErr != nil {
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+		defer wsClient.sendClose()
+		progress := &tailProgressReporter{websocket: &wsClient}
+
+		var req TailMessagesRequest
+		err := wsClient.readJSON(&req)
+		if err != nil {
+			progress.OnError("Failed to parse live tail request")
+			return
+		}
+		// The read loop cancels the context when the client disconnects or stops answering pings
+		go wsClient.readLoop()
+		go wsClient.producePings()
+
+		err = req.OK()
+		if err != nil {
+			progress.OnError(fmt.Sprintf("Failed to validate live tail request: %v", err))
+			return
+		}
+
+		restErr = api.checkCanViewTopicMessages(r, topicName)
+		if restErr != nil {
+			progress.OnError(restErr.Message)
+			return
+		}
+
+		partitionIDs, _ := parsePartitionIDs(req.Partitions) // Error has been checked in validation function
+		tailReq := kafka.TailMessagesRequest{
+			TopicName:            topicName,
+			PartitionIDs:         partitionIDs,
+			MaxMessagesPerSecond: req.MaxMessagesPerSecond,
+			DeserializeHeaders:   isEnabledOrDefault(req.DeserializeHeaders),
+		}
+		err = api.owlSvc(r).TailMessages(ctx, tailReq, progress)
+		api.chargeConsumedBytes(r, atomic.LoadInt64(&progress.bytesConsumed))
+		if err != nil {
+			logger.Debug("live tail has been stopped", zap.Error(err))
+			progress.OnError(err.Error())
+		}
+	}
+}
+
+// handleGetNewestMessages returns the newest messages across all partitions of a topic (?mode=newest&count=50)
+func (api *API) handleGetNewestMessages() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		topicName := chi.URLParam(r, "topicName")
+		logger := api.Logger.With(zap.String("topic_name", topicName))
+
+		count, restErr := parseMessageCount(r)
+		if restErr != nil {
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+
+		partitionIDs, restErr := parsePartitionsQuery(r)
+		if restErr != nil {
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+
+		restErr = api.checkCanViewTopicMessages(r, topicName)
+		if restErr != nil {
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+
+		// Messages at the end of compacted partitions may not exist, hence we return what we have after a while
+		ctx, cancel := context.WithTimeout(r.Context(), 18*time.Second)
+		defer cancel()
+
+		res, err := api.owlSvc(r).ListNewestMessages(ctx, topicName, partitionIDs, count)
+		if err != nil {
+			restErr := &rest.Error{
+				Err:      err,
+				Status:   consumeErrorStatus(err),
+				Message:  fmt.Sprintf("Could not list newest messages: %v", err.Error()),
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+		api.chargeConsumedBytes(r, res.ConsumedBytes)
+
+		rest.SendResponse(w, r, logger, http.StatusOK, res)
+	}
+}
+
+// handleGetCompactLatestMessages returns the latest message per key within the newest messages of each partition
+// (?mode=compact-latest&window=1000), as they would be retained once the topic has been compacted
+func (api *API) handleGetCompactLatestMessages() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		topicName := chi.URLParam(r, "topicName")
+		logger := api.Logger.With(zap.String("topic_name", topicName))
+
+		window, restErr := parseCompactLatestWindow(r)
+		if restErr != nil {
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+
+		partitionIDs, restErr := parsePartitionsQuery(r)
+		if restErr != nil {
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+
+		restErr = api.checkCanViewTopicMessages(r, topicName)
+		if restErr != nil {
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 18*time.Second)
+		defer cancel()
+
+		res, err := api.owlSvc(r).ListCompactLatestMessages(ctx, topicName, partitionIDs, window)
+		if err != nil {
+			restErr := &rest.Error{
+				Err:      err,
+				Status:   consumeErrorStatus(err),
+				Message:  fmt.Sprintf("Could not list latest messages per key: %v", err.Error()),
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+		api.chargeConsumedBytes(r, res.ConsumedBytes)
+
+		rest.SendResponse(w, r, logger, http.StatusOK, res)
+	}
+}
+
+// handleGetMessagesFromTimestamp returns the messages which have been produced at or after the given RFC3339
+// timestamp across all partitions of a topic (?mode=timestamp&timestamp=2020-11-20T14:32:00Z&count=50)
+func (api *API) handleGetMessagesFromTimestamp() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		topicName := chi.URLParam(r, "topicName")
+		logger := api.Logger.With(zap.String("topic_name", topicName))
+
+		timestampStr := r.URL.Query().Get("timestamp")
+		timestamp, err := time.Parse(time.RFC3339, timestampStr)
+		if err != nil {
+			restErr := &rest.Error{
+				Err:      fmt.Errorf("invalid timestamp '%v': %w", timestampStr, err),
+				Status:   http.StatusBadRequest,
+				Message:  "Timestamp must be given in RFC3339 format, e.g. 2020-11-20T14:32:00Z",
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+
+		count, restErr := parseMessageCount(r)
+		if restErr != nil {
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+
+		partitionIDs, restErr := parsePartitionsQuery(r)
+		if restErr != nil {
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+
+		restErr = api.checkCanViewTopicMessages(r, topicName)
+		if restErr != nil {
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 18*time.Second)
+		defer cancel()
+
+		res, err := api.owlSvc(r).ListMessagesFromTimestamp(ctx, topicName, partitionIDs, timestamp, count)
+		if err != nil {
+			restErr := &rest.Error{
+				Err:      err,
+				Status:   consumeErrorStatus(err),
+				Message:  fmt.Sprintf("Could not list messages from timestamp: %v", err.Error()),
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+		api.chargeConsumedBytes(r, res.ConsumedBytes)
+
+		rest.SendResponse(w, r, logger, http.StatusOK, res)
+	}
+}
+
+// handleGetOffsetRangeMessages returns all messages within an offset range of the given partitions
+// (?mode=range&partitions=2&startOffset=10000&endOffset=10100&endInclusive=true). The end offset is exclusive unless
+// endInclusive is set. End offsets beyond the high watermark are clamped, which is flagged per partition. The messages
+// contain the attributes of their record batch, control records are only returned if includeControlRecords is set.
+func (api *API) handleGetOffsetRangeMessages() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		topicName := chi.URLParam(r, "topicName")
+		logger := api.Logger.With(zap.String("topic_name", topicName))
+
+		partitionIDs, restErr := parsePartitionsQuery(r)
+		if restErr != nil {
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+		if len(partitionIDs) == 0 {
+			restErr := &rest.Error{
+				Err:      fmt.Errorf("no partitions have been given for the offset range"),
+				Status:   http.StatusBadRequest,
+				Message:  "The partitions of the offset range must be given, e.g. partitions=2",
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+
+		startOffset, endOffset, restErr := parseOffsetRange(r)
+		if restErr != nil {
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+
+		includeControlRecords := false
+		if includeStr := r.URL.Query().Get("includeControlRecords"); includeStr != "" {
+			var err error
+			includeControlRecords, err = strconv.ParseBool(includeStr)
+			if err != nil {
+				restErr := &rest.Error{
+					Err:      fmt.Errorf("invalid includeControlRecords '%v'", includeStr),
+					Status:   http.StatusBadRequest,
+					Message:  "IncludeControlRecords must be true or false",
+					IsSilent: false,
+				}
+				rest.SendRESTError(w, r, logger, restErr)
+				return
+			}
+		}
+
+		restErr = api.checkCanViewTopicMessages(r, topicName)
+		if restErr != nil {
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+
+		ranges := make([]kafka.OffsetRange, len(partitionIDs))
+		for i, partitionID := range partitionIDs {
+			ranges[i] = kafka.OffsetRange{PartitionID: partitionID, StartOffset: startOffset, EndOffset: endOffset}
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 18*time.Second)
+		defer cancel()
+
+		res, err := api.owlSvc(r).ListMessagesInOffsetRange(ctx, topicName, ranges, includeControlRecords)
+		if err != nil {
+			restErr := &rest.Error{
+				Err:      err,
+				Status:   consumeErrorStatus(err),
+				Message:  fmt.Sprintf("Could not list messages in offset range: %v", err.Error()),
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+		api.chargeConsumedBytes(r, res.ConsumedBytes)
+
+		rest.SendResponse(w, r, logger, http.StatusOK, res)
+	}
+}
+
+// maxOffsetRangeSize is the max number of offsets per partition which can be requested in a single offset range
+const maxOffsetRangeSize = 500
+
+// parseOffsetRange parses the startOffset, endOffset and endInclusive query parameters and returns the range with an
+// exclusive end offset
+func parseOffsetRange(r *http.Request) (int64, int64, *rest.Error) {
+	query := r.URL.Query()
+	invalidRange := func(err error, message string) (int64, int64, *rest.Error) {
+		return 0, 0, &rest.Error{
+			Err:      err,
+			Status:   http.StatusBadRequest,
+			Message:  message,
+			IsSilent: false,
+		}
+	}
+
+	startOffset, err := strconv.ParseInt(query.Get("startOffset"), 10, 64)
+	if err != nil || startOffset < 0 {
+		return invalidRange(fmt.Errorf("invalid start offset '%v'", query.Get("startOffset")),
+			"Start offset must be a number which is not negative")
+	}
+	endOffset, err := strconv.ParseInt(query.Get("endOffset"), 10, 64)
+	if err != nil || endOffset < 0 {
+		return invalidRange(fmt.Errorf("invalid end offset '%v'", query.Get("endOffset")),
+			"End offset must be a number which is not negative")
+	}
+	if endInclusiveStr := query.Get("endInclusive"); endInclusiveStr != "" {
+		endInclusive, err := strconv.ParseBool(endInclusiveStr)
+		if err != nil {
+			return invalidRange(fmt.Errorf("invalid endInclusive '%v'", endInclusiveStr), "EndInclusive must be true or false")
+		}
+		if endInclusive {
+			endOffset++
+		}
+	}
+
+	if endOffset < startOffset {
+		return invalidRange(fmt.Errorf("end offset %v is before start offset %v", endOffset, startOffset),
+			"End offset must not be before the start offset")
+	}
+	if endOffset-startOffset > maxOffsetRangeSize {
+		return invalidRange(fmt.Errorf("offset range of %v offsets exceeds the limit", endOffset-startOffset),
+			fmt.Sprintf("The offset range may contain at most %v offsets per partition", maxOffsetRangeSize))
+	}
+
+	return startOffset, endOffset, nil
+}
+
+// parseMessageCount parses the optional count query parameter, which defaults to 50 messages
+func parseMessageCount(r *http.Request) (int64, *rest.Error) {
+	countStr := r.URL.Query().Get("count")
+	if countStr == "" {
+		return 50, nil
+	}
+
+	count, err := strconv.ParseInt(countStr, 10, 64)
+	if err != nil || count <= 0 || count > 500 {
+		return 0, &rest.Error{
+			Err:      fmt.Errorf("invalid count '%v'", countStr),
+			Status:   http.StatusBadRequest,
+			Message:  "Count must be a number between 1 and 500",
+			IsSilent: false,
+		}
+	}
+
+	return count, nil
+}
+
+// parseCompactLatestWindow parses the number of newest messages per partition which are compacted to the latest
+// value per key
+func parseCompactLatestWindow(r *http.Request) (int64, *rest.Error) {
+	windowStr := r.URL.Query().Get("window")
+	if windowStr == "" {
+		return 1000, nil
+	}
+
+	window, err := strconv.ParseInt(windowStr, 10, 64)
+	if err != nil || window <= 0 || window > 100000 {
+		return 0, &rest.Error{
+			Err:      fmt.Errorf("invalid window '%v'", windowStr),
+			Status:   http.StatusBadRequest,
+			Message:  "Window must be a number between 1 and 100000",
+			IsSilent: false,
+		}
+	}
+
+	return window, nil
+}
+
+// parsePartitionIDs parses a comma separated list of partition ids. An empty string or "all" selects all partitions
+// and returns nil.
+func parsePartitionIDs(partitions string) ([]int32, error) {
+	partitions = strings.TrimSpace(partitions)
+	if partitions == "" || partitions == "all" {
+		return nil, nil
+	}
+
+	parts := strings.Split(partitions, ",")
+	partitionIDs := make([]int32, len(parts))
+	for i, part := range parts {
+		partitionID, err := strconv.ParseInt(strings.TrimSpace(part), 10, 32)
+		if err != nil || partitionID < 0 {
+			return nil, fmt.Errorf("partitions must be 'all' or a comma separated list of partition ids, but '%v' is not a valid partition id", part)
+		}
+		partitionIDs[i] = int32(partitionID)
+	}
+
+	return partitionIDs, nil
+}
+
+// parsePartitionsQuery parses the optional partitions query parameter, which defaults to all partitions
+func parsePartitionsQuery(r *http.Request) ([]int32, *rest.Error) {
+	partitionIDs, err := parsePartitionIDs(r.URL.Query().Get("partitions"))
+	if err != nil {
+		return nil, &rest.Error{
+			Err:      err,
+			Status:   http.StatusBadRequest,
+			Message:  err.Error(),
+			IsSilent: false,
+		}
+	}
+
+	return partitionIDs, nil
+}
+
+// consumeErrorStatus returns the status code for errors which occurred while consuming messages
+func consumeErrorStatus(err error) int {
+	if errors.Is(err, kafka.ErrInvalidPartition) || errors.Is(err, kafka.ErrInvalidOffset) ||
+		errors.Is(err, kafka.ErrRawExportOfTransformedTopic) || errors.Is(err, kafka.ErrRawFilterOfTransformedTopic) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// checkCanViewTopicMessages returns a rest error if the logged in user is not allowed to list messages in the topic
+func (api *API) checkCanViewTopicMessages(r *http.Request, topicName string) *rest.Error {
+	canViewMessages, restErr := api.Hooks.Owl.CanViewTopicMessages(r.Context(), topicName)
+	if restErr != nil {
+		return restErr
+	}
+	if !canViewMessages {
+		return &rest.Error{
+			Err:      fmt.Errorf("requester has no permissions to view messages in the requested topic"),
+			Status:   http.StatusForbidden,
+			Message:  "You don't have permissions to view messages in this topic",
+			IsSilent: false,
+		}
+	}
+
+	return nil
+}
+
+const (
+	defaultSearchTimeBudget = 10 * time.Second
+	maxSearchTimeBudget     = 60 * time.Second
+)
+
+// SearchMessagesRequest is the request body for a paginated message search in a single partition
+type SearchMessagesRequest struct {
+	PartitionID       int32  `json:"partitionId"`
+	StartOffset       int64  `json:"startOffset"`
+	EndOffset         int64  `json:"endOffset"` // -1 for the latest message
+	Limit             int    `json:"limit"`
+	TimeBudgetMs      int64  `json:"timeBudgetMs"`
+	ContinuationToken string `json:"continuationToken"`
+
+	// DeserializeHeaders decodes the header values like keys and values, defaults to true
+	DeserializeHeaders *bool `json:"deserializeHeaders"`
+
+	Filter struct {
+		Mode            kafka.MessageFilterMode `json:"mode"`
+		InterpreterCode string                  `json:"interpreterCode"` // Base64 encoded code
+		HeaderKey       string                  `json:"headerKey"`
+		HeaderValue     string                  `json:"headerValue"`
+		Substring       string                  `json:"substring"`
+	} `json:"filter"`
+}
+
+func (s *SearchMessagesRequest) OK() error {
+	if s.PartitionID < 0 {
+		return fmt.Errorf("partitionId must not be negative")
+	}
+
+	if s.StartOffset < 0 {
+		return fmt.Errorf("startOffset must not be negative")
+	}
+
+	if s.EndOffset < -1 {
+		return fmt.Errorf("endOffset is smaller than -1")
+	}
+
+	if s.Limit <= 0 || s.Limit > 500 {
+		return fmt.Errorf("limit must be between 1 and 500")
+	}
+
+	if s.TimeBudgetMs < 0 || time.Duration(s.TimeBudgetMs)*time.Millisecond > maxSearchTimeBudget {
+		return fmt.Errorf("timeBudgetMs must be between 0 and %d", maxSearchTimeBudget.Milliseconds())
+	}
+
+	if _, err := base64.StdEncoding.DecodeString(s.Filter.InterpreterCode); err != nil {
+		return fmt.Errorf("failed to decode interpreter code %w", err)
+	}
+
+	return nil
+}
+
+// handleSearchMessages consumes a single partition and returns one page of messages which pass the server side filter
+func (api *API) handleSearchMessages() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		topicName := chi.URLParam(r, "topicName")
+		logger := api.Logger.With(zap.String("topic_name", topicName))
+
+		var req SearchMessagesRequest
+		err := rest.Decode(r, &req)
+		if err != nil {
+			restErr := &rest.Error{
+				Err:      err,
+				Status:   http.StatusBadRequest,
+				Message:  fmt.Sprintf("Failed to parse request: %v", err.Error()),
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+
+		// Check if logged in user is allowed to search messages for the given topic
+		canViewMessages, restErr := api.Hooks.Owl.CanViewTopicMessages(r.Context(), topicName)
+		if restErr != nil {
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+		if !canViewMessages {
+			restErr := &rest.Error{
+				Err:      fmt.Errorf("requester has no permissions to view messages in the requested topic"),
+				Status:   http.StatusForbidden,
+				Message:  "You don't have permissions to view messages in this topic",
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+
+		if req.Filter.Mode != kafka.MessageFilterModeNone {
+			canUseMessageSearchFilters, restErr := api.Hooks.Owl.CanUseMessageSearchFilters(r.Context(), topicName)
+			if restErr != nil {
+				rest.SendRESTError(w, r, logger, restErr)
+				return
+			}
+			if !canUseMessageSearchFilters {
+				restErr := &rest.Error{
+					Err:      fmt.Errorf("requester has no permissions to use message filters in the requested topic"),
+					Status:   http.StatusForbidden,
+					Message:  "You don't have permissions to use message filters in this topic",
+					IsSilent: false,
+				}
+				rest.SendRESTError(w, r, logger, restErr)
+				return
+			}
+		}
+
+		interpreterCode, _ := base64.StdEncoding.DecodeString(req.Filter.InterpreterCode) // Error has been checked in validation function
+		timeBudget := time.Duration(req.TimeBudgetMs) * time.Millisecond
+		if timeBudget == 0 {
+			timeBudget = defaultSearchTimeBudget
+		}
+
+		searchReq := owl.SearchMessagesRequest{
+			TopicName:   topicName,
+			PartitionID: req.PartitionID,
+			StartOffset: req.StartOffset,
+			EndOffset:   req.EndOffset,
+			Filter: kafka.MessageFilter{
+				Mode:            req.Filter.Mode,
+				InterpreterCode: string(interpreterCode),
+				HeaderKey:       req.Filter.HeaderKey,
+				HeaderValue:     req.Filter.HeaderValue,
+				Substring:       req.Filter.Substring,
+			},
+			Limit:              req.Limit,
+			TimeBudget:         timeBudget,
+			ContinuationToken:  req.ContinuationToken,
+			DeserializeHeaders: isEnabledOrDefault(req.DeserializeHeaders),
+		}
+		res, err := api.owlSvc(r).SearchMessages(r.Context(), searchReq)
+		if res != nil {
+			api.chargeConsumedBytes(r, res.ConsumedBytes)
+		}
+		if err != nil {
+			restErr := &rest.Error{
+				Err:      err,
+				Status:   consumeErrorStatus(err),
+				Message:  fmt.Sprintf("Could not search messages: %v", err.Error()),
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+
+		rest.SendResponse(w, r, logger, http.StatusOK, res)
 	}
 }
@@ -0,0 +1,116 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/cloudhut/kowl/backend/pkg/kafka"
+	"github.com/cloudhut/kowl/backend/pkg/owl"
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestParseOffsetRange(t *testing.T) {
+	tt := []struct {
+		query   string
+		isValid bool
+		start   int64
+		end     int64
+	}{
+		{"startOffset=10000&endOffset=10100", true, 10000, 10100},
+		{"startOffset=10000&endOffset=10100&endInclusive=true", true, 10000, 10101},
+		{"startOffset=5&endOffset=5", true, 5, 5},
+		{"startOffset=10&endOffset=5", false, 0, 0},
+		{"startOffset=0&endOffset=501", false, 0, 0},
+		{"startOffset=-1&endOffset=5", false, 0, 0},
+		{"endOffset=5", false, 0, 0},
+		{"startOffset=0&endOffset=5&endInclusive=maybe", false, 0, 0},
+	}
+	for _, table := range tt {
+		r := httptest.NewRequest("GET", "/api/topics/orders/messages?mode=range&"+table.query, nil)
+		start, end, restErr := parseOffsetRange(r)
+		if !table.isValid {
+			assert.NotNil(t, restErr, table.query)
+			continue
+		}
+		assert.Nil(t, restErr, table.query)
+		assert.Equal(t, table.start, start, table.query)
+		assert.Equal(t, table.end, end, table.query)
+	}
+}
+
+func TestGetMessages_ChargesConsumedBytes(t *testing.T) {
+	timestamp := time.Date(2020, 11, 20, 14, 32, 0, 0, time.UTC)
+	value := sarama.StringEncoder(strings.Repeat("a", 200))
+	res := &sarama.FetchResponse{Version: 4}
+	res.AddRecordBatch("orders", 0, nil, value, 0, -1, false)
+	res.AddRecordBatch("orders", 0, nil, value, 1, -1, false)
+	res.GetBlock("orders", 0).HighWaterMarkOffset = 2
+
+	broker := sarama.NewMockBroker(t, 1)
+	defer broker.Close()
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).
+			SetBroker(broker.Addr(), broker.BrokerID()).
+			SetLeader("orders", 0, broker.BrokerID()),
+		// The offset for the timestamp is requested first, the watermarks are requested with version 0 afterwards
+		"OffsetRequest": sarama.NewMockSequence(
+			sarama.NewMockOffsetResponse(t).
+				SetVersion(1).
+				SetOffset("orders", 0, timestamp.UnixNano()/int64(time.Millisecond), 0),
+			sarama.NewMockOffsetResponse(t).
+				SetOffset("orders", 0, sarama.OffsetOldest, 0).
+				SetOffset("orders", 0, sarama.OffsetNewest, 2),
+		),
+		"FetchRequest": sarama.NewMockWrapper(res),
+	})
+
+	cfg := sarama.NewConfig()
+	cfg.Version = sarama.V1_0_0_0
+	client, err := sarama.NewClient([]string{broker.Addr()}, cfg)
+	require.NoError(t, err)
+	defer client.Close()
+
+	kafkaSvc := &kafka.Service{Client: client, Logger: zap.NewNop()}
+	api := &API{
+		Logger:       zap.NewNop(),
+		Hooks:        newDefaultHooks(),
+		clusters:     map[string]*cluster{"dev": {Name: "dev", KafkaSvc: kafkaSvc, OwlSvc: owl.NewService(zap.NewNop(), kafkaSvc, nil)}},
+		clusterNames: []string{"dev"},
+		consumeRateLimiter: newConsumeRateLimiter(ConsumeRateLimitConfig{
+			Enabled:          true,
+			ConsumeRateLimit: ConsumeRateLimit{BytesPerSecond: 1, BytesBurst: 100},
+			IdleTimeout:      time.Minute,
+		}),
+	}
+	router := chi.NewRouter()
+	router.With(api.rateLimitConsume).Get("/api/topics/{topicName}/messages", api.handleGetMessages())
+	router.With(api.rateLimitConsume).Get("/api/topics/{topicName}/size-distribution", api.handleGetTopicSizeDistribution())
+
+	// Each request consumes more bytes than the limit allows, hence the next request of the client is rejected
+	queries := []string{
+		"/api/topics/orders/messages?mode=timestamp&timestamp=2020-11-20T14:32:00Z&count=2",
+		"/api/topics/orders/messages?mode=newest&count=2",
+		"/api/topics/orders/messages?mode=compact-latest",
+		"/api/topics/orders/messages?mode=range&partitions=0&startOffset=0&endOffset=2",
+		"/api/topics/orders/size-distribution",
+	}
+	for i, query := range queries {
+		request := func() int {
+			r := httptest.NewRequest(http.MethodGet, query, nil)
+			r.RemoteAddr = fmt.Sprintf("10.0.0.%d:5000", i+1)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+			return w.Code
+		}
+		assert.Equal(t, http.StatusOK, request(), query)
+		assert.Equal(t, http.StatusTooManyRequests, request(), query)
+	}
+}
@@ -2,49 +2,144 @@ package api
 
 import (
 	_ "context"
+	"errors"
 	"fmt"
 	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
 	_ "time"
 
 	"go.uber.org/zap"
 
 	"github.com/cloudhut/common/rest"
+	"github.com/cloudhut/kowl/backend/pkg/kafka"
 	"github.com/cloudhut/kowl/backend/pkg/owl"
 	"github.com/go-chi/chi"
+	"github.com/gorilla/schema"
 )
 
+// getTopicsRequest filters, sorts and paginates the listed topics. Search matches topic names which contain the given
+// substring (case insensitive), Regex matches them against a regular expression. Both may be combined.
+type getTopicsRequest struct {
+	Search    string `schema:"search"`
+	Regex     string `schema:"regex"`
+	SortBy    string `schema:"sortBy"`    // name (default), partitionCount or size
+	SortOrder string `schema:"sortOrder"` // asc (default) or desc
+	Limit     int    `schema:"limit"`     // 0 returns all topics
+	Offset    int    `schema:"offset"`
+}
+
+func (g *getTopicsRequest) OK() error {
+	if _, err := g.regex(); err != nil {
+		return fmt.Errorf("regex is invalid: %w", err)
+	}
+
+	switch g.SortBy {
+	case "", owl.TopicsSortByName, owl.TopicsSortByPartitionCount, owl.TopicsSortBySize:
+	default:
+		return fmt.Errorf("sortBy must be one of '%v', '%v' or '%v'", owl.TopicsSortByName, owl.TopicsSortByPartitionCount, owl.TopicsSortBySize)
+	}
+
+	if g.SortOrder != "" && g.SortOrder != "asc" && g.SortOrder != "desc" {
+		return fmt.Errorf("sortOrder must be either 'asc' or 'desc'")
+	}
+
+	if g.Limit < 0 || g.Offset < 0 {
+		return fmt.Errorf("limit and offset must not be negative")
+	}
+
+	return nil
+}
+
+// regex returns the compiled regex of the request, or nil if none is given
+func (g *getTopicsRequest) regex() (*regexp.Regexp, error) {
+	if g.Regex == "" {
+		return nil, nil
+	}
+	return regexp.Compile(g.Regex)
+}
+
+// matches returns true if the topic name matches the search and regex of the request
+func (g *getTopicsRequest) matches(topicName string, regex *regexp.Regexp) bool {
+	if g.Search != "" && !strings.Contains(strings.ToLower(topicName), strings.ToLower(g.Search)) {
+		return false
+	}
+	return regex == nil || regex.MatchString(topicName)
+}
+
 func (api *API) handleGetTopics() http.HandlerFunc {
 	type response struct {
-		Topics []*owl.TopicOverview `json:"topics"`
+		Topics     []*owl.TopicOverview `json:"topics"`
+		TotalCount int                  `json:"totalCount"`
+		IsStale    bool                 `json:"isStale"`
 	}
 
 	return func(w http.ResponseWriter, r *http.Request) {
-		topics, err := api.OwlSvc.GetTopicsOverview()
+		// Parse request from url parameters
+		req := &getTopicsRequest{}
+		err := schema.NewDecoder().Decode(req, r.URL.Query())
 		if err != nil {
 			restErr := &rest.Error{
 				Err:      err,
-				Status:   http.StatusInternalServerError,
-				Message:  "Could not list topics from Kafka cluster",
+				Status:   http.StatusBadRequest,
+				Message:  "Failed to parse request parameters",
 				IsSilent: false,
 			}
 			rest.SendRESTError(w, r, api.Logger, restErr)
 			return
 		}
-
-		visibleTopics := make([]*owl.TopicOverview, 0, len(topics))
-		for _, topic := range topics {
-			// Check if logged in user is allowed to see this topic. If not remove the topic from the list.
-			canSee, restErr := api.Hooks.Owl.CanSeeTopic(r.Context(), topic.TopicName)
-			if restErr != nil {
-				rest.SendRESTError(w, r, api.Logger, restErr)
-				return
+		err = req.OK()
+		if err != nil {
+			restErr := &rest.Error{
+				Err:      err,
+				Status:   http.StatusBadRequest,
+				Message:  fmt.Sprintf("Failed to validate request parameters: %v", err.Error()),
+				IsSilent: false,
 			}
+			rest.SendRESTError(w, r, api.Logger, restErr)
+			return
+		}
+		regex, _ := req.regex() // Error has been checked in validation function
 
-			if canSee {
-				visibleTopics = append(visibleTopics, topic)
+		// Topics which don't match or which the logged in user is not allowed to see are filtered out before the page
+		// is selected, so that all pages are complete
+		var filterErr *rest.Error
+		overview, err := api.owlSvc(r).GetTopicsOverview(r.Context(), owl.TopicsOverviewRequest{
+			Filter: func(topicName string) (bool, error) {
+				if !api.topicFilter.isAllowed(topicName) || !req.matches(topicName, regex) {
+					return false, nil
+				}
+				canSee, restErr := api.Hooks.Owl.CanSeeTopic(r.Context(), topicName)
+				if restErr != nil {
+					filterErr = restErr
+					return false, restErr.Err
+				}
+				return canSee, nil
+			},
+			SortBy:     req.SortBy,
+			Descending: req.SortOrder == "desc",
+			Offset:     req.Offset,
+			Limit:      req.Limit,
+		})
+		if filterErr != nil {
+			rest.SendRESTError(w, r, api.Logger, filterErr)
+			return
+		}
+		if err != nil {
+			restErr := &rest.Error{
+				Err:      err,
+				Status:   http.StatusInternalServerError,
+				Message:  "Could not list topics from Kafka cluster",
+				IsSilent: false,
 			}
+			rest.SendRESTError(w, r, api.Logger, restErr)
+			return
+		}
 
-			// Attach allowed actions for each topic
+		// Attach allowed actions for each topic
+		for _, topic := range overview.Topics {
+			var restErr *rest.Error
 			topic.AllowedActions, restErr = api.Hooks.Owl.AllowedTopicActions(r.Context(), topic.TopicName)
 			if restErr != nil {
 				rest.SendRESTError(w, r, api.Logger, restErr)
@@ -53,7 +148,9 @@ func (api *API) handleGetTopics() http.HandlerFunc {
 		}
 
 		response := response{
-			Topics: visibleTopics,
+			Topics:     overview.Topics,
+			TotalCount: overview.TotalCount,
+			IsStale:    overview.IsStale,
 		}
 		rest.SendResponse(w, r, api.Logger, http.StatusOK, response)
 	}
@@ -87,7 +184,7 @@ func (api *API) handleGetPartitions() http.HandlerFunc {
 			return
 		}
 
-		partitions, err := api.OwlSvc.ListTopicPartitions(topicName)
+		partitions, err := api.owlSvc(r).ListTopicPartitions(r.Context(), topicName)
 		if err != nil {
 			restErr := &rest.Error{
 				Err:      err,
@@ -107,6 +204,153 @@ func (api *API) handleGetPartitions() http.HandlerFunc {
 	}
 }
 
+// handleGetTopicSize returns the disk usage of each partition replica of a topic along with the topic's totals.
+// Brokers which fail to respond are marked as unavailable.
+func (api *API) handleGetTopicSize() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		topicName := chi.URLParam(r, "topicName")
+		logger := api.Logger.With(zap.String("topic_name", topicName))
+
+		// Check if logged in user is allowed to view partitions for the given topic
+		canView, restErr := api.Hooks.Owl.CanViewTopicPartitions(r.Context(), topicName)
+		if restErr != nil {
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+		if !canView {
+			restErr := &rest.Error{
+				Err:      fmt.Errorf("requester has no permissions to view partitions for the requested topic"),
+				Status:   http.StatusForbidden,
+				Message:  "You don't have permissions to view partitions for that topic",
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+
+		summary, err := api.owlSvc(r).GetTopicLogDirs(r.Context(), topicName)
+		if err != nil {
+			restErr := &rest.Error{
+				Err:      err,
+				Status:   http.StatusInternalServerError,
+				Message:  "Could not get the log dirs for requested topic",
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+
+		rest.SendResponse(w, r, logger, http.StatusOK, summary)
+	}
+}
+
+// handleGetTopicOffsets returns the low and high watermarks, the message count and the last message's timestamp of
+// each partition of a topic. Leaderless partitions are marked as unavailable.
+func (api *API) handleGetTopicOffsets() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		topicName := chi.URLParam(r, "topicName")
+		logger := api.Logger.With(zap.String("topic_name", topicName))
+
+		// Check if logged in user is allowed to view partitions for the given topic
+		canView, restErr := api.Hooks.Owl.CanViewTopicPartitions(r.Context(), topicName)
+		if restErr != nil {
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+		if !canView {
+			restErr := &rest.Error{
+				Err:      fmt.Errorf("requester has no permissions to view partitions for the requested topic"),
+				Status:   http.StatusForbidden,
+				Message:  "You don't have permissions to view partitions for that topic",
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+
+		offsets, err := api.owlSvc(r).GetTopicOffsets(r.Context(), topicName)
+		if err != nil {
+			restErr := &rest.Error{
+				Err:      err,
+				Status:   http.StatusInternalServerError,
+				Message:  "Could not get the offsets for requested topic",
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+
+		rest.SendResponse(w, r, logger, http.StatusOK, offsets)
+	}
+}
+
+// handleGetTopicSizeDistribution samples the newest messages of each partition and returns the percentiles of their key
+// and value sizes (?sampleSize=100)
+func (api *API) handleGetTopicSizeDistribution() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		topicName := chi.URLParam(r, "topicName")
+		logger := api.Logger.With(zap.String("topic_name", topicName))
+
+		// The sizes are derived from the messages, hence the requester must be allowed to view them
+		canView, restErr := api.Hooks.Owl.CanViewTopicMessages(r.Context(), topicName)
+		if restErr != nil {
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+		if !canView {
+			restErr := &rest.Error{
+				Err:      fmt.Errorf("requester has no permissions to view messages in the requested topic"),
+				Status:   http.StatusForbidden,
+				Message:  "You don't have permissions to view messages in that topic",
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+
+		sampleSize, restErr := parseSizeSampleSize(r)
+		if restErr != nil {
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+
+		distribution, err := api.owlSvc(r).GetMessageSizeDistribution(r.Context(), topicName, sampleSize)
+		if err != nil {
+			restErr := &rest.Error{
+				Err:      err,
+				Status:   http.StatusInternalServerError,
+				Message:  fmt.Sprintf("Could not get the message size distribution for requested topic: %v", err.Error()),
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+		api.chargeConsumedBytes(r, distribution.SampledBytes)
+
+		rest.SendResponse(w, r, logger, http.StatusOK, distribution)
+	}
+}
+
+// parseSizeSampleSize parses the number of newest messages per partition whose sizes are sampled
+func parseSizeSampleSize(r *http.Request) (int64, *rest.Error) {
+	sampleSizeStr := r.URL.Query().Get("sampleSize")
+	if sampleSizeStr == "" {
+		return 100, nil
+	}
+
+	sampleSize, err := strconv.ParseInt(sampleSizeStr, 10, 64)
+	if err != nil || sampleSize <= 0 || sampleSize > 10000 {
+		return 0, &rest.Error{
+			Err:      fmt.Errorf("invalid sample size '%v'", sampleSizeStr),
+			Status:   http.StatusBadRequest,
+			Message:  "Sample size must be a number between 1 and 10000",
+			IsSilent: false,
+		}
+	}
+
+	return sampleSize, nil
+}
+
 // handleGetTopicConfig returns all set configuration options for a specific topic
 func (api *API) handleGetTopicConfig() http.HandlerFunc {
 	type response struct {
@@ -134,7 +378,7 @@ func (api *API) handleGetTopicConfig() http.HandlerFunc {
 			return
 		}
 
-		description, err := api.OwlSvc.GetTopicConfigs(topicName, []string{})
+		description, err := api.owlSvc(r).GetTopicConfigs(r.Context(), topicName, []string{})
 		if err != nil {
 			restErr := &rest.Error{
 				Err:      err,
@@ -153,6 +397,99 @@ func (api *API) handleGetTopicConfig() http.HandlerFunc {
 	}
 }
 
+type patchTopicConfigRequest struct {
+	// Configs maps config names to their new values. A null value resets the config to it's default value.
+	Configs map[string]*string `json:"configs"`
+}
+
+func (p *patchTopicConfigRequest) OK() error {
+	if len(p.Configs) == 0 {
+		return fmt.Errorf("at least one config must be given")
+	}
+	for name := range p.Configs {
+		if name == "" {
+			return fmt.Errorf("config names must not be empty")
+		}
+	}
+
+	return nil
+}
+
+// handlePatchTopicConfig alters the given config entries of a specific topic and returns the updated topic config
+func (api *API) handlePatchTopicConfig() http.HandlerFunc {
+	type response struct {
+		TopicDescription *owl.TopicConfigs `json:"topicDescription"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		topicName := chi.URLParam(r, "topicName")
+		logger := api.Logger.With(zap.String("topic_name", topicName))
+
+		if !api.Cfg.EnableTopicOperations {
+			restErr := &rest.Error{
+				Err:      fmt.Errorf("topic operations are disabled"),
+				Status:   http.StatusForbidden,
+				Message:  "Topic operations are disabled, set 'enableTopicOperations' to true in order to alter topic configs",
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+
+		// Check if logged in user is allowed to edit the config for the given topic
+		canEdit, restErr := api.Hooks.Owl.CanEditTopicConfig(r.Context(), topicName)
+		if restErr != nil {
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+		if !canEdit {
+			restErr := &rest.Error{
+				Err:      fmt.Errorf("requester has no permissions to edit config for the requested topic"),
+				Status:   http.StatusForbidden,
+				Message:  "You don't have permissions to edit the config for that topic",
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+
+		var req patchTopicConfigRequest
+		err := rest.Decode(r, &req)
+		if err != nil {
+			restErr := &rest.Error{
+				Err:      err,
+				Status:   http.StatusBadRequest,
+				Message:  fmt.Sprintf("Failed to parse request: %v", err.Error()),
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+
+		description, err := api.owlSvc(r).AlterTopicConfig(r.Context(), topicName, req.Configs)
+		if err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, kafka.ErrTopicNotFound) {
+				status = http.StatusNotFound
+			}
+			restErr := &rest.Error{
+				Err:      err,
+				Status:   status,
+				Message:  fmt.Sprintf("Could not alter topic config: %v", err.Error()),
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+		logger.Info("altered topic config", zap.Int("changed_entries", len(req.Configs)))
+
+		res := response{
+			TopicDescription: description,
+		}
+		rest.SendResponse(w, r, logger, http.StatusOK, res)
+	}
+}
+
 // handleGetTopicConsumers returns all consumers along with their summed lag which consume the given topic
 func (api *API) handleGetTopicConsumers() http.HandlerFunc {
 	type response struct {
@@ -181,7 +518,7 @@ func (api *API) handleGetTopicConsumers() http.HandlerFunc {
 			return
 		}
 
-		consumers, err := api.OwlSvc.ListTopicConsumers(r.Context(), topicName)
+		consumers, err := api.owlSvc(r).ListTopicConsumers(r.Context(), topicName)
 		if err != nil {
 			restErr := &rest.Error{
 				Err:      err,
@@ -200,3 +537,317 @@ func (api *API) handleGetTopicConsumers() http.HandlerFunc {
 		rest.SendResponse(w, r, logger, http.StatusOK, res)
 	}
 }
+
+type deleteTopicRecordsRequest struct {
+	Partitions []struct {
+		PartitionID int32 `json:"partitionId"`
+		Offset      int64 `json:"offset"` // Records before this offset will be deleted, -1 deletes all records
+	} `json:"partitions"`
+}
+
+func (d *deleteTopicRecordsRequest) OK() error {
+	if len(d.Partitions) == 0 {
+		return fmt.Errorf("at least one partition must be given")
+	}
+
+	seen := make(map[int32]bool, len(d.Partitions))
+	for _, p := range d.Partitions {
+		if seen[p.PartitionID] {
+			return fmt.Errorf("partition %v has been given more than once", p.PartitionID)
+		}
+		seen[p.PartitionID] = true
+
+		if p.Offset < -1 {
+			return fmt.Errorf("offset of partition %v must be -1 or greater", p.PartitionID)
+		}
+	}
+
+	return nil
+}
+
+// handleDeleteTopicRecords deletes all records before the given offsets and returns the new low water marks
+func (api *API) handleDeleteTopicRecords() http.HandlerFunc {
+	type response struct {
+		TopicName  string                        `json:"topicName"`
+		Partitions []owl.DeletedRecordsPartition `json:"partitions"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		topicName := chi.URLParam(r, "topicName")
+		logger := api.Logger.With(zap.String("topic_name", topicName))
+
+		if !api.Cfg.EnableTopicOperations {
+			restErr := &rest.Error{
+				Err:      fmt.Errorf("topic operations are disabled"),
+				Status:   http.StatusForbidden,
+				Message:  "Topic operations are disabled, set 'enableTopicOperations' to true in order to delete records",
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+
+		// Check if logged in user is allowed to delete records of the given topic
+		canDelete, restErr := api.Hooks.Owl.CanDeleteTopicRecords(r.Context(), topicName)
+		if restErr != nil {
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+		if !canDelete {
+			restErr := &rest.Error{
+				Err:      fmt.Errorf("requester has no permissions to delete records of the requested topic"),
+				Status:   http.StatusForbidden,
+				Message:  "You don't have permissions to delete records of that topic",
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+
+		var req deleteTopicRecordsRequest
+		err := rest.Decode(r, &req)
+		if err != nil {
+			restErr := &rest.Error{
+				Err:      err,
+				Status:   http.StatusBadRequest,
+				Message:  fmt.Sprintf("Failed to parse request: %v", err.Error()),
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+
+		partitionOffsets := make(map[int32]int64, len(req.Partitions))
+		for _, p := range req.Partitions {
+			partitionOffsets[p.PartitionID] = p.Offset
+		}
+
+		partitions, err := api.owlSvc(r).DeleteRecords(r.Context(), topicName, partitionOffsets)
+		if err != nil {
+			status := http.StatusInternalServerError
+			switch {
+			case errors.Is(err, kafka.ErrTopicNotFound):
+				status = http.StatusNotFound
+			case errors.Is(err, kafka.ErrInvalidOffset):
+				status = http.StatusBadRequest
+			}
+			restErr := &rest.Error{
+				Err:      err,
+				Status:   status,
+				Message:  fmt.Sprintf("Could not delete records: %v", err.Error()),
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+		logger.Info("deleted topic records", zap.Int("partitions", len(partitions)))
+
+		res := response{
+			TopicName:  topicName,
+			Partitions: partitions,
+		}
+		rest.SendResponse(w, r, logger, http.StatusOK, res)
+	}
+}
+
+type createTopicRequest struct {
+	TopicName         string `json:"topicName"`
+	PartitionCount    int32  `json:"partitionCount"`
+	ReplicationFactor int16  `json:"replicationFactor"`
+	ReplicaAssignment []struct {
+		PartitionID int32   `json:"partitionId"`
+		Replicas    []int32 `json:"replicas"`
+	} `json:"replicaAssignment"`
+	Configs map[string]*string `json:"configs"`
+}
+
+func (c *createTopicRequest) OK() error {
+	if c.TopicName == "" {
+		return fmt.Errorf("topic name is required")
+	}
+	hasCount := c.PartitionCount != 0 || c.ReplicationFactor != 0
+	if hasCount && len(c.ReplicaAssignment) > 0 {
+		return fmt.Errorf("either partitionCount and replicationFactor or replicaAssignment may be given, but not both")
+	}
+	if !hasCount && len(c.ReplicaAssignment) == 0 {
+		return fmt.Errorf("either partitionCount and replicationFactor or replicaAssignment must be given")
+	}
+
+	return nil
+}
+
+// ToTopicSpec returns the topic spec which is passed to the kafka package
+func (c *createTopicRequest) ToTopicSpec() kafka.TopicSpec {
+	spec := kafka.TopicSpec{
+		PartitionCount:    c.PartitionCount,
+		ReplicationFactor: c.ReplicationFactor,
+		Configs:           c.Configs,
+	}
+	if len(c.ReplicaAssignment) > 0 {
+		spec.ReplicaAssignment = make(map[int32][]int32, len(c.ReplicaAssignment))
+		for _, assignment := range c.ReplicaAssignment {
+			spec.ReplicaAssignment[assignment.PartitionID] = assignment.Replicas
+		}
+	}
+
+	return spec
+}
+
+// handleCreateTopic creates a new topic either by partition count and replication factor or by an explicit replica
+// assignment and returns the created topic's metadata
+func (api *API) handleCreateTopic() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !api.Cfg.EnableTopicOperations {
+			restErr := &rest.Error{
+				Err:      fmt.Errorf("topic operations are disabled"),
+				Status:   http.StatusForbidden,
+				Message:  "Topic operations are disabled, set 'enableTopicOperations' to true in order to create topics",
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, api.Logger, restErr)
+			return
+		}
+
+		var req createTopicRequest
+		err := rest.Decode(r, &req)
+		if err != nil {
+			restErr := &rest.Error{
+				Err:      err,
+				Status:   http.StatusBadRequest,
+				Message:  fmt.Sprintf("Failed to parse request: %v", err.Error()),
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, api.Logger, restErr)
+			return
+		}
+		logger := api.Logger.With(zap.String("topic_name", req.TopicName))
+
+		if !api.topicFilter.isAllowed(req.TopicName) {
+			rest.SendRESTError(w, r, logger, newTopicNotAllowedError(req.TopicName))
+			return
+		}
+
+		// Check if logged in user is allowed to create the given topic
+		canCreate, restErr := api.Hooks.Owl.CanCreateTopic(r.Context(), req.TopicName)
+		if restErr != nil {
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+		if !canCreate {
+			restErr := &rest.Error{
+				Err:      fmt.Errorf("requester has no permissions to create the requested topic"),
+				Status:   http.StatusForbidden,
+				Message:  "You don't have permissions to create that topic",
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+
+		topic, err := api.owlSvc(r).CreateTopic(r.Context(), req.TopicName, req.ToTopicSpec())
+		if err != nil {
+			status := http.StatusInternalServerError
+			switch {
+			case errors.Is(err, kafka.ErrInvalidTopicSpec):
+				status = http.StatusBadRequest
+			case errors.Is(err, kafka.ErrTopicAlreadyExists):
+				status = http.StatusConflict
+			}
+			restErr := &rest.Error{
+				Err:      err,
+				Status:   status,
+				Message:  fmt.Sprintf("Could not create topic: %v", err.Error()),
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+
+		rest.SendResponse(w, r, logger, http.StatusCreated, topic)
+	}
+}
+
+type increasePartitionsRequest struct {
+	PartitionCount int32 `json:"partitionCount"`
+
+	// ReplicaAssignment optionally contains the replica broker IDs for each new partition
+	ReplicaAssignment [][]int32 `json:"replicaAssignment"`
+}
+
+func (i *increasePartitionsRequest) OK() error {
+	if i.PartitionCount < 1 {
+		return fmt.Errorf("partition count must be at least 1")
+	}
+
+	return nil
+}
+
+// handleIncreasePartitions increases the partition count of a topic
+func (api *API) handleIncreasePartitions() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		topicName := chi.URLParam(r, "topicName")
+		logger := api.Logger.With(zap.String("topic_name", topicName))
+
+		if !api.Cfg.EnableTopicOperations {
+			restErr := &rest.Error{
+				Err:      fmt.Errorf("topic operations are disabled"),
+				Status:   http.StatusForbidden,
+				Message:  "Topic operations are disabled, set 'enableTopicOperations' to true in order to increase partitions",
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+
+		// Check if logged in user is allowed to change the partitions of the given topic
+		canEdit, restErr := api.Hooks.Owl.CanEditTopicPartitions(r.Context(), topicName)
+		if restErr != nil {
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+		if !canEdit {
+			restErr := &rest.Error{
+				Err:      fmt.Errorf("requester has no permissions to edit partitions of the requested topic"),
+				Status:   http.StatusForbidden,
+				Message:  "You don't have permissions to edit the partitions of that topic",
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+
+		var req increasePartitionsRequest
+		err := rest.Decode(r, &req)
+		if err != nil {
+			restErr := &rest.Error{
+				Err:      err,
+				Status:   http.StatusBadRequest,
+				Message:  fmt.Sprintf("Failed to parse request: %v", err.Error()),
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+
+		res, err := api.owlSvc(r).IncreasePartitions(r.Context(), topicName, req.PartitionCount, req.ReplicaAssignment)
+		if err != nil {
+			status := http.StatusInternalServerError
+			switch {
+			case errors.Is(err, kafka.ErrTopicNotFound):
+				status = http.StatusNotFound
+			case errors.Is(err, kafka.ErrInvalidPartitionCount):
+				status = http.StatusBadRequest
+			}
+			restErr := &rest.Error{
+				Err:      err,
+				Status:   status,
+				Message:  fmt.Sprintf("Could not increase partitions: %v", err.Error()),
+				IsSilent: false,
+			}
+			rest.SendRESTError(w, r, logger, restErr)
+			return
+		}
+
+		rest.SendResponse(w, r, logger, http.StatusOK, res)
+	}
+}
@@ -0,0 +1,29 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetTopicsRequest(t *testing.T) {
+	req := getTopicsRequest{Search: "Order", Regex: "^team-a\\."}
+	require.NoError(t, req.OK())
+	regex, err := req.regex()
+	require.NoError(t, err)
+	assert.True(t, req.matches("team-a.orders", regex))
+	assert.True(t, req.matches("team-a.ORDER-events", regex), "the search is case insensitive")
+	assert.False(t, req.matches("team-b.orders", regex))
+	assert.False(t, req.matches("team-a.payments", regex))
+
+	invalid := []getTopicsRequest{
+		{Regex: "orders("},
+		{SortBy: "replicationFactor"},
+		{SortOrder: "up"},
+		{Limit: -1},
+	}
+	for _, req := range invalid {
+		assert.Error(t, req.OK(), req)
+	}
+}
@@ -3,6 +3,7 @@ package api
 import (
 	"context"
 	"github.com/cloudhut/kowl/backend/pkg/owl"
+	"net"
 	"net/http"
 
 	"github.com/cloudhut/common/rest"
@@ -37,18 +38,44 @@ type OwlHooks interface {
 	CanSeeTopic(ctx context.Context, topicName string) (bool, *rest.Error)
 	CanViewTopicPartitions(ctx context.Context, topicName string) (bool, *rest.Error)
 	CanViewTopicConfig(ctx context.Context, topicName string) (bool, *rest.Error)
+	CanCreateTopic(ctx context.Context, topicName string) (bool, *rest.Error)
+	CanEditTopicConfig(ctx context.Context, topicName string) (bool, *rest.Error)
+	CanDeleteTopicRecords(ctx context.Context, topicName string) (bool, *rest.Error)
+	CanEditTopicPartitions(ctx context.Context, topicName string) (bool, *rest.Error)
 	CanViewTopicMessages(ctx context.Context, topicName string) (bool, *rest.Error)
 	CanUseMessageSearchFilters(ctx context.Context, topicName string) (bool, *rest.Error)
+	CanProduceToTopic(ctx context.Context, topicName string) (bool, *rest.Error)
 	CanViewTopicConsumers(ctx context.Context, topicName string) (bool, *rest.Error)
 	AllowedTopicActions(ctx context.Context, topicName string) ([]string, *rest.Error)
 	PrintListMessagesAuditLog(r *http.Request, req *owl.ListMessageRequest)
+	ConsumeRateLimitPrincipal(r *http.Request) string
 
 	// ACL Hooks
 	CanListACLs(ctx context.Context) (bool, *rest.Error)
+	CanCreateACL(ctx context.Context) (bool, *rest.Error)
+	CanDeleteACL(ctx context.Context) (bool, *rest.Error)
+
+	// Quota Hooks
+	CanListQuotas(ctx context.Context) (bool, *rest.Error)
+	CanAlterQuotas(ctx context.Context) (bool, *rest.Error)
+
+	// Cluster Hooks
+	CanViewCluster(ctx context.Context) (bool, *rest.Error)
+	CanRefreshClusterMetadata(ctx context.Context) (bool, *rest.Error)
+	CanRunSelfTest(ctx context.Context) (bool, *rest.Error)
 
 	// ConsumerGroup Hooks
 	CanSeeConsumerGroup(ctx context.Context, groupName string) (bool, *rest.Error)
 	AllowedConsumerGroupActions(ctx context.Context, groupName string) ([]string, *rest.Error)
+	CanEditConsumerGroup(ctx context.Context, groupName string) (bool, *rest.Error)
+	CanDeleteConsumerGroup(ctx context.Context, groupName string) (bool, *rest.Error)
+
+	// Schema Registry Hooks
+	CanEditSchemaSubject(ctx context.Context, subject string) (bool, *rest.Error)
+
+	// Kafka Connect Hooks
+	CanViewConnectCluster(ctx context.Context, clusterName string) (bool, *rest.Error)
+	CanEditConnectCluster(ctx context.Context, clusterName string) (bool, *rest.Error)
 }
 
 // defaultHooks is the default hook which is used if you don't attach your own hooks
@@ -77,6 +104,21 @@ func (*defaultHooks) CanViewTopicPartitions(_ context.Context, _ string) (bool,
 func (*defaultHooks) CanViewTopicConfig(_ context.Context, _ string) (bool, *rest.Error) {
 	return true, nil
 }
+func (*defaultHooks) CanCreateTopic(_ context.Context, _ string) (bool, *rest.Error) {
+	return true, nil
+}
+func (*defaultHooks) CanEditTopicConfig(_ context.Context, _ string) (bool, *rest.Error) {
+	return true, nil
+}
+func (*defaultHooks) CanDeleteTopicRecords(_ context.Context, _ string) (bool, *rest.Error) {
+	return true, nil
+}
+func (*defaultHooks) CanEditTopicPartitions(_ context.Context, _ string) (bool, *rest.Error) {
+	return true, nil
+}
+func (*defaultHooks) CanProduceToTopic(_ context.Context, _ string) (bool, *rest.Error) {
+	return true, nil
+}
 func (*defaultHooks) CanViewTopicMessages(_ context.Context, _ string) (bool, *rest.Error) {
 	return true, nil
 }
@@ -91,9 +133,44 @@ func (*defaultHooks) AllowedTopicActions(_ context.Context, _ string) ([]string,
 	return []string{"all"}, nil
 }
 func (*defaultHooks) PrintListMessagesAuditLog(_ *http.Request, _ *owl.ListMessageRequest) {}
+func (*defaultHooks) ConsumeRateLimitPrincipal(r *http.Request) string {
+	if user, ok := UserFromContext(r.Context()); ok {
+		return user.Name
+	}
+	// The remote address may have been replaced by the RealIP middleware with an untrusted forwarded address
+	if addr, ok := clientAddrFromContext(r.Context()); ok {
+		return addr
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
 func (*defaultHooks) CanListACLs(_ context.Context) (bool, *rest.Error) {
 	return true, nil
 }
+func (*defaultHooks) CanCreateACL(_ context.Context) (bool, *rest.Error) {
+	return true, nil
+}
+func (*defaultHooks) CanDeleteACL(_ context.Context) (bool, *rest.Error) {
+	return true, nil
+}
+func (*defaultHooks) CanListQuotas(_ context.Context) (bool, *rest.Error) {
+	return true, nil
+}
+func (*defaultHooks) CanAlterQuotas(_ context.Context) (bool, *rest.Error) {
+	return true, nil
+}
+func (*defaultHooks) CanViewCluster(_ context.Context) (bool, *rest.Error) {
+	return true, nil
+}
+func (*defaultHooks) CanRefreshClusterMetadata(_ context.Context) (bool, *rest.Error) {
+	return true, nil
+}
+func (*defaultHooks) CanRunSelfTest(_ context.Context) (bool, *rest.Error) {
+	return true, nil
+}
 func (*defaultHooks) CanSeeConsumerGroup(_ context.Context, _ string) (bool, *rest.Error) {
 	return true, nil
 }
@@ -101,3 +178,18 @@ func (*defaultHooks) AllowedConsumerGroupActions(_ context.Context, _ string) ([
 	// "all" will be considered as wild card - all actions are allowed
 	return []string{"all"}, nil
 }
+func (*defaultHooks) CanEditConsumerGroup(_ context.Context, _ string) (bool, *rest.Error) {
+	return true, nil
+}
+func (*defaultHooks) CanDeleteConsumerGroup(_ context.Context, _ string) (bool, *rest.Error) {
+	return true, nil
+}
+func (*defaultHooks) CanViewConnectCluster(_ context.Context, _ string) (bool, *rest.Error) {
+	return true, nil
+}
+func (*defaultHooks) CanEditConnectCluster(_ context.Context, _ string) (bool, *rest.Error) {
+	return true, nil
+}
+func (*defaultHooks) CanEditSchemaSubject(_ context.Context, _ string) (bool, *rest.Error) {
+	return true, nil
+}
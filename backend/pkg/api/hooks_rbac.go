@@ -0,0 +1,162 @@
+package api
+
+import (
+	"context"
+
+	"github.com/cloudhut/common/rest"
+)
+
+// rbacHooks are the owl hooks which authorize all requests with the configured roles
+type rbacHooks struct {
+	*defaultHooks
+	authorizer *authorizer
+}
+
+func newRBACHooks(authorizer *authorizer) *rbacHooks {
+	return &rbacHooks{defaultHooks: &defaultHooks{}, authorizer: authorizer}
+}
+
+func (h *rbacHooks) canOnTopic(ctx context.Context, operation string, topicName string) (bool, *rest.Error) {
+	return h.authorizer.isAllowed(ctx, operation, rbacResourceTopic, topicName)
+}
+
+func (h *rbacHooks) canOnConsumerGroup(ctx context.Context, operation string, groupName string) (bool, *rest.Error) {
+	return h.authorizer.isAllowed(ctx, operation, rbacResourceConsumerGroup, groupName)
+}
+
+func (h *rbacHooks) canOnCluster(ctx context.Context, operation string) (bool, *rest.Error) {
+	return h.authorizer.isAllowed(ctx, operation, rbacResourceCluster, "")
+}
+
+// Topic Hooks
+func (h *rbacHooks) CanSeeTopic(ctx context.Context, topicName string) (bool, *rest.Error) {
+	return h.canOnTopic(ctx, rbacOperationView, topicName)
+}
+func (h *rbacHooks) CanViewTopicPartitions(ctx context.Context, topicName string) (bool, *rest.Error) {
+	return h.canOnTopic(ctx, rbacOperationView, topicName)
+}
+func (h *rbacHooks) CanViewTopicConfig(ctx context.Context, topicName string) (bool, *rest.Error) {
+	return h.canOnTopic(ctx, rbacOperationView, topicName)
+}
+func (h *rbacHooks) CanCreateTopic(ctx context.Context, topicName string) (bool, *rest.Error) {
+	return h.canOnTopic(ctx, rbacOperationAdmin, topicName)
+}
+func (h *rbacHooks) CanEditTopicConfig(ctx context.Context, topicName string) (bool, *rest.Error) {
+	return h.canOnTopic(ctx, rbacOperationAdmin, topicName)
+}
+func (h *rbacHooks) CanDeleteTopicRecords(ctx context.Context, topicName string) (bool, *rest.Error) {
+	return h.canOnTopic(ctx, rbacOperationAdmin, topicName)
+}
+func (h *rbacHooks) CanEditTopicPartitions(ctx context.Context, topicName string) (bool, *rest.Error) {
+	return h.canOnTopic(ctx, rbacOperationAdmin, topicName)
+}
+func (h *rbacHooks) CanViewTopicMessages(ctx context.Context, topicName string) (bool, *rest.Error) {
+	return h.canOnTopic(ctx, rbacOperationConsume, topicName)
+}
+func (h *rbacHooks) CanUseMessageSearchFilters(ctx context.Context, topicName string) (bool, *rest.Error) {
+	return h.canOnTopic(ctx, rbacOperationConsume, topicName)
+}
+func (h *rbacHooks) CanProduceToTopic(ctx context.Context, topicName string) (bool, *rest.Error) {
+	return h.canOnTopic(ctx, rbacOperationProduce, topicName)
+}
+func (h *rbacHooks) CanViewTopicConsumers(ctx context.Context, topicName string) (bool, *rest.Error) {
+	return h.canOnTopic(ctx, rbacOperationView, topicName)
+}
+func (h *rbacHooks) AllowedTopicActions(ctx context.Context, topicName string) ([]string, *rest.Error) {
+	isAdmin, restErr := h.canOnTopic(ctx, rbacOperationAdmin, topicName)
+	if restErr != nil {
+		return nil, restErr
+	}
+	if isAdmin {
+		return []string{"all"}, nil
+	}
+
+	actions := make([]string, 0)
+	canView, restErr := h.canOnTopic(ctx, rbacOperationView, topicName)
+	if restErr != nil {
+		return nil, restErr
+	}
+	if canView {
+		actions = append(actions, "seeTopic", "viewPartitions", "viewConsumers", "viewConfig")
+	}
+	canConsume, restErr := h.canOnTopic(ctx, rbacOperationConsume, topicName)
+	if restErr != nil {
+		return nil, restErr
+	}
+	if canConsume {
+		actions = append(actions, "viewMessages", "useSearchFilter")
+	}
+	return actions, nil
+}
+
+// ACL Hooks
+func (h *rbacHooks) CanListACLs(ctx context.Context) (bool, *rest.Error) {
+	return h.canOnCluster(ctx, rbacOperationView)
+}
+func (h *rbacHooks) CanCreateACL(ctx context.Context) (bool, *rest.Error) {
+	return h.canOnCluster(ctx, rbacOperationAdmin)
+}
+func (h *rbacHooks) CanDeleteACL(ctx context.Context) (bool, *rest.Error) {
+	return h.canOnCluster(ctx, rbacOperationAdmin)
+}
+
+// Quota Hooks
+func (h *rbacHooks) CanListQuotas(ctx context.Context) (bool, *rest.Error) {
+	return h.canOnCluster(ctx, rbacOperationView)
+}
+func (h *rbacHooks) CanAlterQuotas(ctx context.Context) (bool, *rest.Error) {
+	return h.canOnCluster(ctx, rbacOperationAdmin)
+}
+
+// Cluster Hooks
+func (h *rbacHooks) CanViewCluster(ctx context.Context) (bool, *rest.Error) {
+	return h.canOnCluster(ctx, rbacOperationView)
+}
+func (h *rbacHooks) CanRefreshClusterMetadata(ctx context.Context) (bool, *rest.Error) {
+	return h.canOnCluster(ctx, rbacOperationAdmin)
+}
+func (h *rbacHooks) CanRunSelfTest(ctx context.Context) (bool, *rest.Error) {
+	return h.canOnCluster(ctx, rbacOperationAdmin)
+}
+
+// ConsumerGroup Hooks
+func (h *rbacHooks) CanSeeConsumerGroup(ctx context.Context, groupName string) (bool, *rest.Error) {
+	return h.canOnConsumerGroup(ctx, rbacOperationView, groupName)
+}
+func (h *rbacHooks) AllowedConsumerGroupActions(ctx context.Context, groupName string) ([]string, *rest.Error) {
+	isAdmin, restErr := h.canOnConsumerGroup(ctx, rbacOperationAdmin, groupName)
+	if restErr != nil {
+		return nil, restErr
+	}
+	if isAdmin {
+		return []string{"all"}, nil
+	}
+
+	canSee, restErr := h.canOnConsumerGroup(ctx, rbacOperationView, groupName)
+	if restErr != nil {
+		return nil, restErr
+	}
+	if canSee {
+		return []string{"seeConsumerGroup"}, nil
+	}
+	return []string{}, nil
+}
+func (h *rbacHooks) CanEditConsumerGroup(ctx context.Context, groupName string) (bool, *rest.Error) {
+	return h.canOnConsumerGroup(ctx, rbacOperationAdmin, groupName)
+}
+func (h *rbacHooks) CanDeleteConsumerGroup(ctx context.Context, groupName string) (bool, *rest.Error) {
+	return h.canOnConsumerGroup(ctx, rbacOperationAdmin, groupName)
+}
+
+// Schema Registry Hooks
+func (h *rbacHooks) CanEditSchemaSubject(ctx context.Context, _ string) (bool, *rest.Error) {
+	return h.canOnCluster(ctx, rbacOperationAdmin)
+}
+
+// Kafka Connect Hooks
+func (h *rbacHooks) CanViewConnectCluster(ctx context.Context, _ string) (bool, *rest.Error) {
+	return h.canOnCluster(ctx, rbacOperationView)
+}
+func (h *rbacHooks) CanEditConnectCluster(ctx context.Context, _ string) (bool, *rest.Error) {
+	return h.canOnCluster(ctx, rbacOperationAdmin)
+}
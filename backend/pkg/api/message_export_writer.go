@@ -0,0 +1,293 @@
+package api
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloudhut/kowl/backend/pkg/kafka"
+)
+
+// Formats which are supported by the message export
+const (
+	exportFormatNDJSON = "ndjson"
+	exportFormatJSON   = "json"
+	exportFormatCSV    = "csv"
+)
+
+// Columns which can be selected for the message export
+const (
+	exportColumnKey       = "key"
+	exportColumnValue     = "value"
+	exportColumnTimestamp = "timestamp"
+	exportColumnPartition = "partition"
+	exportColumnOffset    = "offset"
+	exportColumnHeaders   = "headers"
+	exportColumnTombstone = "isTombstone"
+)
+
+// defaultExportColumns are the exported columns unless requested otherwise
+var defaultExportColumns = []string{exportColumnPartition, exportColumnOffset, exportColumnTimestamp,
+	exportColumnKey, exportColumnValue, exportColumnHeaders}
+
+// supportedExportColumns are all columns which can be selected. The tombstone column tells null values apart from
+// empty values in CSV exports, in JSON exports null values are exported as null and empty values as empty string.
+var supportedExportColumns = []string{exportColumnPartition, exportColumnOffset, exportColumnTimestamp,
+	exportColumnKey, exportColumnValue, exportColumnHeaders, exportColumnTombstone}
+
+// parseExportColumns parses a comma separated list of columns, an empty string selects the default columns
+func parseExportColumns(columns string) ([]string, error) {
+	if strings.TrimSpace(columns) == "" {
+		return defaultExportColumns, nil
+	}
+
+	isKnown := make(map[string]bool, len(supportedExportColumns))
+	for _, column := range supportedExportColumns {
+		isKnown[column] = true
+	}
+
+	parts := strings.Split(columns, ",")
+	res := make([]string, len(parts))
+	for i, part := range parts {
+		column := strings.TrimSpace(part)
+		if !isKnown[column] {
+			return nil, fmt.Errorf("column '%v' is unknown, supported columns are: %v", column, strings.Join(supportedExportColumns, ", "))
+		}
+		res[i] = column
+	}
+
+	return res, nil
+}
+
+// messageExportWriter writes the exported messages in one of the export formats
+type messageExportWriter interface {
+	WriteMessage(m *kafka.ExportedMessage) error
+
+	// Flush writes buffered data to the underlying writer
+	Flush() error
+
+	// Close finishes the export (e. g. closes the JSON array), it does not close the underlying writer
+	Close() error
+
+	// Abort finishes an export which could not be completed. JSON exports end with an error record, so that a truncated
+	// export can be told apart from a complete one. CSV exports have no room for an error record, the error is only
+	// reported by the exportErrorTrailer.
+	Abort(err error) error
+}
+
+// newMessageExportWriter returns the writer for the given format along with the content type and file extension of
+// the export
+func newMessageExportWriter(format string, columns []string, w io.Writer) (messageExportWriter, string, error) {
+	switch format {
+	case exportFormatNDJSON, "":
+		return &jsonExportWriter{w: w, columns: columns, isArray: false}, "application/x-ndjson", nil
+	case exportFormatJSON:
+		return &jsonExportWriter{w: w, columns: columns, isArray: true}, "application/json", nil
+	case exportFormatCSV:
+		return &csvExportWriter{w: csv.NewWriter(w), columns: columns}, "text/csv", nil
+	default:
+		return nil, "", fmt.Errorf("format '%v' is unknown, supported formats are: %v, %v, %v", format,
+			exportFormatNDJSON, exportFormatJSON, exportFormatCSV)
+	}
+}
+
+// exportedHeader is the representation of a message header in exports
+type exportedHeader struct {
+	Key   string      `json:"key"`
+	Value interface{} `json:"value"`
+}
+
+func exportedHeaders(m *kafka.ExportedMessage) []exportedHeader {
+	headers := make([]exportedHeader, len(m.Headers))
+	for i, header := range m.Headers {
+		headers[i] = exportedHeader{Key: header.Key}
+		// Empty header values are exported as null
+		if len(header.RawValue) > 0 {
+			headers[i].Value = header.Value
+		}
+	}
+	return headers
+}
+
+// jsonExportWriter writes one JSON object per message, either newline delimited or as JSON array
+type jsonExportWriter struct {
+	w         io.Writer
+	columns   []string
+	isArray   bool
+	isStarted bool
+}
+
+func (j *jsonExportWriter) WriteMessage(m *kafka.ExportedMessage) error {
+	var sb strings.Builder
+	switch {
+	case j.isArray && !j.isStarted:
+		sb.WriteString("[\n")
+	case j.isArray:
+		sb.WriteString(",\n")
+	}
+	j.isStarted = true
+
+	sb.WriteString("{")
+	for i, column := range j.columns {
+		value, err := j.columnJSON(m, column)
+		if err != nil {
+			return fmt.Errorf("failed to encode %v of message at offset %v in partition %v: %w", column, m.Offset, m.PartitionID, err)
+		}
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		sb.WriteString(strconv.Quote(column))
+		sb.WriteString(":")
+		sb.Write(value)
+	}
+	sb.WriteString("}")
+	if !j.isArray {
+		sb.WriteString("\n")
+	}
+
+	_, err := io.WriteString(j.w, sb.String())
+	return err
+}
+
+func (j *jsonExportWriter) columnJSON(m *kafka.ExportedMessage, column string) ([]byte, error) {
+	switch column {
+	case exportColumnKey:
+		if m.Key.IsNull() {
+			return []byte("null"), nil
+		}
+		if len(m.Key.NormalizedPayload) == 0 {
+			return []byte(`""`), nil
+		}
+		return m.Key.MarshalJSON()
+	case exportColumnValue:
+		if m.Value.IsNull() {
+			return []byte("null"), nil
+		}
+		if len(m.Value.NormalizedPayload) == 0 {
+			return []byte(`""`), nil
+		}
+		return m.Value.MarshalJSON()
+	case exportColumnTimestamp:
+		return json.Marshal(m.Timestamp.UTC().Format(time.RFC3339Nano))
+	case exportColumnPartition:
+		return json.Marshal(m.PartitionID)
+	case exportColumnOffset:
+		return json.Marshal(m.Offset)
+	case exportColumnHeaders:
+		return json.Marshal(exportedHeaders(m))
+	case exportColumnTombstone:
+		return json.Marshal(m.IsTombstone)
+	default:
+		return nil, fmt.Errorf("unknown column")
+	}
+}
+
+func (j *jsonExportWriter) Flush() error {
+	return nil
+}
+
+func (j *jsonExportWriter) Close() error {
+	if !j.isArray {
+		return nil
+	}
+	if !j.isStarted {
+		_, err := io.WriteString(j.w, "[]\n")
+		return err
+	}
+	_, err := io.WriteString(j.w, "\n]\n")
+	return err
+}
+
+func (j *jsonExportWriter) Abort(err error) error {
+	record, marshalErr := json.Marshal(map[string]string{"error": err.Error()})
+	if marshalErr != nil {
+		return marshalErr
+	}
+
+	var sb strings.Builder
+	switch {
+	case j.isArray && !j.isStarted:
+		sb.WriteString("[\n")
+	case j.isArray:
+		sb.WriteString(",\n")
+	}
+	j.isStarted = true
+	sb.Write(record)
+	sb.WriteString("\n")
+	if j.isArray {
+		sb.WriteString("]\n")
+	}
+
+	_, writeErr := io.WriteString(j.w, sb.String())
+	return writeErr
+}
+
+// csvExportWriter writes a header row with the column names followed by one row per message
+type csvExportWriter struct {
+	w         *csv.Writer
+	columns   []string
+	isStarted bool
+}
+
+func (c *csvExportWriter) writeHeader() error {
+	if c.isStarted {
+		return nil
+	}
+	c.isStarted = true
+	return c.w.Write(c.columns)
+}
+
+func (c *csvExportWriter) WriteMessage(m *kafka.ExportedMessage) error {
+	err := c.writeHeader()
+	if err != nil {
+		return err
+	}
+
+	row := make([]string, len(c.columns))
+	for i, column := range c.columns {
+		switch column {
+		case exportColumnKey:
+			row[i] = m.Key.String()
+		case exportColumnValue:
+			row[i] = m.Value.String()
+		case exportColumnTimestamp:
+			row[i] = m.Timestamp.UTC().Format(time.RFC3339Nano)
+		case exportColumnPartition:
+			row[i] = strconv.Itoa(int(m.PartitionID))
+		case exportColumnOffset:
+			row[i] = strconv.FormatInt(m.Offset, 10)
+		case exportColumnHeaders:
+			// Headers are exported as JSON array, because header keys may be duplicated
+			headers, err := json.Marshal(exportedHeaders(m))
+			if err != nil {
+				return fmt.Errorf("failed to encode headers of message at offset %v in partition %v: %w", m.Offset, m.PartitionID, err)
+			}
+			row[i] = string(headers)
+		case exportColumnTombstone:
+			row[i] = strconv.FormatBool(m.IsTombstone)
+		}
+	}
+
+	return c.w.Write(row)
+}
+
+func (c *csvExportWriter) Flush() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *csvExportWriter) Abort(_ error) error {
+	return c.Close()
+}
+
+func (c *csvExportWriter) Close() error {
+	err := c.writeHeader()
+	if err != nil {
+		return err
+	}
+	return c.Flush()
+}
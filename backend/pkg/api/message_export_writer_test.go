@@ -0,0 +1,79 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/cloudhut/kowl/backend/pkg/kafka"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessageExportWriter(t *testing.T) {
+	timestamp := time.Date(2020, 11, 20, 14, 32, 0, 0, time.UTC)
+	messages := []*kafka.ExportedMessage{
+		{PartitionID: 3, Offset: 0, Timestamp: timestamp, Headers: []kafka.MessageHeader{{Key: "trace-id"}}},
+		{PartitionID: 3, Offset: 1, Timestamp: timestamp, IsTombstone: true},
+	}
+
+	tt := []struct {
+		format   string
+		columns  string
+		expected string
+	}{
+		{exportFormatNDJSON, "offset,partition",
+			`{"offset":0,"partition":3}` + "\n" + `{"offset":1,"partition":3}` + "\n"},
+		{exportFormatJSON, "offset,timestamp",
+			"[\n" + `{"offset":0,"timestamp":"2020-11-20T14:32:00Z"}` + ",\n" +
+				`{"offset":1,"timestamp":"2020-11-20T14:32:00Z"}` + "\n]\n"},
+		{exportFormatCSV, "offset,headers",
+			"offset,headers\n" + `0,"[{""key"":""trace-id"",""value"":null}]"` + "\n" + "1,[]\n"},
+		{exportFormatCSV, "offset,isTombstone", "offset,isTombstone\n0,false\n1,true\n"},
+		{exportFormatNDJSON, "isTombstone", `{"isTombstone":false}` + "\n" + `{"isTombstone":true}` + "\n"},
+	}
+	for _, table := range tt {
+		columns, err := parseExportColumns(table.columns)
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		writer, _, err := newMessageExportWriter(table.format, columns, &buf)
+		require.NoError(t, err)
+		for _, m := range messages {
+			require.NoError(t, writer.WriteMessage(m))
+		}
+		require.NoError(t, writer.Close())
+		assert.Equal(t, table.expected, buf.String(), table.format)
+	}
+
+	// Empty exports are still valid documents
+	var buf bytes.Buffer
+	writer, _, err := newMessageExportWriter(exportFormatJSON, defaultExportColumns, &buf)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+	assert.Equal(t, "[]\n", buf.String())
+
+	// Aborted exports end with an error record and are valid documents as well
+	abortTests := []struct {
+		format   string
+		expected string
+	}{
+		{exportFormatNDJSON, `{"offset":0}` + "\n" + `{"error":"broker is unavailable"}` + "\n"},
+		{exportFormatJSON, "[\n" + `{"offset":0}` + ",\n" + `{"error":"broker is unavailable"}` + "\n]\n"},
+		{exportFormatCSV, "offset\n0\n"},
+	}
+	for _, table := range abortTests {
+		buf.Reset()
+		writer, _, err := newMessageExportWriter(table.format, []string{exportColumnOffset}, &buf)
+		require.NoError(t, err)
+		require.NoError(t, writer.WriteMessage(messages[0]))
+		require.NoError(t, writer.Abort(fmt.Errorf("broker is unavailable")))
+		assert.Equal(t, table.expected, buf.String(), table.format)
+	}
+
+	_, err = parseExportColumns("key,size")
+	assert.Error(t, err)
+	_, _, err = newMessageExportWriter("xml", defaultExportColumns, &buf)
+	assert.Error(t, err)
+}
@@ -6,7 +6,9 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/cloudhut/kowl/backend/pkg/kafka"
 	"github.com/go-chi/chi"
+	chimiddleware "github.com/go-chi/chi/middleware"
 )
 
 func cache(h http.Handler) http.Handler {
@@ -145,3 +147,20 @@ func createSetVersionInfoHeader(version versionInfo) func(next http.Handler) htt
 
 	return m
 }
+
+// setCorrelationID is a middleware which passes the request id on to the Kafka service as correlation id, so that the
+// logs of the Kafka operations can be correlated with the request which has triggered them. The request id is taken
+// from the X-Request-Id request header if present and is returned in the X-Request-Id response header.
+func setCorrelationID(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		requestID := chimiddleware.GetReqID(r.Context())
+		if requestID != "" {
+			w.Header().Set(chimiddleware.RequestIDHeader, requestID)
+			r = r.WithContext(kafka.ContextWithCorrelationID(r.Context(), requestID))
+		}
+
+		next.ServeHTTP(w, r)
+	}
+
+	return http.HandlerFunc(fn)
+}
@@ -0,0 +1,247 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudhut/common/rest"
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+)
+
+// consumeRateLimiter keeps a token bucket for requests and one for consumed bytes per principal. Consumed bytes are
+// only known once a request has finished, hence they are charged afterwards and subsequent requests are rejected
+// until the principal is below its byte limit again. The limiter does not start any go routines, the state of idle
+// principals is discarded lazily.
+type consumeRateLimiter struct {
+	cfg            ConsumeRateLimitConfig
+	trustedProxies []*net.IPNet
+
+	mutex     sync.Mutex
+	limiters  map[string]*principalLimiter
+	lastSweep time.Time
+}
+
+type principalLimiter struct {
+	requests *rate.Limiter
+	bytes    *rate.Limiter
+	lastSeen time.Time
+}
+
+func newConsumeRateLimiter(cfg ConsumeRateLimitConfig) *consumeRateLimiter {
+	trustedProxies, _ := parseTrustedProxies(cfg.TrustedProxies) // Error has been checked in config validation
+	return &consumeRateLimiter{
+		cfg:            cfg,
+		trustedProxies: trustedProxies,
+		limiters:       make(map[string]*principalLimiter),
+		lastSweep:      time.Now(),
+	}
+}
+
+// newLimiter returns a token bucket limiter, or an unlimited limiter if the rate is not set
+func newLimiter(perSecond float64, burst int) *rate.Limiter {
+	if perSecond <= 0 {
+		return rate.NewLimiter(rate.Inf, 0)
+	}
+	return rate.NewLimiter(rate.Limit(perSecond), burst)
+}
+
+// limiter returns the limiter of the given principal and creates it if it doesn't exist yet
+func (l *consumeRateLimiter) limiter(principal string, now time.Time) *principalLimiter {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if now.Sub(l.lastSweep) > l.cfg.IdleTimeout {
+		for key, limiter := range l.limiters {
+			if now.Sub(limiter.lastSeen) > l.cfg.IdleTimeout {
+				delete(l.limiters, key)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	limiter, ok := l.limiters[principal]
+	if !ok {
+		if l.cfg.MaxPrincipals > 0 && len(l.limiters) >= l.cfg.MaxPrincipals {
+			l.evictLeastRecentlySeen()
+		}
+		limit, ok := l.cfg.Principals[principal]
+		if !ok {
+			limit = l.cfg.ConsumeRateLimit
+		}
+		limiter = &principalLimiter{
+			requests: newLimiter(limit.RequestsPerSecond, limit.RequestsBurst),
+			bytes:    newLimiter(limit.BytesPerSecond, limit.BytesBurst),
+		}
+		l.limiters[principal] = limiter
+	}
+	limiter.lastSeen = now
+
+	return limiter
+}
+
+// evictLeastRecentlySeen discards the limiter state of the principal which has been seen least recently. The mutex
+// must be held by the caller.
+func (l *consumeRateLimiter) evictLeastRecentlySeen() {
+	var oldestKey string
+	var oldest time.Time
+	for key, limiter := range l.limiters {
+		if oldestKey == "" || limiter.lastSeen.Before(oldest) {
+			oldestKey, oldest = key, limiter.lastSeen
+		}
+	}
+	delete(l.limiters, oldestKey)
+}
+
+// allow takes a request token and returns true if the principal may send another consume request. Otherwise it
+// returns the duration after which the request may be retried.
+func (l *consumeRateLimiter) allow(principal string) (bool, time.Duration) {
+	now := time.Now()
+	limiter := l.limiter(principal, now)
+
+	// A reservation of 0 bytes is delayed as long as the principal has consumed more than its byte limit allows
+	if delay := limiter.bytes.ReserveN(now, 0).DelayFrom(now); delay > 0 {
+		return false, delay
+	}
+
+	reservation := limiter.requests.ReserveN(now, 1)
+	if delay := reservation.DelayFrom(now); delay > 0 {
+		reservation.CancelAt(now)
+		return false, delay
+	}
+
+	return true, 0
+}
+
+// consumed charges the consumed bytes to the principal's byte limit
+func (l *consumeRateLimiter) consumed(principal string, bytes int64) {
+	now := time.Now()
+	limiter := l.limiter(principal, now)
+	if limiter.bytes.Limit() == rate.Inf || bytes <= 0 {
+		return
+	}
+
+	// Reservations must not exceed the burst, hence larger amounts are charged in chunks
+	burst := int64(limiter.bytes.Burst())
+	for bytes > 0 {
+		n := bytes
+		if n > burst {
+			n = burst
+		}
+		limiter.bytes.ReserveN(now, int(n))
+		bytes -= n
+	}
+}
+
+// rateLimitConsume is a middleware which rejects consume requests of principals which have exceeded their rate limit
+// with 429 Too Many Requests
+func (api *API) rateLimitConsume(next http.Handler) http.Handler {
+	if api.consumeRateLimiter == nil {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal := api.Hooks.Owl.ConsumeRateLimitPrincipal(r)
+		isAllowed, retryAfter := api.consumeRateLimiter.allow(principal)
+		if !isAllowed {
+			retryAfterSeconds := int(math.Ceil(retryAfter.Seconds()))
+			w.Header().Set("Retry-After", strconv.Itoa(retryAfterSeconds))
+			restErr := &rest.Error{
+				Err:      fmt.Errorf("consume rate limit of principal '%v' has been exceeded", principal),
+				Status:   http.StatusTooManyRequests,
+				Message:  fmt.Sprintf("Too many consume requests, please retry in %v seconds", retryAfterSeconds),
+				IsSilent: true,
+			}
+			rest.SendRESTError(w, r, api.Logger.With(zap.String("principal", principal)), restErr)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// chargeConsumedBytes charges the bytes which have been consumed by a request to the requester's byte limit
+func (api *API) chargeConsumedBytes(r *http.Request, bytes int64) {
+	if api.consumeRateLimiter == nil {
+		return
+	}
+	api.consumeRateLimiter.consumed(api.Hooks.Owl.ConsumeRateLimitPrincipal(r), bytes)
+}
+
+// clientAddrKey is the context key of the client address, which identifies unauthenticated rate limit principals
+type clientAddrKey struct{}
+
+// clientAddrFromContext returns the client address which has been stored by rememberClientAddr
+func clientAddrFromContext(ctx context.Context) (string, bool) {
+	addr, ok := ctx.Value(clientAddrKey{}).(string)
+	return addr, ok
+}
+
+// rememberClientAddr is a middleware which stores the client address in the request context. It must run before the
+// RealIP middleware, which replaces the remote address with the forwarded address of any request. Forwarded
+// addresses are only used if the request has been sent by a trusted proxy, so that clients can't pick their
+// principal to evade the rate limit.
+func (api *API) rememberClientAddr(next http.Handler) http.Handler {
+	if api.consumeRateLimiter == nil {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		addr := clientAddr(r, api.consumeRateLimiter.trustedProxies)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), clientAddrKey{}, addr)))
+	})
+}
+
+// clientAddr returns the ip address of the request's peer. If the peer is a trusted proxy, it returns the last
+// forwarded address which has not been added by a trusted proxy instead.
+func clientAddr(r *http.Request, trustedProxies []*net.IPNet) string {
+	addr, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		addr = r.RemoteAddr
+	}
+	if !isTrustedProxy(addr, trustedProxies) {
+		return addr
+	}
+
+	// Each proxy appends the address of its peer, hence the addresses are checked from right to left
+	forwarded := strings.Split(r.Header.Get("X-Forwarded-For"), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		forwardedAddr := strings.TrimSpace(forwarded[i])
+		if forwardedAddr == "" {
+			continue
+		}
+		if net.ParseIP(forwardedAddr) == nil {
+			break
+		}
+		addr = forwardedAddr
+		if !isTrustedProxy(addr, trustedProxies) {
+			return addr
+		}
+	}
+	if realIP := r.Header.Get("X-Real-IP"); net.ParseIP(realIP) != nil && isTrustedProxy(addr, trustedProxies) {
+		return realIP
+	}
+
+	return addr
+}
+
+// isTrustedProxy returns true if the ip address is within one of the trusted proxy ranges
+func isTrustedProxy(addr string, trustedProxies []*net.IPNet) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, network := range trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
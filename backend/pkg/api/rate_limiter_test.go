@@ -0,0 +1,176 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	chimiddleware "github.com/go-chi/chi/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestConsumeRateLimiter(t *testing.T) {
+	limiter := newConsumeRateLimiter(ConsumeRateLimitConfig{
+		Enabled:          true,
+		ConsumeRateLimit: ConsumeRateLimit{RequestsPerSecond: 1, RequestsBurst: 2, BytesPerSecond: 100, BytesBurst: 100},
+		Principals: map[string]ConsumeRateLimit{
+			"admin": {},
+		},
+		IdleTimeout: time.Minute,
+	})
+
+	// The burst allows two requests, the third one has to wait for the next token
+	isAllowed, _ := limiter.allow("alice")
+	assert.True(t, isAllowed)
+	isAllowed, _ = limiter.allow("alice")
+	assert.True(t, isAllowed)
+	isAllowed, retryAfter := limiter.allow("alice")
+	assert.False(t, isAllowed)
+	assert.Greater(t, int64(retryAfter), int64(0))
+	assert.LessOrEqual(t, int64(retryAfter), int64(time.Second))
+
+	// Consuming more bytes than the limit allows rejects further requests until the debt has been paid off
+	limiter.consumed("bob", 350)
+	isAllowed, retryAfter = limiter.allow("bob")
+	assert.False(t, isAllowed)
+	assert.Greater(t, int64(retryAfter), int64(2*time.Second))
+
+	// Principals with their own limit are not affected by the global limit
+	limiter.consumed("admin", 1000)
+	for i := 0; i < 10; i++ {
+		isAllowed, _ = limiter.allow("admin")
+		assert.True(t, isAllowed)
+	}
+}
+
+func TestConsumeRateLimiterEvictsIdlePrincipals(t *testing.T) {
+	limiter := newConsumeRateLimiter(ConsumeRateLimitConfig{
+		Enabled:          true,
+		ConsumeRateLimit: ConsumeRateLimit{RequestsPerSecond: 1, RequestsBurst: 1},
+		IdleTimeout:      time.Minute,
+	})
+
+	now := time.Now()
+	limiter.limiter("alice", now)
+	limiter.limiter("bob", now.Add(30*time.Second))
+	assert.Len(t, limiter.limiters, 2)
+
+	// Alice has been idle for longer than the idle timeout when the next sweep happens
+	limiter.limiter("carol", now.Add(80*time.Second))
+	assert.Len(t, limiter.limiters, 2)
+	assert.NotContains(t, limiter.limiters, "alice")
+	assert.Contains(t, limiter.limiters, "bob")
+}
+
+func TestRateLimitConsumeMiddleware(t *testing.T) {
+	api := &API{
+		Logger: zap.NewNop(),
+		Hooks:  newDefaultHooks(),
+		consumeRateLimiter: newConsumeRateLimiter(ConsumeRateLimitConfig{
+			Enabled:          true,
+			ConsumeRateLimit: ConsumeRateLimit{RequestsPerSecond: 0.5, RequestsBurst: 1},
+			IdleTimeout:      time.Minute,
+		}),
+	}
+	handler := api.rateLimitConsume(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	request := func(remoteAddr string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/api/topics/orders/messages/search", nil)
+		r.RemoteAddr = remoteAddr
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, request("10.0.0.1:5000").Code)
+	rejected := request("10.0.0.1:5001")
+	assert.Equal(t, http.StatusTooManyRequests, rejected.Code)
+	assert.Equal(t, "2", rejected.Header().Get("Retry-After"))
+
+	// Other clients have their own limit
+	assert.Equal(t, http.StatusOK, request("10.0.0.2:5000").Code)
+}
+
+func TestConsumeRateLimiterMaxPrincipals(t *testing.T) {
+	limiter := newConsumeRateLimiter(ConsumeRateLimitConfig{
+		Enabled:          true,
+		ConsumeRateLimit: ConsumeRateLimit{RequestsPerSecond: 1, RequestsBurst: 1},
+		IdleTimeout:      time.Hour,
+		MaxPrincipals:    2,
+	})
+
+	now := time.Now()
+	limiter.limiter("alice", now)
+	limiter.limiter("bob", now.Add(time.Second))
+	limiter.limiter("alice", now.Add(2*time.Second))
+
+	// Bob has been seen least recently
+	limiter.limiter("carol", now.Add(3*time.Second))
+	assert.Len(t, limiter.limiters, 2)
+	assert.NotContains(t, limiter.limiters, "bob")
+}
+
+func TestClientAddr(t *testing.T) {
+	trustedProxies, err := parseTrustedProxies([]string{"10.0.0.0/8", "192.168.1.1"})
+	require.NoError(t, err)
+
+	tt := []struct {
+		name         string
+		remoteAddr   string
+		forwardedFor string
+		realIP       string
+		expectedAddr string
+	}{
+		{"direct request", "203.0.113.7:5000", "", "", "203.0.113.7"},
+		{"untrusted peer", "203.0.113.7:5000", "198.51.100.1", "198.51.100.2", "203.0.113.7"},
+		{"trusted proxy", "10.0.0.1:5000", "198.51.100.1", "", "198.51.100.1"},
+		{"proxy chain", "10.0.0.1:5000", "198.51.100.9, 198.51.100.1, 192.168.1.1", "", "198.51.100.1"},
+		{"real ip of trusted proxy", "192.168.1.1:5000", "", "198.51.100.2", "198.51.100.2"},
+	}
+	for _, table := range tt {
+		r := httptest.NewRequest(http.MethodGet, "/api/topics", nil)
+		r.RemoteAddr = table.remoteAddr
+		if table.forwardedFor != "" {
+			r.Header.Set("X-Forwarded-For", table.forwardedFor)
+		}
+		if table.realIP != "" {
+			r.Header.Set("X-Real-IP", table.realIP)
+		}
+		assert.Equal(t, table.expectedAddr, clientAddr(r, trustedProxies), table.name)
+	}
+
+	_, err = parseTrustedProxies([]string{"proxy.local"})
+	assert.Error(t, err)
+}
+
+func TestConsumeRateLimitPrincipal(t *testing.T) {
+	api := &API{
+		Logger: zap.NewNop(),
+		Hooks:  newDefaultHooks(),
+		consumeRateLimiter: newConsumeRateLimiter(ConsumeRateLimitConfig{
+			Enabled:     true,
+			IdleTimeout: time.Minute,
+		}),
+	}
+	var principal string
+	handler := api.rememberClientAddr(chimiddleware.RealIP(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal = api.Hooks.Owl.ConsumeRateLimitPrincipal(r)
+	})))
+
+	// Forwarded addresses of untrusted peers are ignored, even though the RealIP middleware has used them
+	r := httptest.NewRequest(http.MethodGet, "/api/topics", nil)
+	r.RemoteAddr = "203.0.113.7:5000"
+	r.Header.Set("X-Forwarded-For", "198.51.100.1")
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+	assert.Equal(t, "203.0.113.7", principal)
+
+	// Authenticated users are identified by their name
+	r = r.WithContext(ContextWithUser(r.Context(), User{Name: "alice"}))
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+	assert.Equal(t, "alice", principal)
+}
@@ -0,0 +1,198 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+
+	"github.com/cloudhut/common/rest"
+	"github.com/cloudhut/kowl/backend/pkg/kafka"
+)
+
+// User is the authenticated identity of a requester, which is mapped to roles by the RBAC role bindings
+type User struct {
+	Name   string
+	Groups []string
+}
+
+// userCtxKey is the context key of the authenticated user, see ContextWithUser
+var userCtxKey = &struct{ name string }{"KowlUser"}
+
+// ContextWithUser returns a copy of the context which carries the authenticated user. It must be called by the
+// authentication middleware (e.g. registered via the route hooks) so that the requester can be authorized. The user
+// name is passed on to the Kafka service as well, so that it can be part of the consumers' client id.
+func ContextWithUser(ctx context.Context, user User) context.Context {
+	ctx = kafka.ContextWithUser(ctx, user.Name)
+	return context.WithValue(ctx, userCtxKey, user)
+}
+
+// UserFromContext returns the authenticated user of the request context
+func UserFromContext(ctx context.Context) (User, bool) {
+	user, ok := ctx.Value(userCtxKey).(User)
+	return user, ok
+}
+
+// Kinds of resources on which operations are granted
+const (
+	rbacResourceTopic = iota
+	rbacResourceConsumerGroup
+	rbacResourceCluster
+)
+
+type rbacPermission struct {
+	operations     map[string]bool
+	topics         []*regexp.Regexp
+	consumerGroups []*regexp.Regexp
+	cluster        bool
+}
+
+// grants returns true if the permission grants the operation on the given resource
+func (p *rbacPermission) grants(operation string, resourceType int, resourceName string) bool {
+	if !p.operations[operation] && !p.operations[rbacOperationAdmin] {
+		return false
+	}
+
+	var expressions []*regexp.Regexp
+	switch resourceType {
+	case rbacResourceTopic:
+		expressions = p.topics
+	case rbacResourceConsumerGroup:
+		expressions = p.consumerGroups
+	case rbacResourceCluster:
+		return p.cluster
+	}
+	for _, r := range expressions {
+		if r.MatchString(resourceName) {
+			return true
+		}
+	}
+	return false
+}
+
+// authorizer resolves the roles of users and checks whether they are granted operations on resources
+type authorizer struct {
+	roles    map[string][]rbacPermission
+	bindings []RoleBindingConfig
+}
+
+func newAuthorizer(cfg RBACConfig) (*authorizer, error) {
+	a := &authorizer{roles: make(map[string][]rbacPermission), bindings: cfg.RoleBindings}
+	for _, role := range builtInRoles() {
+		permissions, err := compilePermissions(role)
+		if err != nil {
+			return nil, err
+		}
+		a.roles[role.Name] = permissions
+	}
+
+	for _, role := range cfg.Roles {
+		if role.Name == "" {
+			return nil, fmt.Errorf("role name must not be empty")
+		}
+		if _, exists := a.roles[role.Name]; exists {
+			return nil, fmt.Errorf("role '%v' is defined more than once or is a built-in role", role.Name)
+		}
+		permissions, err := compilePermissions(role)
+		if err != nil {
+			return nil, err
+		}
+		a.roles[role.Name] = permissions
+	}
+
+	for _, binding := range cfg.RoleBindings {
+		if _, exists := a.roles[binding.Role]; !exists {
+			return nil, fmt.Errorf("role binding refers to unknown role '%v'", binding.Role)
+		}
+		if len(binding.Users) == 0 && len(binding.Groups) == 0 {
+			return nil, fmt.Errorf("role binding of role '%v' must contain users or groups", binding.Role)
+		}
+	}
+
+	return a, nil
+}
+
+func compilePermissions(role RoleConfig) ([]rbacPermission, error) {
+	permissions := make([]rbacPermission, len(role.Permissions))
+	for i, cfg := range role.Permissions {
+		err := cfg.validate()
+		if err != nil {
+			return nil, fmt.Errorf("invalid permission of role '%v': %w", role.Name, err)
+		}
+		topics, err := compileRegexps("topics", cfg.Topics)
+		if err != nil {
+			return nil, fmt.Errorf("invalid permission of role '%v': %w", role.Name, err)
+		}
+		consumerGroups, err := compileRegexps("consumerGroups", cfg.ConsumerGroups)
+		if err != nil {
+			return nil, fmt.Errorf("invalid permission of role '%v': %w", role.Name, err)
+		}
+
+		operations := make(map[string]bool, len(cfg.Operations))
+		for _, operation := range cfg.Operations {
+			operations[operation] = true
+		}
+		permissions[i] = rbacPermission{
+			operations:     operations,
+			topics:         topics,
+			consumerGroups: consumerGroups,
+			cluster:        cfg.Cluster,
+		}
+	}
+
+	return permissions, nil
+}
+
+// rolesOf returns the names of all roles which are bound to the user or to one of its groups
+func (a *authorizer) rolesOf(user User) []string {
+	groups := make(map[string]bool, len(user.Groups))
+	for _, group := range user.Groups {
+		groups[group] = true
+	}
+
+	roles := make([]string, 0)
+	for _, binding := range a.bindings {
+		if isBound(binding, user.Name, groups) {
+			roles = append(roles, binding.Role)
+		}
+	}
+
+	return roles
+}
+
+func isBound(binding RoleBindingConfig, userName string, groups map[string]bool) bool {
+	for _, name := range binding.Users {
+		if name == userName {
+			return true
+		}
+	}
+	for _, group := range binding.Groups {
+		if groups[group] {
+			return true
+		}
+	}
+	return false
+}
+
+// isAllowed returns true if the user of the context is granted the operation on the given resource by any of its
+// roles. Requests without an authenticated user are rejected.
+func (a *authorizer) isAllowed(ctx context.Context, operation string, resourceType int, resourceName string) (bool, *rest.Error) {
+	user, ok := UserFromContext(ctx)
+	if !ok {
+		return false, &rest.Error{
+			Err:      fmt.Errorf("request has no authenticated user"),
+			Status:   http.StatusUnauthorized,
+			Message:  "You must be logged in to access this resource",
+			IsSilent: false,
+		}
+	}
+
+	for _, role := range a.rolesOf(user) {
+		for _, permission := range a.roles[role] {
+			if permission.grants(operation, resourceType, resourceName) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,211 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/cloudhut/common/rest"
+	"github.com/cloudhut/kowl/backend/pkg/kafka"
+	"github.com/cloudhut/kowl/backend/pkg/owl"
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func testRBACConfig() RBACConfig {
+	return RBACConfig{
+		Enabled: true,
+		Roles: []RoleConfig{
+			{
+				Name: "team-a",
+				Permissions: []RolePermissionConfig{
+					{Operations: []string{"view", "consume"}, Topics: []string{"team-a\\..*"}},
+					{Operations: []string{"admin"}, ConsumerGroups: []string{"team-a-.*"}},
+				},
+			},
+		},
+		RoleBindings: []RoleBindingConfig{
+			{Role: "admin", Users: []string{"alice"}},
+			{Role: "viewer", Groups: []string{"support"}},
+			{Role: "team-a", Groups: []string{"team-a"}},
+		},
+	}
+}
+
+func TestRBACHooks(t *testing.T) {
+	authorizer, err := newAuthorizer(testRBACConfig())
+	require.NoError(t, err)
+	hooks := newRBACHooks(authorizer)
+
+	admin := ContextWithUser(context.Background(), User{Name: "alice"})
+	viewer := ContextWithUser(context.Background(), User{Name: "bob", Groups: []string{"support"}})
+	teamA := ContextWithUser(context.Background(), User{Name: "carol", Groups: []string{"team-a"}})
+	unbound := ContextWithUser(context.Background(), User{Name: "dave"})
+
+	tests := []struct {
+		name     string
+		hook     func() (bool, *rest.Error)
+		expected bool
+	}{
+		{"admin creates topic", func() (bool, *rest.Error) { return hooks.CanCreateTopic(admin, "orders") }, true},
+		{"admin alters quotas", func() (bool, *rest.Error) { return hooks.CanAlterQuotas(admin) }, true},
+		{"viewer sees topic", func() (bool, *rest.Error) { return hooks.CanSeeTopic(viewer, "orders") }, true},
+		{"viewer consumes", func() (bool, *rest.Error) { return hooks.CanViewTopicMessages(viewer, "orders") }, false},
+		{"viewer lists acls", func() (bool, *rest.Error) { return hooks.CanListACLs(viewer) }, true},
+		{"viewer creates acl", func() (bool, *rest.Error) { return hooks.CanCreateACL(viewer) }, false},
+		{"team consumes own topic", func() (bool, *rest.Error) { return hooks.CanViewTopicMessages(teamA, "team-a.orders") }, true},
+		{"team sees other topic", func() (bool, *rest.Error) { return hooks.CanSeeTopic(teamA, "team-b.orders") }, false},
+		{"team produces", func() (bool, *rest.Error) { return hooks.CanProduceToTopic(teamA, "team-a.orders") }, false},
+		{"team edits own group", func() (bool, *rest.Error) { return hooks.CanEditConsumerGroup(teamA, "team-a-billing") }, true},
+		{"team sees other group", func() (bool, *rest.Error) { return hooks.CanSeeConsumerGroup(teamA, "team-b-billing") }, false},
+		{"team lists quotas", func() (bool, *rest.Error) { return hooks.CanListQuotas(teamA) }, false},
+		{"viewer views cluster", func() (bool, *rest.Error) { return hooks.CanViewCluster(viewer) }, true},
+		{"team views cluster", func() (bool, *rest.Error) { return hooks.CanViewCluster(teamA) }, false},
+		{"team sees topic with own prefix", func() (bool, *rest.Error) { return hooks.CanSeeTopic(teamA, "team-a.orders-internal") }, true},
+		{"team sees topic containing own prefix", func() (bool, *rest.Error) { return hooks.CanSeeTopic(teamA, "x.team-a.orders") }, false},
+		{"unbound user sees topic", func() (bool, *rest.Error) { return hooks.CanSeeTopic(unbound, "orders") }, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			isAllowed, restErr := test.hook()
+			require.Nil(t, restErr)
+			assert.Equal(t, test.expected, isAllowed)
+		})
+	}
+
+	actions, restErr := hooks.AllowedTopicActions(teamA, "team-a.orders")
+	require.Nil(t, restErr)
+	assert.ElementsMatch(t, []string{"seeTopic", "viewPartitions", "viewConsumers", "viewConfig", "viewMessages",
+		"useSearchFilter"}, actions)
+
+	_, restErr = hooks.CanSeeTopic(context.Background(), "orders")
+	require.NotNil(t, restErr)
+	assert.Equal(t, http.StatusUnauthorized, restErr.Status)
+}
+
+func TestRBACConfigValidate(t *testing.T) {
+	cfg := testRBACConfig()
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Roles = append(cfg.Roles, RoleConfig{Name: "viewer"})
+	assert.Error(t, cfg.Validate(), "built-in roles must not be redefined")
+
+	cfg = testRBACConfig()
+	cfg.RoleBindings = append(cfg.RoleBindings, RoleBindingConfig{Role: "operator", Users: []string{"erin"}})
+	assert.Error(t, cfg.Validate(), "bindings must refer to defined roles")
+
+	cfg = testRBACConfig()
+	cfg.Roles[0].Permissions[0].Operations = []string{"delete"}
+	assert.Error(t, cfg.Validate(), "operations must be known")
+
+	cfg = testRBACConfig()
+	cfg.Roles[0].Permissions[0].Topics = []string{"(unclosed"}
+	assert.Error(t, cfg.Validate(), "regexes must compile")
+}
+
+func TestRBACHooks_ClusterReadEndpoints(t *testing.T) {
+	authorizer, err := newAuthorizer(testRBACConfig())
+	require.NoError(t, err)
+	api := &API{Logger: zap.NewNop(), Hooks: &Hooks{Owl: newRBACHooks(authorizer)}}
+	teamA := ContextWithUser(context.Background(), User{Name: "carol", Groups: []string{"team-a"}})
+
+	// The role grants no cluster permission, hence the requests are rejected before the cluster is accessed
+	handlers := map[string]http.HandlerFunc{
+		"api versions":       api.handleGetAPIVersions(),
+		"broker config":      api.handleGetBrokerConfig(),
+		"broker metrics":     api.handleGetBrokerMetrics(),
+		"cluster overview":   api.handleGetClusterOverview(),
+		"describe cluster":   api.handleDescribeCluster(),
+		"schema overview":    api.handleGetSchemaOverview(),
+		"schema details":     api.handleGetSchemaDetails(),
+		"schema subjects":    api.handleGetSchemaRegistrySubjects(),
+		"subject versions":   api.handleGetSchemaRegistrySubjectVersions(),
+		"schema":             api.handleGetSchemaRegistrySchema(),
+		"subject compatible": api.handleGetSubjectCompatibility(),
+	}
+	for name, handler := range handlers {
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest(http.MethodGet, "/", nil).WithContext(teamA))
+		assert.Equal(t, http.StatusForbidden, w.Code, name)
+	}
+}
+
+func TestRBACHooks_TopicDocumentationRequiresVisibleTopic(t *testing.T) {
+	authorizer, err := newAuthorizer(testRBACConfig())
+	require.NoError(t, err)
+	api := &API{Logger: zap.NewNop(), Hooks: &Hooks{Owl: newRBACHooks(authorizer)}}
+	teamA := ContextWithUser(context.Background(), User{Name: "carol", Groups: []string{"team-a"}})
+
+	routeCtx := chi.NewRouteContext()
+	routeCtx.URLParams.Add("topicName", "team-b.orders")
+	ctx := context.WithValue(teamA, chi.RouteCtxKey, routeCtx)
+
+	w := httptest.NewRecorder()
+	api.handleGetTopicDocumentation()(w, httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestRBACHooks_ClusterHealthCountsVisibleTopics(t *testing.T) {
+	broker := sarama.NewMockBroker(t, 1)
+	defer broker.Close()
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).
+			SetController(broker.BrokerID()).
+			SetBroker(broker.Addr(), broker.BrokerID()).
+			SetLeader("team-a.orders", 0, broker.BrokerID()).
+			SetLeader("team-a.orders", 1, broker.BrokerID()).
+			SetLeader("team-b.orders", 0, -1).
+			SetLeader("team-b.payments", 0, -1),
+	})
+	cfg := sarama.NewConfig()
+	cfg.Version = sarama.V1_0_0_0
+	client, err := sarama.NewClient([]string{broker.Addr()}, cfg)
+	require.NoError(t, err)
+	defer client.Close()
+	// Topics are listed from any connected broker
+	_, err = client.Controller()
+	require.NoError(t, err)
+
+	authorizer, err := newAuthorizer(testRBACConfig())
+	require.NoError(t, err)
+	topicFilter, err := newTopicFilter(TopicsConfig{})
+	require.NoError(t, err)
+	kafkaSvc := &kafka.Service{Client: client, Logger: zap.NewNop()}
+	api := &API{
+		Logger:       zap.NewNop(),
+		Hooks:        &Hooks{Owl: newRBACHooks(authorizer)},
+		clusters:     map[string]*cluster{"dev": {Name: "dev", KafkaSvc: kafkaSvc, OwlSvc: owl.NewService(zap.NewNop(), kafkaSvc, nil)}},
+		clusterNames: []string{"dev"},
+		topicFilter:  topicFilter,
+	}
+
+	request := func(user User) owl.ClusterHealth {
+		r := httptest.NewRequest(http.MethodGet, "/api/cluster/health", nil)
+		w := httptest.NewRecorder()
+		api.handleGetClusterHealth()(w, r.WithContext(ContextWithUser(r.Context(), user)))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var health owl.ClusterHealth
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &health))
+		return health
+	}
+
+	// The offline partitions of team b must neither be listed nor counted for team a
+	health := request(User{Name: "carol", Groups: []string{"team-a"}})
+	assert.True(t, health.IsHealthy)
+	assert.Equal(t, 1, health.TopicCount)
+	assert.Equal(t, 2, health.PartitionCount)
+	assert.Equal(t, 0, health.OfflineCount)
+	assert.Empty(t, health.OfflinePartitions)
+
+	health = request(User{Name: "alice"})
+	assert.False(t, health.IsHealthy)
+	assert.Equal(t, 3, health.TopicCount)
+	assert.Equal(t, 4, health.PartitionCount)
+	assert.Equal(t, 2, health.OfflineCount)
+	assert.Len(t, health.OfflinePartitions, 2)
+}
@@ -17,11 +17,13 @@ func (api *API) routes() *chi.Mux {
 	baseRouter.NotFound(rest.HandleNotFound(api.Logger))
 	baseRouter.MethodNotAllowed(rest.HandleMethodNotAllowed(api.Logger))
 
-	instrument := middleware.NewInstrument(api.Cfg.MetricsNamespace)
 	recoverer := middleware.Recoverer{Logger: api.Logger}
 	handleBasePath := createHandleBasePathMiddleware(api.Cfg.REST.BasePath, api.Cfg.REST.SetBasePathFromXForwardedPrefix, api.Cfg.REST.StripPrefix)
 	baseRouter.Use(recoverer.Wrap,
+		api.rememberClientAddr,
 		chimiddleware.RealIP,
+		chimiddleware.RequestID,
+		setCorrelationID,
 		// requirePrefix(api.Cfg.REST.BasePath), // only for debugging
 		handleBasePath,
 		chimiddleware.StripSlashes, // Doesn't really help for the Frontend because the SPA is in charge of it
@@ -37,9 +39,12 @@ func (api *API) routes() *chi.Mux {
 
 		router.Use(
 			middleware.Intercept,
-			instrument.Wrap,
 			// TODO: Add timeout middleware which allows route excludes
 		)
+		if api.Cfg.EnableMetrics {
+			instrument := middleware.NewInstrument(api.Cfg.MetricsNamespace)
+			router.Use(instrument.Wrap)
+		}
 
 		// This should be called here so that you can still add middlewares in the hook function.
 		// Middlewares must be defined before routes.
@@ -47,8 +52,13 @@ func (api *API) routes() *chi.Mux {
 
 		// Private routes - these should only be accessible from within Kubernetes or a protected ingress
 		router.Group(func(r chi.Router) {
-			r.Route("/admin", func(r chi.Router) {
+			if api.Cfg.EnableMetrics {
 				r.Handle("/metrics", promhttp.Handler())
+			}
+			r.Route("/admin", func(r chi.Router) {
+				if api.Cfg.EnableMetrics {
+					r.Handle("/metrics", promhttp.Handler())
+				}
 				r.Handle("/health", api.handleLivenessProbe())
 				r.Handle("/startup", api.handleStartupProbe())
 			})
@@ -57,23 +67,30 @@ func (api *API) routes() *chi.Mux {
 			r.Mount("/debug", chimiddleware.Profiler())
 		})
 
+		// Login routes of the OIDC authentication
+		if api.OIDCSvc.IsEnabled() {
+			router.Route("/auth", func(r chi.Router) {
+				r.Get("/login", api.handleLogin())
+				r.Get("/callback", api.handleLoginCallback())
+				r.Get("/logout", api.handleLogout())
+			})
+		}
+
 		// API routes
 		router.Group(func(r chi.Router) {
 			r.Use(createSetVersionInfoHeader(api.version))
+			if api.OIDCSvc.IsEnabled() {
+				r.Use(api.authenticate)
+			}
 			api.Hooks.Route.ConfigAPIRouter(r)
 
 			r.Route("/api", func(r chi.Router) {
-				r.Get("/cluster/config", api.handleClusterConfig())
-				r.Get("/cluster", api.handleDescribeCluster())
-				r.Get("/topics", api.handleGetTopics())
-				r.Get("/acls", api.handleGetACLsOverview())
-				r.Get("/topics/{topicName}/partitions", api.handleGetPartitions())
-				r.Get("/topics/{topicName}/configuration", api.handleGetTopicConfig())
-				r.Get("/topics/{topicName}/consumers", api.handleGetTopicConsumers())
-				r.Get("/topics/{topicName}/documentation", api.handleGetTopicDocumentation())
-				r.Get("/consumer-groups", api.handleGetConsumerGroups())
-				r.Get("/schemas", api.handleGetSchemaOverview())
-				r.Get("/schemas/subjects/{subject}/versions/{version}", api.handleGetSchemaDetails())
+				api.configAPIRoutes(r)
+				r.Get("/clusters", api.handleGetClusters())
+				r.Route("/clusters/{clusterName}", func(r chi.Router) {
+					r.Use(api.selectCluster)
+					api.configAPIRoutes(r)
+				})
 			})
 		})
 
@@ -99,10 +116,87 @@ func (api *API) routes() *chi.Mux {
 
 	// Websockets live in it's own group because not all middlewares support websockets
 	baseRouter.Group(func(wsRouter chi.Router) {
+		if api.OIDCSvc.IsEnabled() {
+			wsRouter.Use(api.authenticate)
+		}
 		api.Hooks.Route.ConfigWsRouter(wsRouter)
 
-		wsRouter.Get("/api/topics/{topicName}/messages", api.handleGetMessages())
+		api.configWsRoutes(wsRouter, "/api")
+		api.configWsRoutes(wsRouter.With(api.selectCluster), "/api/clusters/{clusterName}")
 	})
 
 	return baseRouter
 }
+
+// configAPIRoutes registers the routes of the REST API, which are served for the default cluster at /api and for
+// all clusters at /api/clusters/{clusterName}
+func (api *API) configAPIRoutes(r chi.Router) {
+	r.Get("/users/me", api.handleGetCurrentUser())
+	r.Get("/cluster/config", api.handleClusterConfig())
+	r.Get("/cluster", api.handleDescribeCluster())
+	r.Get("/cluster/health", api.handleGetClusterHealth())
+	r.Get("/cluster/overview", api.handleGetClusterOverview())
+	r.Get("/cluster/api-versions", api.handleGetAPIVersions())
+	r.Get("/cluster/brokers/{brokerID}/config", api.handleGetBrokerConfig())
+	r.Get("/cluster/brokers/{brokerID}/metrics", api.handleGetBrokerMetrics())
+	r.Delete("/cluster/topic-metadata-cache", api.handleInvalidateTopicMetadataCache())
+	r.Post("/cluster/refresh", api.handleRefreshClusterMetadata())
+	r.With(api.audit("cluster.selfTest")).Post("/admin/self-test", api.handleSelfTest())
+	r.Get("/operations/reassign-partitions", api.handleGetPartitionReassignments())
+	r.With(api.audit("partitions.reassign")).Post("/operations/reassign-partitions", api.handleReassignPartitions())
+	r.Get("/topics", api.handleGetTopics())
+	r.With(api.audit("topic.create")).Post("/topics", api.handleCreateTopic())
+	r.Get("/acls", api.handleGetACLsOverview())
+	r.With(api.audit("acl.create")).Post("/acls", api.handleCreateACL())
+	r.With(api.audit("acl.delete")).Delete("/acls", api.handleDeleteACLs())
+	r.Get("/quotas", api.handleGetQuotas())
+	r.With(api.audit("quota.alter")).Patch("/quotas", api.handlePatchQuotas())
+	// Requests of topics which are not allowed by the topics config are rejected
+	r.Group(func(r chi.Router) {
+		r.Use(api.restrictTopicAccess)
+		r.Get("/topics/{topicName}/partitions", api.handleGetPartitions())
+		r.With(api.audit("topic.increasePartitions")).Post("/topics/{topicName}/partitions", api.handleIncreasePartitions())
+		r.Get("/topics/{topicName}/size", api.handleGetTopicSize())
+		r.Get("/topics/{topicName}/offsets", api.handleGetTopicOffsets())
+		r.With(api.rateLimitConsume).Get("/topics/{topicName}/size-distribution", api.handleGetTopicSizeDistribution())
+		r.Get("/topics/{topicName}/configuration", api.handleGetTopicConfig())
+		r.Get("/topics/{topicName}/config", api.handleGetTopicConfig())
+		r.With(api.audit("topic.alterConfig")).Patch("/topics/{topicName}/config", api.handlePatchTopicConfig())
+		r.With(api.rateLimitConsume).Post("/topics/{topicName}/messages/search", api.handleSearchMessages())
+		r.With(api.rateLimitConsume).Get("/topics/{topicName}/messages/export", api.handleExportMessages())
+		r.With(api.audit("topic.produce")).Post("/topics/{topicName}/messages", api.handleProduceMessage())
+		r.With(api.audit("topic.importMessages")).Post("/topics/{topicName}/messages/import", api.handleImportMessages())
+		r.With(api.audit("topic.deleteRecords")).Delete("/topics/{topicName}/records", api.handleDeleteTopicRecords())
+		r.Get("/topics/{topicName}/consumers", api.handleGetTopicConsumers())
+		r.Get("/topics/{topicName}/documentation", api.handleGetTopicDocumentation())
+	})
+	r.Get("/consumer-groups", api.handleGetConsumerGroups())
+	r.Get("/consumer-groups/{groupId}/lag", api.handleGetConsumerGroupLag())
+	r.Get("/consumer-groups/{groupId}/members", api.handleGetConsumerGroupMembers())
+	r.With(api.audit("consumerGroup.deleteMany")).Delete("/consumer-groups", api.handleDeleteConsumerGroups())
+	r.With(api.audit("consumerGroup.delete")).Delete("/consumer-groups/{groupId}", api.handleDeleteConsumerGroup())
+	r.With(api.audit("consumerGroup.resetOffsets")).Post("/consumer-groups/{groupId}/reset-offsets", api.handleResetConsumerGroupOffsets())
+	r.With(api.audit("consumerGroup.copyOffsets")).Post("/consumer-groups/{groupId}/copy-offsets", api.handleCopyConsumerGroupOffsets())
+	r.Get("/schemas", api.handleGetSchemaOverview())
+	r.Get("/schemas/subjects/{subject}/versions/{version}", api.handleGetSchemaDetails())
+	r.Get("/schema-registry/subjects", api.handleGetSchemaRegistrySubjects())
+	r.Get("/schema-registry/subjects/{subject}/versions", api.handleGetSchemaRegistrySubjectVersions())
+	r.Get("/schema-registry/subjects/{subject}/versions/{version}", api.handleGetSchemaRegistrySchema())
+	r.Post("/schema-registry/subjects/{subject}/compatibility", api.handleCheckSchemaCompatibility())
+	r.Get("/schema-registry/subjects/{subject}/config", api.handleGetSubjectCompatibility())
+	r.With(api.audit("schemaRegistry.setCompatibility")).Put("/schema-registry/subjects/{subject}/config", api.handleSetSubjectCompatibility())
+	r.Get("/connect/connectors", api.handleGetConnectors())
+	r.Get("/connect/clusters/{connectClusterName}/connectors/{connector}", api.handleGetConnector())
+	r.With(api.audit("connector.restart")).Post("/connect/clusters/{connectClusterName}/connectors/{connector}/restart", api.handleRestartConnector())
+	r.With(api.audit("connector.restartTask")).Post("/connect/clusters/{connectClusterName}/connectors/{connector}/tasks/{taskID}/restart", api.handleRestartConnectorTask())
+	r.With(api.audit("connector.pause")).Put("/connect/clusters/{connectClusterName}/connectors/{connector}/pause", api.handlePauseConnector())
+	r.With(api.audit("connector.resume")).Put("/connect/clusters/{connectClusterName}/connectors/{connector}/resume", api.handleResumeConnector())
+}
+
+// configWsRoutes registers the websocket routes with the given path prefix, they are served for the default cluster
+// at /api and for all clusters at /api/clusters/{clusterName}
+func (api *API) configWsRoutes(r chi.Router, prefix string) {
+	r = r.With(api.restrictTopicAccess, api.rateLimitConsume)
+	r.Get(prefix+"/topics/{topicName}/messages", api.handleGetMessages())
+	r.Get(prefix+"/topics/{topicName}/messages/tail", api.handleTailMessages())
+}
@@ -0,0 +1,181 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/cloudhut/common/rest"
+	"github.com/cloudhut/kowl/backend/pkg/owl"
+	"github.com/go-chi/chi"
+	"go.uber.org/zap"
+)
+
+// internalTopicPrefix is the name prefix of internal topics such as __consumer_offsets or _schemas
+const internalTopicPrefix = "_"
+
+// Display names of ACL resources and the protocol type of consumer groups, which are used to filter topics from the
+// responses
+const (
+	aclResourceTypeTopic    = "TOPIC"
+	aclWildcardResourceName = "*"
+	consumerProtocolType    = "consumer"
+)
+
+// topicFilter decides which topics are served according to the configured allow and deny lists
+type topicFilter struct {
+	allowList []*regexp.Regexp
+	denyList  []*regexp.Regexp
+}
+
+func newTopicFilter(cfg TopicsConfig) (*topicFilter, error) {
+	allowList, err := compileRegexps("allowList", cfg.AllowList)
+	if err != nil {
+		return nil, err
+	}
+	denyList, err := compileRegexps("denyList", cfg.DenyList)
+	if err != nil {
+		return nil, err
+	}
+
+	return &topicFilter{allowList: allowList, denyList: denyList}, nil
+}
+
+// isAllowed returns true if the topic may be listed and accessed
+func (f *topicFilter) isAllowed(topicName string) bool {
+	for _, r := range f.denyList {
+		if r.MatchString(topicName) {
+			return false
+		}
+	}
+
+	isExplicitlyAllowed := false
+	for _, r := range f.allowList {
+		if r.MatchString(topicName) {
+			isExplicitlyAllowed = true
+			break
+		}
+	}
+	if isExplicitlyAllowed {
+		return true
+	}
+
+	// Internal topics must always be allowed explicitly
+	if strings.HasPrefix(topicName, internalTopicPrefix) {
+		return false
+	}
+	return len(f.allowList) == 0
+}
+
+// filterAssignments returns the member assignments of allowed topics
+func (f *topicFilter) filterAssignments(assignments []*owl.GroupMemberAssignment) []*owl.GroupMemberAssignment {
+	allowed := make([]*owl.GroupMemberAssignment, 0, len(assignments))
+	for _, assignment := range assignments {
+		if f.isAllowed(assignment.TopicName) {
+			allowed = append(allowed, assignment)
+		}
+	}
+	return allowed
+}
+
+// filterGroupOverview removes the assignments and lags of topics which are not allowed from the group overview
+func (f *topicFilter) filterGroupOverview(group *owl.ConsumerGroupOverview) {
+	for _, member := range group.Members {
+		member.Assignments = f.filterAssignments(member.Assignments)
+	}
+	if group.Lags != nil {
+		lags := make([]*owl.TopicLag, 0, len(group.Lags.TopicLags))
+		for _, lag := range group.Lags.TopicLags {
+			if f.isAllowed(lag.Topic) {
+				lags = append(lags, lag)
+			}
+		}
+		group.Lags.TopicLags = lags
+	}
+}
+
+// filterGroupLagDetails removes the topics which are not allowed from the group lag, the total lag only sums the
+// allowed topics
+func (f *topicFilter) filterGroupLagDetails(lag *owl.ConsumerGroupLagDetails) {
+	topics := make([]*owl.TopicLagDetails, 0, len(lag.Topics))
+	lag.TotalLag = 0
+	for _, topic := range lag.Topics {
+		if f.isAllowed(topic.TopicName) {
+			topics = append(topics, topic)
+			lag.TotalLag += topic.SummedLag
+		}
+	}
+	lag.Topics = topics
+}
+
+// filterGroupMembers removes the subscriptions and assignments of topics which are not allowed from the members. The
+// raw metadata and assignment of consumer members whose data could not be decoded are removed as well, since they
+// contain the topic names.
+func (f *topicFilter) filterGroupMembers(members *owl.ConsumerGroupMembers) {
+	for _, member := range members.Members {
+		subscribed := make([]string, 0, len(member.SubscribedTopics))
+		for _, topicName := range member.SubscribedTopics {
+			if f.isAllowed(topicName) {
+				subscribed = append(subscribed, topicName)
+			}
+		}
+		member.SubscribedTopics = subscribed
+		member.Assignments = f.filterAssignments(member.Assignments)
+		if members.ProtocolType == consumerProtocolType {
+			member.RawMetadata = nil
+			member.RawAssignment = nil
+		}
+	}
+}
+
+// filterAclResources removes the ACLs of topics which are not allowed. Prefixed resources are removed if the prefix
+// itself is not allowed, the wildcard resource (*) is always kept.
+func (f *topicFilter) filterAclResources(resources []*owl.AclResource) []*owl.AclResource {
+	allowed := make([]*owl.AclResource, 0, len(resources))
+	for _, resource := range resources {
+		if resource.ResourceType == aclResourceTypeTopic && resource.ResourceName != aclWildcardResourceName &&
+			!f.isAllowed(resource.ResourceName) {
+			continue
+		}
+		allowed = append(allowed, resource)
+	}
+	return allowed
+}
+
+// filterReassignments removes the reassignments of topics which are not allowed
+func (f *topicFilter) filterReassignments(reassignments *owl.PartitionReassignments) {
+	partitions := make([]owl.PartitionReassignmentStatus, 0, len(reassignments.Partitions))
+	for _, partition := range reassignments.Partitions {
+		if f.isAllowed(partition.TopicName) {
+			partitions = append(partitions, partition)
+		}
+	}
+	reassignments.Partitions = partitions
+	reassignments.RemainingPartitions = len(partitions)
+}
+
+// newTopicNotAllowedError returns the error which is sent for requests of topics which are not allowed by the topics
+// config. It doesn't reveal whether the topic exists.
+func newTopicNotAllowedError(topicName string) *rest.Error {
+	return &rest.Error{
+		Err:      fmt.Errorf("topic '%v' is not allowed by the topics config", topicName),
+		Status:   http.StatusForbidden,
+		Message:  "This topic is not served by this Kowl instance",
+		IsSilent: false,
+	}
+}
+
+// restrictTopicAccess is a middleware which rejects requests of topics (identified by the topicName url parameter)
+// which are not allowed by the topics config
+func (api *API) restrictTopicAccess(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		topicName := chi.URLParam(r, "topicName")
+		if !api.topicFilter.isAllowed(topicName) {
+			rest.SendRESTError(w, r, api.Logger.With(zap.String("topic_name", topicName)), newTopicNotAllowedError(topicName))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,157 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cloudhut/kowl/backend/pkg/owl"
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestTopicFilter(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     TopicsConfig
+		allowed []string
+		denied  []string
+	}{
+		{
+			name:    "defaults hide internal topics",
+			cfg:     TopicsConfig{},
+			allowed: []string{"orders", "payments_v2"},
+			denied:  []string{"__consumer_offsets", "_schemas"},
+		},
+		{
+			name:    "allow list",
+			cfg:     TopicsConfig{AllowList: []string{"team-a\\..*", "_schemas"}},
+			allowed: []string{"team-a.orders", "_schemas"},
+			denied:  []string{"team-b.orders", "__consumer_offsets", "_schemas-backup"},
+		},
+		{
+			name:    "deny list",
+			cfg:     TopicsConfig{DenyList: []string{".*\\.internal"}},
+			allowed: []string{"orders"},
+			denied:  []string{"orders.internal", "__transaction_state"},
+		},
+		{
+			name:    "deny list takes precedence",
+			cfg:     TopicsConfig{AllowList: []string{"team-a\\..*"}, DenyList: []string{".*secret.*"}},
+			allowed: []string{"team-a.orders"},
+			denied:  []string{"team-a.secrets"},
+		},
+		{
+			name:    "expressions match the whole name",
+			cfg:     TopicsConfig{AllowList: []string{"orders"}, DenyList: []string{"secret"}},
+			allowed: []string{"orders"},
+			denied:  []string{"_confluent-orders-changelog", "orders-v2"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			filter, err := newTopicFilter(test.cfg)
+			require.NoError(t, err)
+			for _, topicName := range test.allowed {
+				assert.True(t, filter.isAllowed(topicName), topicName)
+			}
+			for _, topicName := range test.denied {
+				assert.False(t, filter.isAllowed(topicName), topicName)
+			}
+		})
+	}
+}
+
+func TestTopicsConfigValidate(t *testing.T) {
+	cfg := TopicsConfig{AllowList: []string{"^orders$"}, DenyList: []string{"(unclosed"}}
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "denyList")
+
+	cfg.DenyList = []string{"^orders-dlq$"}
+	assert.NoError(t, cfg.Validate())
+}
+
+func TestRestrictTopicAccessMiddleware(t *testing.T) {
+	filter, err := newTopicFilter(TopicsConfig{DenyList: []string{"secret.*"}})
+	require.NoError(t, err)
+	api := &API{Logger: zap.NewNop(), topicFilter: filter}
+
+	router := chi.NewRouter()
+	router.With(api.restrictTopicAccess).Get("/api/topics/{topicName}/offsets", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	request := func(path string) int {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, request("/api/topics/orders/offsets"))
+	assert.Equal(t, http.StatusForbidden, request("/api/topics/secret-orders/offsets"))
+	assert.Equal(t, http.StatusForbidden, request("/api/topics/__consumer_offsets/offsets"))
+}
+
+func TestTopicFilter_FilterResponses(t *testing.T) {
+	filter, err := newTopicFilter(TopicsConfig{AllowList: []string{"team-a\\..*"}})
+	require.NoError(t, err)
+
+	group := &owl.ConsumerGroupOverview{
+		Members: []*owl.GroupMemberDescription{{Assignments: []*owl.GroupMemberAssignment{
+			{TopicName: "team-a.orders"}, {TopicName: "team-b.payments"},
+		}}},
+		Lags: &owl.ConsumerGroupLag{TopicLags: []*owl.TopicLag{{Topic: "team-b.payments"}}},
+	}
+	filter.filterGroupOverview(group)
+	require.Len(t, group.Members[0].Assignments, 1)
+	assert.Equal(t, "team-a.orders", group.Members[0].Assignments[0].TopicName)
+	assert.Empty(t, group.Lags.TopicLags)
+
+	lag := &owl.ConsumerGroupLagDetails{TotalLag: 15, Topics: []*owl.TopicLagDetails{
+		{TopicName: "team-a.orders", SummedLag: 5}, {TopicName: "team-b.payments", SummedLag: 10},
+	}}
+	filter.filterGroupLagDetails(lag)
+	require.Len(t, lag.Topics, 1)
+	assert.Equal(t, int64(5), lag.TotalLag)
+
+	members := &owl.ConsumerGroupMembers{ProtocolType: "consumer", Members: []*owl.ConsumerGroupMember{{
+		SubscribedTopics: []string{"team-a.orders", "team-b.payments"},
+		RawMetadata:      []byte("team-b.payments"),
+	}}}
+	filter.filterGroupMembers(members)
+	assert.Equal(t, []string{"team-a.orders"}, members.Members[0].SubscribedTopics)
+	assert.Nil(t, members.Members[0].RawMetadata)
+
+	acls := filter.filterAclResources([]*owl.AclResource{
+		{ResourceType: "TOPIC", ResourceName: "team-a.orders"},
+		{ResourceType: "TOPIC", ResourceName: "team-b.", ResourcePatternType: "PREFIXED"},
+		{ResourceType: "TOPIC", ResourceName: "*"},
+		{ResourceType: "GROUP", ResourceName: "team-b.consumers"},
+	})
+	require.Len(t, acls, 3)
+	assert.Equal(t, "team-a.orders", acls[0].ResourceName)
+	assert.Equal(t, "*", acls[1].ResourceName)
+
+	reassignments := &owl.PartitionReassignments{RemainingPartitions: 2, Partitions: []owl.PartitionReassignmentStatus{
+		{TopicName: "team-a.orders"}, {TopicName: "team-b.payments"},
+	}}
+	filter.filterReassignments(reassignments)
+	assert.Equal(t, 1, reassignments.RemainingPartitions)
+	require.Len(t, reassignments.Partitions, 1)
+}
+
+func TestTopicFilter_CreateACL(t *testing.T) {
+	filter, err := newTopicFilter(TopicsConfig{DenyList: []string{"secret.*"}})
+	require.NoError(t, err)
+	api := &API{Cfg: &Config{EnableTopicOperations: true}, Logger: zap.NewNop(), Hooks: newDefaultHooks(), topicFilter: filter}
+
+	body := `{"resourceType":"TOPIC","resourceName":"secret-orders","principal":"User:alice","operation":"READ","permissionType":"ALLOW"}`
+	w := httptest.NewRecorder()
+	api.handleCreateACL()(w, httptest.NewRequest(http.MethodPost, "/api/acls", strings.NewReader(body)))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
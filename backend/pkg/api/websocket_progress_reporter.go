@@ -6,6 +6,7 @@ import (
 	"github.com/cloudhut/kowl/backend/pkg/owl"
 	"go.uber.org/zap"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -24,7 +25,7 @@ type progressReporter struct {
 func (p *progressReporter) Start() {
 	// If search is disabled do not report progress regularly as each consumed message will be sent through the socket
 	// anyways
-	if p.request.FilterInterpreterCode == "" {
+	if p.request.FilterInterpreterCode == "" && p.request.HeaderFilter.IsEmpty() {
 		return
 	}
 
@@ -77,7 +78,7 @@ func (p *progressReporter) OnMessage(message *kafka.TopicMessage) {
 	}{"message", message})
 }
 
-func (p *progressReporter) OnComplete(elapsedMs int64, isCancelled bool) {
+func (p *progressReporter) OnComplete(elapsedMs int64, isCancelled bool, isPartial bool, offsetsReached map[int32]int64) {
 	p.statsMutex.RLock()
 	defer p.statsMutex.RUnlock()
 
@@ -87,7 +88,12 @@ func (p *progressReporter) OnComplete(elapsedMs int64, isCancelled bool) {
 		IsCancelled      bool   `json:"isCancelled"`
 		MessagesConsumed int64  `json:"messagesConsumed"`
 		BytesConsumed    int64  `json:"bytesConsumed"`
-	}{"done", elapsedMs, isCancelled, p.messagesConsumed, p.bytesConsumed})
+
+		// IsPartial is set if the max duration has been exceeded, OffsetsReached contains the next offset to consume
+		// of each partition in that case
+		IsPartial      bool            `json:"partial"`
+		OffsetsReached map[int32]int64 `json:"offsetsReached,omitempty"`
+	}{"done", elapsedMs, isCancelled, p.messagesConsumed, p.bytesConsumed, isPartial, offsetsReached})
 }
 
 func (p *progressReporter) OnError(message string) {
@@ -96,3 +102,31 @@ func (p *progressReporter) OnError(message string) {
 		Message string `json:"message"`
 	}{"error", message})
 }
+
+// tailProgressReporter sends the messages of a live tail to the frontend
+type tailProgressReporter struct {
+	websocket     *websocketClient
+	bytesConsumed int64
+}
+
+func (p *tailProgressReporter) OnMessage(message *kafka.TopicMessage) error {
+	atomic.AddInt64(&p.bytesConsumed, int64(message.Size))
+	return p.websocket.writeJSON(struct {
+		Type    string              `json:"type"`
+		Message *kafka.TopicMessage `json:"message"`
+	}{"message", message})
+}
+
+func (p *tailProgressReporter) OnMessagesDropped(count int64) {
+	_ = p.websocket.writeJSON(struct {
+		Type  string `json:"type"`
+		Count int64  `json:"count"`
+	}{"messagesDropped", count})
+}
+
+func (p *tailProgressReporter) OnError(message string) {
+	_ = p.websocket.writeJSON(struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	}{"error", message})
+}
@@ -0,0 +1,65 @@
+package audit
+
+import (
+	"fmt"
+)
+
+// Sinks to which the audit log can be written
+const (
+	SinkStdout = "stdout"
+	SinkFile   = "file"
+	SinkKafka  = "kafka"
+)
+
+// Config for the audit log of mutating operations
+type Config struct {
+	Enabled bool `yaml:"enabled"`
+
+	// Sink is either stdout, file or kafka. Entries are written as JSON, one entry per line or message.
+	Sink  string          `yaml:"sink"`
+	File  FileSinkConfig  `yaml:"file"`
+	Kafka KafkaSinkConfig `yaml:"kafka"`
+
+	// RedactFields are (case insensitive) substrings of request parameter names whose values are not written to the
+	// audit log, e.g. the passwords of SCRAM credentials
+	RedactFields []string `yaml:"redactFields"`
+}
+
+// FileSinkConfig is the file the audit log is appended to
+type FileSinkConfig struct {
+	Path string `yaml:"path"`
+}
+
+// KafkaSinkConfig is the topic of the (default) Kafka cluster the audit log is produced to
+type KafkaSinkConfig struct {
+	Topic string `yaml:"topic"`
+}
+
+// SetDefaults for the audit config
+func (c *Config) SetDefaults() {
+	c.Sink = SinkStdout
+	c.RedactFields = []string{"password", "secret", "token", "credential", "apikey"}
+}
+
+// Validate the audit config
+func (c *Config) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+
+	switch c.Sink {
+	case SinkStdout:
+	case SinkFile:
+		if c.File.Path == "" {
+			return fmt.Errorf("audit log sink is file but no file path is configured")
+		}
+	case SinkKafka:
+		if c.Kafka.Topic == "" {
+			return fmt.Errorf("audit log sink is kafka but no topic is configured")
+		}
+	default:
+		return fmt.Errorf("unknown audit log sink '%v', must be one of stdout, file or kafka", c.Sink)
+	}
+
+	return nil
+}
@@ -0,0 +1,78 @@
+package audit
+
+import (
+	"strings"
+	"time"
+)
+
+// maxParameterLength is the max length of a string parameter in the audit log, longer values such as produced
+// message payloads are truncated
+const maxParameterLength = 1024
+
+// redactedValue replaces the values of redacted parameters
+const redactedValue = "[REDACTED]"
+
+// Entry is a single record of the audit log
+type Entry struct {
+	Timestamp  time.Time `json:"timestamp"`
+	Action     string    `json:"action"`
+	Principal  string    `json:"principal"`
+	RemoteAddr string    `json:"remoteAddr"`
+	Cluster    string    `json:"cluster"`
+
+	// Resource identifies the target of the operation, e.g. the topic name or the consumer group id
+	Resource map[string]string `json:"resource"`
+
+	// Parameters are the (redacted) request parameters, e.g. the decoded JSON body
+	Parameters interface{} `json:"parameters,omitempty"`
+
+	Result Result `json:"result"`
+}
+
+// Result of an audited operation. Error is the error message which has been returned to the requester.
+type Result struct {
+	Success    bool   `json:"success"`
+	StatusCode int    `json:"statusCode"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"durationMs"`
+}
+
+// Redact replaces the values of all parameters whose names contain one of the redacted fields (case insensitive)
+// and truncates long strings. Maps and lists are redacted recursively, the given value is not modified.
+func Redact(value interface{}, redactFields []string) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		redacted := make(map[string]interface{}, len(v))
+		for name, nested := range v {
+			if isRedactedField(name, redactFields) {
+				redacted[name] = redactedValue
+				continue
+			}
+			redacted[name] = Redact(nested, redactFields)
+		}
+		return redacted
+	case []interface{}:
+		redacted := make([]interface{}, len(v))
+		for i, nested := range v {
+			redacted[i] = Redact(nested, redactFields)
+		}
+		return redacted
+	case string:
+		if len(v) > maxParameterLength {
+			return v[:maxParameterLength] + "...(truncated)"
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+func isRedactedField(name string, redactFields []string) bool {
+	name = strings.ToLower(name)
+	for _, field := range redactFields {
+		if strings.Contains(name, strings.ToLower(field)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,51 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+
+	"go.uber.org/zap"
+)
+
+// Sink persists audit log entries
+type Sink interface {
+	Write(ctx context.Context, entry []byte) error
+}
+
+// Logger writes audit log entries to the configured sink. Entries which can't be written to the sink are written to
+// the application log instead, so that no entry is lost without notice.
+type Logger struct {
+	cfg    Config
+	sink   Sink
+	logger *zap.Logger
+}
+
+// NewLogger creates an audit logger which writes to the given sink, see NewStdoutSink, NewFileSink and NewKafkaSink
+func NewLogger(cfg Config, sink Sink, logger *zap.Logger) *Logger {
+	return &Logger{
+		cfg:    cfg,
+		sink:   sink,
+		logger: logger.With(zap.String("source", "audit_log")),
+	}
+}
+
+// RedactFields returns the configured names of redacted parameters
+func (l *Logger) RedactFields() []string {
+	return l.cfg.RedactFields
+}
+
+// Log writes the entry to the sink
+func (l *Logger) Log(ctx context.Context, entry *Entry) {
+	encoded, err := json.Marshal(entry)
+	if err != nil {
+		l.logger.Error("failed to encode audit log entry", zap.String("action", entry.Action),
+			zap.String("principal", entry.Principal), zap.Error(err))
+		return
+	}
+
+	err = l.sink.Write(ctx, encoded)
+	if err != nil {
+		l.logger.Error("failed to write audit log entry to the sink, the entry is logged here instead",
+			zap.String("sink", l.cfg.Sink), zap.ByteString("entry", encoded), zap.Error(err))
+	}
+}
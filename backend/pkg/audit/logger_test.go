@@ -0,0 +1,79 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+type memorySink struct {
+	entries [][]byte
+	err     error
+}
+
+func (s *memorySink) Write(_ context.Context, entry []byte) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.entries = append(s.entries, entry)
+	return nil
+}
+
+func TestRedact(t *testing.T) {
+	params := map[string]interface{}{
+		"topicName": "orders",
+		"configs": []interface{}{
+			map[string]interface{}{"name": "retention.ms", "value": "1000"},
+		},
+		"credentials":  map[string]interface{}{"username": "kowl", "password": "secret"},
+		"saslPassword": "secret",
+		"value":        string(make([]byte, 2000)),
+	}
+
+	redacted := Redact(params, []string{"password", "credential"}).(map[string]interface{})
+	assert.Equal(t, "orders", redacted["topicName"])
+	assert.Equal(t, params["configs"], redacted["configs"])
+	assert.Equal(t, redactedValue, redacted["credentials"])
+	assert.Equal(t, redactedValue, redacted["saslPassword"])
+	assert.Len(t, redacted["value"], maxParameterLength+len("...(truncated)"))
+
+	// The original parameters must not be modified
+	assert.Equal(t, "secret", params["saslPassword"])
+}
+
+func TestLogger_Log(t *testing.T) {
+	cfg := Config{}
+	cfg.SetDefaults()
+	cfg.Enabled = true
+	core, logs := observer.New(zap.InfoLevel)
+	sink := &memorySink{}
+	logger := NewLogger(cfg, sink, zap.New(core))
+
+	entry := &Entry{
+		Timestamp: time.Now(),
+		Action:    "topic.create",
+		Principal: "alice",
+		Resource:  map[string]string{"topicName": "orders"},
+		Result:    Result{Success: true, StatusCode: 200},
+	}
+	logger.Log(context.Background(), entry)
+	require.Len(t, sink.entries, 1)
+	var written Entry
+	require.NoError(t, json.Unmarshal(sink.entries[0], &written))
+	assert.Equal(t, "alice", written.Principal)
+	assert.Equal(t, "orders", written.Resource["topicName"])
+	assert.Equal(t, 0, logs.Len())
+
+	// Entries which can't be written to the sink are written to the application log
+	sink.err = fmt.Errorf("broker not available")
+	logger.Log(context.Background(), entry)
+	require.Equal(t, 1, logs.Len())
+	assert.Contains(t, logs.All()[0].ContextMap()["entry"], `"principal":"alice"`)
+}
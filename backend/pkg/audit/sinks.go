@@ -0,0 +1,57 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/cloudhut/kowl/backend/pkg/kafka"
+)
+
+// writerSink writes one entry per line, the writes of concurrent requests are serialized. The file of the file sink
+// is kept open for the lifetime of the process.
+type writerSink struct {
+	mutex  sync.Mutex
+	writer io.Writer
+}
+
+// NewStdoutSink writes the audit log to stdout
+func NewStdoutSink() Sink {
+	return &writerSink{writer: os.Stdout}
+}
+
+// NewFileSink appends the audit log to the file, which is created if it doesn't exist
+func NewFileSink(path string) (Sink, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log file: %w", err)
+	}
+
+	return &writerSink{writer: file}, nil
+}
+
+func (s *writerSink) Write(_ context.Context, entry []byte) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	_, err := s.writer.Write(append(entry, '\n'))
+	return err
+}
+
+// kafkaSink produces each entry as a message to a Kafka topic
+type kafkaSink struct {
+	kafkaSvc *kafka.Service
+	topic    string
+}
+
+// NewKafkaSink produces the audit log to the topic with the producer of the given Kafka service
+func NewKafkaSink(kafkaSvc *kafka.Service, topic string) Sink {
+	return &kafkaSink{kafkaSvc: kafkaSvc, topic: topic}
+}
+
+func (s *kafkaSink) Write(ctx context.Context, entry []byte) error {
+	_, err := s.kafkaSvc.ProduceMessage(ctx, kafka.ProduceMessageRequest{TopicName: s.topic, Value: entry})
+	return err
+}
@@ -0,0 +1,210 @@
+package connect
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// client that talks to a single Kafka Connect cluster via REST
+type client struct {
+	cfg    ConfigCluster
+	client *resty.Client
+}
+
+// RestError is the error response of the Kafka Connect REST API
+type RestError struct {
+	ErrorCode int    `json:"error_code"`
+	Message   string `json:"message"`
+}
+
+func (e *RestError) Error() string {
+	return fmt.Sprintf("kafka connect request failed: %d - %s", e.ErrorCode, e.Message)
+}
+
+func newClient(cfg ConfigCluster, requestTimeout time.Duration) (*client, error) {
+	c := resty.New().
+		SetHostURL(cfg.URL).
+		SetHeader("User-Agent", "Kowl").
+		SetHeader("Accept", "application/json").
+		SetError(&RestError{}).
+		SetTimeout(requestTimeout)
+
+	// Configure credentials
+	if cfg.Username != "" {
+		c = c.SetBasicAuth(cfg.Username, cfg.Password)
+	}
+	if cfg.BearerToken != "" {
+		c = c.SetAuthToken(cfg.BearerToken)
+	}
+
+	// Use custom root ca and client certificate if desired
+	tlsCfg := &tls.Config{InsecureSkipVerify: cfg.TLS.InsecureSkipTLSVerify}
+	if cfg.TLS.CaFilepath != "" {
+		ca, err := ioutil.ReadFile(cfg.TLS.CaFilepath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read ca file for kafka connect client: %w", err)
+		}
+		pool := x509.NewCertPool()
+		pool.AppendCertsFromPEM(ca)
+		tlsCfg.RootCAs = pool
+	}
+	if cfg.TLS.CertFilepath != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.TLS.CertFilepath, cfg.TLS.KeyFilepath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate for kafka connect client: %w", err)
+		}
+		tlsCfg.Certificates = []tls.Certificate{cert}
+	}
+	c.SetTLSClientConfig(tlsCfg)
+
+	return &client{
+		cfg:    cfg,
+		client: c,
+	}, nil
+}
+
+// get sends a GET request and parses the response into result
+func (c *client) get(ctx context.Context, path string, result interface{}) error {
+	res, err := c.client.R().SetContext(ctx).SetResult(result).Get(path)
+	if err != nil {
+		return fmt.Errorf("kafka connect request failed: %w", err)
+	}
+
+	return responseError(res)
+}
+
+// responseError returns the error of a failed request, if the response has no error body the error is derived from
+// the status code
+func responseError(res *resty.Response) error {
+	if !res.IsError() {
+		return nil
+	}
+	restErr, ok := res.Error().(*RestError)
+	if !ok || restErr.ErrorCode == 0 {
+		return &RestError{ErrorCode: res.StatusCode(), Message: http.StatusText(res.StatusCode())}
+	}
+	return restErr
+}
+
+// RootInfo is the version information of a Kafka Connect worker
+type RootInfo struct {
+	Version        string `json:"version"`
+	Commit         string `json:"commit"`
+	KafkaClusterID string `json:"kafka_cluster_id"`
+}
+
+// GetRoot returns the version of the worker which serves the request
+func (c *client) GetRoot(ctx context.Context) (*RootInfo, error) {
+	var info RootInfo
+	err := c.get(ctx, "/", &info)
+	if err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// ConnectorInfo is the config along with the tasks of a connector
+type ConnectorInfo struct {
+	Name   string            `json:"name"`
+	Config map[string]string `json:"config"`
+	Tasks  []struct {
+		Connector string `json:"connector"`
+		Task      int    `json:"task"`
+	} `json:"tasks"`
+	Type string `json:"type"` // source or sink
+}
+
+// ConnectorStatus is the state of a connector and its tasks along with the workers they are running on
+type ConnectorStatus struct {
+	Name      string `json:"name"`
+	Connector struct {
+		State    string `json:"state"`
+		WorkerID string `json:"worker_id"`
+		Trace    string `json:"trace,omitempty"`
+	} `json:"connector"`
+	Tasks []TaskStatus `json:"tasks"`
+	Type  string       `json:"type"`
+}
+
+// TaskStatus is the state of a single connector task. Trace contains the stack trace if the task has FAILED.
+type TaskStatus struct {
+	ID       int    `json:"id"`
+	State    string `json:"state"`
+	WorkerID string `json:"worker_id"`
+	Trace    string `json:"trace,omitempty"`
+}
+
+// expandedConnector is a connector as returned by listing connectors with expanded info and status
+type expandedConnector struct {
+	Info   ConnectorInfo   `json:"info"`
+	Status ConnectorStatus `json:"status"`
+}
+
+// ListConnectorsExpanded returns the info and status of all connectors by name. Expanding connectors requires Kafka
+// Connect 2.3 or newer.
+func (c *client) ListConnectorsExpanded(ctx context.Context) (map[string]expandedConnector, error) {
+	connectors := make(map[string]expandedConnector)
+	err := c.get(ctx, "/connectors?expand=info&expand=status", &connectors)
+	if err != nil {
+		return nil, err
+	}
+	return connectors, nil
+}
+
+// GetConnectorInfo returns the config and tasks of a connector
+func (c *client) GetConnectorInfo(ctx context.Context, connector string) (*ConnectorInfo, error) {
+	var info ConnectorInfo
+	err := c.get(ctx, "/connectors/"+url.PathEscape(connector), &info)
+	if err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// GetConnectorStatus returns the state of a connector and its tasks
+func (c *client) GetConnectorStatus(ctx context.Context, connector string) (*ConnectorStatus, error) {
+	var status ConnectorStatus
+	err := c.get(ctx, "/connectors/"+url.PathEscape(connector)+"/status", &status)
+	if err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// send sends a request without body and discards the response, which is empty for all connector actions
+func (c *client) send(ctx context.Context, method string, path string) error {
+	res, err := c.client.R().SetContext(ctx).Execute(method, path)
+	if err != nil {
+		return fmt.Errorf("kafka connect request failed: %w", err)
+	}
+
+	return responseError(res)
+}
+
+// RestartConnector restarts the connector instance, its tasks are not restarted
+func (c *client) RestartConnector(ctx context.Context, connector string) error {
+	return c.send(ctx, http.MethodPost, "/connectors/"+url.PathEscape(connector)+"/restart")
+}
+
+// RestartTask restarts a single task of a connector
+func (c *client) RestartTask(ctx context.Context, connector string, taskID int) error {
+	return c.send(ctx, http.MethodPost, fmt.Sprintf("/connectors/%v/tasks/%d/restart", url.PathEscape(connector), taskID))
+}
+
+// PauseConnector pauses the connector and its tasks asynchronously
+func (c *client) PauseConnector(ctx context.Context, connector string) error {
+	return c.send(ctx, http.MethodPut, "/connectors/"+url.PathEscape(connector)+"/pause")
+}
+
+// ResumeConnector resumes a paused connector and its tasks asynchronously
+func (c *client) ResumeConnector(ctx context.Context, connector string) error {
+	return c.send(ctx, http.MethodPut, "/connectors/"+url.PathEscape(connector)+"/resume")
+}
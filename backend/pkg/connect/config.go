@@ -0,0 +1,70 @@
+package connect
+
+import (
+	"fmt"
+	"time"
+)
+
+// Config for monitoring one or more Kafka Connect clusters via their REST API
+type Config struct {
+	Enabled  bool            `yaml:"enabled"`
+	Clusters []ConfigCluster `yaml:"clusters"`
+
+	// RequestTimeout is the timeout of a single request against a Kafka Connect cluster
+	RequestTimeout time.Duration `yaml:"requestTimeout"`
+}
+
+// ConfigCluster is the connection config of a single Kafka Connect cluster
+type ConfigCluster struct {
+	// Name is shown in the frontend and identifies the cluster in the API
+	Name string `yaml:"name"`
+	URL  string `yaml:"url"`
+
+	// Credentials
+	Username    string `yaml:"username"`
+	Password    string `yaml:"password"`
+	BearerToken string `yaml:"bearerToken"`
+
+	// TLS / Custom CA
+	TLS TLSConfig `yaml:"tls"`
+}
+
+// SetDefaults for the Kafka Connect config
+func (c *Config) SetDefaults() {
+	c.RequestTimeout = 10 * time.Second
+}
+
+// Validate the Kafka Connect config
+func (c *Config) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+
+	if len(c.Clusters) == 0 {
+		return fmt.Errorf("kafka connect is enabled but no cluster is configured")
+	}
+	if c.RequestTimeout <= 0 {
+		return fmt.Errorf("kafka connect requestTimeout must be greater than 0")
+	}
+
+	names := make(map[string]bool, len(c.Clusters))
+	for i, cluster := range c.Clusters {
+		if cluster.Name == "" {
+			return fmt.Errorf("kafka connect cluster at index %v has no name", i)
+		}
+		if names[cluster.Name] {
+			return fmt.Errorf("kafka connect cluster name '%v' is not unique", cluster.Name)
+		}
+		names[cluster.Name] = true
+
+		if cluster.URL == "" {
+			return fmt.Errorf("kafka connect cluster '%v' has no url", cluster.Name)
+		}
+		err := cluster.TLS.Validate()
+		if err != nil {
+			return fmt.Errorf("failed to validate tls config of kafka connect cluster '%v': %w", cluster.Name, err)
+		}
+	}
+
+	return nil
+}
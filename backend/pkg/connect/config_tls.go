@@ -0,0 +1,20 @@
+package connect
+
+import "fmt"
+
+// TLSConfig to connect to a Kafka Connect cluster via TLS
+type TLSConfig struct {
+	CaFilepath            string `yaml:"caFilepath"`
+	CertFilepath          string `yaml:"certFilepath"`
+	KeyFilepath           string `yaml:"keyFilepath"`
+	InsecureSkipTLSVerify bool   `yaml:"insecureSkipTlsVerify"`
+}
+
+// Validate the Kafka Connect TLS config
+func (c *TLSConfig) Validate() error {
+	if (c.CertFilepath == "") != (c.KeyFilepath == "") {
+		return fmt.Errorf("certFilepath and keyFilepath must be supplied as a pair")
+	}
+
+	return nil
+}
@@ -0,0 +1,117 @@
+package connect
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	// statusPollAttempts is the number of times the status is requested after an action, because Kafka Connect
+	// applies actions asynchronously and the status lags behind briefly
+	statusPollAttempts = 3
+)
+
+// RestartConnector restarts the connector instance (not its tasks) and returns the connector once it is running again
+// or the status poll attempts are exhausted
+func (s *Service) RestartConnector(ctx context.Context, clusterName string, connector string) (*ConnectorDetails, error) {
+	return s.runConnectorAction(ctx, clusterName, connector,
+		func(c *client) error {
+			return c.RestartConnector(ctx, connector)
+		},
+		func(status *ConnectorStatus) bool {
+			return status.Connector.State == StateRunning
+		})
+}
+
+// RestartTask restarts a single task of the connector and returns the connector once the task is running again or
+// the status poll attempts are exhausted
+func (s *Service) RestartTask(ctx context.Context, clusterName string, connector string, taskID int) (*ConnectorDetails, error) {
+	return s.runConnectorAction(ctx, clusterName, connector,
+		func(c *client) error {
+			return c.RestartTask(ctx, connector, taskID)
+		},
+		func(status *ConnectorStatus) bool {
+			for _, task := range status.Tasks {
+				if task.ID == taskID {
+					return task.State == StateRunning
+				}
+			}
+			return false
+		})
+}
+
+// PauseConnector pauses the connector and its tasks and returns the connector once all of them are paused or the
+// status poll attempts are exhausted
+func (s *Service) PauseConnector(ctx context.Context, clusterName string, connector string) (*ConnectorDetails, error) {
+	return s.runConnectorAction(ctx, clusterName, connector,
+		func(c *client) error {
+			return c.PauseConnector(ctx, connector)
+		},
+		func(status *ConnectorStatus) bool {
+			return hasState(status, StatePaused)
+		})
+}
+
+// ResumeConnector resumes the paused connector and its tasks and returns the connector once all of them are running
+// or the status poll attempts are exhausted
+func (s *Service) ResumeConnector(ctx context.Context, clusterName string, connector string) (*ConnectorDetails, error) {
+	return s.runConnectorAction(ctx, clusterName, connector,
+		func(c *client) error {
+			return c.ResumeConnector(ctx, connector)
+		},
+		func(status *ConnectorStatus) bool {
+			return hasState(status, StateRunning)
+		})
+}
+
+// hasState returns true if the connector and all its tasks are in the given state
+func hasState(status *ConnectorStatus, state string) bool {
+	if status.Connector.State != state {
+		return false
+	}
+	for _, task := range status.Tasks {
+		if task.State != state {
+			return false
+		}
+	}
+	return true
+}
+
+// runConnectorAction runs the action against the connector and polls its status until isSettled returns true. The
+// last polled status is returned even if the action has not settled yet, as the caller can't tell a slow worker from
+// an action which will never settle (e.g. a task which fails again right after a restart).
+func (s *Service) runConnectorAction(ctx context.Context, clusterName string, connector string, action func(c *client) error, isSettled func(status *ConnectorStatus) bool) (*ConnectorDetails, error) {
+	c, err := s.client(clusterName)
+	if err != nil {
+		return nil, err
+	}
+
+	err = action(c)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := c.GetConnectorInfo(ctx, connector)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connector info: %w", err)
+	}
+	var status *ConnectorStatus
+	for attempt := 1; attempt <= statusPollAttempts; attempt++ {
+		status, err = c.GetConnectorStatus(ctx, connector)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get connector status: %w", err)
+		}
+		if isSettled(status) || attempt == statusPollAttempts {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(s.statusPollInterval):
+		}
+	}
+
+	return newConnectorDetails(clusterName, info, status), nil
+}
@@ -0,0 +1,75 @@
+package connect
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_PauseConnector(t *testing.T) {
+	s := newTestService(t, ConfigCluster{Name: "datawarehouse", URL: "http://connect-dwh:8083"})
+	s.statusPollInterval = time.Millisecond
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("PUT", "http://connect-dwh:8083/connectors/s3-sink/pause",
+		httpmock.NewStringResponder(http.StatusAccepted, ""))
+	httpmock.RegisterResponder("GET", "http://connect-dwh:8083/connectors/s3-sink",
+		jsonResponder(http.StatusOK, `{"name": "s3-sink", "config": {}, "tasks": [], "type": "sink"}`))
+
+	// The status lags behind the pause request, the task is still running when the status is requested the first time
+	statusRequests := 0
+	httpmock.RegisterResponder("GET", "http://connect-dwh:8083/connectors/s3-sink/status",
+		func(_ *http.Request) (*http.Response, error) {
+			statusRequests++
+			taskState := StateRunning
+			if statusRequests > 1 {
+				taskState = StatePaused
+			}
+			return jsonResponse(http.StatusOK, `{"name": "s3-sink", "connector": {"state": "PAUSED", "worker_id": "10.0.0.1:8083"},
+"tasks": [{"id": 0, "state": "`+taskState+`", "worker_id": "10.0.0.1:8083"}], "type": "sink"}`), nil
+		})
+
+	connector, err := s.PauseConnector(context.Background(), "datawarehouse", "s3-sink")
+	require.NoError(t, err)
+	assert.Equal(t, 2, statusRequests)
+	assert.Equal(t, StatePaused, connector.State)
+	assert.Equal(t, StatePaused, connector.Tasks[0].State)
+}
+
+func TestService_RestartTask(t *testing.T) {
+	s := newTestService(t, ConfigCluster{Name: "datawarehouse", URL: "http://connect-dwh:8083"})
+	s.statusPollInterval = time.Millisecond
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("POST", "http://connect-dwh:8083/connectors/postgres-orders/tasks/1/restart",
+		httpmock.NewStringResponder(http.StatusNoContent, ""))
+	httpmock.RegisterResponder("GET", "http://connect-dwh:8083/connectors/postgres-orders",
+		jsonResponder(http.StatusOK, `{"name": "postgres-orders", "config": {}, "tasks": [], "type": "source"}`))
+
+	// A task which fails again right after the restart never settles, the last status must be returned
+	statusRequests := 0
+	httpmock.RegisterResponder("GET", "http://connect-dwh:8083/connectors/postgres-orders/status",
+		func(_ *http.Request) (*http.Response, error) {
+			statusRequests++
+			return jsonResponse(http.StatusOK, `{"name": "postgres-orders", "connector": {"state": "RUNNING", "worker_id": "10.0.0.1:8083"},
+"tasks": [{"id": 1, "state": "FAILED", "worker_id": "10.0.0.2:8083", "trace": "java.lang.IllegalStateException"}], "type": "source"}`), nil
+		})
+
+	connector, err := s.RestartTask(context.Background(), "datawarehouse", "postgres-orders", 1)
+	require.NoError(t, err)
+	assert.Equal(t, statusPollAttempts, statusRequests)
+	require.Len(t, connector.Errors, 1)
+	assert.Equal(t, "java.lang.IllegalStateException", connector.Errors[0].Message)
+
+	// Kafka Connect rejects actions while the workers are rebalancing
+	httpmock.RegisterResponder("POST", "http://connect-dwh:8083/connectors/postgres-orders/restart",
+		jsonResponder(http.StatusConflict, `{"error_code": 409, "message": "Cannot complete request momentarily due to stale configuration (typically caused by a concurrent config change)"}`))
+	_, err = s.RestartConnector(context.Background(), "datawarehouse", "postgres-orders")
+	require.Error(t, err)
+	assert.Equal(t, http.StatusConflict, err.(*RestError).ErrorCode)
+}
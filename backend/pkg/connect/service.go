@@ -0,0 +1,298 @@
+package connect
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+var (
+	// ErrNotConfigured is returned if Kafka Connect is not enabled
+	ErrNotConfigured = errors.New("kafka connect is not configured")
+	// ErrClusterNotFound is returned if the requested Kafka Connect cluster is not configured
+	ErrClusterNotFound = errors.New("kafka connect cluster is not configured")
+)
+
+// Connector and task states as reported by Kafka Connect
+const (
+	StateRunning    = "RUNNING"
+	StatePaused     = "PAUSED"
+	StateFailed     = "FAILED"
+	StateUnassigned = "UNASSIGNED"
+)
+
+// Service for monitoring the connectors of all configured Kafka Connect clusters
+type Service struct {
+	cfg    Config
+	logger *zap.Logger
+
+	// clients by cluster name, clusterNames is the configured order of the clusters
+	clients      map[string]*client
+	clusterNames []string
+
+	// statusPollInterval is the wait time between polling the status of a connector after an action
+	statusPollInterval time.Duration
+}
+
+// NewService creates a client for each configured Kafka Connect cluster. The clusters are not contacted until
+// connectors are requested.
+func NewService(cfg Config, logger *zap.Logger) (*Service, error) {
+	s := &Service{
+		cfg:     cfg,
+		logger:  logger.With(zap.String("source", "kafka_connect")),
+		clients: make(map[string]*client),
+
+		statusPollInterval: 500 * time.Millisecond,
+	}
+	if !cfg.Enabled {
+		return s, nil
+	}
+
+	for _, clusterCfg := range cfg.Clusters {
+		c, err := newClient(clusterCfg, cfg.RequestTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create client for kafka connect cluster '%v': %w", clusterCfg.Name, err)
+		}
+		s.clients[clusterCfg.Name] = c
+		s.clusterNames = append(s.clusterNames, clusterCfg.Name)
+	}
+
+	return s, nil
+}
+
+// IsEnabled returns true if at least one Kafka Connect cluster is configured
+func (s *Service) IsEnabled() bool {
+	return s.cfg.Enabled
+}
+
+// ClusterConnectors are all connectors of a single Kafka Connect cluster. Error is set instead of the connectors if
+// the cluster could not be reached, so that a single unavailable cluster doesn't hide the connectors of all others.
+type ClusterConnectors struct {
+	ClusterName    string              `json:"clusterName"`
+	ClusterAddress string              `json:"clusterAddress"`
+	ClusterVersion string              `json:"clusterVersion,omitempty"`
+	Connectors     []ConnectorOverview `json:"connectors"`
+
+	TotalConnectors   int `json:"totalConnectors"`
+	RunningConnectors int `json:"runningConnectors"`
+	FailedConnectors  int `json:"failedConnectors"`
+
+	Error string `json:"error,omitempty"`
+}
+
+// ConnectorOverview is the state of a connector and its tasks
+type ConnectorOverview struct {
+	Name     string `json:"name"`
+	Class    string `json:"class"`
+	Type     string `json:"type"` // source or sink
+	Topics   string `json:"topics,omitempty"`
+	State    string `json:"state"`
+	WorkerID string `json:"workerId"`
+
+	Tasks        []TaskStatus `json:"tasks"`
+	TotalTasks   int          `json:"totalTasks"`
+	RunningTasks int          `json:"runningTasks"`
+
+	// Errors are the failures of the connector and its tasks along with their stack traces
+	Errors []ConnectorError `json:"errors"`
+}
+
+// ConnectorError is the failure of a connector or one of its tasks. TaskID is nil if the connector itself has failed.
+type ConnectorError struct {
+	TaskID   *int   `json:"taskId,omitempty"`
+	WorkerID string `json:"workerId"`
+
+	// Message is the first line of the trace, which usually contains the exception and its message
+	Message string `json:"message"`
+	Trace   string `json:"trace"`
+}
+
+// ConnectorDetails is the overview of a connector along with its config
+type ConnectorDetails struct {
+	ConnectorOverview
+	ClusterName string            `json:"clusterName"`
+	Config      map[string]string `json:"config"`
+}
+
+// ClusterNames returns the names of all configured Kafka Connect clusters in the configured order
+func (s *Service) ClusterNames() []string {
+	return s.clusterNames
+}
+
+// ListConnectors returns the connectors of all given clusters, the clusters are requested concurrently
+func (s *Service) ListConnectors(ctx context.Context, clusterNames []string) ([]ClusterConnectors, error) {
+	if !s.cfg.Enabled {
+		return nil, ErrNotConfigured
+	}
+
+	res := make([]ClusterConnectors, len(clusterNames))
+	wg := sync.WaitGroup{}
+	for i, clusterName := range clusterNames {
+		c, exists := s.clients[clusterName]
+		if !exists {
+			return nil, fmt.Errorf("%w: %v", ErrClusterNotFound, clusterName)
+		}
+
+		wg.Add(1)
+		go func(i int, c *client) {
+			defer wg.Done()
+			res[i] = s.listClusterConnectors(ctx, c)
+		}(i, c)
+	}
+	wg.Wait()
+
+	return res, nil
+}
+
+// listClusterConnectors returns the connectors of a single cluster
+func (s *Service) listClusterConnectors(ctx context.Context, c *client) ClusterConnectors {
+	res := ClusterConnectors{
+		ClusterName:    c.cfg.Name,
+		ClusterAddress: c.cfg.URL,
+		Connectors:     []ConnectorOverview{},
+	}
+
+	root, err := c.GetRoot(ctx)
+	if err == nil {
+		res.ClusterVersion = root.Version
+	}
+	connectors, err := c.ListConnectorsExpanded(ctx)
+	if err != nil {
+		s.logger.Warn("failed to list connectors", zap.String("connect_cluster", c.cfg.Name), zap.Error(err))
+		res.Error = err.Error()
+		return res
+	}
+
+	for _, connector := range connectors {
+		overview := newConnectorOverview(connector.Info, connector.Status)
+		switch overview.State {
+		case StateRunning:
+			res.RunningConnectors++
+		case StateFailed:
+			res.FailedConnectors++
+		}
+		res.Connectors = append(res.Connectors, overview)
+	}
+	sort.Slice(res.Connectors, func(i, j int) bool {
+		return res.Connectors[i].Name < res.Connectors[j].Name
+	})
+	res.TotalConnectors = len(res.Connectors)
+
+	return res
+}
+
+// GetConnector returns the details of a single connector, including its config
+func (s *Service) GetConnector(ctx context.Context, clusterName string, connector string) (*ConnectorDetails, error) {
+	c, err := s.client(clusterName)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := c.GetConnectorInfo(ctx, connector)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connector info: %w", err)
+	}
+	status, err := c.GetConnectorStatus(ctx, connector)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connector status: %w", err)
+	}
+
+	return newConnectorDetails(clusterName, info, status), nil
+}
+
+// client returns the client of the given cluster
+func (s *Service) client(clusterName string) (*client, error) {
+	if !s.cfg.Enabled {
+		return nil, ErrNotConfigured
+	}
+	c, exists := s.clients[clusterName]
+	if !exists {
+		return nil, fmt.Errorf("%w: %v", ErrClusterNotFound, clusterName)
+	}
+	return c, nil
+}
+
+func newConnectorDetails(clusterName string, info *ConnectorInfo, status *ConnectorStatus) *ConnectorDetails {
+	return &ConnectorDetails{
+		ConnectorOverview: newConnectorOverview(*info, *status),
+		ClusterName:       clusterName,
+		Config:            redactConfig(info.Config),
+	}
+}
+
+// newConnectorOverview summarizes the state of the connector and its tasks and collects the traces of all failures
+func newConnectorOverview(info ConnectorInfo, status ConnectorStatus) ConnectorOverview {
+	connectorType := info.Type
+	if connectorType == "" {
+		connectorType = status.Type
+	}
+	overview := ConnectorOverview{
+		Name:       info.Name,
+		Class:      info.Config["connector.class"],
+		Type:       connectorType,
+		Topics:     info.Config["topics"],
+		State:      status.Connector.State,
+		WorkerID:   status.Connector.WorkerID,
+		Tasks:      status.Tasks,
+		TotalTasks: len(status.Tasks),
+		Errors:     []ConnectorError{},
+	}
+	if overview.Name == "" {
+		overview.Name = status.Name
+	}
+	if overview.Tasks == nil {
+		overview.Tasks = []TaskStatus{}
+	}
+
+	if status.Connector.State == StateFailed {
+		overview.Errors = append(overview.Errors, newConnectorError(nil, status.Connector.WorkerID, status.Connector.Trace))
+	}
+	for _, task := range status.Tasks {
+		switch task.State {
+		case StateRunning:
+			overview.RunningTasks++
+		case StateFailed:
+			taskID := task.ID
+			overview.Errors = append(overview.Errors, newConnectorError(&taskID, task.WorkerID, task.Trace))
+		}
+	}
+
+	return overview
+}
+
+func newConnectorError(taskID *int, workerID string, trace string) ConnectorError {
+	message := strings.TrimSpace(trace)
+	if i := strings.IndexByte(message, '\n'); i >= 0 {
+		message = strings.TrimSpace(message[:i])
+	}
+	if message == "" {
+		message = "failed without a trace, the worker logs may contain more details"
+	}
+
+	return ConnectorError{
+		TaskID:   taskID,
+		WorkerID: workerID,
+		Message:  message,
+		Trace:    trace,
+	}
+}
+
+// redactConfig replaces the values of config entries which likely contain credentials, because Kafka Connect returns
+// them in plain text unless a config provider is used
+func redactConfig(config map[string]string) map[string]string {
+	redacted := make(map[string]string, len(config))
+	for key, value := range config {
+		lowerKey := strings.ToLower(key)
+		if strings.Contains(lowerKey, "password") || strings.Contains(lowerKey, "secret") {
+			value = "[REDACTED]"
+		}
+		redacted[key] = value
+	}
+	return redacted
+}
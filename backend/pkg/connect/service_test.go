@@ -0,0 +1,143 @@
+package connect
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+const failedTrace = "org.apache.kafka.connect.errors.ConnectException: Connection refused\n\tat io.debezium.Task.start(Task.java:42)\n"
+
+func newTestService(t *testing.T, clusters ...ConfigCluster) *Service {
+	cfg := Config{Enabled: true, Clusters: clusters}
+	cfg.SetDefaults()
+	require.NoError(t, cfg.Validate())
+
+	s, err := NewService(cfg, zap.NewNop())
+	require.NoError(t, err)
+	for _, c := range s.clients {
+		httpmock.ActivateNonDefault(c.client.GetClient())
+	}
+	return s
+}
+
+// jsonResponse returns a response with the content type of Kafka Connect responses, which resty requires for parsing
+func jsonResponse(status int, body string) *http.Response {
+	res := httpmock.NewStringResponse(status, body)
+	res.Header.Set("Content-Type", "application/json")
+	return res
+}
+
+func jsonResponder(status int, body string) httpmock.Responder {
+	return func(_ *http.Request) (*http.Response, error) {
+		return jsonResponse(status, body), nil
+	}
+}
+
+func TestService_ListConnectors(t *testing.T) {
+	s := newTestService(t,
+		ConfigCluster{Name: "datawarehouse", URL: "http://connect-dwh:8083"},
+		ConfigCluster{Name: "offline", URL: "http://connect-offline:8083"})
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "http://connect-dwh:8083/",
+		jsonResponder(http.StatusOK, `{"version":"2.8.0","commit":"ebb1d6e21cc92130","kafka_cluster_id":"I4ZmrWqfT2e-upky_4fdPA"}`))
+	httpmock.RegisterResponder("GET", "http://connect-dwh:8083/connectors",
+		func(req *http.Request) (*http.Response, error) {
+			assert.Equal(t, []string{"info", "status"}, req.URL.Query()["expand"])
+			return jsonResponse(http.StatusOK, `{
+  "postgres-orders": {
+    "info": {"name": "postgres-orders", "config": {"connector.class": "io.debezium.connector.postgresql.PostgresConnector"}, "tasks": [{"connector": "postgres-orders", "task": 0}, {"connector": "postgres-orders", "task": 1}], "type": "source"},
+    "status": {"name": "postgres-orders", "connector": {"state": "RUNNING", "worker_id": "10.0.0.1:8083"}, "tasks": [
+      {"id": 0, "state": "RUNNING", "worker_id": "10.0.0.1:8083"},
+      {"id": 1, "state": "FAILED", "worker_id": "10.0.0.2:8083", "trace": "`+"org.apache.kafka.connect.errors.ConnectException: Connection refused\\n\\tat io.debezium.Task.start(Task.java:42)\\n"+`"}
+    ], "type": "source"}
+  },
+  "s3-sink": {
+    "info": {"name": "s3-sink", "config": {"connector.class": "io.confluent.connect.s3.S3SinkConnector", "topics": "orders"}, "tasks": [], "type": "sink"},
+    "status": {"name": "s3-sink", "connector": {"state": "PAUSED", "worker_id": "10.0.0.1:8083"}, "tasks": [], "type": "sink"}
+  }
+}`), nil
+		})
+	httpmock.RegisterResponder("GET", "http://connect-offline:8083/connectors",
+		httpmock.NewErrorResponder(errors.New("connection refused")))
+	httpmock.RegisterResponder("GET", "http://connect-offline:8083/",
+		httpmock.NewErrorResponder(errors.New("connection refused")))
+
+	clusters, err := s.ListConnectors(context.Background(), s.ClusterNames())
+	require.NoError(t, err)
+	require.Len(t, clusters, 2)
+
+	dwh := clusters[0]
+	assert.Empty(t, dwh.Error)
+	assert.Equal(t, "2.8.0", dwh.ClusterVersion)
+	assert.Equal(t, 2, dwh.TotalConnectors)
+	assert.Equal(t, 1, dwh.RunningConnectors)
+	require.Len(t, dwh.Connectors, 2)
+
+	orders := dwh.Connectors[0]
+	assert.Equal(t, "postgres-orders", orders.Name)
+	assert.Equal(t, "io.debezium.connector.postgresql.PostgresConnector", orders.Class)
+	assert.Equal(t, 2, orders.TotalTasks)
+	assert.Equal(t, 1, orders.RunningTasks)
+	require.Len(t, orders.Errors, 1)
+	require.NotNil(t, orders.Errors[0].TaskID)
+	assert.Equal(t, 1, *orders.Errors[0].TaskID)
+	assert.Equal(t, "10.0.0.2:8083", orders.Errors[0].WorkerID)
+	assert.Equal(t, "org.apache.kafka.connect.errors.ConnectException: Connection refused", orders.Errors[0].Message)
+	assert.Equal(t, failedTrace, orders.Errors[0].Trace)
+
+	assert.Equal(t, "s3-sink", dwh.Connectors[1].Name)
+	assert.Equal(t, "orders", dwh.Connectors[1].Topics)
+	assert.Equal(t, StatePaused, dwh.Connectors[1].State)
+
+	// An unavailable cluster must not hide the connectors of other clusters
+	offline := clusters[1]
+	assert.Equal(t, "offline", offline.ClusterName)
+	assert.NotEmpty(t, offline.Error)
+	assert.Empty(t, offline.Connectors)
+
+	_, err = s.ListConnectors(context.Background(), []string{"unknown"})
+	assert.True(t, errors.Is(err, ErrClusterNotFound))
+}
+
+func TestService_GetConnector(t *testing.T) {
+	s := newTestService(t, ConfigCluster{Name: "datawarehouse", URL: "http://connect-dwh:8083"})
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "http://connect-dwh:8083/connectors/postgres-orders",
+		jsonResponder(http.StatusOK, `{"name": "postgres-orders", "config": {"connector.class": "io.debezium.connector.postgresql.PostgresConnector", "database.password": "hunter2", "database.user": "kowl"}, "tasks": [], "type": "source"}`))
+	httpmock.RegisterResponder("GET", "http://connect-dwh:8083/connectors/postgres-orders/status",
+		jsonResponder(http.StatusOK, `{"name": "postgres-orders", "connector": {"state": "FAILED", "worker_id": "10.0.0.1:8083", "trace": "java.lang.OutOfMemoryError"}, "tasks": [], "type": "source"}`))
+	httpmock.RegisterResponder("GET", "http://connect-dwh:8083/connectors/unknown",
+		jsonResponder(http.StatusNotFound, `{"error_code": 404, "message": "Connector unknown not found"}`))
+
+	connector, err := s.GetConnector(context.Background(), "datawarehouse", "postgres-orders")
+	require.NoError(t, err)
+	assert.Equal(t, StateFailed, connector.State)
+	assert.Equal(t, "kowl", connector.Config["database.user"])
+	assert.Equal(t, "[REDACTED]", connector.Config["database.password"])
+	require.Len(t, connector.Errors, 1)
+	assert.Nil(t, connector.Errors[0].TaskID)
+	assert.Equal(t, "java.lang.OutOfMemoryError", connector.Errors[0].Message)
+
+	_, err = s.GetConnector(context.Background(), "datawarehouse", "unknown")
+	var restErr *RestError
+	require.True(t, errors.As(err, &restErr))
+	assert.Equal(t, http.StatusNotFound, restErr.ErrorCode)
+}
+
+func TestService_NotConfigured(t *testing.T) {
+	s, err := NewService(Config{}, zap.NewNop())
+	require.NoError(t, err)
+	assert.False(t, s.IsEnabled())
+
+	_, err = s.ListConnectors(context.Background(), s.ClusterNames())
+	assert.Equal(t, ErrNotConfigured, err)
+}
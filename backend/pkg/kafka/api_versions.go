@@ -0,0 +1,229 @@
+package kafka
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"go.uber.org/zap"
+)
+
+// apiKeyNames maps the Kafka API keys to their names as documented in the Kafka protocol guide
+var apiKeyNames = map[int16]string{
+	0: "Produce", 1: "Fetch", 2: "ListOffsets", 3: "Metadata", 4: "LeaderAndIsr", 5: "StopReplica",
+	6: "UpdateMetadata", 7: "ControlledShutdown", 8: "OffsetCommit", 9: "OffsetFetch", 10: "FindCoordinator",
+	11: "JoinGroup", 12: "Heartbeat", 13: "LeaveGroup", 14: "SyncGroup", 15: "DescribeGroups", 16: "ListGroups",
+	17: "SaslHandshake", 18: "ApiVersions", 19: "CreateTopics", 20: "DeleteTopics", 21: "DeleteRecords",
+	22: "InitProducerId", 23: "OffsetForLeaderEpoch", 24: "AddPartitionsToTxn", 25: "AddOffsetsToTxn",
+	26: "EndTxn", 27: "WriteTxnMarkers", 28: "TxnOffsetCommit", 29: "DescribeAcls", 30: "CreateAcls",
+	31: "DeleteAcls", 32: "DescribeConfigs", 33: "AlterConfigs", 34: "AlterReplicaLogDirs", 35: "DescribeLogDirs",
+	36: "SaslAuthenticate", 37: "CreatePartitions", 38: "CreateDelegationToken", 39: "RenewDelegationToken",
+	40: "ExpireDelegationToken", 41: "DescribeDelegationToken", 42: "DeleteGroups", 43: "ElectLeaders",
+	44: "IncrementalAlterConfigs", 45: "AlterPartitionReassignments", 46: "ListPartitionReassignments",
+	47: "OffsetDelete", 48: "DescribeClientQuotas", 49: "AlterClientQuotas", 50: "DescribeUserScramCredentials",
+	51: "AlterUserScramCredentials",
+}
+
+// APIKeyName returns the name of the given Kafka API key or an empty string if the key is unknown
+func APIKeyName(apiKey int16) string {
+	return apiKeyNames[apiKey]
+}
+
+// clientAPIVersion is the request version the client sends once the configured Kafka version is at least since
+type clientAPIVersion struct {
+	since   sarama.KafkaVersion
+	version int16
+}
+
+// clientAPIVersions are the request versions Sarama (v1.30) sends per API key, ordered by the Kafka version they
+// require. Sarama does not negotiate versions, each request is sent with the version that matches the configured Kafka
+// version. APIs which are not listed are implemented neither by Sarama nor by Kowl, or are not used by Kowl.
+var clientAPIVersions = map[int16][]clientAPIVersion{
+	0:  {{sarama.V0_8_2_0, 0}, {sarama.V0_10_0_0, 2}, {sarama.V0_11_0_0, 3}}, // Produce, v7 with zstd compression
+	1:  {{sarama.V0_8_2_0, 0}, {sarama.V0_9_0_0, 1}, {sarama.V0_10_0_0, 2}, {sarama.V0_10_1_0, 3}, {sarama.V0_11_0_0, 4}, {sarama.V1_1_0_0, 7}, {sarama.V2_1_0_0, 10}, {sarama.V2_3_0_0, 11}},
+	2:  {{sarama.V0_8_2_0, 0}, {sarama.V0_10_1_0, 1}},
+	3:  {{sarama.V0_8_2_0, 0}, {sarama.V0_10_0_0, 1}, {sarama.V1_0_0_0, 5}},
+	9:  {{sarama.V0_8_2_0, 0}, {sarama.V0_8_2_2, 1}, {sarama.V0_10_2_0, 2}},
+	10: {{sarama.V0_8_2_0, 0}},
+	15: {{sarama.V0_9_0_0, 0}},
+	16: {{sarama.V0_9_0_0, 0}},
+	18: {{sarama.V0_10_0_0, 0}, {sarama.V2_4_0_0, 3}},
+	19: {{sarama.V0_10_1_0, 0}, {sarama.V0_11_0_0, 1}, {sarama.V1_0_0_0, 2}},
+	20: {{sarama.V0_10_1_0, 0}, {sarama.V0_11_0_0, 1}},
+	21: {{sarama.V0_11_0_0, 0}},
+	29: {{sarama.V0_11_0_0, 0}, {sarama.V2_0_0_0, 1}},
+	30: {{sarama.V0_11_0_0, 0}, {sarama.V2_0_0_0, 1}},
+	31: {{sarama.V0_11_0_0, 0}, {sarama.V2_0_0_0, 1}},
+	32: {{sarama.V0_11_0_0, 0}, {sarama.V1_1_0_0, 1}, {sarama.V2_0_0_0, 2}},
+	33: {{sarama.V0_11_0_0, 0}},
+	35: {{sarama.V1_0_0_0, 0}},
+	37: {{sarama.V1_0_0_0, 0}},
+	42: {{sarama.V1_1_0_0, 0}},
+	44: {{sarama.V2_3_0_0, 0}},
+	45: {{sarama.V2_4_0_0, 0}},
+	46: {{sarama.V2_4_0_0, 0}},
+	48: {{sarama.V2_6_0_0, 0}},
+	49: {{sarama.V2_6_0_0, 0}},
+}
+
+// ClientAPIVersion returns the request version the client sends for the API key given its configured Kafka version.
+// It returns false if the client does not use the API or the configured Kafka version is too old for it.
+func ClientAPIVersion(apiKey int16, kafkaVersion sarama.KafkaVersion) (int16, bool) {
+	version, ok := int16(0), false
+	for _, v := range clientAPIVersions[apiKey] {
+		if !kafkaVersion.IsAtLeast(v.since) {
+			break
+		}
+		version, ok = v.version, true
+	}
+	return version, ok
+}
+
+// NegotiatedAPIVersion returns the version which is used for requests of the API key between the client (configured
+// with the given Kafka version) and the broker. It returns false if the client does not use the API or the broker
+// does not support the version the client sends, in which case the requests fail.
+func NegotiatedAPIVersion(apiKey int16, kafkaVersion sarama.KafkaVersion, broker sarama.ApiVersionsResponseKey) (int16, bool) {
+	version, ok := ClientAPIVersion(apiKey, kafkaVersion)
+	if !ok || version < broker.MinVersion || version > broker.MaxVersion {
+		return 0, false
+	}
+	return version, true
+}
+
+// APIVersionsResponse is the (cached) ApiVersions response of a broker
+type APIVersionsResponse struct {
+	BrokerID    int32
+	APIVersions []sarama.ApiVersionsResponseKey
+	FetchedAt   time.Time
+}
+
+// apiVersionsCache caches the last ApiVersions response, because the supported versions rarely change
+type apiVersionsCache struct {
+	mutex    sync.Mutex
+	response *APIVersionsResponse
+}
+
+// DescribeAPIVersions returns the API versions which are supported by one of the connected brokers. The response is
+// cached for the configured TTL, unless forceRefresh is true.
+func (s *Service) DescribeAPIVersions(forceRefresh bool) (*APIVersionsResponse, error) {
+	s.apiVersions.mutex.Lock()
+	defer s.apiVersions.mutex.Unlock()
+
+	cached := s.apiVersions.response
+	if !forceRefresh && cached != nil && time.Since(cached.FetchedAt) < s.Config.APIVersionsCacheTTL {
+		return cached, nil
+	}
+
+	broker, err := s.findAnyBroker()
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := broker.ApiVersions(&sarama.ApiVersionsRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to request api versions: %w", err)
+	}
+	if kErr := sarama.KError(res.ErrorCode); kErr != sarama.ErrNoError {
+		return nil, fmt.Errorf("failed to request api versions: %w", kErr)
+	}
+
+	s.apiVersions.response = &APIVersionsResponse{
+		BrokerID:    broker.ID(),
+		APIVersions: res.ApiKeys,
+		FetchedAt:   time.Now(),
+	}
+
+	return s.apiVersions.response, nil
+}
+
+// kafkaVersionMarker is an API (version) which has been introduced with the given Kafka version
+type kafkaVersionMarker struct {
+	apiKey     int16
+	maxVersion int16
+	since      sarama.KafkaVersion
+}
+
+// kafkaVersionMarkers are ordered from the newest to the oldest Kafka version. Kafka versions which did not introduce
+// an API that tells them apart are resolved to the previous version, which only disables features of the client.
+var kafkaVersionMarkers = []kafkaVersionMarker{
+	{apiKey: 61, maxVersion: 0, since: sarama.V3_0_0_0},  // DescribeProducers
+	{apiKey: 60, maxVersion: 0, since: sarama.V2_8_0_0},  // DescribeCluster
+	{apiKey: 50, maxVersion: 0, since: sarama.V2_7_0_0},  // DescribeUserScramCredentials
+	{apiKey: 48, maxVersion: 0, since: sarama.V2_6_0_0},  // DescribeClientQuotas
+	{apiKey: 45, maxVersion: 0, since: sarama.V2_4_0_0},  // AlterPartitionReassignments
+	{apiKey: 44, maxVersion: 0, since: sarama.V2_3_0_0},  // IncrementalAlterConfigs
+	{apiKey: 43, maxVersion: 0, since: sarama.V2_2_0_0},  // ElectLeaders
+	{apiKey: 1, maxVersion: 10, since: sarama.V2_1_0_0},  // Fetch v10
+	{apiKey: 29, maxVersion: 1, since: sarama.V2_0_0_0},  // DescribeAcls v1
+	{apiKey: 42, maxVersion: 0, since: sarama.V1_1_0_0},  // DeleteGroups
+	{apiKey: 37, maxVersion: 0, since: sarama.V1_0_0_0},  // CreatePartitions
+	{apiKey: 21, maxVersion: 0, since: sarama.V0_11_0_0}, // DeleteRecords
+	{apiKey: 19, maxVersion: 0, since: sarama.V0_10_1_0}, // CreateTopics
+}
+
+// inferKafkaVersion returns the newest Kafka version whose APIs are all supported according to the ApiVersions
+// response. Brokers which answer ApiVersions requests are at least on Kafka 0.10.0.
+func inferKafkaVersion(apiVersions []sarama.ApiVersionsResponseKey) sarama.KafkaVersion {
+	maxVersions := make(map[int16]int16, len(apiVersions))
+	for _, apiVersion := range apiVersions {
+		maxVersions[apiVersion.ApiKey] = apiVersion.MaxVersion
+	}
+	for _, marker := range kafkaVersionMarkers {
+		if maxVersion, ok := maxVersions[marker.apiKey]; ok && maxVersion >= marker.maxVersion {
+			return marker.since
+		}
+	}
+	return sarama.V0_10_0_0
+}
+
+// resolveClusterVersion replaces the cluster version "auto" with the version which is inferred from the ApiVersions
+// response of the first reachable seed broker, so that all clients are created with the actual version
+func resolveClusterVersion(cfg *Config, logger *zap.Logger) error {
+	if cfg.ClusterVersion != ClusterVersionAuto {
+		return nil
+	}
+
+	saramaConfig, err := NewSaramaConfig(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create a valid sarama config: %w", err)
+	}
+
+	var lastErr error
+	for _, addr := range cfg.Brokers {
+		var res *sarama.ApiVersionsResponse
+		res, lastErr = requestAPIVersions(addr, saramaConfig)
+		if lastErr != nil {
+			logger.Warn("failed to request api versions from seed broker", zap.String("broker", addr), zap.Error(lastErr))
+			continue
+		}
+
+		cfg.ClusterVersion = inferKafkaVersion(res.ApiKeys).String()
+		logger.Info("resolved the cluster version from the api versions of the brokers",
+			zap.String("broker", addr), zap.String("cluster_version", cfg.ClusterVersion))
+		return nil
+	}
+
+	return fmt.Errorf("failed to resolve the cluster version 'auto', no seed broker returned its api versions: %w", lastErr)
+}
+
+// requestAPIVersions sends an ApiVersions request over a new connection to the given broker
+func requestAPIVersions(addr string, saramaConfig *sarama.Config) (*sarama.ApiVersionsResponse, error) {
+	broker := sarama.NewBroker(addr)
+	err := broker.Open(saramaConfig)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = broker.Close()
+	}()
+
+	res, err := broker.ApiVersions(&sarama.ApiVersionsRequest{})
+	if err != nil {
+		return nil, err
+	}
+	if kErr := sarama.KError(res.ErrorCode); kErr != sarama.ErrNoError {
+		return nil, kErr
+	}
+	return res, nil
+}
@@ -0,0 +1,79 @@
+package kafka
+
+import (
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestNegotiatedAPIVersion(t *testing.T) {
+	fetch := sarama.ApiVersionsResponseKey{ApiKey: 1, MinVersion: 0, MaxVersion: 12}
+	tt := []struct {
+		name         string
+		apiKey       int16
+		kafkaVersion sarama.KafkaVersion
+		broker       sarama.ApiVersionsResponseKey
+		isSupported  bool
+		version      int16
+	}{
+		{"fetch with kafka 1.0", 1, sarama.V1_0_0_0, fetch, true, 4},
+		{"fetch with kafka 2.6", 1, sarama.V2_6_0_0, fetch, true, 11},
+		{"client version above broker max", 1, sarama.V2_6_0_0, sarama.ApiVersionsResponseKey{ApiKey: 1, MaxVersion: 10}, false, 0},
+		{"client version below broker min", 3, sarama.V0_10_0_0, sarama.ApiVersionsResponseKey{ApiKey: 3, MinVersion: 4, MaxVersion: 9}, false, 0},
+		{"api requires newer kafka version", 44, sarama.V2_0_0_0, sarama.ApiVersionsResponseKey{ApiKey: 44, MaxVersion: 1}, false, 0},
+		{"api not used by the client", 57, sarama.V2_8_0_0, sarama.ApiVersionsResponseKey{ApiKey: 57, MaxVersion: 0}, false, 0},
+		{"describe configs with kafka 2.0", 32, sarama.V2_0_0_0, sarama.ApiVersionsResponseKey{ApiKey: 32, MaxVersion: 4}, true, 2},
+	}
+	for _, table := range tt {
+		version, ok := NegotiatedAPIVersion(table.apiKey, table.kafkaVersion, table.broker)
+		assert.Equal(t, table.isSupported, ok, table.name)
+		assert.Equal(t, table.version, version, table.name)
+	}
+}
+
+func TestInferKafkaVersion(t *testing.T) {
+	apiKeys := func(keys ...sarama.ApiVersionsResponseKey) []sarama.ApiVersionsResponseKey {
+		return keys
+	}
+	tt := []struct {
+		name     string
+		apiKeys  []sarama.ApiVersionsResponseKey
+		expected sarama.KafkaVersion
+	}{
+		{"only api versions", apiKeys(sarama.ApiVersionsResponseKey{ApiKey: 18, MaxVersion: 0}), sarama.V0_10_0_0},
+		{"delete records", apiKeys(sarama.ApiVersionsResponseKey{ApiKey: 19, MaxVersion: 2}, sarama.ApiVersionsResponseKey{ApiKey: 21, MaxVersion: 0}), sarama.V0_11_0_0},
+		{"fetch v7 is older than 2.1", apiKeys(sarama.ApiVersionsResponseKey{ApiKey: 1, MaxVersion: 7}, sarama.ApiVersionsResponseKey{ApiKey: 42, MaxVersion: 0}), sarama.V1_1_0_0},
+		{"fetch v10", apiKeys(sarama.ApiVersionsResponseKey{ApiKey: 1, MaxVersion: 10}, sarama.ApiVersionsResponseKey{ApiKey: 42, MaxVersion: 1}), sarama.V2_1_0_0},
+		{"client quotas", apiKeys(sarama.ApiVersionsResponseKey{ApiKey: 45, MaxVersion: 0}, sarama.ApiVersionsResponseKey{ApiKey: 48, MaxVersion: 0}), sarama.V2_6_0_0},
+	}
+	for _, table := range tt {
+		assert.Equal(t, table.expected, inferKafkaVersion(table.apiKeys), table.name)
+	}
+}
+
+func TestResolveClusterVersion(t *testing.T) {
+	broker := sarama.NewMockBroker(t, 1)
+	defer broker.Close()
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"ApiVersionsRequest": sarama.NewMockWrapper(&sarama.ApiVersionsResponse{
+			ApiKeys: []sarama.ApiVersionsResponseKey{{ApiKey: 1, MaxVersion: 11}, {ApiKey: 44, MaxVersion: 1}},
+		}),
+	})
+
+	cfg := Config{}
+	cfg.SetDefaults()
+	cfg.Brokers = []string{"127.0.0.1:1", broker.Addr()}
+	cfg.ClusterVersion = ClusterVersionAuto
+
+	// Unreachable seed brokers are skipped
+	require.NoError(t, resolveClusterVersion(&cfg, zap.NewNop()))
+	assert.Equal(t, sarama.V2_3_0_0.String(), cfg.ClusterVersion)
+
+	// Configured versions are kept
+	cfg.ClusterVersion = "2.0.0"
+	require.NoError(t, resolveClusterVersion(&cfg, zap.NewNop()))
+	assert.Equal(t, "2.0.0", cfg.ClusterVersion)
+}
@@ -0,0 +1,83 @@
+package kafka
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/session"
+	v4 "github.com/aws/aws-sdk-go/aws/signer/v4"
+)
+
+const (
+	awsMskIamSigningService = "kafka-cluster"
+	awsMskIamTokenExpiry    = 15 * time.Minute
+)
+
+// awsMskIamTokenProvider implements sarama's AccessTokenProvider. The token is a base64 encoded, SigV4 presigned
+// kafka-cluster:Connect URL as expected by AWS MSK brokers for SASL/OAUTHBEARER.
+type awsMskIamTokenProvider struct {
+	region      string
+	credentials *credentials.Credentials
+}
+
+func newAWSMskIamTokenProvider(cfg SASLAWSMskIamConfig) (*awsMskIamTokenProvider, error) {
+	awsCfg := aws.NewConfig()
+	if cfg.Region != "" {
+		awsCfg = awsCfg.WithRegion(cfg.Region)
+	}
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Config:            *awsCfg,
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create aws session: %w", err)
+	}
+
+	region := aws.StringValue(sess.Config.Region)
+	if region == "" {
+		return nil, fmt.Errorf("no aws region configured, please set the region in the config or via AWS_REGION")
+	}
+
+	// Credentials are cached and refreshed automatically by the SDK once they have expired
+	creds := sess.Config.Credentials
+	if cfg.RoleARN != "" {
+		creds = stscreds.NewCredentials(sess, cfg.RoleARN, func(p *stscreds.AssumeRoleProvider) {
+			p.RoleSessionName = cfg.SessionName
+		})
+	}
+
+	return &awsMskIamTokenProvider{
+		region:      region,
+		credentials: creds,
+	}, nil
+}
+
+// Token returns a freshly signed token. Sarama requests a token whenever it opens a new broker connection, so that
+// expired temporary credentials are refreshed before the next connection is authenticated.
+func (a *awsMskIamTokenProvider) Token() (*sarama.AccessToken, error) {
+	endpoint := fmt.Sprintf("https://kafka.%s.amazonaws.com/?Action=kafka-cluster%%3AConnect", a.region)
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	signer := v4.NewSigner(a.credentials)
+	_, err = signer.Presign(req, nil, awsMskIamSigningService, a.region, awsMskIamTokenExpiry, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign aws msk iam token: %w", err)
+	}
+
+	query := req.URL.Query()
+	query.Set("User-Agent", "kowl")
+	req.URL.RawQuery = query.Encode()
+
+	return &sarama.AccessToken{
+		Token: base64.RawURLEncoding.EncodeToString([]byte(req.URL.String())),
+	}, nil
+}
@@ -0,0 +1,37 @@
+package kafka
+
+import (
+	"encoding/base64"
+	"net/url"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAWSMskIamTokenProvider_Token(t *testing.T) {
+	provider := &awsMskIamTokenProvider{
+		region:      "eu-central-1",
+		credentials: credentials.NewStaticCredentials("AKID", "SECRET", "SESSION"),
+	}
+
+	token, err := provider.Token()
+	require.NoError(t, err)
+
+	decoded, err := base64.RawURLEncoding.DecodeString(token.Token)
+	require.NoError(t, err)
+	signedURL, err := url.Parse(string(decoded))
+	require.NoError(t, err)
+
+	assert.Equal(t, "kafka.eu-central-1.amazonaws.com", signedURL.Host)
+	query := signedURL.Query()
+	assert.Equal(t, "kafka-cluster:Connect", query.Get("Action"))
+	assert.Equal(t, "AWS4-HMAC-SHA256", query.Get("X-Amz-Algorithm"))
+	assert.Contains(t, query.Get("X-Amz-Credential"), "AKID/")
+	assert.Contains(t, query.Get("X-Amz-Credential"), "/eu-central-1/kafka-cluster/aws4_request")
+	assert.Equal(t, "SESSION", query.Get("X-Amz-Security-Token"))
+	assert.Equal(t, "900", query.Get("X-Amz-Expires"))
+	assert.NotEmpty(t, query.Get("X-Amz-Signature"))
+	assert.Equal(t, "kowl", query.Get("User-Agent"))
+}
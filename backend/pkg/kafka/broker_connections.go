@@ -0,0 +1,76 @@
+package kafka
+
+import (
+	"net"
+	"sync"
+
+	"golang.org/x/net/proxy"
+)
+
+// connectionTracker counts the open connections per address. Sarama does not expose its connections, hence they are
+// counted by the dialer of the sarama config (see clusterClientMetrics).
+type connectionTracker struct {
+	mutex       sync.Mutex
+	connections map[string]int
+}
+
+func newConnectionTracker() *connectionTracker {
+	return &connectionTracker{connections: make(map[string]int)}
+}
+
+// Count returns the number of open connections to the given address
+func (t *connectionTracker) Count(addr string) int {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	return t.connections[addr]
+}
+
+func (t *connectionTracker) add(addr string, delta int) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.connections[addr] += delta
+	if t.connections[addr] <= 0 {
+		delete(t.connections, addr)
+	}
+}
+
+// countingDialer dials connections with the forward dialer (e.g. a proxy dialer) and tracks them until they are
+// closed. TLS is established by sarama on top of the returned connection, closing the TLS connection closes the
+// tracked connection as well.
+type countingDialer struct {
+	forward proxy.Dialer
+	tracker *connectionTracker
+}
+
+func newCountingDialer(forward proxy.Dialer, tracker *connectionTracker) *countingDialer {
+	return &countingDialer{forward: forward, tracker: tracker}
+}
+
+func (d *countingDialer) Dial(network, addr string) (net.Conn, error) {
+	conn, err := d.forward.Dial(network, addr)
+	if err != nil {
+		return nil, err
+	}
+	d.tracker.add(addr, 1)
+
+	return &trackedConn{Conn: conn, onClose: func() { d.tracker.add(addr, -1) }}, nil
+}
+
+// String is logged by sarama whenever a connection is dialed
+func (d *countingDialer) String() string {
+	return "connection counting dialer"
+}
+
+// trackedConn calls onClose once, when the connection is closed for the first time
+type trackedConn struct {
+	net.Conn
+	closeOnce sync.Once
+	onClose   func()
+}
+
+func (c *trackedConn) Close() error {
+	c.closeOnce.Do(c.onClose)
+	return c.Conn.Close()
+}
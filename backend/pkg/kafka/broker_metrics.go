@@ -0,0 +1,122 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/rcrowley/go-metrics"
+)
+
+// BrokerMetrics are the throughput and request metrics of Kowl's connections to a single broker as recorded by
+// sarama's metric registry. Rates are one minute moving averages. Unless client metrics are enabled, only the
+// requests of the shared client (admin requests and consumers) are included. With client metrics enabled the registry
+// is shared by all clients and clusters, hence brokers with the same id in different clusters share their metrics.
+type BrokerMetrics struct {
+	BrokerID    int32  `json:"brokerId"`
+	Address     string `json:"address"`
+	IsConnected bool   `json:"isConnected"`
+
+	// Connections is the number of open connections of all clients to the broker
+	Connections int `json:"connections"`
+
+	IncomingBytesPerSecond float64 `json:"incomingBytesPerSecond"`
+	OutgoingBytesPerSecond float64 `json:"outgoingBytesPerSecond"`
+	RequestsPerSecond      float64 `json:"requestsPerSecond"`
+	ResponsesPerSecond     float64 `json:"responsesPerSecond"`
+
+	IncomingBytesTotal int64 `json:"incomingBytesTotal"`
+	OutgoingBytesTotal int64 `json:"outgoingBytesTotal"`
+	RequestsTotal      int64 `json:"requestsTotal"`
+	RequestsInFlight   int64 `json:"requestsInFlight"`
+
+	RequestLatencyMeanMs float64 `json:"requestLatencyMeanMs"`
+	RequestLatencyP99Ms  float64 `json:"requestLatencyP99Ms"`
+
+	// MetadataRefreshedAt is the time at which the cluster metadata (brokers and their addresses) has been refreshed
+	// and CollectedAt the time at which the metrics have been read
+	MetadataRefreshedAt time.Time `json:"metadataRefreshedAt"`
+	CollectedAt         time.Time `json:"collectedAt"`
+}
+
+// brokerMetricsCache holds the metrics of all brokers, which are collected at most once per refresh interval. The
+// mutex is held while the metrics are collected, so that concurrent requests share a single metadata refresh.
+type brokerMetricsCache struct {
+	mutex   sync.Mutex
+	brokers map[int32]*BrokerMetrics
+
+	// collectedAt is zero until the metrics have been collected for the first time
+	collectedAt time.Time
+}
+
+// GetBrokerMetrics returns the (cached) metrics of a single broker. ErrBrokerNotFound is returned if the broker is not
+// part of the cluster metadata.
+func (s *Service) GetBrokerMetrics(ctx context.Context, brokerID int32) (res *BrokerMetrics, err error) {
+	defer s.observeOperation(ctx, operationTypeAdmin, "get_broker_metrics", time.Now(), &err)
+
+	c := &s.brokerMetrics
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.brokers == nil || time.Since(c.collectedAt) >= s.Config.BrokerMetricsRefreshInterval {
+		err = runWithContext(ctx, func() error {
+			return s.Client.RefreshMetadata()
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to refresh cluster metadata: %w", err)
+		}
+		c.collectedAt = time.Now()
+		c.brokers = collectBrokerMetrics(s.Client.Config().MetricRegistry, s.Client.Brokers(), s.Config.clientMetrics.connections, c.collectedAt)
+	}
+
+	brokerMetrics, exists := c.brokers[brokerID]
+	if !exists {
+		return nil, ErrBrokerNotFound
+	}
+	return brokerMetrics, nil
+}
+
+// collectBrokerMetrics reads the per broker metrics of the given brokers from sarama's metric registry
+func collectBrokerMetrics(registry metrics.Registry, brokers []*sarama.Broker, connections *connectionTracker, metadataRefreshedAt time.Time) map[int32]*BrokerMetrics {
+	res := make(map[int32]*BrokerMetrics, len(brokers))
+	for _, broker := range brokers {
+		connected, _ := broker.Connected()
+		m := &BrokerMetrics{
+			BrokerID:            broker.ID(),
+			Address:             broker.Addr(),
+			IsConnected:         connected,
+			Connections:         connections.Count(broker.Addr()),
+			MetadataRefreshedAt: metadataRefreshedAt,
+			CollectedAt:         time.Now(),
+		}
+
+		metricName := func(name string) string {
+			return fmt.Sprintf("%v-for-broker-%d", name, broker.ID())
+		}
+		if meter, ok := registry.Get(metricName("incoming-byte-rate")).(metrics.Meter); ok {
+			m.IncomingBytesPerSecond, m.IncomingBytesTotal = meter.Rate1(), meter.Count()
+		}
+		if meter, ok := registry.Get(metricName("outgoing-byte-rate")).(metrics.Meter); ok {
+			m.OutgoingBytesPerSecond, m.OutgoingBytesTotal = meter.Rate1(), meter.Count()
+		}
+		if meter, ok := registry.Get(metricName("request-rate")).(metrics.Meter); ok {
+			m.RequestsPerSecond, m.RequestsTotal = meter.Rate1(), meter.Count()
+		}
+		if meter, ok := registry.Get(metricName("response-rate")).(metrics.Meter); ok {
+			m.ResponsesPerSecond = meter.Rate1()
+		}
+		if counter, ok := registry.Get(metricName("requests-in-flight")).(metrics.Counter); ok {
+			m.RequestsInFlight = counter.Count()
+		}
+		if histogram, ok := registry.Get(metricName("request-latency-in-ms")).(metrics.Histogram); ok {
+			snapshot := histogram.Snapshot()
+			m.RequestLatencyMeanMs, m.RequestLatencyP99Ms = snapshot.Mean(), snapshot.Percentile(0.99)
+		}
+
+		res[broker.ID()] = m
+	}
+
+	return res
+}
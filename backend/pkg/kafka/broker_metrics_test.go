@@ -0,0 +1,66 @@
+package kafka
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/rcrowley/go-metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollectBrokerMetrics(t *testing.T) {
+	// Brokers which are not part of a metadata response have the id -1
+	broker := sarama.NewBroker("kafka-0:9092")
+	registry := metrics.NewRegistry()
+	metrics.GetOrRegisterMeter("incoming-byte-rate-for-broker--1", registry).Mark(2048)
+	metrics.GetOrRegisterMeter("request-rate-for-broker--1", registry).Mark(4)
+	metrics.GetOrRegisterCounter("requests-in-flight-for-broker--1", registry).Inc(1)
+	latency := metrics.GetOrRegisterHistogram("request-latency-in-ms-for-broker--1", registry, metrics.NewUniformSample(10))
+	latency.Update(10)
+	latency.Update(30)
+	metrics.GetOrRegisterMeter("incoming-byte-rate-for-broker-2", registry).Mark(1)
+
+	tracker := newConnectionTracker()
+	tracker.add("kafka-0:9092", 2)
+
+	refreshedAt := time.Now()
+	res := collectBrokerMetrics(registry, []*sarama.Broker{broker}, tracker, refreshedAt)
+	require.Len(t, res, 1)
+	m := res[-1]
+	assert.Equal(t, "kafka-0:9092", m.Address)
+	assert.False(t, m.IsConnected)
+	assert.Equal(t, 2, m.Connections)
+	assert.Equal(t, int64(2048), m.IncomingBytesTotal)
+	assert.Equal(t, int64(4), m.RequestsTotal)
+	assert.Equal(t, int64(1), m.RequestsInFlight)
+	assert.Equal(t, float64(20), m.RequestLatencyMeanMs)
+	assert.Equal(t, refreshedAt, m.MetadataRefreshedAt)
+}
+
+type pipeDialer struct{}
+
+func (pipeDialer) Dial(_, _ string) (net.Conn, error) {
+	conn, _ := net.Pipe()
+	return conn, nil
+}
+
+func TestCountingDialer(t *testing.T) {
+	tracker := newConnectionTracker()
+	dialer := newCountingDialer(pipeDialer{}, tracker)
+
+	first, err := dialer.Dial("tcp", "kafka-0:9092")
+	require.NoError(t, err)
+	second, err := dialer.Dial("tcp", "kafka-0:9092")
+	require.NoError(t, err)
+	assert.Equal(t, 2, tracker.Count("kafka-0:9092"))
+
+	// Closing a connection twice must only be counted once
+	require.NoError(t, first.Close())
+	_ = first.Close()
+	assert.Equal(t, 1, tracker.Count("kafka-0:9092"))
+	require.NoError(t, second.Close())
+	assert.Equal(t, 0, tracker.Count("kafka-0:9092"))
+}
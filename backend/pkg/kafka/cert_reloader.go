@@ -0,0 +1,236 @@
+package kafka
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// tlsMaterial are the client certificates and the optional CA pool which have been loaded by a certLoader. The CA
+// pool is nil if the CAs are not reloaded.
+type tlsMaterial struct {
+	certs  []tls.Certificate
+	caPool *x509.CertPool
+}
+
+// certLoader loads the TLS material, e.g. from disk or from Vault
+type certLoader func() (*tlsMaterial, error)
+
+// fileCertLoader loads the given cert pairs from disk
+func fileCertLoader(pairs []CertPair) certLoader {
+	return func() (*tlsMaterial, error) {
+		certs, err := parseCerts(pairs)
+		if err != nil {
+			return nil, err
+		}
+		return &tlsMaterial{certs: certs}, nil
+	}
+}
+
+// certReloader reloads the client certificates (and CAs) so that rotated certificates are picked up without
+// restarting Kowl. If reloading fails, the last successfully loaded certificates will be used. The material is loaded
+// without holding the mutex, so that TLS handshakes are not blocked by a slow certificate source.
+type certReloader struct {
+	logger          *zap.Logger
+	load            certLoader
+	refreshInterval time.Duration
+
+	// staticCAPool holds the configured CAs, which are used if the loader returns no CAs. If it is nil as well, the
+	// system's cert pool is used.
+	staticCAPool *x509.CertPool
+
+	// watchToken renews the credentials of the certificate source independently of the refresh interval, if the
+	// source requires it (Vault tokens). It returns once the stop channel is closed.
+	watchToken func(stop <-chan struct{})
+
+	mutex      sync.Mutex
+	certs      []tls.Certificate
+	caPool     *x509.CertPool
+	lastReload time.Time
+	reloading  bool
+}
+
+func newCertReloader(load certLoader, refreshInterval time.Duration, logger *zap.Logger) (*certReloader, error) {
+	material, err := load()
+	if err != nil {
+		return nil, err
+	}
+
+	return &certReloader{
+		logger:          logger,
+		load:            load,
+		refreshInterval: refreshInterval,
+		certs:           material.certs,
+		caPool:          material.caPool,
+		lastReload:      time.Now(),
+	}, nil
+}
+
+// GetClientCertificate can be used as tls.Config.GetClientCertificate. It returns the first certificate which
+// satisfies the broker's certificate request. Certificates are reloaded if the cached ones are older than the refresh
+// interval.
+func (c *certReloader) GetClientCertificate(cri *tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	c.reloadIfStale()
+
+	c.mutex.Lock()
+	certs := c.certs
+	c.mutex.Unlock()
+
+	if len(certs) == 0 {
+		// Sending no certificate at all lets the broker decide whether that's fine or not
+		return &tls.Certificate{}, nil
+	}
+	for i := range certs {
+		if err := cri.SupportsCertificate(&certs[i]); err == nil {
+			return &certs[i], nil
+		}
+	}
+
+	return &certs[0], nil
+}
+
+// watch reloads the certificates in the configured refresh interval, so that certificate changes are logged even if
+// no new connections are established. It returns once the stop channel is closed.
+func (c *certReloader) watch(stop <-chan struct{}) {
+	ticker := time.NewTicker(c.refreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			c.reloadIfStale()
+		}
+	}
+}
+
+// VerifyConnection can be used as tls.Config.VerifyConnection if the reloader loads the CAs. It verifies the broker's
+// certificate chain against the most recently loaded CAs.
+func (c *certReloader) VerifyConnection(cs tls.ConnectionState) error {
+	c.reloadIfStale()
+
+	c.mutex.Lock()
+	caPool := c.caPool
+	c.mutex.Unlock()
+	if caPool == nil {
+		caPool = c.staticCAPool
+	}
+
+	if len(cs.PeerCertificates) == 0 {
+		return fmt.Errorf("broker presented no certificate")
+	}
+	intermediates := x509.NewCertPool()
+	for _, cert := range cs.PeerCertificates[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err := cs.PeerCertificates[0].Verify(x509.VerifyOptions{
+		Roots:         caPool,
+		Intermediates: intermediates,
+		DNSName:       cs.ServerName,
+	})
+	return err
+}
+
+// reloadIfStale loads the certificates again if they are older than the refresh interval. If another reload is in
+// progress already, the current certificates are kept until that reload completes.
+func (c *certReloader) reloadIfStale() {
+	c.mutex.Lock()
+	if c.reloading || time.Since(c.lastReload) <= c.refreshInterval {
+		c.mutex.Unlock()
+		return
+	}
+	c.reloading = true
+	c.mutex.Unlock()
+
+	material, err := c.load()
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.reloading = false
+	c.lastReload = time.Now()
+	if err != nil {
+		c.logger.Warn("failed to reload tls client certificates, continuing to use the previously loaded ones", zap.Error(err))
+		return
+	}
+
+	for i := range material.certs {
+		if i >= len(c.certs) || !isSameCertificate(c.certs[i], material.certs[i]) {
+			c.logger.Info("tls client certificate has changed and has been reloaded", zap.Int("certificate_index", i))
+		}
+	}
+	c.certs = material.certs
+	c.caPool = material.caPool
+}
+
+func isSameCertificate(a, b tls.Certificate) bool {
+	if len(a.Certificate) != len(b.Certificate) {
+		return false
+	}
+	for i := range a.Certificate {
+		if !bytes.Equal(a.Certificate[i], b.Certificate[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// hasFileBasedCerts returns true if at least one of the cert pairs is loaded from disk
+func hasFileBasedCerts(pairs []CertPair) bool {
+	for _, pair := range pairs {
+		if !pair.isInline() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// setupCertReloader creates a cert reloader for file based client certificates if a refresh interval is configured
+// or for the TLS material from Vault, and hooks it into the TLS config. It returns nil if no reloader is required.
+func setupCertReloader(cfg *TLSConfig, logger *zap.Logger) (*certReloader, error) {
+	if cfg.GetClientCertificate != nil {
+		return nil, nil
+	}
+
+	if cfg.Vault.Enabled {
+		client := newVaultClient(cfg.Vault, logger.With(zap.String("source", "vault")))
+		reloader, err := newCertReloader(client.loadTLSMaterial, cfg.vaultRefreshInterval(), logger)
+		if err != nil {
+			return nil, &ErrTLSConfig{Err: fmt.Errorf("failed to fetch tls material from vault: %w", err)}
+		}
+		if cfg.hasCustomCAs() {
+			reloader.staticCAPool, _, err = loadCACertPool(cfg)
+			if err != nil {
+				return nil, &ErrTLSConfig{Err: err}
+			}
+		}
+		reloader.watchToken = client.watchToken
+		cfg.GetClientCertificate = reloader.GetClientCertificate
+		cfg.VerifyConnection = reloader.VerifyConnection
+		return reloader, nil
+	}
+
+	if cfg.RefreshInterval <= 0 {
+		return nil, nil
+	}
+
+	pairs := cfg.certPairs()
+	if !hasFileBasedCerts(pairs) {
+		return nil, nil
+	}
+
+	reloader, err := newCertReloader(fileCertLoader(pairs), cfg.RefreshInterval, logger)
+	if err != nil {
+		return nil, &ErrTLSConfig{Err: fmt.Errorf("failed to load tls client certificates: %w", err)}
+	}
+	cfg.GetClientCertificate = reloader.GetClientCertificate
+
+	return reloader, nil
+}
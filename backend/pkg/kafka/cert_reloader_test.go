@@ -0,0 +1,131 @@
+package kafka
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestCertReloader_GetClientCertificate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kowl-certs")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	pair := CertPair{CertFilepath: filepath.Join(dir, "tls.crt"), KeyFilepath: filepath.Join(dir, "tls.key")}
+	writeSelfSignedCert(t, pair, "first")
+
+	reloader, err := newCertReloader(fileCertLoader([]CertPair{pair}), time.Minute, zap.NewNop())
+	require.NoError(t, err)
+
+	cri := &tls.CertificateRequestInfo{}
+	cert, err := reloader.GetClientCertificate(cri)
+	require.NoError(t, err)
+	assert.Equal(t, "first", parseLeaf(t, cert).Subject.CommonName)
+
+	// Rotated certificate is picked up after the refresh interval
+	writeSelfSignedCert(t, pair, "second")
+	reloader.lastReload = time.Now().Add(-2 * time.Minute)
+	cert, err = reloader.GetClientCertificate(cri)
+	require.NoError(t, err)
+	assert.Equal(t, "second", parseLeaf(t, cert).Subject.CommonName)
+
+	// A broken certificate on disk must not replace the last good certificate
+	require.NoError(t, ioutil.WriteFile(pair.CertFilepath, []byte("broken"), 0600))
+	reloader.lastReload = time.Now().Add(-2 * time.Minute)
+	cert, err = reloader.GetClientCertificate(cri)
+	require.NoError(t, err)
+	assert.Equal(t, "second", parseLeaf(t, cert).Subject.CommonName)
+}
+
+func TestCertReloader_VerifyConnection_StaticCAs(t *testing.T) {
+	certPEM, tlsCert := newLocalhostCert(t)
+	leaf, err := x509.ParseCertificate(tlsCert.Certificate[0])
+	require.NoError(t, err)
+	cs := tls.ConnectionState{PeerCertificates: []*x509.Certificate{leaf}, ServerName: "127.0.0.1"}
+
+	// The loader returns no CAs, hence the broker's certificate is verified against the static CAs
+	reloader, err := newCertReloader(func() (*tlsMaterial, error) { return &tlsMaterial{}, nil }, time.Minute, zap.NewNop())
+	require.NoError(t, err)
+	assert.Error(t, reloader.VerifyConnection(cs), "the system cert pool must be used without static CAs")
+
+	reloader.staticCAPool = x509.NewCertPool()
+	require.True(t, reloader.staticCAPool.AppendCertsFromPEM(certPEM))
+	assert.NoError(t, reloader.VerifyConnection(cs))
+}
+
+func TestCertReloader_ReloadDoesNotBlockHandshakes(t *testing.T) {
+	loading := make(chan struct{})
+	unblock := make(chan struct{})
+	calls := 0
+	load := func() (*tlsMaterial, error) {
+		calls++
+		if calls > 1 {
+			close(loading)
+			<-unblock
+		}
+		return &tlsMaterial{certs: []tls.Certificate{{Certificate: [][]byte{{byte(calls)}}}}}, nil
+	}
+	reloader, err := newCertReloader(load, time.Minute, zap.NewNop())
+	require.NoError(t, err)
+
+	reloader.lastReload = time.Now().Add(-2 * time.Minute)
+	reloaded := make(chan struct{})
+	go func() {
+		reloader.reloadIfStale()
+		close(reloaded)
+	}()
+	<-loading
+
+	// While the slow reload is in progress, the previous certificate is returned right away
+	cert, err := reloader.GetClientCertificate(&tls.CertificateRequestInfo{})
+	require.NoError(t, err)
+	assert.Equal(t, []byte{1}, cert.Certificate[0])
+
+	close(unblock)
+	<-reloaded
+	cert, err = reloader.GetClientCertificate(&tls.CertificateRequestInfo{})
+	require.NoError(t, err)
+	assert.Equal(t, []byte{2}, cert.Certificate[0])
+}
+
+func writeSelfSignedCert(t *testing.T, pair CertPair, commonName string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	require.NoError(t, ioutil.WriteFile(pair.CertFilepath, certPEM, 0600))
+	require.NoError(t, ioutil.WriteFile(pair.KeyFilepath, keyPEM, 0600))
+}
+
+func parseLeaf(t *testing.T, cert *tls.Certificate) *x509.Certificate {
+	require.NotEmpty(t, cert.Certificate)
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	require.NoError(t, err)
+	return leaf
+}
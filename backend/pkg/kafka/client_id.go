@@ -0,0 +1,100 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Placeholders which can be used in the consumer's client id template
+const (
+	clientIDPlaceholderClientID  = "{clientID}"
+	clientIDPlaceholderUser      = "{user}"
+	clientIDPlaceholderRequestID = "{reqid}"
+)
+
+// clientIDUnknownValue replaces placeholders whose value is not known, e.g. the user of unauthenticated requests
+const clientIDUnknownValue = "unknown"
+
+// clientIDMaxValueLength is the max length of a value which is inserted for a placeholder, so that client ids stay
+// readable in the broker logs
+const clientIDMaxValueLength = 64
+
+var (
+	// clientIDPlaceholderRegex matches all placeholders of a template, including unknown ones
+	clientIDPlaceholderRegex = regexp.MustCompile(`{[^{}]*}`)
+
+	// invalidClientIDCharsRegex matches all characters which are not allowed in client ids by Kafka
+	invalidClientIDCharsRegex = regexp.MustCompile(`[^a-zA-Z0-9._-]`)
+)
+
+// userKey is the context key of the user who has initiated a request
+type userKey struct{}
+
+// ContextWithUser returns a context which carries the name of the user who has initiated the request. It is inserted
+// into the client id of consumers if a client id template is configured.
+func ContextWithUser(ctx context.Context, user string) context.Context {
+	return context.WithValue(ctx, userKey{}, user)
+}
+
+// UserFromContext returns the user of the context or an empty string if it has none
+func UserFromContext(ctx context.Context) string {
+	user, _ := ctx.Value(userKey{}).(string)
+	return user
+}
+
+// validateClientIDTemplate checks that the template only contains known placeholders and characters which are valid
+// in client ids. The template must contain the base client id, so that all of Kowl's clients can still be identified.
+func validateClientIDTemplate(template string) error {
+	if !strings.Contains(template, clientIDPlaceholderClientID) {
+		return fmt.Errorf("the template must contain the placeholder %v", clientIDPlaceholderClientID)
+	}
+	for _, placeholder := range clientIDPlaceholderRegex.FindAllString(template, -1) {
+		switch placeholder {
+		case clientIDPlaceholderClientID, clientIDPlaceholderUser, clientIDPlaceholderRequestID:
+		default:
+			return fmt.Errorf("unknown placeholder '%v', supported placeholders are %v, %v and %v", placeholder,
+				clientIDPlaceholderClientID, clientIDPlaceholderUser, clientIDPlaceholderRequestID)
+		}
+	}
+	literals := clientIDPlaceholderRegex.ReplaceAllString(template, "")
+	if invalidClientIDCharsRegex.MatchString(literals) {
+		return fmt.Errorf("the template may only contain the characters a-z, A-Z, 0-9, '.', '_' and '-' besides placeholders")
+	}
+
+	return nil
+}
+
+// renderClientID replaces the placeholders of the template. The user and request id are sanitized, so that the
+// resulting client id is valid regardless of their values.
+func renderClientID(template string, clientID string, user string, requestID string) string {
+	return strings.NewReplacer(
+		clientIDPlaceholderClientID, clientID,
+		clientIDPlaceholderUser, sanitizeClientIDValue(user),
+		clientIDPlaceholderRequestID, sanitizeClientIDValue(requestID),
+	).Replace(template)
+}
+
+// sanitizeClientIDValue replaces all characters which are not allowed in client ids with underscores and truncates
+// the value to clientIDMaxValueLength
+func sanitizeClientIDValue(value string) string {
+	if value == "" {
+		return clientIDUnknownValue
+	}
+	value = invalidClientIDCharsRegex.ReplaceAllString(value, "_")
+	if len(value) > clientIDMaxValueLength {
+		value = value[:clientIDMaxValueLength]
+	}
+	return value
+}
+
+// consumerClientID returns the client id of consumers which are created for the request of the context. It is the
+// configured client id, unless a client id template is configured.
+func (s *Service) consumerClientID(ctx context.Context) string {
+	if s.Config.Consumer.ClientIDTemplate == "" {
+		return s.Config.ClientID
+	}
+	return renderClientID(s.Config.Consumer.ClientIDTemplate, s.Config.ClientID, UserFromContext(ctx),
+		CorrelationIDFromContext(ctx))
+}
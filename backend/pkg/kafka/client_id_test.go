@@ -0,0 +1,98 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestValidateClientIDTemplate(t *testing.T) {
+	assert.NoError(t, validateClientIDTemplate("{clientID}-{user}-{reqid}"))
+	assert.NoError(t, validateClientIDTemplate("{clientID}.console"))
+
+	assert.Error(t, validateClientIDTemplate("{user}-{reqid}"), "the base client id must be preserved")
+	assert.Error(t, validateClientIDTemplate("{clientID}-{group}"), "unknown placeholder")
+	assert.Error(t, validateClientIDTemplate("{clientID} {user}"), "invalid character")
+}
+
+func TestRenderClientID(t *testing.T) {
+	template := "{clientID}-{user}-{reqid}"
+	assert.Equal(t, "kowl-jane_doe_example.com-host_abc-000001",
+		renderClientID(template, "kowl", "jane doe@example.com", "host/abc-000001"))
+	assert.Equal(t, "kowl-unknown-unknown", renderClientID(template, "kowl", "", ""))
+}
+
+func TestNewConsumer_ClientIDTemplate(t *testing.T) {
+	broker := sarama.NewMockBroker(t, 1)
+	defer broker.Close()
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).SetBroker(broker.Addr(), broker.BrokerID()),
+	})
+
+	cfg := Config{}
+	cfg.SetDefaults()
+	cfg.Brokers = []string{broker.Addr()}
+	sharedConfig, err := NewConsumerConfig(&cfg, ConsumerConfigOverride{})
+	require.NoError(t, err)
+	assert.Equal(t, "kowl", sharedConfig.ClientID, "the base client id is kept without override")
+	client, err := sarama.NewClient(cfg.Brokers, sharedConfig)
+	require.NoError(t, err)
+	defer client.Close()
+
+	ctx := ContextWithCorrelationID(ContextWithUser(context.Background(), "jane"), "req-1")
+
+	// Without a template the shared client is used
+	svc := &Service{Client: client, Config: cfg, Logger: zap.NewNop()}
+	consumer, err := svc.NewConsumer(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, consumer.(*trackedConsumer).client)
+	require.NoError(t, consumer.Close())
+
+	// With a template each consumer has its own client
+	svc.Config.Consumer.ClientIDTemplate = "{clientID}-{user}-{reqid}"
+	require.NoError(t, svc.Config.Validate())
+	consumer, err = svc.NewConsumer(ctx)
+	require.NoError(t, err)
+	ownClient := consumer.(*trackedConsumer).client
+	require.NotNil(t, ownClient)
+	assert.Equal(t, "kowl-jane-req-1", ownClient.Config().ClientID)
+	require.NoError(t, consumer.Close())
+	assert.True(t, ownClient.Closed())
+	assert.False(t, client.Closed())
+}
+
+func TestNewConsumer_ClientIDTemplateContextDone(t *testing.T) {
+	// The broker accepts connections, but never responds to the metadata request of the new client
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	cfg := Config{}
+	cfg.SetDefaults()
+	cfg.Brokers = []string{listener.Addr().String()}
+	cfg.Consumer.ClientIDTemplate = "{clientID}-{user}-{reqid}"
+	svc := &Service{Config: cfg, Logger: zap.NewNop()}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err = svc.NewConsumer(ctx)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
@@ -0,0 +1,138 @@
+package kafka
+
+import (
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rcrowley/go-metrics"
+)
+
+// clientMetricsPrefix is the prefix of all exported sarama metrics
+const clientMetricsPrefix = "kafka_client_"
+
+// clusterClientMetrics are the go-metrics registry and the open broker connections which are shared by all sarama
+// clients (admin, consumers and producers) of a cluster, so that their metrics end up in one place. Each cluster has
+// its own, because sarama names the per broker metrics by broker id, which is not unique across clusters.
+type clusterClientMetrics struct {
+	registry    metrics.Registry
+	connections *connectionTracker
+}
+
+func newClusterClientMetrics() *clusterClientMetrics {
+	return &clusterClientMetrics{registry: metrics.NewRegistry(), connections: newConnectionTracker()}
+}
+
+// clientMetricsCollector translates the go-metrics which are recorded by sarama into prometheus metrics whenever
+// they are scraped. Sarama records most metrics once in total and once per broker ('-for-broker-<id>') or per topic
+// ('-for-topic-<name>'). The per broker and per topic variants are exported with a broker or topic label, the totals
+// are only exported if there is no labelled variant, because they can be aggregated in prometheus.
+type clientMetricsCollector struct {
+	registry metrics.Registry
+}
+
+func newClientMetricsCollector(registry metrics.Registry) *clientMetricsCollector {
+	return &clientMetricsCollector{registry: registry}
+}
+
+// Describe sends no descriptors, which makes this an unchecked collector. The set of sarama metrics grows with the
+// number of brokers and topics and is not known upfront.
+func (c *clientMetricsCollector) Describe(chan<- *prometheus.Desc) {}
+
+func (c *clientMetricsCollector) Collect(ch chan<- prometheus.Metric) {
+	type namedMetric struct {
+		name   clientMetricName
+		metric interface{}
+	}
+	collected := make([]namedMetric, 0)
+	hasLabelledVariant := make(map[string]bool)
+	c.registry.Each(func(name string, metric interface{}) {
+		metricName := parseClientMetricName(name)
+		if metricName.labelName != "" {
+			hasLabelledVariant[metricName.name] = true
+		}
+		collected = append(collected, namedMetric{name: metricName, metric: metric})
+	})
+
+	for _, m := range collected {
+		if m.name.labelName == "" && hasLabelledVariant[m.name.name] {
+			continue
+		}
+		if metric := newClientMetric(m.name, m.metric); metric != nil {
+			ch <- metric
+		}
+	}
+}
+
+// clientMetricName is the prometheus compatible name of a sarama metric along with its optional broker or topic label
+type clientMetricName struct {
+	name       string
+	labelName  string
+	labelValue string
+}
+
+// parseClientMetricName splits a sarama metric name such as 'request-latency-in-ms-for-broker-1' into the metric
+// name 'request_latency_in_ms' and the label broker=1
+func parseClientMetricName(name string) clientMetricName {
+	metricName := clientMetricName{name: name}
+	for _, label := range []string{"broker", "topic"} {
+		separator := "-for-" + label + "-"
+		if i := strings.Index(name, separator); i > 0 {
+			metricName = clientMetricName{name: name[:i], labelName: label, labelValue: name[i+len(separator):]}
+			break
+		}
+	}
+	metricName.name = strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, metricName.name)
+
+	return metricName
+}
+
+// newClientMetric converts a single go-metric into a prometheus metric. Meters become counters of the total number of
+// marked events, histograms become summaries of their sample and counters and gauges become gauges, because sarama's
+// counters (e. g. requests in flight) are decremented as well. Unknown metric types return nil.
+func newClientMetric(name clientMetricName, metric interface{}) prometheus.Metric {
+	var labelNames, labelValues []string
+	if name.labelName != "" {
+		labelNames = []string{name.labelName}
+		labelValues = []string{name.labelValue}
+	}
+	newDesc := func(fqName string, help string) *prometheus.Desc {
+		return prometheus.NewDesc(clientMetricsPrefix+fqName, help, labelNames, nil)
+	}
+
+	switch m := metric.(type) {
+	case metrics.Meter:
+		// Meters are named after their rate (e. g. 'request-rate'), but we export the count and leave the rate to
+		// prometheus
+		counterName := strings.TrimSuffix(name.name, "_rate") + "_total"
+		return prometheus.MustNewConstMetric(newDesc(counterName, "Sarama meter "+name.name),
+			prometheus.CounterValue, float64(m.Count()), labelValues...)
+	case metrics.Histogram:
+		snapshot := m.Snapshot()
+		quantiles := []float64{0.5, 0.75, 0.95, 0.99}
+		percentiles := snapshot.Percentiles(quantiles)
+		values := make(map[float64]float64, len(quantiles))
+		for i, quantile := range quantiles {
+			values[quantile] = percentiles[i]
+		}
+		// The histogram only keeps a sample, hence the sum is estimated from its mean
+		sum := snapshot.Mean() * float64(snapshot.Count())
+		return prometheus.MustNewConstSummary(newDesc(name.name, "Sarama histogram "+name.name),
+			uint64(snapshot.Count()), sum, values, labelValues...)
+	case metrics.Counter:
+		return prometheus.MustNewConstMetric(newDesc(name.name, "Sarama counter "+name.name),
+			prometheus.GaugeValue, float64(m.Count()), labelValues...)
+	case metrics.Gauge:
+		return prometheus.MustNewConstMetric(newDesc(name.name, "Sarama gauge "+name.name),
+			prometheus.GaugeValue, float64(m.Value()), labelValues...)
+	case metrics.GaugeFloat64:
+		return prometheus.MustNewConstMetric(newDesc(name.name, "Sarama gauge "+name.name),
+			prometheus.GaugeValue, m.Value(), labelValues...)
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,110 @@
+package kafka
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/rcrowley/go-metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseClientMetricName(t *testing.T) {
+	assert.Equal(t, clientMetricName{name: "request_latency_in_ms", labelName: "broker", labelValue: "1"},
+		parseClientMetricName("request-latency-in-ms-for-broker-1"))
+	assert.Equal(t, clientMetricName{name: "incoming_byte_rate", labelName: "broker", labelValue: "-1"},
+		parseClientMetricName("incoming-byte-rate-for-broker--1"))
+	assert.Equal(t, clientMetricName{name: "batch_size", labelName: "topic", labelValue: "orders-v2"},
+		parseClientMetricName("batch-size-for-topic-orders-v2"))
+	assert.Equal(t, clientMetricName{name: "consumer_batch_size"}, parseClientMetricName("consumer-batch-size"))
+}
+
+func TestClientMetricsCollector(t *testing.T) {
+	registry := metrics.NewRegistry()
+	metrics.GetOrRegisterMeter("request-rate", registry).Mark(3)
+	metrics.GetOrRegisterMeter("request-rate-for-broker-1", registry).Mark(1)
+	metrics.GetOrRegisterMeter("request-rate-for-broker-2", registry).Mark(2)
+	latency := metrics.GetOrRegisterHistogram("request-latency-in-ms-for-broker-1", registry, metrics.NewUniformSample(10))
+	latency.Update(10)
+	latency.Update(30)
+	metrics.GetOrRegisterCounter("requests-in-flight", registry).Inc(2)
+
+	promRegistry := prometheus.NewRegistry()
+	require.NoError(t, promRegistry.Register(newClientMetricsCollector(registry)))
+	families, err := promRegistry.Gather()
+	require.NoError(t, err)
+	byName := make(map[string]*dto.MetricFamily)
+	for _, family := range families {
+		byName[family.GetName()] = family
+	}
+	require.Len(t, byName, 3)
+
+	// The total request rate is dropped in favour of the per broker meters
+	requests := byName["kafka_client_request_total"]
+	require.NotNil(t, requests)
+	require.Len(t, requests.Metric, 2)
+	assert.Equal(t, "broker", requests.Metric[0].Label[0].GetName())
+	assert.Equal(t, "1", requests.Metric[0].Label[0].GetValue())
+	assert.Equal(t, float64(1), requests.Metric[0].Counter.GetValue())
+
+	latencies := byName["kafka_client_request_latency_in_ms"]
+	require.NotNil(t, latencies)
+	assert.Equal(t, uint64(2), latencies.Metric[0].Summary.GetSampleCount())
+	assert.Equal(t, float64(40), latencies.Metric[0].Summary.GetSampleSum())
+
+	inFlight := byName["kafka_client_requests_in_flight"]
+	require.NotNil(t, inFlight)
+	assert.Equal(t, float64(2), inFlight.Metric[0].Gauge.GetValue())
+}
+
+func TestNewSaramaConfigSharesMetricRegistry(t *testing.T) {
+	cfg := Config{}
+	cfg.SetDefaults()
+	cfg.Brokers = []string{"localhost:9092"}
+	cfg.clientMetrics = newClusterClientMetrics()
+
+	first, err := NewSaramaConfig(&cfg)
+	require.NoError(t, err)
+	second, err := NewConsumerConfig(&cfg, ConsumerConfigOverride{})
+	require.NoError(t, err)
+	assert.True(t, first.MetricRegistry == cfg.clientMetrics.registry)
+	assert.True(t, second.MetricRegistry == cfg.clientMetrics.registry)
+
+	// Each cluster has a registry of its own
+	otherCluster := cfg
+	otherCluster.clientMetrics = newClusterClientMetrics()
+	other, err := NewSaramaConfig(&otherCluster)
+	require.NoError(t, err)
+	assert.False(t, other.MetricRegistry == cfg.clientMetrics.registry)
+
+	cfg.EnableClientMetrics = false
+	disabled, err := NewSaramaConfig(&cfg)
+	require.NoError(t, err)
+	assert.False(t, disabled.MetricRegistry == cfg.clientMetrics.registry)
+}
+
+func TestClientMetricsCollector_ClusterLabel(t *testing.T) {
+	promRegistry := prometheus.NewRegistry()
+	for _, cluster := range []string{"a", "b"} {
+		registry := metrics.NewRegistry()
+		metrics.GetOrRegisterMeter("request-rate-for-broker-1", registry).Mark(1)
+		registerer := prometheus.WrapRegistererWith(prometheus.Labels{"cluster": cluster}, promRegistry)
+		require.NoError(t, registerer.Register(newClientMetricsCollector(registry)))
+	}
+
+	// Broker 1 of both clusters is exported separately
+	families, err := promRegistry.Gather()
+	require.NoError(t, err)
+	require.Len(t, families, 1)
+	require.Len(t, families[0].Metric, 2)
+	clusters := make([]string, 0, 2)
+	for _, metric := range families[0].Metric {
+		for _, label := range metric.Label {
+			if label.GetName() == "cluster" {
+				clusters = append(clusters, label.GetValue())
+			}
+		}
+	}
+	assert.ElementsMatch(t, []string{"a", "b"}, clusters)
+}
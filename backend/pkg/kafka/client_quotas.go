@@ -0,0 +1,229 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Shopify/sarama"
+)
+
+// describeClientQuotasAPIKey and alterClientQuotasAPIKey are the Kafka API keys of the client quota APIs, which have
+// been added in Kafka 2.6 (KIP-546)
+const (
+	describeClientQuotasAPIKey = 48
+	alterClientQuotasAPIKey    = 49
+)
+
+// Quota config keys which can be altered. The connection creation rate can only be set for ip addresses, all other
+// quotas can only be set for users and client ids.
+const (
+	QuotaProducerByteRate       = "producer_byte_rate"
+	QuotaConsumerByteRate       = "consumer_byte_rate"
+	QuotaRequestPercentage      = "request_percentage"
+	QuotaConnectionCreationRate = "connection_creation_rate"
+)
+
+// QuotaEntity identifies the user, client id or ip address a quota applies to. Quotas can be set for the
+// combination of a user and a client id. If Default is set, the quota applies to all entities of that type which
+// don't have a specific quota and Name must be empty.
+type QuotaEntity struct {
+	EntityType sarama.QuotaEntityType
+	Name       string
+	Default    bool
+}
+
+// ClientQuota are the quota values which are set for an entity
+type ClientQuota struct {
+	Entity []QuotaEntity
+	Values map[string]float64
+}
+
+// DescribeClientQuotas returns all client quotas which are set for the given entity type. An empty entity type
+// returns the quotas of all entity types, a non empty name only returns the quotas of that specific entity.
+func (s *Service) DescribeClientQuotas(ctx context.Context, entityType sarama.QuotaEntityType, name string) (quotas []ClientQuota, err error) {
+	defer s.observeOperation(ctx, operationTypeAdmin, "describe_client_quotas", time.Now(), &err)
+	if !s.supportsAPI(describeClientQuotasAPIKey) {
+		return nil, ErrClientQuotasNotSupported
+	}
+
+	var components []sarama.QuotaFilterComponent
+	switch {
+	case entityType == "" && name != "":
+		return nil, fmt.Errorf("%w: an entity type must be given to filter by name", ErrInvalidQuota)
+	case entityType != "":
+		if err := validateQuotaEntityType(entityType); err != nil {
+			return nil, err
+		}
+		component := sarama.QuotaFilterComponent{EntityType: entityType, MatchType: sarama.QuotaMatchAny}
+		if name != "" {
+			component.MatchType = sarama.QuotaMatchExact
+			component.Match = name
+		}
+		components = append(components, component)
+	}
+
+	var entries []sarama.DescribeClientQuotasEntry
+	err = runWithContext(ctx, func() error {
+		var err error
+		entries, err = s.AdminClient.DescribeClientQuotas(components, false)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe client quotas: %w", err)
+	}
+
+	quotas = make([]ClientQuota, len(entries))
+	for i, entry := range entries {
+		entity := make([]QuotaEntity, len(entry.Entity))
+		for j, component := range entry.Entity {
+			entity[j] = QuotaEntity{
+				EntityType: component.EntityType,
+				Name:       component.Name,
+				Default:    component.MatchType == sarama.QuotaMatchDefault,
+			}
+		}
+		sort.Slice(entity, func(i, j int) bool { return entity[i].EntityType < entity[j].EntityType })
+		quotas[i] = ClientQuota{Entity: entity, Values: entry.Values}
+	}
+
+	return quotas, nil
+}
+
+// AlterClientQuotas sets the given quota values of an entity. Values which are set to nil are removed, so that the
+// default quota applies again. All other quota values of the entity remain untouched.
+func (s *Service) AlterClientQuotas(ctx context.Context, entity []QuotaEntity, values map[string]*float64) (err error) {
+	defer s.observeOperation(ctx, operationTypeAdmin, "alter_client_quotas", time.Now(), &err)
+	if !s.supportsAPI(alterClientQuotasAPIKey) {
+		return ErrClientQuotasNotSupported
+	}
+	if err := ValidateQuotaEntity(entity); err != nil {
+		return err
+	}
+	if err := validateQuotaValues(entity, values); err != nil {
+		return err
+	}
+
+	entry := sarama.AlterClientQuotasEntry{}
+	for _, e := range entity {
+		component := sarama.QuotaEntityComponent{EntityType: e.EntityType, MatchType: sarama.QuotaMatchExact, Name: e.Name}
+		if e.Default {
+			component.MatchType = sarama.QuotaMatchDefault
+		}
+		entry.Entity = append(entry.Entity, component)
+	}
+	for key, value := range values {
+		op := sarama.ClientQuotasOp{Key: key, Remove: value == nil}
+		if value != nil {
+			op.Value = *value
+		}
+		entry.Ops = append(entry.Ops, op)
+	}
+	sort.Slice(entry.Ops, func(i, j int) bool { return entry.Ops[i].Key < entry.Ops[j].Key })
+
+	// The admin client only supports a single op per request, hence we send the request to the controller directly,
+	// so that all values are altered at once
+	controller, err := s.Client.Controller()
+	if err != nil {
+		return fmt.Errorf("failed to get controller: %w", err)
+	}
+	var res *sarama.AlterClientQuotasResponse
+	err = runWithContext(ctx, func() error {
+		var err error
+		res, err = controller.AlterClientQuotas(&sarama.AlterClientQuotasRequest{Entries: []sarama.AlterClientQuotasEntry{entry}})
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to alter client quotas: %w", err)
+	}
+	for _, resEntry := range res.Entries {
+		if resEntry.ErrorCode == sarama.ErrNoError {
+			continue
+		}
+		if resEntry.ErrorCode == sarama.ErrInvalidRequest && resEntry.ErrorMsg != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidQuota, *resEntry.ErrorMsg)
+		}
+		if resEntry.ErrorMsg != nil {
+			return fmt.Errorf("failed to alter client quotas: %w: %v", resEntry.ErrorCode, *resEntry.ErrorMsg)
+		}
+		return fmt.Errorf("failed to alter client quotas: %w", resEntry.ErrorCode)
+	}
+
+	return nil
+}
+
+// ValidateQuotaEntity returns an ErrInvalidQuota if the entity can not have quotas. Quotas can be set for a user, a
+// client id, the combination of both or an ip address. Names must be valid for their entity type.
+func ValidateQuotaEntity(entity []QuotaEntity) error {
+	if len(entity) == 0 {
+		return fmt.Errorf("%w: at least one entity must be given", ErrInvalidQuota)
+	}
+
+	seen := make(map[sarama.QuotaEntityType]bool, len(entity))
+	for _, e := range entity {
+		if err := validateQuotaEntityType(e.EntityType); err != nil {
+			return err
+		}
+		if seen[e.EntityType] {
+			return fmt.Errorf("%w: entity type '%v' is given more than once", ErrInvalidQuota, e.EntityType)
+		}
+		seen[e.EntityType] = true
+
+		switch {
+		case e.Default && e.Name != "":
+			return fmt.Errorf("%w: the default %v entity must not have a name", ErrInvalidQuota, e.EntityType)
+		case e.Default:
+		case e.Name == "":
+			return fmt.Errorf("%w: the %v entity must either have a name or be the default entity", ErrInvalidQuota, e.EntityType)
+		case e.EntityType == sarama.QuotaEntityIP && net.ParseIP(e.Name) == nil:
+			return fmt.Errorf("%w: '%v' is not a valid ip address", ErrInvalidQuota, e.Name)
+		}
+	}
+	if seen[sarama.QuotaEntityIP] && len(entity) > 1 {
+		return fmt.Errorf("%w: ip quotas can not be combined with user or client-id quotas", ErrInvalidQuota)
+	}
+
+	return nil
+}
+
+func validateQuotaEntityType(entityType sarama.QuotaEntityType) error {
+	switch entityType {
+	case sarama.QuotaEntityUser, sarama.QuotaEntityClientID, sarama.QuotaEntityIP:
+		return nil
+	default:
+		return fmt.Errorf("%w: unknown entity type '%v', accepted values are: %v, %v, %v", ErrInvalidQuota, entityType,
+			sarama.QuotaEntityUser, sarama.QuotaEntityClientID, sarama.QuotaEntityIP)
+	}
+}
+
+// validateQuotaValues checks the quota keys against the keys which are supported by the (already validated) entity
+func validateQuotaValues(entity []QuotaEntity, values map[string]*float64) error {
+	if len(values) == 0 {
+		return fmt.Errorf("%w: at least one quota value must be given", ErrInvalidQuota)
+	}
+
+	supported := []string{QuotaProducerByteRate, QuotaConsumerByteRate, QuotaRequestPercentage}
+	if entity[0].EntityType == sarama.QuotaEntityIP {
+		supported = []string{QuotaConnectionCreationRate}
+	}
+	for key, value := range values {
+		isSupported := false
+		for _, supportedKey := range supported {
+			if key == supportedKey {
+				isSupported = true
+			}
+		}
+		if !isSupported {
+			return fmt.Errorf("%w: quota '%v' can not be set for %v entities, accepted values are: %v",
+				ErrInvalidQuota, key, entity[0].EntityType, strings.Join(supported, ", "))
+		}
+		if value != nil && *value <= 0 {
+			return fmt.Errorf("%w: quota '%v' must be greater than 0", ErrInvalidQuota, key)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,101 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateQuotaEntity(t *testing.T) {
+	user := QuotaEntity{EntityType: sarama.QuotaEntityUser, Name: "alice"}
+	clientID := QuotaEntity{EntityType: sarama.QuotaEntityClientID, Default: true}
+
+	tt := []struct {
+		name    string
+		entity  []QuotaEntity
+		isValid bool
+	}{
+		{"user", []QuotaEntity{user}, true},
+		{"user and default client id", []QuotaEntity{user, clientID}, true},
+		{"ip address", []QuotaEntity{{EntityType: sarama.QuotaEntityIP, Name: "10.0.0.1"}}, true},
+		{"no entity", nil, false},
+		{"unknown entity type", []QuotaEntity{{EntityType: "group", Name: "billing"}}, false},
+		{"missing name", []QuotaEntity{{EntityType: sarama.QuotaEntityUser}}, false},
+		{"default with name", []QuotaEntity{{EntityType: sarama.QuotaEntityUser, Name: "alice", Default: true}}, false},
+		{"duplicate entity type", []QuotaEntity{user, user}, false},
+		{"invalid ip address", []QuotaEntity{{EntityType: sarama.QuotaEntityIP, Name: "10.0.0"}}, false},
+		{"ip combined with user", []QuotaEntity{user, {EntityType: sarama.QuotaEntityIP, Name: "10.0.0.1"}}, false},
+	}
+	for _, table := range tt {
+		err := ValidateQuotaEntity(table.entity)
+		if table.isValid {
+			assert.NoError(t, err, table.name)
+		} else {
+			assert.True(t, errors.Is(err, ErrInvalidQuota), table.name)
+		}
+	}
+}
+
+func TestClientQuotas(t *testing.T) {
+	svc, broker := newTestAdminService(t, sarama.V2_6_0_0, func(broker *sarama.MockBroker) map[string]sarama.MockResponse {
+		return map[string]sarama.MockResponse{
+			"MetadataRequest": sarama.NewMockMetadataResponse(t).
+				SetController(broker.BrokerID()).
+				SetBroker(broker.Addr(), broker.BrokerID()),
+			"ApiVersionsRequest": sarama.NewMockWrapper(&sarama.ApiVersionsResponse{
+				ApiKeys: []sarama.ApiVersionsResponseKey{
+					{ApiKey: describeClientQuotasAPIKey, MinVersion: 0, MaxVersion: 0},
+					{ApiKey: alterClientQuotasAPIKey, MinVersion: 0, MaxVersion: 0},
+				},
+			}),
+			"DescribeClientQuotasRequest": sarama.NewMockWrapper(&sarama.DescribeClientQuotasResponse{
+				Entries: []sarama.DescribeClientQuotasEntry{{
+					Entity: []sarama.QuotaEntityComponent{
+						{EntityType: sarama.QuotaEntityUser, MatchType: sarama.QuotaMatchExact, Name: "alice"},
+						{EntityType: sarama.QuotaEntityClientID, MatchType: sarama.QuotaMatchDefault},
+					},
+					Values: map[string]float64{QuotaProducerByteRate: 1024},
+				}},
+			}),
+			"AlterClientQuotasRequest": sarama.NewMockWrapper(&sarama.AlterClientQuotasResponse{
+				Entries: []sarama.AlterClientQuotasEntryResponse{{ErrorCode: sarama.ErrNoError}},
+			}),
+		}
+	})
+
+	quotas, err := svc.DescribeClientQuotas(context.Background(), sarama.QuotaEntityUser, "")
+	require.NoError(t, err)
+	require.Len(t, quotas, 1)
+	assert.Equal(t, []QuotaEntity{
+		{EntityType: sarama.QuotaEntityClientID, Default: true},
+		{EntityType: sarama.QuotaEntityUser, Name: "alice"},
+	}, quotas[0].Entity)
+	assert.Equal(t, map[string]float64{QuotaProducerByteRate: 1024}, quotas[0].Values)
+
+	// Quotas which are not supported by the entity type are rejected before they are sent to the cluster
+	rate := float64(2048)
+	ipEntity := []QuotaEntity{{EntityType: sarama.QuotaEntityIP, Name: "10.0.0.1"}}
+	err = svc.AlterClientQuotas(context.Background(), ipEntity, map[string]*float64{QuotaProducerByteRate: &rate})
+	assert.True(t, errors.Is(err, ErrInvalidQuota))
+
+	userEntity := []QuotaEntity{{EntityType: sarama.QuotaEntityUser, Name: "alice"}}
+	err = svc.AlterClientQuotas(context.Background(), userEntity, map[string]*float64{QuotaProducerByteRate: &rate, QuotaConsumerByteRate: nil})
+	require.NoError(t, err)
+
+	var alterReq *sarama.AlterClientQuotasRequest
+	for _, rr := range broker.History() {
+		if req, ok := rr.Request.(*sarama.AlterClientQuotasRequest); ok {
+			alterReq = req
+		}
+	}
+	require.NotNil(t, alterReq)
+	require.Len(t, alterReq.Entries, 1)
+	assert.Equal(t, []sarama.ClientQuotasOp{
+		{Key: QuotaConsumerByteRate, Remove: true},
+		{Key: QuotaProducerByteRate, Value: 2048},
+	}, alterReq.Entries[0].Ops)
+}
@@ -3,10 +3,31 @@ package kafka
 import (
 	"flag"
 	"fmt"
+	"strings"
+	"time"
+
 	"github.com/Shopify/sarama"
+	"github.com/cloudhut/kowl/backend/pkg/proto"
 	"github.com/cloudhut/kowl/backend/pkg/schema"
+	"go.uber.org/zap/zapcore"
+)
+
+// ClusterVersionAuto can be configured as clusterVersion to infer the Kafka version from the ApiVersions response of
+// the brokers when the service is created (see resolveClusterVersion). Kafka versions which did not introduce a new
+// API are resolved to the previous version.
+const ClusterVersionAuto = "auto"
+
+// Security protocols which can be configured to enable TLS and SASL consistently
+const (
+	SecurityProtocolPlaintext     = "PLAINTEXT"
+	SecurityProtocolSSL           = "SSL"
+	SecurityProtocolSASLPlaintext = "SASL_PLAINTEXT"
+	SecurityProtocolSASLSSL       = "SASL_SSL"
 )
 
+// commonClusterVersions are shown as example values if the configured cluster version can not be parsed
+var commonClusterVersions = []string{"0.11.0", "1.0.0", "1.1.0", "2.0.0", "2.4.0", "2.5.0", "2.6.0"}
+
 // Config required for opening a connection to Kafka
 type Config struct {
 	// General
@@ -14,43 +35,252 @@ type Config struct {
 	ClientID       string   `yaml:"clientId"`
 	ClusterVersion string   `yaml:"clusterVersion"`
 
+	// SecurityProtocol (PLAINTEXT, SSL, SASL_PLAINTEXT or SASL_SSL) determines whether TLS and SASL are used. If set,
+	// it takes precedence over tls.enabled and sasl.enabled.
+	SecurityProtocol string `yaml:"securityProtocol"`
+
+	// Timeouts for network connections to the brokers
+	DialTimeout  time.Duration `yaml:"dialTimeout"`
+	ReadTimeout  time.Duration `yaml:"readTimeout"`
+	WriteTimeout time.Duration `yaml:"writeTimeout"`
+	KeepAlive    time.Duration `yaml:"keepAlive"`
+
 	// Schema Registry
 	Schema schema.Config `yaml:"schemaRegistry"`
 
-	TLS  TLSConfig  `yaml:"tls"`
-	SASL SASLConfig `yaml:"sasl"`
+	// Protobuf deserialization
+	Proto proto.Config `yaml:"protobuf"`
+
+	// Deserialization defines the order in which the decoders are tried
+	Deserialization DeserializationConfig `yaml:"deserialization"`
+
+	TLS   TLSConfig   `yaml:"tls"`
+	SASL  SASLConfig  `yaml:"sasl"`
+	Proxy ProxyConfig `yaml:"proxy"`
+
+	Consumer ConsumerConfig `yaml:"consumer"`
+	Producer ProducerConfig `yaml:"producer"`
+	Metadata MetadataConfig `yaml:"metadata"`
+	SelfTest SelfTestConfig `yaml:"selfTest"`
+
+	// Retry configures the retries of idempotent admin and metadata requests which fail with a transient error
+	Retry RetryConfig `yaml:"retry"`
+
+	// MetadataRefreshInterval is deprecated, please use Metadata.RefreshInterval instead
+	MetadataRefreshInterval time.Duration `yaml:"metadataRefreshInterval"`
+
+	// APIVersionsCacheTTL is the duration for which the broker's supported API versions are cached
+	APIVersionsCacheTTL time.Duration `yaml:"apiVersionsCacheTtl"`
+
+	// TopicMetadataCacheTTL is the duration for which the metadata of all topics (partitions, replicas and ISR) is
+	// cached. The log dir sizes of the topic list are cached for the same duration. Zero disables the cache.
+	TopicMetadataCacheTTL time.Duration `yaml:"topicMetadataCacheTtl"`
+
+	// TopicMetadataCacheStaleWindow is the duration after the expiry of the cached topic metadata in which it is still
+	// served, flagged as stale, while it is refreshed in the background
+	TopicMetadataCacheStaleWindow time.Duration `yaml:"topicMetadataCacheStaleWindow"`
+
+	// BrokerMetricsRefreshInterval is the minimum interval in which the cluster metadata is refreshed and the per broker
+	// metrics are collected when they are requested
+	BrokerMetricsRefreshInterval time.Duration `yaml:"brokerMetricsRefreshInterval"`
+
+	// EnableClientMetrics exports sarama's client metrics (e. g. request rates and latencies per broker) with the
+	// prefix kafka_client_ and the label cluster
+	EnableClientMetrics bool `yaml:"enableClientMetrics"`
+
+	// LogLevel is the minimum level of the logs of the Kafka service and sarama (e. g. "info" to hide sarama's debug
+	// logs). It can only be more restrictive than the global log level, which is used if it is empty. sarama's logs
+	// are shared by all clusters, hence they use the log level of the default cluster.
+	LogLevel string `yaml:"logLevel"`
+
+	// ShutdownDrainTimeout is the max duration in which in-flight consume and produce operations may finish on
+	// shutdown, before they are cancelled and the Kafka clients are closed. It should be shorter than the graceful
+	// shutdown timeout of the REST server, which stops waiting for the in-flight HTTP requests afterwards.
+	ShutdownDrainTimeout time.Duration `yaml:"shutdownDrainTimeout"`
+
+	// clientMetrics are shared by all sarama configs of the cluster, it is set by NewService. Configs which are created
+	// without it get metrics of their own.
+	clientMetrics *clusterClientMetrics
 }
 
 // RegisterFlags registers all nested config flags.
 func (c *Config) RegisterFlags(f *flag.FlagSet) {
 	c.TLS.RegisterFlags(f)
 	c.SASL.RegisterFlags(f)
+	c.Proxy.RegisterFlags(f)
 	c.Schema.RegisterFlags(f)
 }
 
-// Validate the Kafka config
+// Validate the Kafka config. All static checks (including the readability of the configured TLS files) are performed
+// without opening any network connections. If more than one check fails, a ValidationErrors error listing every
+// problem is returned.
 func (c *Config) Validate() error {
+	errs := ValidationErrors{}
+
 	if len(c.Brokers) == 0 {
-		return fmt.Errorf("you must specify at least one broker to connect to")
+		errs.Add(fmt.Errorf("you must specify at least one broker to connect to"))
 	}
 
-	_, err := sarama.ParseKafkaVersion(c.ClusterVersion)
-	if err != nil {
-		return fmt.Errorf("failed to parse the given clusterVersion for Kafka: %w", err)
+	version, err := parseClusterVersion(c.ClusterVersion)
+	errs.Add(err)
+	if err == nil {
+		errs.Add(c.Producer.validate(version))
+	}
+	if err == nil && c.Consumer.RackID != "" && !version.IsAtLeast(sarama.V2_4_0_0) {
+		errs.Add(fmt.Errorf("fetching from the closest replica (consumer rackId) requires a clusterVersion of at least 2.4.0"))
+	}
+
+	if c.DialTimeout <= 0 || c.ReadTimeout <= 0 || c.WriteTimeout <= 0 || c.KeepAlive <= 0 {
+		errs.Add(fmt.Errorf("dialTimeout, readTimeout, writeTimeout and keepAlive must be positive durations"))
+	}
+
+	if c.MetadataRefreshInterval < 0 {
+		errs.Add(fmt.Errorf("metadataRefreshInterval must not be negative"))
+	}
+
+	if c.TopicMetadataCacheTTL < 0 || c.TopicMetadataCacheStaleWindow < 0 {
+		errs.Add(fmt.Errorf("topicMetadataCacheTtl and topicMetadataCacheStaleWindow must not be negative"))
+	}
+
+	if c.BrokerMetricsRefreshInterval < 0 {
+		errs.Add(fmt.Errorf("brokerMetricsRefreshInterval must not be negative"))
 	}
 
-	err = c.Schema.Validate()
+	if c.ShutdownDrainTimeout < 0 {
+		errs.Add(fmt.Errorf("shutdownDrainTimeout must not be negative"))
+	}
+
+	if c.LogLevel != "" {
+		var level zapcore.Level
+		if err := level.UnmarshalText([]byte(c.LogLevel)); err != nil {
+			errs.Add(fmt.Errorf("given logLevel '%v' is invalid, accepted values are: debug, info, warn, error", c.LogLevel))
+		}
+	}
+
+	errs.Add(c.validateSecurityProtocol())
+
+	if c.IsSASLEnabled() {
+		err = c.SASL.Validate()
+		if err != nil {
+			errs.Add(&ErrSASLConfig{Err: err})
+		}
+	}
+
+	err = c.TLS.Validate()
 	if err != nil {
-		return err
+		errs.Add(&ErrTLSConfig{Err: err})
+	} else if c.IsTLSEnabled() {
+		for _, err := range c.TLS.validateFiles() {
+			errs.Add(&ErrTLSConfig{Err: err})
+		}
+	}
+
+	errs.Add(c.Proxy.Validate())
+	errs.Add(c.Schema.Validate())
+	errs.Add(c.Proto.Validate())
+	errs.Add(c.Deserialization.Validate())
+	errs.Add(c.Consumer.Validate())
+	errs.Add(c.Metadata.Validate())
+	errs.Add(c.SelfTest.Validate())
+	errs.Add(c.Retry.Validate())
+
+	return errs.ErrOrNil()
+}
+
+// validateSecurityProtocol checks whether the security protocol is known and does not contradict the tls.enabled and
+// sasl.enabled settings.
+func (c *Config) validateSecurityProtocol() error {
+	switch c.SecurityProtocol {
+	case "":
+		return nil
+	case SecurityProtocolPlaintext, SecurityProtocolSSL, SecurityProtocolSASLPlaintext, SecurityProtocolSASLSSL:
+	default:
+		return fmt.Errorf("given securityProtocol '%v' is invalid, accepted values are: %v", c.SecurityProtocol,
+			strings.Join([]string{SecurityProtocolPlaintext, SecurityProtocolSSL, SecurityProtocolSASLPlaintext, SecurityProtocolSASLSSL}, ", "))
+	}
+
+	if c.TLS.Enabled && !c.IsTLSEnabled() {
+		return fmt.Errorf("tls is enabled, but securityProtocol '%v' does not use TLS", c.SecurityProtocol)
+	}
+	if c.SASL.Enabled && !c.IsSASLEnabled() {
+		return fmt.Errorf("sasl is enabled, but securityProtocol '%v' does not use SASL", c.SecurityProtocol)
 	}
 
 	return nil
 }
 
+// IsTLSEnabled returns whether TLS shall be used. The security protocol takes precedence over tls.enabled.
+func (c *Config) IsTLSEnabled() bool {
+	switch c.SecurityProtocol {
+	case SecurityProtocolSSL, SecurityProtocolSASLSSL:
+		return true
+	case SecurityProtocolPlaintext, SecurityProtocolSASLPlaintext:
+		return false
+	default:
+		return c.TLS.Enabled
+	}
+}
+
+// IsSASLEnabled returns whether SASL shall be used. The security protocol takes precedence over sasl.enabled.
+func (c *Config) IsSASLEnabled() bool {
+	switch c.SecurityProtocol {
+	case SecurityProtocolSASLPlaintext, SecurityProtocolSASLSSL:
+		return true
+	case SecurityProtocolPlaintext, SecurityProtocolSSL:
+		return false
+	default:
+		return c.SASL.Enabled
+	}
+}
+
+// metadataRefreshInterval returns the configured metadata refresh interval, the deprecated MetadataRefreshInterval
+// takes precedence if it is set.
+func (c *Config) metadataRefreshInterval() time.Duration {
+	if c.MetadataRefreshInterval > 0 {
+		return c.MetadataRefreshInterval
+	}
+	return c.Metadata.RefreshInterval
+}
+
 // SetDefaults for Kafka config
 func (c *Config) SetDefaults() {
 	c.ClientID = "kowl"
 	c.ClusterVersion = "1.0.0"
+	c.DialTimeout = 15 * time.Second
+	c.ReadTimeout = 15 * time.Second
+	c.WriteTimeout = 15 * time.Second
+	c.KeepAlive = 15 * time.Second
+	c.APIVersionsCacheTTL = 10 * time.Minute
+	c.TopicMetadataCacheTTL = 10 * time.Second
+	c.TopicMetadataCacheStaleWindow = time.Minute
+	c.BrokerMetricsRefreshInterval = 30 * time.Second
+	c.EnableClientMetrics = true
+	c.ShutdownDrainTimeout = 15 * time.Second
 
+	c.TLS.SetDefaults()
 	c.SASL.SetDefaults()
+	c.Consumer.SetDefaults()
+	c.Producer.SetDefaults()
+	c.Metadata.SetDefaults()
+	c.SelfTest.SetDefaults()
+	c.Retry.SetDefaults()
+	c.Schema.SetDefaults()
+	c.Deserialization.SetDefaults()
+}
+
+// parseClusterVersion parses the configured cluster version. The sentinel value "auto" is replaced with the version
+// of the brokers by NewService, until then it returns sarama's default version (1.0.0).
+func parseClusterVersion(clusterVersion string) (sarama.KafkaVersion, error) {
+	if clusterVersion == ClusterVersionAuto {
+		return sarama.NewConfig().Version, nil
+	}
+
+	version, err := sarama.ParseKafkaVersion(clusterVersion)
+	if err != nil {
+		return sarama.KafkaVersion{}, &ErrKafkaVersion{Err: fmt.Errorf("failed to parse the given clusterVersion '%v' for Kafka. The version "+
+			"must be in the format 'major.minor.patch' (e.g. %v) or '%v': %w",
+			clusterVersion, strings.Join(commonClusterVersions, ", "), ClusterVersionAuto, err)}
+	}
+
+	return version, nil
 }
@@ -0,0 +1,104 @@
+package kafka
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Shopify/sarama"
+)
+
+// minFetchBytesLimit is the lower bound for the configurable max fetch sizes
+const minFetchBytesLimit = 1024
+
+// ConsumerConfig contains the settings which are applied to the consumers Kowl uses to fetch messages
+type ConsumerConfig struct {
+	// FetchMinBytes is the minimum number of bytes the broker should return for a fetch request. The broker waits up to
+	// MaxWaitTime until that many bytes are available.
+	FetchMinBytes int32 `yaml:"fetchMinBytes"`
+
+	// FetchMaxBytes is the maximum size of a fetch response (across all partitions) for the records Kowl fetches
+	// directly (see ConsumeOffsetRanges). Sarama's consumers always request up to sarama.MaxResponseSize (100MB), which
+	// is also the largest response Sarama parses, hence FetchMaxBytes must not exceed it.
+	FetchMaxBytes int32 `yaml:"fetchMaxBytes"`
+
+	// MaxPartitionFetchBytes is the maximum number of bytes which are fetched per partition. Records which are larger
+	// than this limit can not be consumed, hence it should be at least as large as the largest max.message.bytes
+	// of the topics (or the broker's message.max.bytes).
+	MaxPartitionFetchBytes int32 `yaml:"maxPartitionFetchBytes"`
+
+	// MaxWaitTime is the maximum duration the broker waits for FetchMinBytes to become available
+	MaxWaitTime time.Duration `yaml:"maxWaitTime"`
+
+	// LiveTailBufferSize is the number of messages which are buffered per live tail for slow clients and
+	// LiveTailMaxMessagesPerSecond is the max rate at which messages are streamed to a client. Messages which exceed
+	// either limit are dropped.
+	LiveTailBufferSize           int `yaml:"liveTailBufferSize"`
+	LiveTailMaxMessagesPerSecond int `yaml:"liveTailMaxMessagesPerSecond"`
+
+	// CompactLatestMaxKeys is the max number of distinct keys which are kept when browsing the latest value per key.
+	// Messages with further keys are skipped and the result is marked as truncated.
+	CompactLatestMaxKeys int `yaml:"compactLatestMaxKeys"`
+
+	// RackID is the rack (e.g. availability zone) Kowl runs in. If set, brokers with a rack aware replica selector
+	// let Kowl fetch from the closest replica instead of the leader (KIP-392).
+	RackID string `yaml:"rackId"`
+
+	// ClientIDTemplate is the client id of the consumers which are created per request, so that broker logs and
+	// quotas can tell the requests (and the users who have sent them) apart. The placeholders {clientID}, {user} and
+	// {reqid} are replaced with the configured client id, the user and the request id, e.g. "{clientID}-{user}-{reqid}".
+	// Each request opens its own connections if a template is set. Empty uses the shared client with the configured
+	// client id.
+	ClientIDTemplate string `yaml:"clientIdTemplate"`
+}
+
+// SetDefaults for the consumer config
+func (c *ConsumerConfig) SetDefaults() {
+	c.FetchMinBytes = 1
+	c.FetchMaxBytes = 100 * 1024 * 1024
+	c.MaxPartitionFetchBytes = 50 * 1024 * 1024
+	c.MaxWaitTime = 250 * time.Millisecond
+	c.LiveTailBufferSize = 500
+	c.LiveTailMaxMessagesPerSecond = 100
+	c.CompactLatestMaxKeys = 10000
+}
+
+// Validate consumer config input
+func (c *ConsumerConfig) Validate() error {
+	if c.FetchMinBytes < 1 {
+		return fmt.Errorf("consumer fetchMinBytes must be at least 1")
+	}
+	if c.FetchMaxBytes < minFetchBytesLimit {
+		return fmt.Errorf("consumer fetchMaxBytes must be at least %d bytes", minFetchBytesLimit)
+	}
+	if c.MaxPartitionFetchBytes < minFetchBytesLimit {
+		return fmt.Errorf("consumer maxPartitionFetchBytes must be at least %d bytes", minFetchBytesLimit)
+	}
+	if c.FetchMaxBytes > sarama.MaxResponseSize {
+		return fmt.Errorf("consumer fetchMaxBytes must not exceed %d bytes, the max response size of the Kafka client", sarama.MaxResponseSize)
+	}
+	if c.FetchMaxBytes < c.FetchMinBytes {
+		return fmt.Errorf("consumer fetchMaxBytes must not be smaller than fetchMinBytes")
+	}
+	if c.FetchMaxBytes < c.MaxPartitionFetchBytes {
+		return fmt.Errorf("consumer fetchMaxBytes must not be smaller than maxPartitionFetchBytes")
+	}
+	if c.MaxWaitTime <= 0 {
+		return fmt.Errorf("consumer maxWaitTime must be a positive duration")
+	}
+	if c.LiveTailBufferSize < 1 {
+		return fmt.Errorf("consumer liveTailBufferSize must be at least 1")
+	}
+	if c.LiveTailMaxMessagesPerSecond < 1 {
+		return fmt.Errorf("consumer liveTailMaxMessagesPerSecond must be at least 1")
+	}
+	if c.CompactLatestMaxKeys < 1 {
+		return fmt.Errorf("consumer compactLatestMaxKeys must be at least 1")
+	}
+	if c.ClientIDTemplate != "" {
+		if err := validateClientIDTemplate(c.ClientIDTemplate); err != nil {
+			return fmt.Errorf("consumer clientIdTemplate is invalid: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,26 @@
+package kafka
+
+import (
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConsumerConfig_Validate(t *testing.T) {
+	cfg := ConsumerConfig{}
+	cfg.SetDefaults()
+	assert.NoError(t, cfg.Validate())
+
+	tooSmall := cfg
+	tooSmall.MaxPartitionFetchBytes = 512
+	assert.Error(t, tooSmall.Validate())
+
+	exceedsFetchMax := cfg
+	exceedsFetchMax.MaxPartitionFetchBytes = cfg.FetchMaxBytes + 1
+	assert.Error(t, exceedsFetchMax.Validate())
+
+	exceedsResponseSize := cfg
+	exceedsResponseSize.FetchMaxBytes = sarama.MaxResponseSize + 1
+	assert.Error(t, exceedsResponseSize.Validate())
+}
@@ -0,0 +1,163 @@
+package kafka
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// DeserializationConfig defines which decoders are tried (in order) to deserialize the keys, values and headers of
+// consumed messages. The first decoder which accepts the payload wins. Payloads which are rejected by all decoders
+// are shown as raw text if they are valid UTF-8 and as binary otherwise.
+type DeserializationConfig struct {
+	// DefaultChain is used for all topics which do not match any of the topic specific chains
+	DefaultChain []string `yaml:"defaultChain"`
+
+	// Topics define decoder chains for all topics whose name match the TopicName regex. The first matching entry is used.
+	Topics []DeserializationTopicConfig `yaml:"topics"`
+
+	// MaxDecompressionRatio is the max factor by which a compressed message value may expand when it is decompressed.
+	// Larger values are shown compressed, which protects against decompression bombs.
+	MaxDecompressionRatio int `yaml:"maxDecompressionRatio"`
+
+	// ValidateJSONSchema validates payloads of the JSON Schema serializer against their schema. Invalid payloads are
+	// still shown, but they are flagged as invalid.
+	ValidateJSONSchema bool `yaml:"validateJsonSchema"`
+
+	// TransformTimeout is the max duration of the transform pipeline of a single payload. Payloads whose transforms
+	// exceed it are withheld.
+	TransformTimeout time.Duration `yaml:"transformTimeout"`
+}
+
+// DeserializationTopicConfig maps all topics whose name match the TopicName regex to a decoder chain. If no chain is
+// given the default chain is used. Compression (gzip, snappy, lz4, zstd or none) is a hint for values which have been
+// compressed by the producer, by default the compression is detected by the magic bytes of the value.
+type DeserializationTopicConfig struct {
+	TopicName   string   `yaml:"topicName"`
+	Chain       []string `yaml:"chain"`
+	Compression string   `yaml:"compression"`
+
+	// Transform is applied to the decoded values, e.g. "debeziumUnwrap" to show the row state of Debezium change
+	// events instead of the envelope. If KeepEnvelope is enabled, the full envelope is passed along with the
+	// unwrapped value.
+	Transform    string `yaml:"transform"`
+	KeepEnvelope bool   `yaml:"keepEnvelope"`
+
+	// Transforms are applied in order to the decoded (and unwrapped) values, e.g. to mask personal data before it
+	// is shown or exported. KeyTransforms are applied to the decoded keys.
+	Transforms    []MessageTransformConfig `yaml:"transforms"`
+	KeyTransforms []MessageTransformConfig `yaml:"keyTransforms"`
+}
+
+// MessageTransformConfig is a stage of a topic's transform pipeline. Field paths are dot separated or JSONPath-like
+// selectors (e.g. "$..email" or "$.items[*].card"), the wildcard * selects all fields of an object or all elements of
+// an array and arrays are traversed implicitly.
+type MessageTransformConfig struct {
+	// Type is one of mask, redact, flatten or extractField
+	Type string `yaml:"type"`
+
+	// Fields are the paths of the fields which are masked or redacted
+	Fields []string `yaml:"fields"`
+
+	// KeepLast is the number of trailing characters which are not masked
+	KeepLast int `yaml:"keepLast"`
+
+	// Mode defines how redacted fields are replaced: remove (default) removes them, mask replaces their values with
+	// *** and hash with the HMAC-SHA256 of the value keyed by the Salt
+	Mode string `yaml:"mode"`
+
+	// Salt is the secret key of the hashes, it's required in mode hash. Anyone who knows it can test guessed values
+	// against the hashes, hence it must be kept secret.
+	Salt string `yaml:"salt"`
+
+	// Field is the path of the field whose value replaces the payload (extractField)
+	Field string `yaml:"field"`
+
+	// Separator joins the keys of nested fields (flatten), it defaults to "."
+	Separator string `yaml:"separator"`
+}
+
+// SetDefaults for the deserialization config
+func (c *DeserializationConfig) SetDefaults() {
+	c.DefaultChain = append([]string(nil), defaultDecoderChain...)
+	c.MaxDecompressionRatio = defaultMaxDecompressionRatio
+	c.ValidateJSONSchema = true
+	c.TransformTimeout = defaultTransformTimeout
+}
+
+// Validate deserialization config input
+func (c *DeserializationConfig) Validate() error {
+	err := validateDecoderChain(c.DefaultChain)
+	if err != nil {
+		return fmt.Errorf("deserialization defaultChain is invalid: %w", err)
+	}
+
+	for i, topic := range c.Topics {
+		if topic.TopicName == "" {
+			return fmt.Errorf("deserialization topic chain at index %d must specify a topicName", i)
+		}
+		_, err := regexp.Compile(topic.TopicName)
+		if err != nil {
+			return fmt.Errorf("deserialization topicName '%v' is not a valid regex: %w", topic.TopicName, err)
+		}
+		hasTransforms := len(topic.Transforms) > 0 || len(topic.KeyTransforms) > 0
+		if len(topic.Chain) == 0 && topic.Compression == "" && topic.Transform == "" && !hasTransforms {
+			return fmt.Errorf("deserialization config for topic '%v' must specify a chain, a compression or a transform", topic.TopicName)
+		}
+		err = validateDecoderChain(topic.Chain)
+		if err != nil {
+			return fmt.Errorf("deserialization chain for topic '%v' is invalid: %w", topic.TopicName, err)
+		}
+		switch topic.Compression {
+		case "", compressionNone, compressionGzip, compressionSnappy, compressionLZ4, compressionZstd:
+		default:
+			return fmt.Errorf("deserialization compression '%v' for topic '%v' is invalid, accepted values are: %v",
+				topic.Compression, topic.TopicName, strings.Join([]string{compressionNone, compressionGzip, compressionSnappy, compressionLZ4, compressionZstd}, ", "))
+		}
+		if topic.Transform != "" && topic.Transform != transformDebeziumUnwrap {
+			return fmt.Errorf("deserialization transform '%v' for topic '%v' is invalid, accepted values are: %v",
+				topic.Transform, topic.TopicName, transformDebeziumUnwrap)
+		}
+		if topic.KeepEnvelope && topic.Transform == "" {
+			return fmt.Errorf("deserialization keepEnvelope for topic '%v' requires a transform", topic.TopicName)
+		}
+		if topic.KeepEnvelope && hasTransforms {
+			return fmt.Errorf("deserialization keepEnvelope for topic '%v' can not be combined with transforms, because "+
+				"the envelope is not transformed", topic.TopicName)
+		}
+		_, err = newMessageTransforms(topic.Transforms)
+		if err != nil {
+			return fmt.Errorf("deserialization transforms for topic '%v' are invalid: %w", topic.TopicName, err)
+		}
+		_, err = newMessageTransforms(topic.KeyTransforms)
+		if err != nil {
+			return fmt.Errorf("deserialization keyTransforms for topic '%v' are invalid: %w", topic.TopicName, err)
+		}
+	}
+
+	if c.MaxDecompressionRatio < 1 {
+		return fmt.Errorf("deserialization maxDecompressionRatio must be at least 1")
+	}
+
+	if c.TransformTimeout <= 0 {
+		return fmt.Errorf("deserialization transformTimeout must be a positive duration")
+	}
+
+	return nil
+}
+
+func validateDecoderChain(chain []string) error {
+	seen := make(map[string]bool, len(chain))
+	for _, name := range chain {
+		if _, exists := payloadDecoders[name]; !exists {
+			return fmt.Errorf("unknown decoder '%v', accepted values are: %v", name, strings.Join(decoderNames(), ", "))
+		}
+		if seen[name] {
+			return fmt.Errorf("decoder '%v' is listed more than once", name)
+		}
+		seen[name] = true
+	}
+
+	return nil
+}
@@ -0,0 +1,42 @@
+package kafka
+
+import (
+	"fmt"
+	"time"
+)
+
+// metadataRetryMax is the number of retries of a metadata request if RefreshOnError is enabled (sarama's default)
+const metadataRetryMax = 3
+
+// MetadataConfig contains the settings for the cluster metadata which is maintained by the sarama clients
+type MetadataConfig struct {
+	// RefreshInterval is the interval in which the cluster metadata is refreshed in the background
+	RefreshInterval time.Duration `yaml:"refreshInterval"`
+
+	// RefreshOnError retries metadata requests whose response contains errors, e.g. because a leader election is in
+	// progress. If disabled, the metadata is only fetched once and partitions without a leader are reported right away.
+	RefreshOnError bool `yaml:"refreshOnError"`
+}
+
+// SetDefaults for the metadata config
+func (c *MetadataConfig) SetDefaults() {
+	c.RefreshInterval = 10 * time.Minute
+	c.RefreshOnError = true
+}
+
+// Validate metadata config input
+func (c *MetadataConfig) Validate() error {
+	if c.RefreshInterval <= 0 {
+		return fmt.Errorf("metadata refreshInterval must be a positive duration")
+	}
+
+	return nil
+}
+
+// retryMax returns the max number of retries of a metadata request
+func (c *MetadataConfig) retryMax() int {
+	if !c.RefreshOnError {
+		return 0
+	}
+	return metadataRetryMax
+}
@@ -0,0 +1,38 @@
+package kafka
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetadataConfig_Validate(t *testing.T) {
+	cfg := MetadataConfig{}
+	cfg.SetDefaults()
+	assert.NoError(t, cfg.Validate())
+
+	cfg.RefreshInterval = 0
+	assert.Error(t, cfg.Validate())
+}
+
+func TestNewSaramaConfig_Metadata(t *testing.T) {
+	cfg := Config{Brokers: []string{"localhost:9092"}}
+	cfg.SetDefaults()
+	cfg.Metadata.RefreshInterval = time.Minute
+	cfg.Metadata.RefreshOnError = false
+
+	sConfig, err := NewSaramaConfig(&cfg)
+	require.NoError(t, err)
+	assert.Equal(t, time.Minute, sConfig.Metadata.RefreshFrequency)
+	assert.Equal(t, 0, sConfig.Metadata.Retry.Max)
+
+	// The deprecated interval takes precedence, so that existing configs keep working
+	cfg.MetadataRefreshInterval = 2 * time.Minute
+	cfg.Metadata.RefreshOnError = true
+	sConfig, err = NewSaramaConfig(&cfg)
+	require.NoError(t, err)
+	assert.Equal(t, 2*time.Minute, sConfig.Metadata.RefreshFrequency)
+	assert.Equal(t, metadataRetryMax, sConfig.Metadata.Retry.Max)
+}
@@ -0,0 +1,167 @@
+package kafka
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/Shopify/sarama"
+)
+
+// compressionCodecs maps the configurable compression names to sarama's codecs
+var compressionCodecs = map[string]sarama.CompressionCodec{
+	"none":   sarama.CompressionNone,
+	"gzip":   sarama.CompressionGZIP,
+	"snappy": sarama.CompressionSnappy,
+	"lz4":    sarama.CompressionLZ4,
+	"zstd":   sarama.CompressionZSTD,
+}
+
+// requiredAcks maps the configurable acknowledgement levels to sarama's required acks
+var requiredAcks = map[string]sarama.RequiredAcks{
+	"none":   sarama.NoResponse,
+	"leader": sarama.WaitForLocal,
+	"all":    sarama.WaitForAll,
+}
+
+// Default number of in flight requests per broker connection of producers which are not idempotent
+const defaultProducerMaxInFlight = 5
+
+// ProducerConfig contains the settings which are applied to the producer Kowl uses to publish messages
+type ProducerConfig struct {
+	// Idempotent enables the idempotent producer, so that retries do not produce duplicates. It requires Kafka 0.11
+	// or newer, the IDEMPOTENT_WRITE permission on the cluster, requiredAcks all, at least one retry and (due to
+	// sarama) a single in flight request.
+	Idempotent bool `yaml:"idempotent"`
+
+	// RequiredAcks is the acknowledgement level (none, leader or all) the brokers must reach before a produce request
+	// succeeds. The offsets of messages produced with none are unknown.
+	RequiredAcks string `yaml:"requiredAcks"`
+
+	// MaxInFlight is the max number of unacknowledged requests per broker connection. 0 defaults to 1 for the
+	// idempotent producer and to 5 otherwise.
+	MaxInFlight int `yaml:"maxInFlight"`
+
+	// Retries is the max number of times a failed produce request is retried
+	Retries int `yaml:"retries"`
+
+	// Compression is the default codec (none, gzip, snappy, lz4 or zstd) of produced messages, it can be overridden
+	// per produce request. CompressionLevel only applies to the default codec, 0 uses the codec's default level.
+	Compression      string `yaml:"compression"`
+	CompressionLevel int    `yaml:"compressionLevel"`
+}
+
+// SetDefaults for the producer config
+func (c *ProducerConfig) SetDefaults() {
+	c.RequiredAcks = "all"
+	c.Retries = 3
+	c.Compression = "none"
+}
+
+// validate the producer config against the cluster version
+func (c *ProducerConfig) validate(version sarama.KafkaVersion) error {
+	acks, err := c.requiredAcks()
+	if err != nil {
+		return err
+	}
+	if c.MaxInFlight < 0 {
+		return fmt.Errorf("producer maxInFlight must not be negative")
+	}
+	if c.Retries < 0 {
+		return fmt.Errorf("producer retries must not be negative")
+	}
+	if c.Idempotent {
+		switch {
+		case !version.IsAtLeast(sarama.V0_11_0_0):
+			return fmt.Errorf("the idempotent producer requires a clusterVersion of at least 0.11.0")
+		case acks != sarama.WaitForAll:
+			return fmt.Errorf("the idempotent producer requires producer requiredAcks 'all', but it is '%v'", c.RequiredAcks)
+		case c.Retries < 1:
+			return fmt.Errorf("the idempotent producer requires producer retries to be at least 1")
+		case c.MaxInFlight > 1:
+			return fmt.Errorf("the idempotent producer only supports a producer maxInFlight of 1, but it is %d", c.MaxInFlight)
+		}
+	}
+
+	codec, err := parseCompressionCodec(c.Compression, version)
+	if err != nil {
+		return fmt.Errorf("producer compression is invalid: %w", err)
+	}
+	if c.CompressionLevel == 0 {
+		return nil
+	}
+	switch codec {
+	case sarama.CompressionNone, sarama.CompressionSnappy:
+		return fmt.Errorf("producer compressionLevel can not be set for compression '%v'", codec)
+	case sarama.CompressionGZIP:
+		if _, err := gzip.NewWriterLevel(ioutil.Discard, c.CompressionLevel); err != nil {
+			return fmt.Errorf("producer compressionLevel %d is invalid for gzip: %w", c.CompressionLevel, err)
+		}
+	}
+
+	return nil
+}
+
+// requiredAcks returns the configured acknowledgement level, an empty level defaults to all
+func (c *ProducerConfig) requiredAcks() (sarama.RequiredAcks, error) {
+	if c.RequiredAcks == "" {
+		return sarama.WaitForAll, nil
+	}
+	acks, ok := requiredAcks[c.RequiredAcks]
+	if !ok {
+		return sarama.WaitForAll, fmt.Errorf("producer requiredAcks '%v' is invalid, accepted values are: none, leader, all",
+			c.RequiredAcks)
+	}
+	return acks, nil
+}
+
+// maxInFlight returns the configured max in flight requests or the default for the producer type
+func (c *ProducerConfig) maxInFlight() int {
+	switch {
+	case c.MaxInFlight > 0:
+		return c.MaxInFlight
+	case c.Idempotent:
+		return 1
+	default:
+		return defaultProducerMaxInFlight
+	}
+}
+
+// compressionLevel returns the configured level for the codec, or sarama's default level if the codec is not the
+// configured default codec
+func (c *ProducerConfig) compressionLevel(codec sarama.CompressionCodec) int {
+	defaultCodec, ok := compressionCodecs[c.Compression]
+	if c.CompressionLevel == 0 || !ok || codec != defaultCodec {
+		return sarama.CompressionLevelDefault
+	}
+	return c.CompressionLevel
+}
+
+// parseCompressionCodec returns the codec with the given name (an empty name means no compression) and checks that
+// the cluster version supports it
+func parseCompressionCodec(name string, version sarama.KafkaVersion) (sarama.CompressionCodec, error) {
+	if name == "" {
+		return sarama.CompressionNone, nil
+	}
+	codec, ok := compressionCodecs[name]
+	if !ok {
+		names := make([]string, 0, len(compressionCodecs))
+		for name := range compressionCodecs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return sarama.CompressionNone, fmt.Errorf("%w: unknown compression '%v', accepted values are: %v",
+			ErrInvalidCompression, name, strings.Join(names, ", "))
+	}
+
+	switch {
+	case codec == sarama.CompressionLZ4 && !version.IsAtLeast(sarama.V0_10_0_0):
+		return codec, fmt.Errorf("%w: lz4 compression requires a clusterVersion of at least 0.10.0", ErrInvalidCompression)
+	case codec == sarama.CompressionZSTD && !version.IsAtLeast(sarama.V2_1_0_0):
+		return codec, fmt.Errorf("%w: zstd compression requires a clusterVersion of at least 2.1.0", ErrInvalidCompression)
+	}
+
+	return codec, nil
+}
@@ -0,0 +1,100 @@
+package kafka
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProducerConfig_Validate(t *testing.T) {
+	cfg := ProducerConfig{}
+	cfg.SetDefaults()
+	assert.NoError(t, cfg.validate(sarama.V1_0_0_0))
+
+	tt := []struct {
+		name    string
+		cfg     ProducerConfig
+		version sarama.KafkaVersion
+		valid   bool
+	}{
+		{"gzip with level", ProducerConfig{Compression: "gzip", CompressionLevel: 9}, sarama.V1_0_0_0, true},
+		{"invalid gzip level", ProducerConfig{Compression: "gzip", CompressionLevel: 10}, sarama.V1_0_0_0, false},
+		{"snappy has no levels", ProducerConfig{Compression: "snappy", CompressionLevel: 1}, sarama.V1_0_0_0, false},
+		{"zstd", ProducerConfig{Compression: "zstd"}, sarama.V2_1_0_0, true},
+		{"zstd requires kafka 2.1", ProducerConfig{Compression: "zstd"}, sarama.V2_0_0_0, false},
+		{"unknown codec", ProducerConfig{Compression: "brotli"}, sarama.V2_1_0_0, false},
+		{"idempotent", ProducerConfig{Idempotent: true, RequiredAcks: "all", Retries: 3}, sarama.V0_11_0_0, true},
+		{"idempotent requires kafka 0.11", ProducerConfig{Idempotent: true, Retries: 3}, sarama.V0_10_2_0, false},
+		{"idempotent requires acks all", ProducerConfig{Idempotent: true, RequiredAcks: "leader", Retries: 3}, sarama.V1_0_0_0, false},
+		{"idempotent requires retries", ProducerConfig{Idempotent: true}, sarama.V1_0_0_0, false},
+		{"idempotent requires a single in flight request", ProducerConfig{Idempotent: true, Retries: 3, MaxInFlight: 5}, sarama.V1_0_0_0, false},
+		{"unknown acks", ProducerConfig{RequiredAcks: "quorum"}, sarama.V1_0_0_0, false},
+		{"negative retries", ProducerConfig{Retries: -1}, sarama.V1_0_0_0, false},
+	}
+	for _, table := range tt {
+		err := table.cfg.validate(table.version)
+		if table.valid {
+			assert.NoError(t, err, table.name)
+		} else {
+			assert.Error(t, err, table.name)
+		}
+	}
+}
+
+func TestNewProducerConfig_Compression(t *testing.T) {
+	cfg := Config{Brokers: []string{"localhost:9092"}}
+	cfg.SetDefaults()
+	cfg.ClusterVersion = "2.1.0"
+	cfg.Producer.Compression = "zstd"
+	cfg.Producer.CompressionLevel = 3
+	require.NoError(t, cfg.Validate())
+
+	sConfig, err := NewProducerConfig(&cfg, sarama.CompressionZSTD)
+	require.NoError(t, err)
+	assert.Equal(t, sarama.CompressionZSTD, sConfig.Producer.Compression)
+	assert.Equal(t, 3, sConfig.Producer.CompressionLevel)
+
+	// The level only applies to the configured codec, per request overrides use the codec's default level
+	sConfig, err = NewProducerConfig(&cfg, sarama.CompressionGZIP)
+	require.NoError(t, err)
+	assert.Equal(t, sarama.CompressionLevelDefault, sConfig.Producer.CompressionLevel)
+}
+
+func TestNewProducerConfig_DeliveryGuarantees(t *testing.T) {
+	cfg := Config{Brokers: []string{"localhost:9092"}}
+	cfg.SetDefaults()
+	cfg.Producer.Idempotent = true
+	require.NoError(t, cfg.Validate())
+
+	sConfig, err := NewProducerConfig(&cfg, sarama.CompressionNone)
+	require.NoError(t, err)
+	assert.True(t, sConfig.Producer.Idempotent)
+	assert.Equal(t, sarama.WaitForAll, sConfig.Producer.RequiredAcks)
+	assert.Equal(t, 1, sConfig.Net.MaxOpenRequests)
+	assert.Equal(t, 3, sConfig.Producer.Retry.Max)
+
+	cfg.Producer = ProducerConfig{RequiredAcks: "leader", Retries: 0}
+	sConfig, err = NewProducerConfig(&cfg, sarama.CompressionNone)
+	require.NoError(t, err)
+	assert.Equal(t, sarama.WaitForLocal, sConfig.Producer.RequiredAcks)
+	assert.Equal(t, defaultProducerMaxInFlight, sConfig.Net.MaxOpenRequests)
+	assert.Equal(t, 0, sConfig.Producer.Retry.Max)
+}
+
+func TestService_ProducerCompression(t *testing.T) {
+	s := &Service{Config: Config{ClusterVersion: "2.0.0", Producer: ProducerConfig{Compression: "lz4"}}}
+
+	codec, err := s.producerCompression("")
+	require.NoError(t, err)
+	assert.Equal(t, sarama.CompressionLZ4, codec, "the configured compression is the default")
+
+	codec, err = s.producerCompression("snappy")
+	require.NoError(t, err)
+	assert.Equal(t, sarama.CompressionSnappy, codec)
+
+	_, err = s.producerCompression("zstd")
+	assert.True(t, errors.Is(err, ErrInvalidCompression), "zstd requires a newer cluster version")
+}
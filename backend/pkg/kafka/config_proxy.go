@@ -0,0 +1,45 @@
+package kafka
+
+import (
+	"flag"
+	"fmt"
+	"net/url"
+)
+
+// ProxyConfig to route all broker connections through a SOCKS5 or HTTP CONNECT proxy
+type ProxyConfig struct {
+	// URL of the proxy, e.g. socks5://bastion:1080 or http://proxy:3128. The port of HTTP proxies defaults to 80, or
+	// 443 for https.
+	URL      string `yaml:"url"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+}
+
+// RegisterFlags for all sensitive proxy configs
+func (c *ProxyConfig) RegisterFlags(f *flag.FlagSet) {
+	f.StringVar(&c.Password, "kafka.proxy.password", "", "Password for the proxy which is used to connect to the brokers")
+}
+
+// Validate proxy config input
+func (c *ProxyConfig) Validate() error {
+	if c.URL == "" {
+		return nil
+	}
+
+	u, err := url.Parse(c.URL)
+	if err != nil {
+		return fmt.Errorf("failed to parse proxy url: %w", redactURLError(err))
+	}
+
+	switch u.Scheme {
+	case "socks5", "http", "https":
+	default:
+		return fmt.Errorf("proxy scheme '%v' is not supported, supported schemes are socks5, http and https", u.Scheme)
+	}
+
+	if u.Host == "" {
+		return fmt.Errorf("proxy url must contain a host")
+	}
+
+	return nil
+}
@@ -0,0 +1,44 @@
+package kafka
+
+import (
+	"fmt"
+	"time"
+)
+
+// RetryConfig configures the retries of idempotent admin and metadata requests which fail with a transient error, e.g.
+// because a partition has no leader right after a reassignment. The backoff doubles after each attempt (starting at
+// InitialBackoff, at most MaxBackoff) and is randomized by up to half of its duration.
+type RetryConfig struct {
+	// MaxAttempts is the max number of attempts including the first one, 1 disables retries
+	MaxAttempts int `yaml:"maxAttempts"`
+
+	InitialBackoff time.Duration `yaml:"initialBackoff"`
+	MaxBackoff     time.Duration `yaml:"maxBackoff"`
+
+	// MaxElapsedTime bounds the total duration of all attempts and backoffs. No retry is started if its backoff would
+	// exceed it.
+	MaxElapsedTime time.Duration `yaml:"maxElapsedTime"`
+}
+
+// SetDefaults for the retry config
+func (c *RetryConfig) SetDefaults() {
+	c.MaxAttempts = 4
+	c.InitialBackoff = 200 * time.Millisecond
+	c.MaxBackoff = 2 * time.Second
+	c.MaxElapsedTime = 10 * time.Second
+}
+
+// Validate the retry config
+func (c *RetryConfig) Validate() error {
+	if c.MaxAttempts < 1 {
+		return fmt.Errorf("retry maxAttempts must be at least 1")
+	}
+	if c.InitialBackoff <= 0 || c.MaxBackoff < c.InitialBackoff {
+		return fmt.Errorf("retry initialBackoff must be positive and maxBackoff must not be lower than initialBackoff")
+	}
+	if c.MaxElapsedTime <= 0 {
+		return fmt.Errorf("retry maxElapsedTime must be a positive duration")
+	}
+
+	return nil
+}
@@ -9,36 +9,66 @@ import (
 
 // SASLConfig for Kafka client
 type SASLConfig struct {
-	Enabled      bool             `yaml:"enabled"`
-	UseHandshake bool             `yaml:"useHandshake"`
-	Username     string           `yaml:"username"`
-	Password     string           `yaml:"password"`
-	Mechanism    string           `yaml:"mechanism"`
-	GSSAPIConfig SASLGSSAPIConfig `yaml:"gssapi"`
+	Enabled      bool                  `yaml:"enabled"`
+	UseHandshake bool                  `yaml:"useHandshake"`
+	Username     string                `yaml:"username"`
+	Password     string                `yaml:"password"`
+	Mechanism    string                `yaml:"mechanism"`
+	GSSAPIConfig SASLGSSAPIConfig      `yaml:"gssapi"`
+	OAuth        SASLOAuthBearerConfig `yaml:"oauth"`
+	AWSMskIam    SASLAWSMskIamConfig   `yaml:"awsMskIam"`
+
+	// DelegationToken authenticates with a delegation token instead of username and password (SCRAM only)
+	DelegationToken SASLDelegationTokenConfig `yaml:"delegationToken"`
 }
 
 // RegisterFlags for all sensitive Kafka SASL configs.
 func (c *SASLConfig) RegisterFlags(f *flag.FlagSet) {
 	f.StringVar(&c.Password, "kafka.sasl.password", "", "SASL password")
 	c.GSSAPIConfig.RegisterFlags(f)
+	c.OAuth.RegisterFlags(f)
+	c.DelegationToken.RegisterFlags(f)
 }
 
 // SetDefaults for SASL Config
 func (c *SASLConfig) SetDefaults() {
 	c.UseHandshake = true
 	c.Mechanism = sarama.SASLTypePlaintext
+	c.AWSMskIam.SetDefaults()
 }
 
 // Validate SASL config input
 func (c *SASLConfig) Validate() error {
 	switch c.Mechanism {
-	case sarama.SASLTypePlaintext, sarama.SASLTypeSCRAMSHA256, sarama.SASLTypeSCRAMSHA512, sarama.SASLTypeGSSAPI:
+	case sarama.SASLTypePlaintext, sarama.SASLTypeSCRAMSHA256, sarama.SASLTypeSCRAMSHA512, SASLMechanismAWSMSKIAM:
 		// Valid and supported
+	case sarama.SASLTypeGSSAPI:
+		err := c.GSSAPIConfig.Validate()
+		if err != nil {
+			return err
+		}
 	case sarama.SASLTypeOAuth:
-		return fmt.Errorf("sasl mechanism '%v' is valid but not yet supported. Please submit an issue if you need it", c.Mechanism)
+		err := c.OAuth.Validate()
+		if err != nil {
+			return err
+		}
 	default:
 		return fmt.Errorf("given sasl mechanism '%v' is invalid", c.Mechanism)
 	}
 
+	if c.DelegationToken.IsEnabled() {
+		if c.Mechanism != sarama.SASLTypeSCRAMSHA256 && c.Mechanism != sarama.SASLTypeSCRAMSHA512 {
+			return fmt.Errorf("delegation tokens can only be used with the sasl mechanisms %v and %v",
+				sarama.SASLTypeSCRAMSHA256, sarama.SASLTypeSCRAMSHA512)
+		}
+		if c.Username != "" || c.Password != "" {
+			return fmt.Errorf("username and password must not be set when authenticating with a delegation token")
+		}
+		err := c.DelegationToken.Validate()
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
@@ -0,0 +1,24 @@
+package kafka
+
+// SASLMechanismAWSMSKIAM is the mechanism name to use IAM access control for AWS MSK. Under the hood it uses
+// SASL/OAUTHBEARER with a SigV4 signed token.
+const SASLMechanismAWSMSKIAM = "AWS_MSK_IAM"
+
+// SASLAWSMskIamConfig is the config for IAM access control for AWS MSK. Credentials are resolved using the default AWS
+// credential chain (environment, shared config, EC2/ECS/EKS roles). If RoleARN is set, these credentials are used to
+// assume the given role.
+type SASLAWSMskIamConfig struct {
+	// Region of the MSK cluster. If empty, the region of the default AWS config (e.g. AWS_REGION) is used.
+	Region string `yaml:"region"`
+
+	// RoleARN is the optional ARN of the role which shall be assumed
+	RoleARN string `yaml:"roleArn"`
+
+	// SessionName that is used when assuming a role. Defaults to "kowl".
+	SessionName string `yaml:"sessionName"`
+}
+
+// SetDefaults for AWS MSK IAM config
+func (c *SASLAWSMskIamConfig) SetDefaults() {
+	c.SessionName = "kowl"
+}
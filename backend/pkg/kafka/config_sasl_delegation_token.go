@@ -0,0 +1,44 @@
+package kafka
+
+import (
+	"encoding/base64"
+	"flag"
+	"fmt"
+)
+
+// SASLDelegationTokenConfig is the config for the authentication with a delegation token (KIP-48) via SCRAM. The
+// token ID and the base64 encoded HMAC are used instead of username and password.
+//
+// Kowl does not renew the token. Once it has expired (after the renew period, unless it has been renewed by one of its
+// renewers, and at the latest after its max lifetime) the brokers reject new connections, while established connections
+// are closed on their next reauthentication (if connections.max.reauth.ms is set). Kowl must be restarted with a new
+// token in that case.
+type SASLDelegationTokenConfig struct {
+	TokenID string `yaml:"tokenId"`
+	HMAC    string `yaml:"hmac"`
+}
+
+// RegisterFlags for all sensitive delegation token configs
+func (c *SASLDelegationTokenConfig) RegisterFlags(f *flag.FlagSet) {
+	f.StringVar(&c.HMAC, "kafka.sasl.delegation-token.hmac", "", "Base64 encoded HMAC of the delegation token")
+}
+
+// IsEnabled returns true if a delegation token has been configured
+func (c *SASLDelegationTokenConfig) IsEnabled() bool {
+	return c.TokenID != "" || c.HMAC != ""
+}
+
+// Validate delegation token config input
+func (c *SASLDelegationTokenConfig) Validate() error {
+	if c.TokenID == "" {
+		return fmt.Errorf("you must specify the token id when authenticating with a delegation token")
+	}
+	if c.HMAC == "" {
+		return fmt.Errorf("you must specify the hmac when authenticating with a delegation token")
+	}
+	if _, err := base64.StdEncoding.DecodeString(c.HMAC); err != nil {
+		return fmt.Errorf("the hmac of the delegation token must be base64 encoded: %w", err)
+	}
+
+	return nil
+}
@@ -2,6 +2,24 @@ package kafka
 
 import (
 	"flag"
+	"fmt"
+	"strings"
+)
+
+const (
+	// GSSAPIAuthTypeUser authenticates using username and password
+	GSSAPIAuthTypeUser = "USER_AUTH"
+
+	// GSSAPIAuthTypeKeytab authenticates using a keytab file
+	GSSAPIAuthTypeKeytab = "KEYTAB_AUTH"
+
+	// gssapiAuthTypeUserDeprecated used to be the only accepted value for user auth due to a typo. It is still accepted
+	// as an alias for GSSAPIAuthTypeUser.
+	gssapiAuthTypeUserDeprecated = "USER_AUTH:"
+
+	// GSSAPIAuthTypeCCache would authenticate using an existing Kerberos credentials cache (KRB5CCNAME). This is not
+	// yet supported, because sarama only allows to authenticate via keytab or username & password.
+	GSSAPIAuthTypeCCache = "CCACHE_AUTH"
 )
 
 // SASLGSSAPIConfig represents the Kafka Kerberos config
@@ -13,9 +31,34 @@ type SASLGSSAPIConfig struct {
 	Username           string `yaml:"username"`
 	Password           string `yaml:"password"`
 	Realm              string `yaml:"realm"`
+
+	// DisablePAFXFAST disables the FAST negotiation (PA-FX-FAST), which is not supported by some Active Directory domain
+	// controllers.
+	DisablePAFXFAST bool `yaml:"disablePaFxFast"`
 }
 
 // RegisterFlags registers all sensitive Kerberos settings as flag
 func (c *SASLGSSAPIConfig) RegisterFlags(f *flag.FlagSet) {
 	f.StringVar(&c.Password, "kafka.sasl.gssapi.password", "", "Kerberos password if auth type user auth is used")
 }
+
+// Validate GSSAPI config input
+func (c *SASLGSSAPIConfig) Validate() error {
+	switch c.AuthType {
+	case GSSAPIAuthTypeUser, gssapiAuthTypeUserDeprecated, GSSAPIAuthTypeKeytab:
+		// Valid and supported
+	case GSSAPIAuthTypeCCache:
+		return fmt.Errorf("gssapi auth type '%v' is not supported by the Kafka client library yet, please use "+
+			"%v or %v", GSSAPIAuthTypeCCache, GSSAPIAuthTypeKeytab, GSSAPIAuthTypeUser)
+	default:
+		return fmt.Errorf("given gssapi auth type '%v' is invalid, accepted values are: %v", c.AuthType,
+			strings.Join([]string{GSSAPIAuthTypeKeytab, GSSAPIAuthTypeUser}, ", "))
+	}
+
+	return nil
+}
+
+// IsDeprecatedAuthType returns true if the auth type is configured using a deprecated alias
+func (c *SASLGSSAPIConfig) IsDeprecatedAuthType() bool {
+	return c.AuthType == gssapiAuthTypeUserDeprecated
+}
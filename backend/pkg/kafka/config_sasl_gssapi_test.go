@@ -0,0 +1,42 @@
+package kafka
+
+import (
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSASLGSSAPIConfig_Validate(t *testing.T) {
+	for _, authType := range []string{"USER_AUTH", "USER_AUTH:", "KEYTAB_AUTH"} {
+		cfg := SASLGSSAPIConfig{AuthType: authType}
+		assert.NoError(t, cfg.Validate(), authType)
+	}
+
+	for _, authType := range []string{"", "user_auth", "CCACHE_AUTH"} {
+		cfg := SASLGSSAPIConfig{AuthType: authType}
+		assert.Error(t, cfg.Validate(), authType)
+	}
+}
+
+func TestNewSaramaConfig_GSSAPIUserAuth(t *testing.T) {
+	for _, authType := range []string{"USER_AUTH", "USER_AUTH:"} {
+		cfg := Config{Brokers: []string{"localhost:9092"}}
+		cfg.SetDefaults()
+		cfg.SASL.Enabled = true
+		cfg.SASL.Mechanism = sarama.SASLTypeGSSAPI
+		cfg.SASL.Username = "kowl"
+		cfg.SASL.GSSAPIConfig = SASLGSSAPIConfig{
+			AuthType:           authType,
+			KerberosConfigPath: "/etc/krb5.conf",
+			ServiceName:        "kafka",
+			Password:           "secret",
+			Realm:              "EXAMPLE.COM",
+		}
+
+		sConfig, err := NewSaramaConfig(&cfg)
+		require.NoError(t, err, authType)
+		assert.Equal(t, sarama.KRB5_USER_AUTH, sConfig.Net.SASL.GSSAPI.AuthType, authType)
+	}
+}
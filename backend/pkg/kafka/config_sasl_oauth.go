@@ -0,0 +1,34 @@
+package kafka
+
+import (
+	"flag"
+	"fmt"
+)
+
+// SASLOAuthBearerConfig is the config for SASL/OAUTHBEARER. Kowl obtains the bearer token from the configured token
+// endpoint using the OAuth2 client credentials grant.
+type SASLOAuthBearerConfig struct {
+	TokenEndpoint string            `yaml:"tokenEndpoint"`
+	ClientID      string            `yaml:"clientId"`
+	ClientSecret  string            `yaml:"clientSecret"`
+	Scopes        []string          `yaml:"scopes"`
+	Extensions    map[string]string `yaml:"extensions"`
+}
+
+// RegisterFlags for all sensitive OAuth bearer configs
+func (c *SASLOAuthBearerConfig) RegisterFlags(f *flag.FlagSet) {
+	f.StringVar(&c.ClientSecret, "kafka.sasl.oauth.client-secret", "", "OAuth client secret which is used to request the bearer token")
+}
+
+// Validate OAuth bearer config input
+func (c *SASLOAuthBearerConfig) Validate() error {
+	if c.TokenEndpoint == "" {
+		return fmt.Errorf("you must specify a token endpoint when using sasl mechanism OAUTHBEARER")
+	}
+
+	if c.ClientID == "" {
+		return fmt.Errorf("you must specify a client id when using sasl mechanism OAUTHBEARER")
+	}
+
+	return nil
+}
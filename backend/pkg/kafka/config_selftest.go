@@ -0,0 +1,38 @@
+package kafka
+
+import (
+	"fmt"
+	"time"
+)
+
+// SelfTestConfig configures the self test, which produces a message and consumes it back to verify the connectivity
+// to the cluster
+type SelfTestConfig struct {
+	// Topic is used by the self test if set. Otherwise a temporary topic with a single partition is created and deleted
+	// for each self test, which requires the permissions to create and delete topics.
+	Topic string `yaml:"topic"`
+
+	// ReplicationFactor of the temporary topics
+	ReplicationFactor int16 `yaml:"replicationFactor"`
+
+	// Timeout is the max duration of a self test, the cleanup of the temporary topic is not part of it
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// SetDefaults for the self test config
+func (c *SelfTestConfig) SetDefaults() {
+	c.ReplicationFactor = 1
+	c.Timeout = 30 * time.Second
+}
+
+// Validate the self test config
+func (c *SelfTestConfig) Validate() error {
+	if c.ReplicationFactor < 1 {
+		return fmt.Errorf("selfTest replicationFactor must be at least 1")
+	}
+	if c.Timeout <= 0 {
+		return fmt.Errorf("selfTest timeout must be a positive duration")
+	}
+
+	return nil
+}
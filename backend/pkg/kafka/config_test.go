@@ -0,0 +1,97 @@
+package kafka
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_Validate_AggregatesErrors(t *testing.T) {
+	cfg := Config{}
+	cfg.SetDefaults()
+	cfg.ClusterVersion = "2.6"
+	cfg.TLS.Enabled = true
+	cfg.TLS.CertFilepath = "/does/not/exist.crt"
+	cfg.TLS.KeyFilepath = "/does/not/exist.key"
+
+	err := cfg.Validate()
+	var validationErrs ValidationErrors
+	require.True(t, errors.As(err, &validationErrs))
+	assert.Len(t, validationErrs, 3) // brokers, cluster version, tls files
+
+	var versionErr *ErrKafkaVersion
+	assert.True(t, errors.As(err, &versionErr))
+	var tlsErr *ErrTLSConfig
+	assert.True(t, errors.As(err, &tlsErr))
+}
+
+func TestValidationErrors_Is(t *testing.T) {
+	errs := ValidationErrors{errors.New("brokers must be set"), fmt.Errorf("wrapped: %w", ErrInvalidCompression)}
+	assert.True(t, errors.Is(errs, ErrInvalidCompression))
+	assert.False(t, errors.Is(errs, ErrTopicNotFound))
+}
+
+func TestConfig_Validate_SingleError(t *testing.T) {
+	cfg := Config{Brokers: []string{"localhost:9092"}}
+	cfg.SetDefaults()
+	cfg.ClusterVersion = "2.6"
+
+	err := cfg.Validate()
+	var versionErr *ErrKafkaVersion
+	assert.True(t, errors.As(err, &versionErr), "a single error must be returned as is")
+}
+
+func TestConfig_Validate_RackID(t *testing.T) {
+	cfg := Config{Brokers: []string{"localhost:9092"}}
+	cfg.SetDefaults()
+	cfg.Consumer.RackID = "eu-central-1a"
+	assert.Error(t, cfg.Validate(), "fetch from follower requires Kafka 2.4")
+
+	cfg.ClusterVersion = "2.4.0"
+	assert.NoError(t, cfg.Validate())
+
+	sConfig, err := NewConsumerConfig(&cfg, ConsumerConfigOverride{})
+	require.NoError(t, err)
+	assert.Equal(t, "eu-central-1a", sConfig.RackID)
+}
+
+func TestConfig_SecurityProtocol(t *testing.T) {
+	cfg := Config{Brokers: []string{"localhost:9092"}}
+	cfg.SetDefaults()
+	cfg.SASL.Username = "kowl"
+	cfg.SASL.Password = "secret"
+
+	cfg.SecurityProtocol = SecurityProtocolSASLSSL
+	require.NoError(t, cfg.Validate())
+	assert.True(t, cfg.IsTLSEnabled())
+	assert.True(t, cfg.IsSASLEnabled())
+
+	sConfig, err := NewSaramaConfig(&cfg)
+	require.NoError(t, err)
+	assert.True(t, sConfig.Net.TLS.Enable)
+	assert.True(t, sConfig.Net.SASL.Enable)
+
+	// Explicitly enabled TLS contradicts the security protocol
+	cfg.SecurityProtocol = SecurityProtocolSASLPlaintext
+	cfg.TLS.Enabled = true
+	assert.Error(t, cfg.Validate())
+
+	cfg.SecurityProtocol = "SASL_TLS"
+	assert.Error(t, cfg.Validate())
+
+	// Without security protocol the booleans are used
+	cfg.SecurityProtocol = ""
+	assert.True(t, cfg.IsTLSEnabled())
+	assert.False(t, cfg.IsSASLEnabled())
+}
+
+func TestParseClusterVersion_Auto(t *testing.T) {
+	// auto is the client library's default version until it has been resolved with the brokers
+	version, err := parseClusterVersion(ClusterVersionAuto)
+	require.NoError(t, err)
+	assert.Equal(t, sarama.V1_0_0_0, version)
+}
@@ -1,18 +1,327 @@
 package kafka
 
-import "flag"
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
 
 // TLSConfig to connect to Kafka via TLS
 type TLSConfig struct {
-	Enabled               bool   `yaml:"enabled"`
+	Enabled bool `yaml:"enabled"`
+
+	// CaFilepath is deprecated, please use CaFilepaths instead
 	CaFilepath            string `yaml:"caFilepath"`
 	CertFilepath          string `yaml:"certFilepath"`
 	KeyFilepath           string `yaml:"keyFilepath"`
 	Passphrase            string `yaml:"passphrase"`
 	InsecureSkipTLSVerify bool   `yaml:"insecureSkipTlsVerify"`
+
+	// CaFilepaths and all *.pem / *.crt files in CaDir are loaded into the same cert pool, along with CaFilepath
+	// and CaPem.
+	CaFilepaths []string `yaml:"caFilepaths"`
+	CaDir       string   `yaml:"caDir"`
+
+	// CaPem, CertPem and KeyPem can be used to pass the PEM encoded TLS material directly, instead of loading it
+	// from the respective file paths.
+	CaPem   string `yaml:"caPem"`
+	CertPem string `yaml:"certPem"`
+	KeyPem  string `yaml:"keyPem"`
+
+	// Certificates can be used instead of CertFilepath/KeyFilepath if you need to present different client
+	// certificates. The Go TLS client will present the first certificate which satisfies the certificate request
+	// (e.g. acceptable CAs) sent by the broker.
+	Certificates []CertPair `yaml:"certificates"`
+
+	// PinnedCertSHA256 is a list of hex encoded SHA-256 fingerprints of DER encoded certificates. If set, the broker's
+	// certificate chain is accepted if and only if the leaf matches one of the pins, or the leaf is issued for the
+	// broker's host name by a presented certificate which matches one of the pins, regardless of whether the chain is
+	// trusted by the CA pool.
+	PinnedCertSHA256 []string `yaml:"pinnedCertSha256"`
+
+	// MinVersion and MaxVersion restrict the accepted TLS versions ("1.0", "1.1", "1.2" or "1.3"). MinVersion defaults
+	// to "1.2", MaxVersion defaults to the highest version supported by Go.
+	MinVersion string `yaml:"minVersion"`
+	MaxVersion string `yaml:"maxVersion"`
+
+	// CipherSuites restricts the cipher suites for TLS 1.2 and below, e.g. "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256".
+	// TLS 1.3 cipher suites are not configurable. If empty, Go's default cipher suites are used.
+	CipherSuites []string `yaml:"cipherSuites"`
+
+	// RefreshInterval enables reloading of file based client certificates, so that rotated certificates are used
+	// without restarting Kowl. Certificates are re-read from disk if they are older than the refresh interval.
+	RefreshInterval time.Duration `yaml:"refreshInterval"`
+
+	// Vault fetches the client certificate, its key and the CAs from HashiCorp Vault instead of files or PEMs
+	Vault VaultConfig `yaml:"vault"`
+
+	// GetClientCertificate can be set programmatically to take over the client certificate selection. If set, it
+	// takes precedence over the configured certificates.
+	GetClientCertificate func(*tls.CertificateRequestInfo) (*tls.Certificate, error) `yaml:"-"`
+
+	// VerifyConnection can be set programmatically to verify the broker's certificate chain against CAs which may
+	// change at runtime. It replaces the verification against the configured CAs and is ignored if certificates are
+	// pinned.
+	VerifyConnection func(tls.ConnectionState) error `yaml:"-"`
+}
+
+// CertPair is a client certificate along with it's private key and the optional passphrase to decrypt the key.
+type CertPair struct {
+	CertFilepath string `yaml:"certFilepath"`
+	CertPem      string `yaml:"certPem"`
+	KeyFilepath  string `yaml:"keyFilepath"`
+	KeyPem       string `yaml:"keyPem"`
+	Passphrase   string `yaml:"passphrase"`
+}
+
+// Validate cert pair input
+func (c *CertPair) Validate() error {
+	if c.CertFilepath != "" && c.CertPem != "" {
+		return fmt.Errorf("certFilepath and certPem are mutually exclusive, please only configure one of them")
+	}
+	if c.KeyFilepath != "" && c.KeyPem != "" {
+		return fmt.Errorf("keyFilepath and keyPem are mutually exclusive, please only configure one of them")
+	}
+
+	hasCert := c.CertFilepath != "" || c.CertPem != ""
+	hasKey := c.KeyFilepath != "" || c.KeyPem != ""
+	if hasCert != hasKey {
+		return fmt.Errorf("certificate and key must be supplied as a pair")
+	}
+
+	return nil
+}
+
+// isInline returns true if the certificate or the key is passed as PEM string rather than a file path.
+func (c *CertPair) isInline() bool {
+	return c.CertPem != "" || c.KeyPem != ""
+}
+
+// SetDefaults for TLS config
+func (c *TLSConfig) SetDefaults() {
+	c.MinVersion = "1.2"
+	c.Vault.SetDefaults()
 }
 
 // RegisterFlags for all sensitive Kafka TLS configs
 func (c *TLSConfig) RegisterFlags(f *flag.FlagSet) {
 	f.StringVar(&c.Passphrase, "kafka.tls.passphrase", "", "Passphrase to optionally decrypt the private key")
+	c.Vault.RegisterFlags(f)
+}
+
+// Validate TLS config input
+func (c *TLSConfig) Validate() error {
+	if c.CaFilepath != "" && c.CaPem != "" {
+		return fmt.Errorf("caFilepath and caPem are mutually exclusive, please only configure one of them")
+	}
+
+	minVersion, err := parseTLSVersion(c.MinVersion)
+	if err != nil {
+		return fmt.Errorf("invalid tls minVersion: %w", err)
+	}
+	maxVersion, err := parseTLSVersion(c.MaxVersion)
+	if err != nil {
+		return fmt.Errorf("invalid tls maxVersion: %w", err)
+	}
+	if minVersion != 0 && maxVersion != 0 && minVersion > maxVersion {
+		return fmt.Errorf("tls minVersion '%v' must not be greater than maxVersion '%v'", c.MinVersion, c.MaxVersion)
+	}
+
+	_, err = parseCipherSuites(c.CipherSuites)
+	if err != nil {
+		return err
+	}
+
+	if c.RefreshInterval < 0 {
+		return fmt.Errorf("tls refreshInterval must not be negative")
+	}
+
+	for _, pin := range c.PinnedCertSHA256 {
+		_, err := parseCertFingerprint(pin)
+		if err != nil {
+			return fmt.Errorf("invalid pinned certificate fingerprint '%v': %w", pin, err)
+		}
+	}
+
+	if c.Vault.Enabled {
+		return c.validateVault()
+	}
+
+	single := c.singleCertPair()
+	err = single.Validate()
+	if err != nil {
+		return err
+	}
+
+	if len(c.Certificates) == 0 {
+		return nil
+	}
+
+	if single.CertFilepath != "" || single.CertPem != "" || single.KeyFilepath != "" || single.KeyPem != "" {
+		return fmt.Errorf("tls certificates and the single certificate/key settings are mutually exclusive, please only configure one of them")
+	}
+
+	for i, pair := range c.Certificates {
+		if pair.CertFilepath == "" && pair.CertPem == "" {
+			return fmt.Errorf("tls certificate at index %d must specify a certificate and a key", i)
+		}
+		err := pair.Validate()
+		if err != nil {
+			return fmt.Errorf("tls certificate at index %d is invalid: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+// validateFiles checks whether all configured CA, certificate and key files are readable. It returns one error for
+// each file problem.
+func (c *TLSConfig) validateFiles() []error {
+	var errs []error
+
+	for _, path := range c.caFilepaths() {
+		if !canReadFile(path) {
+			errs = append(errs, fmt.Errorf("failed to read ca file '%v'", path))
+		}
+	}
+	if c.CaDir != "" {
+		info, err := os.Stat(c.CaDir)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to read ca directory: %w", err))
+		} else if !info.IsDir() {
+			errs = append(errs, fmt.Errorf("caDir '%v' is not a directory", c.CaDir))
+		}
+	}
+
+	for _, pair := range c.certPairs() {
+		if pair.isInline() {
+			continue
+		}
+		err := canReadCertAndKey(pair.CertFilepath, pair.KeyFilepath)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errs
+}
+
+// hasCustomCAs returns true if at least one CA source is configured. Otherwise the system's cert pool is used.
+func (c *TLSConfig) hasCustomCAs() bool {
+	return c.CaFilepath != "" || c.CaPem != "" || len(c.CaFilepaths) > 0 || c.CaDir != ""
+}
+
+// caFilepaths returns all configured CA file paths, including the deprecated single CaFilepath.
+func (c *TLSConfig) caFilepaths() []string {
+	paths := make([]string, 0, len(c.CaFilepaths)+1)
+	if c.CaFilepath != "" {
+		paths = append(paths, c.CaFilepath)
+	}
+	return append(paths, c.CaFilepaths...)
+}
+
+// singleCertPair returns the cert pair which is configured via the single certificate / key fields.
+func (c *TLSConfig) singleCertPair() CertPair {
+	return CertPair{
+		CertFilepath: c.CertFilepath,
+		CertPem:      c.CertPem,
+		KeyFilepath:  c.KeyFilepath,
+		KeyPem:       c.KeyPem,
+		Passphrase:   c.Passphrase,
+	}
+}
+
+// certPairs returns all configured certificate pairs, regardless of whether they have been configured via the
+// single cert/key fields or the list of certificates.
+func (c *TLSConfig) certPairs() []CertPair {
+	if len(c.Certificates) > 0 {
+		return c.Certificates
+	}
+
+	single := c.singleCertPair()
+	hasCert := single.CertFilepath != "" || single.CertPem != ""
+	hasKey := single.KeyFilepath != "" || single.KeyPem != ""
+	if hasCert && hasKey {
+		return []CertPair{single}
+	}
+
+	return nil
+}
+
+// parseCertFingerprint decodes a hex encoded SHA-256 fingerprint. Colons as separators (as printed by openssl) and upper
+// case letters are accepted.
+func parseCertFingerprint(fingerprint string) ([sha256.Size]byte, error) {
+	var res [sha256.Size]byte
+
+	decoded, err := hex.DecodeString(strings.ReplaceAll(fingerprint, ":", ""))
+	if err != nil {
+		return res, err
+	}
+	if len(decoded) != sha256.Size {
+		return res, fmt.Errorf("expected %d bytes, but got %d", sha256.Size, len(decoded))
+	}
+	copy(res[:], decoded)
+
+	return res, nil
+}
+
+// tlsVersions maps the accepted config values to the TLS versions
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// parseTLSVersion returns the TLS version for the given config value. An empty value returns 0, which lets Go choose
+// the version.
+func parseTLSVersion(version string) (uint16, error) {
+	if version == "" {
+		return 0, nil
+	}
+
+	v, exists := tlsVersions[version]
+	if !exists {
+		return 0, fmt.Errorf("unknown tls version '%v', accepted versions are: 1.0, 1.1, 1.2, 1.3", version)
+	}
+
+	return v, nil
+}
+
+// parseCipherSuites returns the IDs of the given cipher suite names. Only the cipher suites which are considered as
+// secure by Go (see tls.CipherSuites()) are accepted.
+func parseCipherSuites(names []string) ([]uint16, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	supported := make(map[string]uint16)
+	supportedNames := make([]string, 0)
+	for _, suite := range tls.CipherSuites() {
+		supported[suite.Name] = suite.ID
+		supportedNames = append(supportedNames, suite.Name)
+	}
+	insecure := make(map[string]struct{})
+	for _, suite := range tls.InsecureCipherSuites() {
+		insecure[suite.Name] = struct{}{}
+	}
+
+	ids := make([]uint16, len(names))
+	for i, name := range names {
+		id, exists := supported[name]
+		if !exists {
+			if _, isInsecure := insecure[name]; isInsecure {
+				return nil, fmt.Errorf("tls cipher suite '%v' is insecure and not supported", name)
+			}
+			return nil, fmt.Errorf("unknown tls cipher suite '%v', supported cipher suites are: %v", name, strings.Join(supportedNames, ", "))
+		}
+		ids[i] = id
+	}
+
+	return ids, nil
 }
@@ -0,0 +1,80 @@
+package kafka
+
+import (
+	"crypto/tls"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTLSConfig_Validate_VersionsAndCipherSuites(t *testing.T) {
+	cfg := TLSConfig{}
+	cfg.SetDefaults()
+	require.NoError(t, cfg.Validate())
+
+	cfg.MaxVersion = "1.1"
+	assert.Error(t, cfg.Validate(), "min version must not be greater than max version")
+
+	cfg.MaxVersion = "1.4"
+	assert.Error(t, cfg.Validate())
+
+	cfg.MaxVersion = ""
+	cfg.CipherSuites = []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256", "TLS_RSA_WITH_RC4_128_SHA"}
+	assert.Error(t, cfg.Validate(), "insecure cipher suites must be rejected")
+
+	cfg.CipherSuites = []string{"TLS_DOES_NOT_EXIST"}
+	assert.Error(t, cfg.Validate())
+}
+
+func TestNewTLSConfig_VersionsAndCipherSuites(t *testing.T) {
+	cfg := TLSConfig{Enabled: true, MinVersion: "1.2", MaxVersion: "1.3"}
+	cfg.CipherSuites = []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"}
+
+	tlsConfig, err := newTLSConfig(&cfg)
+	require.NoError(t, err)
+	assert.Equal(t, uint16(tls.VersionTLS12), tlsConfig.MinVersion)
+	assert.Equal(t, uint16(tls.VersionTLS13), tlsConfig.MaxVersion)
+	assert.Equal(t, []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256}, tlsConfig.CipherSuites)
+}
+
+func TestTLSConfig_Validate_Vault(t *testing.T) {
+	newCfg := func() TLSConfig {
+		cfg := TLSConfig{}
+		cfg.SetDefaults()
+		cfg.Vault.Enabled = true
+		cfg.Vault.Address = "https://vault:8200"
+		cfg.Vault.AppRole.RoleID = "role"
+		cfg.Vault.AppRole.SecretID = "secret"
+		cfg.Vault.PKI.Path = "pki/issue/kowl"
+		cfg.Vault.PKI.CommonName = "kowl"
+		return cfg
+	}
+
+	cfg := newCfg()
+	require.NoError(t, cfg.Validate())
+	assert.Equal(t, vaultDefaultRefreshInterval, cfg.vaultRefreshInterval())
+
+	cfg.CertFilepath = "client.crt"
+	cfg.KeyFilepath = "client.key"
+	assert.Error(t, cfg.Validate(), "file based certificates must be rejected")
+
+	cfg = newCfg()
+	cfg.CaFilepaths = []string{"ca.pem"}
+	assert.NoError(t, cfg.Validate(), "CAs are used if vault returns no CA")
+
+	cfg = newCfg()
+	cfg.Vault.Token = "token"
+	assert.Error(t, cfg.Validate(), "token and approle are mutually exclusive")
+
+	cfg = newCfg()
+	cfg.Vault.KV.Path = "secret/data/kowl"
+	assert.Error(t, cfg.Validate(), "pki and kv are mutually exclusive")
+
+	cfg = newCfg()
+	cfg.Vault.PKI.TTL = time.Hour
+	assert.Equal(t, 30*time.Minute, cfg.vaultRefreshInterval())
+	cfg.RefreshInterval = time.Hour
+	assert.Error(t, cfg.Validate(), "certificates must be refreshed before they expire")
+}
@@ -0,0 +1,150 @@
+package kafka
+
+import (
+	"flag"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// vaultDefaultRefreshInterval is the interval in which the TLS material is fetched from Vault again if neither a
+// refresh interval nor a PKI TTL is configured
+const vaultDefaultRefreshInterval = time.Hour
+
+// VaultConfig configures HashiCorp Vault as source of the client certificate, its key and the CAs. The TLS material
+// is either issued by a PKI secrets engine or read from a KV secret. The configured CAs are used if Vault returns no
+// CA.
+type VaultConfig struct {
+	Enabled bool `yaml:"enabled"`
+
+	// Address of the Vault server, e.g. https://vault:8200
+	Address string `yaml:"address"`
+
+	// Namespace is sent as X-Vault-Namespace header (Vault Enterprise only)
+	Namespace string `yaml:"namespace"`
+
+	// Token or AppRole is used to authenticate against Vault
+	Token   string             `yaml:"token"`
+	AppRole VaultAppRoleConfig `yaml:"appRole"`
+
+	// PKI or KV is the source of the TLS material
+	PKI VaultPKIConfig `yaml:"pki"`
+	KV  VaultKVConfig  `yaml:"kv"`
+}
+
+// VaultAppRoleConfig is the config for the AppRole auth method
+type VaultAppRoleConfig struct {
+	MountPath string `yaml:"mountPath"`
+	RoleID    string `yaml:"roleId"`
+	SecretID  string `yaml:"secretId"`
+}
+
+// VaultPKIConfig configures the issuing of a client certificate by a PKI secrets engine, e.g. path "pki/issue/kowl"
+type VaultPKIConfig struct {
+	Path       string        `yaml:"path"`
+	CommonName string        `yaml:"commonName"`
+	TTL        time.Duration `yaml:"ttl"`
+}
+
+// VaultKVConfig configures the KV secret (v1 or v2) which contains the PEM encoded TLS material, e.g. path
+// "secret/data/kowl/kafka" for a KV v2 engine mounted at secret/
+type VaultKVConfig struct {
+	Path      string `yaml:"path"`
+	CertField string `yaml:"certField"`
+	KeyField  string `yaml:"keyField"`
+	CaField   string `yaml:"caField"`
+}
+
+// SetDefaults for the Vault config
+func (c *VaultConfig) SetDefaults() {
+	c.AppRole.MountPath = "approle"
+	c.KV.CertField = "certificate"
+	c.KV.KeyField = "private_key"
+	c.KV.CaField = "ca"
+}
+
+// RegisterFlags for all sensitive Vault configs
+func (c *VaultConfig) RegisterFlags(f *flag.FlagSet) {
+	f.StringVar(&c.Token, "kafka.tls.vault.token", "", "Vault token which is used to fetch the TLS material")
+	f.StringVar(&c.AppRole.SecretID, "kafka.tls.vault.approle.secret-id", "", "Vault AppRole secret id which is used to fetch the TLS material")
+}
+
+// Validate Vault config input
+func (c *VaultConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+
+	if c.Address == "" {
+		return fmt.Errorf("you must specify the vault address")
+	}
+	u, err := url.Parse(c.Address)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return fmt.Errorf("vault address '%v' must be an absolute url, e.g. https://vault:8200", c.Address)
+	}
+
+	hasAppRole := c.AppRole.RoleID != "" || c.AppRole.SecretID != ""
+	if c.Token != "" && hasAppRole {
+		return fmt.Errorf("vault token and appRole are mutually exclusive, please only configure one of them")
+	}
+	if c.Token == "" && !hasAppRole {
+		return fmt.Errorf("you must specify either a vault token or an appRole")
+	}
+	if hasAppRole && (c.AppRole.RoleID == "" || c.AppRole.SecretID == "") {
+		return fmt.Errorf("vault appRole requires a roleId and a secretId")
+	}
+	if hasAppRole && c.AppRole.MountPath == "" {
+		return fmt.Errorf("vault appRole mountPath must not be empty")
+	}
+
+	if c.PKI.Path != "" && c.KV.Path != "" {
+		return fmt.Errorf("vault pki and kv are mutually exclusive, please only configure one of them")
+	}
+	if c.PKI.Path == "" && c.KV.Path == "" {
+		return fmt.Errorf("you must specify either a vault pki path or a kv path")
+	}
+	if c.PKI.Path != "" && c.PKI.CommonName == "" {
+		return fmt.Errorf("you must specify the common name of the certificate which is issued by vault pki")
+	}
+	if c.PKI.TTL < 0 {
+		return fmt.Errorf("vault pki ttl must not be negative")
+	}
+	if c.KV.Path != "" && (c.KV.CertField == "" || c.KV.KeyField == "") {
+		return fmt.Errorf("vault kv certField and keyField must not be empty")
+	}
+
+	return nil
+}
+
+// validateVault checks that the TLS material is not configured from other sources if it's fetched from Vault and
+// that the certificates are fetched again before an issued certificate expires.
+func (c *TLSConfig) validateVault() error {
+	err := c.Vault.Validate()
+	if err != nil {
+		return err
+	}
+
+	hasLocalCerts := len(c.Certificates) > 0 ||
+		c.CertFilepath != "" || c.CertPem != "" || c.KeyFilepath != "" || c.KeyPem != ""
+	if hasLocalCerts {
+		return fmt.Errorf("tls vault can not be combined with certificate or key files and pems, please only configure one of them")
+	}
+
+	if c.Vault.PKI.TTL > 0 && c.RefreshInterval >= c.Vault.PKI.TTL {
+		return fmt.Errorf("tls refreshInterval '%v' must be lower than the vault pki ttl '%v'", c.RefreshInterval, c.Vault.PKI.TTL)
+	}
+
+	return nil
+}
+
+// vaultRefreshInterval returns the interval in which the TLS material is fetched from Vault. Unless configured, issued
+// certificates are renewed after half of their TTL.
+func (c *TLSConfig) vaultRefreshInterval() time.Duration {
+	if c.RefreshInterval > 0 {
+		return c.RefreshInterval
+	}
+	if c.Vault.PKI.TTL > 0 {
+		return c.Vault.PKI.TTL / 2
+	}
+	return vaultDefaultRefreshInterval
+}
@@ -1,85 +1,182 @@
 package kafka
 
 import (
+	"crypto/sha256"
 	"crypto/tls"
 	"crypto/x509"
 	"encoding/pem"
 	"fmt"
 	"io/ioutil"
+	"net"
 	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/Shopify/sarama"
+	"github.com/youmark/pkcs8"
+	"golang.org/x/net/proxy"
 )
 
 // NewSaramaConfig creates a new sarama config which can be used for the admin client
 func NewSaramaConfig(cfg *Config) (*sarama.Config, error) {
+	sConfig, err := newBaseSaramaConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	err = sConfig.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return sConfig, nil
+}
+
+// ConsumerConfigOverride contains settings which differ from the configuration for a single consumer config
+type ConsumerConfigOverride struct {
+	// ClientID replaces the configured client id if set
+	ClientID string
+}
+
+// NewConsumerConfig creates a new sarama config which can be used for consumers. On top of the admin config it applies
+// the configured fetch settings and the given override.
+func NewConsumerConfig(cfg *Config, override ConsumerConfigOverride) (*sarama.Config, error) {
+	sConfig, err := newBaseSaramaConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if override.ClientID != "" {
+		sConfig.ClientID = override.ClientID
+	}
+
+	sConfig.Consumer.Return.Errors = true
+	sConfig.Consumer.Offsets.Initial = sarama.OffsetOldest
+	sConfig.Consumer.Fetch.Min = cfg.Consumer.FetchMinBytes
+	if cfg.Consumer.MaxPartitionFetchBytes > 0 {
+		// Sarama starts with the default fetch size and doubles it until the max, if a record doesn't fit
+		sConfig.Consumer.Fetch.Max = cfg.Consumer.MaxPartitionFetchBytes
+		if sConfig.Consumer.Fetch.Default > sConfig.Consumer.Fetch.Max {
+			sConfig.Consumer.Fetch.Default = sConfig.Consumer.Fetch.Max
+		}
+	}
+	sConfig.Consumer.MaxWaitTime = cfg.Consumer.MaxWaitTime
+	sConfig.RackID = cfg.Consumer.RackID
+
+	err = sConfig.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return sConfig, nil
+}
+
+// NewProducerConfig creates a new sarama config which can be used for (sync) producers which compress messages with
+// the given codec
+func NewProducerConfig(cfg *Config, codec sarama.CompressionCodec) (*sarama.Config, error) {
+	sConfig, err := newBaseSaramaConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	sConfig.Producer.Return.Successes = true
+	sConfig.Producer.Return.Errors = true
+	sConfig.Producer.Partitioner = newExplicitPartitioner
+	sConfig.Producer.Compression = codec
+	sConfig.Producer.CompressionLevel = cfg.Producer.compressionLevel(codec)
+	sConfig.Producer.Idempotent = cfg.Producer.Idempotent
+	sConfig.Producer.Retry.Max = cfg.Producer.Retries
+	sConfig.Net.MaxOpenRequests = cfg.Producer.maxInFlight()
+	sConfig.Producer.RequiredAcks, err = cfg.Producer.requiredAcks()
+	if err != nil {
+		return nil, err
+	}
+
+	err = sConfig.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return sConfig, nil
+}
+
+// newBaseSaramaConfig creates a sarama config with all settings which are shared across all client roles, such as
+// the network, TLS and SASL settings.
+func newBaseSaramaConfig(cfg *Config) (*sarama.Config, error) {
 	sConfig := sarama.NewConfig()
 
 	// Configure general Kafka settings
-	version, err := sarama.ParseKafkaVersion(cfg.ClusterVersion)
+	version, err := parseClusterVersion(cfg.ClusterVersion)
 	if err != nil {
 		return nil, err
 	}
 	sConfig.ClientID = cfg.ClientID
 	sConfig.Version = version
-	sConfig.Net.KeepAlive = 15 * time.Second
-	sConfig.Net.DialTimeout = 15 * time.Second
-	sConfig.Net.ReadTimeout = 15 * time.Second
-	sConfig.Net.WriteTimeout = 15 * time.Second
-
-	// Configure TLS
-	if cfg.TLS.Enabled {
-		sConfig.Net.TLS.Enable = true
-		sConfig.Net.TLS.Config = &tls.Config{InsecureSkipVerify: cfg.TLS.InsecureSkipTLSVerify}
+	sConfig.Net.KeepAlive = durationOrDefault(cfg.KeepAlive, 15*time.Second)
+	sConfig.Net.DialTimeout = durationOrDefault(cfg.DialTimeout, 15*time.Second)
+	sConfig.Net.ReadTimeout = durationOrDefault(cfg.ReadTimeout, 15*time.Second)
+	sConfig.Net.WriteTimeout = durationOrDefault(cfg.WriteTimeout, 15*time.Second)
+	sConfig.Metadata.RefreshFrequency = durationOrDefault(cfg.metadataRefreshInterval(), 10*time.Minute)
+	sConfig.Metadata.Retry.Max = cfg.Metadata.retryMax()
+	clientMetrics := cfg.clientMetrics
+	if clientMetrics == nil {
+		clientMetrics = newClusterClientMetrics()
+	}
+	if cfg.EnableClientMetrics {
+		sConfig.MetricRegistry = clientMetrics.registry
+	}
 
-		// Load CA file
-		if cfg.TLS.CaFilepath != "" {
-			ca, err := ioutil.ReadFile(cfg.TLS.CaFilepath)
-			if err != nil {
-				return nil, err
-			}
-			caCertPool := x509.NewCertPool()
-			caCertPool.AppendCertsFromPEM(ca)
-			sConfig.Net.TLS.Config.RootCAs = caCertPool
+	// Configure Proxy. All connections are dialed by the counting dialer, so that the open connections per broker
+	// can be shown. Sarama only supports custom dialers as proxy dialer, hence its proxy setting is always enabled,
+	// even if the connections are not routed through a proxy (see saramaLogger).
+	forward := &net.Dialer{Timeout: sConfig.Net.DialTimeout, KeepAlive: sConfig.Net.KeepAlive, LocalAddr: sConfig.Net.LocalAddr}
+	var dialer proxy.Dialer = forward
+	if cfg.Proxy.URL != "" {
+		dialer, err = newProxyDialer(cfg.Proxy, forward)
+		if err != nil {
+			return nil, err
 		}
+	}
+	sConfig.Net.Proxy.Enable = true
+	sConfig.Net.Proxy.Dialer = newCountingDialer(dialer, clientMetrics.connections)
 
-		// Load TLS / Key files
-		if cfg.TLS.CertFilepath != "" && cfg.TLS.KeyFilepath != "" {
-			err := canReadCertAndKey(cfg.TLS.CertFilepath, cfg.TLS.KeyFilepath)
-			if err != nil {
-				return nil, err
-			}
-
-			// Load Cert files and if necessary decrypt it too
-			certs, err := parseCerts(cfg.TLS.CertFilepath, cfg.TLS.KeyFilepath, cfg.TLS.Passphrase)
-			if err != nil {
-				return nil, err
-			}
-			sConfig.Net.TLS.Config.Certificates = certs
+	// Configure TLS
+	if cfg.IsTLSEnabled() {
+		tlsConfig, err := newTLSConfig(&cfg.TLS)
+		if err != nil {
+			return nil, &ErrTLSConfig{Err: err}
 		}
+		sConfig.Net.TLS.Enable = true
+		sConfig.Net.TLS.Config = tlsConfig
 	}
 
 	// Configure SASL
-	if cfg.SASL.Enabled {
+	if cfg.IsSASLEnabled() {
 		sConfig.Net.SASL.Enable = true
 		sConfig.Net.SASL.Handshake = cfg.SASL.UseHandshake
 		sConfig.Net.SASL.User = cfg.SASL.Username
 		sConfig.Net.SASL.Password = cfg.SASL.Password
+		isDelegationToken := cfg.SASL.DelegationToken.IsEnabled()
+		if isDelegationToken {
+			sConfig.Net.SASL.User = cfg.SASL.DelegationToken.TokenID
+			sConfig.Net.SASL.Password = cfg.SASL.DelegationToken.HMAC
+		}
 
 		switch cfg.SASL.Mechanism {
+		case sarama.SASLTypePlaintext:
+			sConfig.Net.SASL.Mechanism = sarama.SASLTypePlaintext
 		case sarama.SASLTypeSCRAMSHA256:
 			sConfig.Net.SASL.Mechanism = sarama.SASLTypeSCRAMSHA256
-			sConfig.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient { return &xdgSCRAMClient{HashGeneratorFcn: scramSha256} }
+			sConfig.Net.SASL.SCRAMClientGeneratorFunc = newSCRAMClientGenerator(scramSha256, isDelegationToken)
 		case sarama.SASLTypeSCRAMSHA512:
 			sConfig.Net.SASL.Mechanism = sarama.SASLTypeSCRAMSHA512
-			sConfig.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient { return &xdgSCRAMClient{HashGeneratorFcn: scramSha512} }
+			sConfig.Net.SASL.SCRAMClientGeneratorFunc = newSCRAMClientGenerator(scramSha512, isDelegationToken)
 		case sarama.SASLTypeGSSAPI:
 			sConfig.Net.SASL.Mechanism = sarama.SASLTypeGSSAPI
 			switch cfg.SASL.GSSAPIConfig.AuthType {
-			case "USER_AUTH:":
+			case GSSAPIAuthTypeUser, gssapiAuthTypeUserDeprecated:
 				sConfig.Net.SASL.GSSAPI.AuthType = sarama.KRB5_USER_AUTH
-			case "KEYTAB_AUTH":
+			case GSSAPIAuthTypeKeytab:
 				sConfig.Net.SASL.GSSAPI.AuthType = sarama.KRB5_KEYTAB_AUTH
 				sConfig.Net.SASL.GSSAPI.KeyTabPath = cfg.SASL.GSSAPIConfig.KeyTabPath
 			}
@@ -88,15 +185,224 @@ func NewSaramaConfig(cfg *Config) (*sarama.Config, error) {
 			sConfig.Net.SASL.GSSAPI.KerberosConfigPath = cfg.SASL.GSSAPIConfig.KerberosConfigPath
 			sConfig.Net.SASL.GSSAPI.ServiceName = cfg.SASL.GSSAPIConfig.ServiceName
 			sConfig.Net.SASL.GSSAPI.Realm = cfg.SASL.GSSAPIConfig.Realm
+			sConfig.Net.SASL.GSSAPI.DisablePAFXFAST = cfg.SASL.GSSAPIConfig.DisablePAFXFAST
+		case sarama.SASLTypeOAuth:
+			sConfig.Net.SASL.Mechanism = sarama.SASLTypeOAuth
+			sConfig.Net.SASL.TokenProvider = newOAuthTokenProvider(cfg.SASL.OAuth)
+		case SASLMechanismAWSMSKIAM:
+			tokenProvider, err := newAWSMskIamTokenProvider(cfg.SASL.AWSMskIam)
+			if err != nil {
+				return nil, &ErrSASLConfig{Err: fmt.Errorf("failed to create aws msk iam token provider: %w", err)}
+			}
+			sConfig.Net.SASL.Mechanism = sarama.SASLTypeOAuth
+			sConfig.Net.SASL.TokenProvider = tokenProvider
+		default:
+			return nil, &ErrSASLConfig{Err: fmt.Errorf("unsupported SASL mechanism %q", cfg.SASL.Mechanism)}
 		}
 	}
 
-	err = sConfig.Validate()
+	return sConfig, nil
+}
+
+// newTLSConfig creates the TLS config for the broker connections, including the CA, client certificates and optional
+// certificate pinning.
+func newTLSConfig(cfg *TLSConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: cfg.InsecureSkipTLSVerify}
+
+	// TLS versions and cipher suites
+	minVersion, err := parseTLSVersion(cfg.MinVersion)
 	if err != nil {
 		return nil, err
 	}
+	maxVersion, err := parseTLSVersion(cfg.MaxVersion)
+	if err != nil {
+		return nil, err
+	}
+	cipherSuites, err := parseCipherSuites(cfg.CipherSuites)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig.MinVersion = minVersion
+	tlsConfig.MaxVersion = maxVersion
+	tlsConfig.CipherSuites = cipherSuites
 
-	return sConfig, nil
+	// Load CA files
+	if cfg.hasCustomCAs() {
+		caCertPool, _, err := loadCACertPool(cfg)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = caCertPool
+	}
+
+	// Load TLS / Key files
+	certPairs := cfg.certPairs()
+	if len(certPairs) > 0 {
+		for _, pair := range certPairs {
+			if pair.isInline() {
+				continue
+			}
+			err := canReadCertAndKey(pair.CertFilepath, pair.KeyFilepath)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		// Load Cert files and if necessary decrypt it too
+		certs, err := parseCerts(certPairs)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = certs
+	}
+	tlsConfig.GetClientCertificate = cfg.GetClientCertificate
+
+	// CAs which may change at runtime (e.g. fetched from Vault) are verified by the given callback instead of by the
+	// static RootCAs
+	if cfg.VerifyConnection != nil && !cfg.InsecureSkipTLSVerify && len(cfg.PinnedCertSHA256) == 0 {
+		tlsConfig.InsecureSkipVerify = true
+		tlsConfig.VerifyConnection = cfg.VerifyConnection
+	}
+
+	// Certificate pinning replaces the standard chain verification
+	if len(cfg.PinnedCertSHA256) > 0 {
+		verifier, err := newPinnedCertVerifier(cfg.PinnedCertSHA256)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.InsecureSkipVerify = true
+		tlsConfig.VerifyConnection = verifier
+	}
+
+	return tlsConfig, nil
+}
+
+// loadCACertPool creates a cert pool which contains the certificates of all configured CA sources. It also returns
+// the number of loaded certificates.
+func loadCACertPool(cfg *TLSConfig) (*x509.CertPool, int, error) {
+	pool := x509.NewCertPool()
+	count := 0
+
+	addPEM := func(source string, pemBytes []byte) error {
+		n, err := addCertsFromPEM(pool, pemBytes)
+		if err != nil {
+			return fmt.Errorf("failed to load ca certificates from %v: %w", source, err)
+		}
+		if n == 0 {
+			return fmt.Errorf("no ca certificates found in %v", source)
+		}
+		count += n
+		return nil
+	}
+
+	if cfg.CaPem != "" {
+		err := addPEM("caPem", []byte(cfg.CaPem))
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+
+	paths := cfg.caFilepaths()
+	if cfg.CaDir != "" {
+		entries, err := ioutil.ReadDir(cfg.CaDir)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to read ca directory: %w", err)
+		}
+		for _, entry := range entries {
+			ext := strings.ToLower(filepath.Ext(entry.Name()))
+			if entry.IsDir() || (ext != ".pem" && ext != ".crt") {
+				continue
+			}
+			paths = append(paths, filepath.Join(cfg.CaDir, entry.Name()))
+		}
+	}
+
+	for _, path := range paths {
+		pemBytes, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, 0, err
+		}
+		err = addPEM(fmt.Sprintf("file '%v'", path), pemBytes)
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+
+	return pool, count, nil
+}
+
+// addCertsFromPEM parses all certificates in the given PEM data and adds them to the pool
+func addCertsFromPEM(pool *x509.CertPool, pemBytes []byte) (int, error) {
+	count := 0
+	for {
+		var block *pem.Block
+		block, pemBytes = pem.Decode(pemBytes)
+		if block == nil {
+			return count, nil
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return count, err
+		}
+		pool.AddCert(cert)
+		count++
+	}
+}
+
+// newPinnedCertVerifier returns a VerifyConnection func which succeeds only if the broker's leaf certificate matches
+// one of the given SHA-256 fingerprints, or if the leaf is issued for the broker's host name by a presented CA
+// certificate which matches one of them. Merely presenting a pinned certificate next to an unrelated leaf is rejected.
+func newPinnedCertVerifier(pins []string) (func(tls.ConnectionState) error, error) {
+	fingerprints := make(map[[sha256.Size]byte]struct{}, len(pins))
+	for _, pin := range pins {
+		fingerprint, err := parseCertFingerprint(pin)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pinned certificate fingerprint '%v': %w", pin, err)
+		}
+		fingerprints[fingerprint] = struct{}{}
+	}
+
+	return func(cs tls.ConnectionState) error {
+		if len(cs.PeerCertificates) == 0 {
+			return fmt.Errorf("broker presented no certificate")
+		}
+		leaf := cs.PeerCertificates[0]
+		if _, exists := fingerprints[sha256.Sum256(leaf.Raw)]; exists {
+			return nil
+		}
+
+		// Pinned CAs are the only roots, the other presented certificates may serve as intermediates
+		roots := x509.NewCertPool()
+		intermediates := x509.NewCertPool()
+		for _, cert := range cs.PeerCertificates[1:] {
+			if _, exists := fingerprints[sha256.Sum256(cert.Raw)]; exists {
+				roots.AddCert(cert)
+			} else {
+				intermediates.AddCert(cert)
+			}
+		}
+		_, err := leaf.Verify(x509.VerifyOptions{
+			Roots:         roots,
+			Intermediates: intermediates,
+			DNSName:       cs.ServerName,
+		})
+		if err != nil {
+			return fmt.Errorf("broker certificate is neither pinned nor issued by a pinned certificate: %w", err)
+		}
+		return nil
+	}, nil
+}
+
+// durationOrDefault returns the given duration or the fallback if the duration is not set
+func durationOrDefault(d time.Duration, fallback time.Duration) time.Duration {
+	if d <= 0 {
+		return fallback
+	}
+	return d
 }
 
 // canReadCertAndKey returns true if the certificate and key files already exists otherwise returns false
@@ -131,40 +437,65 @@ func canReadFile(path string) bool {
 	return true
 }
 
-// parseCert parses a TLS certificate from the CertFile and KeyFile.
+// parseCerts parses one TLS certificate for each given cert pair from the CertFile and KeyFile.
+// If the key is encrypted, the pair's passphrase will be used to decrypt it.
+func parseCerts(pairs []CertPair) ([]tls.Certificate, error) {
+	certs := make([]tls.Certificate, len(pairs))
+	for i, pair := range pairs {
+		cert, err := parseCert(pair)
+		if err != nil {
+			return nil, err
+		}
+		certs[i] = cert
+	}
+
+	return certs, nil
+}
+
+// parseCert parses a TLS certificate from the given cert pair. The certificate and key are either read from the
+// inline PEM strings or from the configured file paths.
 // If the key is encrypted, the passphrase will be used to decrypt it.
-func parseCerts(certFilePath string, keyFilePath string, passphrase string) ([]tls.Certificate, error) {
-	if certFilePath == "" && keyFilePath == "" {
-		return nil, fmt.Errorf("No file path specified for TLS key and certificate in environment variables")
+func parseCert(pair CertPair) (tls.Certificate, error) {
+	if pair.CertFilepath == "" && pair.KeyFilepath == "" && !pair.isInline() {
+		return tls.Certificate{}, fmt.Errorf("No file path specified for TLS key and certificate in environment variables")
 	}
 
 	errMessage := "Could not load X509 key pair. "
 
-	cert, err := ioutil.ReadFile(certFilePath)
-	if err != nil {
-		return nil, fmt.Errorf(errMessage, err)
+	cert := []byte(pair.CertPem)
+	if pair.CertFilepath != "" {
+		var err error
+		cert, err = ioutil.ReadFile(pair.CertFilepath)
+		if err != nil {
+			return tls.Certificate{}, fmt.Errorf(errMessage, err)
+		}
 	}
 
-	prKeyBytes, err := ioutil.ReadFile(keyFilePath)
-	if err != nil {
-		return nil, fmt.Errorf(errMessage, err)
+	prKeyBytes := []byte(pair.KeyPem)
+	if pair.KeyFilepath != "" {
+		var err error
+		prKeyBytes, err = ioutil.ReadFile(pair.KeyFilepath)
+		if err != nil {
+			return tls.Certificate{}, fmt.Errorf(errMessage, err)
+		}
 	}
 
-	prKeyBytes, err = decodePrivateKey(prKeyBytes, passphrase)
+	prKeyBytes, err := decodePrivateKey(prKeyBytes, pair.Passphrase)
 	if err != nil {
-		return nil, fmt.Errorf(errMessage, err)
+		return tls.Certificate{}, fmt.Errorf(errMessage, err)
 	}
 
 	tlsCert, err := tls.X509KeyPair(cert, prKeyBytes)
 	if err != nil {
-		return nil, fmt.Errorf(errMessage, err)
+		return tls.Certificate{}, fmt.Errorf(errMessage, err)
 	}
 
-	return []tls.Certificate{tlsCert}, nil
+	return tlsCert, nil
 }
 
-// getPrivateKey returns the private key in 'keyBytes', in a PEM-encoded format.
-// If the private key is encrypted, 'passphrase' is used to decrypted the private key.
+// decodePrivateKey returns the private key in 'keyBytes', in a PEM-encoded format.
+// If the private key is encrypted, 'passphrase' is used to decrypted the private key. Both, the legacy PEM encryption
+// (Proc-Type / DEK-Info headers) and encrypted PKCS#8 keys ('ENCRYPTED PRIVATE KEY') are supported.
 func decodePrivateKey(keyBytes []byte, passphrase string) ([]byte, error) {
 	// this section makes some small changes to code from notary/tuf/utils/x509.go
 	pemBlock, _ := pem.Decode(keyBytes)
@@ -172,6 +503,18 @@ func decodePrivateKey(keyBytes []byte, passphrase string) ([]byte, error) {
 		return nil, fmt.Errorf("no valid private key found")
 	}
 
+	if pemBlock.Type == "ENCRYPTED PRIVATE KEY" {
+		key, err := pkcs8.ParsePKCS8PrivateKey(pemBlock.Bytes, []byte(passphrase))
+		if err != nil {
+			return nil, fmt.Errorf("private key is encrypted, but could not decrypt it: '%s'", err)
+		}
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal decrypted private key: '%s'", err)
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+	}
+
 	var err error
 	if x509.IsEncryptedPEMBlock(pemBlock) {
 		keyBytes, err = x509.DecryptPEMBlock(pemBlock, []byte(passphrase))
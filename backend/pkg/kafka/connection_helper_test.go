@@ -0,0 +1,261 @@
+package kafka
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/youmark/pkcs8"
+)
+
+func TestDecodePrivateKey(t *testing.T) {
+	passphrase := "kowl-secret"
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	ecDER, err := x509.MarshalECPrivateKey(ecKey)
+	require.NoError(t, err)
+
+	legacyEncrypted := func(blockType string, der []byte) []byte {
+		block, err := x509.EncryptPEMBlock(rand.Reader, blockType, der, []byte(passphrase), x509.PEMCipherAES256)
+		require.NoError(t, err)
+		return pem.EncodeToMemory(block)
+	}
+	pkcs8Encrypted := func(key interface{}) []byte {
+		der, err := pkcs8.MarshalPrivateKey(key, []byte(passphrase), nil)
+		require.NoError(t, err)
+		return pem.EncodeToMemory(&pem.Block{Type: "ENCRYPTED PRIVATE KEY", Bytes: der})
+	}
+
+	tt := []struct {
+		name     string
+		keyPEM   []byte
+		expected interface{}
+	}{
+		{"rsa unencrypted", pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)}), rsaKey},
+		{"rsa legacy encrypted", legacyEncrypted("RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey)), rsaKey},
+		{"rsa pkcs8 encrypted", pkcs8Encrypted(rsaKey), rsaKey},
+		{"ec legacy encrypted", legacyEncrypted("EC PRIVATE KEY", ecDER), ecKey},
+		{"ec pkcs8 encrypted", pkcs8Encrypted(ecKey), ecKey},
+	}
+
+	for _, table := range tt {
+		decoded, err := decodePrivateKey(table.keyPEM, passphrase)
+		require.NoError(t, err, table.name)
+
+		block, _ := pem.Decode(decoded)
+		require.NotNil(t, block, table.name)
+		// Keys are compared with Equal, because their internal precomputed values may differ
+		expected := table.expected.(interface{ Equal(crypto.PrivateKey) bool })
+		assert.True(t, expected.Equal(parsePrivateKeyDER(t, block)), table.name)
+	}
+}
+
+func TestDecodePrivateKey_WrongPassphrase(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := pkcs8.MarshalPrivateKey(ecKey, []byte("correct"), nil)
+	require.NoError(t, err)
+
+	_, err = decodePrivateKey(pem.EncodeToMemory(&pem.Block{Type: "ENCRYPTED PRIVATE KEY", Bytes: der}), "wrong")
+	assert.Error(t, err)
+}
+
+func parsePrivateKeyDER(t *testing.T, block *pem.Block) interface{} {
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		require.NoError(t, err)
+		return key
+	case "EC PRIVATE KEY":
+		key, err := x509.ParseECPrivateKey(block.Bytes)
+		require.NoError(t, err)
+		return key
+	default:
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		require.NoError(t, err)
+		return key
+	}
+}
+
+func TestNewPinnedCertVerifier(t *testing.T) {
+	ca, caKey := newTestCert(t, "ca", nil, nil)
+	leaf, _ := newTestCert(t, "broker.example.com", ca, caKey)
+	unrelatedLeaf, _ := newTestCert(t, "broker.example.com", nil, nil)
+	pin := func(cert *x509.Certificate) string {
+		fingerprint := sha256.Sum256(cert.Raw)
+		return hex.EncodeToString(fingerprint[:])
+	}
+	state := func(serverName string, certs ...*x509.Certificate) tls.ConnectionState {
+		return tls.ConnectionState{ServerName: serverName, PeerCertificates: certs}
+	}
+
+	// Pins are accepted in openssl's colon separated upper case notation as well
+	opensslPin := strings.ToUpper(pin(ca))
+	var colonSeparated []string
+	for i := 0; i < len(opensslPin); i += 2 {
+		colonSeparated = append(colonSeparated, opensslPin[i:i+2])
+	}
+
+	verifyCA, err := newPinnedCertVerifier([]string{strings.Join(colonSeparated, ":")})
+	require.NoError(t, err)
+	assert.NoError(t, verifyCA(state("broker.example.com", leaf, ca)))
+	assert.Error(t, verifyCA(state("other.example.com", leaf, ca)), "leaf must be issued for the broker")
+	assert.Error(t, verifyCA(state("broker.example.com", leaf)), "pinned ca must be presented")
+	assert.Error(t, verifyCA(state("broker.example.com", unrelatedLeaf, ca)), "pinned ca did not issue the leaf")
+	assert.Error(t, verifyCA(state("broker.example.com")))
+
+	verifyLeaf, err := newPinnedCertVerifier([]string{pin(unrelatedLeaf)})
+	require.NoError(t, err)
+	assert.NoError(t, verifyLeaf(state("broker.example.com", unrelatedLeaf)))
+	assert.Error(t, verifyLeaf(state("broker.example.com", leaf, unrelatedLeaf)), "only the leaf may match a leaf pin")
+
+	_, err = newPinnedCertVerifier([]string{"abc"})
+	assert.Error(t, err)
+}
+
+// newTestCert creates a certificate for the given common name which is signed by the given parent, or
+// a self signed CA certificate if parent is nil
+func newTestCert(t *testing.T, commonName string, parent *x509.Certificate, parentKey crypto.Signer) (*x509.Certificate, crypto.Signer) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     []string{commonName},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	if parent == nil {
+		template.KeyUsage |= x509.KeyUsageCertSign
+		template.BasicConstraintsValid = true
+		template.IsCA = true
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return cert, key
+}
+
+func TestNewSaramaConfig_TypedErrors(t *testing.T) {
+	newConfig := func() Config {
+		cfg := Config{Brokers: []string{"localhost:9092"}}
+		cfg.SetDefaults()
+		return cfg
+	}
+
+	cfg := newConfig()
+	cfg.ClusterVersion = "1.0"
+	_, err := NewSaramaConfig(&cfg)
+	var versionErr *ErrKafkaVersion
+	assert.True(t, errors.As(err, &versionErr))
+
+	cfg = newConfig()
+	cfg.TLS.Enabled = true
+	cfg.TLS.CaFilepath = "/does/not/exist.pem"
+	_, err = NewSaramaConfig(&cfg)
+	var tlsErr *ErrTLSConfig
+	assert.True(t, errors.As(err, &tlsErr))
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+}
+
+func TestLoadCACertPool(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kowl-ca")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// Reuse the cert writer, the generated keys are ignored
+	for _, name := range []string{"internal", "partner"} {
+		writeSelfSignedCert(t, CertPair{
+			CertFilepath: filepath.Join(dir, name+".crt"),
+			KeyFilepath:  filepath.Join(dir, name+".key"),
+		}, name)
+	}
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "README.txt"), []byte("not a cert"), 0600))
+
+	_, count, err := loadCACertPool(&TLSConfig{CaDir: dir})
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	_, count, err = loadCACertPool(&TLSConfig{
+		CaFilepath:  filepath.Join(dir, "internal.crt"),
+		CaFilepaths: []string{filepath.Join(dir, "partner.crt")},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	_, _, err = loadCACertPool(&TLSConfig{CaFilepaths: []string{filepath.Join(dir, "README.txt")}})
+	assert.Error(t, err)
+}
+
+func TestNewSaramaConfig_SASLMechanisms(t *testing.T) {
+	newConfig := func(mechanism string) Config {
+		cfg := Config{Brokers: []string{"localhost:9092"}}
+		cfg.SetDefaults()
+		cfg.SASL.Enabled = true
+		cfg.SASL.Mechanism = mechanism
+		cfg.SASL.Username = "kowl"
+		cfg.SASL.Password = "secret"
+		cfg.SASL.GSSAPIConfig = SASLGSSAPIConfig{
+			AuthType:           GSSAPIAuthTypeUser,
+			KerberosConfigPath: "/etc/krb5.conf",
+			ServiceName:        "kafka",
+			Password:           "secret",
+			Realm:              "EXAMPLE.COM",
+		}
+		cfg.SASL.OAuth = SASLOAuthBearerConfig{TokenEndpoint: "https://idp.example.com/token", ClientID: "kowl"}
+		cfg.SASL.AWSMskIam.Region = "eu-central-1"
+		return cfg
+	}
+
+	tt := []struct {
+		mechanism string
+		expected  sarama.SASLMechanism
+	}{
+		{sarama.SASLTypePlaintext, sarama.SASLTypePlaintext},
+		{sarama.SASLTypeSCRAMSHA256, sarama.SASLTypeSCRAMSHA256},
+		{sarama.SASLTypeSCRAMSHA512, sarama.SASLTypeSCRAMSHA512},
+		{sarama.SASLTypeGSSAPI, sarama.SASLTypeGSSAPI},
+		{sarama.SASLTypeOAuth, sarama.SASLTypeOAuth},
+		{SASLMechanismAWSMSKIAM, sarama.SASLTypeOAuth},
+	}
+	for _, table := range tt {
+		cfg := newConfig(table.mechanism)
+		sConfig, err := NewSaramaConfig(&cfg)
+		require.NoError(t, err, table.mechanism)
+		assert.Equal(t, table.expected, sConfig.Net.SASL.Mechanism, table.mechanism)
+	}
+
+	cfg := newConfig("SCRAM-SHA-1")
+	_, err := NewSaramaConfig(&cfg)
+	var saslErr *ErrSASLConfig
+	require.True(t, errors.As(err, &saslErr))
+	assert.Equal(t, `unsupported SASL mechanism "SCRAM-SHA-1"`, err.Error())
+}
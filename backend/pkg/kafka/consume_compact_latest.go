@@ -0,0 +1,181 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"go.uber.org/zap"
+)
+
+// LatestKeyMessage is the latest message of a key within the consumed window. Tombstones (messages with a null value)
+// are kept, because they mark the key as deleted once the topic has been compacted.
+type LatestKeyMessage struct {
+	*TopicMessage
+
+	// SupersededCount is the number of older messages with the same key within the window
+	SupersededCount int64 `json:"supersededCount"`
+}
+
+// CompactLatestMessages are the latest messages per key within the newest messages of a topic
+type CompactLatestMessages struct {
+	// Messages contains the latest message per key, sorted by timestamp (oldest first)
+	Messages []*LatestKeyMessage `json:"messages"`
+
+	ConsumedCount int64 `json:"consumedCount"`
+	ConsumedBytes int64 `json:"consumedBytes"`
+
+	// NullKeyCount is the number of messages with a null key, which are skipped because they can't be compacted
+	NullKeyCount int64 `json:"nullKeyCount"`
+
+	// IsTruncated is true if more than the configured max distinct keys have been consumed. Messages with keys which
+	// have been consumed after the limit has been reached are skipped.
+	IsTruncated bool `json:"isTruncated"`
+}
+
+// compactLatestCollector keeps the latest message per raw key, up to maxKeys distinct keys
+type compactLatestCollector struct {
+	mutex   sync.Mutex
+	maxKeys int
+	byKey   map[string]*compactLatestEntry
+
+	consumedCount int64
+	consumedBytes int64
+	nullKeyCount  int64
+	isTruncated   bool
+}
+
+type compactLatestEntry struct {
+	latest          timestampedMessage
+	supersededCount int64
+}
+
+func newCompactLatestCollector(maxKeys int) *compactLatestCollector {
+	return &compactLatestCollector{maxKeys: maxKeys, byKey: make(map[string]*compactLatestEntry)}
+}
+
+// add adds a consumed message along with its raw key, which is nil for null keys. Keys are compared by their raw bytes
+// like the log cleaner does, because different keys may have the same deserialized representation (e.g. if they can't
+// be decoded). Messages of the same partition must be added in offset order.
+func (c *compactLatestCollector) add(m timestampedMessage, rawKey []byte) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.consumedCount++
+	c.consumedBytes += int64(m.size)
+	if rawKey == nil {
+		c.nullKeyCount++
+		return
+	}
+
+	key := string(rawKey)
+	entry, exists := c.byKey[key]
+	if !exists {
+		if len(c.byKey) >= c.maxKeys {
+			c.isTruncated = true
+			return
+		}
+		c.byKey[key] = &compactLatestEntry{latest: m}
+		return
+	}
+
+	// Keys are usually written to a single partition, otherwise the timestamp decides which message is the latest
+	entry.supersededCount++
+	if entry.latest.isOlderThan(m) {
+		entry.latest = m
+	}
+}
+
+// result returns the collected messages sorted by timestamp
+func (c *compactLatestCollector) result() *CompactLatestMessages {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	entries := make([]*compactLatestEntry, 0, len(c.byKey))
+	for _, entry := range c.byKey {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].latest.isOlderThan(entries[j].latest)
+	})
+
+	messages := make([]*LatestKeyMessage, len(entries))
+	for i, entry := range entries {
+		messages[i] = &LatestKeyMessage{
+			TopicMessage:    entry.latest.message,
+			SupersededCount: entry.supersededCount,
+		}
+	}
+
+	return &CompactLatestMessages{
+		Messages:      messages,
+		ConsumedCount: c.consumedCount,
+		ConsumedBytes: c.consumedBytes,
+		NullKeyCount:  c.nullKeyCount,
+		IsTruncated:   c.isTruncated,
+	}
+}
+
+// ConsumeCompactLatest consumes the newest window messages of each partition and returns the latest message per key,
+// as a compacted topic would retain them. Keys are compared by their raw bytes. The messages are aggregated while they
+// are consumed, so that the memory is bounded by the configured max distinct keys rather than the window size. An
+// empty list of partitionIDs selects all partitions of the topic.
+func (s *Service) ConsumeCompactLatest(ctx context.Context, topicName string, partitionIDs []int32, window int64) (res *CompactLatestMessages, err error) {
+	defer s.observeOperation(ctx, operationTypeConsume, "consume_compact_latest", time.Now(), &err)
+	ctx, done, err := s.TrackOperation(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	if window <= 0 {
+		return nil, fmt.Errorf("window must be greater than 0")
+	}
+
+	partitionIDs, err = s.SelectPartitions(topicName, partitionIDs)
+	if err != nil {
+		return nil, err
+	}
+	marks, err := s.WaterMarks(ctx, topicName, partitionIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get watermarks: %w", err)
+	}
+
+	client, release, err := s.newFetchClient(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create consumer client: %w", err)
+	}
+	defer release()
+
+	collector := newCompactLatestCollector(s.Config.Consumer.CompactLatestMaxKeys)
+	wg := sync.WaitGroup{}
+	for _, mark := range marks {
+		startOffset, endOffset, hasMessages := newestOffsetRange(mark, window)
+		if !hasMessages {
+			continue
+		}
+
+		wg.Add(1)
+		go func(partitionID int32, startOffset, endOffset int64) {
+			defer wg.Done()
+			err := s.consumeRawOffsetRange(ctx, client, topicName, partitionID, startOffset, endOffset, func(m *sarama.ConsumerMessage) {
+				collector.add(s.newTimestampedMessage(m), m.Key)
+			})
+			if err != nil {
+				s.Logger.Warn("failed to consume messages of partition for the latest values per key",
+					zap.String("topic", topicName), zap.Int32("partition_id", partitionID), zap.Error(err))
+			}
+		}(mark.PartitionID, startOffset, endOffset)
+	}
+	wg.Wait()
+
+	res = collector.result()
+	if res.IsTruncated {
+		s.Logger.Warn("latest values per key have been truncated, because the max distinct keys have been reached",
+			zap.String("topic", topicName), zap.Int("max_keys", s.Config.Consumer.CompactLatestMaxKeys))
+	}
+
+	return res, nil
+}
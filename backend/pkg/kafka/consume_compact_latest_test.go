@@ -0,0 +1,68 @@
+package kafka
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompactLatestCollector(t *testing.T) {
+	now := time.Now()
+	msg := func(partitionID int32, offset int64, key []byte, isTombstone bool) (timestampedMessage, []byte) {
+		keyPayload := &deserializedPayload{NormalizedPayload: key, RecognizedEncoding: messageEncodingText}
+		return timestampedMessage{
+			message: &TopicMessage{PartitionID: partitionID, Offset: offset, Key: keyPayload, IsKeyNull: key == nil,
+				IsTombstone: isTombstone},
+			timestamp: now.Add(time.Duration(offset) * time.Second),
+		}, key
+	}
+
+	collector := newCompactLatestCollector(2)
+	collector.add(msg(0, 1, []byte("a"), false))
+	collector.add(msg(0, 2, []byte("b"), false))
+	collector.add(msg(0, 3, nil, false))
+	collector.add(msg(0, 4, []byte("a"), false))
+	collector.add(msg(0, 5, []byte("c"), false))
+	collector.add(msg(0, 6, []byte("b"), true))
+	// Older message of the same key in another partition must not replace the latest one
+	collector.add(msg(1, 0, []byte("a"), false))
+
+	res := collector.result()
+	assert.Equal(t, int64(7), res.ConsumedCount)
+	assert.Equal(t, int64(1), res.NullKeyCount)
+	assert.True(t, res.IsTruncated)
+
+	require.Len(t, res.Messages, 2)
+	assert.Equal(t, "a", res.Messages[0].Key.String())
+	assert.Equal(t, int64(4), res.Messages[0].Offset)
+	assert.Equal(t, int64(2), res.Messages[0].SupersededCount)
+	assert.False(t, res.Messages[0].IsTombstone)
+
+	assert.Equal(t, "b", res.Messages[1].Key.String())
+	assert.Equal(t, int64(6), res.Messages[1].Offset)
+	assert.Equal(t, int64(1), res.Messages[1].SupersededCount)
+	assert.True(t, res.Messages[1].IsTombstone)
+}
+
+func TestCompactLatestCollector_RawKeys(t *testing.T) {
+	now := time.Now()
+	undecodable := func(offset int64) timestampedMessage {
+		keyPayload := &deserializedPayload{NormalizedPayload: []byte(`"<binary>"`), RecognizedEncoding: messageEncodingBinary}
+		return timestampedMessage{message: &TopicMessage{Offset: offset, Key: keyPayload}, timestamp: now}
+	}
+
+	// Keys with the same deserialized representation are distinct keys, empty keys are not null keys
+	collector := newCompactLatestCollector(10)
+	collector.add(undecodable(0), []byte{0x00, 0x01})
+	collector.add(undecodable(1), []byte{0x00, 0x02})
+	collector.add(undecodable(2), []byte{})
+
+	res := collector.result()
+	assert.Equal(t, int64(0), res.NullKeyCount)
+	require.Len(t, res.Messages, 3)
+	for _, m := range res.Messages {
+		assert.Equal(t, int64(0), m.SupersededCount)
+	}
+}
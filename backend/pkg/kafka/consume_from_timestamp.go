@@ -0,0 +1,175 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"go.uber.org/zap"
+)
+
+// ConsumeFromTimestampResponse contains the messages starting at a given timestamp along with the resolved start
+// offset of each partition. Partitions without messages at or after the timestamp are not contained. ConsumedBytes
+// includes the messages which have been dropped while merging the partitions. PartitionErrors contains the error of
+// each partition which could not be consumed completely.
+type ConsumeFromTimestampResponse struct {
+	ResolvedOffsets map[int32]int64
+	Messages        []*TopicMessage
+	ConsumedBytes   int64
+	PartitionErrors map[int32]error
+}
+
+// OffsetsForTimestamp resolves the earliest offset of each partition whose timestamp is greater than or equal to the
+// given timestamp. Partitions which do not have a message at or after the timestamp are omitted from the result.
+func (s *Service) OffsetsForTimestamp(ctx context.Context, topicName string, partitionIDs []int32, timestamp time.Time) (offsets map[int32]int64, err error) {
+	defer s.observeOperation(ctx, operationTypeAdmin, "offsets_for_timestamp", time.Now(), &err)
+	// 1. Bucket all partitions by their leader broker. Version 1 of the offset request is required for timestamps.
+	brokers := make(map[int32]*sarama.Broker)
+	reqs := make(map[int32]*sarama.OffsetRequest)
+	timestampMs := timestamp.UnixNano() / int64(time.Millisecond)
+	for _, partitionID := range partitionIDs {
+		broker, err := s.Client.Leader(topicName, partitionID)
+		if err != nil {
+			return nil, err
+		}
+		id := broker.ID()
+		brokers[id] = broker
+
+		if _, ok := reqs[id]; !ok {
+			reqs[id] = &sarama.OffsetRequest{Version: 1}
+		}
+		reqs[id].AddBlock(topicName, partitionID, timestampMs, 1)
+	}
+
+	// 2. Fetch offsets from all brokers in parallel
+	type response struct {
+		Error   error
+		Offsets *sarama.OffsetResponse
+	}
+	ch := make(chan response, len(reqs))
+	for brokerID, req := range reqs {
+		go func(b *sarama.Broker, req *sarama.OffsetRequest) {
+			res, err := b.GetAvailableOffsets(req)
+			ch <- response{Error: err, Offsets: res}
+		}(brokers[brokerID], req)
+	}
+
+	offsets = make(map[int32]int64, len(partitionIDs))
+	for i := 0; i < cap(ch); i++ {
+		var r response
+		select {
+		case r = <-ch:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if r.Error != nil {
+			return nil, r.Error
+		}
+
+		for partitionID, block := range r.Offsets.Blocks[topicName] {
+			if block.Err != sarama.ErrNoError {
+				return nil, fmt.Errorf("failed to resolve offset for partition %v: %w", partitionID, block.Err)
+			}
+			// The broker returns -1 if there is no message at or after the timestamp
+			if block.Offset < 0 {
+				continue
+			}
+			offsets[partitionID] = block.Offset
+		}
+	}
+
+	return offsets, nil
+}
+
+// ConsumeFromTimestamp returns up to count messages in total, which have been produced at or after the given
+// timestamp. The messages of all partitions are sorted by timestamp (oldest first). An error is returned if the
+// consumption of every partition has failed. An empty list of partitionIDs selects all partitions of the topic.
+func (s *Service) ConsumeFromTimestamp(ctx context.Context, topicName string, partitionIDs []int32, timestamp time.Time, count int64) (response *ConsumeFromTimestampResponse, err error) {
+	defer s.observeOperation(ctx, operationTypeConsume, "consume_from_timestamp", time.Now(), &err)
+	ctx, done, err := s.TrackOperation(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	if count <= 0 {
+		return nil, fmt.Errorf("count must be greater than 0")
+	}
+
+	partitionIDs, err = s.SelectPartitions(topicName, partitionIDs)
+	if err != nil {
+		return nil, err
+	}
+	startOffsets, err := s.OffsetsForTimestamp(ctx, topicName, partitionIDs, timestamp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve offsets for timestamp: %w", err)
+	}
+	marks, err := s.WaterMarks(ctx, topicName, partitionIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get watermarks: %w", err)
+	}
+
+	// The consumer's client has been created with the config from NewConsumerConfig
+	client, release, err := s.newFetchClient(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create consumer client: %w", err)
+	}
+	defer release()
+
+	var mutex sync.Mutex
+	messages := make([]timestampedMessage, 0)
+	partitionErrors := make(map[int32]error)
+	consumedPartitions := 0
+	wg := sync.WaitGroup{}
+	for partitionID, startOffset := range startOffsets {
+		mark, exists := marks[partitionID]
+		if !exists || startOffset >= mark.High {
+			continue
+		}
+		endOffset := startOffset + count - 1
+		if endOffset > mark.High-1 {
+			endOffset = mark.High - 1
+		}
+
+		consumedPartitions++
+		wg.Add(1)
+		go func(partitionID int32, startOffset, endOffset int64) {
+			defer wg.Done()
+			consumed, err := s.consumeOffsetRange(ctx, client, topicName, partitionID, startOffset, endOffset)
+			if err != nil {
+				s.Logger.Warn("failed to consume messages from timestamp", zap.String("topic", topicName),
+					zap.Int32("partition_id", partitionID), zap.Error(err))
+			}
+			mutex.Lock()
+			messages = append(messages, consumed...)
+			if err != nil {
+				partitionErrors[partitionID] = err
+			}
+			mutex.Unlock()
+		}(partitionID, startOffset, endOffset)
+	}
+	wg.Wait()
+
+	err = allPartitionsFailedError(ctx, partitionErrors, consumedPartitions)
+	if err != nil {
+		return nil, err
+	}
+
+	bytes := consumedBytes(messages)
+	sortByTimestamp(messages)
+	if int64(len(messages)) > count {
+		messages = messages[:count]
+	}
+	res := make([]*TopicMessage, len(messages))
+	for i, msg := range messages {
+		res[i] = msg.message
+	}
+
+	return &ConsumeFromTimestampResponse{
+		ResolvedOffsets: startOffsets,
+		Messages:        res,
+		ConsumedBytes:   bytes,
+		PartitionErrors: partitionErrors,
+	}, nil
+}
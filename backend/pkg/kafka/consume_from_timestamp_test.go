@@ -0,0 +1,33 @@
+package kafka
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOffsetsForTimestamp(t *testing.T) {
+	timestamp := time.Date(2020, 11, 20, 14, 32, 0, 0, time.UTC)
+	timestampMs := timestamp.UnixNano() / int64(time.Millisecond)
+
+	svc, _ := newTestService(t, sarama.V1_0_0_0, func(broker *sarama.MockBroker) map[string]sarama.MockResponse {
+		return map[string]sarama.MockResponse{
+			"MetadataRequest": sarama.NewMockMetadataResponse(t).
+				SetBroker(broker.Addr(), broker.BrokerID()).
+				SetLeader("orders", 0, broker.BrokerID()).
+				SetLeader("orders", 1, broker.BrokerID()),
+			"OffsetRequest": sarama.NewMockOffsetResponse(t).
+				SetVersion(1).
+				SetOffset("orders", 0, timestampMs, 4711).
+				SetOffset("orders", 1, timestampMs, -1), // No message at or after the timestamp
+		}
+	})
+
+	offsets, err := svc.OffsetsForTimestamp(context.Background(), "orders", []int32{0, 1}, timestamp)
+	require.NoError(t, err)
+	assert.Equal(t, map[int32]int64{0: 4711}, offsets)
+}
@@ -0,0 +1,217 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"go.uber.org/zap"
+)
+
+// timestampedMessage is a consumed message along with it's exact timestamp, which is used to merge the messages of
+// all partitions. Size is the number of bytes of the key and value as they have been consumed.
+type timestampedMessage struct {
+	message   *TopicMessage
+	timestamp time.Time
+	size      int
+}
+
+// ConsumeNewestMessagesResponse contains the newest messages of a topic along with the number of bytes which have been
+// consumed for them, which includes the messages which have been dropped while merging the partitions.
+// PartitionErrors contains the error of each partition which could not be consumed completely, the messages which
+// have been consumed before the error are returned nevertheless.
+type ConsumeNewestMessagesResponse struct {
+	Messages        []*TopicMessage
+	ConsumedBytes   int64
+	PartitionErrors map[int32]error
+}
+
+// ConsumeNewestMessages returns the newest count messages of the given topic, sorted by timestamp (oldest first). For
+// each partition up to count messages before the high watermark are consumed, afterwards the messages of all
+// partitions are merged and only the newest count messages are kept. If the context is done before all partitions have
+// been consumed, the messages which have been consumed so far are returned. An error is returned if the consumption of
+// every partition has failed. An empty list of partitionIDs selects all partitions of the topic.
+func (s *Service) ConsumeNewestMessages(ctx context.Context, topicName string, partitionIDs []int32, count int64) (res *ConsumeNewestMessagesResponse, err error) {
+	defer s.observeOperation(ctx, operationTypeConsume, "consume_newest_messages", time.Now(), &err)
+	ctx, done, err := s.TrackOperation(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	if count <= 0 {
+		return nil, fmt.Errorf("count must be greater than 0")
+	}
+
+	partitionIDs, err = s.SelectPartitions(topicName, partitionIDs)
+	if err != nil {
+		return nil, err
+	}
+	marks, err := s.WaterMarks(ctx, topicName, partitionIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get watermarks: %w", err)
+	}
+
+	client, release, err := s.newFetchClient(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create consumer client: %w", err)
+	}
+	defer release()
+
+	var mutex sync.Mutex
+	messages := make([]timestampedMessage, 0)
+	partitionErrors := make(map[int32]error)
+	consumedPartitions := 0
+	wg := sync.WaitGroup{}
+	for _, mark := range marks {
+		startOffset, endOffset, hasMessages := newestOffsetRange(mark, count)
+		if !hasMessages {
+			continue
+		}
+
+		consumedPartitions++
+		wg.Add(1)
+		go func(partitionID int32, startOffset, endOffset int64) {
+			defer wg.Done()
+			consumed, err := s.consumeOffsetRange(ctx, client, topicName, partitionID, startOffset, endOffset)
+			if err != nil {
+				s.Logger.Warn("failed to consume newest messages of partition", zap.String("topic", topicName),
+					zap.Int32("partition_id", partitionID), zap.Error(err))
+			}
+			mutex.Lock()
+			messages = append(messages, consumed...)
+			if err != nil {
+				partitionErrors[partitionID] = err
+			}
+			mutex.Unlock()
+		}(mark.PartitionID, startOffset, endOffset)
+	}
+	wg.Wait()
+
+	err = allPartitionsFailedError(ctx, partitionErrors, consumedPartitions)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ConsumeNewestMessagesResponse{
+		Messages:        mergeNewestMessages(messages, count),
+		ConsumedBytes:   consumedBytes(messages),
+		PartitionErrors: partitionErrors,
+	}, nil
+}
+
+// allPartitionsFailedError returns an error if the consumption of every consumed partition has failed, so that a
+// broken cluster is not mistaken for an empty topic. If the context is done, the partial result is still returned.
+func allPartitionsFailedError(ctx context.Context, partitionErrors map[int32]error, consumedPartitions int) error {
+	if consumedPartitions == 0 || len(partitionErrors) < consumedPartitions || ctx.Err() != nil {
+		return nil
+	}
+
+	// The error of the lowest partition is returned, so that the error is deterministic
+	firstPartitionID := int32(-1)
+	for partitionID := range partitionErrors {
+		if firstPartitionID == -1 || partitionID < firstPartitionID {
+			firstPartitionID = partitionID
+		}
+	}
+	return fmt.Errorf("failed to consume all %v partitions, partition %v: %w", consumedPartitions, firstPartitionID,
+		partitionErrors[firstPartitionID])
+}
+
+// consumedBytes returns the sum of the sizes of the consumed messages
+func consumedBytes(messages []timestampedMessage) int64 {
+	var bytes int64
+	for _, m := range messages {
+		bytes += int64(m.size)
+	}
+	return bytes
+}
+
+// newestOffsetRange returns the offset range which contains the newest count messages of a partition. The start offset
+// is clamped at the low watermark. The third return value is false if the partition is empty.
+func newestOffsetRange(mark *WaterMark, count int64) (int64, int64, bool) {
+	if mark.High <= mark.Low {
+		return 0, 0, false
+	}
+
+	startOffset := mark.High - count
+	if startOffset < mark.Low {
+		startOffset = mark.Low
+	}
+
+	// mark.High - 1 is the last message which can actually be consumed
+	return startOffset, mark.High - 1, true
+}
+
+// mergeNewestMessages sorts the messages of all partitions by timestamp and returns the newest count messages
+func mergeNewestMessages(messages []timestampedMessage, count int64) []*TopicMessage {
+	sortByTimestamp(messages)
+	if int64(len(messages)) > count {
+		messages = messages[int64(len(messages))-count:]
+	}
+
+	res := make([]*TopicMessage, len(messages))
+	for i, msg := range messages {
+		res[i] = msg.message
+	}
+
+	return res
+}
+
+// sortByTimestamp sorts the messages of all partitions by timestamp (oldest first). Messages with the same timestamp
+// are sorted by partition and offset.
+func sortByTimestamp(messages []timestampedMessage) {
+	sort.SliceStable(messages, func(i, j int) bool {
+		return messages[i].isOlderThan(messages[j])
+	})
+}
+
+// isOlderThan orders messages by timestamp, messages with the same timestamp are ordered by partition and offset
+func (m timestampedMessage) isOlderThan(other timestampedMessage) bool {
+	if m.timestamp.Equal(other.timestamp) {
+		if m.message.PartitionID == other.message.PartitionID {
+			return m.message.Offset < other.message.Offset
+		}
+		return m.message.PartitionID < other.message.PartitionID
+	}
+	return m.timestamp.Before(other.timestamp)
+}
+
+// consumeOffsetRange consumes all messages from startOffset up to and including endOffset. In compacted partitions
+// or partitions with transaction markers the end offset may not exist, hence it also stops once the partition's
+// high watermark has been reached.
+func (s *Service) consumeOffsetRange(ctx context.Context, client sarama.Client, topicName string, partitionID int32, startOffset, endOffset int64) ([]timestampedMessage, error) {
+	messages := make([]timestampedMessage, 0, endOffset-startOffset+1)
+	err := s.consumeOffsetRangeFunc(ctx, client, topicName, partitionID, startOffset, endOffset, func(m timestampedMessage) {
+		messages = append(messages, m)
+	})
+
+	return messages, err
+}
+
+// consumeOffsetRangeFunc works like consumeOffsetRange, but it passes each message to onMessage instead of collecting
+// them, so that callers can aggregate large offset ranges without holding all messages in memory
+func (s *Service) consumeOffsetRangeFunc(ctx context.Context, client sarama.Client, topicName string, partitionID int32, startOffset, endOffset int64, onMessage func(timestampedMessage)) error {
+	return s.consumeRawOffsetRange(ctx, client, topicName, partitionID, startOffset, endOffset, func(m *sarama.ConsumerMessage) {
+		onMessage(s.newTimestampedMessage(m))
+	})
+}
+
+// newTimestampedMessage deserializes the consumed message including its headers
+func (s *Service) newTimestampedMessage(m *sarama.ConsumerMessage) timestampedMessage {
+	topicMessage, _ := newTopicMessage(m, &s.Deserializer, true)
+	return timestampedMessage{message: topicMessage, timestamp: m.Timestamp, size: len(m.Key) + len(m.Value)}
+}
+
+// consumeRawOffsetRange passes the consumed messages to onMessage without deserializing them. The records are fetched
+// directly (see fetchOffsetRange), because a consumer does not tell whether the remaining offsets before the high
+// watermark are transaction markers, which would never be delivered.
+func (s *Service) consumeRawOffsetRange(ctx context.Context, client sarama.Client, topicName string, partitionID int32, startOffset, endOffset int64, onMessage func(*sarama.ConsumerMessage)) error {
+	r := OffsetRange{PartitionID: partitionID, StartOffset: startOffset, EndOffset: endOffset + 1}
+	return s.fetchOffsetRange(ctx, client, topicName, r, false, func(record fetchedRecord) error {
+		onMessage(record.Message)
+		return nil
+	})
+}
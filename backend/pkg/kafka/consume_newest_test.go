@@ -0,0 +1,97 @@
+package kafka
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewestOffsetRange(t *testing.T) {
+	tt := []struct {
+		name        string
+		mark        WaterMark
+		count       int64
+		start       int64
+		end         int64
+		hasMessages bool
+	}{
+		{"enough messages", WaterMark{Low: 0, High: 1000}, 50, 950, 999, true},
+		{"count exceeds available messages", WaterMark{Low: 0, High: 10}, 50, 0, 9, true},
+		{"clamped at log start offset", WaterMark{Low: 980, High: 1000}, 50, 980, 999, true},
+		{"empty partition", WaterMark{Low: 500, High: 500}, 50, 0, 0, false},
+	}
+	for _, table := range tt {
+		start, end, hasMessages := newestOffsetRange(&table.mark, table.count)
+		assert.Equal(t, table.hasMessages, hasMessages, table.name)
+		assert.Equal(t, table.start, start, table.name)
+		assert.Equal(t, table.end, end, table.name)
+	}
+}
+
+func TestMergeNewestMessages(t *testing.T) {
+	now := time.Now()
+	msg := func(partitionID int32, offset int64, ageSeconds int) timestampedMessage {
+		return timestampedMessage{
+			message:   &TopicMessage{PartitionID: partitionID, Offset: offset},
+			timestamp: now.Add(-time.Duration(ageSeconds) * time.Second),
+		}
+	}
+
+	messages := []timestampedMessage{
+		msg(0, 10, 30), msg(0, 11, 10),
+		msg(1, 5, 20), msg(1, 6, 0),
+		msg(2, 7, 40),
+	}
+	merged := mergeNewestMessages(messages, 3)
+
+	assert.Len(t, merged, 3)
+	assert.Equal(t, int32(1), merged[0].PartitionID)
+	assert.Equal(t, int64(5), merged[0].Offset)
+	assert.Equal(t, int64(11), merged[1].Offset)
+	assert.Equal(t, int64(6), merged[2].Offset)
+}
+
+func TestConsumeNewestMessages_PartitionErrors(t *testing.T) {
+	res := &sarama.FetchResponse{Version: 4}
+	res.AddRecordBatch("orders", 0, nil, sarama.StringEncoder("a"), 0, -1, false)
+	res.AddRecordBatch("orders", 0, nil, sarama.StringEncoder("b"), 1, -1, false)
+	res.GetBlock("orders", 0).HighWaterMarkOffset = 2
+	res.AddError("orders", 1, sarama.ErrOffsetOutOfRange)
+	failed := &sarama.FetchResponse{Version: 4}
+	failed.AddError("orders", 0, sarama.ErrOffsetOutOfRange)
+	failed.AddError("orders", 1, sarama.ErrOffsetOutOfRange)
+
+	var handlers map[string]sarama.MockResponse
+	svc, broker := newTestService(t, sarama.V1_0_0_0, func(broker *sarama.MockBroker) map[string]sarama.MockResponse {
+		handlers = map[string]sarama.MockResponse{
+			"MetadataRequest": sarama.NewMockMetadataResponse(t).
+				SetBroker(broker.Addr(), broker.BrokerID()).
+				SetLeader("orders", 0, broker.BrokerID()).
+				SetLeader("orders", 1, broker.BrokerID()),
+			"OffsetRequest": sarama.NewMockOffsetResponse(t).
+				SetOffset("orders", 0, sarama.OffsetOldest, 0).
+				SetOffset("orders", 0, sarama.OffsetNewest, 2).
+				SetOffset("orders", 1, sarama.OffsetOldest, 0).
+				SetOffset("orders", 1, sarama.OffsetNewest, 2),
+			"FetchRequest": sarama.NewMockWrapper(res),
+		}
+		return handlers
+	})
+
+	// The error of the failed partition is returned along with the messages of the other partition
+	consumed, err := svc.ConsumeNewestMessages(context.Background(), "orders", nil, 10)
+	require.NoError(t, err)
+	assert.Len(t, consumed.Messages, 2)
+	require.Len(t, consumed.PartitionErrors, 1)
+	assert.ErrorIs(t, consumed.PartitionErrors[1], sarama.ErrOffsetOutOfRange)
+
+	// If every partition fails, the consumption fails as well
+	handlers["FetchRequest"] = sarama.NewMockWrapper(failed)
+	broker.SetHandlerByMap(handlers)
+	_, err = svc.ConsumeNewestMessages(context.Background(), "orders", nil, 10)
+	assert.ErrorIs(t, err, sarama.ErrOffsetOutOfRange)
+}
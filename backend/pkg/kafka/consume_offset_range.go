@@ -0,0 +1,153 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// OffsetRange is the range of offsets from StartOffset (inclusive) to EndOffset (exclusive) within a partition
+type OffsetRange struct {
+	PartitionID int32
+	StartOffset int64
+	EndOffset   int64
+}
+
+// ConsumedOffsetRange is the range which has actually been consumed for a requested offset range. If the requested
+// end offset exceeds the high watermark, the end offset is clamped to the high watermark and IsClamped is set. Err is
+// set if the range could not be consumed completely, in which case only the messages before the error are returned.
+type ConsumedOffsetRange struct {
+	OffsetRange
+	LowWaterMark  int64
+	HighWaterMark int64
+	IsClamped     bool
+	Err           error
+}
+
+// ConsumeOffsetRangesResponse contains the messages of the requested offset ranges, sorted by partition and offset
+type ConsumeOffsetRangesResponse struct {
+	Ranges        []ConsumedOffsetRange
+	Messages      []*TopicMessage
+	ConsumedBytes int64
+}
+
+// ConsumeOffsetRanges returns all messages within the given offset ranges. A start offset below the low watermark or
+// at or beyond the high watermark returns ErrInvalidOffset, since these messages have been deleted or do not exist
+// yet. End offsets beyond the high watermark are clamped. If a range fails to be consumed (e.g. because the context
+// is done), the messages which have been consumed so far are returned and the error is set on the range.
+//
+// The records are fetched directly rather than with a consumer, so that the attributes of their record batches are
+// known (see TopicMessage.Batch). Control records (transaction markers) are only returned if includeControlRecords
+// is set.
+func (s *Service) ConsumeOffsetRanges(ctx context.Context, topicName string, ranges []OffsetRange, includeControlRecords bool) (response *ConsumeOffsetRangesResponse, err error) {
+	defer s.observeOperation(ctx, operationTypeConsume, "consume_offset_ranges", time.Now(), &err)
+	ctx, done, err := s.TrackOperation(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	partitionIDs := make([]int32, len(ranges))
+	for i, offsetRange := range ranges {
+		if offsetRange.StartOffset < 0 || offsetRange.EndOffset < offsetRange.StartOffset {
+			return nil, fmt.Errorf("%w: the range %v to %v of partition %v is invalid", ErrInvalidOffset,
+				offsetRange.StartOffset, offsetRange.EndOffset, offsetRange.PartitionID)
+		}
+		partitionIDs[i] = offsetRange.PartitionID
+	}
+	partitionIDs, err = s.SelectPartitions(topicName, partitionIDs)
+	if err != nil {
+		return nil, err
+	}
+	if len(partitionIDs) != len(ranges) {
+		return nil, fmt.Errorf("%w: each partition may only have a single range", ErrInvalidOffset)
+	}
+	marks, err := s.WaterMarks(ctx, topicName, partitionIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get watermarks: %w", err)
+	}
+
+	consumedRanges := make([]ConsumedOffsetRange, len(ranges))
+	for i, offsetRange := range ranges {
+		consumedRanges[i], err = clampOffsetRange(offsetRange, marks[offsetRange.PartitionID])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	client, release, err := s.newFetchClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	var mutex sync.Mutex
+	messages := make([]*TopicMessage, 0)
+	var bytes int64
+	wg := sync.WaitGroup{}
+	for i, consumedRange := range consumedRanges {
+		if consumedRange.StartOffset >= consumedRange.EndOffset {
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, r OffsetRange) {
+			defer wg.Done()
+			consumed := make([]*TopicMessage, 0, r.EndOffset-r.StartOffset)
+			var consumedBytes int64
+			err := s.fetchOffsetRange(ctx, client, topicName, r, includeControlRecords, func(record fetchedRecord) error {
+				consumedBytes += int64(len(record.Message.Key) + len(record.Message.Value))
+				topicMessage, _ := newTopicMessage(record.Message, &s.Deserializer, true)
+				topicMessage.Batch = &record.Batch
+				topicMessage.ControlRecordType = record.ControlRecordType
+				consumed = append(consumed, topicMessage)
+				return nil
+			})
+			mutex.Lock()
+			consumedRanges[i].Err = err
+			messages = append(messages, consumed...)
+			bytes += consumedBytes
+			mutex.Unlock()
+		}(i, consumedRange.OffsetRange)
+	}
+	wg.Wait()
+
+	sort.Slice(messages, func(i, j int) bool {
+		if messages[i].PartitionID == messages[j].PartitionID {
+			return messages[i].Offset < messages[j].Offset
+		}
+		return messages[i].PartitionID < messages[j].PartitionID
+	})
+
+	return &ConsumeOffsetRangesResponse{
+		Ranges:        consumedRanges,
+		Messages:      messages,
+		ConsumedBytes: bytes,
+	}, nil
+}
+
+// clampOffsetRange validates the offset range against the watermarks of its partition and clamps the end offset to
+// the high watermark
+func clampOffsetRange(offsetRange OffsetRange, mark *WaterMark) (ConsumedOffsetRange, error) {
+	if mark == nil {
+		return ConsumedOffsetRange{}, fmt.Errorf("no watermarks have been returned for partition %v", offsetRange.PartitionID)
+	}
+	if offsetRange.StartOffset < mark.Low {
+		return ConsumedOffsetRange{}, fmt.Errorf("%w: start offset %v of partition %v is below the low watermark %v",
+			ErrInvalidOffset, offsetRange.StartOffset, offsetRange.PartitionID, mark.Low)
+	}
+	if offsetRange.StartOffset >= mark.High {
+		return ConsumedOffsetRange{}, fmt.Errorf("%w: start offset %v of partition %v is not below the high watermark %v",
+			ErrInvalidOffset, offsetRange.StartOffset, offsetRange.PartitionID, mark.High)
+	}
+
+	consumed := ConsumedOffsetRange{OffsetRange: offsetRange, LowWaterMark: mark.Low, HighWaterMark: mark.High}
+	if consumed.EndOffset > mark.High {
+		consumed.EndOffset = mark.High
+		consumed.IsClamped = true
+	}
+
+	return consumed, nil
+}
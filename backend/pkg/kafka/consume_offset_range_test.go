@@ -0,0 +1,78 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClampOffsetRange(t *testing.T) {
+	mark := &WaterMark{PartitionID: 2, Low: 100, High: 200}
+	tt := []struct {
+		name      string
+		start     int64
+		end       int64
+		isValid   bool
+		clampedTo int64
+		isClamped bool
+	}{
+		{"within watermarks", 120, 150, true, 150, false},
+		{"end at high watermark", 150, 200, true, 200, false},
+		{"end beyond high watermark", 150, 300, true, 200, true},
+		{"start below low watermark", 50, 150, false, 0, false},
+		{"start at high watermark", 200, 210, false, 0, false},
+	}
+	for _, table := range tt {
+		consumed, err := clampOffsetRange(OffsetRange{PartitionID: 2, StartOffset: table.start, EndOffset: table.end}, mark)
+		if !table.isValid {
+			assert.True(t, errors.Is(err, ErrInvalidOffset), table.name)
+			continue
+		}
+		require.NoError(t, err, table.name)
+		assert.Equal(t, table.start, consumed.StartOffset, table.name)
+		assert.Equal(t, table.clampedTo, consumed.EndOffset, table.name)
+		assert.Equal(t, table.isClamped, consumed.IsClamped, table.name)
+		assert.Equal(t, int64(100), consumed.LowWaterMark, table.name)
+		assert.Equal(t, int64(200), consumed.HighWaterMark, table.name)
+	}
+}
+
+func TestConsumeOffsetRanges_PartitionError(t *testing.T) {
+	res := &sarama.FetchResponse{Version: 4}
+	res.AddRecordBatch("orders", 0, nil, sarama.StringEncoder("a"), 0, -1, false)
+	res.AddRecordBatch("orders", 0, nil, sarama.StringEncoder("b"), 1, -1, false)
+	res.GetBlock("orders", 0).HighWaterMarkOffset = 2
+	res.AddError("orders", 1, sarama.ErrOffsetOutOfRange)
+
+	svc, _ := newTestService(t, sarama.V1_0_0_0, func(broker *sarama.MockBroker) map[string]sarama.MockResponse {
+		return map[string]sarama.MockResponse{
+			"MetadataRequest": sarama.NewMockMetadataResponse(t).
+				SetBroker(broker.Addr(), broker.BrokerID()).
+				SetLeader("orders", 0, broker.BrokerID()).
+				SetLeader("orders", 1, broker.BrokerID()),
+			"OffsetRequest": sarama.NewMockOffsetResponse(t).
+				SetOffset("orders", 0, sarama.OffsetOldest, 0).
+				SetOffset("orders", 0, sarama.OffsetNewest, 2).
+				SetOffset("orders", 1, sarama.OffsetOldest, 0).
+				SetOffset("orders", 1, sarama.OffsetNewest, 2),
+			"FetchRequest": sarama.NewMockWrapper(res),
+		}
+	})
+
+	// The failed range carries its error, the messages of the other range are still returned
+	consumed, err := svc.ConsumeOffsetRanges(context.Background(), "orders", []OffsetRange{
+		{PartitionID: 0, StartOffset: 0, EndOffset: 2},
+		{PartitionID: 1, StartOffset: 0, EndOffset: 2},
+	}, false)
+	require.NoError(t, err)
+	require.Len(t, consumed.Ranges, 2)
+	assert.NoError(t, consumed.Ranges[0].Err)
+	assert.True(t, errors.Is(consumed.Ranges[1].Err, sarama.ErrOffsetOutOfRange))
+	require.Len(t, consumed.Messages, 2)
+	assert.Equal(t, int32(0), consumed.Messages[1].PartitionID)
+	assert.Equal(t, int64(1), consumed.Messages[1].Offset)
+}
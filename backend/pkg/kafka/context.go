@@ -0,0 +1,27 @@
+package kafka
+
+import (
+	"context"
+)
+
+// runWithContext runs a blocking call and returns as soon as the call has finished or the context is done, whichever
+// happens first. Most sarama calls can not be aborted once they have been sent, hence the call keeps running in the
+// background if the context is done, but the caller stops waiting and ctx.Err() is returned. Results must therefore
+// only be assigned to variables which are not read if an error is returned.
+func runWithContext(ctx context.Context, fn func() error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- fn()
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,89 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunWithContext(t *testing.T) {
+	// A context which is already done doesn't run the call at all
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	called := false
+	err := runWithContext(ctx, func() error {
+		called = true
+		return nil
+	})
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.False(t, called)
+
+	// A blocking call is abandoned as soon as the context is done
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	block := make(chan struct{})
+	defer close(block)
+	start := time.Now()
+	err = runWithContext(ctx, func() error {
+		<-block
+		return nil
+	})
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.Less(t, int64(time.Since(start)), int64(time.Second))
+
+	// Errors of the call are returned as is
+	errFailed := errors.New("failed")
+	assert.Equal(t, errFailed, runWithContext(context.Background(), func() error { return errFailed }))
+}
+
+func TestOperationsReturnOnContextCancellation(t *testing.T) {
+	svc, broker := newTestService(t, sarama.V1_0_0_0, func(broker *sarama.MockBroker) map[string]sarama.MockResponse {
+		return map[string]sarama.MockResponse{
+			"MetadataRequest": sarama.NewMockMetadataResponse(t).
+				SetController(broker.BrokerID()).
+				SetBroker(broker.Addr(), broker.BrokerID()).
+				SetLeader("orders", 0, broker.BrokerID()),
+			"ListGroupsRequest": sarama.NewMockListGroupsResponse(t),
+			"OffsetRequest": sarama.NewMockOffsetResponse(t).
+				SetOffset("orders", 0, sarama.OffsetOldest, 0).
+				SetOffset("orders", 0, sarama.OffsetNewest, 10),
+		}
+	})
+	_, err := svc.Client.Controller()
+	require.NoError(t, err)
+
+	// From now on the broker is too slow to respond before the deadline
+	broker.SetLatency(time.Second)
+
+	operations := map[string]func(ctx context.Context) error{
+		"describe cluster": func(ctx context.Context) error {
+			_, err := svc.DescribeCluster(ctx)
+			return err
+		},
+		"list topics": func(ctx context.Context) error {
+			_, err := svc.ListTopics(ctx)
+			return err
+		},
+		"list consumer groups": func(ctx context.Context) error {
+			_, err := svc.ListConsumerGroups(ctx)
+			return err
+		},
+		"water marks": func(ctx context.Context) error {
+			_, err := svc.WaterMarks(ctx, "orders", []int32{0})
+			return err
+		},
+	}
+	for name, operation := range operations {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		start := time.Now()
+		err := operation(ctx)
+		cancel()
+		assert.True(t, errors.Is(err, context.DeadlineExceeded), "%v: %v", name, err)
+		assert.Less(t, int64(time.Since(start)), int64(500*time.Millisecond), name)
+	}
+}
@@ -0,0 +1,161 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Shopify/sarama"
+)
+
+// CopyConsumerGroupOffsetsRequest describes from which group to which group the committed offsets shall be copied. An
+// empty list of topics selects all topics for which the source group has committed offsets.
+type CopyConsumerGroupOffsetsRequest struct {
+	SourceGroupID      string
+	DestinationGroupID string
+	Topics             []string
+
+	// IsTopicAllowed restricts the selected topics further, e.g. to the topics which are served. The offsets of topics
+	// for which it returns false are neither copied nor returned. All topics are allowed if it is nil.
+	IsTopicAllowed func(topicName string) bool
+
+	// OffsetDelta is added to each copied offset, e.g. -100 to let the destination group reprocess the last 100
+	// messages of each partition
+	OffsetDelta int64
+}
+
+// CopiedConsumerGroupOffset is the offset which has been committed for the destination group along with the
+// committed offset of the source group it has been derived from
+type CopiedConsumerGroupOffset struct {
+	TopicName    string
+	PartitionID  int32
+	SourceOffset int64
+	Offset       int64
+}
+
+// CopyConsumerGroupOffsets commits the committed offsets of the source group for the destination group, e.g. to seed
+// a renamed consumer group so that it continues where the old one has stopped. The copied offsets (plus the delta)
+// are clamped into the range of available offsets. It refuses to overwrite the offsets of an active destination group.
+func (s *Service) CopyConsumerGroupOffsets(ctx context.Context, req CopyConsumerGroupOffsetsRequest) (copied []CopiedConsumerGroupOffset, err error) {
+	defer s.observeOperation(ctx, operationTypeAdmin, "copy_consumer_group_offsets", time.Now(), &err)
+	if req.SourceGroupID == req.DestinationGroupID {
+		return nil, fmt.Errorf("source and destination group must be different")
+	}
+
+	isActive, err := s.isConsumerGroupActive(ctx, req.DestinationGroupID)
+	if err != nil {
+		return nil, err
+	}
+	if isActive {
+		return nil, ErrConsumerGroupActive
+	}
+
+	committed, err := s.ListConsumerGroupOffsets(ctx, req.SourceGroupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list committed offsets of the source group: %w", err)
+	}
+	if kErr := committed.Err; kErr != sarama.ErrNoError {
+		return nil, fmt.Errorf("failed to list committed offsets of the source group: %w", kErr)
+	}
+	sourceOffsets := committedOffsetsByTopic(committed, req.Topics, req.IsTopicAllowed)
+	if len(sourceOffsets) == 0 {
+		return nil, ErrNoCommittedOffsets
+	}
+
+	coordinator, err := s.Client.Coordinator(req.DestinationGroupID)
+	if err != nil {
+		return nil, err
+	}
+	commitReq := &sarama.OffsetCommitRequest{
+		Version:                 2,
+		ConsumerGroup:           req.DestinationGroupID,
+		ConsumerGroupGeneration: sarama.GroupGenerationUndefined,
+		RetentionTime:           -1,
+	}
+
+	copied = make([]CopiedConsumerGroupOffset, 0)
+	for topicName, offsets := range sourceOffsets {
+		partitionIDs := make([]int32, 0, len(offsets))
+		for partitionID := range offsets {
+			partitionIDs = append(partitionIDs, partitionID)
+		}
+		marks, err := s.WaterMarks(ctx, topicName, partitionIDs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get watermarks for topic '%v': %w", topicName, err)
+		}
+
+		for partitionID, target := range resolveCopiedOffsets(offsets, marks, req.OffsetDelta) {
+			commitReq.AddBlock(topicName, partitionID, target, 0, "")
+			copied = append(copied, CopiedConsumerGroupOffset{
+				TopicName:    topicName,
+				PartitionID:  partitionID,
+				SourceOffset: offsets[partitionID],
+				Offset:       target,
+			})
+		}
+	}
+
+	err = commitOffsets(ctx, coordinator, commitReq)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(copied, func(i, j int) bool {
+		if copied[i].TopicName == copied[j].TopicName {
+			return copied[i].PartitionID < copied[j].PartitionID
+		}
+		return copied[i].TopicName < copied[j].TopicName
+	})
+
+	return copied, nil
+}
+
+// committedOffsetsByTopic returns the committed offsets by topic and partition. Partitions without a committed offset
+// are skipped. An empty list of topics selects all topics, which are restricted further by isAllowed (if set).
+func committedOffsetsByTopic(committed *sarama.OffsetFetchResponse, topics []string, isAllowed func(string) bool) map[string]map[int32]int64 {
+	selected := make(map[string]bool, len(topics))
+	for _, topic := range topics {
+		selected[topic] = true
+	}
+
+	res := make(map[string]map[int32]int64)
+	for topicName, blocks := range committed.Blocks {
+		if len(topics) > 0 && !selected[topicName] {
+			continue
+		}
+		if isAllowed != nil && !isAllowed(topicName) {
+			continue
+		}
+		for partitionID, block := range blocks {
+			if block.Err != sarama.ErrNoError || block.Offset < 0 {
+				continue
+			}
+			if _, exists := res[topicName]; !exists {
+				res[topicName] = make(map[int32]int64)
+			}
+			res[topicName][partitionID] = block.Offset
+		}
+	}
+
+	return res
+}
+
+// resolveCopiedOffsets adds the delta to the source offsets and clamps them into the range of available offsets
+func resolveCopiedOffsets(sourceOffsets map[int32]int64, marks map[int32]*WaterMark, delta int64) map[int32]int64 {
+	targets := make(map[int32]int64, len(sourceOffsets))
+	for partitionID, offset := range sourceOffsets {
+		target := offset + delta
+		if mark, exists := marks[partitionID]; exists {
+			if target < mark.Low {
+				target = mark.Low
+			}
+			if target > mark.High {
+				target = mark.High
+			}
+		}
+		targets[partitionID] = target
+	}
+
+	return targets
+}
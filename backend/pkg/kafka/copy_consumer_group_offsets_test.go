@@ -0,0 +1,43 @@
+package kafka
+
+import (
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommittedOffsetsByTopic(t *testing.T) {
+	committed := &sarama.OffsetFetchResponse{}
+	committed.AddBlock("orders", 0, &sarama.OffsetFetchResponseBlock{Offset: 42})
+	committed.AddBlock("orders", 1, &sarama.OffsetFetchResponseBlock{Offset: -1})
+	committed.AddBlock("orders", 2, &sarama.OffsetFetchResponseBlock{Offset: 7, Err: sarama.ErrUnknownTopicOrPartition})
+	committed.AddBlock("payments", 0, &sarama.OffsetFetchResponseBlock{Offset: 3})
+
+	assert.Equal(t, map[string]map[int32]int64{
+		"orders":   {0: 42},
+		"payments": {0: 3},
+	}, committedOffsetsByTopic(committed, nil, nil))
+
+	assert.Equal(t, map[string]map[int32]int64{
+		"payments": {0: 3},
+	}, committedOffsetsByTopic(committed, []string{"payments", "unknown"}, nil))
+
+	// Topics which are not allowed are skipped, even if all topics are selected
+	isAllowed := func(topicName string) bool { return topicName != "payments" }
+	assert.Equal(t, map[string]map[int32]int64{
+		"orders": {0: 42},
+	}, committedOffsetsByTopic(committed, nil, isAllowed))
+}
+
+func TestResolveCopiedOffsets(t *testing.T) {
+	marks := map[int32]*WaterMark{
+		0: {PartitionID: 0, Low: 100, High: 500},
+		1: {PartitionID: 1, Low: 0, High: 20},
+	}
+	source := map[int32]int64{0: 150, 1: 15}
+
+	assert.Equal(t, map[int32]int64{0: 150, 1: 15}, resolveCopiedOffsets(source, marks, 0))
+	assert.Equal(t, map[int32]int64{0: 100, 1: 0}, resolveCopiedOffsets(source, marks, -100))
+	assert.Equal(t, map[int32]int64{0: 160, 1: 20}, resolveCopiedOffsets(source, marks, 10))
+}
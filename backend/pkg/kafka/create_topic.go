@@ -0,0 +1,169 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Shopify/sarama"
+)
+
+// TopicSpec describes a topic which shall be created. Either PartitionCount and ReplicationFactor or an explicit
+// ReplicaAssignment (partitionID -> replica broker IDs, the first replica is the preferred leader) must be given.
+type TopicSpec struct {
+	PartitionCount    int32
+	ReplicationFactor int16
+	ReplicaAssignment map[int32][]int32
+	Configs           map[string]*string
+}
+
+// CreateTopic creates a new topic and returns its metadata as reported by the controller
+func (s *Service) CreateTopic(ctx context.Context, topicName string, spec TopicSpec) (topicMetadata *sarama.TopicMetadata, err error) {
+	defer s.observeOperation(ctx, operationTypeAdmin, "create_topic", time.Now(), &err)
+	defer s.InvalidateTopicMetadataCache()
+	if topicName == "" {
+		return nil, fmt.Errorf("%w: topic name must be set", ErrInvalidTopicSpec)
+	}
+
+	if err := validateTopicSpec(spec, s.brokerIDs()); err != nil {
+		return nil, err
+	}
+
+	exists, err := s.TopicExists(ctx, topicName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check whether the topic exists: %w", err)
+	}
+	if exists {
+		return nil, ErrTopicAlreadyExists
+	}
+
+	detail := &sarama.TopicDetail{
+		NumPartitions:     spec.PartitionCount,
+		ReplicationFactor: spec.ReplicationFactor,
+		ConfigEntries:     spec.Configs,
+	}
+	if len(spec.ReplicaAssignment) > 0 {
+		// Kafka requires the partition count and replication factor to be unset if replicas are assigned manually
+		detail.NumPartitions = -1
+		detail.ReplicationFactor = -1
+		detail.ReplicaAssignment = spec.ReplicaAssignment
+	}
+	err = runWithContext(ctx, func() error {
+		return s.AdminClient.CreateTopic(topicName, detail, false)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create topic: %w", err)
+	}
+
+	// The controller is the first broker which knows about the new topic
+	controller, err := s.Client.Controller()
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to get controller: %v", errTopicCreatedWithoutMetadata, err)
+	}
+	var metadata *sarama.MetadataResponse
+	err = runWithContext(ctx, func() error {
+		var err error
+		metadata, err = controller.GetMetadata(&sarama.MetadataRequest{Version: 1, Topics: []string{topicName}})
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errTopicCreatedWithoutMetadata, err)
+	}
+	for _, topic := range metadata.Topics {
+		if topic.Name == topicName {
+			return topic, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: it is not yet part of the metadata", errTopicCreatedWithoutMetadata)
+}
+
+// validateTopicSpec checks that exactly one of the two modes has been chosen and that the replication factor or the
+// replica assignment can be satisfied by the given brokers.
+func validateTopicSpec(spec TopicSpec, brokerIDs []int32) error {
+	hasCount := spec.PartitionCount != 0 || spec.ReplicationFactor != 0
+	hasAssignment := len(spec.ReplicaAssignment) > 0
+	if hasCount && hasAssignment {
+		return fmt.Errorf("%w: either partition count and replication factor or a replica assignment may be given, "+
+			"but not both", ErrInvalidTopicSpec)
+	}
+	if !hasCount && !hasAssignment {
+		return fmt.Errorf("%w: either partition count and replication factor or a replica assignment must be given",
+			ErrInvalidTopicSpec)
+	}
+
+	if hasCount {
+		if spec.PartitionCount < 1 {
+			return fmt.Errorf("%w: partition count must be at least 1", ErrInvalidTopicSpec)
+		}
+		if spec.ReplicationFactor < 1 {
+			return fmt.Errorf("%w: replication factor must be at least 1", ErrInvalidTopicSpec)
+		}
+		if int(spec.ReplicationFactor) > len(brokerIDs) {
+			return fmt.Errorf("%w: replication factor %v is larger than the number of available brokers (%v)",
+				ErrInvalidTopicSpec, spec.ReplicationFactor, len(brokerIDs))
+		}
+		return nil
+	}
+
+	partitionIDs := make([]int32, 0, len(spec.ReplicaAssignment))
+	for partitionID := range spec.ReplicaAssignment {
+		partitionIDs = append(partitionIDs, partitionID)
+	}
+	sort.Slice(partitionIDs, func(i, j int) bool { return partitionIDs[i] < partitionIDs[j] })
+
+	replicationFactor := len(spec.ReplicaAssignment[partitionIDs[0]])
+	for i, partitionID := range partitionIDs {
+		if partitionID != int32(i) {
+			return fmt.Errorf("%w: partition IDs of the replica assignment must be consecutive starting at 0",
+				ErrInvalidTopicSpec)
+		}
+
+		if err := validateReplicas(partitionID, spec.ReplicaAssignment[partitionID], replicationFactor, brokerIDs); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidTopicSpec, err)
+		}
+	}
+
+	return nil
+}
+
+// validateReplicas checks that a partition has the expected number of replicas and that each replica references a
+// distinct, existing broker.
+func validateReplicas(partitionID int32, replicas []int32, replicationFactor int, brokerIDs []int32) error {
+	if len(replicas) == 0 {
+		return fmt.Errorf("partition %v has no replicas", partitionID)
+	}
+	if len(replicas) != replicationFactor {
+		return fmt.Errorf("partition %v has %v replicas, but all partitions must have %v replicas",
+			partitionID, len(replicas), replicationFactor)
+	}
+
+	knownBrokers := make(map[int32]bool, len(brokerIDs))
+	for _, id := range brokerIDs {
+		knownBrokers[id] = true
+	}
+	seen := make(map[int32]bool, len(replicas))
+	for _, brokerID := range replicas {
+		if !knownBrokers[brokerID] {
+			return fmt.Errorf("partition %v references the unknown broker %v", partitionID, brokerID)
+		}
+		if seen[brokerID] {
+			return fmt.Errorf("partition %v references broker %v more than once", partitionID, brokerID)
+		}
+		seen[brokerID] = true
+	}
+
+	return nil
+}
+
+// brokerIDs returns the IDs of all brokers which are known to the client
+func (s *Service) brokerIDs() []int32 {
+	brokers := s.Client.Brokers()
+	ids := make([]int32, len(brokers))
+	for i, broker := range brokers {
+		ids[i] = broker.ID()
+	}
+
+	return ids
+}
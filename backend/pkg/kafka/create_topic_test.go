@@ -0,0 +1,37 @@
+package kafka
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateTopicSpec(t *testing.T) {
+	brokerIDs := []int32{1, 2, 3}
+
+	tt := []struct {
+		name  string
+		spec  TopicSpec
+		valid bool
+	}{
+		{"partition count and replication factor", TopicSpec{PartitionCount: 6, ReplicationFactor: 3}, true},
+		{"replication factor exceeds brokers", TopicSpec{PartitionCount: 6, ReplicationFactor: 4}, false},
+		{"missing partition count", TopicSpec{ReplicationFactor: 3}, false},
+		{"no mode", TopicSpec{}, false},
+		{"both modes", TopicSpec{PartitionCount: 1, ReplicationFactor: 1, ReplicaAssignment: map[int32][]int32{0: {1}}}, false},
+		{"replica assignment", TopicSpec{ReplicaAssignment: map[int32][]int32{0: {1, 2}, 1: {2, 3}}}, true},
+		{"unknown broker", TopicSpec{ReplicaAssignment: map[int32][]int32{0: {1, 4}}}, false},
+		{"duplicate broker", TopicSpec{ReplicaAssignment: map[int32][]int32{0: {1, 1}}}, false},
+		{"gap in partition ids", TopicSpec{ReplicaAssignment: map[int32][]int32{0: {1}, 2: {2}}}, false},
+		{"different replica counts", TopicSpec{ReplicaAssignment: map[int32][]int32{0: {1}, 1: {2, 3}}}, false},
+	}
+	for _, table := range tt {
+		err := validateTopicSpec(table.spec, brokerIDs)
+		if table.valid {
+			assert.NoError(t, err, table.name)
+		} else {
+			assert.True(t, errors.Is(err, ErrInvalidTopicSpec), table.name)
+		}
+	}
+}
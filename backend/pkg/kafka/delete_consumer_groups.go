@@ -0,0 +1,70 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Shopify/sarama"
+)
+
+// DeleteConsumerGroup deletes a consumer group along with its committed offsets. Groups which still have active members
+// are refused with ErrConsumerGroupActive.
+func (s *Service) DeleteConsumerGroup(ctx context.Context, groupID string) error {
+	errs, err := s.DeleteConsumerGroups(ctx, []string{groupID})
+	if err != nil {
+		return err
+	}
+
+	return errs[groupID]
+}
+
+// DeleteConsumerGroups deletes multiple consumer groups and returns the error of each group which could not be
+// deleted, groups which have been deleted successfully are not contained. The returned error is only set if the
+// groups could not be described at all.
+func (s *Service) DeleteConsumerGroups(ctx context.Context, groupIDs []string) (errs map[string]error, err error) {
+	defer s.observeOperation(ctx, operationTypeAdmin, "delete_consumer_groups", time.Now(), &err)
+	if len(groupIDs) == 0 {
+		return nil, fmt.Errorf("at least one consumer group must be given")
+	}
+
+	// 1. Check whether the groups are still active
+	described, err := s.DescribeConsumerGroups(ctx, groupIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe consumer groups: %w", err)
+	}
+	deletable := make([]string, 0, len(groupIDs))
+	errs = make(map[string]error)
+	for _, res := range described {
+		for _, group := range res.Groups {
+			switch {
+			case group.Err != sarama.ErrNoError:
+				errs[group.GroupId] = fmt.Errorf("failed to describe consumer group: %w", group.Err)
+			case len(group.Members) > 0:
+				errs[group.GroupId] = ErrConsumerGroupActive
+			default:
+				deletable = append(deletable, group.GroupId)
+			}
+		}
+	}
+
+	// 2. Delete all inactive groups, the brokers refuse to delete groups which have become active in the meantime
+	for _, groupID := range deletable {
+		err := runWithContext(ctx, func() error {
+			return s.AdminClient.DeleteConsumerGroup(groupID)
+		})
+		switch {
+		case err == nil:
+		case err == ctx.Err():
+			return nil, err
+		case err == sarama.ErrNonEmptyGroup:
+			errs[groupID] = ErrConsumerGroupActive
+		case err == sarama.ErrGroupIDNotFound:
+			errs[groupID] = ErrConsumerGroupNotFound
+		default:
+			errs[groupID] = fmt.Errorf("failed to delete consumer group: %w", err)
+		}
+	}
+
+	return errs, nil
+}
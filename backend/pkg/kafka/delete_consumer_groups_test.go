@@ -0,0 +1,75 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeleteConsumerGroup(t *testing.T) {
+	activeGroup := &sarama.GroupDescription{
+		GroupId:      "billing",
+		State:        "Stable",
+		ProtocolType: "consumer",
+		Members:      map[string]*sarama.GroupMemberDescription{"member-1": {ClientId: "billing-1"}},
+	}
+	var metadata, coordinator sarama.MockResponse
+	svc, broker := newTestAdminService(t, sarama.V1_1_0_0, func(broker *sarama.MockBroker) map[string]sarama.MockResponse {
+		metadata = sarama.NewMockMetadataResponse(t).
+			SetController(broker.BrokerID()).
+			SetBroker(broker.Addr(), broker.BrokerID())
+		coordinator = sarama.NewMockFindCoordinatorResponse(t).
+			SetCoordinator(sarama.CoordinatorGroup, "billing", broker).
+			SetCoordinator(sarama.CoordinatorGroup, "shipping", broker)
+		return map[string]sarama.MockResponse{
+			"MetadataRequest":        metadata,
+			"FindCoordinatorRequest": coordinator,
+			"DescribeGroupsRequest":  sarama.NewMockDescribeGroupsResponse(t).AddGroupDescription("billing", activeGroup),
+			"DeleteGroupsRequest":    sarama.NewMockDeleteGroupsRequest(t).SetDeletedGroups([]string{"billing"}),
+		}
+	})
+
+	// The group is active as long as it has members
+	err := svc.DeleteConsumerGroup(context.Background(), "billing")
+	assert.True(t, errors.Is(err, ErrConsumerGroupActive))
+	assertDeleteGroupsRequests(t, broker, 0)
+
+	// Once all members have left, the group is empty and can be deleted
+	abandonedGroup := &sarama.GroupDescription{GroupId: "billing", State: "Empty", ProtocolType: "consumer"}
+	shippingGroup := &sarama.GroupDescription{
+		GroupId: "shipping",
+		State:   "Stable",
+		Members: map[string]*sarama.GroupMemberDescription{"member-1": {ClientId: "shipping-1"}},
+	}
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest":        metadata,
+		"FindCoordinatorRequest": coordinator,
+		"DescribeGroupsRequest":  sarama.NewMockDescribeGroupsResponse(t).AddGroupDescription("billing", abandonedGroup).AddGroupDescription("shipping", shippingGroup),
+		"DeleteGroupsRequest":    sarama.NewMockDeleteGroupsRequest(t).SetDeletedGroups([]string{"billing"}),
+	})
+	err = svc.DeleteConsumerGroup(context.Background(), "billing")
+	require.NoError(t, err)
+	assertDeleteGroupsRequests(t, broker, 1)
+
+	// Deleting multiple groups returns the error of each group which could not be deleted
+	errs, err := svc.DeleteConsumerGroups(context.Background(), []string{"billing", "shipping"})
+	require.NoError(t, err)
+	assert.Len(t, errs, 1)
+	assert.True(t, errors.Is(errs["shipping"], ErrConsumerGroupActive))
+	assertDeleteGroupsRequests(t, broker, 2)
+}
+
+// assertDeleteGroupsRequests asserts how many delete groups requests the broker has received so far
+func assertDeleteGroupsRequests(t *testing.T, broker *sarama.MockBroker, expected int) {
+	count := 0
+	for _, rr := range broker.History() {
+		if _, ok := rr.Request.(*sarama.DeleteGroupsRequest); ok {
+			count++
+		}
+	}
+	assert.Equal(t, expected, count)
+}
@@ -0,0 +1,79 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// DeleteRecords deletes all records of the given partitions before the respective offset and returns the new low
+// water mark of each partition. An offset of -1 deletes all records up to the current high water mark. Offsets beyond
+// the high water mark are rejected before any records are deleted.
+func (s *Service) DeleteRecords(ctx context.Context, topicName string, partitionOffsets map[int32]int64) (lowWaterMarks map[int32]int64, err error) {
+	defer s.observeOperation(ctx, operationTypeAdmin, "delete_records", time.Now(), &err)
+	if len(partitionOffsets) == 0 {
+		return nil, fmt.Errorf("at least one partition must be given")
+	}
+
+	exists, err := s.TopicExists(ctx, topicName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check whether the topic exists: %w", err)
+	}
+	if !exists {
+		return nil, ErrTopicNotFound
+	}
+
+	partitionIDs := make([]int32, 0, len(partitionOffsets))
+	for partitionID := range partitionOffsets {
+		partitionIDs = append(partitionIDs, partitionID)
+	}
+	marks, err := s.WaterMarks(ctx, topicName, partitionIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get watermarks: %w", err)
+	}
+	offsets, err := resolveDeleteRecordsOffsets(partitionOffsets, marks)
+	if err != nil {
+		return nil, err
+	}
+
+	err = runWithContext(ctx, func() error {
+		return s.AdminClient.DeleteRecords(topicName, offsets)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete records: %w", err)
+	}
+
+	// The admin client does not return the low water marks of the delete records response, hence we fetch them again
+	marks, err = s.WaterMarks(ctx, topicName, partitionIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get watermarks after deleting records: %w", err)
+	}
+	lowWaterMarks = make(map[int32]int64, len(marks))
+	for partitionID, mark := range marks {
+		lowWaterMarks[partitionID] = mark.Low
+	}
+
+	return lowWaterMarks, nil
+}
+
+// resolveDeleteRecordsOffsets replaces -1 with the partition's high water mark and validates that all offsets are
+// within the partition's high water mark.
+func resolveDeleteRecordsOffsets(partitionOffsets map[int32]int64, marks map[int32]*WaterMark) (map[int32]int64, error) {
+	offsets := make(map[int32]int64, len(partitionOffsets))
+	for partitionID, offset := range partitionOffsets {
+		mark, exists := marks[partitionID]
+		if !exists {
+			return nil, fmt.Errorf("%w: partition %v does not exist", ErrInvalidOffset, partitionID)
+		}
+		if offset == -1 {
+			offset = mark.High
+		}
+		if offset < 0 || offset > mark.High {
+			return nil, fmt.Errorf("%w: offset %v of partition %v must be between 0 and the high water mark %v",
+				ErrInvalidOffset, offset, partitionID, mark.High)
+		}
+		offsets[partitionID] = offset
+	}
+
+	return offsets, nil
+}
@@ -0,0 +1,49 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeleteRecords(t *testing.T) {
+	svc, broker := newTestAdminService(t, sarama.V1_0_0_0, func(broker *sarama.MockBroker) map[string]sarama.MockResponse {
+		return map[string]sarama.MockResponse{
+			"MetadataRequest": sarama.NewMockMetadataResponse(t).
+				SetController(broker.BrokerID()).
+				SetBroker(broker.Addr(), broker.BrokerID()).
+				SetLeader("orders", 0, broker.BrokerID()).
+				SetLeader("orders", 1, broker.BrokerID()),
+			"OffsetRequest": sarama.NewMockOffsetResponse(t).
+				SetOffset("orders", 0, sarama.OffsetOldest, 0).
+				SetOffset("orders", 0, sarama.OffsetNewest, 100).
+				SetOffset("orders", 1, sarama.OffsetOldest, 0).
+				SetOffset("orders", 1, sarama.OffsetNewest, 20),
+			"DeleteRecordsRequest": sarama.NewMockDeleteRecordsResponse(t),
+		}
+	})
+
+	// Offsets beyond the high water mark must be rejected without deleting any records
+	_, err := svc.DeleteRecords(context.Background(), "orders", map[int32]int64{0: 50, 1: 21})
+	assert.True(t, errors.Is(err, ErrInvalidOffset))
+
+	_, err = svc.DeleteRecords(context.Background(), "customers", map[int32]int64{0: 50})
+	assert.True(t, errors.Is(err, ErrTopicNotFound))
+
+	lowWaterMarks, err := svc.DeleteRecords(context.Background(), "orders", map[int32]int64{0: 50, 1: -1})
+	require.NoError(t, err)
+	assert.Len(t, lowWaterMarks, 2)
+
+	var deleteReq *sarama.DeleteRecordsRequest
+	for _, rr := range broker.History() {
+		if req, ok := rr.Request.(*sarama.DeleteRecordsRequest); ok {
+			deleteReq = req
+		}
+	}
+	require.NotNil(t, deleteReq)
+	assert.Equal(t, map[int32]int64{0: 50, 1: 20}, deleteReq.Topics["orders"].PartitionOffsets)
+}
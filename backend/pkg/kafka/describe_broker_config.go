@@ -1,17 +1,32 @@
 package kafka
 
 import (
+	"context"
 	"strconv"
+	"time"
 
 	"github.com/Shopify/sarama"
 )
 
 // DescribeBrokerConfig fetches config entries which apply at the Broker Scope (e.g. offset.retention.minutes).
 // Use an empty array for configNames in order to get all config entries.
-func (s *Service) DescribeBrokerConfig(brokerID int32, configNames []string) ([]sarama.ConfigEntry, error) {
-	return s.AdminClient.DescribeConfig(sarama.ConfigResource{
-		Type:        sarama.BrokerResource,
-		Name:        strconv.Itoa(int(brokerID)),
-		ConfigNames: configNames,
+func (s *Service) DescribeBrokerConfig(ctx context.Context, brokerID int32, configNames []string) (entries []sarama.ConfigEntry, err error) {
+	defer s.observeOperation(ctx, operationTypeAdmin, "describe_broker_config", time.Now(), &err)
+	var described []sarama.ConfigEntry
+	err = s.retry(ctx, "describe_broker_config", func() error {
+		return runWithContext(ctx, func() error {
+			var err error
+			described, err = s.AdminClient.DescribeConfig(sarama.ConfigResource{
+				Type:        sarama.BrokerResource,
+				Name:        strconv.Itoa(int(brokerID)),
+				ConfigNames: configNames,
+			})
+			return err
+		})
 	})
+	if err != nil {
+		return nil, err
+	}
+
+	return described, nil
 }
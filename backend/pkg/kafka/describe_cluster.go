@@ -1,22 +1,39 @@
 package kafka
 
 import (
+	"context"
 	"fmt"
+	"time"
 
 	"github.com/Shopify/sarama"
 )
 
 // DescribeCluster returns some generic information about the brokers in the given cluster
-func (s *Service) DescribeCluster() (*sarama.MetadataResponse, error) {
-	controller, err := s.Client.Controller()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get cluster controller from client: %w", err)
-	}
-
+func (s *Service) DescribeCluster(ctx context.Context) (metadata *sarama.MetadataResponse, err error) {
+	defer s.observeOperation(ctx, operationTypeAdmin, "describe_cluster", time.Now(), &err)
 	req := &sarama.MetadataRequest{
 		Version: 1, // Version 1 is required to fetch the ControllerID & RackID
 		Topics:  []string{},
 	}
+	if s.Client.Config().Version.IsAtLeast(sarama.V0_10_1_0) {
+		req.Version = 2 // Version 2 adds the ClusterID
+	}
+
+	var res *sarama.MetadataResponse
+	err = s.retry(ctx, "describe_cluster", func() error {
+		controller, err := s.Client.Controller()
+		if err != nil {
+			return fmt.Errorf("failed to get cluster controller from client: %w", err)
+		}
+		return runWithContext(ctx, func() error {
+			var err error
+			res, err = controller.GetMetadata(req)
+			return err
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
 
-	return controller.GetMetadata(req)
+	return res, nil
 }
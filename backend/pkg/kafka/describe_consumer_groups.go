@@ -3,6 +3,7 @@ package kafka
 import (
 	"context"
 	"sync"
+	"time"
 
 	"github.com/Shopify/sarama"
 	"golang.org/x/sync/errgroup"
@@ -10,7 +11,8 @@ import (
 
 // DescribeConsumerGroups fetches additional information from Kafka about one or more Consumer groups.
 // It returns a map where the coordinator BrokerID is the key.
-func (s *Service) DescribeConsumerGroups(ctx context.Context, groups []string) (map[int32]*sarama.DescribeGroupsResponse, error) {
+func (s *Service) DescribeConsumerGroups(ctx context.Context, groups []string) (res map[int32]*sarama.DescribeGroupsResponse, err error) {
+	defer s.observeOperation(ctx, operationTypeAdmin, "describe_consumer_groups", time.Now(), &err)
 	// 1. Bucket all groupIDs by their respective Consumer group coordinator/broker
 	brokersByID := make(map[int32]*sarama.Broker)
 	groupsByBrokerID := make(map[int32][]string)
@@ -26,14 +28,19 @@ func (s *Service) DescribeConsumerGroups(ctx context.Context, groups []string) (
 	}
 
 	// 2. Describe groups in bulk for each broker
-	eg, _ := errgroup.WithContext(ctx)
-	res := make(map[int32]*sarama.DescribeGroupsResponse, len(groupsByBrokerID))
+	eg, groupCtx := errgroup.WithContext(ctx)
+	res = make(map[int32]*sarama.DescribeGroupsResponse, len(groupsByBrokerID))
 	mutex := sync.Mutex{}
 
 	f := func(b *sarama.Broker, grps []string) func() error {
 		return func() error {
 			req := &sarama.DescribeGroupsRequest{Groups: grps}
-			r, err := b.DescribeGroups(req)
+			var r *sarama.DescribeGroupsResponse
+			err := runWithContext(groupCtx, func() error {
+				var err error
+				r, err = b.DescribeGroups(req)
+				return err
+			})
 			if err != nil {
 				return err
 			}
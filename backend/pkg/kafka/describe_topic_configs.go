@@ -1,13 +1,17 @@
 package kafka
 
 import (
+	"context"
+	"time"
+
 	"github.com/Shopify/sarama"
 	"go.uber.org/zap"
 )
 
 // DescribeTopicsConfigs fetches all topic config options for the given set of topic names and config names.
 // Use an empty array for configNames to fetch all configs.
-func (s *Service) DescribeTopicsConfigs(topicNames []string, configNames []string) (*sarama.DescribeConfigsResponse, error) {
+func (s *Service) DescribeTopicsConfigs(ctx context.Context, topicNames []string, configNames []string) (configs *sarama.DescribeConfigsResponse, err error) {
+	defer s.observeOperation(ctx, operationTypeAdmin, "describe_topic_configs", time.Now(), &err)
 	// 1. Create request object
 	resources := make([]*sarama.ConfigResource, len(topicNames))
 	for i, topicName := range topicNames {
@@ -22,13 +26,29 @@ func (s *Service) DescribeTopicsConfigs(topicNames []string, configNames []strin
 		Resources: resources,
 	}
 
-	// 2. Send/receive request and check for errors
-	b, err := s.Client.Controller()
-	if err != nil {
-		s.Logger.Error("could not get cluster controller broker", zap.Error(err))
-		return nil, err
+	// Config sources and the sensitive flag are only returned by version 1+
+	version := s.Client.Config().Version
+	if version.IsAtLeast(sarama.V1_1_0_0) {
+		req.Version = 1
 	}
-	response, err := b.DescribeConfigs(req)
+	if version.IsAtLeast(sarama.V2_0_0_0) {
+		req.Version = 2
+	}
+
+	// 2. Send/receive request and check for errors
+	var response *sarama.DescribeConfigsResponse
+	err = s.retry(ctx, "describe_topic_configs", func() error {
+		b, err := s.Client.Controller()
+		if err != nil {
+			s.Logger.Error("could not get cluster controller broker", zap.Error(err))
+			return err
+		}
+		return runWithContext(ctx, func() error {
+			var err error
+			response, err = b.DescribeConfigs(req)
+			return err
+		})
+	})
 	if err != nil {
 		s.Logger.Error("could not describe topic configs", zap.Error(err))
 		return nil, err
@@ -5,26 +5,67 @@ import (
 	"encoding/base64"
 	"encoding/binary"
 	"encoding/json"
-	xj "github.com/basgys/goxml2json"
-	"github.com/cloudhut/kowl/backend/pkg/schema"
-	"strings"
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
 	"unicode/utf8"
+
+	"github.com/cloudhut/kowl/backend/pkg/proto"
+	"github.com/cloudhut/kowl/backend/pkg/schema"
 )
 
 // deserializer can deserialize messages from various formats (json, xml, avro, ..) into a Go native form.
+// The decoders are tried in the order of the configured decoder chain of the topic.
 type deserializer struct {
 	SchemaService *schema.Service
+	ProtoService  *proto.Service
+
+	// DefaultChain is used if no topic chain matches. If it is empty, defaultDecoderChain is used.
+	DefaultChain []string
+	TopicChains  []topicDecoderChain
+
+	// MaxDecompressionRatio limits the size of decompressed message values. If it is 0, defaultMaxDecompressionRatio
+	// is used.
+	MaxDecompressionRatio int
+
+	// ValidateJSONSchema enables the validation of JSON Schema payloads against their schema
+	ValidateJSONSchema bool
+
+	// TransformTimeout is the max duration of the transform pipeline of a single payload. If it is 0,
+	// defaultTransformTimeout is used.
+	TransformTimeout time.Duration
+}
+
+// topicDecoderChain is the decoder chain, compression hint and transform for all topics whose name match the regex.
+// An empty chain selects the default chain.
+type topicDecoderChain struct {
+	TopicName     *regexp.Regexp
+	Chain         []string
+	Compression   string
+	Transform     string
+	KeepEnvelope  bool
+	Transforms    []messageTransform
+	KeyTransforms []messageTransform
 }
 
 type messageEncoding string
 
 const (
-	messageEncodingNone   messageEncoding = "none"
-	messageEncodingAvro   messageEncoding = "avro"
-	messageEncodingJSON   messageEncoding = "json"
-	messageEncodingXML    messageEncoding = "xml"
-	messageEncodingText   messageEncoding = "text"
-	messageEncodingBinary messageEncoding = "binary"
+	messageEncodingNone        messageEncoding = "none"
+	messageEncodingNull        messageEncoding = "null"
+	messageEncodingAvro        messageEncoding = "avro"
+	messageEncodingProtobuf    messageEncoding = "protobuf"
+	messageEncodingJSON        messageEncoding = "json"
+	messageEncodingJSONSchema  messageEncoding = "jsonSchema"
+	messageEncodingXML         messageEncoding = "xml"
+	messageEncodingMessagePack messageEncoding = "msgpack"
+	messageEncodingCBOR        messageEncoding = "cbor"
+	messageEncodingText        messageEncoding = "text"
+	messageEncodingBinary      messageEncoding = "binary"
+
+	messageEncodingConsumerOffsets  messageEncoding = "consumerOffsets"
+	messageEncodingTransactionState messageEncoding = "transactionState"
 )
 
 type deserializedPayload struct {
@@ -34,6 +75,24 @@ type deserializedPayload struct {
 	// Object is the parsed version of the payload. This will be passed to the JavaScript interpreter
 	Object             interface{}
 	RecognizedEncoding messageEncoding
+
+	// Decoder is the name of the decoder which accepted the payload and ContentType is the detected content type of
+	// the original payload
+	Decoder     string
+	ContentType string
+
+	// Compression is the compression codec the payload has been decompressed with before it was decoded
+	Compression string
+
+	// Validation is the result of validating the payload against its schema. It is nil if it hasn't been validated.
+	Validation *payloadValidation
+
+	// Envelope describes the envelope (e.g. of Debezium) the payload has been unwrapped from. It is nil if the
+	// payload has not been transformed.
+	Envelope *payloadEnvelope
+
+	// TransformError is set if the topic's transform pipeline failed, the payload is withheld in this case
+	TransformError string
 }
 
 // MarshalJSON implements the 'Marshaller' interface for deserialized payload.
@@ -42,6 +101,8 @@ func (d *deserializedPayload) MarshalJSON() ([]byte, error) {
 	switch d.RecognizedEncoding {
 	case messageEncodingNone:
 		return []byte("{}"), nil
+	case messageEncodingNull:
+		return []byte("null"), nil
 	case messageEncodingText:
 		return json.Marshal(string(d.NormalizedPayload))
 	case messageEncodingBinary:
@@ -52,66 +113,320 @@ func (d *deserializedPayload) MarshalJSON() ([]byte, error) {
 	}
 }
 
-// DeserializePayload tries to deserialize a given byte array.
+// String returns the textual representation of the payload, e. g. for CSV exports. Text is returned as is, binary
+// content base64 encoded and all other encodings as JSON.
+func (d *deserializedPayload) String() string {
+	switch d.RecognizedEncoding {
+	case messageEncodingNone, messageEncodingNull:
+		return ""
+	case messageEncodingText:
+		return string(d.NormalizedPayload)
+	case messageEncodingBinary:
+		return base64.StdEncoding.EncodeToString(d.NormalizedPayload)
+	default:
+		return string(d.NormalizedPayload)
+	}
+}
+
+// IsNull returns true if the payload is null (e.g. the value of a tombstone), as opposed to an empty payload
+func (d *deserializedPayload) IsNull() bool {
+	return d.RecognizedEncoding == messageEncodingNull
+}
+
+// Names of the decoders which can be configured in a decoder chain
+const (
+	decoderProtobuf       = "protobuf"
+	decoderSchemaRegistry = "schemaRegistry"
+	decoderJSON           = "json"
+	decoderXML            = "xml"
+	decoderText           = "text"
+	decoderMessagePack    = "msgpack"
+	decoderCBOR           = "cbor"
+	decoderBinary         = "binary"
+
+	// The decoders of the internal topics only accept the records of their topic. They are used for their topic unless
+	// a topic chain is configured.
+	decoderConsumerOffsets  = "consumerOffsets"
+	decoderTransactionState = "transactionState"
+)
+
+// defaultDecoderChain is the order in which the decoders are tried unless configured otherwise. Payloads with the
+// schema registry's magic byte must be tested before UTF-8, because the avro binary encoding may be valid UTF-8 as well.
+// MessagePack and CBOR are tested after UTF-8, because almost any short text is a valid MessagePack or CBOR value (e.g.
+// single ASCII characters are integers), whereas encoded maps and arrays never start with a valid UTF-8 byte.
+var defaultDecoderChain = []string{decoderProtobuf, decoderSchemaRegistry, decoderJSON, decoderXML, decoderText,
+	decoderMessagePack, decoderCBOR, decoderBinary}
+
+// payloadDecoder decodes a non empty payload. It returns an error if the payload is not encoded in the decoder's format.
+type payloadDecoder func(d *deserializer, payload []byte, topicName string, recordType proto.RecordType) (*deserializedPayload, error)
+
+var payloadDecoders = map[string]payloadDecoder{
+	decoderProtobuf:       decodeMappedProtobuf,
+	decoderSchemaRegistry: decodeSchemaRegistry,
+	decoderJSON:           decodeJSON,
+	decoderXML:            decodeXML,
+	decoderText:           decodeText,
+	decoderMessagePack:    decodeMessagePack,
+	decoderCBOR:           decodeCBOR,
+	decoderBinary:         decodeBinary,
+
+	decoderConsumerOffsets:  decodeConsumerOffsets,
+	decoderTransactionState: decodeTransactionState,
+}
+
+// decoderNames returns the sorted names of all registered decoders
+func decoderNames() []string {
+	names := make([]string, 0, len(payloadDecoders))
+	for name := range payloadDecoders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// newDeserializer creates a deserializer which uses the decoder chains of the given (validated) config
+func newDeserializer(cfg DeserializationConfig, schemaSvc *schema.Service, protoSvc *proto.Service) (*deserializer, error) {
+	topicChains := make([]topicDecoderChain, len(cfg.Topics))
+	for i, topic := range cfg.Topics {
+		regex, err := regexp.Compile(topic.TopicName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile deserialization topicName '%v': %w", topic.TopicName, err)
+		}
+		transforms, err := newMessageTransforms(topic.Transforms)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create transforms for topic '%v': %w", topic.TopicName, err)
+		}
+		keyTransforms, err := newMessageTransforms(topic.KeyTransforms)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create key transforms for topic '%v': %w", topic.TopicName, err)
+		}
+		topicChains[i] = topicDecoderChain{TopicName: regex, Chain: topic.Chain, Compression: topic.Compression,
+			Transform: topic.Transform, KeepEnvelope: topic.KeepEnvelope, Transforms: transforms, KeyTransforms: keyTransforms}
+	}
+
+	return &deserializer{
+		SchemaService: schemaSvc,
+		ProtoService:  protoSvc,
+		DefaultChain:  cfg.DefaultChain,
+		TopicChains:   topicChains,
+
+		MaxDecompressionRatio: cfg.MaxDecompressionRatio,
+		ValidateJSONSchema:    cfg.ValidateJSONSchema,
+		TransformTimeout:      cfg.TransformTimeout,
+	}, nil
+}
+
+// decoderChain returns the names of the decoders which shall be tried for the given topic along with the configured
+// compression hint of the topic
+func (d *deserializer) decoderChain(topicName string) ([]string, string) {
+	defaultChain := d.DefaultChain
+	if len(defaultChain) == 0 {
+		defaultChain = defaultDecoderChain
+	}
+
+	if topic := d.topicChain(topicName); topic != nil {
+		if len(topic.Chain) == 0 {
+			return defaultChain, topic.Compression
+		}
+		return topic.Chain, topic.Compression
+	}
+
+	// The records of the internal topics are always stored in Kafka's binary format
+	if decoder, exists := internalTopicDecoders[topicName]; exists {
+		return []string{decoder, decoderBinary}, ""
+	}
+
+	return defaultChain, ""
+}
+
+// topicChain returns the first topic chain which matches the topic name or nil if none matches
+func (d *deserializer) topicChain(topicName string) *topicDecoderChain {
+	if topicName == "" {
+		return nil
+	}
+	for i := range d.TopicChains {
+		if d.TopicChains[i].TopicName.MatchString(topicName) {
+			return &d.TopicChains[i]
+		}
+	}
+
+	return nil
+}
+
+// hasMessageTransforms returns true if a transform pipeline is configured for the topic
+func (d *deserializer) hasMessageTransforms(topicName string) bool {
+	topic := d.topicChain(topicName)
+	return topic != nil && (len(topic.Transforms) > 0 || len(topic.KeyTransforms) > 0)
+}
+
+// DeserializePayload tries to deserialize a given byte array using the default decoder chain.
 // The payload's byte array may represent
-//  - an encoded message such as JSON, Avro or XML
-//  - UTF-8 Text
-//  - Binary content
-// Idea: Add encoding hint where user can suggest the backend to test this encoding first.
+//   - an encoded message such as JSON, Avro or XML
+//   - UTF-8 Text
+//   - Binary content
 func (d *deserializer) DeserializePayload(payload []byte) *deserializedPayload {
+	return d.DeserializeRecordPayload(payload, "", "")
+}
+
+// DeserializeRecordPayload works like DeserializePayload, but it uses the decoder chain which is configured for the
+// given topic. The protobuf decoder uses the type which is mapped to the topic and record type (key or value). Values
+// which have been compressed by the producer are decompressed before they are passed to the decoders.
+func (d *deserializer) DeserializeRecordPayload(payload []byte, topicName string, recordType proto.RecordType) *deserializedPayload {
+	// Null payloads (e.g. the values of tombstones) are not decoded, so that they can be told apart from empty payloads
+	if payload == nil {
+		return &deserializedPayload{Object: "", RecognizedEncoding: messageEncodingNull}
+	}
 	if len(payload) == 0 {
 		return &deserializedPayload{NormalizedPayload: payload, Object: "", RecognizedEncoding: messageEncodingNone}
 	}
 
-	trimmed := bytes.TrimLeft(payload, " \t\r\n")
-	if len(trimmed) == 0 {
-		return &deserializedPayload{NormalizedPayload: payload, Object: string(payload), RecognizedEncoding: messageEncodingText}
+	chain, compressionHint := d.decoderChain(topicName)
+	compression := ""
+	if recordType == proto.RecordValue {
+		payload, compression = d.decompressPayload(payload, compressionHint)
 	}
 
-	// 1. Test for valid JSON
-	startsWithJSON := trimmed[0] == '[' || trimmed[0] == '{'
-	if startsWithJSON {
-		var obj interface{}
-		err := json.Unmarshal(payload, &obj)
+	res := d.decodePayload(payload, chain, topicName, recordType)
+	res.Compression = compression
+
+	// The envelope of values is unwrapped before the transform pipeline runs, keys have their own pipeline
+	topic := d.topicChain(topicName)
+	switch {
+	case topic == nil:
+	case recordType == proto.RecordValue:
+		if topic.Transform != "" {
+			applyPayloadTransform(res, topic.Transform, topic.KeepEnvelope)
+		}
+		if len(topic.Transforms) > 0 {
+			d.applyMessageTransforms(res, topic.Transforms)
+		}
+	case recordType == proto.RecordKey && len(topic.KeyTransforms) > 0:
+		d.applyMessageTransforms(res, topic.KeyTransforms)
+	}
+
+	return res
+}
+
+// decodePayload returns the result of the first decoder of the chain which accepts the payload
+func (d *deserializer) decodePayload(payload []byte, chain []string, topicName string, recordType proto.RecordType) *deserializedPayload {
+	for _, name := range chain {
+		decoder, exists := payloadDecoders[name]
+		if !exists {
+			continue
+		}
+		res, err := safeDecode(decoder, d, payload, topicName, recordType)
 		if err == nil {
-			return &deserializedPayload{NormalizedPayload: trimmed, Object: obj, RecognizedEncoding: messageEncodingJSON}
+			res.Decoder = name
+			return res
 		}
 	}
 
-	// 2. Test for valid XML
-	startsWithXML := trimmed[0] == '<'
-	if startsWithXML {
-		r := strings.NewReader(string(trimmed))
-		jsonPayload, err := xj.Convert(r)
+	// Anything else is shown as raw text or binary content
+	res, err := decodeText(d, payload, topicName, recordType)
+	if err == nil {
+		res.Decoder = decoderText
+		return res
+	}
+	res, _ = decodeBinary(d, payload, topicName, recordType)
+	res.Decoder = decoderBinary
+	return res
+}
+
+// safeDecode calls the decoder and turns a panic (e.g. caused by malformed input) into an error, so that the next
+// decoder of the chain can be tried.
+func safeDecode(decoder payloadDecoder, d *deserializer, payload []byte, topicName string, recordType proto.RecordType) (res *deserializedPayload, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			res = nil
+			err = fmt.Errorf("decoder panicked: %v", r)
+		}
+	}()
+
+	return decoder(d, payload, topicName, recordType)
+}
+
+// decodeMappedProtobuf uses the protobuf type which is mapped to the topic and record type
+func decodeMappedProtobuf(d *deserializer, payload []byte, topicName string, recordType proto.RecordType) (*deserializedPayload, error) {
+	if d.ProtoService == nil || topicName == "" || !d.ProtoService.HasMapping(topicName, recordType) {
+		return nil, fmt.Errorf("no protobuf type mapped to topic")
+	}
+
+	jsonPayload, err := d.ProtoService.UnmarshalMappedPayload(payload, topicName, recordType)
+	if err != nil {
+		return nil, err
+	}
+	var obj interface{}
+	_ = json.Unmarshal(jsonPayload, &obj)
+
+	return &deserializedPayload{NormalizedPayload: jsonPayload, Object: obj, RecognizedEncoding: messageEncodingProtobuf,
+		ContentType: "application/x-protobuf"}, nil
+}
+
+// decodeSchemaRegistry decodes Avro, Protobuf and JSON Schema payloads which start with the schema registry's magic byte and schema
+// id (reference: https://docs.confluent.io/current/schema-registry/serdes-develop/index.html#wire-format)
+func decodeSchemaRegistry(d *deserializer, payload []byte, _ string, _ proto.RecordType) (*deserializedPayload, error) {
+	if d.SchemaService == nil || len(payload) <= 5 || payload[0] != byte(0) {
+		return nil, fmt.Errorf("payload has no schema registry header")
+	}
+
+	schemaID := binary.BigEndian.Uint32(payload[1:5])
+	schemaRes, err := d.SchemaService.GetSchemaByID(schemaID)
+	if err == nil && schemaRes.Type() == schema.SchemaTypeJSON {
+		return d.decodeJSONSchemaPayload(schemaID, payload[5:])
+	}
+
+	codec, err := d.SchemaService.GetAvroSchemaByID(schemaID)
+	if err == nil {
+		native, _, err := codec.NativeFromBinary(payload[5:])
 		if err == nil {
-			var obj interface{}
-			_ = json.Unmarshal(jsonPayload.Bytes(), &obj) // no err possible unless the xml2json package is buggy
-			return &deserializedPayload{NormalizedPayload: jsonPayload.Bytes(), Object: obj, RecognizedEncoding: messageEncodingXML}
+			normalized, _ := json.Marshal(native)
+			return &deserializedPayload{NormalizedPayload: normalized, Object: native, RecognizedEncoding: messageEncodingAvro,
+				ContentType: "application/avro"}, nil
 		}
 	}
 
-	// 3. Test for UTF-8 validity
-	isUTF8 := utf8.Valid(payload)
-	if isUTF8 {
-		return &deserializedPayload{NormalizedPayload: payload, Object: string(payload), RecognizedEncoding: messageEncodingText}
-	}
-
-	// 4. Test for Avro (reference: https://docs.confluent.io/current/schema-registry/serdes-develop/index.html#wire-format)
-	if d.SchemaService != nil && len(payload) > 5 {
-		// Check if magic byte is set
-		if payload[0] == byte(0) {
-			schemaID := binary.BigEndian.Uint32(payload[1:5])
-			codec, err := d.SchemaService.GetAvroSchemaByID(schemaID)
-			if err == nil {
-				native, _, err := codec.NativeFromBinary(payload[5:])
-				if err == nil {
-					normalized, _ := json.Marshal(native)
-					return &deserializedPayload{NormalizedPayload: normalized, Object: native, RecognizedEncoding: messageEncodingAvro}
-				}
-			}
+	// The schema may be a protobuf schema as well
+	if d.ProtoService != nil {
+		jsonPayload, err := d.ProtoService.UnmarshalConfluentPayload(payload)
+		if err == nil {
+			var obj interface{}
+			_ = json.Unmarshal(jsonPayload, &obj)
+			return &deserializedPayload{NormalizedPayload: jsonPayload, Object: obj, RecognizedEncoding: messageEncodingProtobuf,
+				ContentType: "application/x-protobuf"}, nil
 		}
 	}
 
-	// Anything else is considered as binary content
-	return &deserializedPayload{NormalizedPayload: payload, Object: payload, RecognizedEncoding: messageEncodingBinary}
+	return nil, fmt.Errorf("payload could not be decoded with schema id %v", schemaID)
+}
+
+func decodeJSON(_ *deserializer, payload []byte, _ string, _ proto.RecordType) (*deserializedPayload, error) {
+	trimmed := bytes.TrimLeft(payload, " \t\r\n")
+	if len(trimmed) == 0 || (trimmed[0] != '[' && trimmed[0] != '{') {
+		return nil, fmt.Errorf("payload is not a JSON object or array")
+	}
+
+	var obj interface{}
+	err := json.Unmarshal(payload, &obj)
+	if err != nil {
+		return nil, err
+	}
+
+	return &deserializedPayload{NormalizedPayload: trimmed, Object: obj, RecognizedEncoding: messageEncodingJSON,
+		ContentType: "application/json"}, nil
+}
+
+func decodeText(_ *deserializer, payload []byte, _ string, _ proto.RecordType) (*deserializedPayload, error) {
+	if !utf8.Valid(payload) {
+		return nil, fmt.Errorf("payload is not valid UTF-8")
+	}
+
+	return &deserializedPayload{NormalizedPayload: payload, Object: string(payload), RecognizedEncoding: messageEncodingText,
+		ContentType: "text/plain; charset=utf-8"}, nil
+}
+
+// decodeBinary accepts any payload. Binary payloads are passed to the frontend base64 encoded.
+func decodeBinary(_ *deserializer, payload []byte, _ string, _ proto.RecordType) (*deserializedPayload, error) {
+	return &deserializedPayload{NormalizedPayload: payload, Object: payload, RecognizedEncoding: messageEncodingBinary,
+		ContentType: "application/octet-stream"}, nil
 }
@@ -0,0 +1,81 @@
+package kafka
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/cloudhut/kowl/backend/pkg/proto"
+	"github.com/fxamacker/cbor/v2"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// decodeMessagePack accepts payloads which consist of exactly one MessagePack encoded value. Binary blobs often start
+// with bytes which look like a valid MessagePack value, hence the decoded value must consume the entire payload.
+func decodeMessagePack(_ *deserializer, payload []byte, _ string, _ proto.RecordType) (*deserializedPayload, error) {
+	r := bytes.NewReader(payload)
+	dec := msgpack.NewDecoder(r)
+	native, err := dec.DecodeInterface()
+	if err != nil {
+		return nil, err
+	}
+	if r.Len() > 0 {
+		return nil, fmt.Errorf("payload contains %d bytes after the MessagePack value", r.Len())
+	}
+
+	return newBinaryFormatPayload(native, messageEncodingMessagePack, "application/x-msgpack")
+}
+
+// decodeCBOR accepts payloads which consist of exactly one CBOR encoded value
+func decodeCBOR(_ *deserializer, payload []byte, _ string, _ proto.RecordType) (*deserializedPayload, error) {
+	dec := cbor.NewDecoder(bytes.NewReader(payload))
+	var native interface{}
+	err := dec.Decode(&native)
+	if err != nil {
+		return nil, err
+	}
+	if dec.NumBytesRead() != len(payload) {
+		return nil, fmt.Errorf("payload contains %d bytes after the CBOR value", len(payload)-dec.NumBytesRead())
+	}
+
+	return newBinaryFormatPayload(native, messageEncodingCBOR, "application/cbor")
+}
+
+// newBinaryFormatPayload converts the decoded value into a form which can be represented as JSON. Values which can
+// not be represented as JSON (e.g. NaN) are rejected.
+func newBinaryFormatPayload(native interface{}, encoding messageEncoding, contentType string) (*deserializedPayload, error) {
+	obj := normalizeDecodedValue(native)
+	normalized, err := json.Marshal(obj)
+	if err != nil {
+		return nil, fmt.Errorf("decoded value can not be represented as JSON: %w", err)
+	}
+
+	return &deserializedPayload{NormalizedPayload: normalized, Object: obj, RecognizedEncoding: encoding, ContentType: contentType}, nil
+}
+
+// normalizeDecodedValue turns maps with non string keys, which both MessagePack and CBOR allow, into maps with string
+// keys and replaces CBOR tags with their content.
+func normalizeDecodedValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(val))
+		for key, value := range val {
+			m[fmt.Sprint(normalizeDecodedValue(key))] = normalizeDecodedValue(value)
+		}
+		return m
+	case map[string]interface{}:
+		for key, value := range val {
+			val[key] = normalizeDecodedValue(value)
+		}
+		return val
+	case []interface{}:
+		for i, value := range val {
+			val[i] = normalizeDecodedValue(value)
+		}
+		return val
+	case cbor.Tag:
+		return normalizeDecodedValue(val.Content)
+	default:
+		return val
+	}
+}
@@ -0,0 +1,52 @@
+package kafka
+
+import (
+	"testing"
+
+	"github.com/cloudhut/kowl/backend/pkg/proto"
+	"github.com/fxamacker/cbor/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+func TestDeserializer_MessagePack(t *testing.T) {
+	d := &deserializer{}
+	payload, err := msgpack.Marshal(map[string]interface{}{"id": 1, "tags": []string{"a", "b"}})
+	require.NoError(t, err)
+
+	res := d.DeserializePayload(payload)
+	assert.Equal(t, messageEncodingMessagePack, res.RecognizedEncoding)
+	assert.Equal(t, decoderMessagePack, res.Decoder)
+	assert.JSONEq(t, `{"id":1,"tags":["a","b"]}`, string(res.NormalizedPayload))
+
+	// Trailing bytes after a valid value are rejected
+	_, err = decodeMessagePack(d, append(payload, 0xc1), "", proto.RecordValue)
+	assert.Error(t, err)
+}
+
+func TestDeserializer_CBOR(t *testing.T) {
+	d := &deserializer{}
+	payload, err := cbor.Marshal(map[interface{}]interface{}{1: "one", "nested": map[string]int{"a": 1}})
+	require.NoError(t, err)
+
+	res := d.DeserializePayload(payload)
+	assert.Equal(t, messageEncodingCBOR, res.RecognizedEncoding)
+	assert.Equal(t, decoderCBOR, res.Decoder)
+	assert.JSONEq(t, `{"1":"one","nested":{"a":1}}`, string(res.NormalizedPayload))
+
+	_, err = decodeCBOR(d, append(payload, 0x01), "", proto.RecordValue)
+	assert.Error(t, err)
+}
+
+func TestDeserializer_BinaryFormatsFallBackToBinary(t *testing.T) {
+	d := &deserializer{}
+
+	// 0x92 is a MessagePack array with two elements, followed by more bytes than it contains
+	res := d.DeserializePayload([]byte{0x92, 0x01, 0x02, 0xff, 0xfe})
+	assert.Equal(t, messageEncodingBinary, res.RecognizedEncoding)
+
+	// Truncated values are rejected
+	res = d.DeserializePayload([]byte{0xdd, 0xff, 0xff, 0xff, 0xff, 0x01})
+	assert.Equal(t, messageEncodingBinary, res.RecognizedEncoding)
+}
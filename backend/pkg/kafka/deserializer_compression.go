@@ -0,0 +1,134 @@
+package kafka
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4"
+)
+
+// Compression codecs which can be detected or configured as hint for compressed message values. Compression at the
+// Kafka protocol level is handled by the consumer already, these are used for values which have been compressed by the
+// producing application.
+const (
+	compressionNone   = "none"
+	compressionGzip   = "gzip"
+	compressionSnappy = "snappy"
+	compressionLZ4    = "lz4"
+	compressionZstd   = "zstd"
+)
+
+// defaultMaxDecompressionRatio is used if no max decompression ratio has been configured
+const defaultMaxDecompressionRatio = 100
+
+var (
+	gzipMagic         = []byte{0x1f, 0x8b}
+	zstdMagic         = []byte{0x28, 0xb5, 0x2f, 0xfd}
+	lz4FrameMagic     = []byte{0x04, 0x22, 0x4d, 0x18}
+	snappyFramedMagic = []byte{0xff, 0x06, 0x00, 0x00, 's', 'N', 'a', 'P', 'p', 'Y'}
+)
+
+// detectCompression returns the compression codec whose magic bytes the payload starts with. Raw snappy blocks do not
+// have magic bytes and can therefore only be decompressed if snappy is configured as hint.
+func detectCompression(payload []byte) string {
+	switch {
+	case bytes.HasPrefix(payload, gzipMagic):
+		return compressionGzip
+	case bytes.HasPrefix(payload, zstdMagic):
+		return compressionZstd
+	case bytes.HasPrefix(payload, lz4FrameMagic):
+		return compressionLZ4
+	case bytes.HasPrefix(payload, snappyFramedMagic):
+		return compressionSnappy
+	default:
+		return ""
+	}
+}
+
+// decompressPayload decompresses the payload with the codec that is configured as hint, or with the codec which has
+// been detected by the payload's magic bytes if there is no hint. It returns the payload along with the name of the
+// compression. Payloads which can not be decompressed, or which would expand by more than the max decompression ratio,
+// are returned unchanged along with an empty compression.
+func (d *deserializer) decompressPayload(payload []byte, hint string) ([]byte, string) {
+	compression := hint
+	if compression == "" {
+		compression = detectCompression(payload)
+	}
+	if compression == "" || compression == compressionNone {
+		return payload, ""
+	}
+
+	maxRatio := d.MaxDecompressionRatio
+	if maxRatio <= 0 {
+		maxRatio = defaultMaxDecompressionRatio
+	}
+	maxSize := int64(len(payload)) * int64(maxRatio)
+
+	decompressed, err := decompress(compression, payload, maxSize)
+	if err != nil {
+		return payload, ""
+	}
+
+	return decompressed, compression
+}
+
+func decompress(compression string, payload []byte, maxSize int64) (res []byte, err error) {
+	// The decompression libraries are not guaranteed to be safe against any malformed input
+	defer func() {
+		if r := recover(); r != nil {
+			res = nil
+			err = fmt.Errorf("decompression panicked: %v", r)
+		}
+	}()
+
+	switch compression {
+	case compressionGzip:
+		r, err := gzip.NewReader(bytes.NewReader(payload))
+		if err != nil {
+			return nil, err
+		}
+		return readLimited(r, maxSize)
+	case compressionZstd:
+		r, err := zstd.NewReader(bytes.NewReader(payload), zstd.WithDecoderConcurrency(1), zstd.WithDecoderMaxMemory(uint64(maxSize)))
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		return readLimited(r, maxSize)
+	case compressionLZ4:
+		return readLimited(lz4.NewReader(bytes.NewReader(payload)), maxSize)
+	case compressionSnappy:
+		if bytes.HasPrefix(payload, snappyFramedMagic) {
+			return readLimited(snappy.NewReader(bytes.NewReader(payload)), maxSize)
+		}
+		// Raw snappy blocks carry the decoded length in their header, so that it can be checked before decoding
+		decodedLen, err := snappy.DecodedLen(payload)
+		if err != nil {
+			return nil, err
+		}
+		if int64(decodedLen) > maxSize {
+			return nil, fmt.Errorf("decompressed payload exceeds the max decompression ratio")
+		}
+		return snappy.Decode(nil, payload)
+	default:
+		return nil, fmt.Errorf("unknown compression '%v'", compression)
+	}
+}
+
+// readLimited reads the decompressed payload and returns an error if it is larger than maxSize bytes
+func readLimited(r io.Reader, maxSize int64) ([]byte, error) {
+	res, err := ioutil.ReadAll(io.LimitReader(r, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(res)) > maxSize {
+		return nil, fmt.Errorf("decompressed payload exceeds the max decompression ratio")
+	}
+
+	return res, nil
+}
@@ -0,0 +1,93 @@
+package kafka
+
+import (
+	"bytes"
+	"compress/gzip"
+	"regexp"
+	"testing"
+
+	"github.com/cloudhut/kowl/backend/pkg/proto"
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeserializer_DecompressesValues(t *testing.T) {
+	payload := []byte(`{"id":"order-1"}`)
+
+	var gzipped bytes.Buffer
+	gw := gzip.NewWriter(&gzipped)
+	_, err := gw.Write(payload)
+	require.NoError(t, err)
+	require.NoError(t, gw.Close())
+
+	var lz4ed bytes.Buffer
+	lw := lz4.NewWriter(&lz4ed)
+	_, err = lw.Write(payload)
+	require.NoError(t, err)
+	require.NoError(t, lw.Close())
+
+	zw, err := zstd.NewWriter(nil)
+	require.NoError(t, err)
+	zstded := zw.EncodeAll(payload, nil)
+
+	var framedSnappy bytes.Buffer
+	sw := snappy.NewBufferedWriter(&framedSnappy)
+	_, err = sw.Write(payload)
+	require.NoError(t, err)
+	require.NoError(t, sw.Close())
+
+	d := &deserializer{}
+	tt := []struct {
+		name       string
+		compressed []byte
+	}{
+		{compressionGzip, gzipped.Bytes()},
+		{compressionLZ4, lz4ed.Bytes()},
+		{compressionZstd, zstded},
+		{compressionSnappy, framedSnappy.Bytes()},
+	}
+	for _, table := range tt {
+		res := d.DeserializeRecordPayload(table.compressed, "orders", proto.RecordValue)
+		assert.Equal(t, table.name, res.Compression, table.name)
+		assert.Equal(t, messageEncodingJSON, res.RecognizedEncoding, table.name)
+		assert.JSONEq(t, string(payload), string(res.NormalizedPayload), table.name)
+	}
+
+	// Keys are not decompressed
+	res := d.DeserializeRecordPayload(gzipped.Bytes(), "orders", proto.RecordKey)
+	assert.Equal(t, "", res.Compression)
+	assert.Equal(t, messageEncodingBinary, res.RecognizedEncoding)
+}
+
+func TestDeserializer_SnappyBlockRequiresHint(t *testing.T) {
+	payload := snappy.Encode(nil, []byte("hello hello hello hello"))
+	d := &deserializer{}
+	res := d.DeserializeRecordPayload(payload, "events", proto.RecordValue)
+	assert.Equal(t, "", res.Compression)
+
+	d.TopicChains = []topicDecoderChain{{TopicName: regexp.MustCompile("^events$"), Compression: compressionSnappy}}
+	res = d.DeserializeRecordPayload(payload, "events", proto.RecordValue)
+	assert.Equal(t, compressionSnappy, res.Compression)
+	assert.Equal(t, "hello hello hello hello", res.Object)
+}
+
+func TestDeserializer_DecompressionRatio(t *testing.T) {
+	var gzipped bytes.Buffer
+	gw := gzip.NewWriter(&gzipped)
+	_, err := gw.Write(make([]byte, 1024*1024))
+	require.NoError(t, err)
+	require.NoError(t, gw.Close())
+
+	d := &deserializer{MaxDecompressionRatio: 10}
+	res := d.DeserializeRecordPayload(gzipped.Bytes(), "events", proto.RecordValue)
+	assert.Equal(t, "", res.Compression)
+	assert.Equal(t, gzipped.Bytes(), res.NormalizedPayload)
+
+	// Corrupt payloads which only start with the magic bytes are shown as they are
+	res = d.DeserializeRecordPayload([]byte{0x1f, 0x8b, 0x00, 0x01}, "events", proto.RecordValue)
+	assert.Equal(t, "", res.Compression)
+	assert.Equal(t, messageEncodingBinary, res.RecognizedEncoding)
+}
@@ -0,0 +1,214 @@
+package kafka
+
+import (
+	"fmt"
+
+	"github.com/Shopify/sarama"
+	"github.com/cloudhut/kowl/backend/pkg/proto"
+)
+
+// consumerOffsetsTopic is the internal topic in which the group coordinators store the committed offsets and the
+// group metadata
+const consumerOffsetsTopic = "__consumer_offsets"
+
+// Record types of the __consumer_offsets topic. The type is determined by the version of the key.
+const (
+	consumerOffsetsTypeOffsetCommit  = "offsetCommit"
+	consumerOffsetsTypeGroupMetadata = "groupMetadata"
+)
+
+// Max supported schema versions of the __consumer_offsets records. Versions at and above the flexible version use
+// compact strings, arrays and bytes along with tagged fields.
+const (
+	offsetCommitKeyMaxVersion         = 1
+	groupMetadataKeyVersion           = 2
+	offsetCommitValueMaxVersion       = 4
+	offsetCommitValueFlexibleVersion  = 4
+	groupMetadataValueMaxVersion      = 4
+	groupMetadataValueFlexibleVersion = 4
+)
+
+// consumerProtocolType is the protocol type of groups of Kafka consumers, whose member metadata is decoded
+const consumerProtocolType = "consumer"
+
+// consumerOffsetsKey is the key of an offset commit (group, topic and partition) or of the group metadata (group only)
+type consumerOffsetsKey struct {
+	Type      string `json:"type"`
+	Version   int16  `json:"version"`
+	Group     string `json:"group"`
+	Topic     string `json:"topic,omitempty"`
+	Partition *int32 `json:"partition,omitempty"`
+}
+
+// offsetCommitValue is the committed offset of a partition. LeaderEpoch is only stored since version 3 and
+// ExpireTimestamp only in version 1, both are -1 if they are not set.
+type offsetCommitValue struct {
+	Type            string `json:"type"`
+	Version         int16  `json:"version"`
+	Offset          int64  `json:"offset"`
+	LeaderEpoch     int32  `json:"leaderEpoch"`
+	Metadata        string `json:"metadata"`
+	CommitTimestamp int64  `json:"commitTimestamp"`
+	ExpireTimestamp int64  `json:"expireTimestamp"`
+}
+
+// groupMetadataValue is the state of a consumer group as stored by the group coordinator after each rebalance
+type groupMetadataValue struct {
+	Type                  string                `json:"type"`
+	Version               int16                 `json:"version"`
+	ProtocolType          string                `json:"protocolType"`
+	Generation            int32                 `json:"generation"`
+	Protocol              *string               `json:"protocol"`
+	Leader                *string               `json:"leader"`
+	CurrentStateTimestamp int64                 `json:"currentStateTimestamp"`
+	Members               []groupMetadataMember `json:"members"`
+}
+
+// groupMetadataMember is a member of a consumer group. The subscription and assignment of members of the "consumer"
+// protocol type are decoded, those of other protocol types (e.g. connect) are passed on as raw bytes.
+type groupMetadataMember struct {
+	MemberID         string             `json:"memberId"`
+	GroupInstanceID  *string            `json:"groupInstanceId"`
+	ClientID         string             `json:"clientId"`
+	ClientHost       string             `json:"clientHost"`
+	RebalanceTimeout int32              `json:"rebalanceTimeout"`
+	SessionTimeout   int32              `json:"sessionTimeout"`
+	SubscribedTopics []string           `json:"subscribedTopics,omitempty"`
+	Assignment       map[string][]int32 `json:"assignment,omitempty"`
+	Subscription     []byte             `json:"subscription,omitempty"`
+	RawAssignment    []byte             `json:"rawAssignment,omitempty"`
+}
+
+// decodeConsumerOffsets accepts the keys and values of the __consumer_offsets topic. Values carry no type, hence they
+// are decoded as offset commit first and as group metadata if they are not a valid offset commit.
+func decodeConsumerOffsets(_ *deserializer, payload []byte, topicName string, recordType proto.RecordType) (*deserializedPayload, error) {
+	if topicName != consumerOffsetsTopic {
+		return nil, fmt.Errorf("payload is not a record of the %v topic", consumerOffsetsTopic)
+	}
+
+	var decoded interface{}
+	var err error
+	switch recordType {
+	case proto.RecordKey:
+		decoded, err = decodeConsumerOffsetsKey(payload)
+	case proto.RecordValue:
+		decoded, err = decodeOffsetCommitValue(payload)
+		if err != nil {
+			decoded, err = decodeGroupMetadataValue(payload)
+		}
+	default:
+		return nil, fmt.Errorf("only keys and values of the %v topic can be decoded", consumerOffsetsTopic)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return newInternalRecordPayload(decoded, messageEncodingConsumerOffsets)
+}
+
+func decodeConsumerOffsetsKey(payload []byte) (*consumerOffsetsKey, error) {
+	r := &internalRecordReader{buf: payload}
+	key := &consumerOffsetsKey{Version: r.int16()}
+	switch {
+	case key.Version >= 0 && key.Version <= offsetCommitKeyMaxVersion:
+		key.Type = consumerOffsetsTypeOffsetCommit
+		key.Group = r.string(false)
+		key.Topic = r.string(false)
+		partition := r.int32()
+		key.Partition = &partition
+	case key.Version == groupMetadataKeyVersion:
+		key.Type = consumerOffsetsTypeGroupMetadata
+		key.Group = r.string(false)
+	default:
+		return nil, fmt.Errorf("unknown key version %d", key.Version)
+	}
+
+	return key, r.finish()
+}
+
+func decodeOffsetCommitValue(payload []byte) (*offsetCommitValue, error) {
+	r := &internalRecordReader{buf: payload}
+	value := &offsetCommitValue{Type: consumerOffsetsTypeOffsetCommit, Version: r.int16(), LeaderEpoch: -1, ExpireTimestamp: -1}
+	if value.Version < 0 || value.Version > offsetCommitValueMaxVersion {
+		return nil, fmt.Errorf("unknown offset commit value version %d", value.Version)
+	}
+	flexible := value.Version >= offsetCommitValueFlexibleVersion
+
+	value.Offset = r.int64()
+	if value.Version >= 3 {
+		value.LeaderEpoch = r.int32()
+	}
+	value.Metadata = r.string(flexible)
+	value.CommitTimestamp = r.int64()
+	if value.Version == 1 {
+		value.ExpireTimestamp = r.int64()
+	}
+	if flexible {
+		r.skipTaggedFields()
+	}
+
+	return value, r.finish()
+}
+
+func decodeGroupMetadataValue(payload []byte) (*groupMetadataValue, error) {
+	r := &internalRecordReader{buf: payload}
+	value := &groupMetadataValue{Type: consumerOffsetsTypeGroupMetadata, Version: r.int16(), CurrentStateTimestamp: -1}
+	if value.Version < 0 || value.Version > groupMetadataValueMaxVersion {
+		return nil, fmt.Errorf("unknown group metadata value version %d", value.Version)
+	}
+	flexible := value.Version >= groupMetadataValueFlexibleVersion
+
+	value.ProtocolType = r.string(flexible)
+	value.Generation = r.int32()
+	value.Protocol = r.nullableString(flexible)
+	value.Leader = r.nullableString(flexible)
+	if value.Version >= 2 {
+		value.CurrentStateTimestamp = r.int64()
+	}
+
+	memberCount := r.arrayLength(flexible)
+	value.Members = make([]groupMetadataMember, 0)
+	for i := 0; i < memberCount && r.err == nil; i++ {
+		member := groupMetadataMember{RebalanceTimeout: -1}
+		member.MemberID = r.string(flexible)
+		if value.Version >= 3 {
+			member.GroupInstanceID = r.nullableString(flexible)
+		}
+		member.ClientID = r.string(flexible)
+		member.ClientHost = r.string(flexible)
+		if value.Version >= 1 {
+			member.RebalanceTimeout = r.int32()
+		}
+		member.SessionTimeout = r.int32()
+		subscription := r.bytes(flexible)
+		assignment := r.bytes(flexible)
+		if flexible {
+			r.skipTaggedFields()
+		}
+		setMemberProtocolData(&member, value.ProtocolType, subscription, assignment)
+		value.Members = append(value.Members, member)
+	}
+	if flexible {
+		r.skipTaggedFields()
+	}
+
+	return value, r.finish()
+}
+
+// setMemberProtocolData decodes the subscription and assignment of consumers. If they can not be decoded, they are
+// set as raw bytes.
+func setMemberProtocolData(member *groupMetadataMember, protocolType string, subscription, assignment []byte) {
+	if protocolType == consumerProtocolType {
+		description := &sarama.GroupMemberDescription{MemberMetadata: subscription, MemberAssignment: assignment}
+		metadata, metadataErr := description.GetMemberMetadata()
+		memberAssignment, assignmentErr := description.GetMemberAssignment()
+		if metadataErr == nil && assignmentErr == nil {
+			member.SubscribedTopics = metadata.Topics
+			member.Assignment = memberAssignment.Topics
+			return
+		}
+	}
+
+	member.Subscription = subscription
+	member.RawAssignment = assignment
+}
@@ -0,0 +1,94 @@
+package kafka
+
+import (
+	"testing"
+
+	"github.com/cloudhut/kowl/backend/pkg/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeConsumerOffsets_OffsetCommit(t *testing.T) {
+	d := &deserializer{}
+	key := (&internalRecordWriter{}).int16(1).string("orders-service").string("orders").int32(3).buf
+	value := (&internalRecordWriter{}).int16(3).int64(42).int32(7).string("meta").int64(1600000000000).buf
+
+	res := d.DeserializeRecordPayload(key, consumerOffsetsTopic, proto.RecordKey)
+	assert.Equal(t, decoderConsumerOffsets, res.Decoder)
+	assert.Equal(t, messageEncodingConsumerOffsets, res.RecognizedEncoding)
+	assert.JSONEq(t, `{"type":"offsetCommit","version":1,"group":"orders-service","topic":"orders","partition":3}`,
+		string(res.NormalizedPayload))
+
+	res = d.DeserializeRecordPayload(value, consumerOffsetsTopic, proto.RecordValue)
+	assert.Equal(t, decoderConsumerOffsets, res.Decoder)
+	assert.JSONEq(t, `{"type":"offsetCommit","version":3,"offset":42,"leaderEpoch":7,"metadata":"meta",
+		"commitTimestamp":1600000000000,"expireTimestamp":-1}`, string(res.NormalizedPayload))
+
+	// Version 1 contains the expire timestamp, but no leader epoch
+	value = (&internalRecordWriter{}).int16(1).int64(42).string("").int64(1600000000000).int64(1600086400000).buf
+	res = d.DeserializeRecordPayload(value, consumerOffsetsTopic, proto.RecordValue)
+	assert.JSONEq(t, `{"type":"offsetCommit","version":1,"offset":42,"leaderEpoch":-1,"metadata":"",
+		"commitTimestamp":1600000000000,"expireTimestamp":1600086400000}`, string(res.NormalizedPayload))
+
+	// Version 4 uses compact strings and tagged fields
+	value = (&internalRecordWriter{}).int16(4).int64(42).int32(7).raw(5).raw([]byte("meta")...).int64(1600000000000).raw(0).buf
+	decoded, err := decodeOffsetCommitValue(value)
+	require.NoError(t, err)
+	assert.Equal(t, &offsetCommitValue{Type: consumerOffsetsTypeOffsetCommit, Version: 4, Offset: 42, LeaderEpoch: 7,
+		Metadata: "meta", CommitTimestamp: 1600000000000, ExpireTimestamp: -1}, decoded)
+}
+
+func TestDecodeConsumerOffsets_GroupMetadata(t *testing.T) {
+	d := &deserializer{}
+	key := (&internalRecordWriter{}).int16(2).string("orders-service").buf
+	res := d.DeserializeRecordPayload(key, consumerOffsetsTopic, proto.RecordKey)
+	assert.JSONEq(t, `{"type":"groupMetadata","version":2,"group":"orders-service"}`, string(res.NormalizedPayload))
+
+	subscription := (&internalRecordWriter{}).int16(0).int32(1).string("orders").bytes(nil).buf
+	assignment := (&internalRecordWriter{}).int16(0).int32(1).string("orders").int32(2).int32(0).int32(1).bytes(nil).buf
+	value := (&internalRecordWriter{}).int16(3).string("consumer").int32(5).string("range").string("member-1").
+		int64(1600000000000).int32(1).
+		string("member-1").int16(-1).string("client-1").string("/10.0.0.1").int32(300000).int32(10000).
+		bytes(subscription).bytes(assignment).buf
+
+	decoded, err := decodeGroupMetadataValue(value)
+	require.NoError(t, err)
+	require.Len(t, decoded.Members, 1)
+	assert.Equal(t, "consumer", decoded.ProtocolType)
+	assert.Equal(t, int32(5), decoded.Generation)
+	assert.Equal(t, "range", *decoded.Protocol)
+	assert.Equal(t, int64(1600000000000), decoded.CurrentStateTimestamp)
+
+	member := decoded.Members[0]
+	assert.Equal(t, "member-1", member.MemberID)
+	assert.Nil(t, member.GroupInstanceID)
+	assert.Equal(t, "/10.0.0.1", member.ClientHost)
+	assert.Equal(t, []string{"orders"}, member.SubscribedTopics)
+	assert.Equal(t, map[string][]int32{"orders": {0, 1}}, member.Assignment)
+	assert.Nil(t, member.Subscription)
+
+	// Group metadata values are not mistaken as offset commits
+	res = d.DeserializeRecordPayload(value, consumerOffsetsTopic, proto.RecordValue)
+	assert.Equal(t, decoderConsumerOffsets, res.Decoder)
+	assert.Contains(t, string(res.NormalizedPayload), `"type":"groupMetadata"`)
+}
+
+func TestDecodeConsumerOffsets_Rejected(t *testing.T) {
+	d := &deserializer{}
+	key := (&internalRecordWriter{}).int16(1).string("group").string("orders").int32(0).buf
+
+	// Other topics are not decoded, even though the payload matches the format
+	_, err := decodeConsumerOffsets(d, key, "orders", proto.RecordKey)
+	assert.Error(t, err)
+
+	_, err = decodeConsumerOffsets(d, key[:len(key)-1], consumerOffsetsTopic, proto.RecordKey)
+	assert.Error(t, err)
+	_, err = decodeConsumerOffsets(d, append(key, 0), consumerOffsetsTopic, proto.RecordKey)
+	assert.Error(t, err)
+	_, err = decodeConsumerOffsets(d, (&internalRecordWriter{}).int16(9).buf, consumerOffsetsTopic, proto.RecordKey)
+	assert.Error(t, err)
+
+	// Undecodable records are shown as binary
+	res := d.DeserializeRecordPayload([]byte("hello"), consumerOffsetsTopic, proto.RecordValue)
+	assert.Equal(t, decoderBinary, res.Decoder)
+}
@@ -0,0 +1,108 @@
+package kafka
+
+import (
+	"encoding/json"
+)
+
+// Names of the transforms which can be applied to the decoded values of a topic
+const (
+	transformDebeziumUnwrap = "debeziumUnwrap"
+)
+
+// Envelope types of payloadEnvelope
+const (
+	envelopeTypeDebezium = "debezium"
+)
+
+// debeziumOperations maps the op codes of Debezium change events to readable operation names
+var debeziumOperations = map[string]string{
+	"c": "create",
+	"u": "update",
+	"d": "delete",
+	"r": "read",
+	"t": "truncate",
+	"m": "message",
+}
+
+// payloadEnvelope describes the envelope a payload has been unwrapped from
+type payloadEnvelope struct {
+	Type string `json:"type"`
+
+	// Operation is the kind of change of a change event, e.g. create, update or delete
+	Operation string `json:"operation"`
+
+	// Payload is the full envelope, it is only set if keepEnvelope is enabled for the topic
+	Payload interface{} `json:"payload,omitempty"`
+}
+
+// applyPayloadTransform applies the transform to the decoded payload in place. Payloads which are not recognized by
+// the transform are left as they are.
+func applyPayloadTransform(res *deserializedPayload, transform string, keepEnvelope bool) {
+	switch transform {
+	case transformDebeziumUnwrap:
+		unwrapDebeziumEnvelope(res, keepEnvelope)
+	}
+}
+
+// unwrapDebeziumEnvelope replaces the Debezium change event with the row state after the change, or the row state
+// before the change for deletes. Envelopes of the JSON converter with enabled schemas ({"schema": .., "payload": ..})
+// and Avro envelopes, whose optional before and after fields are wrapped in unions, are recognized as well.
+func unwrapDebeziumEnvelope(res *deserializedPayload, keepEnvelope bool) {
+	envelope, ok := res.Object.(map[string]interface{})
+	if !ok {
+		return
+	}
+	if payload, ok := envelope["payload"].(map[string]interface{}); ok {
+		if _, hasSchema := envelope["schema"]; hasSchema {
+			envelope = payload
+		}
+	}
+
+	isAvro := res.RecognizedEncoding == messageEncodingAvro
+	op, ok := debeziumField(envelope, "op", isAvro).(string)
+	if !ok {
+		return
+	}
+	operation, ok := debeziumOperations[op]
+	if !ok {
+		return
+	}
+	_, hasBefore := envelope["before"]
+	_, hasAfter := envelope["after"]
+	if !hasBefore && !hasAfter {
+		return
+	}
+
+	res.Envelope = &payloadEnvelope{Type: envelopeTypeDebezium, Operation: operation}
+	if keepEnvelope {
+		res.Envelope.Payload = res.Object
+	}
+
+	row := debeziumField(envelope, "after", isAvro)
+	if op == "d" {
+		row = debeziumField(envelope, "before", isAvro)
+	}
+	if row == nil {
+		// Truncates and messages carry no row state, hence the envelope is shown as it is
+		return
+	}
+	normalized, err := json.Marshal(row)
+	if err != nil {
+		return
+	}
+	res.Object = row
+	res.NormalizedPayload = normalized
+}
+
+// debeziumField returns the field of the envelope. Optional fields of Avro envelopes are unions, which are decoded as
+// map with the type name as single key.
+func debeziumField(envelope map[string]interface{}, name string, isAvro bool) interface{} {
+	value := envelope[name]
+	if union, ok := value.(map[string]interface{}); ok && isAvro && len(union) == 1 {
+		for _, v := range union {
+			return v
+		}
+	}
+
+	return value
+}
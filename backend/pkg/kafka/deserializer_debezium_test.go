@@ -0,0 +1,85 @@
+package kafka
+
+import (
+	"testing"
+
+	"github.com/cloudhut/kowl/backend/pkg/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeserializer_DebeziumUnwrap(t *testing.T) {
+	cfg := DeserializationConfig{
+		Topics: []DeserializationTopicConfig{
+			{TopicName: "^cdc\\.", Transform: transformDebeziumUnwrap},
+			{TopicName: "^audit\\.", Transform: transformDebeziumUnwrap, KeepEnvelope: true},
+		},
+	}
+	cfg.SetDefaults()
+	require.NoError(t, cfg.Validate())
+	d, err := newDeserializer(cfg, nil, nil)
+	require.NoError(t, err)
+
+	tt := []struct {
+		name      string
+		topic     string
+		payload   string
+		operation string
+		row       string
+	}{
+		{"update", "cdc.orders", `{"before":{"id":1,"state":"new"},"after":{"id":1,"state":"paid"},"op":"u","ts_ms":1}`, "update", `{"id":1,"state":"paid"}`},
+		{"delete shows before", "cdc.orders", `{"before":{"id":1},"after":null,"op":"d"}`, "delete", `{"id":1}`},
+		{"snapshot with schema", "cdc.orders", `{"schema":{},"payload":{"before":null,"after":{"id":2},"op":"r"}}`, "read", `{"id":2}`},
+		{"truncate keeps envelope", "cdc.orders", `{"before":null,"after":null,"op":"t"}`, "truncate", `{"before":null,"after":null,"op":"t"}`},
+		{"no envelope", "cdc.orders", `{"id":1,"op":"u"}`, "", `{"id":1,"op":"u"}`},
+		{"no transform", "orders", `{"before":null,"after":{"id":1},"op":"c"}`, "", `{"before":null,"after":{"id":1},"op":"c"}`},
+	}
+	for _, table := range tt {
+		res := d.DeserializeRecordPayload([]byte(table.payload), table.topic, proto.RecordValue)
+		assert.JSONEq(t, table.row, string(res.NormalizedPayload), table.name)
+		if table.operation == "" {
+			assert.Nil(t, res.Envelope, table.name)
+			continue
+		}
+		require.NotNil(t, res.Envelope, table.name)
+		assert.Equal(t, envelopeTypeDebezium, res.Envelope.Type, table.name)
+		assert.Equal(t, table.operation, res.Envelope.Operation, table.name)
+		assert.Nil(t, res.Envelope.Payload, table.name)
+	}
+
+	// Keys are not transformed
+	res := d.DeserializeRecordPayload([]byte(`{"before":null,"after":{"id":1},"op":"c"}`), "cdc.orders", proto.RecordKey)
+	assert.Nil(t, res.Envelope)
+
+	res = d.DeserializeRecordPayload([]byte(`{"before":null,"after":{"id":1},"op":"c"}`), "audit.orders", proto.RecordValue)
+	require.NotNil(t, res.Envelope)
+	assert.JSONEq(t, `{"id":1}`, string(res.NormalizedPayload))
+	assert.Equal(t, map[string]interface{}{"before": nil, "after": map[string]interface{}{"id": float64(1)}, "op": "c"}, res.Envelope.Payload)
+}
+
+func TestUnwrapDebeziumEnvelope_AvroUnions(t *testing.T) {
+	res := &deserializedPayload{
+		RecognizedEncoding: messageEncodingAvro,
+		Object: map[string]interface{}{
+			"before": nil,
+			"after":  map[string]interface{}{"db.public.orders.Value": map[string]interface{}{"id": 3}},
+			"source": map[string]interface{}{"table": "orders"},
+			"op":     "c",
+		},
+	}
+	unwrapDebeziumEnvelope(res, false)
+
+	require.NotNil(t, res.Envelope)
+	assert.Equal(t, "create", res.Envelope.Operation)
+	assert.Equal(t, map[string]interface{}{"id": 3}, res.Object)
+	assert.JSONEq(t, `{"id":3}`, string(res.NormalizedPayload))
+}
+
+func TestDeserializationConfig_Validate_Transform(t *testing.T) {
+	cfg := DeserializationConfig{Topics: []DeserializationTopicConfig{{TopicName: "cdc", Transform: "unknown"}}}
+	cfg.SetDefaults()
+	assert.Error(t, cfg.Validate())
+
+	cfg.Topics[0] = DeserializationTopicConfig{TopicName: "cdc", Chain: []string{decoderJSON}, KeepEnvelope: true}
+	assert.Error(t, cfg.Validate(), "keepEnvelope requires a transform")
+}
@@ -0,0 +1,174 @@
+package kafka
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+)
+
+// internalTopicDecoders maps Kafka's internal topics to the decoder of their binary record format. Records which can
+// not be decoded (e.g. because a newer Kafka version uses an unknown schema version) are shown as binary.
+var internalTopicDecoders = map[string]string{
+	consumerOffsetsTopic:  decoderConsumerOffsets,
+	transactionStateTopic: decoderTransactionState,
+}
+
+// newInternalRecordPayload returns the decoded record of an internal topic as JSON
+func newInternalRecordPayload(decoded interface{}, encoding messageEncoding) (*deserializedPayload, error) {
+	normalized, err := json.Marshal(decoded)
+	if err != nil {
+		return nil, err
+	}
+	var obj interface{}
+	err = json.Unmarshal(normalized, &obj)
+	if err != nil {
+		return nil, err
+	}
+
+	return &deserializedPayload{NormalizedPayload: normalized, Object: obj, RecognizedEncoding: encoding,
+		ContentType: "application/octet-stream"}, nil
+}
+
+// internalRecordReader reads the fields of the records of Kafka's internal topics. The first error is kept and all further
+// reads return zero values, so that the error only needs to be checked once the record has been read.
+type internalRecordReader struct {
+	buf []byte
+	err error
+}
+
+func (r *internalRecordReader) take(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || n > len(r.buf) {
+		r.err = fmt.Errorf("unexpected end of record, %d bytes requested but only %d left", n, len(r.buf))
+		return nil
+	}
+	b := r.buf[:n]
+	r.buf = r.buf[n:]
+	return b
+}
+
+func (r *internalRecordReader) int8() int8 {
+	b := r.take(1)
+	if b == nil {
+		return 0
+	}
+	return int8(b[0])
+}
+
+func (r *internalRecordReader) int16() int16 {
+	b := r.take(2)
+	if b == nil {
+		return 0
+	}
+	return int16(binary.BigEndian.Uint16(b))
+}
+
+func (r *internalRecordReader) int32() int32 {
+	b := r.take(4)
+	if b == nil {
+		return 0
+	}
+	return int32(binary.BigEndian.Uint32(b))
+}
+
+func (r *internalRecordReader) int64() int64 {
+	b := r.take(8)
+	if b == nil {
+		return 0
+	}
+	return int64(binary.BigEndian.Uint64(b))
+}
+
+func (r *internalRecordReader) uvarint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(r.buf)
+	if n <= 0 {
+		r.err = fmt.Errorf("invalid unsigned varint")
+		return 0
+	}
+	r.buf = r.buf[n:]
+	return v
+}
+
+// length reads the length of a string, bytes or array field. It is -1 for null values. Compact lengths are stored as
+// unsigned varint of the length plus one.
+func (r *internalRecordReader) length(compact bool, classic func() int) int {
+	if !compact {
+		return classic()
+	}
+	l := r.uvarint()
+	if l > uint64(len(r.buf))+1 {
+		r.err = fmt.Errorf("compact length %d exceeds the record", l)
+		return 0
+	}
+	return int(l) - 1
+}
+
+func (r *internalRecordReader) nullableString(compact bool) *string {
+	l := r.length(compact, func() int { return int(r.int16()) })
+	if l < 0 || r.err != nil {
+		return nil
+	}
+	s := string(r.take(l))
+	return &s
+}
+
+func (r *internalRecordReader) string(compact bool) string {
+	s := r.nullableString(compact)
+	if s == nil {
+		if r.err == nil {
+			r.err = fmt.Errorf("unexpected null string")
+		}
+		return ""
+	}
+	return *s
+}
+
+func (r *internalRecordReader) bytes(compact bool) []byte {
+	l := r.length(compact, func() int { return int(r.int32()) })
+	if l < 0 {
+		return nil
+	}
+	return r.take(l)
+}
+
+func (r *internalRecordReader) arrayLength(compact bool) int {
+	l := r.length(compact, func() int { return int(r.int32()) })
+	if l < 0 {
+		return 0
+	}
+	// Each element takes at least one byte, which rejects bogus lengths before anything is allocated
+	if l > len(r.buf) {
+		r.err = fmt.Errorf("array length %d exceeds the record", l)
+		return 0
+	}
+	return l
+}
+
+func (r *internalRecordReader) skipTaggedFields() {
+	count := r.uvarint()
+	for i := uint64(0); i < count && r.err == nil; i++ {
+		r.uvarint() // tag
+		size := r.uvarint()
+		if size > uint64(len(r.buf)) {
+			r.err = fmt.Errorf("tagged field size %d exceeds the record", size)
+			return
+		}
+		r.take(int(size))
+	}
+}
+
+// finish returns the first read error or an error if the record contains trailing bytes
+func (r *internalRecordReader) finish() error {
+	if r.err != nil {
+		return r.err
+	}
+	if len(r.buf) > 0 {
+		return fmt.Errorf("record contains %d unexpected trailing bytes", len(r.buf))
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package kafka
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// internalRecordWriter encodes records of the internal topics in the classic (non flexible) format
+type internalRecordWriter struct {
+	buf []byte
+}
+
+func (w *internalRecordWriter) int16(v int16) *internalRecordWriter {
+	w.buf = append(w.buf, 0, 0)
+	binary.BigEndian.PutUint16(w.buf[len(w.buf)-2:], uint16(v))
+	return w
+}
+
+func (w *internalRecordWriter) int32(v int32) *internalRecordWriter {
+	w.buf = append(w.buf, 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(w.buf[len(w.buf)-4:], uint32(v))
+	return w
+}
+
+func (w *internalRecordWriter) int64(v int64) *internalRecordWriter {
+	w.buf = append(w.buf, 0, 0, 0, 0, 0, 0, 0, 0)
+	binary.BigEndian.PutUint64(w.buf[len(w.buf)-8:], uint64(v))
+	return w
+}
+
+func (w *internalRecordWriter) string(s string) *internalRecordWriter {
+	w.int16(int16(len(s)))
+	w.buf = append(w.buf, s...)
+	return w
+}
+
+func (w *internalRecordWriter) bytes(b []byte) *internalRecordWriter {
+	w.int32(int32(len(b)))
+	w.buf = append(w.buf, b...)
+	return w
+}
+
+func (w *internalRecordWriter) raw(b ...byte) *internalRecordWriter {
+	w.buf = append(w.buf, b...)
+	return w
+}
+
+func (w *internalRecordWriter) int8(v int8) *internalRecordWriter {
+	w.buf = append(w.buf, byte(v))
+	return w
+}
+
+func TestInternalRecordReader(t *testing.T) {
+	// Compact string "abc", a null compact string and two tagged fields (tag 0 with 2 bytes, tag 5 with 1 byte)
+	r := &internalRecordReader{buf: []byte{4, 'a', 'b', 'c', 0, 2, 0, 2, 0xff, 0xff, 5, 1, 0xff}}
+	assert.Equal(t, "abc", r.string(true))
+	assert.Nil(t, r.nullableString(true))
+	r.skipTaggedFields()
+	assert.NoError(t, r.finish())
+
+	// Reads beyond the end of the record fail and the first error is kept
+	r = &internalRecordReader{buf: []byte{0, 5, 'a'}}
+	assert.Equal(t, "", r.string(false))
+	assert.Equal(t, int32(0), r.int32())
+	assert.EqualError(t, r.finish(), "unexpected end of record, 5 bytes requested but only 1 left")
+
+	// Array lengths which exceed the record are rejected before anything is allocated
+	r = &internalRecordReader{buf: []byte{0x7f, 0xff, 0xff, 0xff}}
+	assert.Equal(t, 0, r.arrayLength(false))
+	assert.Error(t, r.finish())
+}
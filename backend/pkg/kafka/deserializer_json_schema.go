@@ -0,0 +1,47 @@
+package kafka
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/cloudhut/kowl/backend/pkg/schema"
+)
+
+// payloadValidation is the result of validating a payload against the schema it has been serialized with
+type payloadValidation struct {
+	SchemaID uint32   `json:"schemaId"`
+	IsValid  bool     `json:"isValid"`
+	Errors   []string `json:"errors"`
+}
+
+// decodeJSONSchemaPayload decodes the payload of the Confluent JSON Schema serializer, which is a JSON document after
+// the schema registry header. If validation is enabled the document is validated against its schema, invalid
+// documents are returned nevertheless, so that they can be inspected.
+func (d *deserializer) decodeJSONSchemaPayload(schemaID uint32, payload []byte) (*deserializedPayload, error) {
+	var jsonSchema *schema.JSONSchema
+	if d.ValidateJSONSchema {
+		// Payloads whose schema can't be compiled are shown without a validation result
+		jsonSchema, _ = d.SchemaService.GetJSONSchemaByID(schemaID)
+	}
+
+	return newJSONSchemaPayload(schemaID, payload, jsonSchema)
+}
+
+// newJSONSchemaPayload parses the JSON document and validates it against the schema, unless the schema is nil
+func newJSONSchemaPayload(schemaID uint32, payload []byte, jsonSchema *schema.JSONSchema) (*deserializedPayload, error) {
+	var obj interface{}
+	err := json.Unmarshal(payload, &obj)
+	if err != nil {
+		return nil, fmt.Errorf("payload with json schema id %v is not valid JSON: %w", schemaID, err)
+	}
+
+	var validation *payloadValidation
+	if jsonSchema != nil {
+		errs := jsonSchema.Validate(obj)
+		validation = &payloadValidation{SchemaID: schemaID, IsValid: len(errs) == 0, Errors: errs}
+	}
+
+	return &deserializedPayload{NormalizedPayload: bytes.TrimSpace(payload), Object: obj,
+		RecognizedEncoding: messageEncodingJSONSchema, ContentType: "application/json", Validation: validation}, nil
+}
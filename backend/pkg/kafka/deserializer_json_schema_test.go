@@ -0,0 +1,33 @@
+package kafka
+
+import (
+	"testing"
+
+	"github.com/cloudhut/kowl/backend/pkg/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewJSONSchemaPayload(t *testing.T) {
+	jsonSchema, err := schema.CompileJSONSchema(`{"type": "object", "required": ["id"], "properties": {"id": {"type": "integer"}}}`)
+	require.NoError(t, err)
+
+	res, err := newJSONSchemaPayload(7, []byte(`{"id": 1}`), jsonSchema)
+	require.NoError(t, err)
+	assert.Equal(t, messageEncodingJSONSchema, res.RecognizedEncoding)
+	assert.Equal(t, &payloadValidation{SchemaID: 7, IsValid: true, Errors: []string{}}, res.Validation)
+
+	// Invalid payloads are returned along with the validation errors
+	res, err = newJSONSchemaPayload(7, []byte(`{"id": "1"}`), jsonSchema)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"id": "1"}, res.Object)
+	assert.False(t, res.Validation.IsValid)
+	assert.Equal(t, []string{"$.id: expected type integer but got string"}, res.Validation.Errors)
+
+	res, err = newJSONSchemaPayload(7, []byte(`{"id": 1}`), nil)
+	require.NoError(t, err)
+	assert.Nil(t, res.Validation)
+
+	_, err = newJSONSchemaPayload(7, []byte{0xff, 0x01}, jsonSchema)
+	assert.Error(t, err)
+}
@@ -0,0 +1,92 @@
+package kafka
+
+import (
+	"testing"
+
+	"github.com/cloudhut/kowl/backend/pkg/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeserializer_DefaultChain(t *testing.T) {
+	d := &deserializer{}
+	tt := []struct {
+		name        string
+		payload     []byte
+		encoding    messageEncoding
+		decoder     string
+		contentType string
+	}{
+		{"json object", []byte(` {"a": 1}`), messageEncodingJSON, decoderJSON, "application/json"},
+		{"xml", []byte(`<a>b</a>`), messageEncodingXML, decoderXML, "application/xml"},
+		{"invalid json is text", []byte(`{"a": `), messageEncodingText, decoderText, "text/plain; charset=utf-8"},
+		{"whitespace only", []byte("  \n"), messageEncodingText, decoderText, "text/plain; charset=utf-8"},
+		{"magic byte without schema registry", []byte{0, 0, 0, 0, 1, 0xff, 0xfe}, messageEncodingBinary, decoderBinary, "application/octet-stream"},
+	}
+	for _, table := range tt {
+		res := d.DeserializeRecordPayload(table.payload, "orders", proto.RecordValue)
+		assert.Equal(t, table.encoding, res.RecognizedEncoding, table.name)
+		assert.Equal(t, table.decoder, res.Decoder, table.name)
+		assert.Equal(t, table.contentType, res.ContentType, table.name)
+	}
+
+	res := d.DeserializePayload([]byte{})
+	assert.Equal(t, messageEncodingNone, res.RecognizedEncoding)
+	assert.False(t, res.IsNull())
+
+	// Null payloads are not decoded
+	res = d.DeserializePayload(nil)
+	assert.Equal(t, messageEncodingNull, res.RecognizedEncoding)
+	assert.True(t, res.IsNull())
+	assert.Equal(t, "", res.Decoder)
+}
+
+func TestDeserializer_TopicChain(t *testing.T) {
+	cfg := DeserializationConfig{
+		Topics: []DeserializationTopicConfig{{TopicName: "^raw-.*", Chain: []string{decoderText}}},
+	}
+	cfg.SetDefaults()
+	require.NoError(t, cfg.Validate())
+	d, err := newDeserializer(cfg, nil, nil)
+	require.NoError(t, err)
+
+	res := d.DeserializeRecordPayload([]byte(`{"a":1}`), "raw-events", proto.RecordValue)
+	assert.Equal(t, decoderText, res.Decoder)
+
+	// Payloads which are rejected by all decoders of the chain fall back to text or binary
+	res = d.DeserializeRecordPayload([]byte{0xff, 0xfe}, "raw-events", proto.RecordValue)
+	assert.Equal(t, decoderBinary, res.Decoder)
+
+	res = d.DeserializeRecordPayload([]byte(`{"a":1}`), "events", proto.RecordValue)
+	assert.Equal(t, decoderJSON, res.Decoder)
+}
+
+func TestDeserializer_RecoversFromPanic(t *testing.T) {
+	payloadDecoders["panic"] = func(_ *deserializer, _ []byte, _ string, _ proto.RecordType) (*deserializedPayload, error) {
+		panic("malformed input")
+	}
+	defer delete(payloadDecoders, "panic")
+
+	d := &deserializer{DefaultChain: []string{"panic", decoderText}}
+	res := d.DeserializePayload([]byte("hello"))
+	assert.Equal(t, decoderText, res.Decoder)
+}
+
+func TestDeserializationConfig_Validate(t *testing.T) {
+	cfg := DeserializationConfig{}
+	cfg.SetDefaults()
+	assert.NoError(t, cfg.Validate())
+
+	cfg.DefaultChain = []string{decoderJSON, "yaml"}
+	assert.Error(t, cfg.Validate())
+
+	cfg.DefaultChain = []string{decoderJSON, decoderJSON}
+	assert.Error(t, cfg.Validate())
+
+	cfg.SetDefaults()
+	cfg.Topics = []DeserializationTopicConfig{{TopicName: "[", Chain: []string{decoderText}}}
+	assert.Error(t, cfg.Validate())
+
+	cfg.Topics = []DeserializationTopicConfig{{TopicName: "events"}}
+	assert.Error(t, cfg.Validate())
+}
@@ -0,0 +1,130 @@
+package kafka
+
+import (
+	"fmt"
+
+	"github.com/cloudhut/kowl/backend/pkg/proto"
+)
+
+// transactionStateTopic is the internal topic in which the transaction coordinators store the state of all
+// transactional producers
+const transactionStateTopic = "__transaction_state"
+
+// Supported schema versions of the __transaction_state records. Values at and above the flexible version use compact
+// strings and arrays along with tagged fields.
+const (
+	transactionLogKeyVersion           = 0
+	transactionLogValueMaxVersion      = 1
+	transactionLogValueFlexibleVersion = 1
+)
+
+// transactionStatusUnknown is the name of statuses which have been added by newer Kafka versions
+const transactionStatusUnknown = "Unknown"
+
+// transactionStatusNames are the names of the transaction states as stored by the transaction coordinator
+var transactionStatusNames = map[int8]string{
+	0: "Empty", 1: "Ongoing", 2: "PrepareCommit", 3: "PrepareAbort", 4: "CompleteCommit", 5: "CompleteAbort",
+	6: "Dead", 7: "PrepareEpochFence",
+}
+
+// transactionLogKey is the key of a transaction's state
+type transactionLogKey struct {
+	Version         int16  `json:"version"`
+	TransactionalID string `json:"transactionalId"`
+}
+
+// transactionLogValue is the state of a transaction. StartTimestamp is -1 if no transaction is in progress.
+type transactionLogValue struct {
+	Version             int16                      `json:"version"`
+	ProducerID          int64                      `json:"producerId"`
+	ProducerEpoch       int16                      `json:"producerEpoch"`
+	TransactionTimeout  int32                      `json:"transactionTimeoutMs"`
+	Status              string                     `json:"status"`
+	StatusCode          int8                       `json:"statusCode"`
+	Partitions          []transactionLogPartitions `json:"partitions"`
+	LastUpdateTimestamp int64                      `json:"lastUpdateTimestamp"`
+	StartTimestamp      int64                      `json:"startTimestamp"`
+}
+
+// transactionLogPartitions are the partitions of a topic which have been added to a transaction
+type transactionLogPartitions struct {
+	Topic        string  `json:"topic"`
+	PartitionIDs []int32 `json:"partitionIds"`
+}
+
+// decodeTransactionState accepts the keys and values of the __transaction_state topic. Records with unknown schema
+// versions are rejected, so that they are shown as binary.
+func decodeTransactionState(_ *deserializer, payload []byte, topicName string, recordType proto.RecordType) (*deserializedPayload, error) {
+	if topicName != transactionStateTopic {
+		return nil, fmt.Errorf("payload is not a record of the %v topic", transactionStateTopic)
+	}
+
+	var decoded interface{}
+	var err error
+	switch recordType {
+	case proto.RecordKey:
+		decoded, err = decodeTransactionLogKey(payload)
+	case proto.RecordValue:
+		decoded, err = decodeTransactionLogValue(payload)
+	default:
+		return nil, fmt.Errorf("only keys and values of the %v topic can be decoded", transactionStateTopic)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return newInternalRecordPayload(decoded, messageEncodingTransactionState)
+}
+
+func decodeTransactionLogKey(payload []byte) (*transactionLogKey, error) {
+	r := &internalRecordReader{buf: payload}
+	key := &transactionLogKey{Version: r.int16()}
+	if key.Version != transactionLogKeyVersion {
+		return nil, fmt.Errorf("unknown transaction log key version %d", key.Version)
+	}
+	key.TransactionalID = r.string(false)
+
+	return key, r.finish()
+}
+
+func decodeTransactionLogValue(payload []byte) (*transactionLogValue, error) {
+	r := &internalRecordReader{buf: payload}
+	value := &transactionLogValue{Version: r.int16()}
+	if value.Version < 0 || value.Version > transactionLogValueMaxVersion {
+		return nil, fmt.Errorf("unknown transaction log value version %d", value.Version)
+	}
+	flexible := value.Version >= transactionLogValueFlexibleVersion
+
+	value.ProducerID = r.int64()
+	value.ProducerEpoch = r.int16()
+	value.TransactionTimeout = r.int32()
+	value.StatusCode = r.int8()
+	value.Status = transactionStatusNames[value.StatusCode]
+	if value.Status == "" {
+		value.Status = transactionStatusUnknown
+	}
+
+	// The partitions are null if the transaction does not contain any partitions
+	topicCount := r.arrayLength(flexible)
+	value.Partitions = make([]transactionLogPartitions, 0, topicCount)
+	for i := 0; i < topicCount && r.err == nil; i++ {
+		partitions := transactionLogPartitions{Topic: r.string(flexible)}
+		partitionCount := r.arrayLength(flexible)
+		partitions.PartitionIDs = make([]int32, 0, partitionCount)
+		for j := 0; j < partitionCount && r.err == nil; j++ {
+			partitions.PartitionIDs = append(partitions.PartitionIDs, r.int32())
+		}
+		if flexible {
+			r.skipTaggedFields()
+		}
+		value.Partitions = append(value.Partitions, partitions)
+	}
+
+	value.LastUpdateTimestamp = r.int64()
+	value.StartTimestamp = r.int64()
+	if flexible {
+		r.skipTaggedFields()
+	}
+
+	return value, r.finish()
+}
@@ -0,0 +1,49 @@
+package kafka
+
+import (
+	"testing"
+
+	"github.com/cloudhut/kowl/backend/pkg/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeTransactionState(t *testing.T) {
+	d := &deserializer{}
+	key := (&internalRecordWriter{}).int16(0).string("payments-tx-1").buf
+	res := d.DeserializeRecordPayload(key, transactionStateTopic, proto.RecordKey)
+	assert.Equal(t, decoderTransactionState, res.Decoder)
+	assert.Equal(t, messageEncodingTransactionState, res.RecognizedEncoding)
+	assert.JSONEq(t, `{"version":0,"transactionalId":"payments-tx-1"}`, string(res.NormalizedPayload))
+
+	value := (&internalRecordWriter{}).int16(0).int64(4000).int16(3).int32(60000).int8(1).
+		int32(2).string("orders").int32(2).int32(0).int32(4).string("payments").int32(1).int32(1).
+		int64(1600000001000).int64(1600000000000).buf
+	res = d.DeserializeRecordPayload(value, transactionStateTopic, proto.RecordValue)
+	assert.Equal(t, decoderTransactionState, res.Decoder)
+	assert.JSONEq(t, `{"version":0,"producerId":4000,"producerEpoch":3,"transactionTimeoutMs":60000,"status":"Ongoing",
+		"statusCode":1,"partitions":[{"topic":"orders","partitionIds":[0,4]},{"topic":"payments","partitionIds":[1]}],
+		"lastUpdateTimestamp":1600000001000,"startTimestamp":1600000000000}`, string(res.NormalizedPayload))
+
+	// Completed transactions don't contain any partitions, version 1 uses compact arrays and tagged fields
+	value = (&internalRecordWriter{}).int16(1).int64(4000).int16(3).int32(60000).int8(4).raw(0).
+		int64(1600000002000).int64(-1).raw(0).buf
+	decoded, err := decodeTransactionLogValue(value)
+	require.NoError(t, err)
+	assert.Equal(t, "CompleteCommit", decoded.Status)
+	assert.Empty(t, decoded.Partitions)
+	assert.Equal(t, int64(-1), decoded.StartTimestamp)
+}
+
+func TestDecodeTransactionState_UnknownVersion(t *testing.T) {
+	d := &deserializer{}
+	value := (&internalRecordWriter{}).int16(7).int64(4000).buf
+
+	// Unknown schema versions are shown as raw bytes
+	res := d.DeserializeRecordPayload(value, transactionStateTopic, proto.RecordValue)
+	assert.Equal(t, decoderBinary, res.Decoder)
+	assert.Equal(t, messageEncodingBinary, res.RecognizedEncoding)
+
+	_, err := decodeTransactionState(d, value, "orders", proto.RecordValue)
+	assert.Error(t, err)
+}
@@ -0,0 +1,543 @@
+package kafka
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Types of the stages of a message transform pipeline
+const (
+	messageTransformMask         = "mask"
+	messageTransformRedact       = "redact"
+	messageTransformFlatten      = "flatten"
+	messageTransformExtractField = "extractField"
+)
+
+// messageTransformTypes are all accepted transform types in the order they are listed in error messages
+var messageTransformTypes = []string{messageTransformMask, messageTransformRedact, messageTransformFlatten,
+	messageTransformExtractField}
+
+// Modes of the redact transform
+const (
+	redactModeRemove = "remove"
+	redactModeMask   = "mask"
+	redactModeHash   = "hash"
+)
+
+// redactedValue replaces the values of fields which are redacted with mode mask
+const redactedValue = "***"
+
+// recursiveDescent is the path segment of ".." which selects the remaining path at any depth
+const recursiveDescent = ".."
+
+const (
+	// defaultTransformTimeout is the max duration of all transforms of a single payload unless configured otherwise
+	defaultTransformTimeout = 100 * time.Millisecond
+
+	// maxTransformSteps is the max number of visited values of all transforms of a single payload
+	maxTransformSteps = 1000000
+
+	// transformDeadlineCheckInterval is the number of steps after which the deadline is checked again
+	transformDeadlineCheckInterval = 1024
+
+	// maskedValue replaces all values which are not strings
+	maskedValue = "****"
+)
+
+// messageTransform is a stage of a topic's transform pipeline. It takes the decoded JSON value and returns the
+// transformed value, it may modify the given value in place.
+type messageTransform func(value interface{}, budget *transformBudget) (interface{}, error)
+
+// transformBudget bounds the work of the transforms of a single payload, because transforms run as part of the
+// request which consumes the messages
+type transformBudget struct {
+	deadline time.Time
+	steps    int
+}
+
+// step must be called for each visited value, it returns an error once the budget is exhausted
+func (b *transformBudget) step() error {
+	b.steps++
+	if b.steps > maxTransformSteps {
+		return fmt.Errorf("transforms exceeded the max number of %d visited values", maxTransformSteps)
+	}
+	if b.steps%transformDeadlineCheckInterval == 0 && time.Now().After(b.deadline) {
+		return fmt.Errorf("transforms exceeded the timeout")
+	}
+
+	return nil
+}
+
+// newMessageTransform creates the transform for the (unvalidated) stage config
+func newMessageTransform(cfg MessageTransformConfig) (messageTransform, error) {
+	switch cfg.Type {
+	case messageTransformMask, messageTransformRedact:
+		if len(cfg.Fields) == 0 {
+			return nil, fmt.Errorf("transform '%v' requires at least one field", cfg.Type)
+		}
+		if cfg.KeepLast < 0 {
+			return nil, fmt.Errorf("transform keepLast must not be negative")
+		}
+		paths := make([][]string, len(cfg.Fields))
+		for i, field := range cfg.Fields {
+			path, err := parseFieldPath(field)
+			if err != nil {
+				return nil, err
+			}
+			paths[i] = path
+		}
+		if cfg.Type == messageTransformRedact {
+			return newRedactTransform(paths, cfg.Mode, cfg.Salt)
+		}
+		return newMaskTransform(paths, cfg.KeepLast), nil
+	case messageTransformFlatten:
+		separator := cfg.Separator
+		if separator == "" {
+			separator = "."
+		}
+		return newFlattenTransform(separator), nil
+	case messageTransformExtractField:
+		path, err := parseFieldPath(cfg.Field)
+		if err != nil {
+			return nil, err
+		}
+		for _, segment := range path {
+			if segment == "*" || segment == recursiveDescent {
+				return nil, fmt.Errorf("transform extractField doesn't support wildcards")
+			}
+		}
+		return newExtractFieldTransform(path), nil
+	default:
+		return nil, fmt.Errorf("unknown transform type '%v', accepted values are: %v", cfg.Type, strings.Join(messageTransformTypes, ", "))
+	}
+}
+
+// newMessageTransforms creates the transform pipeline for the given stage configs
+func newMessageTransforms(cfgs []MessageTransformConfig) ([]messageTransform, error) {
+	transforms := make([]messageTransform, len(cfgs))
+	for i, cfg := range cfgs {
+		transform, err := newMessageTransform(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("transform at index %d is invalid: %w", i, err)
+		}
+		transforms[i] = transform
+	}
+
+	return transforms, nil
+}
+
+// parseFieldPath parses a dot separated field path (e.g. "customer.addresses.*.street") or a JSONPath-like selector
+// (e.g. "$.customer.addresses[*].street", "$..email" or "$['first name']"). The wildcard * selects all fields of an
+// object or all elements of an array, ".." selects the remaining path at any depth.
+func parseFieldPath(field string) ([]string, error) {
+	if field == "" {
+		return nil, fmt.Errorf("field path must not be empty")
+	}
+
+	path := make([]string, 0)
+	s := strings.TrimPrefix(field, "$")
+	for len(s) > 0 {
+		switch {
+		case strings.HasPrefix(s, recursiveDescent):
+			path = append(path, recursiveDescent)
+			s = s[len(recursiveDescent):]
+			if s == "" || s[0] == '.' {
+				return nil, fmt.Errorf("field path '%v' must specify a field after '..'", field)
+			}
+		case s[0] == '.':
+			s = s[1:]
+			if s == "" || s[0] == '[' {
+				return nil, fmt.Errorf("field path '%v' contains an empty segment", field)
+			}
+		case s[0] == '[':
+			end := strings.Index(s, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("field path '%v' contains an unterminated bracket", field)
+			}
+			segment, err := parseBracketSegment(s[1:end])
+			if err != nil {
+				return nil, fmt.Errorf("field path '%v' is invalid: %w", field, err)
+			}
+			path = append(path, segment)
+			s = s[end+1:]
+		default:
+			end := strings.IndexAny(s, ".[")
+			if end < 0 {
+				end = len(s)
+			}
+			path = append(path, s[:end])
+			s = s[end:]
+		}
+	}
+	if len(path) == 0 {
+		return nil, fmt.Errorf("field path '%v' does not select any field", field)
+	}
+
+	return path, nil
+}
+
+// parseBracketSegment parses the content of a bracket segment, which is a wildcard, an array index or a quoted name
+func parseBracketSegment(content string) (string, error) {
+	if content == "*" {
+		return content, nil
+	}
+	if _, err := strconv.Atoi(content); err == nil {
+		return content, nil
+	}
+	if len(content) >= 2 && (content[0] == '\'' || content[0] == '"') && content[len(content)-1] == content[0] {
+		return content[1 : len(content)-1], nil
+	}
+
+	return "", fmt.Errorf("bracket segment '[%v]' must be *, an array index or a quoted name", content)
+}
+
+// newMaskTransform replaces the characters of the selected string fields with asterisks, except for the last keepLast
+// characters. All other values (except null) are replaced with a fixed mask, so that their length is not revealed.
+func newMaskTransform(paths [][]string, keepLast int) messageTransform {
+	mask := func(value interface{}) (interface{}, bool) {
+		switch v := value.(type) {
+		case nil:
+			return nil, true
+		case string:
+			runes := []rune(v)
+			visible := keepLast
+			if visible > len(runes) {
+				visible = len(runes)
+			}
+			return strings.Repeat("*", len(runes)-visible) + string(runes[len(runes)-visible:]), true
+		default:
+			return maskedValue, true
+		}
+	}
+
+	return func(value interface{}, budget *transformBudget) (interface{}, error) {
+		for _, path := range paths {
+			err := updateFieldPath(value, path, budget, mask)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return value, nil
+	}
+}
+
+// newRedactTransform removes the selected fields (selected array elements are replaced with null), replaces their
+// values with *** or, in mode hash, with the HMAC-SHA256 of the value keyed by the salt. Hashes allow to correlate
+// messages with the same value without revealing it. The salt is required in mode hash, because plain hashes of
+// guessable values (e.g. email addresses) could be reversed with a dictionary.
+func newRedactTransform(paths [][]string, mode string, salt string) (messageTransform, error) {
+	var redact func(interface{}) (interface{}, bool)
+	switch mode {
+	case "", redactModeRemove:
+		redact = func(interface{}) (interface{}, bool) {
+			return nil, false
+		}
+	case redactModeMask:
+		redact = func(interface{}) (interface{}, bool) {
+			return redactedValue, true
+		}
+	case redactModeHash:
+		if salt == "" {
+			return nil, fmt.Errorf("redact mode hash requires a salt")
+		}
+		redact = func(value interface{}) (interface{}, bool) {
+			return hashValue(value, salt), true
+		}
+	default:
+		return nil, fmt.Errorf("unknown redact mode '%v', accepted values are: %v", mode,
+			strings.Join([]string{redactModeRemove, redactModeMask, redactModeHash}, ", "))
+	}
+	if salt != "" && mode != redactModeHash {
+		return nil, fmt.Errorf("a salt can only be used with redact mode hash")
+	}
+
+	return func(value interface{}, budget *transformBudget) (interface{}, error) {
+		for _, path := range paths {
+			err := updateFieldPath(value, path, budget, redact)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return value, nil
+	}, nil
+}
+
+// hashValue returns the hex encoded HMAC-SHA256 of the value keyed by the salt. Strings are hashed as they are, so
+// that the hash can be compared with the hash of the plain value, all other values are hashed in their JSON encoding.
+func hashValue(value interface{}, salt string) string {
+	encoded, isString := value.(string)
+	if !isString {
+		b, _ := json.Marshal(value)
+		encoded = string(b)
+	}
+	mac := hmac.New(sha256.New, []byte(salt))
+	mac.Write([]byte(encoded))
+
+	return "hmac-sha256:" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// newFlattenTransform flattens nested objects into a single object whose keys are the joined keys of the nested
+// fields, e.g. {"a": {"b": 1}} becomes {"a.b": 1}. Arrays are kept as they are.
+func newFlattenTransform(separator string) messageTransform {
+	var flatten func(prefix string, value interface{}, res map[string]interface{}, budget *transformBudget) error
+	flatten = func(prefix string, value interface{}, res map[string]interface{}, budget *transformBudget) error {
+		err := budget.step()
+		if err != nil {
+			return err
+		}
+		obj, isObject := value.(map[string]interface{})
+		if !isObject || (len(obj) == 0 && prefix != "") {
+			res[prefix] = value
+			return nil
+		}
+		for key, child := range obj {
+			if prefix != "" {
+				key = prefix + separator + key
+			}
+			err := flatten(key, child, res, budget)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return func(value interface{}, budget *transformBudget) (interface{}, error) {
+		if _, isObject := value.(map[string]interface{}); !isObject {
+			return value, nil
+		}
+		res := make(map[string]interface{})
+		err := flatten("", value, res, budget)
+		if err != nil {
+			return nil, err
+		}
+		return res, nil
+	}
+}
+
+// newExtractFieldTransform replaces the value with the value of the selected field. Array elements are selected by
+// their index.
+func newExtractFieldTransform(path []string) messageTransform {
+	return func(value interface{}, budget *transformBudget) (interface{}, error) {
+		for _, segment := range path {
+			err := budget.step()
+			if err != nil {
+				return nil, err
+			}
+			switch v := value.(type) {
+			case map[string]interface{}:
+				child, exists := v[segment]
+				if !exists {
+					return nil, fmt.Errorf("field '%v' does not exist", strings.Join(path, "."))
+				}
+				value = child
+			case []interface{}:
+				index, err := strconv.Atoi(segment)
+				if err != nil || index < 0 || index >= len(v) {
+					return nil, fmt.Errorf("field '%v' does not exist", strings.Join(path, "."))
+				}
+				value = v[index]
+			default:
+				return nil, fmt.Errorf("field '%v' does not exist", strings.Join(path, "."))
+			}
+		}
+		return value, nil
+	}
+}
+
+// updateFieldPath calls update for all values which are selected by the path and replaces them with the returned
+// value. The field is removed (or set to null if it's an array element) if update returns false. Arrays which are
+// not addressed by an index or wildcard are traversed implicitly, so that "items.price" selects the price of all items.
+func updateFieldPath(value interface{}, path []string, budget *transformBudget, update func(interface{}) (interface{}, bool)) error {
+	err := budget.step()
+	if err != nil {
+		return err
+	}
+	if len(path) == 0 {
+		return nil
+	}
+	segment, isLast := path[0], len(path) == 1
+
+	// The remaining path is selected at the current value and at all of its descendants. Arrays are only selected by
+	// wildcards and indexes, they are not traversed implicitly because their elements are visited as descendants.
+	if segment == recursiveDescent {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			err := updateFieldPath(value, path[1:], budget, update)
+			if err != nil {
+				return err
+			}
+			for _, child := range v {
+				err := updateFieldPath(child, path, budget, update)
+				if err != nil {
+					return err
+				}
+			}
+		case []interface{}:
+			if _, indexErr := strconv.Atoi(path[1]); indexErr == nil || path[1] == "*" {
+				err := updateFieldPath(value, path[1:], budget, update)
+				if err != nil {
+					return err
+				}
+			}
+			for _, element := range v {
+				err := updateFieldPath(element, path, budget, update)
+				if err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		keys := []string{segment}
+		if segment == "*" {
+			keys = make([]string, 0, len(v))
+			for key := range v {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+		}
+		for _, key := range keys {
+			child, exists := v[key]
+			if !exists {
+				continue
+			}
+			if !isLast {
+				err := updateFieldPath(child, path[1:], budget, update)
+				if err != nil {
+					return err
+				}
+				continue
+			}
+			updated, keep := update(child)
+			if keep {
+				v[key] = updated
+			} else {
+				delete(v, key)
+			}
+		}
+	case []interface{}:
+		indexes := make([]int, 0, len(v))
+		index, indexErr := strconv.Atoi(segment)
+		switch {
+		case segment == "*":
+			for i := range v {
+				indexes = append(indexes, i)
+			}
+		case indexErr == nil:
+			if index >= 0 && index < len(v) {
+				indexes = append(indexes, index)
+			}
+		default:
+			// The segment addresses the fields of the elements
+			for _, element := range v {
+				err := updateFieldPath(element, path, budget, update)
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		for _, i := range indexes {
+			if !isLast {
+				err := updateFieldPath(v[i], path[1:], budget, update)
+				if err != nil {
+					return err
+				}
+				continue
+			}
+			updated, keep := update(v[i])
+			if !keep {
+				updated = nil
+			}
+			v[i] = updated
+		}
+	}
+
+	return nil
+}
+
+// runMessageTransforms decodes the normalized JSON payload and passes it through all transforms. A panicking
+// transform is turned into an error, so that it can never fail the request which consumes the message.
+func runMessageTransforms(payload []byte, transforms []messageTransform, timeout time.Duration) (res interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			res = nil
+			err = fmt.Errorf("transform panicked: %v", r)
+		}
+	}()
+
+	// Numbers are decoded as json.Number, so that large integers are passed on without losing precision
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+	var value interface{}
+	err = decoder.Decode(&value)
+	if err != nil {
+		return nil, fmt.Errorf("payload is not valid JSON: %w", err)
+	}
+
+	budget := &transformBudget{deadline: time.Now().Add(timeout)}
+	for _, transform := range transforms {
+		value, err = transform(value, budget)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if time.Now().After(budget.deadline) {
+		return nil, fmt.Errorf("transforms exceeded the timeout")
+	}
+
+	return value, nil
+}
+
+// applyMessageTransforms replaces the decoded payload with the result of the transform pipeline. If a transform fails
+// or the payload can not be transformed because it's not decoded as JSON (text and binary), the payload is withheld
+// (shown as null along with the error), so that a failed mask never reveals the fields it should have masked.
+func (d *deserializer) applyMessageTransforms(res *deserializedPayload, transforms []messageTransform) {
+	var err error
+	switch res.RecognizedEncoding {
+	case messageEncodingNone, messageEncodingNull:
+		return
+	case messageEncodingText, messageEncodingBinary:
+		err = fmt.Errorf("payloads of encoding %v can not be transformed", res.RecognizedEncoding)
+	}
+
+	timeout := d.TransformTimeout
+	if timeout <= 0 {
+		timeout = defaultTransformTimeout
+	}
+	var normalized []byte
+	if err == nil {
+		var value interface{}
+		value, err = runMessageTransforms(res.NormalizedPayload, transforms, timeout)
+		if err == nil {
+			normalized, err = json.Marshal(value)
+		}
+	}
+	if err != nil {
+		// The withheld payload is marshalled as JSON null regardless of its original encoding
+		res.RecognizedEncoding = messageEncodingJSON
+		res.Object = nil
+		res.NormalizedPayload = []byte("null")
+		res.TransformError = err.Error()
+		return
+	}
+
+	// The object is decoded like the payloads of the JSON decoder, so that the filter code sees numbers instead of
+	// json.Number strings
+	var obj interface{}
+	_ = json.Unmarshal(normalized, &obj)
+	res.Object = obj
+	res.NormalizedPayload = normalized
+}
@@ -0,0 +1,210 @@
+package kafka
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/cloudhut/kowl/backend/pkg/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessageTransforms(t *testing.T) {
+	payload := `{"id":12345678901234567890,"customer":{"name":"Jane","email":"jane@example.com","age":42},` +
+		`"items":[{"sku":"a","price":1},{"sku":"b","price":2}],"tags":["x","y"]}`
+
+	tt := []struct {
+		name     string
+		cfgs     []MessageTransformConfig
+		expected string
+	}{
+		{
+			"mask",
+			[]MessageTransformConfig{{Type: messageTransformMask, Fields: []string{"customer.email", "customer.age"}, KeepLast: 4}},
+			`{"id":12345678901234567890,"customer":{"name":"Jane","email":"************.com","age":"****"},` +
+				`"items":[{"sku":"a","price":1},{"sku":"b","price":2}],"tags":["x","y"]}`,
+		},
+		{
+			"redact with implicit array traversal and wildcard",
+			[]MessageTransformConfig{{Type: messageTransformRedact, Fields: []string{"items.price", "customer.*", "tags.0"}}},
+			`{"id":12345678901234567890,"customer":{},"items":[{"sku":"a"},{"sku":"b"}],"tags":[null,"y"]}`,
+		},
+		{
+			"redact with JSONPath selectors",
+			[]MessageTransformConfig{{Type: messageTransformRedact, Fields: []string{"$..email", "$.items[*].price", "$['tags'][1]"}}},
+			`{"id":12345678901234567890,"customer":{"name":"Jane","age":42},"items":[{"sku":"a"},{"sku":"b"}],"tags":["x",null]}`,
+		},
+		{
+			"redact array elements at any depth",
+			[]MessageTransformConfig{{Type: messageTransformRedact, Fields: []string{"$..[0]"}}},
+			`{"id":12345678901234567890,"customer":{"name":"Jane","email":"jane@example.com","age":42},` +
+				`"items":[null,{"sku":"b","price":2}],"tags":[null,"y"]}`,
+		},
+		{
+			"redact mode mask",
+			[]MessageTransformConfig{{Type: messageTransformRedact, Fields: []string{"customer.email", "customer.age"}, Mode: redactModeMask}},
+			`{"id":12345678901234567890,"customer":{"name":"Jane","email":"***","age":"***"},` +
+				`"items":[{"sku":"a","price":1},{"sku":"b","price":2}],"tags":["x","y"]}`,
+		},
+		{
+			"redact mode hash",
+			[]MessageTransformConfig{{Type: messageTransformRedact, Fields: []string{"$..name"}, Mode: redactModeHash, Salt: "s"}},
+			`{"id":12345678901234567890,"customer":{"name":"hmac-sha256:` + hmacHex("s", "Jane") + `","email":"jane@example.com","age":42},` +
+				`"items":[{"sku":"a","price":1},{"sku":"b","price":2}],"tags":["x","y"]}`,
+		},
+		{
+			"flatten",
+			[]MessageTransformConfig{{Type: messageTransformFlatten, Separator: "_"}},
+			`{"id":12345678901234567890,"customer_name":"Jane","customer_email":"jane@example.com","customer_age":42,` +
+				`"items":[{"sku":"a","price":1},{"sku":"b","price":2}],"tags":["x","y"]}`,
+		},
+		{
+			"pipeline",
+			[]MessageTransformConfig{
+				{Type: messageTransformExtractField, Field: "customer"},
+				{Type: messageTransformRedact, Fields: []string{"email"}},
+			},
+			`{"name":"Jane","age":42}`,
+		},
+		{
+			"extract array element",
+			[]MessageTransformConfig{{Type: messageTransformExtractField, Field: "items.1.sku"}},
+			`"b"`,
+		},
+	}
+	for _, table := range tt {
+		transforms, err := newMessageTransforms(table.cfgs)
+		require.NoError(t, err, table.name)
+		d := &deserializer{TopicChains: []topicDecoderChain{{TopicName: regexp.MustCompile("^orders$"), Transforms: transforms}}}
+
+		res := d.DeserializeRecordPayload([]byte(payload), "orders", proto.RecordValue)
+		assert.Empty(t, res.TransformError, table.name)
+		assert.JSONEq(t, table.expected, string(res.NormalizedPayload), table.name)
+	}
+}
+
+func TestMessageTransforms_Failures(t *testing.T) {
+	transforms, err := newMessageTransforms([]MessageTransformConfig{{Type: messageTransformExtractField, Field: "missing"}})
+	require.NoError(t, err)
+	panicking := func(interface{}, *transformBudget) (interface{}, error) {
+		panic("broken transform")
+	}
+	d := &deserializer{TopicChains: []topicDecoderChain{
+		{TopicName: regexp.MustCompile("^missing$"), Transforms: transforms},
+		{TopicName: regexp.MustCompile("^panic$"), Transforms: []messageTransform{panicking}},
+	}}
+
+	// Failed transforms withhold the payload
+	for _, topic := range []string{"missing", "panic"} {
+		res := d.DeserializeRecordPayload([]byte(`{"email":"jane@example.com"}`), topic, proto.RecordValue)
+		assert.NotEmpty(t, res.TransformError, topic)
+		assert.Equal(t, "null", string(res.NormalizedPayload), topic)
+		assert.Nil(t, res.Object, topic)
+	}
+
+	// Text can not be transformed, hence it's withheld as well
+	res := d.DeserializeRecordPayload([]byte("hello"), "missing", proto.RecordValue)
+	assert.NotEmpty(t, res.TransformError)
+	assert.Equal(t, "null", string(res.NormalizedPayload))
+	assert.Nil(t, res.Object)
+
+	// The budget is exhausted by large payloads or once the deadline has passed
+	budget := &transformBudget{deadline: time.Now().Add(-time.Second)}
+	var stepErr error
+	for i := 0; i < transformDeadlineCheckInterval && stepErr == nil; i++ {
+		stepErr = budget.step()
+	}
+	assert.Error(t, stepErr)
+}
+
+func TestNewMessageTransform_Validate(t *testing.T) {
+	invalid := []MessageTransformConfig{
+		{Type: "unknown"},
+		{Type: messageTransformMask},
+		{Type: messageTransformRedact, Fields: []string{"a."}},
+		{Type: messageTransformRedact, Fields: []string{"$.a["}},
+		{Type: messageTransformRedact, Fields: []string{"$[a]"}},
+		{Type: messageTransformRedact, Fields: []string{"a..."}},
+		{Type: messageTransformRedact, Fields: []string{"a"}, Mode: "unknown"},
+		{Type: messageTransformRedact, Fields: []string{"a"}, Salt: "s"},
+		{Type: messageTransformRedact, Fields: []string{"a"}, Mode: redactModeHash},
+		{Type: messageTransformMask, Fields: []string{"a"}, KeepLast: -1},
+		{Type: messageTransformExtractField},
+		{Type: messageTransformExtractField, Field: "items.*"},
+		{Type: messageTransformExtractField, Field: "$..items"},
+	}
+	for _, cfg := range invalid {
+		_, err := newMessageTransform(cfg)
+		assert.Error(t, err, cfg.Type)
+	}
+}
+
+func TestParseFieldPath(t *testing.T) {
+	tt := map[string][]string{
+		"customer.addresses.*.street":    {"customer", "addresses", "*", "street"},
+		"$.customer.addresses[*].street": {"customer", "addresses", "*", "street"},
+		"$..email":                       {recursiveDescent, "email"},
+		"$['first name'][0]":             {"first name", "0"},
+		`$.customer["e.mail"]`:           {"customer", "e.mail"},
+		"items.0.sku":                    {"items", "0", "sku"},
+	}
+	for field, expected := range tt {
+		path, err := parseFieldPath(field)
+		require.NoError(t, err, field)
+		assert.Equal(t, expected, path, field)
+	}
+}
+
+func TestMessageTransforms_RedactedFieldsAreAbsentFromResponses(t *testing.T) {
+	cfg := DeserializationConfig{Topics: []DeserializationTopicConfig{{
+		TopicName:     "^customers$",
+		Transforms:    []MessageTransformConfig{{Type: messageTransformRedact, Fields: []string{"$..email"}, Mode: redactModeHash, Salt: "secret"}},
+		KeyTransforms: []MessageTransformConfig{{Type: messageTransformRedact, Fields: []string{"$.email"}, Mode: redactModeMask}},
+	}}}
+	cfg.SetDefaults()
+	require.NoError(t, cfg.Validate())
+	d, err := newDeserializer(cfg, nil, nil)
+	require.NoError(t, err)
+	svc := &Service{Deserializer: *d}
+
+	m := &sarama.ConsumerMessage{
+		Topic: "customers",
+		Key:   []byte(`{"email":"jane@example.com"}`),
+		Value: []byte(`{"name":"Jane","contacts":[{"email":"jane@example.com"}]}`),
+	}
+	topicMessage, _ := newTopicMessage(m, d, false)
+	for name, response := range map[string]interface{}{
+		"list messages":   topicMessage,
+		"export messages": svc.newExportedMessage(m, false),
+	} {
+		b, err := json.Marshal(response)
+		require.NoError(t, err, name)
+		assert.NotContains(t, string(b), "jane@example.com", name)
+	}
+	assert.JSONEq(t, `{"email":"***"}`, string(topicMessage.Key.NormalizedPayload))
+	assert.JSONEq(t, `{"name":"Jane","contacts":[{"email":"hmac-sha256:`+hmacHex("secret", "jane@example.com")+`"}]}`,
+		string(topicMessage.Value.NormalizedPayload))
+}
+
+func TestDeserializationConfig_Validate_KeepEnvelopeWithTransforms(t *testing.T) {
+	cfg := DeserializationConfig{Topics: []DeserializationTopicConfig{{
+		TopicName:     "cdc",
+		Transform:     transformDebeziumUnwrap,
+		KeepEnvelope:  true,
+		KeyTransforms: []MessageTransformConfig{{Type: messageTransformRedact, Fields: []string{"id"}}},
+	}}}
+	cfg.SetDefaults()
+	assert.Error(t, cfg.Validate(), "the kept envelope would reveal redacted fields")
+}
+
+func hmacHex(key string, s string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(s))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,159 @@
+package kafka
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/cloudhut/kowl/backend/pkg/proto"
+)
+
+// Prefixes of the JSON keys which represent attributes and the text content of XML elements
+const (
+	xmlAttributePrefix = "@"
+	xmlTextKey         = "#text"
+)
+
+// xmlElement is an element whose children and text segments are collected while the document is parsed
+type xmlElement struct {
+	name     string
+	attrs    []xml.Attr
+	children map[string][]interface{}
+	text     []string
+}
+
+// decodeXML converts a well-formed XML document into a JSON representation. Elements become objects which are keyed by
+// the element name, attributes are prefixed with '@' and the text content is stored as '#text'. Elements without
+// attributes and children are represented by their text only. Qualified names keep their namespace prefix (e.g.
+// "soap:Envelope"), because the prefix is what users see in the original document.
+func decodeXML(_ *deserializer, payload []byte, _ string, _ proto.RecordType) (*deserializedPayload, error) {
+	trimmed := bytes.TrimLeft(payload, " \t\r\n")
+	if len(trimmed) == 0 || trimmed[0] != '<' {
+		return nil, fmt.Errorf("payload is not XML")
+	}
+
+	obj, err := xmlToObject(trimmed)
+	if err != nil {
+		return nil, err
+	}
+	normalized, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	return &deserializedPayload{NormalizedPayload: normalized, Object: obj, RecognizedEncoding: messageEncodingXML,
+		ContentType: "application/xml"}, nil
+}
+
+// xmlToObject parses the document and returns its root element as map. It returns an error if the document is not
+// well-formed, that is if tags do not match or if there is not exactly one root element.
+func xmlToObject(payload []byte) (map[string]interface{}, error) {
+	dec := xml.NewDecoder(bytes.NewReader(payload))
+	dec.Strict = true
+
+	var root map[string]interface{}
+	stack := make([]*xmlElement, 0)
+	for {
+		// RawToken is used so that the namespace prefixes are kept, hence the matching tags are checked here
+		token, err := dec.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("payload is not well-formed XML: %w", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if root != nil {
+				return nil, fmt.Errorf("payload is not well-formed XML: more than one root element")
+			}
+			seenAttrs := make(map[string]bool, len(t.Attr))
+			for _, attr := range t.Attr {
+				if seenAttrs[xmlQualifiedName(attr.Name)] {
+					return nil, fmt.Errorf("payload is not well-formed XML: duplicate attribute '%v'", xmlQualifiedName(attr.Name))
+				}
+				seenAttrs[xmlQualifiedName(attr.Name)] = true
+			}
+			stack = append(stack, &xmlElement{name: xmlQualifiedName(t.Name), attrs: t.Attr, children: make(map[string][]interface{})})
+		case xml.EndElement:
+			if len(stack) == 0 || stack[len(stack)-1].name != xmlQualifiedName(t.Name) {
+				return nil, fmt.Errorf("payload is not well-formed XML: unexpected end element '%v'", xmlQualifiedName(t.Name))
+			}
+			element := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if len(stack) == 0 {
+				root = map[string]interface{}{element.name: element.value()}
+				continue
+			}
+			parent := stack[len(stack)-1]
+			parent.children[element.name] = append(parent.children[element.name], element.value())
+		case xml.CharData:
+			text := strings.TrimSpace(string(t))
+			if text == "" {
+				continue
+			}
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("payload is not well-formed XML: text outside of the root element")
+			}
+			// Mixed content is kept as list of text segments
+			current := stack[len(stack)-1]
+			current.text = append(current.text, text)
+		}
+	}
+
+	if len(stack) > 0 {
+		return nil, fmt.Errorf("payload is not well-formed XML: element '%v' is not closed", stack[len(stack)-1].name)
+	}
+	if root == nil {
+		return nil, fmt.Errorf("payload is not well-formed XML: no root element")
+	}
+
+	return root, nil
+}
+
+// value returns the JSON representation of the element. Repeated child elements are represented as array.
+func (e *xmlElement) value() interface{} {
+	if len(e.attrs) == 0 && len(e.children) == 0 {
+		return e.textValue()
+	}
+
+	obj := make(map[string]interface{}, len(e.attrs)+len(e.children)+1)
+	for _, attr := range e.attrs {
+		obj[xmlAttributePrefix+xmlQualifiedName(attr.Name)] = attr.Value
+	}
+	for name, children := range e.children {
+		if len(children) == 1 {
+			obj[name] = children[0]
+		} else {
+			obj[name] = children
+		}
+	}
+	if len(e.text) > 0 {
+		obj[xmlTextKey] = e.textValue()
+	}
+
+	return obj
+}
+
+// textValue returns the text of the element. The text segments of elements with mixed content are returned as list.
+func (e *xmlElement) textValue() interface{} {
+	switch len(e.text) {
+	case 0:
+		return ""
+	case 1:
+		return e.text[0]
+	default:
+		return e.text
+	}
+}
+
+func xmlQualifiedName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+	return name.Space + ":" + name.Local
+}
@@ -0,0 +1,52 @@
+package kafka
+
+import (
+	"testing"
+
+	"github.com/cloudhut/kowl/backend/pkg/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeXML(t *testing.T) {
+	tt := []struct {
+		name     string
+		payload  string
+		expected string
+	}{
+		{"text only", `<name>kowl</name>`, `{"name":"kowl"}`},
+		{"attributes and text", `<price currency="EUR">10</price>`, `{"price":{"@currency":"EUR","#text":"10"}}`},
+		{"repeated children", `<order><item>a</item><item>b</item><id>1</id></order>`, `{"order":{"item":["a","b"],"id":"1"}}`},
+		{"mixed content", `<p>Hello <b>world</b> !</p>`, `{"p":{"b":"world","#text":["Hello","!"]}}`},
+		{
+			"namespaces",
+			`<?xml version="1.0"?><soap:Envelope xmlns:soap="http://www.w3.org/2003/05/soap-envelope"><soap:Body/></soap:Envelope>`,
+			`{"soap:Envelope":{"@xmlns:soap":"http://www.w3.org/2003/05/soap-envelope","soap:Body":""}}`,
+		},
+	}
+	for _, table := range tt {
+		res, err := decodeXML(nil, []byte(table.payload), "", proto.RecordValue)
+		require.NoError(t, err, table.name)
+		assert.JSONEq(t, table.expected, string(res.NormalizedPayload), table.name)
+	}
+}
+
+func TestDecodeXML_NotWellFormed(t *testing.T) {
+	payloads := []string{
+		`<a><b></a></b>`,
+		`<a>`,
+		`<a></a><b></b>`,
+		`<a></a> trailing`,
+		`<a x="1" x="2"></a>`,
+	}
+	for _, payload := range payloads {
+		_, err := decodeXML(nil, []byte(payload), "", proto.RecordValue)
+		assert.Error(t, err, payload)
+	}
+
+	// Payloads which are not well-formed are shown as raw text, even if the topic's chain only contains the xml decoder
+	d := &deserializer{DefaultChain: []string{decoderXML}}
+	res := d.DeserializePayload([]byte(`<a><b></a>`))
+	assert.Equal(t, messageEncodingText, res.RecognizedEncoding)
+	assert.Equal(t, `<a><b></a>`, res.Object)
+}
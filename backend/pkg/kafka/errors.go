@@ -0,0 +1,173 @@
+package kafka
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrTLSConfig is returned if the TLS configuration is invalid or if the TLS material (CA, certificates, keys) could
+// not be loaded.
+type ErrTLSConfig struct {
+	Err error
+}
+
+func (e *ErrTLSConfig) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ErrTLSConfig) Unwrap() error {
+	return e.Err
+}
+
+// ErrSASLConfig is returned if the SASL configuration is invalid or the SASL mechanism could not be set up.
+type ErrSASLConfig struct {
+	Err error
+}
+
+func (e *ErrSASLConfig) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ErrSASLConfig) Unwrap() error {
+	return e.Err
+}
+
+// ErrKafkaVersion is returned if the configured Kafka cluster version can not be parsed.
+type ErrKafkaVersion struct {
+	Err error
+}
+
+func (e *ErrKafkaVersion) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ErrKafkaVersion) Unwrap() error {
+	return e.Err
+}
+
+// ValidationErrors is returned by the config validation if more than one check has failed
+type ValidationErrors []error
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Is reports whether any of the contained errors matches the target, so that errors.Is checks each of them
+func (e ValidationErrors) Is(target error) bool {
+	for _, err := range e {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first contained error which matches the target, so that errors.As checks each of them
+func (e ValidationErrors) As(target interface{}) bool {
+	for _, err := range e {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// Add appends the error unless it is nil. The problems of nested ValidationErrors are appended one by one.
+func (e *ValidationErrors) Add(err error) {
+	if nested, ok := err.(ValidationErrors); ok {
+		*e = append(*e, nested...)
+		return
+	}
+	if err != nil {
+		*e = append(*e, err)
+	}
+}
+
+// ErrOrNil returns nil if there are no errors and the error itself if there is exactly one error, so that single
+// errors keep their type and message.
+func (e ValidationErrors) ErrOrNil() error {
+	switch len(e) {
+	case 0:
+		return nil
+	case 1:
+		return e[0]
+	default:
+		return e
+	}
+}
+
+// ErrTopicNotFound is returned if an operation targets a topic which does not exist in the cluster
+var ErrTopicNotFound = errors.New("topic does not exist")
+
+// ErrBrokerNotFound is returned if an operation targets a broker which is not part of the cluster metadata
+var ErrBrokerNotFound = errors.New("broker does not exist")
+
+// ErrConsumerGroupActive is returned if the offsets of a consumer group which still has members shall be reset or
+// overwritten
+var ErrConsumerGroupActive = errors.New("consumer group has active members")
+
+// ErrNoCommittedOffsets is returned if offsets shall be copied from a consumer group which has not committed any
+// offsets for the requested topics
+var ErrNoCommittedOffsets = errors.New("consumer group has no committed offsets")
+
+// ErrConsumerGroupNotFound is returned if an operation targets a consumer group which does not exist
+var ErrConsumerGroupNotFound = errors.New("consumer group does not exist")
+
+// ErrInvalidOffset is returned if a requested offset is not valid for the targeted partition
+var ErrInvalidOffset = errors.New("invalid offset")
+
+// ErrACLsNotSupported is returned if the cluster does not support describing ACLs, either because the brokers are too
+// old or because no authorizer has been configured.
+var ErrACLsNotSupported = errors.New("ACLs are not supported by the cluster")
+
+// ErrInvalidACL is returned if an ACL binding or filter can not be handled by the cluster, e.g. because it uses a
+// resource pattern type which has been introduced in a newer Kafka version
+var ErrInvalidACL = errors.New("invalid ACL")
+
+// ErrTopicAlreadyExists is returned if a topic shall be created which already exists
+var ErrTopicAlreadyExists = errors.New("topic already exists")
+
+// errTopicCreatedWithoutMetadata is returned if a topic has been created, but its metadata could not be fetched
+var errTopicCreatedWithoutMetadata = errors.New("topic has been created, but its metadata is not available")
+
+// ErrInvalidTopicSpec is returned if the partitions or replicas of a topic which shall be created are invalid
+var ErrInvalidTopicSpec = errors.New("invalid topic spec")
+
+// ErrInvalidPartitionCount is returned if the partitions of a topic shall be decreased or the replica assignment of the
+// new partitions is invalid
+var ErrInvalidPartitionCount = errors.New("invalid partition count")
+
+// ErrInvalidReassignment is returned if a partition reassignment is invalid or conflicts with an ongoing reassignment
+var ErrInvalidReassignment = errors.New("invalid partition reassignment")
+
+// ErrPartitionReassignmentsNotSupported is returned if the brokers do not support the partition reassignment APIs,
+// which have been added in Kafka 2.4.
+var ErrPartitionReassignmentsNotSupported = errors.New("partition reassignments are not supported by the cluster")
+
+// ErrInvalidPartition is returned if an operation targets a partition which does not exist
+var ErrInvalidPartition = errors.New("invalid partition")
+
+// ErrClientQuotasNotSupported is returned if the brokers do not support the client quota APIs, which have been added
+// in Kafka 2.6
+var ErrClientQuotasNotSupported = errors.New("client quotas are not supported by the cluster")
+
+// ErrInvalidQuota is returned if a quota entity or value is invalid
+var ErrInvalidQuota = errors.New("invalid client quota")
+
+// ErrRawExportOfTransformedTopic is returned if the messages of a topic with a transform pipeline shall be exported
+// raw, which would bypass the transforms (e.g. masks)
+var ErrRawExportOfTransformedTopic = errors.New("raw exports are not available for topics with message transforms")
+
+// ErrRawFilterOfTransformedTopic is returned if the messages of a topic with a transform pipeline shall be filtered by
+// their raw value or headers, which could be used to probe the values of redacted fields
+var ErrRawFilterOfTransformedTopic = errors.New("filters on raw values and headers are not available for topics with message transforms")
+
+// ErrInvalidCompression is returned if a compression codec is unknown or not supported by the cluster version
+var ErrInvalidCompression = errors.New("invalid compression")
+
+// ErrShuttingDown is returned if a consume or produce operation is started while Kowl is shutting down
+var ErrShuttingDown = errors.New("kowl is shutting down")
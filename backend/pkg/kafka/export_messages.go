@@ -0,0 +1,230 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Shopify/sarama"
+)
+
+// ExportMessagesRequest describes the range of messages which shall be exported. Offsets and timestamps may be
+// combined, the export starts at the later and ends at the earlier bound of each partition.
+type ExportMessagesRequest struct {
+	TopicName string
+
+	// PartitionIDs are the exported partitions, all partitions are exported if it is empty
+	PartitionIDs []int32
+
+	// StartOffset and EndOffset are inclusive and apply to each partition. Negative offsets export from the low
+	// watermark or up to the latest message respectively.
+	StartOffset int64
+	EndOffset   int64
+
+	// StartTime and EndTime are inclusive, zero values don't restrict the export
+	StartTime time.Time
+	EndTime   time.Time
+
+	// Limit is the maximum number of exported messages across all partitions
+	Limit int
+
+	// Raw exports the keys, values and headers as binary instead of decoding them with the topic's decoder chain
+	Raw bool
+}
+
+// ExportedMessage is a message which has been consumed for an export
+type ExportedMessage struct {
+	PartitionID int32
+	Offset      int64
+	Timestamp   time.Time
+	Key         *deserializedPayload
+	Value       *deserializedPayload
+	Headers     []MessageHeader
+
+	// Size is the number of bytes of the key and value as they have been consumed
+	Size int
+
+	// IsTombstone is true if the value is null
+	IsTombstone bool
+}
+
+// errExportLimitReached stops fetching a partition once the export's limit has been reached
+var errExportLimitReached = errors.New("export limit reached")
+
+// exportRange is the resolved, inclusive offset range of a partition
+type exportRange struct {
+	partitionID int32
+	startOffset int64
+	endOffset   int64
+}
+
+// ExportMessages consumes the requested partitions one after another, each in offset order, and passes every message
+// to onMessage as soon as it has been consumed, so that exports don't need to be buffered. The export stops once the
+// limit has been reached, all partitions have been consumed up to their end offset or onMessage returns an error.
+func (s *Service) ExportMessages(ctx context.Context, req ExportMessagesRequest, onMessage func(m *ExportedMessage) error) (exported int, err error) {
+	defer s.observeOperation(ctx, operationTypeConsume, "export_messages", time.Now(), &err)
+	ctx, done, err := s.TrackOperation(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer done()
+	if req.Limit <= 0 {
+		return 0, fmt.Errorf("limit must be greater than 0")
+	}
+	if req.Raw && s.Deserializer.hasMessageTransforms(req.TopicName) {
+		return 0, ErrRawExportOfTransformedTopic
+	}
+
+	ranges, err := s.exportRanges(ctx, req)
+	if err != nil {
+		return 0, err
+	}
+	if len(ranges) == 0 {
+		return 0, nil
+	}
+
+	client, release, err := s.newFetchClient(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("couldn't create consumer client: %w", err)
+	}
+	defer release()
+
+	for _, r := range ranges {
+		n, err := s.exportPartition(ctx, client, req, r, req.Limit-exported, onMessage)
+		exported += n
+		if err != nil {
+			return exported, err
+		}
+		if exported >= req.Limit {
+			break
+		}
+	}
+
+	return exported, nil
+}
+
+// exportRanges resolves the offset range of each requested partition. Partitions without messages in the requested
+// range are omitted.
+func (s *Service) exportRanges(ctx context.Context, req ExportMessagesRequest) ([]exportRange, error) {
+	partitionIDs, err := s.SelectPartitions(req.TopicName, req.PartitionIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	marks, err := s.WaterMarks(ctx, req.TopicName, partitionIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get watermarks: %w", err)
+	}
+
+	var startOffsets, endOffsets map[int32]int64
+	if !req.StartTime.IsZero() {
+		startOffsets, err = s.OffsetsForTimestamp(ctx, req.TopicName, partitionIDs, req.StartTime)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve start offsets: %w", err)
+		}
+	}
+	if !req.EndTime.IsZero() {
+		// The first offset after the end time is excluded from the export
+		endOffsets, err = s.OffsetsForTimestamp(ctx, req.TopicName, partitionIDs, req.EndTime.Add(time.Millisecond))
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve end offsets: %w", err)
+		}
+	}
+
+	ranges := make([]exportRange, 0, len(partitionIDs))
+	for _, partitionID := range partitionIDs {
+		mark, exists := marks[partitionID]
+		if !exists {
+			return nil, fmt.Errorf("partition '%v' does not exist", partitionID)
+		}
+
+		startOffset := req.StartOffset
+		if startOffset < mark.Low {
+			startOffset = mark.Low
+		}
+		if startOffsets != nil {
+			offset, exists := startOffsets[partitionID]
+			if !exists {
+				continue // No messages at or after the start time
+			}
+			if offset > startOffset {
+				startOffset = offset
+			}
+		}
+
+		// mark.High - 1 is the last message which can actually be consumed
+		endOffset := req.EndOffset
+		if endOffset < 0 || endOffset > mark.High-1 {
+			endOffset = mark.High - 1
+		}
+		if offset, exists := endOffsets[partitionID]; exists && offset-1 < endOffset {
+			endOffset = offset - 1
+		}
+
+		if startOffset > endOffset {
+			continue
+		}
+		ranges = append(ranges, exportRange{partitionID: partitionID, startOffset: startOffset, endOffset: endOffset})
+	}
+
+	return ranges, nil
+}
+
+// exportPartition consumes the offset range of a single partition and returns the number of exported messages
+func (s *Service) exportPartition(ctx context.Context, client sarama.Client, req ExportMessagesRequest, r exportRange, limit int, onMessage func(m *ExportedMessage) error) (int, error) {
+	exported := 0
+	offsetRange := OffsetRange{PartitionID: r.partitionID, StartOffset: r.startOffset, EndOffset: r.endOffset + 1}
+	err := s.fetchOffsetRange(ctx, client, req.TopicName, offsetRange, false, func(record fetchedRecord) error {
+		if err := onMessage(s.newExportedMessage(record.Message, req.Raw)); err != nil {
+			return err
+		}
+		exported++
+		if exported >= limit {
+			return errExportLimitReached
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errExportLimitReached) {
+		return exported, err
+	}
+
+	return exported, nil
+}
+
+// newExportedMessage decodes the consumed message with the topic's decoder chain, or as binary if raw is true
+func (s *Service) newExportedMessage(m *sarama.ConsumerMessage, raw bool) *ExportedMessage {
+	exported := &ExportedMessage{
+		PartitionID: m.Partition,
+		Offset:      m.Offset,
+		Timestamp:   m.Timestamp,
+		Size:        len(m.Key) + len(m.Value),
+		IsTombstone: m.Value == nil,
+	}
+	if !raw {
+		topicMessage, _ := newTopicMessage(m, &s.Deserializer, true)
+		exported.Key = topicMessage.Key
+		exported.Value = topicMessage.Value
+		exported.Headers = topicMessage.Headers
+		return exported
+	}
+
+	exported.Key = rawPayload(m.Key)
+	exported.Value = rawPayload(m.Value)
+	exported.Headers = deserializeHeaders(&s.Deserializer, m.Topic, m.Headers, false)
+	return exported
+}
+
+// rawPayload returns the payload as binary, so that it's base64 encoded when it's marshalled
+func rawPayload(payload []byte) *deserializedPayload {
+	if payload == nil {
+		return &deserializedPayload{Object: "", RecognizedEncoding: messageEncodingNull}
+	}
+	if len(payload) == 0 {
+		return &deserializedPayload{NormalizedPayload: payload, Object: "", RecognizedEncoding: messageEncodingNone}
+	}
+
+	res, _ := decodeBinary(nil, payload, "", "")
+	res.Decoder = decoderBinary
+	return res
+}
@@ -0,0 +1,56 @@
+package kafka
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestNewExportedMessage(t *testing.T) {
+	svc := &Service{Logger: zap.NewNop()}
+	m := &sarama.ConsumerMessage{
+		Topic:     "orders",
+		Partition: 2,
+		Offset:    42,
+		Timestamp: time.Unix(1605882720, 0),
+		Key:       []byte("order-1"),
+		Value:     []byte(`{"amount":42}`),
+		Headers:   []*sarama.RecordHeader{{Key: []byte("trace-id"), Value: []byte("abc")}},
+	}
+
+	decoded := svc.newExportedMessage(m, false)
+	assert.Equal(t, int32(2), decoded.PartitionID)
+	assert.Equal(t, int64(42), decoded.Offset)
+	assert.Equal(t, len(m.Key)+len(m.Value), decoded.Size)
+	assert.Equal(t, "order-1", decoded.Key.String())
+	assert.Equal(t, `{"amount":42}`, decoded.Value.String())
+	assert.Equal(t, "abc", decoded.Headers[0].Value.String())
+
+	// Raw exports encode the payloads as base64 instead of decoding them
+	raw := svc.newExportedMessage(m, true)
+	assert.Equal(t, "b3JkZXItMQ==", raw.Key.String())
+	assert.Equal(t, "eyJhbW91bnQiOjQyfQ==", raw.Value.String())
+	assert.Equal(t, "YWJj", raw.Headers[0].Value.String())
+
+	// Empty and null payloads are empty strings, but only null payloads are null
+	m.Key = []byte{}
+	assert.Equal(t, "", svc.newExportedMessage(m, true).Key.String())
+	assert.False(t, svc.newExportedMessage(m, false).Key.IsNull())
+	m.Key = nil
+	assert.Equal(t, "", svc.newExportedMessage(m, true).Key.String())
+	assert.Equal(t, "", svc.newExportedMessage(m, false).Key.String())
+	assert.True(t, svc.newExportedMessage(m, true).Key.IsNull())
+	assert.True(t, svc.newExportedMessage(m, false).Key.IsNull())
+	assert.False(t, decoded.IsTombstone)
+
+	// Tombstones of keyed and unkeyed topics
+	m.Value = nil
+	for _, raw := range []bool{false, true} {
+		tombstone := svc.newExportedMessage(m, raw)
+		assert.True(t, tombstone.IsTombstone)
+		assert.True(t, tombstone.Value.IsNull())
+	}
+}
@@ -0,0 +1,77 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// IncreasePartitions increases the partition count of a topic to newCount and returns the resulting partition count.
+// The optional assignment contains the replica broker IDs for each new partition, if it is nil the brokers choose
+// the replicas. Partitions can not be decreased.
+func (s *Service) IncreasePartitions(ctx context.Context, topicName string, newCount int32, assignment [][]int32) (count int32, err error) {
+	defer s.observeOperation(ctx, operationTypeAdmin, "increase_partitions", time.Now(), &err)
+	defer s.InvalidateTopicMetadataCache()
+	exists, err := s.TopicExists(ctx, topicName)
+	if err != nil {
+		return 0, fmt.Errorf("failed to check whether the topic exists: %w", err)
+	}
+	if !exists {
+		return 0, ErrTopicNotFound
+	}
+
+	// The partitions are served from the client's metadata cache, which might be outdated
+	err = runWithContext(ctx, func() error {
+		return s.Client.RefreshMetadata(topicName)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to refresh topic metadata: %w", err)
+	}
+	partitionIDs, err := s.ListPartitions(topicName)
+	if err != nil {
+		return 0, err
+	}
+	replicas, err := s.Client.Replicas(topicName, 0)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get replicas of the topic: %w", err)
+	}
+
+	currentCount := int32(len(partitionIDs))
+	err = validatePartitionIncrease(currentCount, newCount, assignment, len(replicas), s.brokerIDs())
+	if err != nil {
+		return 0, err
+	}
+
+	err = runWithContext(ctx, func() error {
+		return s.AdminClient.CreatePartitions(topicName, newCount, assignment, false)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to create partitions: %w", err)
+	}
+
+	return newCount, nil
+}
+
+// validatePartitionIncrease checks that the partition count is increased and that the assignment, if given, contains
+// a valid replica list for each of the new partitions.
+func validatePartitionIncrease(currentCount, newCount int32, assignment [][]int32, replicationFactor int, brokerIDs []int32) error {
+	if newCount <= currentCount {
+		return fmt.Errorf("%w: the topic already has %v partitions, partitions can only be increased",
+			ErrInvalidPartitionCount, currentCount)
+	}
+	if assignment == nil {
+		return nil
+	}
+
+	if int32(len(assignment)) != newCount-currentCount {
+		return fmt.Errorf("%w: the replica assignment must contain %v entries, one for each new partition",
+			ErrInvalidPartitionCount, newCount-currentCount)
+	}
+	for i, replicas := range assignment {
+		if err := validateReplicas(currentCount+int32(i), replicas, replicationFactor, brokerIDs); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidPartitionCount, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,31 @@
+package kafka
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidatePartitionIncrease(t *testing.T) {
+	brokerIDs := []int32{1, 2, 3}
+
+	assert.NoError(t, validatePartitionIncrease(3, 6, nil, 2, brokerIDs))
+	assert.NoError(t, validatePartitionIncrease(3, 5, [][]int32{{1, 2}, {2, 3}}, 2, brokerIDs))
+
+	tt := []struct {
+		name       string
+		newCount   int32
+		assignment [][]int32
+	}{
+		{"decrease", 2, nil},
+		{"same count", 3, nil},
+		{"assignment for too few partitions", 5, [][]int32{{1, 2}}},
+		{"wrong replica count", 4, [][]int32{{1}}},
+		{"unknown broker", 4, [][]int32{{1, 7}}},
+	}
+	for _, table := range tt {
+		err := validatePartitionIncrease(3, table.newCount, table.assignment, 2, brokerIDs)
+		assert.True(t, errors.Is(err, ErrInvalidPartitionCount), table.name)
+	}
+}
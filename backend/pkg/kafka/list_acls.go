@@ -1,9 +1,16 @@
 package kafka
 
 import (
+	"context"
+	"fmt"
+	"time"
+
 	"github.com/Shopify/sarama"
 )
 
+// describeACLsAPIKey is the Kafka API key of the DescribeAcls request
+const describeACLsAPIKey = 29
+
 // ListACLs sends a DescribeACL request for one or more specific filters
 //
 // Kafka Request documentation:
@@ -12,6 +19,58 @@ import (
 // "types" of filters in this request: the resource filter and the entry
 // filter, with entries corresponding to users. The first three fields form the
 // resource filter, the last four the entry filter.
-func (s *Service) ListACLs(req sarama.AclFilter) ([]sarama.ResourceAcls, error) {
-	return s.AdminClient.ListAcls(req)
+//
+// The request is sent to the controller directly, because the admin client ignores the error code of the response.
+// ErrACLsNotSupported is returned if the brokers do not support the DescribeAcls API or if no authorizer has been
+// configured on the cluster.
+func (s *Service) ListACLs(ctx context.Context, req sarama.AclFilter) (acls []sarama.ResourceAcls, err error) {
+	defer s.observeOperation(ctx, operationTypeAdmin, "list_acls", time.Now(), &err)
+	if !s.supportsAPI(describeACLsAPIKey) {
+		return nil, ErrACLsNotSupported
+	}
+
+	request := &sarama.DescribeAclsRequest{AclFilter: req}
+	if s.Client.Config().Version.IsAtLeast(sarama.V2_0_0_0) {
+		request.Version = 1
+	}
+
+	controller, err := s.Client.Controller()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get controller: %w", err)
+	}
+	var res *sarama.DescribeAclsResponse
+	err = runWithContext(ctx, func() error {
+		var err error
+		res, err = controller.DescribeAcls(request)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := aclError(res.Err, res.ErrMsg); err != nil {
+		return nil, err
+	}
+
+	acls = make([]sarama.ResourceAcls, len(res.ResourceAcls))
+	for i, resourceACLs := range res.ResourceAcls {
+		acls[i] = *resourceACLs
+	}
+
+	return acls, nil
+}
+
+// supportsAPI returns whether the brokers support the given API key. If the API versions can not be determined, the
+// API is assumed to be supported so that the actual request surfaces the error.
+func (s *Service) supportsAPI(apiKey int16) bool {
+	versions, err := s.DescribeAPIVersions(false)
+	if err != nil {
+		return true
+	}
+	for _, version := range versions.APIVersions {
+		if version.ApiKey == apiKey {
+			return true
+		}
+	}
+
+	return false
 }
@@ -0,0 +1,27 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListACLs_SecurityDisabled(t *testing.T) {
+	svc, _ := newTestService(t, sarama.V1_0_0_0, func(broker *sarama.MockBroker) map[string]sarama.MockResponse {
+		return map[string]sarama.MockResponse{
+			"MetadataRequest": sarama.NewMockMetadataResponse(t).
+				SetController(broker.BrokerID()).
+				SetBroker(broker.Addr(), broker.BrokerID()),
+			"ApiVersionsRequest": sarama.NewMockWrapper(&sarama.ApiVersionsResponse{
+				ApiKeys: []sarama.ApiVersionsResponseKey{{ApiKey: describeACLsAPIKey, MinVersion: 0, MaxVersion: 1}},
+			}),
+			"DescribeAclsRequest": sarama.NewMockWrapper(&sarama.DescribeAclsResponse{Err: sarama.ErrSecurityDisabled}),
+		}
+	})
+
+	_, err := svc.ListACLs(context.Background(), sarama.AclFilter{ResourceType: sarama.AclResourceAny})
+	assert.True(t, errors.Is(err, ErrACLsNotSupported))
+}
@@ -3,13 +3,15 @@ package kafka
 import (
 	"context"
 	"sync"
+	"time"
 
 	"github.com/Shopify/sarama"
 	"golang.org/x/sync/errgroup"
 )
 
 // ListConsumerGroupOffsets returns the committed group offsets for a single group
-func (s *Service) ListConsumerGroupOffsets(group string) (*sarama.OffsetFetchResponse, error) {
+func (s *Service) ListConsumerGroupOffsets(ctx context.Context, group string) (offsets *sarama.OffsetFetchResponse, err error) {
+	defer s.observeOperation(ctx, operationTypeAdmin, "list_consumer_group_offsets", time.Now(), &err)
 	coordinator, err := s.Client.Coordinator(group)
 	if err != nil {
 		return nil, err
@@ -20,24 +22,30 @@ func (s *Service) ListConsumerGroupOffsets(group string) (*sarama.OffsetFetchRes
 		ConsumerGroup: group,
 	}
 
-	offsets, err := coordinator.FetchOffset(req)
+	var res *sarama.OffsetFetchResponse
+	err = runWithContext(ctx, func() error {
+		var err error
+		res, err = coordinator.FetchOffset(req)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	return offsets, nil
+	return res, nil
 }
 
 // ListConsumerGroupOffsetsBulk returns a map which has the Consumer group name as key
-func (s *Service) ListConsumerGroupOffsetsBulk(ctx context.Context, groups []string) (map[string]*sarama.OffsetFetchResponse, error) {
-	eg, _ := errgroup.WithContext(ctx)
+func (s *Service) ListConsumerGroupOffsetsBulk(ctx context.Context, groups []string) (offsets map[string]*sarama.OffsetFetchResponse, err error) {
+	defer s.observeOperation(ctx, operationTypeAdmin, "list_consumer_group_offsets", time.Now(), &err)
+	eg, groupCtx := errgroup.WithContext(ctx)
 
 	mutex := sync.Mutex{}
 	res := make(map[string]*sarama.OffsetFetchResponse)
 
 	f := func(group string) func() error {
 		return func() error {
-			offsets, err := s.ListConsumerGroupOffsets(group)
+			offsets, err := s.ListConsumerGroupOffsets(groupCtx, group)
 			if err != nil {
 				return err
 			}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/Shopify/sarama"
 	"golang.org/x/sync/errgroup"
+	"time"
 )
 
 type ListConsumerGroupsResponse struct {
@@ -14,7 +15,8 @@ type ListConsumerGroupsResponse struct {
 
 // ListConsumerGroups returns an array of Consumer group ids. Failed broker requests will be returned in the response.
 // If all broker requests fail an error will be returned.
-func (s *Service) ListConsumerGroups(ctx context.Context) (*ListConsumerGroupsResponse, error) {
+func (s *Service) ListConsumerGroups(ctx context.Context) (groups *ListConsumerGroupsResponse, err error) {
+	defer s.observeOperation(ctx, operationTypeAdmin, "list_consumer_groups", time.Now(), &err)
 	// 1. Query all brokers in the cluster in parallel in order to get all Consumer Groups
 	brokers := s.Client.Brokers()
 	type response struct {
@@ -24,13 +26,22 @@ func (s *Service) ListConsumerGroups(ctx context.Context) (*ListConsumerGroupsRe
 	}
 	resCh := make(chan response, len(brokers))
 
-	g, ctx := errgroup.WithContext(ctx)
+	g, groupCtx := errgroup.WithContext(ctx)
 	for _, broker := range brokers {
 		// Wrap in a func to avoid race conditions
 		func(b *sarama.Broker) {
 			g.Go(func() error {
 				_ = b.Open(s.Client.Config())
-				r, err := b.ListGroups(&sarama.ListGroupsRequest{})
+				var r *sarama.ListGroupsResponse
+				err := runWithContext(groupCtx, func() error {
+					var err error
+					r, err = b.ListGroups(&sarama.ListGroupsRequest{})
+					if err == nil && r.Err != sarama.ErrNoError {
+						// E.g. the broker is still loading the groups or Kowl is not authorized to describe the cluster
+						return r.Err
+					}
+					return err
+				})
 				if err != nil {
 					resCh <- response{
 						Err:            err,
@@ -52,6 +63,9 @@ func (s *Service) ListConsumerGroups(ctx context.Context) (*ListConsumerGroupsRe
 	// Wait until errgroup is done. We ignore the returned error as we won't ever return an error
 	_ = g.Wait()
 	close(resCh)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
 	// Fetch all groupIDs from channels until channels are closed or context is Done
 	groupIDs := make([]string, 0)
@@ -13,3 +13,41 @@ func (s *Service) ListPartitions(topicName string) ([]int32, error) {
 
 	return partitions, nil
 }
+
+// SelectPartitions returns the requested partitionIDs after checking that they exist in the given topic. If no
+// partitionIDs are requested, all partitions of the topic are returned.
+func (s *Service) SelectPartitions(topicName string, partitionIDs []int32) ([]int32, error) {
+	partitions, err := s.ListPartitions(topicName)
+	if err != nil {
+		return nil, err
+	}
+
+	return selectPartitions(partitions, partitionIDs)
+}
+
+func selectPartitions(partitions []int32, requested []int32) ([]int32, error) {
+	if len(requested) == 0 {
+		return partitions, nil
+	}
+
+	exists := make(map[int32]bool, len(partitions))
+	for _, partitionID := range partitions {
+		exists[partitionID] = true
+	}
+
+	selected := make([]int32, 0, len(requested))
+	isSelected := make(map[int32]bool, len(requested))
+	for _, partitionID := range requested {
+		if !exists[partitionID] {
+			return nil, fmt.Errorf("%w: partition %v does not exist, the topic has %v partitions (0 to %v)",
+				ErrInvalidPartition, partitionID, len(partitions), len(partitions)-1)
+		}
+		if isSelected[partitionID] {
+			continue
+		}
+		isSelected[partitionID] = true
+		selected = append(selected, partitionID)
+	}
+
+	return selected, nil
+}
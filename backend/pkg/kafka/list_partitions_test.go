@@ -0,0 +1,24 @@
+package kafka
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelectPartitions(t *testing.T) {
+	partitions := []int32{0, 1, 2, 3}
+
+	selected, err := selectPartitions(partitions, nil)
+	require.NoError(t, err)
+	assert.Equal(t, partitions, selected)
+
+	selected, err = selectPartitions(partitions, []int32{3, 1, 3})
+	require.NoError(t, err)
+	assert.Equal(t, []int32{3, 1}, selected)
+
+	_, err = selectPartitions(partitions, []int32{4})
+	assert.True(t, errors.Is(err, ErrInvalidPartition))
+}
@@ -1,6 +1,8 @@
 package kafka
 
 import (
+	"context"
+
 	"github.com/Shopify/sarama"
 	"go.uber.org/zap"
 )
@@ -15,7 +17,21 @@ type LogDirResponse struct {
 // DescribeLogDirs concurrently fetches LogDirs from all Brokers
 // and returns them in a map where the BrokerID is the key.
 // map[BrokerID]LogDirResponse
-func (s *Service) DescribeLogDirs() map[int32]*LogDirResponse {
+// Brokers which have not responded until the context is done are contained with the context's error.
+func (s *Service) DescribeLogDirs(ctx context.Context) map[int32]*LogDirResponse {
+	return s.describeLogDirs(ctx, &sarama.DescribeLogDirsRequest{})
+}
+
+// DescribeTopicLogDirs concurrently fetches the LogDirs of the given topic partitions from all Brokers. Brokers
+// which fail to respond are contained with an error, so that they can be reported as unavailable.
+func (s *Service) DescribeTopicLogDirs(ctx context.Context, topicName string, partitionIDs []int32) map[int32]*LogDirResponse {
+	req := &sarama.DescribeLogDirsRequest{
+		DescribeTopics: []sarama.DescribeLogDirsRequestTopic{{Topic: topicName, PartitionIDs: partitionIDs}},
+	}
+	return s.describeLogDirs(ctx, req)
+}
+
+func (s *Service) describeLogDirs(ctx context.Context, req *sarama.DescribeLogDirsRequest) map[int32]*LogDirResponse {
 	// 1. Fetch Log Dirs from all brokers
 	type response struct {
 		BrokerID int32
@@ -24,7 +40,6 @@ func (s *Service) DescribeLogDirs() map[int32]*LogDirResponse {
 	}
 
 	brokers := s.Client.Brokers()
-	req := &sarama.DescribeLogDirsRequest{}
 	resCh := make(chan response, len(brokers))
 
 	for _, broker := range brokers {
@@ -41,10 +56,19 @@ func (s *Service) DescribeLogDirs() map[int32]*LogDirResponse {
 	// 2. Put log dir responses into a structured map as they arrive
 	result := make(map[int32]*LogDirResponse)
 	for i := 0; i < len(brokers); i++ {
-		r := <-resCh
+		var r response
+		select {
+		case r = <-resCh:
+		case <-ctx.Done():
+			for _, broker := range brokers {
+				if _, ok := result[broker.ID()]; !ok {
+					result[broker.ID()] = &LogDirResponse{Err: ctx.Err()}
+				}
+			}
+			return result
+		}
 		if r.Err != nil {
 			s.Logger.Warn("listing log dir size for broker has failed", zap.Error(r.Err), zap.Int32("broker", r.BrokerID))
-			continue
 		}
 
 		result[r.BrokerID] = &LogDirResponse{r.Res, r.Err}
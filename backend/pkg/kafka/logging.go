@@ -0,0 +1,119 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// correlationIDKey is the context key of the correlation id
+type correlationIDKey struct{}
+
+// ContextWithCorrelationID returns a context which carries the given correlation id. All Kafka operations which are
+// called with this context log the id, so that the logs of a request can be correlated with its Kafka calls.
+func ContextWithCorrelationID(ctx context.Context, correlationID string) context.Context {
+	return context.WithValue(ctx, correlationIDKey{}, correlationID)
+}
+
+// CorrelationIDFromContext returns the correlation id of the context or an empty string if it has none
+func CorrelationIDFromContext(ctx context.Context) string {
+	correlationID, _ := ctx.Value(correlationIDKey{}).(string)
+	return correlationID
+}
+
+// loggerFromContext returns the service's logger, which includes the correlation id of the context if there is one
+func (s *Service) loggerFromContext(ctx context.Context) *zap.Logger {
+	correlationID := CorrelationIDFromContext(ctx)
+	if correlationID == "" {
+		return s.Logger
+	}
+
+	return s.Logger.With(zap.String("correlation_id", correlationID))
+}
+
+// observeOperation records the metrics of an admin, consume or produce operation which has been started at the given
+// time and logs its outcome along with the correlation id of the context. It is supposed to be deferred with a
+// pointer to the named error result.
+func (s *Service) observeOperation(ctx context.Context, operationType string, operation string, start time.Time, err *error) {
+	s.metrics().observeOperation(operationType, operation, start, err)
+
+	logger := s.loggerFromContext(ctx)
+	if !logger.Core().Enabled(zapcore.DebugLevel) {
+		return
+	}
+	fields := []zap.Field{
+		zap.String("operation_type", operationType),
+		zap.String("operation", operation),
+		zap.Duration("duration", time.Since(start)),
+	}
+	if err != nil && *err != nil {
+		fields = append(fields, zap.String("error_kind", errorKind(*err)), zap.Error(*err))
+		logger.Debug("kafka operation failed", fields...)
+		return
+	}
+	logger.Debug("kafka operation succeeded", fields...)
+}
+
+// newLeveledLogger returns a logger which only logs entries of at least the given level. An empty level returns the
+// logger unchanged, the level can only be more restrictive than the level of the given logger.
+func newLeveledLogger(logger *zap.Logger, level string) (*zap.Logger, error) {
+	if level == "" {
+		return logger, nil
+	}
+
+	var lvl zapcore.Level
+	err := lvl.UnmarshalText([]byte(level))
+	if err != nil {
+		return nil, err
+	}
+
+	return logger.WithOptions(zap.IncreaseLevel(lvl)), nil
+}
+
+// SetSaramaLogger routes sarama's logs to the given logger, filtered by the given log level (see Config.LogLevel).
+// sarama's logger is a process global which is shared by all Kafka services, hence it must be set once rather than
+// per cluster.
+func SetSaramaLogger(logger *zap.Logger, level string) error {
+	logger, err := newLeveledLogger(logger, level)
+	if err != nil {
+		return fmt.Errorf("failed to set log level: %w", err)
+	}
+
+	saramaLogger, err := zap.NewStdLogAt(logger.With(zap.String("source", "sarama")), zapcore.DebugLevel)
+	if err != nil {
+		return fmt.Errorf("failed to create std logger for sarama: %w", err)
+	}
+	sarama.Logger = &filteredSaramaLogger{StdLogger: saramaLogger}
+
+	return nil
+}
+
+// redactURLError strips the url from url parsing errors, because urls may contain credentials
+func redactURLError(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	}
+
+	return err
+}
+
+// filteredSaramaLogger drops sarama's "using proxy" log of every dialed connection. All connections are dialed by the
+// counting dialer, which sarama only supports as proxy dialer, hence the log would be misleading if no proxy is
+// configured. Kowl logs the use of a proxy once instead.
+type filteredSaramaLogger struct {
+	sarama.StdLogger
+}
+
+func (l *filteredSaramaLogger) Printf(format string, v ...interface{}) {
+	if format == "using proxy %s" {
+		return
+	}
+	l.StdLogger.Printf(format, v...)
+}
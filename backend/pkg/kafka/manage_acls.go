@@ -0,0 +1,100 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Shopify/sarama"
+)
+
+// CreateACL creates a single ACL binding. Like ListACLs the request is sent to the controller directly, because the
+// admin client ignores the error codes of the response.
+func (s *Service) CreateACL(ctx context.Context, resource sarama.Resource, acl sarama.Acl) (err error) {
+	defer s.observeOperation(ctx, operationTypeAdmin, "create_acl", time.Now(), &err)
+	request := &sarama.CreateAclsRequest{
+		AclCreations: []*sarama.AclCreation{{Resource: resource, Acl: acl}},
+	}
+	if s.Client.Config().Version.IsAtLeast(sarama.V2_0_0_0) {
+		request.Version = 1
+	} else if resource.ResourcePatternType != sarama.AclPatternLiteral {
+		// Version 0 of the request has no pattern type, all ACLs would be created as LITERAL
+		return fmt.Errorf("%w: resource pattern type %v requires a clusterVersion of at least 2.0.0", ErrInvalidACL, resource.ResourcePatternType.String())
+	}
+
+	controller, err := s.Client.Controller()
+	if err != nil {
+		return fmt.Errorf("failed to get controller: %w", err)
+	}
+	var res *sarama.CreateAclsResponse
+	err = runWithContext(ctx, func() error {
+		var err error
+		res, err = controller.CreateAcls(request)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	for _, creation := range res.AclCreationResponses {
+		if err := aclError(creation.Err, creation.ErrMsg); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// DeleteACLs deletes all ACL bindings which match the given filter and returns the deleted bindings
+func (s *Service) DeleteACLs(ctx context.Context, filter sarama.AclFilter) (matching []sarama.MatchingAcl, err error) {
+	defer s.observeOperation(ctx, operationTypeAdmin, "delete_acls", time.Now(), &err)
+	request := &sarama.DeleteAclsRequest{Filters: []*sarama.AclFilter{&filter}}
+	if s.Client.Config().Version.IsAtLeast(sarama.V2_0_0_0) {
+		request.Version = 1
+	} else if filter.ResourcePatternTypeFilter != sarama.AclPatternAny && filter.ResourcePatternTypeFilter != sarama.AclPatternLiteral {
+		// Version 0 of the request has no pattern type filter, it would match the LITERAL ACLs instead
+		return nil, fmt.Errorf("%w: resource pattern type %v requires a clusterVersion of at least 2.0.0", ErrInvalidACL, filter.ResourcePatternTypeFilter.String())
+	}
+
+	controller, err := s.Client.Controller()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get controller: %w", err)
+	}
+	var res *sarama.DeleteAclsResponse
+	err = runWithContext(ctx, func() error {
+		var err error
+		res, err = controller.DeleteAcls(request)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	deleted := make([]sarama.MatchingAcl, 0)
+	for _, filterRes := range res.FilterResponses {
+		if err := aclError(filterRes.Err, filterRes.ErrMsg); err != nil {
+			return nil, err
+		}
+		for _, matching := range filterRes.MatchingAcls {
+			if err := aclError(matching.Err, matching.ErrMsg); err != nil {
+				return nil, fmt.Errorf("failed to delete ACL for principal '%v': %w", matching.Principal, err)
+			}
+			deleted = append(deleted, *matching)
+		}
+	}
+
+	return deleted, nil
+}
+
+// aclError returns nil if there is no error and ErrACLsNotSupported if no authorizer is configured on the cluster
+func aclError(kErr sarama.KError, errMsg *string) error {
+	switch kErr {
+	case sarama.ErrNoError:
+		return nil
+	case sarama.ErrSecurityDisabled:
+		return ErrACLsNotSupported
+	}
+	if errMsg != nil {
+		return fmt.Errorf("%w: %v", kErr, *errMsg)
+	}
+	return kErr
+}
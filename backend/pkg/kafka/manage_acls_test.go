@@ -0,0 +1,28 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManageACLs_PatternTypeRequiresKafka2(t *testing.T) {
+	svc, _ := newTestService(t, sarama.V1_0_0_0, func(broker *sarama.MockBroker) map[string]sarama.MockResponse {
+		return map[string]sarama.MockResponse{
+			"MetadataRequest": sarama.NewMockMetadataResponse(t).
+				SetController(broker.BrokerID()).
+				SetBroker(broker.Addr(), broker.BrokerID()),
+		}
+	})
+
+	// Version 0 of the requests would silently treat the ACLs as LITERAL
+	resource := sarama.Resource{ResourceType: sarama.AclResourceTopic, ResourceName: "orders", ResourcePatternType: sarama.AclPatternPrefixed}
+	err := svc.CreateACL(context.Background(), resource, sarama.Acl{Principal: "User:bob", Host: "*"})
+	assert.True(t, errors.Is(err, ErrInvalidACL))
+
+	_, err = svc.DeleteACLs(context.Background(), sarama.AclFilter{ResourcePatternTypeFilter: sarama.AclPatternPrefixed})
+	assert.True(t, errors.Is(err, ErrInvalidACL))
+}
@@ -0,0 +1,164 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"go.uber.org/zap"
+)
+
+// sizeSampleTimeout is the max duration for sampling the messages of all partitions. Sampling is stopped once it has
+// been exceeded and the distribution is computed from the messages which have been consumed so far.
+const sizeSampleTimeout = 10 * time.Second
+
+// PayloadSizeStats is the distribution of the key or value sizes in bytes. Null payloads are counted separately and
+// are not part of the distribution.
+type PayloadSizeStats struct {
+	Count     int64   `json:"count"`
+	NullCount int64   `json:"nullCount"`
+	Avg       float64 `json:"avg"`
+	P50       int     `json:"p50"`
+	P90       int     `json:"p90"`
+	P99       int     `json:"p99"`
+	Max       int     `json:"max"`
+}
+
+// MessageSizeDistribution is the distribution of the key and value sizes of the sampled messages of a topic
+type MessageSizeDistribution struct {
+	SampledCount int64            `json:"sampledCount"`
+	SampledBytes int64            `json:"sampledBytes"`
+	Key          PayloadSizeStats `json:"key"`
+	Value        PayloadSizeStats `json:"value"`
+
+	// IsTimedOut is true if sampling has been stopped after sizeSampleTimeout, before all partitions have been sampled
+	IsTimedOut bool `json:"isTimedOut"`
+}
+
+// sizeCollector collects the key and value sizes of the consumed messages
+type sizeCollector struct {
+	mutex          sync.Mutex
+	keySizes       []int
+	valueSizes     []int
+	nullKeyCount   int64
+	nullValueCount int64
+	bytes          int64
+}
+
+func (c *sizeCollector) add(m *sarama.ConsumerMessage) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.bytes += int64(len(m.Key) + len(m.Value))
+	if m.Key == nil {
+		c.nullKeyCount++
+	} else {
+		c.keySizes = append(c.keySizes, len(m.Key))
+	}
+	if m.Value == nil {
+		c.nullValueCount++
+	} else {
+		c.valueSizes = append(c.valueSizes, len(m.Value))
+	}
+}
+
+func (c *sizeCollector) result() *MessageSizeDistribution {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return &MessageSizeDistribution{
+		SampledCount: int64(len(c.keySizes)) + c.nullKeyCount,
+		SampledBytes: c.bytes,
+		Key:          newPayloadSizeStats(c.keySizes, c.nullKeyCount),
+		Value:        newPayloadSizeStats(c.valueSizes, c.nullValueCount),
+	}
+}
+
+// newPayloadSizeStats computes the average and the nearest-rank percentiles of the given sizes, the sizes are sorted
+// in place
+func newPayloadSizeStats(sizes []int, nullCount int64) PayloadSizeStats {
+	stats := PayloadSizeStats{Count: int64(len(sizes)), NullCount: nullCount}
+	if len(sizes) == 0 {
+		return stats
+	}
+
+	sort.Ints(sizes)
+	total := 0
+	for _, size := range sizes {
+		total += size
+	}
+	percentile := func(p float64) int {
+		rank := int(math.Ceil(p * float64(len(sizes))))
+		return sizes[rank-1]
+	}
+
+	stats.Avg = float64(total) / float64(len(sizes))
+	stats.P50 = percentile(0.5)
+	stats.P90 = percentile(0.9)
+	stats.P99 = percentile(0.99)
+	stats.Max = sizes[len(sizes)-1]
+
+	return stats
+}
+
+// GetMessageSizeDistribution samples the newest sampleSize messages of each partition and returns the distribution of
+// their key and value sizes. The payloads are not deserialized, the sizes are the sizes as produced (after the
+// decompression of the record batches).
+func (s *Service) GetMessageSizeDistribution(ctx context.Context, topicName string, sampleSize int64) (res *MessageSizeDistribution, err error) {
+	defer s.observeOperation(ctx, operationTypeConsume, "get_message_size_distribution", time.Now(), &err)
+	ctx, done, err := s.TrackOperation(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	if sampleSize <= 0 {
+		return nil, fmt.Errorf("sample size must be greater than 0")
+	}
+
+	partitionIDs, err := s.ListPartitions(topicName)
+	if err != nil {
+		return nil, err
+	}
+	marks, err := s.WaterMarks(ctx, topicName, partitionIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get watermarks: %w", err)
+	}
+
+	client, release, err := s.newFetchClient(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create consumer client: %w", err)
+	}
+	defer release()
+
+	sampleCtx, cancel := context.WithTimeout(ctx, sizeSampleTimeout)
+	defer cancel()
+
+	collector := &sizeCollector{}
+	wg := sync.WaitGroup{}
+	for _, mark := range marks {
+		startOffset, endOffset, hasMessages := newestOffsetRange(mark, sampleSize)
+		if !hasMessages {
+			continue
+		}
+
+		wg.Add(1)
+		go func(partitionID int32, startOffset, endOffset int64) {
+			defer wg.Done()
+			err := s.consumeRawOffsetRange(sampleCtx, client, topicName, partitionID, startOffset, endOffset, collector.add)
+			if err != nil {
+				s.Logger.Debug("failed to sample message sizes of partition", zap.String("topic", topicName),
+					zap.Int32("partition_id", partitionID), zap.Error(err))
+			}
+		}(mark.PartitionID, startOffset, endOffset)
+	}
+	wg.Wait()
+
+	res = collector.result()
+	res.IsTimedOut = sampleCtx.Err() == context.DeadlineExceeded
+
+	return res, nil
+}
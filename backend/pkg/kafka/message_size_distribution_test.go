@@ -0,0 +1,33 @@
+package kafka
+
+import (
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewPayloadSizeStats(t *testing.T) {
+	sizes := make([]int, 0, 100)
+	for i := 100; i > 0; i-- {
+		sizes = append(sizes, i)
+	}
+
+	stats := newPayloadSizeStats(sizes, 3)
+	assert.Equal(t, PayloadSizeStats{Count: 100, NullCount: 3, Avg: 50.5, P50: 50, P90: 90, P99: 99, Max: 100}, stats)
+
+	assert.Equal(t, PayloadSizeStats{NullCount: 2}, newPayloadSizeStats(nil, 2))
+	assert.Equal(t, PayloadSizeStats{Count: 1, Avg: 7, P50: 7, P90: 7, P99: 7, Max: 7}, newPayloadSizeStats([]int{7}, 0))
+}
+
+func TestSizeCollector(t *testing.T) {
+	c := &sizeCollector{}
+	c.add(&sarama.ConsumerMessage{Key: []byte("a"), Value: []byte("hello")})
+	c.add(&sarama.ConsumerMessage{Key: []byte("abc"), Value: nil})
+	c.add(&sarama.ConsumerMessage{Key: nil, Value: []byte{}})
+
+	res := c.result()
+	assert.Equal(t, int64(3), res.SampledCount)
+	assert.Equal(t, PayloadSizeStats{Count: 2, NullCount: 1, Avg: 2, P50: 1, P90: 3, P99: 3, Max: 3}, res.Key)
+	assert.Equal(t, PayloadSizeStats{Count: 2, NullCount: 1, Avg: 2.5, P50: 0, P90: 5, P99: 5, Max: 5}, res.Value)
+}
@@ -1,20 +1,273 @@
 package kafka
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
 	"time"
 
-	prometheusmetrics "github.com/deathowl/go-metrics-prometheus"
+	"github.com/Shopify/sarama"
 	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
 )
 
-// RegisterMetrics periodically updates all sarama/client Kafka metrics and exposes them
-// on the default prometheus registry.
+// Operation types which are used to label the operation metrics
+const (
+	operationTypeAdmin   = "admin"
+	operationTypeConsume = "consume"
+	operationTypeProduce = "produce"
+)
+
+// serviceMetrics are the metrics of Kowl's own interaction with Kafka. All methods are no-ops if metrics have not
+// been registered, so that the service can be used without metrics (e. g. in tests or if metrics are disabled).
+type serviceMetrics struct {
+	operationDuration *prometheus.HistogramVec
+	operationErrors   *prometheus.CounterVec
+	activeConsumers   prometheus.Gauge
+}
+
+// metricsRegistration makes sure that the metrics are only registered once per service
+type metricsRegistration struct {
+	once    sync.Once
+	metrics *serviceMetrics
+}
+
+// RegisterMetrics exposes the operation metrics of the Kafka service and, if enabled, sarama's client metrics on the
+// default prometheus registry. All metrics are labelled with the name of the cluster. Calling it multiple times has no
+// effect.
 func (s *Service) RegisterMetrics() {
-	pClient := prometheusmetrics.NewPrometheusProvider(
-		s.Client.Config().MetricRegistry,
-		s.MetricsNamespace,
-		"sarama",
-		prometheus.DefaultRegisterer,
-		5*time.Second)
-	go pClient.UpdatePrometheusMetrics()
+	s.metricsRegistration.once.Do(func() {
+		registerer := prometheus.WrapRegistererWith(prometheus.Labels{"cluster": s.ClusterName}, prometheus.DefaultRegisterer)
+		if s.Config.EnableClientMetrics {
+			registerer.MustRegister(newClientMetricsCollector(s.Config.clientMetrics.registry))
+		}
+
+		s.metricsRegistration.metrics = newServiceMetrics(s.MetricsNamespace, registerer)
+	})
+}
+
+// newServiceMetrics creates the operation metrics and registers them. Collectors which have already been registered
+// (e. g. by another service instance of the same cluster) are reused.
+func newServiceMetrics(namespace string, registerer prometheus.Registerer) *serviceMetrics {
+	operationDuration := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "kafka",
+		Name:      "operation_duration_seconds",
+		Help:      "Duration of admin, consume and produce operations against the Kafka cluster",
+		Buckets:   []float64{.01, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60},
+	}, []string{"type", "operation"})
+	operationErrors := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "kafka",
+		Name:      "operation_errors_total",
+		Help:      "Number of failed operations against the Kafka cluster",
+	}, []string{"type", "operation", "kind"})
+	activeConsumers := prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "kafka",
+		Name:      "active_consumers",
+		Help:      "Number of consumers which are currently consuming messages",
+	})
+
+	return &serviceMetrics{
+		operationDuration: registerCollector(registerer, operationDuration).(*prometheus.HistogramVec),
+		operationErrors:   registerCollector(registerer, operationErrors).(*prometheus.CounterVec),
+		activeConsumers:   registerCollector(registerer, activeConsumers).(prometheus.Gauge),
+	}
+}
+
+// registerCollector registers the collector and returns it, or the existing collector if an equal collector has
+// already been registered
+func registerCollector(registerer prometheus.Registerer, collector prometheus.Collector) prometheus.Collector {
+	err := registerer.Register(collector)
+	var alreadyRegistered prometheus.AlreadyRegisteredError
+	if errors.As(err, &alreadyRegistered) {
+		return alreadyRegistered.ExistingCollector
+	}
+
+	return collector
+}
+
+// metrics returns the registered service metrics or nil if metrics have not been registered
+func (s *Service) metrics() *serviceMetrics {
+	return s.metricsRegistration.metrics
+}
+
+// observeOperation records the duration of an operation which has been started at the given time and counts the
+// error, if the operation has failed. It is supposed to be deferred with a pointer to the named error result.
+func (m *serviceMetrics) observeOperation(operationType string, operation string, start time.Time, err *error) {
+	if m == nil {
+		return
+	}
+
+	m.operationDuration.WithLabelValues(operationType, operation).Observe(time.Since(start).Seconds())
+	if err != nil && *err != nil {
+		m.operationErrors.WithLabelValues(operationType, operation, errorKind(*err)).Inc()
+	}
+}
+
+func (m *serviceMetrics) consumerStarted() {
+	if m == nil {
+		return
+	}
+	m.activeConsumers.Inc()
+}
+
+func (m *serviceMetrics) consumerStopped() {
+	if m == nil {
+		return
+	}
+	m.activeConsumers.Dec()
+}
+
+// errorKinds maps the errors returned by the Kafka service to a small and stable set of label values
+var errorKinds = []struct {
+	err  error
+	kind string
+}{
+	{ErrTopicNotFound, "topic_not_found"},
+	{ErrTopicAlreadyExists, "topic_already_exists"},
+	{ErrConsumerGroupNotFound, "consumer_group_not_found"},
+	{ErrConsumerGroupActive, "consumer_group_active"},
+	{ErrInvalidOffset, "invalid_request"},
+	{ErrInvalidACL, "invalid_request"},
+	{ErrInvalidPartition, "invalid_request"},
+	{ErrInvalidTopicSpec, "invalid_request"},
+	{ErrInvalidPartitionCount, "invalid_request"},
+	{ErrInvalidReassignment, "invalid_request"},
+	{ErrInvalidQuota, "invalid_request"},
+	{ErrACLsNotSupported, "not_supported"},
+	{ErrPartitionReassignmentsNotSupported, "not_supported"},
+	{ErrClientQuotasNotSupported, "not_supported"},
+	{context.Canceled, "canceled"},
+	{context.DeadlineExceeded, "timeout"},
+}
+
+// errorKind returns the label value which describes the kind of the given error
+func errorKind(err error) string {
+	for _, e := range errorKinds {
+		if errors.Is(err, e.err) {
+			return e.kind
+		}
+	}
+
+	var tlsErr *ErrTLSConfig
+	var saslErr *ErrSASLConfig
+	var pingErr *PingError
+	var kafkaErr sarama.KError
+	var netErr net.Error
+	switch {
+	case errors.As(err, &tlsErr):
+		return "tls"
+	case errors.As(err, &saslErr):
+		return "sasl"
+	case errors.As(err, &pingErr):
+		return "connection"
+	case errors.As(err, &kafkaErr):
+		return "kafka"
+	case errors.As(err, &netErr):
+		return "network"
+	default:
+		return "other"
+	}
+}
+
+// trackedConsumer is a consumer which is counted as active consumer until it is closed. If the consumer has its own
+// client (rather than the shared one), the client is closed along with the consumer.
+type trackedConsumer struct {
+	sarama.Consumer
+	client    sarama.Client
+	metrics   *serviceMetrics
+	closeOnce sync.Once
+}
+
+func (c *trackedConsumer) Close() error {
+	c.closeOnce.Do(c.metrics.consumerStopped)
+	err := c.Consumer.Close()
+	if c.client != nil {
+		if clientErr := c.client.Close(); err == nil {
+			err = clientErr
+		}
+	}
+	return err
+}
+
+// NewConsumer creates a consumer for the request of the context, which is counted as active consumer until it is
+// closed. The consumer uses the client of newConsumerClient.
+func (s *Service) NewConsumer(ctx context.Context) (sarama.Consumer, error) {
+	client, isOwnClient, err := s.newConsumerClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var ownClient sarama.Client
+	if isOwnClient {
+		ownClient = client
+	}
+
+	consumer, err := sarama.NewConsumerFromClient(client)
+	if err != nil {
+		if ownClient != nil {
+			_ = ownClient.Close()
+		}
+		return nil, err
+	}
+	metrics := s.metrics()
+	metrics.consumerStarted()
+
+	return &trackedConsumer{Consumer: consumer, client: ownClient, metrics: metrics}, nil
+}
+
+// newConsumerClient returns the client which is used to consume for the request of the context. It is the shared
+// client, unless a client id template is configured. In that case a new client is created whose client id identifies
+// the user and request of the context, which must be closed by the caller (indicated by the second return value).
+// Connecting the new client is aborted once the context is done.
+func (s *Service) newConsumerClient(ctx context.Context) (sarama.Client, bool, error) {
+	if s.Config.Consumer.ClientIDTemplate == "" {
+		return s.Client, false, nil
+	}
+
+	sConfig, err := NewConsumerConfig(&s.Config, ConsumerConfigOverride{ClientID: s.consumerClientID(ctx)})
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to create consumer config: %w", err)
+	}
+
+	// The client is always sent, even if it could not be created, so that a client which is created after the context
+	// is done can be closed
+	created := make(chan sarama.Client, 1)
+	err = runWithContext(ctx, func() error {
+		client, err := sarama.NewClient(s.Config.Brokers, sConfig)
+		created <- client
+		return err
+	})
+	if err != nil {
+		go func() {
+			if client := <-created; client != nil {
+				_ = client.Close()
+			}
+		}()
+		return nil, false, fmt.Errorf("failed to create kafka client: %w", err)
+	}
+
+	return <-created, true, nil
+}
+
+// newFetchClient returns the client of newConsumerClient for requests which fetch records directly rather than with
+// a consumer (see fetchOffsetRange), along with a function which closes the client unless it is the shared one
+func (s *Service) newFetchClient(ctx context.Context) (sarama.Client, func(), error) {
+	client, isOwnClient, err := s.newConsumerClient(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	release := func() {}
+	if isOwnClient {
+		release = func() {
+			if err := client.Close(); err != nil {
+				s.Logger.Error("closing consumer client failed", zap.Error(err))
+			}
+		}
+	}
+
+	return client, release, nil
 }
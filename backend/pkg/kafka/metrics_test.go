@@ -0,0 +1,62 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorKind(t *testing.T) {
+	tt := []struct {
+		err  error
+		kind string
+	}{
+		{ErrTopicNotFound, "topic_not_found"},
+		{fmt.Errorf("failed to describe topic: %w", ErrTopicNotFound), "topic_not_found"},
+		{fmt.Errorf("%w: at least one entity must be given", ErrInvalidQuota), "invalid_request"},
+		{ErrClientQuotasNotSupported, "not_supported"},
+		{context.DeadlineExceeded, "timeout"},
+		{&ErrTLSConfig{Err: errors.New("bad certificate")}, "tls"},
+		{fmt.Errorf("failed to create topic: %w", sarama.ErrTopicAuthorizationFailed), "kafka"},
+		{errors.New("something went wrong"), "other"},
+	}
+	for _, table := range tt {
+		assert.Equal(t, table.kind, errorKind(table.err), table.err.Error())
+	}
+}
+
+func TestServiceMetrics(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	metrics := newServiceMetrics("kowl", registry)
+
+	// Registering the metrics a second time reuses the existing collectors instead of failing
+	require.Equal(t, metrics, newServiceMetrics("kowl", registry))
+
+	start := time.Now()
+	var err error
+	metrics.observeOperation(operationTypeAdmin, "create_topic", start, &err)
+	err = fmt.Errorf("failed to create topic: %w", ErrTopicAlreadyExists)
+	metrics.observeOperation(operationTypeAdmin, "create_topic", start, &err)
+
+	assert.Equal(t, 1, testutil.CollectAndCount(metrics.operationDuration))
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.operationErrors.WithLabelValues(operationTypeAdmin, "create_topic", "topic_already_exists")))
+
+	metrics.consumerStarted()
+	metrics.consumerStarted()
+	metrics.consumerStopped()
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.activeConsumers))
+
+	// Metrics which have not been registered are no-ops
+	var unregistered *serviceMetrics
+	unregistered.observeOperation(operationTypeAdmin, "create_topic", start, &err)
+	unregistered.consumerStarted()
+	unregistered.consumerStopped()
+}
@@ -0,0 +1,126 @@
+package kafka
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Shopify/sarama"
+)
+
+// tokenRefreshMargin is the duration before the token's expiry at which we already try to fetch a new one. Tokens
+// with a short lifetime are refreshed after half of their lifetime at the earliest, so that they are not requested
+// for every connection.
+const tokenRefreshMargin = 30 * time.Second
+
+// defaultTokenLifetime is assumed if the token response doesn't contain expires_in, which is only recommended by
+// RFC 6749
+const defaultTokenLifetime = 5 * time.Minute
+
+// oauthTokenProvider implements sarama's AccessTokenProvider. It fetches bearer tokens via the client credentials grant
+// and caches them until they are about to expire.
+type oauthTokenProvider struct {
+	cfg        SASLOAuthBearerConfig
+	httpClient *http.Client
+
+	mutex     sync.Mutex
+	token     string
+	refreshAt time.Time
+	expiresAt time.Time
+}
+
+type oauthTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+func newOAuthTokenProvider(cfg SASLOAuthBearerConfig) *oauthTokenProvider {
+	return &oauthTokenProvider{
+		cfg:        cfg,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Token returns the cached access token or fetches a new one if it's about to expire. If the token endpoint is not
+// available, the cached token will be returned as long as it is not yet expired.
+func (o *oauthTokenProvider) Token() (*sarama.AccessToken, error) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	now := time.Now()
+	if o.token != "" && now.Before(o.refreshAt) {
+		return o.accessToken(), nil
+	}
+
+	res, err := o.requestToken()
+	if err != nil {
+		if o.token != "" && now.Before(o.expiresAt) {
+			// Token endpoint is unavailable, but our current token is still valid
+			return o.accessToken(), nil
+		}
+		return nil, fmt.Errorf("failed to request oauth bearer token: %w", err)
+	}
+
+	lifetime := time.Duration(res.ExpiresIn) * time.Second
+	if lifetime <= 0 {
+		lifetime = defaultTokenLifetime
+	}
+	o.token = res.AccessToken
+	o.expiresAt = now.Add(lifetime)
+	o.refreshAt = o.expiresAt.Add(-tokenRefreshMargin)
+	if earliest := now.Add(lifetime / 2); o.refreshAt.Before(earliest) {
+		o.refreshAt = earliest
+	}
+
+	return o.accessToken(), nil
+}
+
+func (o *oauthTokenProvider) accessToken() *sarama.AccessToken {
+	return &sarama.AccessToken{
+		Token:      o.token,
+		Extensions: o.cfg.Extensions,
+	}
+}
+
+// requestToken requests a new access token from the token endpoint using the client credentials grant
+func (o *oauthTokenProvider) requestToken() (*oauthTokenResponse, error) {
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	if len(o.cfg.Scopes) > 0 {
+		form.Set("scope", strings.Join(o.cfg.Scopes, " "))
+	}
+
+	req, err := http.NewRequest(http.MethodPost, o.cfg.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(o.cfg.ClientID), url.QueryEscape(o.cfg.ClientSecret))
+
+	res, err := o.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("token endpoint returned status code %d", res.StatusCode)
+	}
+
+	var tokenRes oauthTokenResponse
+	err = json.NewDecoder(res.Body).Decode(&tokenRes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode token response: %w", err)
+	}
+
+	if tokenRes.AccessToken == "" {
+		return nil, fmt.Errorf("token endpoint returned an empty access token")
+	}
+
+	return &tokenRes, nil
+}
@@ -0,0 +1,85 @@
+package kafka
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOAuthTokenProvider_Token(t *testing.T) {
+	requestCount := 0
+	isAvailable := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestCount++
+		if !isAvailable {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		assert.Equal(t, "client_credentials", r.FormValue("grant_type"))
+		assert.Equal(t, "kafka openid", r.FormValue("scope"))
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token":"my-token","token_type":"bearer","expires_in":3600}`))
+	}))
+	defer server.Close()
+
+	provider := newOAuthTokenProvider(SASLOAuthBearerConfig{
+		TokenEndpoint: server.URL,
+		ClientID:      "kowl",
+		ClientSecret:  "secret",
+		Scopes:        []string{"kafka", "openid"},
+		Extensions:    map[string]string{"logicalCluster": "lkc-123"},
+	})
+
+	token, err := provider.Token()
+	require.NoError(t, err)
+	assert.Equal(t, "my-token", token.Token)
+	assert.Equal(t, "lkc-123", token.Extensions["logicalCluster"])
+
+	// Second call must be served from cache
+	_, err = provider.Token()
+	require.NoError(t, err)
+	assert.Equal(t, 1, requestCount)
+
+	// Token is about to expire and the token endpoint is unavailable, the cached token must be returned
+	isAvailable = false
+	provider.refreshAt = time.Now().Add(-time.Second)
+	provider.expiresAt = time.Now().Add(10 * time.Second)
+	token, err = provider.Token()
+	require.NoError(t, err)
+	assert.Equal(t, "my-token", token.Token)
+	assert.Equal(t, 2, requestCount)
+
+	// Token has expired and the token endpoint is still unavailable
+	provider.expiresAt = time.Now().Add(-time.Second)
+	_, err = provider.Token()
+	assert.Error(t, err)
+}
+
+func TestOAuthTokenProvider_TokenLifetime(t *testing.T) {
+	expiresIn := ""
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token":"my-token","token_type":"bearer"` + expiresIn + `}`))
+	}))
+	defer server.Close()
+
+	// Without expires_in the default lifetime is assumed
+	provider := newOAuthTokenProvider(SASLOAuthBearerConfig{TokenEndpoint: server.URL})
+	_, err := provider.Token()
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(defaultTokenLifetime), provider.expiresAt, time.Second)
+	assert.WithinDuration(t, time.Now().Add(defaultTokenLifetime-tokenRefreshMargin), provider.refreshAt, time.Second)
+
+	// Tokens whose lifetime is shorter than the refresh margin are cached for half of their lifetime
+	expiresIn = `,"expires_in":20`
+	provider = newOAuthTokenProvider(SASLOAuthBearerConfig{TokenEndpoint: server.URL})
+	_, err = provider.Token()
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(20*time.Second), provider.expiresAt, time.Second)
+	assert.WithinDuration(t, time.Now().Add(10*time.Second), provider.refreshAt, time.Second)
+}
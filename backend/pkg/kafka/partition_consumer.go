@@ -2,11 +2,14 @@ package kafka
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync/atomic"
 	"time"
 
 	"github.com/Shopify/sarama"
 	"github.com/cloudhut/kowl/backend/pkg/interpreter"
+	"github.com/cloudhut/kowl/backend/pkg/proto"
 	"github.com/dop251/goja"
 	"go.uber.org/zap"
 )
@@ -16,7 +19,9 @@ type IListMessagesProgress interface {
 	OnPhase(name string) // todo(?): eventually we might want to convert this into an enum
 	OnMessage(message *TopicMessage)
 	OnMessageConsumed(size int64)
-	OnComplete(elapsedMs int64, isCancelled bool)
+	// OnComplete is called once consuming has finished. If the time budget has been exhausted before, isPartial is set
+	// and offsetsReached contains the next offset to consume of each partition.
+	OnComplete(elapsedMs int64, isCancelled bool, isPartial bool, offsetsReached map[int32]int64)
 	OnError(msg string)
 }
 
@@ -26,22 +31,62 @@ type TopicMessage struct {
 	Offset      int64 `json:"offset"`
 	Timestamp   int64 `json:"timestamp"`
 
-	Headers   []MessageHeader      `json:"headers"`
+	Headers []MessageHeader `json:"headers"`
+
+	// HeadersByKey contains the values of all headers grouped by key, because Kafka allows duplicate header keys
+	HeadersByKey map[string][]*deserializedPayload `json:"headersByKey"`
+
 	Key       *deserializedPayload `json:"key"`
 	KeyType   string               `json:"keyType"`
 	Value     *deserializedPayload `json:"value"`
 	ValueType string               `json:"valueType"`
 
-	Size        int  `json:"size"`
+	// Decoder is the name of the decoder (of the topic's decoder chain) which accepted the payload
+	KeyDecoder       string `json:"keyDecoder"`
+	KeyContentType   string `json:"keyContentType"`
+	ValueDecoder     string `json:"valueDecoder"`
+	ValueContentType string `json:"valueContentType"`
+
+	// ValueCompression is the compression which was applied to the value by the producer (not the Kafka compression)
+	ValueCompression string `json:"valueCompression"`
+
+	// KeyValidation and ValueValidation are the results of validating the payloads against their schema, they are only
+	// set for JSON Schema payloads
+	KeyValidation   *payloadValidation `json:"keyValidation"`
+	ValueValidation *payloadValidation `json:"valueValidation"`
+
+	// ValueEnvelope describes the envelope the value has been unwrapped from, it is only set if a transform is
+	// configured for the topic
+	ValueEnvelope *payloadEnvelope `json:"valueEnvelope,omitempty"`
+
+	// KeyTransformError and ValueTransformError are set if the transform pipeline of the topic failed, the key or
+	// value is withheld in this case
+	KeyTransformError   string `json:"keyTransformError,omitempty"`
+	ValueTransformError string `json:"valueTransformError,omitempty"`
+
+	Size int `json:"size"`
+
+	// IsTombstone is true if the value is null, which deletes the key once the topic has been compacted. IsKeyNull is
+	// true if the key is null, empty keys are not null. Null payloads are not decoded.
+	IsTombstone bool `json:"isTombstone"`
+	IsKeyNull   bool `json:"isKeyNull"`
 	IsValueNull bool `json:"isValueNull"`
+
+	// Batch describes the record batch the message has been written in. It is only set for messages which have been
+	// fetched along with their batch attributes. ControlRecordType is "commit" or "abort" for control records
+	// (transaction markers), which are only returned on request.
+	Batch             *RecordBatchInfo `json:"batch,omitempty"`
+	ControlRecordType string           `json:"controlRecordType,omitempty"`
 }
 
 // MessageHeader represents the deserialized key/value pair of a Kafka key + value. The key and value in Kafka is in fact
-// a byte array, but keys are supposed to be strings only. Value however can be encoded in any format.
+// a byte array, but keys are supposed to be strings only. Value however can be encoded in any format. RawValue is
+// the value as it has been produced, which is passed base64 encoded to the frontend.
 type MessageHeader struct {
 	Key           string               `json:"key"`
 	Value         *deserializedPayload `json:"value"`
 	ValueEncoding messageEncoding      `json:"valueEncoding"`
+	RawValue      []byte               `json:"rawValue"`
 }
 
 // PartitionConsumeRequest is a partitionID along with it's calculated start and end offset.
@@ -63,6 +108,9 @@ type interpreterArguments struct {
 	Key          interface{}
 	Value        interface{}
 	HeadersByKey map[string]interface{}
+
+	// HeaderValuesByKey contains all values of each header key
+	HeaderValuesByKey map[string][]interface{}
 }
 
 type PartitionConsumer struct {
@@ -80,6 +128,45 @@ type PartitionConsumer struct {
 
 	Deserializer          *deserializer
 	FilterInterpreterCode string
+
+	// DeserializeHeaders decodes the header values with the topic's decoder chain. Otherwise they are returned as
+	// binary. HeaderFilter only passes messages which have a matching header.
+	DeserializeHeaders bool
+	HeaderFilter       HeaderFilter
+
+	// OffsetReached is updated (atomically) with the next offset to consume once a consumed message has either been
+	// sent via MessageCh or rejected by the filters, so that the caller can report how far the partition has been
+	// consumed. It's optional.
+	OffsetReached *int64
+}
+
+// HeaderFilter matches messages which have a header with the given key. If Value is set, the raw value of at least one
+// of the headers with that key must be equal to it.
+type HeaderFilter struct {
+	Key   string
+	Value *string
+}
+
+// IsEmpty returns true if no header key is set, in which case all messages match
+func (f HeaderFilter) IsEmpty() bool {
+	return f.Key == ""
+}
+
+// Matches returns true if the headers match the filter
+func (f HeaderFilter) Matches(headers []*sarama.RecordHeader) bool {
+	if f.IsEmpty() {
+		return true
+	}
+	for _, header := range headers {
+		if string(header.Key) != f.Key {
+			continue
+		}
+		if f.Value == nil || string(header.Value) == *f.Value {
+			return true
+		}
+	}
+
+	return false
 }
 
 func (p *PartitionConsumer) Run(ctx context.Context) {
@@ -120,39 +207,17 @@ func (p *PartitionConsumer) Run(ctx context.Context) {
 			messageSize := len(m.Key) + len(m.Value)
 			p.Progress.OnMessageConsumed(int64(messageSize))
 
-			// Run Interpreter filter and check if message passes the filter
-			value := p.Deserializer.DeserializePayload(m.Value)
-			key := p.Deserializer.DeserializePayload(m.Key)
-			headers := p.DeserializeHeaders(m.Headers)
-
-			topicMessage := &TopicMessage{
-				PartitionID: m.Partition,
-				Offset:      m.Offset,
-				Timestamp:   m.Timestamp.Unix(),
-				Headers:     headers,
-				Key:         key,
-				KeyType:     string(key.RecognizedEncoding),
-				Value:       value,
-				ValueType:   string(value.RecognizedEncoding),
-				Size:        len(m.Value),
-				IsValueNull: m.Value == nil,
-			}
-
-			headersByKey := make(map[string]interface{}, len(headers))
-			for _, header := range headers {
-				headersByKey[header.Key] = header.Value.Object
-			}
-
-			// Check if message passes filter code
-			args := interpreterArguments{
-				PartitionID:  m.Partition,
-				Offset:       m.Offset,
-				Timestamp:    m.Timestamp,
-				Key:          key.Object,
-				Value:        value.Object,
-				HeadersByKey: headersByKey,
+			// Messages without a matching header are dropped before they are deserialized
+			if !p.HeaderFilter.Matches(m.Headers) {
+				p.markOffsetReached(m.Offset)
+				if m.Offset >= p.Req.EndOffset {
+					return
+				}
+				continue
 			}
 
+			// Run Interpreter filter and check if message passes the filter
+			topicMessage, args := newTopicMessage(m, p.Deserializer, p.DeserializeHeaders)
 			isOK, err := isMessageOK(args)
 			if err != nil {
 				// TODO: This might be changed to debug level, because operators probably do not care about user failures?
@@ -172,10 +237,25 @@ func (p *PartitionConsumer) Run(ctx context.Context) {
 					// Message successfully sent via channel
 				}
 			}
+			p.markOffsetReached(m.Offset)
 
 			if m.Offset >= p.Req.EndOffset || messageCount == p.Req.MaxMessageCount {
 				return // reached end offset
 			}
+		case err, ok := <-pConsumer.Errors():
+			if !ok {
+				p.Logger.Error("partition Consumer error channel has unexpectedly closed")
+				return
+			}
+			if errors.Is(err.Err, sarama.ErrMessageTooLarge) {
+				// Sarama skips the record which is too large and continues with the next one
+				p.Progress.OnError(fmt.Sprintf("a record in partition %v is larger than the configured max partition fetch size "+
+					"(kafka.consumer.maxPartitionFetchBytes) and has been skipped", p.Req.PartitionID))
+				continue
+			}
+			p.Logger.Warn("partition consumer returned an error", zap.Int32("partition_id", p.Req.PartitionID), zap.Error(err))
+			p.Progress.OnError(fmt.Sprintf("partition consumer (partitionId=%v) failed to fetch messages: %v", p.Req.PartitionID, err.Err.Error()))
+			return
 		case <-ctx.Done():
 			p.Logger.Debug("consume request aborted because context has been cancelled")
 			return // search request aborted
@@ -183,17 +263,30 @@ func (p *PartitionConsumer) Run(ctx context.Context) {
 	}
 }
 
+// markOffsetReached sets OffsetReached to the offset after the given one, which has been completely processed
+func (p *PartitionConsumer) markOffsetReached(offset int64) {
+	if p.OffsetReached != nil {
+		atomic.StoreInt64(p.OffsetReached, offset+1)
+	}
+}
+
 // SetupInterpreter initializes the JavaScript interpreter along with the given JS code. It returns a wrapper function
 // which accepts all Kafka message properties (offset, key, value, ...) and returns true (message shall be returned) or false
 // (message shall be filtered).
 func (p *PartitionConsumer) SetupInterpreter() (func(args interpreterArguments) (bool, error), error) {
+	return newInterpreterFilter(p.FilterInterpreterCode)
+}
+
+// newInterpreterFilter compiles the given JavaScript filter code and returns a function which evaluates it for a single
+// message. If no code is given, the returned function allows all messages.
+func newInterpreterFilter(filterCode string) (func(args interpreterArguments) (bool, error), error) {
 	// In case there's no code for the interpreter let's return a dummy function which always allows all messages
-	if p.FilterInterpreterCode == "" {
+	if filterCode == "" {
 		return func(args interpreterArguments) (bool, error) { return true, nil }, nil
 	}
 
 	vm := goja.New()
-	code := fmt.Sprintf(`var isMessageOk = function() {%s}`, p.FilterInterpreterCode)
+	code := fmt.Sprintf(`var isMessageOk = function() {%s}`, filterCode)
 	_, err := vm.RunString(code)
 	if err != nil {
 		return nil, fmt.Errorf("failed to compile given interpreter code: %w", err)
@@ -233,6 +326,7 @@ func (p *PartitionConsumer) SetupInterpreter() (func(args interpreterArguments)
 		vm.Set("key", args.Key)
 		vm.Set("value", args.Value)
 		vm.Set("headers", args.HeadersByKey)
+		vm.Set("headersByKey", args.HeaderValuesByKey)
 		isOkRes, err := vm.RunString("isMessageOk()")
 		if err != nil {
 			return false, fmt.Errorf("failed to evaluate javascript code: %w", err)
@@ -244,17 +338,87 @@ func (p *PartitionConsumer) SetupInterpreter() (func(args interpreterArguments)
 	return isMessageOk, nil
 }
 
-func (p *PartitionConsumer) DeserializeHeaders(headers []*sarama.RecordHeader) []MessageHeader {
+// deserializeHeaders decodes the header values with the decoder chain of the topic. If decode is false the values are
+// returned as binary.
+func deserializeHeaders(d *deserializer, topicName string, headers []*sarama.RecordHeader, decode bool) []MessageHeader {
 	res := make([]MessageHeader, len(headers))
 	for i, header := range headers {
 		key := string(header.Key)
-		value := p.Deserializer.DeserializePayload(header.Value)
+		var value *deserializedPayload
+		switch {
+		case len(header.Value) == 0:
+			value = d.DeserializePayload(header.Value)
+		case decode:
+			value = d.DeserializeRecordPayload(header.Value, topicName, "")
+		default:
+			value, _ = decodeBinary(d, header.Value, topicName, "")
+			value.Decoder = decoderBinary
+		}
 		res[i] = MessageHeader{
 			Key:           key,
 			Value:         value,
 			ValueEncoding: value.RecognizedEncoding,
+			RawValue:      header.Value,
 		}
 	}
 
 	return res
 }
+
+// newTopicMessage deserializes the consumed message and returns it along with the arguments for the filter interpreter
+func newTopicMessage(m *sarama.ConsumerMessage, d *deserializer, decodeHeaders bool) (*TopicMessage, interpreterArguments) {
+	value := d.DeserializeRecordPayload(m.Value, m.Topic, proto.RecordValue)
+	key := d.DeserializeRecordPayload(m.Key, m.Topic, proto.RecordKey)
+	headers := deserializeHeaders(d, m.Topic, m.Headers, decodeHeaders)
+
+	// The filter code can access the last value of each header key via headers and all values via headersByKey
+	headersByKey := make(map[string]interface{}, len(headers))
+	headerValues := make(map[string][]*deserializedPayload, len(headers))
+	headerObjects := make(map[string][]interface{}, len(headers))
+	for _, header := range headers {
+		headersByKey[header.Key] = header.Value.Object
+		headerValues[header.Key] = append(headerValues[header.Key], header.Value)
+		headerObjects[header.Key] = append(headerObjects[header.Key], header.Value.Object)
+	}
+
+	topicMessage := &TopicMessage{
+		PartitionID:  m.Partition,
+		Offset:       m.Offset,
+		Timestamp:    m.Timestamp.Unix(),
+		Headers:      headers,
+		HeadersByKey: headerValues,
+		Key:          key,
+		KeyType:      string(key.RecognizedEncoding),
+		Value:        value,
+		ValueType:    string(value.RecognizedEncoding),
+
+		KeyDecoder:       key.Decoder,
+		KeyContentType:   key.ContentType,
+		ValueDecoder:     value.Decoder,
+		ValueContentType: value.ContentType,
+		ValueCompression: value.Compression,
+		KeyValidation:    key.Validation,
+		ValueValidation:  value.Validation,
+		ValueEnvelope:    value.Envelope,
+
+		KeyTransformError:   key.TransformError,
+		ValueTransformError: value.TransformError,
+
+		Size:        len(m.Value),
+		IsTombstone: m.Value == nil,
+		IsKeyNull:   m.Key == nil,
+		IsValueNull: m.Value == nil,
+	}
+
+	args := interpreterArguments{
+		PartitionID:       m.Partition,
+		Offset:            m.Offset,
+		Timestamp:         m.Timestamp,
+		Key:               key.Object,
+		Value:             value.Object,
+		HeadersByKey:      headersByKey,
+		HeaderValuesByKey: headerObjects,
+	}
+
+	return topicMessage, args
+}
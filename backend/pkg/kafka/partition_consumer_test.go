@@ -0,0 +1,127 @@
+package kafka
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/Shopify/sarama/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestNewTopicMessage_Headers(t *testing.T) {
+	m := &sarama.ConsumerMessage{
+		Topic: "orders",
+		Value: []byte("value"),
+		Headers: []*sarama.RecordHeader{
+			{Key: []byte("trace-id"), Value: []byte(`{"span":1}`)},
+			{Key: []byte("tag"), Value: []byte("a")},
+			{Key: []byte("tag"), Value: []byte("b")},
+		},
+	}
+	d := &deserializer{}
+
+	msg, args := newTopicMessage(m, d, true)
+	require.Len(t, msg.Headers, 3)
+	assert.Equal(t, messageEncodingJSON, msg.Headers[0].ValueEncoding)
+	assert.Equal(t, []byte(`{"span":1}`), msg.Headers[0].RawValue)
+	require.Len(t, msg.HeadersByKey["tag"], 2)
+	assert.Equal(t, "b", msg.HeadersByKey["tag"][1].Object)
+	assert.Equal(t, []interface{}{"a", "b"}, args.HeaderValuesByKey["tag"])
+
+	msg, _ = newTopicMessage(m, d, false)
+	assert.Equal(t, messageEncodingBinary, msg.Headers[0].ValueEncoding)
+	assert.Equal(t, []byte(`{"span":1}`), msg.Headers[0].RawValue)
+}
+
+func TestNewTopicMessage_Tombstones(t *testing.T) {
+	d := &deserializer{}
+	tt := []struct {
+		name      string
+		key       []byte
+		isKeyNull bool
+	}{
+		{"keyed topic", []byte("order-1"), false},
+		{"empty key", []byte{}, false},
+		{"unkeyed topic", nil, true},
+	}
+	for _, table := range tt {
+		msg, _ := newTopicMessage(&sarama.ConsumerMessage{Topic: "orders", Key: table.key, Value: nil}, d, false)
+		assert.True(t, msg.IsTombstone, table.name)
+		assert.True(t, msg.Value.IsNull(), table.name)
+		assert.Equal(t, string(messageEncodingNull), msg.ValueType, table.name)
+		assert.Equal(t, table.isKeyNull, msg.IsKeyNull, table.name)
+		assert.Equal(t, table.isKeyNull, msg.Key.IsNull(), table.name)
+
+		marshalled, err := msg.Value.MarshalJSON()
+		require.NoError(t, err)
+		assert.Equal(t, "null", string(marshalled), table.name)
+	}
+
+	// Empty values are not tombstones
+	msg, _ := newTopicMessage(&sarama.ConsumerMessage{Topic: "orders", Key: []byte("order-1"), Value: []byte{}}, d, false)
+	assert.False(t, msg.IsTombstone)
+	assert.Equal(t, string(messageEncodingNone), msg.ValueType)
+}
+
+func TestHeaderFilter_Matches(t *testing.T) {
+	headers := []*sarama.RecordHeader{{Key: []byte("tag"), Value: []byte("a")}, {Key: []byte("tag"), Value: []byte("b")}}
+	value := "b"
+	other := "c"
+
+	assert.True(t, HeaderFilter{}.Matches(headers))
+	assert.True(t, HeaderFilter{Key: "tag"}.Matches(headers))
+	assert.True(t, HeaderFilter{Key: "tag", Value: &value}.Matches(headers))
+	assert.False(t, HeaderFilter{Key: "tag", Value: &other}.Matches(headers))
+	assert.False(t, HeaderFilter{Key: "trace-id"}.Matches(headers))
+}
+
+// nopProgress ignores all progress updates of a partition consumer
+type nopProgress struct{}
+
+func (nopProgress) OnPhase(string)                                {}
+func (nopProgress) OnMessage(*TopicMessage)                       {}
+func (nopProgress) OnMessageConsumed(int64)                       {}
+func (nopProgress) OnComplete(int64, bool, bool, map[int32]int64) {}
+func (nopProgress) OnError(string)                                {}
+
+func TestPartitionConsumer_OffsetReached(t *testing.T) {
+	consumer := mocks.NewConsumer(t, nil)
+	pConsumer := consumer.ExpectConsumePartition("orders", 0, 0)
+	tag := []*sarama.RecordHeader{{Key: []byte("tag"), Value: []byte("a")}}
+	// The mock assigns the offsets 1, 2 and 3
+	pConsumer.YieldMessage(&sarama.ConsumerMessage{})
+	pConsumer.YieldMessage(&sarama.ConsumerMessage{Headers: tag})
+	pConsumer.YieldMessage(&sarama.ConsumerMessage{Headers: tag})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	doneCh := make(chan struct{}, 1)
+	messageCh := make(chan *TopicMessage)
+	offsetReached := int64(0)
+	p := PartitionConsumer{
+		Logger:        zap.NewNop(),
+		DoneCh:        doneCh,
+		MessageCh:     messageCh,
+		Progress:      nopProgress{},
+		Consumer:      consumer,
+		TopicName:     "orders",
+		Req:           &PartitionConsumeRequest{PartitionID: 0, StartOffset: 0, EndOffset: 3, MaxMessageCount: 10},
+		Deserializer:  &deserializer{},
+		HeaderFilter:  HeaderFilter{Key: "tag"},
+		OffsetReached: &offsetReached,
+	}
+	go p.Run(ctx)
+
+	// Offset 1 is rejected by the header filter, offset 2 is received and offset 3 is never received
+	msg := <-messageCh
+	assert.Equal(t, int64(2), msg.Offset)
+	assert.Eventually(t, func() bool { return atomic.LoadInt64(&offsetReached) == 3 }, time.Second, time.Millisecond)
+	cancel()
+	<-doneCh
+	assert.Equal(t, int64(3), atomic.LoadInt64(&offsetReached), "messages which have not been sent must not be skipped")
+}
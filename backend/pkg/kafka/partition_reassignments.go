@@ -0,0 +1,194 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Shopify/sarama"
+)
+
+const (
+	alterPartitionReassignmentsAPIKey = 45
+	listPartitionReassignmentsAPIKey  = 46
+)
+
+// PartitionReassignment is the target replica list of a single partition
+type PartitionReassignment struct {
+	PartitionID int32
+	Replicas    []int32
+}
+
+// PartitionReassignmentStatus is the state of a partition reassignment which is still in progress
+type PartitionReassignmentStatus struct {
+	TopicName        string
+	PartitionID      int32
+	Replicas         []int32
+	AddingReplicas   []int32
+	RemovingReplicas []int32
+}
+
+// ReassignPartitions submits a reassignment of the given partitions (topic name -> reassignments) to new replica
+// lists. The brokers move the replicas in the background, the progress can be queried via ListPartitionReassignments.
+//
+// The admin client always submits a replica list for all partitions of a topic, starting at partition 0. Partitions
+// which shall not be moved are therefore submitted with their current replicas, which is a no-op for the brokers. In
+// order to not override reassignments which are still in progress, topics with ongoing reassignments are rejected.
+func (s *Service) ReassignPartitions(ctx context.Context, reassignments map[string][]PartitionReassignment) (err error) {
+	defer s.observeOperation(ctx, operationTypeAdmin, "reassign_partitions", time.Now(), &err)
+	defer s.InvalidateTopicMetadataCache()
+	if len(reassignments) == 0 {
+		return fmt.Errorf("%w: at least one partition must be reassigned", ErrInvalidReassignment)
+	}
+	if !s.supportsAPI(alterPartitionReassignmentsAPIKey) {
+		return ErrPartitionReassignmentsNotSupported
+	}
+
+	topicNames := make([]string, 0, len(reassignments))
+	for topicName := range reassignments {
+		topicNames = append(topicNames, topicName)
+	}
+	err = runWithContext(ctx, func() error {
+		return s.Client.RefreshMetadata(topicNames...)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to refresh topic metadata: %w", err)
+	}
+
+	inProgress, err := s.ListPartitionReassignments(ctx, topicNames)
+	if err != nil {
+		return err
+	}
+	for _, status := range inProgress {
+		return fmt.Errorf("%w: topic '%v' has a reassignment in progress for partition %v",
+			ErrInvalidReassignment, status.TopicName, status.PartitionID)
+	}
+
+	// Build and validate the complete assignment of all topics before anything is submitted
+	assignments := make(map[string][][]int32, len(reassignments))
+	for topicName, partitions := range reassignments {
+		current, err := s.currentReplicaAssignment(topicName)
+		if err != nil {
+			return err
+		}
+		assignment, err := mergeReplicaAssignment(current, partitions, s.brokerIDs())
+		if err != nil {
+			return fmt.Errorf("%w: topic '%v': %v", ErrInvalidReassignment, topicName, err)
+		}
+		assignments[topicName] = assignment
+	}
+
+	for topicName, assignment := range assignments {
+		err := runWithContext(ctx, func() error {
+			return s.AdminClient.AlterPartitionReassignments(topicName, assignment)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to reassign partitions of topic '%v': %w", topicName, err)
+		}
+	}
+
+	return nil
+}
+
+// ListPartitionReassignments returns all partitions of the given topics whose reassignment is still in progress
+func (s *Service) ListPartitionReassignments(ctx context.Context, topicNames []string) (statuses []PartitionReassignmentStatus, err error) {
+	defer s.observeOperation(ctx, operationTypeAdmin, "list_partition_reassignments", time.Now(), &err)
+	if !s.supportsAPI(listPartitionReassignmentsAPIKey) {
+		return nil, ErrPartitionReassignmentsNotSupported
+	}
+
+	request := &sarama.ListPartitionReassignmentsRequest{TimeoutMs: 60000}
+	for _, topicName := range topicNames {
+		partitionIDs, err := s.ListPartitions(topicName)
+		if err != nil {
+			return nil, err
+		}
+		request.AddBlock(topicName, partitionIDs)
+	}
+
+	// The admin client only supports a single topic and ignores the error code of the response
+	controller, err := s.Client.Controller()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get controller: %w", err)
+	}
+	var res *sarama.ListPartitionReassignmentsResponse
+	err = runWithContext(ctx, func() error {
+		var err error
+		res, err = controller.ListPartitionReassignments(request)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if res.ErrorCode != sarama.ErrNoError {
+		if res.ErrorMessage != nil {
+			return nil, fmt.Errorf("%w: %v", res.ErrorCode, *res.ErrorMessage)
+		}
+		return nil, res.ErrorCode
+	}
+
+	statuses = make([]PartitionReassignmentStatus, 0)
+	for topicName, partitions := range res.TopicStatus {
+		for partitionID, status := range partitions {
+			statuses = append(statuses, PartitionReassignmentStatus{
+				TopicName:        topicName,
+				PartitionID:      partitionID,
+				Replicas:         status.Replicas,
+				AddingReplicas:   status.AddingReplicas,
+				RemovingReplicas: status.RemovingReplicas,
+			})
+		}
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		if statuses[i].TopicName != statuses[j].TopicName {
+			return statuses[i].TopicName < statuses[j].TopicName
+		}
+		return statuses[i].PartitionID < statuses[j].PartitionID
+	})
+
+	return statuses, nil
+}
+
+// currentReplicaAssignment returns the replicas of each partition of a topic, indexed by partition ID
+func (s *Service) currentReplicaAssignment(topicName string) ([][]int32, error) {
+	partitionIDs, err := s.ListPartitions(topicName)
+	if err != nil {
+		return nil, err
+	}
+
+	assignment := make([][]int32, len(partitionIDs))
+	for _, partitionID := range partitionIDs {
+		if int(partitionID) >= len(assignment) {
+			return nil, fmt.Errorf("topic '%v' has non consecutive partition IDs", topicName)
+		}
+		replicas, err := s.Client.Replicas(topicName, partitionID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get replicas of partition %v: %w", partitionID, err)
+		}
+		assignment[partitionID] = replicas
+	}
+
+	return assignment, nil
+}
+
+// mergeReplicaAssignment replaces the current replicas of the reassigned partitions. The target brokers must exist and
+// the number of replicas must match the current replication factor of the partition.
+func mergeReplicaAssignment(current [][]int32, reassignments []PartitionReassignment, brokerIDs []int32) ([][]int32, error) {
+	merged := make([][]int32, len(current))
+	copy(merged, current)
+
+	for _, reassignment := range reassignments {
+		partitionID := reassignment.PartitionID
+		if partitionID < 0 || int(partitionID) >= len(current) {
+			return nil, fmt.Errorf("partition %v does not exist", partitionID)
+		}
+		err := validateReplicas(partitionID, reassignment.Replicas, len(current[partitionID]), brokerIDs)
+		if err != nil {
+			return nil, err
+		}
+		merged[partitionID] = reassignment.Replicas
+	}
+
+	return merged, nil
+}
@@ -0,0 +1,31 @@
+package kafka
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeReplicaAssignment(t *testing.T) {
+	brokerIDs := []int32{1, 2, 3, 4}
+	current := [][]int32{{1, 2}, {2, 3}, {3, 1}}
+
+	// Move all replicas off broker 3
+	merged, err := mergeReplicaAssignment(current, []PartitionReassignment{
+		{PartitionID: 1, Replicas: []int32{2, 4}},
+		{PartitionID: 2, Replicas: []int32{4, 1}},
+	}, brokerIDs)
+	require.NoError(t, err)
+	assert.Equal(t, [][]int32{{1, 2}, {2, 4}, {4, 1}}, merged)
+	assert.Equal(t, []int32{2, 3}, current[1], "current assignment must not be modified")
+
+	_, err = mergeReplicaAssignment(current, []PartitionReassignment{{PartitionID: 0, Replicas: []int32{1, 5}}}, brokerIDs)
+	assert.Error(t, err, "unknown broker")
+
+	_, err = mergeReplicaAssignment(current, []PartitionReassignment{{PartitionID: 0, Replicas: []int32{1, 2, 4}}}, brokerIDs)
+	assert.Error(t, err, "replica count does not match replication factor")
+
+	_, err = mergeReplicaAssignment(current, []PartitionReassignment{{PartitionID: 3, Replicas: []int32{1, 2}}}, brokerIDs)
+	assert.Error(t, err, "unknown partition")
+}
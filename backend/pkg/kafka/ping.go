@@ -0,0 +1,169 @@
+package kafka
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/Shopify/sarama"
+)
+
+// PingErrorKind describes at which stage the connection attempt to a broker has failed
+type PingErrorKind string
+
+const (
+	PingErrorKindDNS        PingErrorKind = "DNS"
+	PingErrorKindConnection PingErrorKind = "CONNECTION"
+	PingErrorKindTLS        PingErrorKind = "TLS"
+	PingErrorKindSASL       PingErrorKind = "SASL"
+	PingErrorKindTimeout    PingErrorKind = "TIMEOUT"
+	PingErrorKindUnknown    PingErrorKind = "UNKNOWN"
+)
+
+// PingError is returned by PingCluster if none of the seed brokers could be reached
+type PingError struct {
+	Kind   PingErrorKind
+	Broker string
+	Err    error
+}
+
+func (e *PingError) Error() string {
+	return fmt.Sprintf("failed to ping broker '%v' (%v): %v", e.Broker, e.Kind, e.Err)
+}
+
+func (e *PingError) Unwrap() error {
+	return e.Err
+}
+
+// PingCluster builds the sarama config for the given Kafka config, connects to the first reachable seed broker and
+// issues an ApiVersions request. It returns a *PingError for the last broker which has been tried if no broker could be
+// reached. All opened connections are closed before the function returns.
+func PingCluster(ctx context.Context, cfg *Config) error {
+	saramaConfig, err := NewSaramaConfig(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create a valid sarama config: %w", err)
+	}
+
+	if len(cfg.Brokers) == 0 {
+		return fmt.Errorf("no seed brokers configured")
+	}
+
+	// Make sure we do not exceed the context deadline while dialing or waiting for responses
+	if deadline, ok := ctx.Deadline(); ok {
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return &PingError{Kind: PingErrorKindTimeout, Broker: cfg.Brokers[0], Err: ctx.Err()}
+		}
+		if remaining < saramaConfig.Net.DialTimeout {
+			saramaConfig.Net.DialTimeout = remaining
+		}
+		if remaining < saramaConfig.Net.ReadTimeout {
+			saramaConfig.Net.ReadTimeout = remaining
+		}
+		if remaining < saramaConfig.Net.WriteTimeout {
+			saramaConfig.Net.WriteTimeout = remaining
+		}
+	}
+
+	var lastErr error
+	for _, addr := range cfg.Brokers {
+		err := pingBroker(ctx, saramaConfig, addr)
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	return lastErr
+}
+
+// pingBroker opens a connection to a single broker and sends an ApiVersions request.
+func pingBroker(ctx context.Context, saramaConfig *sarama.Config, addr string) error {
+	broker := sarama.NewBroker(addr)
+	err := broker.Open(saramaConfig)
+	if err != nil {
+		return &PingError{Kind: PingErrorKindUnknown, Broker: addr, Err: err}
+	}
+
+	type result struct {
+		err       error
+		connected bool
+	}
+	resCh := make(chan result, 1)
+	go func() {
+		// Connected() blocks until the connection (including the TLS & SASL handshake) has been established
+		_, err := broker.Connected()
+		if err != nil {
+			resCh <- result{err: err}
+			return
+		}
+		_, err = broker.ApiVersions(&sarama.ApiVersionsRequest{})
+		resCh <- result{err: err, connected: true}
+	}()
+
+	select {
+	case <-ctx.Done():
+		// Close blocks until the connection attempt has completed, hence we don't wait for it
+		go func() { _ = broker.Close() }()
+		return &PingError{Kind: PingErrorKindTimeout, Broker: addr, Err: ctx.Err()}
+	case res := <-resCh:
+		_ = broker.Close()
+		if res.err == nil {
+			return nil
+		}
+		isAuthStage := saramaConfig.Net.SASL.Enable && !res.connected
+		return &PingError{Kind: classifyPingError(res.err, isAuthStage), Broker: addr, Err: res.err}
+	}
+}
+
+// classifyPingError tries to find out at which stage the connection has failed. isAuthStage must be true if the error
+// has been returned while the SASL handshake was in progress.
+func classifyPingError(err error, isAuthStage bool) PingErrorKind {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return PingErrorKindDNS
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return PingErrorKindTimeout
+	}
+
+	if isTLSError(err) {
+		return PingErrorKindTLS
+	}
+
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return PingErrorKindConnection
+	}
+
+	if errors.Is(err, sarama.ErrSASLAuthenticationFailed) || isAuthStage {
+		return PingErrorKindSASL
+	}
+
+	return PingErrorKindUnknown
+}
+
+func isTLSError(err error) bool {
+	var recordHeaderErr tls.RecordHeaderError
+	var unknownAuthorityErr x509.UnknownAuthorityError
+	var certInvalidErr x509.CertificateInvalidError
+	var hostnameErr x509.HostnameError
+	if errors.As(err, &recordHeaderErr) || errors.As(err, &unknownAuthorityErr) ||
+		errors.As(err, &certInvalidErr) || errors.As(err, &hostnameErr) {
+		return true
+	}
+
+	// TLS alerts sent by the broker are not exported as a distinct error type
+	return strings.Contains(err.Error(), "tls: ")
+}
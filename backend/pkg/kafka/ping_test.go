@@ -0,0 +1,57 @@
+package kafka
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassifyPingError(t *testing.T) {
+	tt := []struct {
+		name        string
+		err         error
+		isAuthStage bool
+		expected    PingErrorKind
+	}{
+		{"dns", &net.OpError{Op: "dial", Err: &net.DNSError{Err: "no such host", Name: "kafka"}}, false, PingErrorKindDNS},
+		{"connection refused", &net.OpError{Op: "dial", Err: errors.New("connection refused")}, false, PingErrorKindConnection},
+		{"timeout", &net.OpError{Op: "read", Err: os.ErrDeadlineExceeded}, true, PingErrorKindTimeout},
+		{"tls unknown authority", x509.UnknownAuthorityError{}, true, PingErrorKindTLS},
+		{"tls alert", errors.New("remote error: tls: bad certificate"), false, PingErrorKindTLS},
+		{"sasl failed", sarama.ErrSASLAuthenticationFailed, false, PingErrorKindSASL},
+		{"sasl stage", sarama.ErrOutOfBrokers, true, PingErrorKindSASL},
+		{"unknown", sarama.ErrOutOfBrokers, false, PingErrorKindUnknown},
+	}
+
+	for _, table := range tt {
+		assert.Equal(t, table.expected, classifyPingError(table.err, table.isAuthStage), table.name)
+	}
+}
+
+func TestPingCluster_ConnectionRefused(t *testing.T) {
+	// Grab a free port and close the listener again, so that nobody is listening on it
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	addr := listener.Addr().String()
+	listener.Close()
+
+	cfg := Config{Brokers: []string{addr}}
+	cfg.SetDefaults()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err = PingCluster(ctx, &cfg)
+	var pingErr *PingError
+	if assert.True(t, errors.As(err, &pingErr)) {
+		assert.Equal(t, PingErrorKindConnection, pingErr.Kind)
+		assert.Equal(t, addr, pingErr.Broker)
+	}
+}
@@ -0,0 +1,263 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Shopify/sarama"
+)
+
+// ProduceMessageRequest describes a single message which shall be produced. If PartitionID is nil, the partition is
+// chosen by hashing the key (or randomly if there is no key). If Compression is empty, the configured default
+// compression is used.
+type ProduceMessageRequest struct {
+	TopicName   string
+	PartitionID *int32
+	Key         []byte
+	Headers     []sarama.RecordHeader
+	Value       []byte
+	Compression string
+}
+
+// ProduceMessageResponse is the partition and offset the produced message has been written to
+type ProduceMessageResponse struct {
+	PartitionID int32
+	Offset      int64
+}
+
+// producer lazily creates the sync producers, because most Kowl deployments never produce a message. The compression
+// is a producer setting in sarama, hence there is one producer per used compression codec.
+type producer struct {
+	mutex     sync.Mutex
+	producers map[sarama.CompressionCodec]sarama.SyncProducer
+}
+
+// ProduceMessage produces a single message with a sync producer and returns the partition and offset it has been
+// written to. If the context is done while the message is being sent, ctx.Err() is returned, but the message might
+// still be written.
+func (s *Service) ProduceMessage(ctx context.Context, req ProduceMessageRequest) (res *ProduceMessageResponse, err error) {
+	defer s.observeOperation(ctx, operationTypeProduce, "produce_message", time.Now(), &err)
+	ctx, done, err := s.TrackOperation(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	exists, err := s.TopicExists(ctx, req.TopicName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check whether the topic exists: %w", err)
+	}
+	if !exists {
+		return nil, ErrTopicNotFound
+	}
+
+	msg, err := s.newProducerMessage(req)
+	if err != nil {
+		return nil, err
+	}
+	codec, err := s.producerCompression(req.Compression)
+	if err != nil {
+		return nil, err
+	}
+
+	syncProducer, err := s.syncProducer(codec)
+	if err != nil {
+		return nil, err
+	}
+	var partitionID int32
+	var offset int64
+	err = runWithContext(ctx, func() error {
+		var err error
+		partitionID, offset, err = syncProducer.SendMessage(msg)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to produce message: %w", err)
+	}
+
+	return &ProduceMessageResponse{PartitionID: partitionID, Offset: offset}, nil
+}
+
+// ProduceMessagesResult is the outcome of a single message of a batch. Err is set if the message couldn't be produced.
+type ProduceMessagesResult struct {
+	PartitionID int32
+	Offset      int64
+	Err         error
+}
+
+// ProduceMessages produces a batch of messages with the sync producer. A message which can't be produced doesn't
+// abort the batch, the returned results are in the same order as the requests and carry the error of each message.
+// An error is only returned if the batch couldn't be sent at all.
+func (s *Service) ProduceMessages(ctx context.Context, reqs []ProduceMessageRequest) (res []ProduceMessagesResult, err error) {
+	defer s.observeOperation(ctx, operationTypeProduce, "produce_messages", time.Now(), &err)
+	ctx, done, err := s.TrackOperation(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	topics, err := s.ListTopics(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check whether the topics exist: %w", err)
+	}
+	existingTopics := make(map[string]bool, len(topics))
+	for _, topic := range topics {
+		existingTopics[topic.Name] = true
+	}
+
+	// Messages are grouped by their compression, because each codec is sent by its own producer
+	res = make([]ProduceMessagesResult, len(reqs))
+	codecs := make([]sarama.CompressionCodec, 0)
+	msgsByCodec := make(map[sarama.CompressionCodec][]*sarama.ProducerMessage)
+	indexByMsg := make(map[*sarama.ProducerMessage]int, len(reqs))
+	for i, req := range reqs {
+		if !existingTopics[req.TopicName] {
+			res[i].Err = ErrTopicNotFound
+			continue
+		}
+		msg, err := s.newProducerMessage(req)
+		if err != nil {
+			res[i].Err = err
+			continue
+		}
+		codec, err := s.producerCompression(req.Compression)
+		if err != nil {
+			res[i].Err = err
+			continue
+		}
+		if _, exists := msgsByCodec[codec]; !exists {
+			codecs = append(codecs, codec)
+		}
+		msgsByCodec[codec] = append(msgsByCodec[codec], msg)
+		indexByMsg[msg] = i
+	}
+
+	for _, codec := range codecs {
+		msgs := msgsByCodec[codec]
+		syncProducer, err := s.syncProducer(codec)
+		if err != nil {
+			return nil, err
+		}
+		err = runWithContext(ctx, func() error {
+			return syncProducer.SendMessages(msgs)
+		})
+		var producerErrs sarama.ProducerErrors
+		if err != nil && !errors.As(err, &producerErrs) {
+			return nil, fmt.Errorf("failed to produce messages: %w", err)
+		}
+
+		for _, msg := range msgs {
+			i := indexByMsg[msg]
+			res[i].PartitionID = msg.Partition
+			res[i].Offset = msg.Offset
+		}
+		for _, producerErr := range producerErrs {
+			res[indexByMsg[producerErr.Msg]].Err = fmt.Errorf("failed to produce message: %w", producerErr.Err)
+		}
+	}
+
+	return res, nil
+}
+
+// producerCompression returns the codec with the given name, or the configured default codec if the name is empty
+func (s *Service) producerCompression(name string) (sarama.CompressionCodec, error) {
+	if name == "" {
+		name = s.Config.Producer.Compression
+	}
+	version, err := parseClusterVersion(s.Config.ClusterVersion)
+	if err != nil {
+		return sarama.CompressionNone, err
+	}
+
+	return parseCompressionCodec(name, version)
+}
+
+// newProducerMessage validates the requested partition and converts the request into a sarama message
+func (s *Service) newProducerMessage(req ProduceMessageRequest) (*sarama.ProducerMessage, error) {
+	msg := &sarama.ProducerMessage{
+		Topic:   req.TopicName,
+		Headers: req.Headers,
+		Value:   sarama.ByteEncoder(req.Value),
+	}
+	if req.Key != nil {
+		msg.Key = sarama.ByteEncoder(req.Key)
+	}
+	if req.PartitionID != nil {
+		partitionIDs, err := s.ListPartitions(req.TopicName)
+		if err != nil {
+			return nil, err
+		}
+		if *req.PartitionID < 0 || int(*req.PartitionID) >= len(partitionIDs) {
+			return nil, fmt.Errorf("%w: partition %v does not exist", ErrInvalidPartition, *req.PartitionID)
+		}
+		msg.Metadata = explicitPartition(*req.PartitionID)
+	}
+
+	return msg, nil
+}
+
+// syncProducer returns the shared sync producer for the codec and creates it if it doesn't exist yet
+func (s *Service) syncProducer(codec sarama.CompressionCodec) (sarama.SyncProducer, error) {
+	s.producer.mutex.Lock()
+	defer s.producer.mutex.Unlock()
+
+	if syncProducer, exists := s.producer.producers[codec]; exists {
+		return syncProducer, nil
+	}
+
+	cfg, err := NewProducerConfig(&s.Config, codec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create a valid producer config: %w", err)
+	}
+	syncProducer, err := sarama.NewSyncProducer(s.Config.Brokers, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create producer: %w", err)
+	}
+	if s.producer.producers == nil {
+		s.producer.producers = make(map[sarama.CompressionCodec]sarama.SyncProducer)
+	}
+	s.producer.producers[codec] = syncProducer
+
+	return syncProducer, nil
+}
+
+// closeProducer closes the sync producers which have been created and returns the first error
+func (s *Service) closeProducer() error {
+	s.producer.mutex.Lock()
+	defer s.producer.mutex.Unlock()
+
+	var firstErr error
+	for codec, syncProducer := range s.producer.producers {
+		err := syncProducer.Close()
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+		delete(s.producer.producers, codec)
+	}
+	return firstErr
+}
+
+// explicitPartition is set as message metadata if the message must be written to a specific partition
+type explicitPartition int32
+
+// explicitPartitioner writes messages with an explicitPartition as metadata to the given partition, all other
+// messages are partitioned by sarama's default hash partitioner.
+type explicitPartitioner struct {
+	hash sarama.Partitioner
+}
+
+func newExplicitPartitioner(topic string) sarama.Partitioner {
+	return &explicitPartitioner{hash: sarama.NewHashPartitioner(topic)}
+}
+
+func (p *explicitPartitioner) Partition(message *sarama.ProducerMessage, numPartitions int32) (int32, error) {
+	if partition, ok := message.Metadata.(explicitPartition); ok {
+		return int32(partition), nil
+	}
+	return p.hash.Partition(message, numPartitions)
+}
+
+func (p *explicitPartitioner) RequiresConsistency() bool {
+	return p.hash.RequiresConsistency()
+}
@@ -0,0 +1,25 @@
+package kafka
+
+import (
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExplicitPartitioner(t *testing.T) {
+	partitioner := newExplicitPartitioner("orders")
+
+	partition, err := partitioner.Partition(&sarama.ProducerMessage{Metadata: explicitPartition(4)}, 6)
+	require.NoError(t, err)
+	assert.Equal(t, int32(4), partition)
+
+	// Messages without explicit partition are hashed by their key
+	keyed := &sarama.ProducerMessage{Key: sarama.StringEncoder("customer-1")}
+	first, err := partitioner.Partition(keyed, 6)
+	require.NoError(t, err)
+	second, err := partitioner.Partition(keyed, 6)
+	require.NoError(t, err)
+	assert.Equal(t, first, second)
+}
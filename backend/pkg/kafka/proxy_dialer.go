@@ -0,0 +1,146 @@
+package kafka
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"golang.org/x/net/proxy"
+)
+
+// newProxyDialer returns a dialer which connects to the target address through the configured proxy. TLS is
+// established by sarama on top of the returned connection, so that TLS still terminates at the broker.
+func newProxyDialer(cfg ProxyConfig, forward *net.Dialer) (proxy.Dialer, error) {
+	u, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse proxy url: %w", redactURLError(err))
+	}
+	if cfg.Username != "" {
+		u.User = url.UserPassword(cfg.Username, cfg.Password)
+	}
+
+	switch u.Scheme {
+	case "socks5":
+		dialer, err := proxy.FromURL(u, forward)
+		if err != nil {
+			return nil, err
+		}
+		return &socks5Dialer{dialer: dialer.(proxy.ContextDialer), timeout: forward.Timeout}, nil
+	case "http", "https":
+		return &httpConnectDialer{proxyURL: u, forward: forward}, nil
+	default:
+		return nil, fmt.Errorf("proxy scheme '%v' is not supported", u.Scheme)
+	}
+}
+
+// socks5Dialer tunnels connections through a SOCKS5 proxy. The handshake with the proxy is bound by the same timeout
+// as the HTTP CONNECT handshake, the dialer's timeout alone only covers establishing the TCP connection.
+type socks5Dialer struct {
+	dialer  proxy.ContextDialer
+	timeout time.Duration
+}
+
+func (d *socks5Dialer) Dial(network, addr string) (net.Conn, error) {
+	ctx := context.Background()
+	if d.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d.timeout)
+		defer cancel()
+	}
+
+	return d.dialer.DialContext(ctx, network, addr)
+}
+
+// httpConnectDialer tunnels connections through a HTTP proxy using the CONNECT method. The connection to the proxy
+// itself is secured with TLS if the proxy url has the https scheme.
+type httpConnectDialer struct {
+	proxyURL *url.URL
+	forward  *net.Dialer
+}
+
+func (d *httpConnectDialer) Dial(network, addr string) (net.Conn, error) {
+	conn, err := d.forward.Dial(network, proxyAddress(d.proxyURL))
+	if err != nil {
+		return nil, err
+	}
+
+	// The dialer's timeout only covers establishing the TCP connection, the handshake with the proxy must not block
+	// forever either
+	if d.forward.Timeout > 0 {
+		conn.SetDeadline(time.Now().Add(d.forward.Timeout))
+	}
+	if d.proxyURL.Scheme == "https" {
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: d.proxyURL.Hostname()})
+		err = tlsConn.Handshake()
+		if err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to establish tls with proxy: %w", err)
+		}
+		conn = tlsConn
+	}
+
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: make(http.Header),
+	}
+	if d.proxyURL.User != nil {
+		password, _ := d.proxyURL.User.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(d.proxyURL.User.Username() + ":" + password))
+		req.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+
+	err = req.Write(conn)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to send connect request to proxy: %w", err)
+	}
+
+	reader := bufio.NewReader(conn)
+	res, err := http.ReadResponse(reader, req)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to read connect response from proxy: %w", err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("proxy refused to connect to '%v': %v", addr, res.Status)
+	}
+	conn.SetDeadline(time.Time{})
+
+	if reader.Buffered() > 0 {
+		// The proxy has already sent data from the target, which must not get lost
+		return &bufferedConn{Conn: conn, reader: reader}, nil
+	}
+
+	return conn, nil
+}
+
+// proxyAddress returns the host and port of the proxy url, the port defaults to the one of the url's scheme
+func proxyAddress(u *url.URL) string {
+	if u.Port() != "" {
+		return u.Host
+	}
+	if u.Scheme == "https" {
+		return net.JoinHostPort(u.Hostname(), "443")
+	}
+	return net.JoinHostPort(u.Hostname(), "80")
+}
+
+// bufferedConn reads from a buffered reader first, before it reads from the underlying connection
+type bufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.reader.Read(b)
+}
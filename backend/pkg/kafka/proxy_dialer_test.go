@@ -0,0 +1,220 @@
+package kafka
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/binary"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProxy_SOCKS5WithTLS(t *testing.T) {
+	certPEM, tlsCert := newLocalhostCert(t)
+
+	// Mock broker which terminates TLS itself
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{tlsCert}})
+	require.NoError(t, err)
+	broker := sarama.NewMockBrokerListener(t, 1, listener)
+	defer broker.Close()
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).SetBroker(broker.Addr(), broker.BrokerID()),
+	})
+
+	proxyAddr, proxiedConnections := startSOCKS5Server(t)
+
+	cfg := Config{Brokers: []string{broker.Addr()}}
+	cfg.SetDefaults()
+	cfg.TLS.Enabled = true
+	cfg.TLS.CaPem = string(certPEM)
+	cfg.Proxy.URL = "socks5://" + proxyAddr
+
+	saramaConfig, err := NewSaramaConfig(&cfg)
+	require.NoError(t, err)
+
+	// The broker's certificate is verified, hence TLS must have been established with the broker, not the proxy
+	client, err := sarama.NewClient(cfg.Brokers, saramaConfig)
+	require.NoError(t, err)
+	defer client.Close()
+
+	assert.Len(t, client.Brokers(), 1)
+	assert.True(t, atomic.LoadInt32(proxiedConnections) > 0)
+}
+
+// startSOCKS5Server starts a minimal SOCKS5 server (no auth, CONNECT only) and returns its address along with a
+// counter of the proxied connections.
+func startSOCKS5Server(t *testing.T) (string, *int32) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	var connections int32
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			atomic.AddInt32(&connections, 1)
+			go handleSOCKS5Conn(conn)
+		}
+	}()
+
+	return listener.Addr().String(), &connections
+}
+
+func handleSOCKS5Conn(conn net.Conn) {
+	defer conn.Close()
+
+	// Greeting: version, number of methods, methods
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return
+	}
+	if _, err := io.ReadFull(conn, make([]byte, header[1])); err != nil {
+		return
+	}
+	conn.Write([]byte{5, 0}) // No authentication required
+
+	// Request: version, cmd, reserved, address type, address, port
+	req := make([]byte, 4)
+	if _, err := io.ReadFull(conn, req); err != nil {
+		return
+	}
+	var host string
+	switch req[3] {
+	case 1: // IPv4
+		ip := make([]byte, 4)
+		io.ReadFull(conn, ip)
+		host = net.IP(ip).String()
+	case 3: // Domain name
+		length := make([]byte, 1)
+		io.ReadFull(conn, length)
+		domain := make([]byte, length[0])
+		io.ReadFull(conn, domain)
+		host = string(domain)
+	default:
+		return
+	}
+	portBytes := make([]byte, 2)
+	io.ReadFull(conn, portBytes)
+	port := binary.BigEndian.Uint16(portBytes)
+
+	target, err := net.Dial("tcp", net.JoinHostPort(host, strconv.Itoa(int(port))))
+	if err != nil {
+		conn.Write([]byte{5, 1, 0, 1, 0, 0, 0, 0, 0, 0})
+		return
+	}
+	defer target.Close()
+	conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+
+	go io.Copy(target, conn)
+	io.Copy(conn, target)
+}
+
+// newLocalhostCert creates a self signed certificate which is valid for 127.0.0.1
+func newLocalhostCert(t *testing.T) ([]byte, tls.Certificate) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "kafka"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+
+	tlsCert, err := tls.X509KeyPair(certPEM, keyPEM)
+	require.NoError(t, err)
+
+	return certPEM, tlsCert
+}
+
+func TestProxyAddress(t *testing.T) {
+	tests := []struct {
+		url      string
+		expected string
+	}{
+		{"http://proxy:3128", "proxy:3128"},
+		{"http://proxy", "proxy:80"},
+		{"https://proxy", "proxy:443"},
+		{"http://[::1]", "[::1]:80"},
+	}
+	for _, test := range tests {
+		u, err := url.Parse(test.url)
+		require.NoError(t, err)
+		assert.Equal(t, test.expected, proxyAddress(u), test.url)
+	}
+}
+
+func TestHTTPConnectDialer_HandshakeTimeout(t *testing.T) {
+	proxyAddr := startSilentProxy(t)
+	dialer, err := newProxyDialer(ProxyConfig{URL: "http://" + proxyAddr}, &net.Dialer{Timeout: 100 * time.Millisecond})
+	require.NoError(t, err)
+
+	start := time.Now()
+	_, err = dialer.Dial("tcp", "broker:9092")
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestSOCKS5Dialer_HandshakeTimeout(t *testing.T) {
+	proxyAddr := startSilentProxy(t)
+	dialer, err := newProxyDialer(ProxyConfig{URL: "socks5://" + proxyAddr}, &net.Dialer{Timeout: 100 * time.Millisecond})
+	require.NoError(t, err)
+
+	start := time.Now()
+	_, err = dialer.Dial("tcp", "broker:9092")
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+// startSilentProxy starts a proxy which accepts connections, but never responds to the handshake. The connections
+// are closed once the test has finished.
+func startSilentProxy(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		var conns []net.Conn
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				for _, conn := range conns {
+					conn.Close()
+				}
+				return
+			}
+			conns = append(conns, conn)
+		}
+	}()
+
+	return listener.Addr().String()
+}
@@ -0,0 +1,237 @@
+package kafka
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/Shopify/sarama"
+)
+
+// Types of control records, which are written by the transaction coordinator to mark the end of a transaction
+const (
+	controlRecordTypeAbort   = "abort"
+	controlRecordTypeCommit  = "commit"
+	controlRecordTypeUnknown = "unknown"
+)
+
+// RecordBatchInfo describes the record batch (or the message set of the old message format) a message has been
+// written in. Sarama's consumer does not surface these attributes, hence they are only known for messages which have
+// been fetched directly (see ConsumeOffsetRanges).
+type RecordBatchInfo struct {
+	// MagicByte is the message format version. 0 and 1 are the old message format, 2 is the record batch format which
+	// has been introduced in Kafka 0.11.
+	MagicByte       int8   `json:"magicByte"`
+	Compression     string `json:"compression"`
+	IsTransactional bool   `json:"isTransactional"`
+	IsControl       bool   `json:"isControl"`
+
+	// ProducerID is -1 if the batch has not been written by an idempotent or transactional producer
+	ProducerID int64 `json:"producerId"`
+}
+
+// fetchedRecord is a record along with the attributes of its batch. ControlRecordType is only set for control records.
+type fetchedRecord struct {
+	Message           *sarama.ConsumerMessage
+	Batch             RecordBatchInfo
+	ControlRecordType string
+}
+
+// fetchRequestVersion returns the newest fetch request version up to 4 (which returns record batches) that is
+// supported by the cluster version
+func fetchRequestVersion(version sarama.KafkaVersion) int16 {
+	switch {
+	case version.IsAtLeast(sarama.V0_11_0_0):
+		return 4
+	case version.IsAtLeast(sarama.V0_10_1_0):
+		return 3
+	case version.IsAtLeast(sarama.V0_10_0_0):
+		return 2
+	case version.IsAtLeast(sarama.V0_9_0_0):
+		return 1
+	default:
+		return 0
+	}
+}
+
+// fetchOffsetRange fetches all records of the offset range from the partition leader without a consumer, so that the
+// attributes of the record batches are known. Control records are skipped unless includeControlRecords is set.
+// Fetching stops with the error of onRecord if it returns one. Since each fetch returns the position of the next
+// fetch, it also stops once the high watermark has been reached, even if the last offsets of the range do not exist
+// (e.g. because they are transaction markers or have been removed by compaction).
+func (s *Service) fetchOffsetRange(ctx context.Context, client sarama.Client, topicName string, r OffsetRange, includeControlRecords bool, onRecord func(fetchedRecord) error) error {
+	cfg := client.Config()
+	fetchSize := cfg.Consumer.Fetch.Default
+	offset := r.StartOffset
+	for offset < r.EndOffset {
+		broker, err := client.Leader(topicName, r.PartitionID)
+		if err != nil {
+			return fmt.Errorf("failed to get leader of partition %v: %w", r.PartitionID, err)
+		}
+		req := &sarama.FetchRequest{
+			Version:     fetchRequestVersion(cfg.Version),
+			MaxWaitTime: int32(cfg.Consumer.MaxWaitTime / time.Millisecond),
+			MinBytes:    1,
+			MaxBytes:    s.fetchMaxBytes(),
+			Isolation:   sarama.ReadUncommitted,
+		}
+		req.AddBlock(topicName, r.PartitionID, offset, fetchSize)
+
+		var res *sarama.FetchResponse
+		err = runWithContext(ctx, func() error {
+			var err error
+			res, err = broker.Fetch(req)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to fetch partition %v: %w", r.PartitionID, err)
+		}
+		block := res.GetBlock(topicName, r.PartitionID)
+		if block == nil {
+			return fmt.Errorf("failed to fetch partition %v: %w", r.PartitionID, sarama.ErrIncompleteResponse)
+		}
+		if block.Err != sarama.ErrNoError {
+			return fmt.Errorf("failed to fetch partition %v: %w", r.PartitionID, block.Err)
+		}
+
+		next, err := parseFetchedRecords(block, topicName, r.PartitionID, offset, r.EndOffset, includeControlRecords, onRecord)
+		if err != nil {
+			return err
+		}
+		if next >= block.HighWaterMarkOffset {
+			return nil
+		}
+		if next > offset {
+			offset = next
+			fetchSize = cfg.Consumer.Fetch.Default
+			continue
+		}
+
+		// Nothing has been returned before the high watermark, because the next batch is larger than the fetch size
+		if cfg.Consumer.Fetch.Max > 0 && fetchSize >= cfg.Consumer.Fetch.Max {
+			return fmt.Errorf("failed to fetch partition %v at offset %v: %w", r.PartitionID, offset, sarama.ErrMessageTooLarge)
+		}
+		fetchSize *= 2
+		if cfg.Consumer.Fetch.Max > 0 && fetchSize > cfg.Consumer.Fetch.Max {
+			fetchSize = cfg.Consumer.Fetch.Max
+		}
+	}
+
+	return nil
+}
+
+// fetchMaxBytes returns the max size of the responses to the fetch requests which are sent by fetchOffsetRange
+func (s *Service) fetchMaxBytes() int32 {
+	if s.Config.Consumer.FetchMaxBytes > 0 {
+		return s.Config.Consumer.FetchMaxBytes
+	}
+	return sarama.MaxResponseSize
+}
+
+// parseFetchedRecords passes the records of the fetched block from offset up to endOffset (exclusive) to onRecord and
+// returns the offset which has to be fetched next. Parsing stops with the first error returned by onRecord.
+func parseFetchedRecords(block *sarama.FetchResponseBlock, topicName string, partitionID int32, offset int64, endOffset int64, includeControlRecords bool, onRecord func(fetchedRecord) error) (int64, error) {
+	next := offset
+	var err error
+	emit := func(recordOffset int64, record fetchedRecord) bool {
+		// Fetch responses start at the beginning of the batch which contains the requested offset
+		if recordOffset < next {
+			return true
+		}
+		if recordOffset >= endOffset {
+			return false
+		}
+		next = recordOffset + 1
+		if record.Batch.IsControl && !includeControlRecords {
+			return true
+		}
+		record.Message.Topic = topicName
+		record.Message.Partition = partitionID
+		record.Message.Offset = recordOffset
+		err = onRecord(record)
+		return err == nil
+	}
+
+	for _, records := range block.RecordsSet {
+		switch {
+		case records.RecordBatch != nil:
+			batch := records.RecordBatch
+			info := RecordBatchInfo{
+				MagicByte:       batch.Version,
+				Compression:     batch.Codec.String(),
+				IsTransactional: batch.IsTransactional,
+				IsControl:       batch.Control,
+				ProducerID:      batch.ProducerID,
+			}
+			for _, rec := range batch.Records {
+				timestamp := batch.FirstTimestamp.Add(rec.TimestampDelta)
+				if batch.LogAppendTime {
+					timestamp = batch.MaxTimestamp
+				}
+				record := fetchedRecord{
+					Message: &sarama.ConsumerMessage{Key: rec.Key, Value: rec.Value, Headers: rec.Headers, Timestamp: timestamp},
+					Batch:   info,
+				}
+				if batch.Control {
+					record.ControlRecordType = controlRecordType(rec.Key)
+				}
+				if !emit(batch.FirstOffset+rec.OffsetDelta, record) {
+					return next, err
+				}
+			}
+			// The last offsets of a batch may have been removed by compaction
+			if !batch.PartialTrailingRecord && batch.LastOffset() >= next {
+				next = batch.LastOffset() + 1
+				if next > endOffset {
+					next = endOffset
+				}
+			}
+		case records.MsgSet != nil:
+			for _, msgBlock := range records.MsgSet.Messages {
+				messages := msgBlock.Messages()
+				info := RecordBatchInfo{
+					MagicByte:   msgBlock.Msg.Version,
+					Compression: msgBlock.Msg.Codec.String(),
+					ProducerID:  -1,
+				}
+				for _, msg := range messages {
+					// The inner messages of compressed message sets (v1) have relative offsets
+					recordOffset := msg.Offset
+					timestamp := msg.Msg.Timestamp
+					if msg.Msg.Version >= 1 {
+						recordOffset += msgBlock.Offset - messages[len(messages)-1].Offset
+						if msg.Msg.LogAppendTime {
+							timestamp = msgBlock.Msg.Timestamp
+						}
+					}
+					record := fetchedRecord{
+						Message: &sarama.ConsumerMessage{Key: msg.Msg.Key, Value: msg.Msg.Value, Timestamp: timestamp},
+						Batch:   info,
+					}
+					if !emit(recordOffset, record) {
+						return next, err
+					}
+				}
+			}
+		}
+	}
+
+	return next, nil
+}
+
+// controlRecordType returns the type of a control record, which is encoded in its key as version (int16) followed by
+// the type (int16)
+func controlRecordType(key []byte) string {
+	if len(key) < 4 {
+		return controlRecordTypeUnknown
+	}
+	switch binary.BigEndian.Uint16(key[2:4]) {
+	case 0:
+		return controlRecordTypeAbort
+	case 1:
+		return controlRecordTypeCommit
+	default:
+		return controlRecordTypeUnknown
+	}
+}
@@ -0,0 +1,116 @@
+package kafka
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFetchedRecords(t *testing.T) {
+	res := &sarama.FetchResponse{Version: 4}
+	res.AddRecordBatch("orders", 0, nil, sarama.StringEncoder("a"), 10, 7, true)
+	res.AddRecordBatch("orders", 0, nil, sarama.StringEncoder("b"), 11, 7, true)
+	res.AddControlRecord("orders", 0, 12, 7, sarama.ControlRecordCommit)
+	res.AddRecordBatch("orders", 0, nil, sarama.StringEncoder("c"), 13, -1, false)
+	block := res.GetBlock("orders", 0)
+
+	var records []fetchedRecord
+	collect := func(r fetchedRecord) error {
+		records = append(records, r)
+		return nil
+	}
+
+	// Offsets before the requested offset and from the end offset onwards are skipped, as are control records
+	next, err := parseFetchedRecords(block, "orders", 0, 11, 14, false, collect)
+	require.NoError(t, err)
+	assert.Equal(t, int64(14), next)
+	require.Len(t, records, 2)
+	assert.Equal(t, int64(11), records[0].Message.Offset)
+	assert.Equal(t, "b", string(records[0].Message.Value))
+	assert.Equal(t, RecordBatchInfo{MagicByte: 2, Compression: "none", IsTransactional: true, ProducerID: 7}, records[0].Batch)
+	assert.Equal(t, int64(13), records[1].Message.Offset)
+	assert.False(t, records[1].Batch.IsTransactional)
+
+	records = nil
+	next, err = parseFetchedRecords(block, "orders", 0, 11, 13, true, collect)
+	require.NoError(t, err)
+	assert.Equal(t, int64(13), next)
+	require.Len(t, records, 2)
+	assert.True(t, records[1].Batch.IsControl)
+	assert.Equal(t, controlRecordTypeCommit, records[1].ControlRecordType)
+	assert.Empty(t, records[0].ControlRecordType)
+}
+
+func TestParseFetchedRecords_LegacyMessageFormat(t *testing.T) {
+	res := &sarama.FetchResponse{}
+	res.AddMessage("orders", 0, nil, sarama.StringEncoder("a"), 5)
+	res.AddMessage("orders", 0, nil, sarama.StringEncoder("b"), 6)
+
+	var records []fetchedRecord
+	next, err := parseFetchedRecords(res.GetBlock("orders", 0), "orders", 0, 5, 10, false, func(r fetchedRecord) error {
+		records = append(records, r)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), next)
+	require.Len(t, records, 2)
+	assert.Equal(t, int64(6), records[1].Message.Offset)
+	assert.Equal(t, RecordBatchInfo{MagicByte: 0, Compression: "none", ProducerID: -1}, records[1].Batch)
+}
+
+func TestFetchOffsetRange(t *testing.T) {
+	res := &sarama.FetchResponse{Version: 4}
+	res.AddRecordBatch("orders", 0, nil, sarama.StringEncoder("a"), 0, 7, true)
+	res.AddControlRecord("orders", 0, 1, 7, sarama.ControlRecordAbort)
+	res.GetBlock("orders", 0).HighWaterMarkOffset = 2
+
+	svc, _ := newTestService(t, sarama.V0_11_0_0, func(broker *sarama.MockBroker) map[string]sarama.MockResponse {
+		return map[string]sarama.MockResponse{
+			"MetadataRequest": sarama.NewMockMetadataResponse(t).
+				SetBroker(broker.Addr(), broker.BrokerID()).
+				SetLeader("orders", 0, broker.BrokerID()),
+			"FetchRequest": sarama.NewMockWrapper(res),
+		}
+	})
+
+	var records []fetchedRecord
+	err := svc.fetchOffsetRange(context.Background(), svc.Client, "orders", OffsetRange{PartitionID: 0, StartOffset: 0, EndOffset: 2}, true, func(r fetchedRecord) error {
+		records = append(records, r)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, "a", string(records[0].Message.Value))
+	assert.True(t, records[0].Batch.IsTransactional)
+	assert.Equal(t, controlRecordTypeAbort, records[1].ControlRecordType)
+}
+
+func TestConsumeRawOffsetRange_TransactionMarkerAtEnd(t *testing.T) {
+	res := &sarama.FetchResponse{Version: 4}
+	res.AddRecordBatch("orders", 0, nil, sarama.StringEncoder("a"), 0, 7, true)
+	res.AddControlRecord("orders", 0, 1, 7, sarama.ControlRecordCommit)
+	res.GetBlock("orders", 0).HighWaterMarkOffset = 2
+
+	svc, _ := newTestService(t, sarama.V0_11_0_0, func(broker *sarama.MockBroker) map[string]sarama.MockResponse {
+		return map[string]sarama.MockResponse{
+			"MetadataRequest": sarama.NewMockMetadataResponse(t).
+				SetBroker(broker.Addr(), broker.BrokerID()).
+				SetLeader("orders", 0, broker.BrokerID()),
+			"FetchRequest": sarama.NewMockWrapper(res),
+		}
+	})
+
+	// The last offset before the high watermark is a transaction marker which is never delivered as message
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	var offsets []int64
+	err := svc.consumeRawOffsetRange(ctx, svc.Client, "orders", 0, 0, 1, func(m *sarama.ConsumerMessage) {
+		offsets = append(offsets, m.Offset)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []int64{0}, offsets)
+}
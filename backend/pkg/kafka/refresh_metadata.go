@@ -0,0 +1,50 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// ClusterMetadataRefresh summarizes the cluster metadata after a forced refresh
+type ClusterMetadataRefresh struct {
+	BrokerCount int
+	TopicCount  int
+	RefreshedAt time.Time
+}
+
+// RefreshClusterMetadata fetches the metadata of all brokers and topics from the cluster and discards all cached
+// metadata (topic metadata, api versions and broker metrics), so that cluster changes such as added brokers or
+// recreated topics are visible right away instead of after the next background refresh.
+func (s *Service) RefreshClusterMetadata(ctx context.Context) (res *ClusterMetadataRefresh, err error) {
+	defer s.observeOperation(ctx, operationTypeAdmin, "refresh_cluster_metadata", time.Now(), &err)
+
+	err = runWithContext(ctx, func() error {
+		return s.Client.RefreshMetadata()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to refresh cluster metadata: %w", err)
+	}
+	refreshedAt := time.Now()
+
+	s.InvalidateTopicMetadataCache()
+
+	s.apiVersions.mutex.Lock()
+	s.apiVersions.response = nil
+	s.apiVersions.mutex.Unlock()
+
+	s.brokerMetrics.mutex.Lock()
+	s.brokerMetrics.brokers = nil
+	s.brokerMetrics.mutex.Unlock()
+
+	topics, err := s.Client.Topics()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get refreshed topics: %w", err)
+	}
+
+	return &ClusterMetadataRefresh{
+		BrokerCount: len(s.Client.Brokers()),
+		TopicCount:  len(topics),
+		RefreshedAt: refreshedAt,
+	}, nil
+}
@@ -0,0 +1,40 @@
+package kafka
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRefreshClusterMetadata(t *testing.T) {
+	svc, _ := newTestService(t, sarama.V1_0_0_0, func(broker *sarama.MockBroker) map[string]sarama.MockResponse {
+		return map[string]sarama.MockResponse{
+			"MetadataRequest": sarama.NewMockMetadataResponse(t).
+				SetController(broker.BrokerID()).
+				SetBroker(broker.Addr(), broker.BrokerID()).
+				SetLeader("orders", 0, broker.BrokerID()).
+				SetLeader("payments", 0, broker.BrokerID()),
+		}
+	})
+	_, err := svc.Client.Controller()
+	require.NoError(t, err)
+	svc.Config = Config{TopicMetadataCacheTTL: time.Minute, TopicMetadataCacheStaleWindow: time.Minute}
+
+	_, err = svc.ListTopicsCached(context.Background())
+	require.NoError(t, err)
+	svc.apiVersions.response = &APIVersionsResponse{FetchedAt: time.Now()}
+	svc.brokerMetrics.brokers = map[int32]*BrokerMetrics{}
+
+	refresh, err := svc.RefreshClusterMetadata(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, refresh.BrokerCount)
+	assert.Equal(t, 2, refresh.TopicCount)
+
+	assert.Nil(t, svc.topicMetadata.metadata)
+	assert.Nil(t, svc.apiVersions.response)
+	assert.Nil(t, svc.brokerMetrics.brokers)
+}
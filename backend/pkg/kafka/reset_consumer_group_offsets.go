@@ -0,0 +1,205 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Shopify/sarama"
+)
+
+// OffsetResetStrategy describes to which offsets the committed offsets of a consumer group shall be reset
+type OffsetResetStrategy string
+
+const (
+	OffsetResetEarliest  OffsetResetStrategy = "earliest"
+	OffsetResetLatest    OffsetResetStrategy = "latest"
+	OffsetResetOffset    OffsetResetStrategy = "offset"
+	OffsetResetTimestamp OffsetResetStrategy = "timestamp"
+)
+
+// ResetConsumerGroupOffsetsRequest describes the topic partitions whose committed offsets shall be reset. An empty
+// list of partition ids selects all partitions of the topic.
+type ResetConsumerGroupOffsetsRequest struct {
+	GroupID  string
+	Topics   map[string][]int32
+	Strategy OffsetResetStrategy
+
+	// Offset is used by OffsetResetOffset and Timestamp is used by OffsetResetTimestamp
+	Offset    int64
+	Timestamp time.Time
+
+	// Force tries to reset the offsets even though the group has active members. The brokers usually reject offset
+	// commits for active groups, because the commit is not associated with the current generation of the group.
+	Force bool
+}
+
+// ConsumerGroupOffsetChange is the committed offset of a single partition before and after the reset. Before is -1 if
+// there was no committed offset for the partition.
+type ConsumerGroupOffsetChange struct {
+	TopicName   string
+	PartitionID int32
+	Before      int64
+	After       int64
+}
+
+// ResetConsumerGroupOffsets resets the committed offsets of a consumer group for the requested topic partitions and
+// returns the offsets before and after the reset. It refuses to operate on groups with active members unless the
+// request is forced.
+func (s *Service) ResetConsumerGroupOffsets(ctx context.Context, req ResetConsumerGroupOffsetsRequest) (changes []ConsumerGroupOffsetChange, err error) {
+	defer s.observeOperation(ctx, operationTypeAdmin, "reset_consumer_group_offsets", time.Now(), &err)
+	if len(req.Topics) == 0 {
+		return nil, fmt.Errorf("at least one topic must be given")
+	}
+
+	// 1. Check whether the group is still active
+	isActive, err := s.isConsumerGroupActive(ctx, req.GroupID)
+	if err != nil {
+		return nil, err
+	}
+	if isActive && !req.Force {
+		return nil, ErrConsumerGroupActive
+	}
+
+	// 2. Get the currently committed offsets
+	committed, err := s.ListConsumerGroupOffsets(ctx, req.GroupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list committed offsets: %w", err)
+	}
+
+	// 3. Resolve target offsets and commit them
+	coordinator, err := s.Client.Coordinator(req.GroupID)
+	if err != nil {
+		return nil, err
+	}
+	commitReq := &sarama.OffsetCommitRequest{
+		Version:                 2,
+		ConsumerGroup:           req.GroupID,
+		ConsumerGroupGeneration: sarama.GroupGenerationUndefined,
+		RetentionTime:           -1,
+	}
+
+	changes = make([]ConsumerGroupOffsetChange, 0)
+	for topicName, partitionIDs := range req.Topics {
+		if len(partitionIDs) == 0 {
+			partitionIDs, err = s.ListPartitions(topicName)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		marks, err := s.WaterMarks(ctx, topicName, partitionIDs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get watermarks for topic '%v': %w", topicName, err)
+		}
+		var timestampOffsets map[int32]int64
+		if req.Strategy == OffsetResetTimestamp {
+			timestampOffsets, err = s.OffsetsForTimestamp(ctx, topicName, partitionIDs, req.Timestamp)
+			if err != nil {
+				return nil, fmt.Errorf("failed to resolve offsets for timestamp: %w", err)
+			}
+		}
+
+		targets, err := resolveResetOffsets(req.Strategy, marks, req.Offset, timestampOffsets)
+		if err != nil {
+			return nil, err
+		}
+		for _, partitionID := range partitionIDs {
+			before := int64(-1)
+			if block := committed.GetBlock(topicName, partitionID); block != nil && block.Err == sarama.ErrNoError {
+				before = block.Offset
+			}
+			after := targets[partitionID]
+
+			commitReq.AddBlock(topicName, partitionID, after, 0, "")
+			changes = append(changes, ConsumerGroupOffsetChange{
+				TopicName:   topicName,
+				PartitionID: partitionID,
+				Before:      before,
+				After:       after,
+			})
+		}
+	}
+
+	err = commitOffsets(ctx, coordinator, commitReq)
+	if err != nil {
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+// isConsumerGroupActive returns true if the consumer group has members
+func (s *Service) isConsumerGroupActive(ctx context.Context, groupID string) (bool, error) {
+	described, err := s.DescribeConsumerGroups(ctx, []string{groupID})
+	if err != nil {
+		return false, fmt.Errorf("failed to describe consumer group: %w", err)
+	}
+	for _, res := range described {
+		for _, group := range res.Groups {
+			if group.Err != sarama.ErrNoError {
+				return false, fmt.Errorf("failed to describe consumer group: %w", group.Err)
+			}
+			if len(group.Members) > 0 {
+				return true, nil
+			}
+		}
+	}
+
+	return false, nil
+}
+
+// commitOffsets sends the offset commit request to the group coordinator and returns the first partition error
+func commitOffsets(ctx context.Context, coordinator *sarama.Broker, req *sarama.OffsetCommitRequest) error {
+	var res *sarama.OffsetCommitResponse
+	err := runWithContext(ctx, func() error {
+		var err error
+		res, err = coordinator.CommitOffset(req)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to commit offsets: %w", err)
+	}
+	for topicName, errByPartition := range res.Errors {
+		for partitionID, kErr := range errByPartition {
+			if kErr != sarama.ErrNoError {
+				return fmt.Errorf("failed to commit offset for topic '%v' partition %v: %w", topicName, partitionID, kErr)
+			}
+		}
+	}
+
+	return nil
+}
+
+// resolveResetOffsets returns the target offset for each partition. Specific offsets are clamped into the range of
+// available offsets. Partitions without a message at or after the requested timestamp are reset to the latest offset.
+func resolveResetOffsets(strategy OffsetResetStrategy, marks map[int32]*WaterMark, offset int64, timestampOffsets map[int32]int64) (map[int32]int64, error) {
+	targets := make(map[int32]int64, len(marks))
+	for partitionID, mark := range marks {
+		switch strategy {
+		case OffsetResetEarliest:
+			targets[partitionID] = mark.Low
+		case OffsetResetLatest:
+			targets[partitionID] = mark.High
+		case OffsetResetOffset:
+			target := offset
+			if target < mark.Low {
+				target = mark.Low
+			}
+			if target > mark.High {
+				target = mark.High
+			}
+			targets[partitionID] = target
+		case OffsetResetTimestamp:
+			target, exists := timestampOffsets[partitionID]
+			if !exists {
+				target = mark.High
+			}
+			targets[partitionID] = target
+		default:
+			return nil, fmt.Errorf("unknown offset reset strategy '%v'", strategy)
+		}
+	}
+
+	return targets, nil
+}
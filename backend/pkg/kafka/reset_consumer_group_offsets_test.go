@@ -0,0 +1,37 @@
+package kafka
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveResetOffsets(t *testing.T) {
+	marks := map[int32]*WaterMark{
+		0: {PartitionID: 0, Low: 100, High: 500},
+		1: {PartitionID: 1, Low: 0, High: 20},
+	}
+
+	tt := []struct {
+		name             string
+		strategy         OffsetResetStrategy
+		offset           int64
+		timestampOffsets map[int32]int64
+		expected         map[int32]int64
+	}{
+		{"earliest", OffsetResetEarliest, 0, nil, map[int32]int64{0: 100, 1: 0}},
+		{"latest", OffsetResetLatest, 0, nil, map[int32]int64{0: 500, 1: 20}},
+		{"offset is clamped", OffsetResetOffset, 50, nil, map[int32]int64{0: 100, 1: 20}},
+		// Partition 1 does not have a message at or after the timestamp and is reset to the latest offset
+		{"timestamp", OffsetResetTimestamp, 0, map[int32]int64{0: 321}, map[int32]int64{0: 321, 1: 20}},
+	}
+	for _, table := range tt {
+		targets, err := resolveResetOffsets(table.strategy, marks, table.offset, table.timestampOffsets)
+		require.NoError(t, err, table.name)
+		assert.Equal(t, table.expected, targets, table.name)
+	}
+
+	_, err := resolveResetOffsets("newest", marks, 0, nil)
+	assert.Error(t, err)
+}
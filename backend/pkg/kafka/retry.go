@@ -0,0 +1,87 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"go.uber.org/zap"
+)
+
+// retriableErrors are the errors which are expected to clear up on their own, e.g. once a leader has been elected or
+// the controller has moved. Other errors such as failed authentication or invalid requests fail right away.
+var retriableErrors = []error{
+	sarama.ErrOutOfBrokers,
+	sarama.ErrNotConnected,
+	sarama.ErrControllerNotAvailable,
+	sarama.ErrLeaderNotAvailable,
+	sarama.ErrNotLeaderForPartition,
+	sarama.ErrReplicaNotAvailable,
+	sarama.ErrBrokerNotAvailable,
+	sarama.ErrNotController,
+	sarama.ErrRequestTimedOut,
+	sarama.ErrNetworkException,
+	sarama.ErrConsumerCoordinatorNotAvailable,
+	sarama.ErrOffsetsLoadInProgress,
+	sarama.ErrNotCoordinatorForConsumer,
+	sarama.ErrKafkaStorageError,
+}
+
+// isRetriableError returns true if the error is transient, so that the failed request may be retried
+func isRetriableError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	for _, retriable := range retriableErrors {
+		if errors.Is(err, retriable) {
+			return true
+		}
+	}
+	// Errors of single topics are returned as a TopicError, which doesn't unwrap the KError
+	var topicErr *sarama.TopicError
+	if errors.As(err, &topicErr) && topicErr.Err != sarama.ErrNoError {
+		return isRetriableError(topicErr.Err)
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// retry calls fn until it succeeds, fails with an error which is not retriable, the configured attempts or elapsed
+// time have been exhausted or the context is done. It must only be used for idempotent requests. The error of the
+// last attempt is returned.
+func (s *Service) retry(ctx context.Context, operation string, fn func() error) error {
+	cfg := s.Config.Retry
+	start := time.Now()
+	backoff := cfg.InitialBackoff
+
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= cfg.MaxAttempts || !isRetriableError(err) {
+			return err
+		}
+
+		// Randomize the backoff, so that concurrent requests don't retry in lockstep
+		sleep := backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+		if time.Since(start)+sleep > cfg.MaxElapsedTime {
+			return err
+		}
+		s.Logger.Debug("retrying request which failed with a retriable error", zap.String("operation", operation),
+			zap.Int("attempt", attempt), zap.Duration("backoff", sleep), zap.Error(err))
+
+		timer := time.NewTimer(sleep)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+
+		backoff *= 2
+		if backoff > cfg.MaxBackoff {
+			backoff = cfg.MaxBackoff
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestIsRetriableError(t *testing.T) {
+	tt := []struct {
+		err       error
+		retriable bool
+	}{
+		{sarama.ErrLeaderNotAvailable, true},
+		{fmt.Errorf("failed to get cluster controller from client: %w", sarama.ErrControllerNotAvailable), true},
+		{&sarama.TopicError{Err: sarama.ErrNotController}, true},
+		{sarama.ErrOutOfBrokers, true},
+		{sarama.ErrSASLAuthenticationFailed, false},
+		{sarama.ErrTopicAuthorizationFailed, false},
+		{sarama.ErrInvalidRequest, false},
+		{&sarama.TopicError{Err: sarama.ErrInvalidReplicationFactor}, false},
+		{context.DeadlineExceeded, false},
+	}
+	for _, table := range tt {
+		assert.Equal(t, table.retriable, isRetriableError(table.err), table.err.Error())
+	}
+}
+
+func TestRetry(t *testing.T) {
+	svc := &Service{Logger: zap.NewNop()}
+	svc.Config.Retry = RetryConfig{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: 2 * time.Millisecond, MaxElapsedTime: time.Second}
+	ctx := context.Background()
+
+	// A retriable error which clears after one retry
+	attempts := 0
+	err := svc.retry(ctx, "test", func() error {
+		attempts++
+		if attempts == 1 {
+			return sarama.ErrLeaderNotAvailable
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, attempts)
+
+	// Non-retriable errors fail fast
+	attempts = 0
+	err = svc.retry(ctx, "test", func() error {
+		attempts++
+		return sarama.ErrSASLAuthenticationFailed
+	})
+	assert.True(t, errors.Is(err, sarama.ErrSASLAuthenticationFailed))
+	assert.Equal(t, 1, attempts)
+
+	// The attempts are bounded
+	attempts = 0
+	err = svc.retry(ctx, "test", func() error {
+		attempts++
+		return sarama.ErrLeaderNotAvailable
+	})
+	assert.True(t, errors.Is(err, sarama.ErrLeaderNotAvailable))
+	assert.Equal(t, 3, attempts)
+
+	// No retry is started if its backoff would exceed the max elapsed time
+	svc.Config.Retry.InitialBackoff, svc.Config.Retry.MaxBackoff = time.Hour, time.Hour
+	attempts = 0
+	_ = svc.retry(ctx, "test", func() error {
+		attempts++
+		return sarama.ErrLeaderNotAvailable
+	})
+	assert.Equal(t, 1, attempts)
+
+	// Services without a retry config make a single attempt
+	attempts = 0
+	_ = (&Service{Logger: zap.NewNop()}).retry(ctx, "test", func() error {
+		attempts++
+		return sarama.ErrLeaderNotAvailable
+	})
+	assert.Equal(t, 1, attempts)
+}
+
+func TestWaterMarks_RetriesNotLeaderForPartition(t *testing.T) {
+	notLeader := &sarama.OffsetResponse{}
+	notLeader.AddTopicPartition("orders", 0, -1)
+	notLeader.Blocks["orders"][0].Err = sarama.ErrNotLeaderForPartition
+	svc, _ := newTestService(t, sarama.DefaultVersion, func(broker *sarama.MockBroker) map[string]sarama.MockResponse {
+		return map[string]sarama.MockResponse{
+			"MetadataRequest": sarama.NewMockMetadataResponse(t).
+				SetBroker(broker.Addr(), broker.BrokerID()).
+				SetLeader("orders", 0, broker.BrokerID()),
+			"OffsetRequest": sarama.NewMockSequence(notLeader, sarama.NewMockOffsetResponse(t).
+				SetOffset("orders", 0, sarama.OffsetOldest, 10).
+				SetOffset("orders", 0, sarama.OffsetNewest, 25)),
+		}
+	})
+
+	svc.Config.Retry = RetryConfig{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond, MaxElapsedTime: time.Second}
+	marks, err := svc.WaterMarks(context.Background(), "orders", []int32{0})
+	require.NoError(t, err)
+	assert.Equal(t, &WaterMark{PartitionID: 0, Low: 10, High: 25}, marks[0])
+}
@@ -1,16 +1,29 @@
 package kafka
 
 import (
+	"crypto/hmac"
+	"crypto/rand"
 	"crypto/sha256"
 	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
 	"hash"
+	"strconv"
+	"strings"
 
+	"github.com/Shopify/sarama"
 	"github.com/xdg/scram"
 )
 
 var scramSha256 scram.HashGeneratorFcn = func() hash.Hash { return sha256.New() }
 var scramSha512 scram.HashGeneratorFcn = func() hash.Hash { return sha512.New() }
 
+// xdgSCRAMClient implements sarama's SCRAMClient interface.
+//
+// SCRAM channel binding (e.g. tls-server-end-point) is not supported: Kafka brokers only accept the GS2 header
+// without channel binding ('n,,') and neither sarama's SCRAMClient interface nor the xdg/scram client give us
+// access to the TLS connection state that would be required to compute the binding data.
 type xdgSCRAMClient struct {
 	*scram.Client
 	*scram.ClientConversation
@@ -34,3 +47,153 @@ func (x *xdgSCRAMClient) Step(challenge string) (response string, err error) {
 func (x *xdgSCRAMClient) Done() bool {
 	return x.ClientConversation.Done()
 }
+
+// newSCRAMClientGenerator returns the generator of the SCRAM clients for sarama, clients which authenticate with a
+// delegation token must announce it in the conversation
+func newSCRAMClientGenerator(hashGeneratorFcn scram.HashGeneratorFcn, isDelegationToken bool) func() sarama.SCRAMClient {
+	if isDelegationToken {
+		return func() sarama.SCRAMClient { return &scramTokenClient{HashGeneratorFcn: hashGeneratorFcn} }
+	}
+	return func() sarama.SCRAMClient { return &xdgSCRAMClient{HashGeneratorFcn: hashGeneratorFcn} }
+}
+
+// scramMinIterations is the min iteration count a server may request, it's the default of Kafka's SCRAM credentials
+const scramMinIterations = 4096
+
+// scramTokenClient implements sarama's SCRAMClient interface for the authentication with delegation tokens (KIP-48).
+// The username is the token ID and the password the base64 encoded token HMAC. The token must be announced with the
+// extension tokenauth=true in the client's first message, which is part of the signed auth message. The xdg/scram
+// client doesn't support extensions, hence the client side of the conversation (RFC 5802) is implemented here.
+type scramTokenClient struct {
+	HashGeneratorFcn scram.HashGeneratorFcn
+
+	username        string
+	password        string
+	nonce           string
+	clientFirstBare string
+	serverSignature []byte
+	step            int
+	done            bool
+}
+
+func (c *scramTokenClient) Begin(userName, password, authzID string) error {
+	if authzID != "" {
+		return fmt.Errorf("an authorization id can not be used with delegation tokens")
+	}
+	nonce := make([]byte, 24)
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	*c = scramTokenClient{
+		HashGeneratorFcn: c.HashGeneratorFcn,
+		username:         userName,
+		password:         password,
+		nonce:            base64.StdEncoding.EncodeToString(nonce),
+	}
+	return nil
+}
+
+func (c *scramTokenClient) Step(challenge string) (string, error) {
+	c.step++
+	switch c.step {
+	case 1:
+		escaper := strings.NewReplacer("=", "=3D", ",", "=2C")
+		c.clientFirstBare = fmt.Sprintf("n=%s,r=%s,tokenauth=true", escaper.Replace(c.username), c.nonce)
+		return "n,," + c.clientFirstBare, nil
+	case 2:
+		return c.clientFinal(challenge)
+	case 3:
+		c.done = true
+		return "", c.verifyServerFinal(challenge)
+	default:
+		return "", fmt.Errorf("unexpected scram challenge after the conversation has finished")
+	}
+}
+
+func (c *scramTokenClient) Done() bool {
+	return c.done
+}
+
+// clientFinal computes the client proof for the server's first message "r=<nonce>,s=<salt>,i=<iterations>"
+func (c *scramTokenClient) clientFinal(serverFirst string) (string, error) {
+	fields := scramFields(serverFirst)
+	if e, isErr := fields["e"]; isErr {
+		return "", fmt.Errorf("server rejected the authentication: %v", e)
+	}
+	serverNonce := fields["r"]
+	if !strings.HasPrefix(serverNonce, c.nonce) || len(serverNonce) == len(c.nonce) {
+		return "", fmt.Errorf("server nonce does not extend the client nonce")
+	}
+	salt, err := base64.StdEncoding.DecodeString(fields["s"])
+	if err != nil || len(salt) == 0 {
+		return "", fmt.Errorf("server sent an invalid salt")
+	}
+	iterations, err := strconv.Atoi(fields["i"])
+	if err != nil || iterations < scramMinIterations {
+		return "", fmt.Errorf("server sent an invalid iteration count '%v', it must be at least %d", fields["i"], scramMinIterations)
+	}
+
+	saltedPassword := c.saltPassword(salt, iterations)
+	clientKey := c.hmac(saltedPassword, []byte("Client Key"))
+	h := c.HashGeneratorFcn()
+	h.Write(clientKey)
+	storedKey := h.Sum(nil)
+
+	clientFinalWithoutProof := "c=" + base64.StdEncoding.EncodeToString([]byte("n,,")) + ",r=" + serverNonce
+	authMessage := []byte(c.clientFirstBare + "," + serverFirst + "," + clientFinalWithoutProof)
+	clientSignature := c.hmac(storedKey, authMessage)
+	proof := make([]byte, len(clientKey))
+	for i := range clientKey {
+		proof[i] = clientKey[i] ^ clientSignature[i]
+	}
+	c.serverSignature = c.hmac(c.hmac(saltedPassword, []byte("Server Key")), authMessage)
+
+	return clientFinalWithoutProof + ",p=" + base64.StdEncoding.EncodeToString(proof), nil
+}
+
+// verifyServerFinal checks the server signature of the final message "v=<signature>"
+func (c *scramTokenClient) verifyServerFinal(serverFinal string) error {
+	fields := scramFields(serverFinal)
+	if e, isErr := fields["e"]; isErr {
+		return fmt.Errorf("server rejected the authentication: %v", e)
+	}
+	signature, err := base64.StdEncoding.DecodeString(fields["v"])
+	if err != nil || !hmac.Equal(signature, c.serverSignature) {
+		return fmt.Errorf("server signature is invalid")
+	}
+	return nil
+}
+
+// saltPassword is the function Hi of RFC 5802, which is PBKDF2 with HMAC as pseudorandom function and an output
+// length of the hash size
+func (c *scramTokenClient) saltPassword(salt []byte, iterations int) []byte {
+	block := make([]byte, 4)
+	binary.BigEndian.PutUint32(block, 1)
+	u := c.hmac([]byte(c.password), append(append([]byte{}, salt...), block...))
+	res := append([]byte{}, u...)
+	for i := 1; i < iterations; i++ {
+		u = c.hmac([]byte(c.password), u)
+		for j := range res {
+			res[j] ^= u[j]
+		}
+	}
+	return res
+}
+
+func (c *scramTokenClient) hmac(key, data []byte) []byte {
+	mac := hmac.New(c.HashGeneratorFcn, key)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+// scramFields parses the comma separated attributes of a SCRAM message. Values may contain '=', but no ','.
+func scramFields(msg string) map[string]string {
+	fields := make(map[string]string)
+	for _, attribute := range strings.Split(msg, ",") {
+		if kv := strings.SplitN(attribute, "=", 2); len(kv) == 2 {
+			fields[kv[0]] = kv[1]
+		}
+	}
+	return fields
+}
@@ -0,0 +1,100 @@
+package kafka
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xdg/scram"
+)
+
+func TestSCRAMTokenClient(t *testing.T) {
+	tokenID, tokenHMAC := "token-1", "aGVsbG8gd29ybGQ="
+	for _, hashGeneratorFcn := range []scram.HashGeneratorFcn{scramSha256, scramSha512} {
+		// The xdg/scram server verifies the proof against the full client first message, which includes the extension
+		credentials, err := hashGeneratorFcn.NewClient(tokenID, tokenHMAC, "")
+		require.NoError(t, err)
+		server, err := hashGeneratorFcn.NewServer(func(username string) (scram.StoredCredentials, error) {
+			assert.Equal(t, tokenID, username)
+			return credentials.GetStoredCredentials(scram.KeyFactors{Salt: "salt", Iters: scramMinIterations}), nil
+		})
+		require.NoError(t, err)
+		serverConversation := server.NewConversation()
+
+		client := &scramTokenClient{HashGeneratorFcn: hashGeneratorFcn}
+		require.NoError(t, client.Begin(tokenID, tokenHMAC, ""))
+		clientFirst, err := client.Step("")
+		require.NoError(t, err)
+		assert.True(t, strings.HasSuffix(clientFirst, ",tokenauth=true"))
+
+		challenge := clientFirst
+		for !client.Done() {
+			challenge, err = serverConversation.Step(challenge)
+			require.NoError(t, err)
+			challenge, err = client.Step(challenge)
+			require.NoError(t, err)
+		}
+		assert.True(t, serverConversation.Valid())
+	}
+}
+
+func TestSCRAMTokenClient_WrongHMAC(t *testing.T) {
+	credentials, err := scramSha256.NewClient("token-1", "correct", "")
+	require.NoError(t, err)
+	server, err := scramSha256.NewServer(func(string) (scram.StoredCredentials, error) {
+		return credentials.GetStoredCredentials(scram.KeyFactors{Salt: "salt", Iters: scramMinIterations}), nil
+	})
+	require.NoError(t, err)
+	serverConversation := server.NewConversation()
+
+	client := &scramTokenClient{HashGeneratorFcn: scramSha256}
+	require.NoError(t, client.Begin("token-1", "wrong", ""))
+	clientFirst, err := client.Step("")
+	require.NoError(t, err)
+	serverFirst, err := serverConversation.Step(clientFirst)
+	require.NoError(t, err)
+	clientFinal, err := client.Step(serverFirst)
+	require.NoError(t, err)
+	_, err = serverConversation.Step(clientFinal)
+	assert.Error(t, err)
+
+	// Servers which request too few iterations are rejected
+	client = &scramTokenClient{HashGeneratorFcn: scramSha256}
+	require.NoError(t, client.Begin("token-1", "correct", ""))
+	_, err = client.Step("")
+	require.NoError(t, err)
+	_, err = client.Step("r=" + client.nonce + "abc,s=c2FsdA==,i=1")
+	assert.Error(t, err)
+}
+
+func TestSASLConfig_Validate_DelegationToken(t *testing.T) {
+	token := SASLDelegationTokenConfig{TokenID: "token-1", HMAC: "aGVsbG8gd29ybGQ="}
+	cfg := SASLConfig{Enabled: true, Mechanism: sarama.SASLTypeSCRAMSHA512, DelegationToken: token}
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Mechanism = sarama.SASLTypePlaintext
+	assert.Error(t, cfg.Validate(), "delegation tokens can only be used with SCRAM")
+
+	cfg = SASLConfig{Enabled: true, Mechanism: sarama.SASLTypeSCRAMSHA256, Username: "alice", DelegationToken: token}
+	assert.Error(t, cfg.Validate(), "the token replaces username and password")
+
+	cfg = SASLConfig{Enabled: true, Mechanism: sarama.SASLTypeSCRAMSHA256, DelegationToken: SASLDelegationTokenConfig{TokenID: "token-1", HMAC: "not base64!"}}
+	assert.Error(t, cfg.Validate())
+}
+
+func TestNewSaramaConfig_DelegationToken(t *testing.T) {
+	cfg := &Config{Brokers: []string{"localhost:9092"}}
+	cfg.SetDefaults()
+	cfg.SASL.Enabled = true
+	cfg.SASL.Mechanism = sarama.SASLTypeSCRAMSHA256
+	cfg.SASL.DelegationToken = SASLDelegationTokenConfig{TokenID: "token-1", HMAC: "aGVsbG8gd29ybGQ="}
+	require.NoError(t, cfg.Validate())
+
+	sConfig, err := NewSaramaConfig(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, "token-1", sConfig.Net.SASL.User)
+	assert.Equal(t, "aGVsbG8gd29ybGQ=", sConfig.Net.SASL.Password)
+	assert.IsType(t, &scramTokenClient{}, sConfig.Net.SASL.SCRAMClientGeneratorFunc())
+}
@@ -0,0 +1,237 @@
+package kafka
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Shopify/sarama"
+)
+
+// MessageFilterMode describes how the messages of a search request are filtered
+type MessageFilterMode string
+
+const (
+	// MessageFilterModeNone returns all messages in the requested offset range
+	MessageFilterModeNone MessageFilterMode = ""
+	// MessageFilterModeJavaScript evaluates the given JavaScript code for each message, see the list messages filter
+	MessageFilterModeJavaScript MessageFilterMode = "javascript"
+	// MessageFilterModeHeaderKey returns all messages which have a header with the given key
+	MessageFilterModeHeaderKey MessageFilterMode = "headerKey"
+	// MessageFilterModeHeaderValue returns all messages which have a header with the given key and raw value
+	MessageFilterModeHeaderValue MessageFilterMode = "headerValue"
+	// MessageFilterModeValueContains returns all messages whose raw value contains the given substring
+	MessageFilterModeValueContains MessageFilterMode = "valueContains"
+)
+
+// MessageFilter is the server side filter which is applied to all consumed messages of a search request
+type MessageFilter struct {
+	Mode MessageFilterMode
+
+	// InterpreterCode is the JavaScript code for MessageFilterModeJavaScript, HeaderKey, HeaderValue and Substring are
+	// the parameters for the other filter modes.
+	InterpreterCode string
+	HeaderKey       string
+	HeaderValue     string
+	Substring       string
+}
+
+// SearchMessagesRequest describes the offset range of a single partition which shall be searched
+type SearchMessagesRequest struct {
+	TopicName   string
+	PartitionID int32
+
+	// StartOffset is inclusive. Offsets lower than the low watermark start at the low watermark.
+	StartOffset int64
+	// EndOffset is inclusive. A negative end offset (or one beyond the high watermark) searches up to the latest message.
+	EndOffset int64
+
+	Filter MessageFilter
+	Limit  int
+
+	// DeserializeHeaders decodes the header values with the topic's decoder chain, otherwise they are returned as binary
+	DeserializeHeaders bool
+
+	// TimeBudget is the maximum duration the partition will be consumed. The search returns the messages which have
+	// been found so far once it has been exceeded.
+	TimeBudget time.Duration
+}
+
+// errSearchLimitReached stops fetching the partition once the search has found the requested number of messages
+var errSearchLimitReached = errors.New("search limit reached")
+
+// SearchMessagesResponse contains all matching messages along with the offset at which a subsequent search can be
+// continued.
+type SearchMessagesResponse struct {
+	Messages         []*TopicMessage
+	ConsumedMessages int64
+	ConsumedBytes    int64
+
+	// NextOffset is the offset after the last consumed message. IsExhausted is true if the end offset has been
+	// reached and there are no more messages to search.
+	NextOffset  int64
+	EndOffset   int64
+	IsExhausted bool
+	IsTimedOut  bool
+}
+
+// SearchMessages consumes the requested offset range of a single partition and returns the messages which pass the
+// filter. The search stops as soon as the limit, the end offset or the time budget has been reached.
+func (s *Service) SearchMessages(ctx context.Context, req SearchMessagesRequest) (res *SearchMessagesResponse, err error) {
+	defer s.observeOperation(ctx, operationTypeConsume, "search_messages", time.Now(), &err)
+	ctx, done, err := s.TrackOperation(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	if req.Limit <= 0 {
+		return nil, fmt.Errorf("limit must be greater than 0")
+	}
+	if isRawMessageFilter(req.Filter.Mode) && s.Deserializer.hasMessageTransforms(req.TopicName) {
+		return nil, ErrRawFilterOfTransformedTopic
+	}
+	isMessageOK, err := newMessageFilter(req.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	marks, err := s.WaterMarks(ctx, req.TopicName, []int32{req.PartitionID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get watermarks: %w", err)
+	}
+	mark, exists := marks[req.PartitionID]
+	if !exists {
+		return nil, fmt.Errorf("partition '%v' does not exist", req.PartitionID)
+	}
+
+	startOffset, endOffset := req.StartOffset, req.EndOffset
+	if startOffset < mark.Low {
+		startOffset = mark.Low
+	}
+	// mark.High - 1 is the last message which can actually be consumed
+	if endOffset < 0 || endOffset > mark.High-1 {
+		endOffset = mark.High - 1
+	}
+
+	res = &SearchMessagesResponse{
+		Messages:   make([]*TopicMessage, 0),
+		NextOffset: startOffset,
+		EndOffset:  endOffset,
+	}
+	if startOffset > endOffset {
+		res.IsExhausted = true
+		return res, nil
+	}
+
+	// The records are fetched directly (see fetchOffsetRange), because the end offset may be a transaction marker
+	// which would never be delivered by a consumer
+	client, release, err := s.newFetchClient(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create consumer client: %w", err)
+	}
+	defer release()
+
+	budgetCtx, cancel := context.WithTimeout(ctx, req.TimeBudget)
+	defer cancel()
+
+	r := OffsetRange{PartitionID: req.PartitionID, StartOffset: startOffset, EndOffset: endOffset + 1}
+	err = s.fetchOffsetRange(budgetCtx, client, req.TopicName, r, false, func(record fetchedRecord) error {
+		m := record.Message
+		res.ConsumedMessages++
+		res.NextOffset = m.Offset + 1
+		res.ConsumedBytes += int64(len(m.Key) + len(m.Value))
+
+		topicMessage, args := newTopicMessage(m, &s.Deserializer, req.DeserializeHeaders)
+		isOK, err := isMessageOK(m, args)
+		if err != nil {
+			return fmt.Errorf("failed to check if message is ok (partition: '%v', offset: '%v'): %w", m.Partition, m.Offset, err)
+		}
+		if isOK {
+			res.Messages = append(res.Messages, topicMessage)
+		}
+		if len(res.Messages) >= req.Limit && m.Offset < endOffset {
+			return errSearchLimitReached
+		}
+		return nil
+	})
+	switch {
+	case errors.Is(err, errSearchLimitReached):
+		return res, nil
+	case err != nil && ctx.Err() == nil && budgetCtx.Err() != nil:
+		res.IsTimedOut = true
+		return res, nil
+	case err != nil:
+		return nil, err
+	}
+
+	// The fetch also stops at the high watermark, hence the remaining offsets of the range are transaction markers or
+	// have been removed by compaction
+	res.NextOffset = endOffset + 1
+	res.IsExhausted = true
+	return res, nil
+}
+
+// isRawMessageFilter returns true if the filter mode matches the raw message rather than the deserialized and
+// transformed one
+func isRawMessageFilter(mode MessageFilterMode) bool {
+	switch mode {
+	case MessageFilterModeHeaderKey, MessageFilterModeHeaderValue, MessageFilterModeValueContains:
+		return true
+	default:
+		return false
+	}
+}
+
+// CheckHeaderFilter returns ErrRawFilterOfTransformedTopic if a header filter is set for a topic with message
+// transforms, because headers are matched before the transforms have been applied
+func (s *Service) CheckHeaderFilter(topicName string, filter HeaderFilter) error {
+	if !filter.IsEmpty() && s.Deserializer.hasMessageTransforms(topicName) {
+		return ErrRawFilterOfTransformedTopic
+	}
+	return nil
+}
+
+// newMessageFilter returns a function which checks whether a consumed message passes the given filter
+func newMessageFilter(filter MessageFilter) (func(m *sarama.ConsumerMessage, args interpreterArguments) (bool, error), error) {
+	switch filter.Mode {
+	case MessageFilterModeNone:
+		return func(_ *sarama.ConsumerMessage, _ interpreterArguments) (bool, error) { return true, nil }, nil
+	case MessageFilterModeJavaScript:
+		if filter.InterpreterCode == "" {
+			return nil, fmt.Errorf("javascript filter requires interpreter code")
+		}
+		isMessageOK, err := newInterpreterFilter(filter.InterpreterCode)
+		if err != nil {
+			return nil, err
+		}
+		return func(_ *sarama.ConsumerMessage, args interpreterArguments) (bool, error) { return isMessageOK(args) }, nil
+	case MessageFilterModeHeaderKey:
+		if filter.HeaderKey == "" {
+			return nil, fmt.Errorf("header key filter requires a header key")
+		}
+		headerFilter := HeaderFilter{Key: filter.HeaderKey}
+		return func(m *sarama.ConsumerMessage, _ interpreterArguments) (bool, error) {
+			return headerFilter.Matches(m.Headers), nil
+		}, nil
+	case MessageFilterModeHeaderValue:
+		if filter.HeaderKey == "" {
+			return nil, fmt.Errorf("header value filter requires a header key")
+		}
+		headerFilter := HeaderFilter{Key: filter.HeaderKey, Value: &filter.HeaderValue}
+		return func(m *sarama.ConsumerMessage, _ interpreterArguments) (bool, error) {
+			return headerFilter.Matches(m.Headers), nil
+		}, nil
+	case MessageFilterModeValueContains:
+		if filter.Substring == "" {
+			return nil, fmt.Errorf("value contains filter requires a substring")
+		}
+		substring := []byte(filter.Substring)
+		return func(m *sarama.ConsumerMessage, _ interpreterArguments) (bool, error) {
+			return bytes.Contains(m.Value, substring), nil
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown filter mode '%v'", filter.Mode)
+	}
+}
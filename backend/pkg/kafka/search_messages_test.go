@@ -0,0 +1,120 @@
+package kafka
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestNewMessageFilter(t *testing.T) {
+	m := &sarama.ConsumerMessage{
+		Value:   []byte(`{"customer":"kowl","amount":42}`),
+		Headers: []*sarama.RecordHeader{{Key: []byte("trace-id"), Value: []byte("abc")}},
+	}
+	args := interpreterArguments{Value: map[string]interface{}{"amount": 42}}
+
+	tt := []struct {
+		name     string
+		filter   MessageFilter
+		expected bool
+	}{
+		{"no filter", MessageFilter{}, true},
+		{"header key match", MessageFilter{Mode: MessageFilterModeHeaderKey, HeaderKey: "trace-id"}, true},
+		{"header key mismatch", MessageFilter{Mode: MessageFilterModeHeaderKey, HeaderKey: "span-id"}, false},
+		{"header value match", MessageFilter{Mode: MessageFilterModeHeaderValue, HeaderKey: "trace-id", HeaderValue: "abc"}, true},
+		{"header value mismatch", MessageFilter{Mode: MessageFilterModeHeaderValue, HeaderKey: "trace-id", HeaderValue: "xyz"}, false},
+		{"value contains match", MessageFilter{Mode: MessageFilterModeValueContains, Substring: `"customer":"kowl"`}, true},
+		{"value contains mismatch", MessageFilter{Mode: MessageFilterModeValueContains, Substring: "owl-business"}, false},
+		{"javascript match", MessageFilter{Mode: MessageFilterModeJavaScript, InterpreterCode: "return value.amount > 40"}, true},
+		{"javascript mismatch", MessageFilter{Mode: MessageFilterModeJavaScript, InterpreterCode: "return value.amount > 50"}, false},
+	}
+	for _, table := range tt {
+		isMessageOK, err := newMessageFilter(table.filter)
+		require.NoError(t, err, table.name)
+		isOK, err := isMessageOK(m, args)
+		require.NoError(t, err, table.name)
+		assert.Equal(t, table.expected, isOK, table.name)
+	}
+
+	_, err := newMessageFilter(MessageFilter{Mode: MessageFilterModeHeaderKey})
+	assert.Error(t, err)
+	_, err = newMessageFilter(MessageFilter{Mode: MessageFilterModeHeaderValue, HeaderValue: "abc"})
+	assert.Error(t, err)
+	_, err = newMessageFilter(MessageFilter{Mode: "cel"})
+	assert.Error(t, err)
+}
+
+func TestSearchMessages_RawFilterOfTransformedTopic(t *testing.T) {
+	transforms, err := newMessageTransforms([]MessageTransformConfig{{Type: messageTransformExtractField, Field: "amount"}})
+	require.NoError(t, err)
+	svc := &Service{Logger: zap.NewNop(), Deserializer: deserializer{TopicChains: []topicDecoderChain{
+		{TopicName: regexp.MustCompile("^orders$"), Transforms: transforms},
+	}}}
+
+	for _, mode := range []MessageFilterMode{MessageFilterModeHeaderKey, MessageFilterModeHeaderValue, MessageFilterModeValueContains} {
+		_, err := svc.SearchMessages(context.Background(), SearchMessagesRequest{
+			TopicName: "orders",
+			Limit:     10,
+			Filter:    MessageFilter{Mode: mode, HeaderKey: "trace-id", Substring: "jane"},
+		})
+		assert.ErrorIs(t, err, ErrRawFilterOfTransformedTopic, mode)
+	}
+
+	assert.ErrorIs(t, svc.CheckHeaderFilter("orders", HeaderFilter{Key: "trace-id"}), ErrRawFilterOfTransformedTopic)
+	assert.NoError(t, svc.CheckHeaderFilter("orders", HeaderFilter{}))
+	assert.NoError(t, svc.CheckHeaderFilter("customers", HeaderFilter{Key: "trace-id"}))
+}
+
+func TestSearchMessages_TransactionMarkerAtEnd(t *testing.T) {
+	res := &sarama.FetchResponse{Version: 4}
+	res.AddRecordBatch("orders", 0, nil, sarama.StringEncoder("a"), 0, 7, true)
+	res.AddRecordBatch("orders", 0, nil, sarama.StringEncoder("b"), 1, 7, true)
+	res.AddControlRecord("orders", 0, 2, 7, sarama.ControlRecordCommit)
+	res.GetBlock("orders", 0).HighWaterMarkOffset = 3
+
+	svc, _ := newTestService(t, sarama.V0_11_0_0, func(broker *sarama.MockBroker) map[string]sarama.MockResponse {
+		return map[string]sarama.MockResponse{
+			"MetadataRequest": sarama.NewMockMetadataResponse(t).
+				SetBroker(broker.Addr(), broker.BrokerID()).
+				SetLeader("orders", 0, broker.BrokerID()),
+			"OffsetRequest": sarama.NewMockOffsetResponse(t).
+				SetOffset("orders", 0, sarama.OffsetOldest, 0).
+				SetOffset("orders", 0, sarama.OffsetNewest, 3),
+			"FetchRequest": sarama.NewMockWrapper(res),
+		}
+	})
+
+	// The end offset is the commit marker, which is never returned as message
+	searched, err := svc.SearchMessages(context.Background(), SearchMessagesRequest{
+		TopicName:  "orders",
+		EndOffset:  -1,
+		Limit:      10,
+		TimeBudget: 5 * time.Second,
+	})
+	require.NoError(t, err)
+	assert.True(t, searched.IsExhausted)
+	assert.False(t, searched.IsTimedOut)
+	assert.Equal(t, int64(2), searched.EndOffset)
+	assert.Equal(t, int64(3), searched.NextOffset)
+	assert.Equal(t, int64(2), searched.ConsumedMessages)
+	require.Len(t, searched.Messages, 2)
+	assert.Equal(t, int64(1), searched.Messages[1].Offset)
+
+	// The search stops once the limit has been reached and can be continued at the next offset
+	searched, err = svc.SearchMessages(context.Background(), SearchMessagesRequest{
+		TopicName:  "orders",
+		EndOffset:  -1,
+		Limit:      1,
+		TimeBudget: 5 * time.Second,
+	})
+	require.NoError(t, err)
+	assert.False(t, searched.IsExhausted)
+	assert.Equal(t, int64(1), searched.NextOffset)
+	require.Len(t, searched.Messages, 1)
+}
@@ -0,0 +1,195 @@
+package kafka
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"go.uber.org/zap"
+)
+
+// Names of the phases of a self test
+const (
+	selfTestPhaseCreateTopic = "create_topic"
+	selfTestPhaseProduce     = "produce"
+	selfTestPhaseConsume     = "consume"
+	selfTestPhaseDeleteTopic = "delete_topic"
+)
+
+// selfTestTopicPrefix is the name prefix of the temporary topics which are created by self tests
+const selfTestTopicPrefix = "__kowl_self_test_"
+
+// selfTestCleanupTimeout is the max duration of the deletion of the temporary topic. It has its own timeout, so that
+// the topic is deleted even if the self test has timed out.
+const selfTestCleanupTimeout = 15 * time.Second
+
+// SelfTestResult contains the outcome of each phase which has been run. Phases which are not reached because an
+// earlier phase has failed are omitted, except for the deletion of the temporary topic which runs whenever the topic
+// has been created.
+type SelfTestResult struct {
+	TopicName        string
+	IsTemporaryTopic bool
+	Phases           []SelfTestPhase
+
+	// RoundTripLatency is the duration from producing the message until it has been consumed
+	RoundTripLatency time.Duration
+}
+
+// SelfTestPhase is a single step of a self test. Err is set if the step has failed.
+type SelfTestPhase struct {
+	Name     string
+	Duration time.Duration
+	Err      error
+}
+
+// Succeeded returns true if all phases of the self test have succeeded
+func (r *SelfTestResult) Succeeded() bool {
+	for _, phase := range r.Phases {
+		if phase.Err != nil {
+			return false
+		}
+	}
+	return len(r.Phases) > 0
+}
+
+// RunSelfTest verifies the connectivity to the cluster by producing a message to the configured test topic (or a
+// temporary topic) and consuming it back. The failure of a phase is reported in the result, an error is only
+// returned if the self test could not be started.
+func (s *Service) RunSelfTest(ctx context.Context) (res *SelfTestResult, err error) {
+	defer s.observeOperation(ctx, operationTypeAdmin, "self_test", time.Now(), &err)
+	ctx, done, err := s.TrackOperation(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	testCtx, cancel := context.WithTimeout(ctx, s.Config.SelfTest.Timeout)
+	defer cancel()
+
+	nonce := strconv.FormatInt(time.Now().UnixNano(), 10)
+	res = &SelfTestResult{TopicName: s.Config.SelfTest.Topic}
+	if res.TopicName == "" {
+		res.TopicName = selfTestTopicPrefix + nonce
+		res.IsTemporaryTopic = true
+	}
+	runPhase := func(name string, fn func() error) bool {
+		start := time.Now()
+		err := fn()
+		res.Phases = append(res.Phases, SelfTestPhase{Name: name, Duration: time.Since(start), Err: err})
+		return err == nil
+	}
+
+	if res.IsTemporaryTopic {
+		var createErr error
+		created := runPhase(selfTestPhaseCreateTopic, func() error {
+			createErr = s.createSelfTestTopic(testCtx, res.TopicName)
+			return createErr
+		})
+		// The topic is also deleted if it has been created, but its metadata could not be fetched. Topics whose
+		// creation has been rejected are left alone, as they might have existed before.
+		if created || errors.Is(createErr, errTopicCreatedWithoutMetadata) {
+			defer runPhase(selfTestPhaseDeleteTopic, func() error {
+				return s.deleteSelfTestTopic(res.TopicName)
+			})
+		}
+		if !created {
+			return res, nil
+		}
+	}
+
+	var partitionID int32
+	var offset int64
+	produceStart := time.Now()
+	produced := runPhase(selfTestPhaseProduce, func() error {
+		var err error
+		partitionID, offset, err = s.produceSelfTestMessage(testCtx, res.TopicName, nonce)
+		return err
+	})
+	if !produced {
+		return res, nil
+	}
+	consumed := runPhase(selfTestPhaseConsume, func() error {
+		return s.consumeSelfTestMessage(testCtx, res.TopicName, partitionID, offset, nonce)
+	})
+	if consumed {
+		res.RoundTripLatency = time.Since(produceStart)
+	}
+
+	return res, nil
+}
+
+// createSelfTestTopic creates the temporary topic. Its min.insync.replicas is set to 1, so that messages can be
+// produced with acks all regardless of the broker's default.
+func (s *Service) createSelfTestTopic(ctx context.Context, topicName string) error {
+	minInSyncReplicas := "1"
+	_, err := s.CreateTopic(ctx, topicName, TopicSpec{
+		PartitionCount:    1,
+		ReplicationFactor: s.Config.SelfTest.ReplicationFactor,
+		Configs:           map[string]*string{"min.insync.replicas": &minInSyncReplicas},
+	})
+	return err
+}
+
+// deleteSelfTestTopic deletes the temporary topic. It is not bound to the context of the self test, so that the topic
+// is cleaned up even if the self test has been cancelled.
+func (s *Service) deleteSelfTestTopic(topicName string) error {
+	defer s.InvalidateTopicMetadataCache()
+	ctx, cancel := context.WithTimeout(context.Background(), selfTestCleanupTimeout)
+	defer cancel()
+
+	err := runWithContext(ctx, func() error {
+		return s.AdminClient.DeleteTopic(topicName)
+	})
+	if err != nil {
+		s.Logger.Warn("failed to delete the temporary topic of the self test", zap.String("topic_name", topicName), zap.Error(err))
+		return fmt.Errorf("failed to delete topic: %w", err)
+	}
+	return nil
+}
+
+// produceSelfTestMessage produces the nonce with the configured default compression and returns the partition and
+// offset it has been written to
+func (s *Service) produceSelfTestMessage(ctx context.Context, topicName string, nonce string) (int32, int64, error) {
+	codec, err := s.producerCompression("")
+	if err != nil {
+		return 0, 0, err
+	}
+	syncProducer, err := s.syncProducer(codec)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var partitionID int32
+	var offset int64
+	err = runWithContext(ctx, func() error {
+		var err error
+		partitionID, offset, err = syncProducer.SendMessage(&sarama.ProducerMessage{
+			Topic: topicName,
+			Key:   sarama.StringEncoder("kowl-self-test"),
+			Value: sarama.StringEncoder(nonce),
+		})
+		return err
+	})
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to produce message: %w", err)
+	}
+	return partitionID, offset, nil
+}
+
+// consumeSelfTestMessage consumes the message at the given offset and checks that it is the produced nonce
+func (s *Service) consumeSelfTestMessage(ctx context.Context, topicName string, partitionID int32, offset int64, nonce string) error {
+	found := false
+	err := s.consumeRawOffsetRange(ctx, s.Client, topicName, partitionID, offset, offset, func(m *sarama.ConsumerMessage) {
+		found = found || (m.Offset == offset && bytes.Equal(m.Value, []byte(nonce)))
+	})
+	if err != nil {
+		return fmt.Errorf("failed to consume message: %w", err)
+	}
+	if !found {
+		return fmt.Errorf("consumed message at offset %d does not match the produced message", offset)
+	}
+	return nil
+}
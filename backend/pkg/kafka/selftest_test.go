@@ -0,0 +1,69 @@
+package kafka
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunSelfTest_DeletesTemporaryTopicOnFailure(t *testing.T) {
+	// The mock rejects topics with a reserved prefix as of CreateTopics v1, which is used by Kafka 0.11
+	svc, broker := newTestAdminService(t, sarama.V0_10_2_0, func(broker *sarama.MockBroker) map[string]sarama.MockResponse {
+		return map[string]sarama.MockResponse{
+			// The temporary topic is created, but it never becomes part of the metadata
+			"MetadataRequest": sarama.NewMockMetadataResponse(t).
+				SetController(broker.BrokerID()).
+				SetBroker(broker.Addr(), broker.BrokerID()),
+			"CreateTopicsRequest": sarama.NewMockCreateTopicsResponse(t),
+			"DeleteTopicsRequest": sarama.NewMockDeleteTopicsResponse(t),
+		}
+	})
+
+	svc.Config.SelfTest.SetDefaults()
+	res, err := svc.RunSelfTest(context.Background())
+	require.NoError(t, err)
+
+	assert.True(t, res.IsTemporaryTopic)
+	assert.False(t, res.Succeeded())
+	require.Len(t, res.Phases, 2)
+	assert.Equal(t, selfTestPhaseCreateTopic, res.Phases[0].Name)
+	assert.ErrorIs(t, res.Phases[0].Err, errTopicCreatedWithoutMetadata)
+	assert.Equal(t, selfTestPhaseDeleteTopic, res.Phases[1].Name)
+	assert.NoError(t, res.Phases[1].Err)
+
+	deleted := make([]string, 0)
+	for _, rr := range broker.History() {
+		if req, ok := rr.Request.(*sarama.DeleteTopicsRequest); ok {
+			deleted = append(deleted, req.Topics...)
+		}
+	}
+	assert.Equal(t, []string{res.TopicName}, deleted)
+}
+
+func TestRunSelfTest_KeepsTopicWhoseCreationHasBeenRejected(t *testing.T) {
+	svc, broker := newTestAdminService(t, sarama.V1_0_0_0, func(broker *sarama.MockBroker) map[string]sarama.MockResponse {
+		return map[string]sarama.MockResponse{
+			"MetadataRequest": sarama.NewMockMetadataResponse(t).
+				SetController(broker.BrokerID()).
+				SetBroker(broker.Addr(), broker.BrokerID()),
+			"DeleteTopicsRequest": sarama.NewMockDeleteTopicsResponse(t),
+		}
+	})
+
+	// The replication factor can't be satisfied by the single broker, hence the topic is never created
+	svc.Config.SelfTest.SetDefaults()
+	svc.Config.SelfTest.ReplicationFactor = 3
+	res, err := svc.RunSelfTest(context.Background())
+	require.NoError(t, err)
+
+	require.Len(t, res.Phases, 1)
+	assert.Equal(t, selfTestPhaseCreateTopic, res.Phases[0].Name)
+	assert.ErrorIs(t, res.Phases[0].Err, ErrInvalidTopicSpec)
+	for _, rr := range broker.History() {
+		_, isDelete := rr.Request.(*sarama.DeleteTopicsRequest)
+		assert.False(t, isDelete, "the topic must not be deleted")
+	}
+}
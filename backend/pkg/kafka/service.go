@@ -2,8 +2,8 @@ package kafka
 
 import (
 	"fmt"
+	"github.com/cloudhut/kowl/backend/pkg/proto"
 	"github.com/cloudhut/kowl/backend/pkg/schema"
-	"go.uber.org/zap/zapcore"
 	"time"
 
 	"github.com/Shopify/sarama"
@@ -13,26 +13,74 @@ import (
 
 // Service acts as interface to interact with the Kafka Cluster
 type Service struct {
+	Config           Config
 	Logger           *zap.Logger
 	Client           sarama.Client
 	AdminClient      sarama.ClusterAdmin
 	SchemaService    *schema.Service
 	Deserializer     deserializer
+	ClusterName      string
 	MetricsNamespace string
+
+	certReloader  *certReloader
+	apiVersions   apiVersionsCache
+	topicMetadata topicMetadataCache
+	producer      producer
+	brokerMetrics brokerMetricsCache
+	shutdown      shutdownCoordinator
+
+	metricsRegistration metricsRegistration
 }
 
 // NewService creates a new Kafka service and immediately checks connectivity to all components. If any of these external
-// dependencies fail an error wil be returned.
-func NewService(cfg Config, logger *zap.Logger, metricsNamespace string) (*Service, error) {
-	// Create separate logger for sarama
-	saramaLogger, err := zap.NewStdLogAt(logger.With(zap.String("source", "sarama")), zapcore.DebugLevel)
+// dependencies fail an error wil be returned. The cluster name labels the metrics of the service.
+func NewService(cfg Config, logger *zap.Logger, clusterName string, metricsNamespace string) (*Service, error) {
+	logger, err := newLeveledLogger(logger, cfg.LogLevel)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create std logger for sarama: %w", err)
+		return nil, fmt.Errorf("failed to set log level: %w", err)
+	}
+
+	if cfg.IsSASLEnabled() && cfg.SASL.Mechanism == sarama.SASLTypeGSSAPI && cfg.SASL.GSSAPIConfig.IsDeprecatedAuthType() {
+		logger.Warn("the configured gssapi auth type 'USER_AUTH:' is deprecated, please use 'USER_AUTH' instead")
+	}
+
+	if cfg.MetadataRefreshInterval > 0 {
+		logger.Warn("the config metadataRefreshInterval is deprecated, please use metadata.refreshInterval instead")
+	}
+
+	if cfg.IsTLSEnabled() && cfg.TLS.hasCustomCAs() {
+		_, caCount, err := loadCACertPool(&cfg.TLS)
+		if err != nil {
+			return nil, &ErrTLSConfig{Err: err}
+		}
+		logger.Info("loaded ca certificates for the tls cert pool", zap.Int("certificates", caCount))
+	}
+
+	// TLS client certificates which are rotated on disk must be reloaded periodically
+	var reloader *certReloader
+	if cfg.IsTLSEnabled() {
+		reloader, err = setupCertReloader(&cfg.TLS, logger)
+		if err != nil {
+			return nil, err
+		}
 	}
-	sarama.Logger = saramaLogger
 
-	// Sarama Config
-	saramaConfig, err := NewSaramaConfig(&cfg)
+	if cfg.Proxy.URL != "" {
+		logger.Info("routing all broker connections through the configured proxy")
+	}
+
+	// All sarama clients of this cluster share their metrics
+	cfg.clientMetrics = newClusterClientMetrics()
+
+	// The clients send their requests with the versions of the configured cluster version, hence it must be resolved
+	// before they are created
+	err = resolveClusterVersion(&cfg, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	// Sarama Config. The client is shared by the admin client and the consumers, hence we use the consumer config.
+	saramaConfig, err := NewConsumerConfig(&cfg, ConsumerConfigOverride{})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create a valid sarama config: %w", err)
 	}
@@ -66,16 +114,44 @@ func NewService(cfg Config, logger *zap.Logger, metricsNamespace string) (*Servi
 		}
 	}
 
+	// Protobuf
+	var protoSvc *proto.Service
+	if cfg.Proto.Enabled {
+		protoSvc, err = proto.NewService(cfg.Proto, schemaSvc, logger)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create protobuf service: %w", err)
+		}
+	}
+
+	deserializer, err := newDeserializer(cfg.Deserialization, schemaSvc, protoSvc)
+	if err != nil {
+		return nil, err
+	}
+
 	return &Service{
+		Config:           cfg,
 		Logger:           logger,
 		Client:           client,
 		AdminClient:      adminClient,
 		SchemaService:    schemaSvc,
-		Deserializer:     deserializer{SchemaService: schemaSvc},
+		Deserializer:     *deserializer,
+		ClusterName:      clusterName,
 		MetricsNamespace: metricsNamespace,
+		certReloader:     reloader,
 	}, nil
 }
 
+// Close closes the producer (if one has been created) and the shared Kafka client and all its broker connections
+func (s *Service) Close() error {
+	if err := s.closeProducer(); err != nil {
+		s.Logger.Warn("failed to close producer", zap.Error(err))
+	}
+	if s.Client.Closed() {
+		return nil
+	}
+	return s.Client.Close()
+}
+
 // Start initializes the Kafka Service and takes care of stuff like KeepAlive
 func (s *Service) Start() {
 
@@ -87,16 +163,27 @@ func (s *Service) Start() {
 
 	// Custom keep alive for Kafka, because: https://github.com/Shopify/sarama/issues/1487
 	// The KeepAlive property in sarama doesn't work either, because of golang's buggy net module: https://github.com/golang/go/issues/31490
-	go s.keepAlive()
+	s.shutdown.goBackground(s.keepAlive)
+
+	if s.certReloader != nil {
+		s.shutdown.goBackground(s.certReloader.watch)
+		if s.certReloader.watchToken != nil {
+			s.shutdown.goBackground(s.certReloader.watchToken)
+		}
+	}
 }
 
-func (s *Service) keepAlive() {
+func (s *Service) keepAlive(stop <-chan struct{}) {
 	log := s.Logger
 	wasHealthy := false
 	warningLimit := rate.NewLimiter(rate.Every(30*time.Second), 1)
 	for {
 		// Normal keepalive interval is 3 seconds
-		time.Sleep(3 * time.Second)
+		select {
+		case <-stop:
+			return
+		case <-time.After(3 * time.Second):
+		}
 
 		brokers := s.Client.Brokers()
 		connectedCount := 0
@@ -0,0 +1,52 @@
+package kafka
+
+import (
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// newTestService returns a service whose client is connected to a single mock broker, which serves the given handlers.
+// The handlers are created along with the broker, so that they can refer to its address and ID. The broker and the
+// client are closed once the test has finished.
+func newTestService(t *testing.T, version sarama.KafkaVersion, handlers func(broker *sarama.MockBroker) map[string]sarama.MockResponse) (*Service, *sarama.MockBroker) {
+	t.Helper()
+	broker := sarama.NewMockBroker(t, 1)
+	t.Cleanup(broker.Close)
+	broker.SetHandlerByMap(handlers(broker))
+
+	cfg := sarama.NewConfig()
+	cfg.Version = version
+	client, err := sarama.NewClient([]string{broker.Addr()}, cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+
+	return &Service{Client: client, Logger: zap.NewNop()}, broker
+}
+
+// newTestAdminService works like newTestService, but the service has an admin client as well. The handlers must
+// return metadata which contains the controller.
+func newTestAdminService(t *testing.T, version sarama.KafkaVersion, handlers func(broker *sarama.MockBroker) map[string]sarama.MockResponse) (*Service, *sarama.MockBroker) {
+	t.Helper()
+	svc, broker := newTestService(t, version, handlers)
+	adminClient, err := sarama.NewClusterAdminFromClient(svc.Client)
+	require.NoError(t, err)
+	svc.AdminClient = adminClient
+
+	return svc, broker
+}
+
+func TestService_Close(t *testing.T) {
+	svc, _ := newTestService(t, sarama.V1_0_0_0, func(broker *sarama.MockBroker) map[string]sarama.MockResponse {
+		return map[string]sarama.MockResponse{
+			"MetadataRequest": sarama.NewMockMetadataResponse(t).SetBroker(broker.Addr(), broker.BrokerID()),
+		}
+	})
+
+	require.NoError(t, svc.Close())
+	assert.True(t, svc.Client.Closed())
+	assert.NoError(t, svc.Close(), "closing twice is a no-op")
+}
@@ -0,0 +1,173 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// shutdownCancelGracePeriod is the duration we wait for the operations to return after they have been cancelled
+// because they didn't finish within the drain timeout
+const shutdownCancelGracePeriod = 5 * time.Second
+
+// shutdownCoordinator tracks the in-flight consume and produce operations along with the background goroutines of the
+// service, so that they can be drained on shutdown. The zero value is ready to use.
+type shutdownCoordinator struct {
+	mutex          sync.Mutex
+	isShuttingDown bool
+	nextID         uint64
+	operations     map[uint64]trackedOperation
+	stop           chan struct{}
+
+	inFlight   sync.WaitGroup
+	background sync.WaitGroup
+}
+
+// trackedOperation is an in-flight operation. Streams (e.g. live tails) never finish on their own, hence they are
+// cancelled as soon as the shutdown begins.
+type trackedOperation struct {
+	cancel   context.CancelFunc
+	isStream bool
+}
+
+// track registers an in-flight operation. The returned context is cancelled if the operation is still running once
+// the drain timeout has elapsed, the returned func must be called when the operation has finished.
+func (c *shutdownCoordinator) track(ctx context.Context, isStream bool) (context.Context, func(), error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.isShuttingDown {
+		return nil, nil, ErrShuttingDown
+	}
+	if c.operations == nil {
+		c.operations = make(map[uint64]trackedOperation)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	id := c.nextID
+	c.nextID++
+	c.operations[id] = trackedOperation{cancel: cancel, isStream: isStream}
+	c.inFlight.Add(1)
+
+	done := func() {
+		c.mutex.Lock()
+		delete(c.operations, id)
+		c.mutex.Unlock()
+		cancel()
+		c.inFlight.Done()
+	}
+	return ctx, done, nil
+}
+
+// stopChannel returns the channel which is closed when the shutdown begins. The caller must hold the mutex.
+func (c *shutdownCoordinator) stopChannel() chan struct{} {
+	if c.stop == nil {
+		c.stop = make(chan struct{})
+	}
+	return c.stop
+}
+
+// goBackground runs fn in a goroutine which is awaited on shutdown. fn must return once the stop channel is closed.
+func (c *shutdownCoordinator) goBackground(fn func(stop <-chan struct{})) {
+	c.mutex.Lock()
+	stop := c.stopChannel()
+	c.background.Add(1)
+	c.mutex.Unlock()
+
+	go func() {
+		defer c.background.Done()
+		fn(stop)
+	}()
+}
+
+// shutdown rejects new operations, cancels all streams and waits up to the drain timeout for the other operations to
+// finish. Operations which are still running after the drain timeout are cancelled. It returns an error if not all
+// operations have finished in time.
+func (c *shutdownCoordinator) shutdown(drainTimeout time.Duration) error {
+	c.mutex.Lock()
+	if c.isShuttingDown {
+		c.mutex.Unlock()
+		return nil
+	}
+	c.isShuttingDown = true
+	close(c.stopChannel())
+	for _, op := range c.operations {
+		if op.isStream {
+			op.cancel()
+		}
+	}
+	c.mutex.Unlock()
+
+	var err error
+	if !waitTimeout(&c.inFlight, drainTimeout) {
+		c.mutex.Lock()
+		cancelledCount := len(c.operations)
+		for _, op := range c.operations {
+			op.cancel()
+		}
+		c.mutex.Unlock()
+
+		err = fmt.Errorf("%d operations didn't finish within the drain timeout and have been cancelled", cancelledCount)
+		if !waitTimeout(&c.inFlight, shutdownCancelGracePeriod) {
+			err = fmt.Errorf("%d operations didn't finish within the drain timeout and didn't return after they have been cancelled", cancelledCount)
+		}
+	}
+
+	if !waitTimeout(&c.background, shutdownCancelGracePeriod) {
+		err = fmt.Errorf("background tasks didn't stop within %v", shutdownCancelGracePeriod)
+	}
+
+	return err
+}
+
+// waitTimeout waits for the wait group and returns false if it has not been done within the timeout
+func waitTimeout(wg *sync.WaitGroup, timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+// TrackOperation registers an in-flight consume or produce operation, so that it is awaited on shutdown. The returned
+// context must be used for the operation and the returned func must be called once it has finished. ErrShuttingDown
+// is returned if the service is shutting down.
+func (s *Service) TrackOperation(ctx context.Context) (context.Context, func(), error) {
+	return s.shutdown.track(ctx, false)
+}
+
+// TrackStream registers a stream (e.g. a live tail) which never finishes on its own. Its context is cancelled as soon
+// as the shutdown begins.
+func (s *Service) TrackStream(ctx context.Context) (context.Context, func(), error) {
+	return s.shutdown.track(ctx, true)
+}
+
+// Shutdown drains the in-flight consume and produce operations (up to the configured drain timeout), stops all
+// background goroutines, flushes the producer and closes the Kafka clients.
+func (s *Service) Shutdown() error {
+	s.Logger.Info("shutting down kafka service, draining in-flight operations", zap.Duration("drain_timeout", s.Config.ShutdownDrainTimeout))
+	drainErr := s.shutdown.shutdown(s.Config.ShutdownDrainTimeout)
+	if drainErr != nil {
+		s.Logger.Warn("failed to drain in-flight operations", zap.Error(drainErr))
+	}
+
+	err := s.Close()
+	if err != nil {
+		return fmt.Errorf("failed to close kafka clients: %w", err)
+	}
+	s.Logger.Info("kafka service has been shut down")
+
+	return drainErr
+}
@@ -0,0 +1,79 @@
+package kafka
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+	"go.uber.org/zap"
+)
+
+func TestShutdownCoordinator_DrainsOperations(t *testing.T) {
+	c := shutdownCoordinator{}
+
+	opCtx, done, err := c.track(context.Background(), false)
+	require.NoError(t, err)
+	streamCtx, streamDone, err := c.track(context.Background(), true)
+	require.NoError(t, err)
+
+	shutdownErr := make(chan error)
+	go func() {
+		shutdownErr <- c.shutdown(time.Minute)
+	}()
+
+	// Streams are cancelled right away, operations may finish within the drain timeout
+	<-streamCtx.Done()
+	streamDone()
+	require.Eventually(t, func() bool {
+		_, _, err := c.track(context.Background(), false)
+		return err == ErrShuttingDown
+	}, time.Second, time.Millisecond, "new operations must be rejected")
+	assert.NoError(t, opCtx.Err())
+
+	done()
+	assert.NoError(t, <-shutdownErr)
+}
+
+func TestShutdownCoordinator_CancelsAfterDrainTimeout(t *testing.T) {
+	c := shutdownCoordinator{}
+
+	opCtx, done, err := c.track(context.Background(), false)
+	require.NoError(t, err)
+	go func() {
+		<-opCtx.Done()
+		done()
+	}()
+
+	err = c.shutdown(10 * time.Millisecond)
+	assert.Error(t, err)
+	assert.Equal(t, context.Canceled, opCtx.Err())
+}
+
+func TestShutdownCoordinator_NoGoroutineLeaks(t *testing.T) {
+	// Goroutines of other tests in this package (e.g. mock brokers) may still be running
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	c := shutdownCoordinator{}
+	for i := 0; i < 3; i++ {
+		c.goBackground(func(stop <-chan struct{}) {
+			<-stop
+		})
+	}
+	reloader, err := newCertReloader(func() (*tlsMaterial, error) { return &tlsMaterial{}, nil }, time.Millisecond, zap.NewNop())
+	require.NoError(t, err)
+	c.goBackground(reloader.watch)
+
+	for i := 0; i < 5; i++ {
+		ctx, done, err := c.track(context.Background(), i%2 == 0)
+		require.NoError(t, err)
+		go func(ctx context.Context, done func()) {
+			<-ctx.Done()
+			done()
+		}(ctx, done)
+	}
+
+	assert.Error(t, c.shutdown(10*time.Millisecond), "operations which run until they are cancelled must be reported")
+}
@@ -0,0 +1,145 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+)
+
+// ITailMessagesProgress specifies the methods 'TailMessages' will call on your progress-object. If OnMessage returns an
+// error (e.g. because the connection to the client is broken), the tail is stopped.
+type ITailMessagesProgress interface {
+	OnMessage(message *TopicMessage) error
+	OnMessagesDropped(count int64)
+	OnError(msg string)
+}
+
+// TailMessagesRequest describes the partitions whose new messages shall be streamed. An empty list of partitionIDs
+// selects all partitions of the topic.
+type TailMessagesRequest struct {
+	TopicName    string
+	PartitionIDs []int32
+
+	// MaxMessagesPerSecond limits the number of messages which are passed to the receiver, messages above this rate
+	// are dropped. It is capped at the configured consumer.liveTailMaxMessagesPerSecond.
+	MaxMessagesPerSecond int
+
+	DeserializeHeaders bool
+}
+
+// tailDroppedReportInterval is the interval in which the number of dropped messages is reported
+const tailDroppedReportInterval = time.Second
+
+// TailMessages consumes all requested partitions starting at their high watermark and passes new messages to the
+// progress until the context is done. The consumed messages are buffered for the receiver. If the receiver is too slow
+// and the buffer is full, or if the max message rate is exceeded, messages are dropped instead of piling up in memory.
+// The number of dropped messages is reported regularly.
+func (s *Service) TailMessages(ctx context.Context, req TailMessagesRequest, progress ITailMessagesProgress) (err error) {
+	defer s.observeOperation(ctx, operationTypeConsume, "tail_messages", time.Now(), &err)
+	ctx, done, err := s.TrackStream(ctx)
+	if err != nil {
+		return err
+	}
+	defer done()
+	partitionIDs, err := s.SelectPartitions(req.TopicName, req.PartitionIDs)
+	if err != nil {
+		return err
+	}
+
+	maxRate := s.Config.Consumer.LiveTailMaxMessagesPerSecond
+	if req.MaxMessagesPerSecond > 0 && req.MaxMessagesPerSecond < maxRate {
+		maxRate = req.MaxMessagesPerSecond
+	}
+	limiter := rate.NewLimiter(rate.Limit(maxRate), maxRate)
+
+	// The consumer's client has been created with the config from NewConsumerConfig
+	consumer, err := s.NewConsumer(ctx)
+	if err != nil {
+		return fmt.Errorf("couldn't create consumer: %w", err)
+	}
+	defer func() {
+		if err := consumer.Close(); err != nil {
+			s.Logger.Error("closing consumer failed", zap.Error(err))
+		}
+	}()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	buffer := make(chan *TopicMessage, s.Config.Consumer.LiveTailBufferSize)
+	dropped := int64(0)
+	wg := sync.WaitGroup{}
+	for _, partitionID := range partitionIDs {
+		pConsumer, err := consumer.ConsumePartition(req.TopicName, partitionID, sarama.OffsetNewest)
+		if err != nil {
+			cancel()
+			wg.Wait()
+			return fmt.Errorf("couldn't consume partition %v: %w", partitionID, err)
+		}
+
+		wg.Add(1)
+		go func(pConsumer sarama.PartitionConsumer, partitionID int32) {
+			defer wg.Done()
+			defer func() {
+				if err := pConsumer.Close(); err != nil {
+					s.Logger.Error("failed to close partition consumer", zap.Error(err))
+				}
+			}()
+
+			for {
+				select {
+				case m, ok := <-pConsumer.Messages():
+					if !ok {
+						return
+					}
+					if !limiter.Allow() {
+						atomic.AddInt64(&dropped, 1)
+						continue
+					}
+					topicMessage, _ := newTopicMessage(m, &s.Deserializer, req.DeserializeHeaders)
+					select {
+					case buffer <- topicMessage:
+					default:
+						// The receiver is too slow
+						atomic.AddInt64(&dropped, 1)
+					}
+				case err, ok := <-pConsumer.Errors():
+					if !ok {
+						return
+					}
+					progress.OnError(fmt.Sprintf("partition consumer (partitionId=%v) failed to fetch messages: %v", partitionID, err.Err.Error()))
+				case <-ctx.Done():
+					return
+				}
+			}
+		}(pConsumer, partitionID)
+	}
+	defer func() {
+		// The partition consumers must be stopped before the consumer is closed
+		cancel()
+		wg.Wait()
+	}()
+
+	ticker := time.NewTicker(tailDroppedReportInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case msg := <-buffer:
+			if err := progress.OnMessage(msg); err != nil {
+				return fmt.Errorf("failed to send message: %w", err)
+			}
+		case <-ticker.C:
+			if count := atomic.SwapInt64(&dropped, 0); count > 0 {
+				progress.OnMessagesDropped(count)
+			}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package kafka
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testTailProgress struct {
+	mutex    sync.Mutex
+	messages []*TopicMessage
+	onFirst  func()
+}
+
+func (p *testTailProgress) OnMessage(message *TopicMessage) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.messages = append(p.messages, message)
+	if len(p.messages) == 1 {
+		p.onFirst()
+	}
+	return nil
+}
+
+func (p *testTailProgress) OnMessagesDropped(int64) {}
+
+func (p *testTailProgress) OnError(string) {}
+
+func TestTailMessages(t *testing.T) {
+	svc, _ := newTestService(t, sarama.V1_0_0_0, func(broker *sarama.MockBroker) map[string]sarama.MockResponse {
+		return map[string]sarama.MockResponse{
+			"MetadataRequest": sarama.NewMockMetadataResponse(t).
+				SetBroker(broker.Addr(), broker.BrokerID()).
+				SetLeader("orders", 0, broker.BrokerID()),
+			"OffsetRequest": sarama.NewMockOffsetResponse(t).
+				SetVersion(1).
+				SetOffset("orders", 0, sarama.OffsetNewest, 10).
+				SetOffset("orders", 0, sarama.OffsetOldest, 0),
+			"FetchRequest": sarama.NewMockFetchResponse(t, 1).
+				SetVersion(4).
+				SetMessage("orders", 0, 10, sarama.StringEncoder(`{"id":"order-10"}`)).
+				SetHighWaterMark("orders", 0, 11),
+		}
+	})
+
+	svc.Config.Consumer.SetDefaults()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	progress := &testTailProgress{onFirst: cancel}
+	err := svc.TailMessages(ctx, TailMessagesRequest{TopicName: "orders"}, progress)
+	require.NoError(t, err)
+
+	require.NotEmpty(t, progress.messages)
+	assert.Equal(t, int64(10), progress.messages[0].Offset)
+	assert.Equal(t, messageEncodingJSON, progress.messages[0].Value.RecognizedEncoding)
+}
@@ -1 +1,153 @@
 package kafka
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Shopify/sarama"
+)
+
+const incrementalAlterConfigsAPIKey = 44
+
+// AlterTopicConfig changes the dynamic config of the given topic. Config entries which are set to nil are reset to
+// their default value. All other dynamic topic config entries remain untouched.
+//
+// Brokers which support the incremental alter configs API (Kafka 2.3+) only receive the changes. For older brokers
+// the currently set dynamic topic configs are described and merged with the requested changes before the complete set
+// of configs is sent to the cluster via the (non incremental) alter configs API.
+func (s *Service) AlterTopicConfig(ctx context.Context, topicName string, entries map[string]*string) (err error) {
+	defer s.observeOperation(ctx, operationTypeAdmin, "alter_topic_config", time.Now(), &err)
+	exists, err := s.TopicExists(ctx, topicName)
+	if err != nil {
+		return fmt.Errorf("failed to check whether the topic exists: %w", err)
+	}
+	if !exists {
+		return ErrTopicNotFound
+	}
+
+	if s.supportsAPI(incrementalAlterConfigsAPIKey) {
+		return s.incrementalAlterTopicConfig(ctx, topicName, entries)
+	}
+
+	response, err := s.DescribeTopicsConfigs(ctx, []string{topicName}, nil)
+	if err != nil {
+		return fmt.Errorf("failed to describe current topic config: %w", err)
+	}
+	var current []*sarama.ConfigEntry
+	for _, res := range response.Resources {
+		if res.Name != topicName {
+			continue
+		}
+		if res.ErrorMsg != "" {
+			return fmt.Errorf("failed to describe current topic config: %v", res.ErrorMsg)
+		}
+		current = res.Configs
+	}
+
+	merged := mergeTopicConfigEntries(current, entries)
+	err = runWithContext(ctx, func() error {
+		return s.AdminClient.AlterConfig(sarama.TopicResource, topicName, merged, false)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to alter topic config: %w", err)
+	}
+
+	return nil
+}
+
+// incrementalAlterTopicConfig sets or deletes (if the value is nil) the given config entries of the topic via the
+// incremental alter configs API, which is not exposed by the admin client
+func (s *Service) incrementalAlterTopicConfig(ctx context.Context, topicName string, entries map[string]*string) error {
+	configEntries := make(map[string]sarama.IncrementalAlterConfigsEntry, len(entries))
+	for name, value := range entries {
+		entry := sarama.IncrementalAlterConfigsEntry{Operation: sarama.IncrementalAlterConfigsOperationSet, Value: value}
+		if value == nil {
+			entry.Operation = sarama.IncrementalAlterConfigsOperationDelete
+		}
+		configEntries[name] = entry
+	}
+	req := &sarama.IncrementalAlterConfigsRequest{
+		Resources: []*sarama.IncrementalAlterConfigsResource{{
+			Type:          sarama.TopicResource,
+			Name:          topicName,
+			ConfigEntries: configEntries,
+		}},
+	}
+
+	controller, err := s.Client.Controller()
+	if err != nil {
+		return fmt.Errorf("failed to get controller: %w", err)
+	}
+	var res *sarama.IncrementalAlterConfigsResponse
+	err = runWithContext(ctx, func() error {
+		var err error
+		res, err = controller.IncrementalAlterConfigs(req)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to alter topic config: %w", err)
+	}
+	for _, resource := range res.Resources {
+		if resource.Name != topicName || resource.ErrorCode == int16(sarama.ErrNoError) {
+			continue
+		}
+		if resource.ErrorMsg != "" {
+			return fmt.Errorf("failed to alter topic config: %w: %v", sarama.KError(resource.ErrorCode), resource.ErrorMsg)
+		}
+		return fmt.Errorf("failed to alter topic config: %w", sarama.KError(resource.ErrorCode))
+	}
+
+	return nil
+}
+
+// mergeTopicConfigEntries returns all config entries which are dynamically set on the topic, updated by the given
+// changes. Changes with a nil value remove the config entry, so that the default value applies again.
+func mergeTopicConfigEntries(current []*sarama.ConfigEntry, changes map[string]*string) map[string]*string {
+	merged := make(map[string]*string)
+	for _, entry := range current {
+		if !isDynamicTopicConfig(entry) {
+			continue
+		}
+		value := entry.Value
+		merged[entry.Name] = &value
+	}
+
+	for name, value := range changes {
+		if value == nil {
+			delete(merged, name)
+			continue
+		}
+		merged[name] = value
+	}
+
+	return merged
+}
+
+// isDynamicTopicConfig returns true if the config entry has been set on the topic itself. Brokers which do not
+// report config sources (Kafka < 1.1) only tell us whether the value is the default value.
+func isDynamicTopicConfig(entry *sarama.ConfigEntry) bool {
+	if entry.ReadOnly {
+		return false
+	}
+	if entry.Source == sarama.SourceUnknown {
+		return !entry.Default
+	}
+
+	return entry.Source == sarama.SourceTopic
+}
+
+// TopicExists fetches the current list of topics and checks whether it contains the given topic
+func (s *Service) TopicExists(ctx context.Context, topicName string) (bool, error) {
+	topics, err := s.ListTopics(ctx)
+	if err != nil {
+		return false, err
+	}
+	for _, topic := range topics {
+		if topic.Name == topicName {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
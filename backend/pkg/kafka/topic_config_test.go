@@ -0,0 +1,75 @@
+package kafka
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeTopicConfigEntries_SetAndReset(t *testing.T) {
+	strPtr := func(s string) *string { return &s }
+	current := []*sarama.ConfigEntry{
+		{Name: "retention.ms", Value: "86400000", Source: sarama.SourceTopic},
+		{Name: "cleanup.policy", Value: "delete", Source: sarama.SourceDefault, Default: true},
+		{Name: "min.insync.replicas", Value: "2", Source: sarama.SourceStaticBroker},
+	}
+
+	// Set a config, the dynamic configs which have been set before must be kept
+	merged := mergeTopicConfigEntries(current, map[string]*string{"cleanup.policy": strPtr("compact")})
+	assert.Equal(t, map[string]*string{
+		"retention.ms":   strPtr("86400000"),
+		"cleanup.policy": strPtr("compact"),
+	}, merged)
+
+	// Reset the config to it's default value
+	current[1] = &sarama.ConfigEntry{Name: "cleanup.policy", Value: "compact", Source: sarama.SourceTopic}
+	merged = mergeTopicConfigEntries(current, map[string]*string{"cleanup.policy": nil})
+	assert.Equal(t, map[string]*string{"retention.ms": strPtr("86400000")}, merged)
+}
+
+func TestIsDynamicTopicConfig_UnknownSource(t *testing.T) {
+	// Kafka < 1.1 does not report the config source
+	assert.True(t, isDynamicTopicConfig(&sarama.ConfigEntry{Name: "retention.ms", Source: sarama.SourceUnknown}))
+	assert.False(t, isDynamicTopicConfig(&sarama.ConfigEntry{Name: "retention.ms", Source: sarama.SourceUnknown, Default: true}))
+	assert.False(t, isDynamicTopicConfig(&sarama.ConfigEntry{Name: "retention.ms", Source: sarama.SourceUnknown, ReadOnly: true}))
+}
+
+func TestAlterTopicConfig_Incremental(t *testing.T) {
+	svc, broker := newTestService(t, sarama.V2_3_0_0, func(broker *sarama.MockBroker) map[string]sarama.MockResponse {
+		return map[string]sarama.MockResponse{
+			"MetadataRequest": sarama.NewMockMetadataResponse(t).
+				SetController(broker.BrokerID()).
+				SetBroker(broker.Addr(), broker.BrokerID()).
+				SetLeader("orders", 0, broker.BrokerID()),
+			"ApiVersionsRequest": sarama.NewMockWrapper(&sarama.ApiVersionsResponse{
+				ApiKeys: []sarama.ApiVersionsResponseKey{{ApiKey: incrementalAlterConfigsAPIKey, MinVersion: 0, MaxVersion: 1}},
+			}),
+			"IncrementalAlterConfigsRequest": sarama.NewMockWrapper(&sarama.IncrementalAlterConfigsResponse{
+				Resources: []*sarama.AlterConfigsResourceResponse{{Type: sarama.TopicResource, Name: "orders"}},
+			}),
+		}
+	})
+	_, err := svc.Client.Controller()
+	require.NoError(t, err)
+
+	// Only the changes are sent, the current config does not have to be described
+	retention := "86400000"
+	err = svc.AlterTopicConfig(context.Background(), "orders", map[string]*string{"retention.ms": &retention, "cleanup.policy": nil})
+	require.NoError(t, err)
+
+	var alterReq *sarama.IncrementalAlterConfigsRequest
+	for _, rr := range broker.History() {
+		if req, ok := rr.Request.(*sarama.IncrementalAlterConfigsRequest); ok {
+			alterReq = req
+		}
+	}
+	require.NotNil(t, alterReq)
+	require.Len(t, alterReq.Resources, 1)
+	assert.Equal(t, map[string]sarama.IncrementalAlterConfigsEntry{
+		"retention.ms":   {Operation: sarama.IncrementalAlterConfigsOperationSet, Value: &retention},
+		"cleanup.policy": {Operation: sarama.IncrementalAlterConfigsOperationDelete},
+	}, alterReq.Resources[0].ConfigEntries)
+}
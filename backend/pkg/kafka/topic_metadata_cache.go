@@ -0,0 +1,122 @@
+package kafka
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"go.uber.org/zap"
+)
+
+// TopicsMetadata is the (cached) metadata of all topics, including their partitions, replicas and in sync replicas
+type TopicsMetadata struct {
+	Topics    []*sarama.TopicMetadata
+	FetchedAt time.Time
+
+	// IsStale is true if the metadata has expired and is being refreshed in the background
+	IsStale bool
+}
+
+// topicMetadataCache caches the metadata of all topics, because it is requested on every page load of the frontend.
+// Expired metadata is still served (flagged as stale) within the stale window while it is refreshed in the background.
+type topicMetadataCache struct {
+	mutex        sync.Mutex
+	metadata     *TopicsMetadata
+	isRefreshing bool
+
+	// generation is incremented on every invalidation so that refreshes which have been started before are discarded
+	generation uint64
+}
+
+// ListTopicsCached returns the metadata of all topics, which is cached for the configured TTL. The returned topic
+// metadata is shared between callers and must not be modified.
+func (s *Service) ListTopicsCached(ctx context.Context) (*TopicsMetadata, error) {
+	if s.Config.TopicMetadataCacheTTL <= 0 {
+		topics, err := s.ListTopics(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return &TopicsMetadata{Topics: topics, FetchedAt: time.Now()}, nil
+	}
+
+	c := &s.topicMetadata
+	c.mutex.Lock()
+	if cached := c.metadata; cached != nil {
+		age := time.Since(cached.FetchedAt)
+		if age < s.Config.TopicMetadataCacheTTL {
+			c.mutex.Unlock()
+			return cached, nil
+		}
+		if age < s.Config.TopicMetadataCacheTTL+s.Config.TopicMetadataCacheStaleWindow {
+			if !c.isRefreshing {
+				c.isRefreshing = true
+				generation := c.generation
+				s.shutdown.goBackground(func(stop <-chan struct{}) { s.refreshTopicMetadata(stop, generation) })
+			}
+			c.mutex.Unlock()
+			return &TopicsMetadata{Topics: cached.Topics, FetchedAt: cached.FetchedAt, IsStale: true}, nil
+		}
+	}
+	generation := c.generation
+	c.mutex.Unlock()
+
+	topics, err := s.ListTopics(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.storeTopicMetadata(generation, topics), nil
+}
+
+// InvalidateTopicMetadataCache discards the cached topic metadata, so that it is fetched again on the next request.
+// It is called after all operations which modify topics.
+func (s *Service) InvalidateTopicMetadataCache() {
+	c := &s.topicMetadata
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.metadata = nil
+	c.generation++
+}
+
+// refreshTopicMetadata fetches the topic metadata in the background and caches it unless the cache has been
+// invalidated in the meantime. The fetch is cancelled once the stop channel is closed.
+func (s *Service) refreshTopicMetadata(stop <-chan struct{}, generation uint64) {
+	defer func() {
+		s.topicMetadata.mutex.Lock()
+		s.topicMetadata.isRefreshing = false
+		s.topicMetadata.mutex.Unlock()
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	topics, err := s.ListTopics(ctx)
+	if err != nil {
+		s.Logger.Warn("failed to refresh cached topic metadata", zap.Error(err))
+		return
+	}
+	s.storeTopicMetadata(generation, topics)
+}
+
+// storeTopicMetadata caches the fetched topics, if the cache has not been invalidated since the fetch has been started
+func (s *Service) storeTopicMetadata(generation uint64, topics []*sarama.TopicMetadata) *TopicsMetadata {
+	metadata := &TopicsMetadata{Topics: topics, FetchedAt: time.Now()}
+
+	c := &s.topicMetadata
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if generation == c.generation {
+		c.metadata = metadata
+	}
+
+	return metadata
+}
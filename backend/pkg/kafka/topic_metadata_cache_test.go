@@ -0,0 +1,56 @@
+package kafka
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListTopicsCached(t *testing.T) {
+	svc, _ := newTestService(t, sarama.V1_0_0_0, func(broker *sarama.MockBroker) map[string]sarama.MockResponse {
+		return map[string]sarama.MockResponse{
+			"MetadataRequest": sarama.NewMockMetadataResponse(t).
+				SetController(broker.BrokerID()).
+				SetBroker(broker.Addr(), broker.BrokerID()).
+				SetLeader("orders", 0, broker.BrokerID()),
+		}
+	})
+	_, err := svc.Client.Controller()
+	require.NoError(t, err)
+	svc.Config = Config{TopicMetadataCacheTTL: time.Minute, TopicMetadataCacheStaleWindow: time.Minute}
+
+	first, err := svc.ListTopicsCached(context.Background())
+	require.NoError(t, err)
+	require.Len(t, first.Topics, 1)
+	assert.Equal(t, "orders", first.Topics[0].Name)
+	assert.False(t, first.IsStale)
+
+	// Cached metadata is served until it expires
+	second, err := svc.ListTopicsCached(context.Background())
+	require.NoError(t, err)
+	assert.Same(t, first, second)
+
+	// Expired metadata is served as stale within the stale window while it's refreshed in the background
+	svc.topicMetadata.mutex.Lock()
+	svc.topicMetadata.metadata.FetchedAt = time.Now().Add(-90 * time.Second)
+	svc.topicMetadata.mutex.Unlock()
+	stale, err := svc.ListTopicsCached(context.Background())
+	require.NoError(t, err)
+	assert.True(t, stale.IsStale)
+	assert.Eventually(t, func() bool {
+		refreshed, err := svc.ListTopicsCached(context.Background())
+		return err == nil && !refreshed.IsStale && refreshed != first
+	}, time.Second, 10*time.Millisecond)
+
+	// Invalidation discards the cached metadata
+	cached, err := svc.ListTopicsCached(context.Background())
+	require.NoError(t, err)
+	svc.InvalidateTopicMetadataCache()
+	fetched, err := svc.ListTopicsCached(context.Background())
+	require.NoError(t, err)
+	assert.NotSame(t, cached, fetched)
+}
@@ -0,0 +1,145 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"go.uber.org/zap"
+)
+
+// PartitionOffsets are the low and high watermarks of a partition. Err is set if the partition has no leader or the
+// leader failed to return the offsets, in that case the watermarks are not set.
+type PartitionOffsets struct {
+	PartitionID int32
+	Low         int64
+	High        int64
+	Err         error
+}
+
+// ListPartitionOffsets returns the low and high watermarks of the given partitions. In contrast to WaterMarks it
+// does not fail if single partitions are leaderless or their leader doesn't respond, but reports the error for the
+// affected partitions instead.
+func (s *Service) ListPartitionOffsets(ctx context.Context, topicName string, partitionIDs []int32) (res map[int32]*PartitionOffsets, err error) {
+	defer s.observeOperation(ctx, operationTypeAdmin, "list_partition_offsets", time.Now(), &err)
+
+	res = make(map[int32]*PartitionOffsets, len(partitionIDs))
+	brokers := make(map[int32]*sarama.Broker)
+	partitionsByBroker := make(map[int32][]int32)
+	for _, partitionID := range partitionIDs {
+		res[partitionID] = &PartitionOffsets{PartitionID: partitionID}
+		broker, err := s.Client.Leader(topicName, partitionID)
+		if err != nil {
+			res[partitionID].Err = fmt.Errorf("failed to get partition leader: %w", err)
+			continue
+		}
+		brokers[broker.ID()] = broker
+		partitionsByBroker[broker.ID()] = append(partitionsByBroker[broker.ID()], partitionID)
+	}
+
+	// Only one offset can be requested per partition and request, hence the oldest and newest offsets are requested
+	// one after another for each broker
+	var mutex sync.Mutex
+	wg := sync.WaitGroup{}
+	for brokerID, brokerPartitionIDs := range partitionsByBroker {
+		wg.Add(1)
+		go func(broker *sarama.Broker, brokerPartitionIDs []int32) {
+			defer wg.Done()
+			for _, offsetType := range []int64{sarama.OffsetOldest, sarama.OffsetNewest} {
+				req := &sarama.OffsetRequest{}
+				for _, partitionID := range brokerPartitionIDs {
+					req.AddBlock(topicName, partitionID, offsetType, 1)
+				}
+
+				var offsets *sarama.OffsetResponse
+				err := runWithContext(ctx, func() error {
+					var err error
+					offsets, err = broker.GetAvailableOffsets(req)
+					return err
+				})
+
+				mutex.Lock()
+				applyOffsetResponse(res, topicName, brokerPartitionIDs, offsets, err, offsetType)
+				mutex.Unlock()
+			}
+		}(brokers[brokerID], brokerPartitionIDs)
+	}
+	wg.Wait()
+
+	return res, nil
+}
+
+// applyOffsetResponse sets the offsets of the given partitions from the broker's response. If the request failed or
+// a partition's block contains an error, the error is set for the partition instead.
+func applyOffsetResponse(res map[int32]*PartitionOffsets, topicName string, partitionIDs []int32, offsets *sarama.OffsetResponse, reqErr error, offsetType int64) {
+	for _, partitionID := range partitionIDs {
+		partition := res[partitionID]
+		if partition.Err != nil {
+			continue
+		}
+		if reqErr != nil {
+			partition.Err = fmt.Errorf("failed to request offsets from partition leader: %w", reqErr)
+			continue
+		}
+
+		block := offsets.GetBlock(topicName, partitionID)
+		switch {
+		case block == nil:
+			partition.Err = fmt.Errorf("partition leader did not return offsets for the partition")
+		case block.Err != sarama.ErrNoError:
+			partition.Err = block.Err
+		case len(block.Offsets) == 0:
+			partition.Err = fmt.Errorf("partition leader returned an empty offset list")
+		case offsetType == sarama.OffsetOldest:
+			partition.Low = block.Offsets[0]
+		default:
+			partition.High = block.Offsets[0]
+		}
+	}
+}
+
+// LastMessageTimestamps returns the timestamps of the last message of the given partitions. Partitions whose last
+// offset is not a message (e.g. because it has been compacted away or it's a transaction marker) are missing in the
+// result.
+func (s *Service) LastMessageTimestamps(ctx context.Context, topicName string, marks map[int32]*PartitionOffsets) (res map[int32]time.Time, err error) {
+	defer s.observeOperation(ctx, operationTypeConsume, "last_message_timestamps", time.Now(), &err)
+	ctx, done, err := s.TrackOperation(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	client, release, err := s.newFetchClient(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create consumer client: %w", err)
+	}
+	defer release()
+
+	var mutex sync.Mutex
+	res = make(map[int32]time.Time)
+	wg := sync.WaitGroup{}
+	for _, mark := range marks {
+		if mark.Err != nil || mark.High <= mark.Low {
+			continue
+		}
+
+		wg.Add(1)
+		go func(partitionID int32, lastOffset int64) {
+			defer wg.Done()
+			err := s.consumeOffsetRangeFunc(ctx, client, topicName, partitionID, lastOffset, lastOffset, func(m timestampedMessage) {
+				mutex.Lock()
+				res[partitionID] = m.timestamp
+				mutex.Unlock()
+			})
+			if err != nil {
+				s.Logger.Debug("failed to consume last message of partition", zap.String("topic", topicName),
+					zap.Int32("partition_id", partitionID), zap.Error(err))
+			}
+		}(mark.PartitionID, mark.High-1)
+	}
+	wg.Wait()
+
+	return res, nil
+}
@@ -0,0 +1,41 @@
+package kafka
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyOffsetResponse(t *testing.T) {
+	res := map[int32]*PartitionOffsets{
+		0: {PartitionID: 0},
+		1: {PartitionID: 1},
+		2: {PartitionID: 2},
+		3: {PartitionID: 3, Err: sarama.ErrLeaderNotAvailable},
+	}
+
+	oldest := &sarama.OffsetResponse{}
+	oldest.AddTopicPartition("orders", 0, 10)
+	oldest.AddTopicPartition("orders", 1, 0)
+	oldest.Blocks["orders"][1].Err = sarama.ErrNotLeaderForPartition
+	applyOffsetResponse(res, "orders", []int32{0, 1, 2}, oldest, nil, sarama.OffsetOldest)
+
+	newest := &sarama.OffsetResponse{}
+	newest.AddTopicPartition("orders", 0, 42)
+	applyOffsetResponse(res, "orders", []int32{0, 1, 2}, newest, nil, sarama.OffsetNewest)
+
+	assert.NoError(t, res[0].Err)
+	assert.Equal(t, int64(10), res[0].Low)
+	assert.Equal(t, int64(42), res[0].High)
+	assert.True(t, errors.Is(res[1].Err, sarama.ErrNotLeaderForPartition))
+	assert.Error(t, res[2].Err, "missing block")
+	assert.True(t, errors.Is(res[3].Err, sarama.ErrLeaderNotAvailable), "errors are not overwritten")
+
+	// Failed requests mark all partitions of the broker as unavailable
+	res = map[int32]*PartitionOffsets{0: {PartitionID: 0}}
+	applyOffsetResponse(res, "orders", []int32{0}, nil, fmt.Errorf("connection refused"), sarama.OffsetOldest)
+	assert.Error(t, res[0].Err)
+}
@@ -0,0 +1,315 @@
+package kafka
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// vaultClient fetches the TLS material from Vault. It authenticates with the configured token or via AppRole. The
+// token is renewed once half of its TTL has elapsed, either when TLS material is loaded or by watchToken, so that the
+// token does not expire between two certificate refreshes. If the token can not be renewed, it logs in again via
+// AppRole.
+type vaultClient struct {
+	cfg        VaultConfig
+	logger     *zap.Logger
+	httpClient *http.Client
+
+	mutex     sync.Mutex
+	token     string
+	ttl       time.Duration
+	expiresAt time.Time
+	renewable bool
+}
+
+// vaultResponse contains the fields of Vault's API responses which are used by the vault client
+type vaultResponse struct {
+	Data   map[string]interface{} `json:"data"`
+	Auth   *vaultAuth             `json:"auth"`
+	Errors []string               `json:"errors"`
+}
+
+type vaultAuth struct {
+	ClientToken   string `json:"client_token"`
+	LeaseDuration int64  `json:"lease_duration"`
+	Renewable     bool   `json:"renewable"`
+}
+
+// vaultTokenRetryInterval is the delay after which a failed token renewal is retried
+const vaultTokenRetryInterval = 10 * time.Second
+
+func newVaultClient(cfg VaultConfig, logger *zap.Logger) *vaultClient {
+	return &vaultClient{
+		cfg:        cfg,
+		logger:     logger,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// loadTLSMaterial can be used as certLoader. It issues a new certificate (PKI) or reads the certificate (KV) from
+// Vault.
+func (v *vaultClient) loadTLSMaterial() (*tlsMaterial, error) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	err := v.ensureToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to authenticate against vault: %w", err)
+	}
+
+	if v.cfg.PKI.Path != "" {
+		return v.issueCertificate()
+	}
+	return v.readCertificate()
+}
+
+// watchToken renews the token once half of its TTL has elapsed, independently of how often the TLS material is loaded.
+// Failed renewals are retried after vaultTokenRetryInterval. It returns once the stop channel is closed.
+func (v *vaultClient) watchToken(stop <-chan struct{}) {
+	timer := time.NewTimer(v.nextTokenRenewal())
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-timer.C:
+			v.mutex.Lock()
+			err := v.ensureToken()
+			v.mutex.Unlock()
+
+			wait := v.nextTokenRenewal()
+			if err != nil {
+				v.logger.Warn("failed to renew vault token", zap.Error(err))
+				wait = vaultTokenRetryInterval
+			}
+			timer.Reset(wait)
+		}
+	}
+}
+
+// nextTokenRenewal returns the duration until half of the token's TTL has elapsed. Tokens which never expire are
+// checked again after the default refresh interval, tokens which are due but could not be renewed (e.g. static tokens)
+// after the retry interval.
+func (v *vaultClient) nextTokenRenewal() time.Duration {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	if v.token == "" {
+		return vaultTokenRetryInterval
+	}
+	if v.ttl == 0 {
+		return vaultDefaultRefreshInterval
+	}
+	wait := time.Until(v.expiresAt) - v.ttl/2
+	if wait <= 0 {
+		return vaultTokenRetryInterval
+	}
+	return wait
+}
+
+// ensureToken makes sure that the client has a valid token. The caller must hold the mutex.
+func (v *vaultClient) ensureToken() error {
+	// A TTL of 0 is returned for tokens which never expire, e.g. root tokens
+	if v.token != "" && (v.ttl == 0 || time.Until(v.expiresAt) > v.ttl/2) {
+		return nil
+	}
+
+	if v.token != "" && v.renewable {
+		res, err := v.request(http.MethodPost, "auth/token/renew-self", map[string]interface{}{})
+		if err == nil && res.Auth != nil {
+			v.setAuth(res.Auth)
+			return nil
+		}
+		v.logger.Warn("failed to renew vault token", zap.Error(err))
+	}
+
+	if v.cfg.AppRole.RoleID != "" {
+		return v.loginAppRole()
+	}
+	if v.token == "" {
+		return v.lookupToken()
+	}
+
+	// The static token can not be renewed, we keep using it until it's rejected by Vault
+	return nil
+}
+
+// loginAppRole obtains a new token via the AppRole auth method. The caller must hold the mutex.
+func (v *vaultClient) loginAppRole() error {
+	v.token = ""
+	path := fmt.Sprintf("auth/%v/login", strings.Trim(v.cfg.AppRole.MountPath, "/"))
+	res, err := v.request(http.MethodPost, path, map[string]interface{}{
+		"role_id":   v.cfg.AppRole.RoleID,
+		"secret_id": v.cfg.AppRole.SecretID,
+	})
+	if err != nil {
+		return fmt.Errorf("approle login failed: %w", err)
+	}
+	if res.Auth == nil || res.Auth.ClientToken == "" {
+		return fmt.Errorf("approle login returned no client token")
+	}
+	v.setAuth(res.Auth)
+
+	return nil
+}
+
+// lookupToken looks up the TTL of the configured static token. The caller must hold the mutex.
+func (v *vaultClient) lookupToken() error {
+	v.token = v.cfg.Token
+	res, err := v.request(http.MethodGet, "auth/token/lookup-self", nil)
+	if err != nil {
+		v.token = ""
+		return fmt.Errorf("token lookup failed: %w", err)
+	}
+
+	ttl, _ := res.Data["ttl"].(float64)
+	renewable, _ := res.Data["renewable"].(bool)
+	v.setAuth(&vaultAuth{ClientToken: v.cfg.Token, LeaseDuration: int64(ttl), Renewable: renewable})
+
+	return nil
+}
+
+func (v *vaultClient) setAuth(auth *vaultAuth) {
+	if auth.ClientToken != "" {
+		v.token = auth.ClientToken
+	}
+	v.ttl = time.Duration(auth.LeaseDuration) * time.Second
+	v.expiresAt = time.Now().Add(v.ttl)
+	v.renewable = auth.Renewable
+}
+
+// issueCertificate issues a new client certificate using the PKI secrets engine. The caller must hold the mutex.
+func (v *vaultClient) issueCertificate() (*tlsMaterial, error) {
+	body := map[string]interface{}{"common_name": v.cfg.PKI.CommonName}
+	if v.cfg.PKI.TTL > 0 {
+		body["ttl"] = v.cfg.PKI.TTL.String()
+	}
+	res, err := v.request(http.MethodPost, v.cfg.PKI.Path, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to issue certificate: %w", err)
+	}
+
+	certPEM, _ := res.Data["certificate"].(string)
+	keyPEM, _ := res.Data["private_key"].(string)
+	caPEMs := make([]string, 0)
+	if chain, ok := res.Data["ca_chain"].([]interface{}); ok {
+		for _, ca := range chain {
+			if caPEM, ok := ca.(string); ok {
+				caPEMs = append(caPEMs, caPEM)
+			}
+		}
+	}
+	if issuingCA, ok := res.Data["issuing_ca"].(string); ok && len(caPEMs) == 0 {
+		caPEMs = append(caPEMs, issuingCA)
+	}
+
+	return newVaultTLSMaterial(certPEM, keyPEM, caPEMs)
+}
+
+// readCertificate reads the client certificate from a KV secret. The caller must hold the mutex.
+func (v *vaultClient) readCertificate() (*tlsMaterial, error) {
+	res, err := v.request(http.MethodGet, v.cfg.KV.Path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secret: %w", err)
+	}
+
+	// KV v2 wraps the secret's fields along with its metadata
+	fields := res.Data
+	if nested, ok := res.Data["data"].(map[string]interface{}); ok {
+		if _, hasMetadata := res.Data["metadata"]; hasMetadata {
+			fields = nested
+		}
+	}
+
+	certPEM, _ := fields[v.cfg.KV.CertField].(string)
+	keyPEM, _ := fields[v.cfg.KV.KeyField].(string)
+	caPEMs := make([]string, 0)
+	if caPEM, ok := fields[v.cfg.KV.CaField].(string); ok && v.cfg.KV.CaField != "" {
+		caPEMs = append(caPEMs, caPEM)
+	}
+	if certPEM == "" || keyPEM == "" {
+		return nil, fmt.Errorf("secret '%v' does not contain the fields '%v' and '%v'", v.cfg.KV.Path, v.cfg.KV.CertField, v.cfg.KV.KeyField)
+	}
+
+	return newVaultTLSMaterial(certPEM, keyPEM, caPEMs)
+}
+
+// newVaultTLSMaterial parses the PEM encoded TLS material. The CA pool is nil if no CA has been returned by Vault, so
+// that the system's cert pool is used.
+func newVaultTLSMaterial(certPEM, keyPEM string, caPEMs []string) (*tlsMaterial, error) {
+	cert, err := tls.X509KeyPair([]byte(certPEM), []byte(keyPEM))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse certificate from vault: %w", err)
+	}
+
+	material := &tlsMaterial{certs: []tls.Certificate{cert}}
+	if len(caPEMs) == 0 {
+		return material, nil
+	}
+
+	material.caPool = x509.NewCertPool()
+	for _, caPEM := range caPEMs {
+		if !material.caPool.AppendCertsFromPEM([]byte(caPEM)) {
+			return nil, fmt.Errorf("failed to parse ca certificate from vault")
+		}
+	}
+
+	return material, nil
+}
+
+// request sends a request to Vault's HTTP API. The path is relative to /v1/.
+func (v *vaultClient) request(method string, path string, body interface{}) (*vaultResponse, error) {
+	var reqBody io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reqBody = bytes.NewReader(encoded)
+	}
+
+	url := strings.TrimSuffix(v.cfg.Address, "/") + "/v1/" + strings.TrimPrefix(path, "/")
+	req, err := http.NewRequest(method, url, reqBody)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if v.token != "" {
+		req.Header.Set("X-Vault-Token", v.token)
+	}
+	if v.cfg.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", v.cfg.Namespace)
+	}
+
+	res, err := v.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	var vaultRes vaultResponse
+	decodeErr := json.NewDecoder(res.Body).Decode(&vaultRes)
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		if len(vaultRes.Errors) > 0 {
+			return nil, fmt.Errorf("vault returned status code %d: %v", res.StatusCode, strings.Join(vaultRes.Errors, ", "))
+		}
+		return nil, fmt.Errorf("vault returned status code %d", res.StatusCode)
+	}
+	if decodeErr != nil {
+		return nil, fmt.Errorf("failed to decode vault response: %w", decodeErr)
+	}
+
+	return &vaultRes, nil
+}
@@ -0,0 +1,216 @@
+package kafka
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// testVault is a minimal Vault server which supports AppRole logins, token renewals, PKI issuing and KV v2 reads
+type testVault struct {
+	mutex        sync.Mutex
+	logins       int
+	renewals     int
+	failRenewals bool
+	issued       []string
+	namespace    string
+}
+
+func (v *testVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	writeJSON := func(statusCode int, body interface{}) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(statusCode)
+		_ = json.NewEncoder(w).Encode(body)
+	}
+	forbidden := map[string]interface{}{"errors": []string{"permission denied"}}
+	v.namespace = r.Header.Get("X-Vault-Namespace")
+
+	if r.URL.Path == "/v1/auth/approle/login" {
+		var body map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if body["role_id"] != "role" || body["secret_id"] != "secret" {
+			writeJSON(http.StatusBadRequest, map[string]interface{}{"errors": []string{"invalid role or secret id"}})
+			return
+		}
+		v.logins++
+		writeJSON(http.StatusOK, map[string]interface{}{
+			"auth": map[string]interface{}{"client_token": "approle-token", "lease_duration": 60, "renewable": true},
+		})
+		return
+	}
+
+	token := r.Header.Get("X-Vault-Token")
+	if token != "approle-token" && token != "static-token" {
+		writeJSON(http.StatusForbidden, forbidden)
+		return
+	}
+
+	switch r.URL.Path {
+	case "/v1/auth/token/renew-self":
+		if v.failRenewals {
+			writeJSON(http.StatusForbidden, forbidden)
+			return
+		}
+		v.renewals++
+		writeJSON(http.StatusOK, map[string]interface{}{
+			"auth": map[string]interface{}{"client_token": token, "lease_duration": 60, "renewable": true},
+		})
+	case "/v1/auth/token/lookup-self":
+		writeJSON(http.StatusOK, map[string]interface{}{"data": map[string]interface{}{"ttl": 0, "renewable": false}})
+	case "/v1/pki/issue/kowl":
+		var body map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		v.issued = append(v.issued, body["common_name"])
+		certPEM, keyPEM := newSelfSignedPEM(body["common_name"])
+		writeJSON(http.StatusOK, map[string]interface{}{"data": map[string]interface{}{
+			"certificate": certPEM,
+			"private_key": keyPEM,
+			"issuing_ca":  certPEM,
+			"ca_chain":    []string{certPEM},
+		}})
+	case "/v1/secret/data/kowl":
+		certPEM, keyPEM := newSelfSignedPEM("kv")
+		writeJSON(http.StatusOK, map[string]interface{}{"data": map[string]interface{}{
+			"data":     map[string]interface{}{"certificate": certPEM, "private_key": keyPEM},
+			"metadata": map[string]interface{}{"version": 1},
+		}})
+	default:
+		writeJSON(http.StatusNotFound, map[string]interface{}{"errors": []string{}})
+	}
+}
+
+func TestVaultClient_PKIWithAppRole(t *testing.T) {
+	vault := &testVault{}
+	server := httptest.NewServer(vault)
+	defer server.Close()
+
+	cfg := VaultConfig{}
+	cfg.SetDefaults()
+	cfg.Enabled = true
+	cfg.Address = server.URL
+	cfg.AppRole.RoleID = "role"
+	cfg.AppRole.SecretID = "secret"
+	cfg.PKI.Path = "pki/issue/kowl"
+	cfg.PKI.CommonName = "kowl"
+	client := newVaultClient(cfg, zap.NewNop())
+
+	material, err := client.loadTLSMaterial()
+	require.NoError(t, err)
+	require.Len(t, material.certs, 1)
+	assert.Equal(t, "kowl", parseLeaf(t, &material.certs[0]).Subject.CommonName)
+	assert.NotNil(t, material.caPool)
+	assert.Equal(t, 1, vault.logins)
+
+	// The token is renewed once half of its TTL has elapsed
+	client.expiresAt = time.Now().Add(10 * time.Second)
+	_, err = client.loadTLSMaterial()
+	require.NoError(t, err)
+	assert.Equal(t, 1, vault.logins)
+	assert.Equal(t, 1, vault.renewals)
+
+	// If the renewal fails, the client logs in again
+	vault.failRenewals = true
+	client.expiresAt = time.Now().Add(10 * time.Second)
+	_, err = client.loadTLSMaterial()
+	require.NoError(t, err)
+	assert.Equal(t, 2, vault.logins)
+	assert.Len(t, vault.issued, 3)
+}
+
+func TestVaultClient_WatchToken(t *testing.T) {
+	vault := &testVault{}
+	server := httptest.NewServer(vault)
+	defer server.Close()
+
+	cfg := VaultConfig{}
+	cfg.SetDefaults()
+	cfg.Enabled = true
+	cfg.Address = server.URL
+	cfg.AppRole.RoleID = "role"
+	cfg.AppRole.SecretID = "secret"
+	cfg.PKI.Path = "pki/issue/kowl"
+	client := newVaultClient(cfg, zap.NewNop())
+	_, err := client.loadTLSMaterial()
+	require.NoError(t, err)
+
+	// The token is renewed once half of its TTL has elapsed, even though no TLS material is loaded
+	client.expiresAt = time.Now().Add(client.ttl/2 + 50*time.Millisecond)
+	stop := make(chan struct{})
+	defer close(stop)
+	go client.watchToken(stop)
+
+	assert.Eventually(t, func() bool {
+		client.mutex.Lock()
+		defer client.mutex.Unlock()
+		return vault.renewals == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	client.mutex.Lock()
+	assert.Equal(t, 1, vault.logins)
+	assert.Len(t, vault.issued, 1)
+	client.mutex.Unlock()
+}
+
+func TestVaultClient_KVWithToken(t *testing.T) {
+	vault := &testVault{}
+	server := httptest.NewServer(vault)
+	defer server.Close()
+
+	cfg := VaultConfig{}
+	cfg.SetDefaults()
+	cfg.Enabled = true
+	cfg.Address = server.URL
+	cfg.Namespace = "team-a"
+	cfg.Token = "static-token"
+	cfg.KV.Path = "secret/data/kowl"
+	client := newVaultClient(cfg, zap.NewNop())
+
+	material, err := client.loadTLSMaterial()
+	require.NoError(t, err)
+	require.Len(t, material.certs, 1)
+	assert.Equal(t, "kv", parseLeaf(t, &material.certs[0]).Subject.CommonName)
+	assert.Nil(t, material.caPool, "the system cert pool must be used if the secret contains no CA")
+	assert.Equal(t, "team-a", vault.namespace)
+
+	client.cfg.KV.Path = "secret/data/unknown"
+	_, err = client.loadTLSMaterial()
+	assert.Error(t, err)
+
+	cfg.Token = "invalid-token"
+	_, err = newVaultClient(cfg, zap.NewNop()).loadTLSMaterial()
+	assert.Error(t, err)
+}
+
+func newSelfSignedPEM(commonName string) (string, string) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, _ := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	keyDER, _ := x509.MarshalECPrivateKey(key)
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	return string(certPEM), string(keyPEM)
+}
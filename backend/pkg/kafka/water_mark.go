@@ -1,9 +1,11 @@
 package kafka
 
 import (
+	"context"
 	"fmt"
 	"github.com/Shopify/sarama"
 	"go.uber.org/zap"
+	"time"
 )
 
 // WaterMark is a partitionID along with it's highest and lowest message index
@@ -13,8 +15,22 @@ type WaterMark struct {
 	High        int64
 }
 
-// WaterMarks returns a map of: partitionID -> *waterMark
-func (s *Service) WaterMarks(topic string, partitionIDs []int32) (map[int32]*WaterMark, error) {
+// WaterMarks returns a map of: partitionID -> *waterMark. It stops waiting for the brokers once the context is done.
+// Requests which fail with a transient error (e.g. because a partition has no leader) are retried.
+func (s *Service) WaterMarks(ctx context.Context, topic string, partitionIDs []int32) (marks map[int32]*WaterMark, err error) {
+	defer s.observeOperation(ctx, operationTypeAdmin, "water_marks", time.Now(), &err)
+	err = s.retry(ctx, "water_marks", func() error {
+		var err error
+		marks, err = s.waterMarks(ctx, topic, partitionIDs)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return marks, nil
+}
+
+func (s *Service) waterMarks(ctx context.Context, topic string, partitionIDs []int32) (map[int32]*WaterMark, error) {
 	// 1. Generate an OffsetRequest for each topic:partition and bucket it to the leader broker
 	brokers := make(map[int32]*sarama.Broker)
 
@@ -71,7 +87,12 @@ func (s *Service) WaterMarks(topic string, partitionIDs []int32) (map[int32]*Wat
 
 	// Iterate on returned offsets and put them into our response map
 	for i := 0; i < cap(ch); i++ {
-		r := <-ch
+		var r response
+		select {
+		case r = <-ch:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
 		if r.Error != nil {
 			return nil, r.Error
 		}
@@ -81,10 +102,10 @@ func (s *Service) WaterMarks(topic string, partitionIDs []int32) (map[int32]*Wat
 				if block.Err == sarama.ErrNotLeaderForPartition {
 					s.Logger.Info("failed to fetch topic watermarks because metadata is outdated. Metadata will be refreshed and request rescheduled",
 						zap.String("topic", topic))
-					// This might happen due to outdated metadata (e. g. because some kafka brokers restarted recently)
-					err := s.Client.RefreshMetadata()
-					if err == nil {
-						return s.WaterMarks(topic, partitionIDs)
+					// This might happen due to outdated metadata (e. g. because some kafka brokers restarted recently).
+					// The error is retriable, hence the request is retried with the refreshed metadata.
+					if err := s.Client.RefreshMetadata(topic); err != nil {
+						s.Logger.Warn("failed to refresh metadata", zap.String("topic", topic), zap.Error(err))
 					}
 				}
 				if block.Err != sarama.ErrNoError {
@@ -108,7 +129,8 @@ func (s *Service) WaterMarks(topic string, partitionIDs []int32) (map[int32]*Wat
 }
 
 // HighWaterMarks returns a nested map of: topic -> partitionID -> high water mark offset of all available partitions
-func (s *Service) HighWaterMarks(topicPartitions map[string][]int32) (map[string]map[int32]int64, error) {
+func (s *Service) HighWaterMarks(ctx context.Context, topicPartitions map[string][]int32) (marks map[string]map[int32]int64, err error) {
+	defer s.observeOperation(ctx, operationTypeAdmin, "high_water_marks", time.Now(), &err)
 	// 1. Generate an OffsetRequest for each topic:partition and bucket it to the leader broker
 	brokers := make(map[int32]*sarama.Broker)
 
@@ -153,7 +175,12 @@ func (s *Service) HighWaterMarks(topicPartitions map[string][]int32) (map[string
 
 	res := make(map[string]map[int32]int64)
 	for i := 0; i < len(reqs); i++ {
-		r := <-ch
+		var r response
+		select {
+		case r = <-ch:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
 		if r.Error != nil {
 			s.Logger.Error("failed to fetch high water marks from broker", zap.Error(r.Error))
 			return nil, r.Error
@@ -169,7 +196,7 @@ func (s *Service) HighWaterMarks(topicPartitions map[string][]int32) (map[string
 					// This might happen due to outdated metadata (e. g. because some kafka brokers restarted recently)
 					err := s.Client.RefreshMetadata()
 					if err == nil {
-						return s.HighWaterMarks(topicPartitions)
+						return s.HighWaterMarks(ctx, topicPartitions)
 					}
 				}
 
@@ -0,0 +1,95 @@
+package oidc
+
+import (
+	"flag"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// Config for authenticating users of the Kowl UI via OpenID Connect (authorization code flow)
+type Config struct {
+	Enabled bool `yaml:"enabled"`
+
+	// IssuerURL is the URL of the OpenID provider, its configuration is discovered at
+	// <issuerUrl>/.well-known/openid-configuration
+	IssuerURL    string   `yaml:"issuerUrl"`
+	ClientID     string   `yaml:"clientId"`
+	ClientSecret string   `yaml:"clientSecret"`
+	RedirectURL  string   `yaml:"redirectUrl"` // Must point to the callback route /auth/callback of Kowl
+	Scopes       []string `yaml:"scopes"`
+
+	// UsernameClaim and GroupsClaim are the claims of the ID token which identify the user and its groups. The subject
+	// is used as username if the username claim is not set. If the email claim is used, ID tokens whose
+	// email_verified claim is false are rejected.
+	UsernameClaim string `yaml:"usernameClaim"`
+	GroupsClaim   string `yaml:"groupsClaim"`
+
+	// SessionSecret is used to encrypt the session cookies, it must be at least 32 characters long and must be the
+	// same for all Kowl instances behind a load balancer
+	SessionSecret string `yaml:"sessionSecret"`
+
+	// SessionMaxAge is the max duration of a session. Sessions are extended with the refresh token when the ID token
+	// has expired, the user must log in again once the max age has been reached.
+	SessionMaxAge time.Duration `yaml:"sessionMaxAge"`
+
+	// CookieSecure sets the secure attribute of the session cookies, it should only be disabled for local development
+	CookieSecure bool `yaml:"cookieSecure"`
+
+	// RequestTimeout is the timeout of requests against the OpenID provider
+	RequestTimeout time.Duration `yaml:"requestTimeout"`
+}
+
+// SetDefaults for the OIDC config
+func (c *Config) SetDefaults() {
+	c.Scopes = []string{"openid", "profile", "email"}
+	c.UsernameClaim = "email"
+	c.GroupsClaim = "groups"
+	c.SessionMaxAge = 24 * time.Hour
+	c.CookieSecure = true
+	c.RequestTimeout = 10 * time.Second
+}
+
+// RegisterFlags registers all sensitive OIDC config flags
+func (c *Config) RegisterFlags(f *flag.FlagSet) {
+	f.StringVar(&c.ClientSecret, "oidc.client-secret", "", "Client secret for the OpenID Connect login")
+	f.StringVar(&c.SessionSecret, "oidc.session-secret", "", "Secret for encrypting the session cookies of the OpenID Connect login")
+}
+
+// Validate the OIDC config
+func (c *Config) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+
+	if c.IssuerURL == "" {
+		return fmt.Errorf("oidc is enabled but no issuerUrl is configured")
+	}
+	if c.ClientID == "" {
+		return fmt.Errorf("oidc is enabled but no clientId is configured")
+	}
+	if _, err := url.ParseRequestURI(c.RedirectURL); err != nil {
+		return fmt.Errorf("oidc redirectUrl is invalid: %w", err)
+	}
+	hasOpenIDScope := false
+	for _, scope := range c.Scopes {
+		hasOpenIDScope = hasOpenIDScope || scope == "openid"
+	}
+	if !hasOpenIDScope {
+		return fmt.Errorf("oidc scopes must contain the openid scope")
+	}
+	if c.UsernameClaim == "" {
+		return fmt.Errorf("oidc usernameClaim must not be empty")
+	}
+	if len(c.SessionSecret) < 32 {
+		return fmt.Errorf("oidc sessionSecret must be at least 32 characters long")
+	}
+	if c.SessionMaxAge <= 0 {
+		return fmt.Errorf("oidc sessionMaxAge must be a positive duration")
+	}
+	if c.RequestTimeout <= 0 {
+		return fmt.Errorf("oidc requestTimeout must be a positive duration")
+	}
+
+	return nil
+}
@@ -0,0 +1,67 @@
+package oidc
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// idTokenClaims are the verified claims of an ID token
+type idTokenClaims struct {
+	Subject   string
+	ExpiresAt time.Time
+
+	// all contains all claims, including the user and group claims
+	all map[string]interface{}
+}
+
+// verifyIDToken verifies the signature, the issuer, the audience and the expiry of the ID token. The nonce is only
+// checked if it is not empty, as ID tokens of refresh responses carry no nonce.
+func (p *provider) verifyIDToken(ctx context.Context, rawToken string, nonce string) (*idTokenClaims, error) {
+	token, err := p.verifier.Verify(ctx, rawToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify id token: %w", err)
+	}
+	if nonce != "" && token.Nonce != nonce {
+		return nil, fmt.Errorf("id token nonce does not match")
+	}
+
+	claims := &idTokenClaims{Subject: token.Subject, ExpiresAt: token.Expiry}
+	err = token.Claims(&claims.all)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode id token claims: %w", err)
+	}
+
+	return claims, nil
+}
+
+// isEmailUnverified returns true if the provider states that the email claim has not been verified. Tokens without
+// the email_verified claim are accepted, because not all providers issue it.
+func (c *idTokenClaims) isEmailUnverified() bool {
+	verified, ok := c.all["email_verified"].(bool)
+	return ok && !verified
+}
+
+// stringClaim returns the claim if it is a string
+func (c *idTokenClaims) stringClaim(name string) string {
+	value, _ := c.all[name].(string)
+	return value
+}
+
+// stringsClaim returns the claim if it is a list of strings or a single string
+func (c *idTokenClaims) stringsClaim(name string) []string {
+	switch value := c.all[name].(type) {
+	case string:
+		return []string{value}
+	case []interface{}:
+		values := make([]string, 0, len(value))
+		for _, v := range value {
+			if s, ok := v.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,50 @@
+package oidc
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+// provider holds the discovered OpenID provider along with the OAuth2 client config and the ID token verifier of Kowl
+type provider struct {
+	oauth2Config oauth2.Config
+	verifier     *oidc.IDTokenVerifier
+
+	// endSessionEndpoint is not part of the OpenID Connect discovery, it is empty if the provider doesn't announce it
+	endSessionEndpoint string
+}
+
+// discoverProvider fetches the OpenID provider configuration of the issuer. The ID tokens are verified against the
+// clock now, so that it can be replaced in tests.
+func discoverProvider(ctx context.Context, httpClient *http.Client, cfg Config, now func() time.Time) (*provider, error) {
+	// The signing keys are fetched later on with the http client of this context, but not with its deadline
+	p, err := oidc.NewProvider(oidc.ClientContext(ctx, httpClient), cfg.IssuerURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover openid provider: %w", err)
+	}
+
+	var metadata struct {
+		EndSessionEndpoint string `json:"end_session_endpoint"`
+	}
+	err = p.Claims(&metadata)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode openid provider configuration: %w", err)
+	}
+
+	return &provider{
+		oauth2Config: oauth2.Config{
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			Endpoint:     p.Endpoint(),
+			RedirectURL:  cfg.RedirectURL,
+			Scopes:       cfg.Scopes,
+		},
+		verifier:           p.Verifier(&oidc.Config{ClientID: cfg.ClientID, Now: now}),
+		endSessionEndpoint: metadata.EndSessionEndpoint,
+	}, nil
+}
@@ -0,0 +1,383 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"go.uber.org/zap"
+	"golang.org/x/oauth2"
+	"golang.org/x/sync/singleflight"
+)
+
+// Names of the cookies which are set by the login flow
+const (
+	sessionCookieName = "kowl_session"
+	loginCookieName   = "kowl_oidc_login"
+)
+
+// loginStateMaxAge is the max duration between starting the login and the callback of the provider
+const loginStateMaxAge = 10 * time.Minute
+
+// refreshedSessionMaxAge is how long a refreshed session is handed out to requests which still carry the previous
+// session cookie, e.g. because they have been sent by the browser before it received the updated cookie
+const refreshedSessionMaxAge = time.Minute
+
+var (
+	// ErrNotAuthenticated is returned if a request has no valid session
+	ErrNotAuthenticated = errors.New("request is not authenticated")
+	// ErrInvalidLoginState is returned if the callback doesn't belong to a login which has been started by Kowl
+	ErrInvalidLoginState = errors.New("login state is invalid or has expired")
+	// ErrEmailNotVerified is returned if the user is identified by an email address which the provider hasn't verified
+	ErrEmailNotVerified = errors.New("email address has not been verified by the provider")
+)
+
+// Service implements the OpenID Connect authorization code flow and manages the sessions of the logged in users
+type Service struct {
+	cfg        Config
+	logger     *zap.Logger
+	httpClient *http.Client
+	codec      *cookieCodec
+
+	// cookiePath is the path of the cookies, so that they are only sent to Kowl if it is served under a base path
+	cookiePath string
+
+	// provider is discovered on first use, so that Kowl can start while the provider is unavailable
+	providerMutex sync.Mutex
+	provider      *provider
+
+	// refreshGroup coalesces concurrent refreshes of a session, so that its refresh token is only redeemed once.
+	// refreshedSessions hands out the result to requests which arrive shortly afterwards with the previous cookie.
+	refreshGroup      singleflight.Group
+	refreshedMutex    sync.Mutex
+	refreshedSessions map[string]refreshedSession
+
+	// now is replaced in tests
+	now func() time.Time
+}
+
+// refreshedSession is the result of refreshing the session which has been using previousRefreshToken
+type refreshedSession struct {
+	previousRefreshToken string
+	session              *Session
+	refreshedAt          time.Time
+}
+
+// NewService creates the OIDC service. The provider is not contacted until the first login. The cookies are set for
+// the given base path, "/" is used if it is empty.
+func NewService(cfg Config, basePath string, logger *zap.Logger) (*Service, error) {
+	if basePath == "" {
+		basePath = "/"
+	}
+	s := &Service{
+		cfg:        cfg,
+		logger:     logger.With(zap.String("source", "oidc")),
+		httpClient: &http.Client{Timeout: cfg.RequestTimeout},
+		cookiePath: basePath,
+
+		refreshedSessions: make(map[string]refreshedSession),
+		now:               time.Now,
+	}
+	if !cfg.Enabled {
+		return s, nil
+	}
+
+	codec, err := newCookieCodec(cfg.SessionSecret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create session cookie codec: %w", err)
+	}
+	s.codec = codec
+
+	return s, nil
+}
+
+// IsEnabled returns true if users must log in via OIDC
+func (s *Service) IsEnabled() bool {
+	return s.cfg.Enabled
+}
+
+func (s *Service) getProvider(ctx context.Context) (*provider, error) {
+	s.providerMutex.Lock()
+	defer s.providerMutex.Unlock()
+
+	if s.provider != nil {
+		return s.provider, nil
+	}
+	p, err := discoverProvider(ctx, s.httpClient, s.cfg, func() time.Time { return s.now() })
+	if err != nil {
+		return nil, err
+	}
+	s.provider = p
+
+	return p, nil
+}
+
+// StartLogin stores the login state in a cookie and returns the authorization URL of the provider, to which the user
+// must be redirected. The user is redirected to returnTo after the login if it is a path of Kowl.
+func (s *Service) StartLogin(w http.ResponseWriter, r *http.Request, returnTo string) (string, error) {
+	p, err := s.getProvider(r.Context())
+	if err != nil {
+		return "", err
+	}
+
+	state, err := randomString()
+	if err != nil {
+		return "", err
+	}
+	nonce, err := randomString()
+	if err != nil {
+		return "", err
+	}
+	login := loginState{State: state, Nonce: nonce, ReturnTo: sanitizeReturnTo(returnTo), CreatedAt: s.now()}
+	err = s.setCookie(w, loginCookieName, login, loginStateMaxAge)
+	if err != nil {
+		return "", err
+	}
+
+	return p.oauth2Config.AuthCodeURL(state, oidc.Nonce(nonce)), nil
+}
+
+// FinishLogin handles the callback of the provider. It exchanges the authorization code for tokens, verifies the ID
+// token and sets the session cookie. It returns the session and the path to which the user shall be redirected.
+func (s *Service) FinishLogin(w http.ResponseWriter, r *http.Request) (*Session, string, error) {
+	var login loginState
+	err := s.readCookie(r, loginCookieName, &login)
+	if err != nil || login.State == "" || login.State != r.URL.Query().Get("state") ||
+		s.now().Sub(login.CreatedAt) > loginStateMaxAge {
+		return nil, "", ErrInvalidLoginState
+	}
+	s.clearCookie(w, loginCookieName)
+
+	if errCode := r.URL.Query().Get("error"); errCode != "" {
+		return nil, "", fmt.Errorf("provider rejected the login: %v %v", errCode, r.URL.Query().Get("error_description"))
+	}
+	code := r.URL.Query().Get("code")
+	if code == "" {
+		return nil, "", fmt.Errorf("callback contains no authorization code")
+	}
+
+	p, err := s.getProvider(r.Context())
+	if err != nil {
+		return nil, "", err
+	}
+	token, err := p.oauth2Config.Exchange(oidc.ClientContext(r.Context(), s.httpClient), code)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to exchange authorization code: %w", err)
+	}
+	session, err := s.newSession(r.Context(), p, token, login.Nonce, nil)
+	if err != nil {
+		return nil, "", err
+	}
+
+	err = s.setCookie(w, sessionCookieName, session, s.cfg.SessionMaxAge)
+	if err != nil {
+		return nil, "", err
+	}
+	return session, login.ReturnTo, nil
+}
+
+// Authenticate returns the session of the request. Sessions whose ID token has expired are extended with the refresh
+// token and the session cookie is updated. Concurrent requests of the same session share a single refresh.
+// ErrNotAuthenticated is returned if the request has no valid session.
+func (s *Service) Authenticate(w http.ResponseWriter, r *http.Request) (*Session, error) {
+	var session Session
+	err := s.readCookie(r, sessionCookieName, &session)
+	if err != nil {
+		return nil, ErrNotAuthenticated
+	}
+
+	now := s.now()
+	if now.Sub(session.CreatedAt) > s.cfg.SessionMaxAge {
+		s.clearCookie(w, sessionCookieName)
+		return nil, ErrNotAuthenticated
+	}
+	if now.Before(session.ExpiresAt) {
+		return &session, nil
+	}
+	if session.RefreshToken == "" {
+		s.clearCookie(w, sessionCookieName)
+		return nil, ErrNotAuthenticated
+	}
+
+	refreshed, err := s.refreshSession(&session)
+	if err != nil {
+		s.logger.Info("failed to refresh session", zap.String("username", session.Username), zap.Error(err))
+		s.clearCookie(w, sessionCookieName)
+		return nil, ErrNotAuthenticated
+	}
+	err = s.setCookie(w, sessionCookieName, refreshed, s.cfg.SessionMaxAge-now.Sub(refreshed.CreatedAt))
+	if err != nil {
+		return nil, err
+	}
+
+	return refreshed, nil
+}
+
+// Logout clears the session cookie and returns the URL of the provider's end session endpoint, to which the user
+// should be redirected to end the session at the provider as well. It is empty if the provider has no such endpoint.
+// Sessions are stateless and can't be revoked, hence a copy of the session cookie stays valid until its ID token has
+// expired. It can be extended afterwards as long as the provider accepts the refresh token.
+func (s *Service) Logout(w http.ResponseWriter, r *http.Request) string {
+	s.clearCookie(w, sessionCookieName)
+
+	p, err := s.getProvider(r.Context())
+	if err != nil || p.endSessionEndpoint == "" {
+		return ""
+	}
+	query := url.Values{}
+	query.Set("client_id", s.cfg.ClientID)
+	return p.endSessionEndpoint + "?" + query.Encode()
+}
+
+// refreshSession redeems the refresh token of the session and returns the refreshed session. Refreshes of the same
+// session are coalesced, they don't use the context of the request, because other requests may wait for the result.
+func (s *Service) refreshSession(session *Session) (*Session, error) {
+	result, err, _ := s.refreshGroup.Do(session.ID, func() (interface{}, error) {
+		s.refreshedMutex.Lock()
+		refreshed, exists := s.refreshedSessions[session.ID]
+		s.refreshedMutex.Unlock()
+		if exists && refreshed.previousRefreshToken == session.RefreshToken &&
+			s.now().Sub(refreshed.refreshedAt) < refreshedSessionMaxAge {
+			return refreshed.session, nil
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), s.cfg.RequestTimeout)
+		defer cancel()
+		p, err := s.getProvider(ctx)
+		if err != nil {
+			return nil, err
+		}
+		tokenSource := p.oauth2Config.TokenSource(oidc.ClientContext(ctx, s.httpClient), &oauth2.Token{RefreshToken: session.RefreshToken})
+		token, err := tokenSource.Token()
+		if err != nil {
+			return nil, fmt.Errorf("failed to redeem refresh token: %w", err)
+		}
+		newSession, err := s.newSession(ctx, p, token, "", session)
+		if err != nil {
+			return nil, err
+		}
+
+		s.rememberRefreshedSession(session.RefreshToken, newSession)
+		return newSession, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result.(*Session), nil
+}
+
+// rememberRefreshedSession stores the refreshed session for refreshedSessionMaxAge and drops all older results
+func (s *Service) rememberRefreshedSession(previousRefreshToken string, session *Session) {
+	s.refreshedMutex.Lock()
+	defer s.refreshedMutex.Unlock()
+
+	now := s.now()
+	for id, refreshed := range s.refreshedSessions {
+		if now.Sub(refreshed.refreshedAt) >= refreshedSessionMaxAge {
+			delete(s.refreshedSessions, id)
+		}
+	}
+	s.refreshedSessions[session.ID] = refreshedSession{
+		previousRefreshToken: previousRefreshToken,
+		session:              session,
+		refreshedAt:          now,
+	}
+}
+
+// newSession creates a session from the verified ID token of the token response. On refresh the previous session is
+// passed, its identity is kept if the provider doesn't return a new ID token.
+func (s *Service) newSession(ctx context.Context, p *provider, token *oauth2.Token, nonce string, previous *Session) (*Session, error) {
+	rawIDToken, _ := token.Extra("id_token").(string)
+	if rawIDToken == "" && previous == nil {
+		return nil, fmt.Errorf("token response contains no id token")
+	}
+
+	var session *Session
+	if previous != nil {
+		copied := *previous
+		session = &copied
+		if token.RefreshToken != "" {
+			session.RefreshToken = token.RefreshToken
+		}
+	} else {
+		id, err := randomString()
+		if err != nil {
+			return nil, err
+		}
+		session = &Session{ID: id, RefreshToken: token.RefreshToken, CreatedAt: s.now()}
+	}
+	if rawIDToken == "" {
+		// Some providers don't issue a new ID token on refresh, the session lives as long as the access token then
+		session.ExpiresAt = token.Expiry
+		return session, nil
+	}
+
+	claims, err := p.verifyIDToken(ctx, rawIDToken, nonce)
+	if err != nil {
+		return nil, err
+	}
+	if s.cfg.UsernameClaim == "email" && claims.isEmailUnverified() {
+		return nil, ErrEmailNotVerified
+	}
+	session.Username = claims.stringClaim(s.cfg.UsernameClaim)
+	if session.Username == "" {
+		session.Username = claims.Subject
+	}
+	session.Groups = claims.stringsClaim(s.cfg.GroupsClaim)
+	session.ExpiresAt = claims.ExpiresAt
+
+	return session, nil
+}
+
+func (s *Service) setCookie(w http.ResponseWriter, name string, value interface{}, maxAge time.Duration) error {
+	encoded, err := s.codec.encode(name, value)
+	if err != nil {
+		return fmt.Errorf("failed to encode cookie: %w", err)
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    encoded,
+		Path:     s.cookiePath,
+		MaxAge:   int(maxAge.Seconds()),
+		Secure:   s.cfg.CookieSecure,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	return nil
+}
+
+func (s *Service) readCookie(r *http.Request, name string, target interface{}) error {
+	cookie, err := r.Cookie(name)
+	if err != nil {
+		return err
+	}
+	return s.codec.decode(name, cookie.Value, target)
+}
+
+func (s *Service) clearCookie(w http.ResponseWriter, name string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    "",
+		Path:     s.cookiePath,
+		MaxAge:   -1,
+		Secure:   s.cfg.CookieSecure,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// sanitizeReturnTo only accepts local paths, so that the login can't be abused as an open redirect
+func sanitizeReturnTo(returnTo string) string {
+	if !strings.HasPrefix(returnTo, "/") || strings.HasPrefix(returnTo, "//") || strings.HasPrefix(returnTo, "/\\") {
+		return "/"
+	}
+	return returnTo
+}
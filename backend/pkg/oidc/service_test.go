@@ -0,0 +1,305 @@
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gopkg.in/square/go-jose.v2"
+)
+
+// testProvider is a minimal OpenID provider which issues RS256 signed ID tokens
+type testProvider struct {
+	t      *testing.T
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	// claims are added to each issued ID token, nonce is the nonce of the last authorization request
+	mutex        sync.Mutex
+	claims       map[string]interface{}
+	nonce        string
+	refreshCount int
+
+	// clockOffset shifts the expiry of the issued tokens along with the clock of the tested service
+	clockOffset time.Duration
+}
+
+func newTestProvider(t *testing.T) *testProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	p := &testProvider{t: t, key: key, claims: map[string]interface{}{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 p.server.URL,
+			"authorization_endpoint": p.server.URL + "/authorize",
+			"token_endpoint":         p.server.URL + "/token",
+			"jwks_uri":               p.server.URL + "/keys",
+			"end_session_endpoint":   p.server.URL + "/logout",
+		})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+			{Key: &key.PublicKey, KeyID: "key-1", Algorithm: string(jose.RS256), Use: "sig"},
+		}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		clientID, clientSecret, _ := r.BasicAuth()
+		assert.Equal(t, "kowl", clientID)
+		assert.Equal(t, "client-secret", clientSecret)
+
+		p.mutex.Lock()
+		defer p.mutex.Unlock()
+		nonce := ""
+		switch r.FormValue("grant_type") {
+		case "authorization_code":
+			assert.Equal(t, "auth-code", r.FormValue("code"))
+			nonce = p.nonce
+		case "refresh_token":
+			assert.Equal(t, "refresh-token", r.FormValue("refresh_token"))
+			p.refreshCount++
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token":  "access-token",
+			"token_type":    "Bearer",
+			"refresh_token": "refresh-token",
+			"id_token":      p.idToken(nonce, time.Now().Add(p.clockOffset+time.Hour)),
+			"expires_in":    3600,
+		})
+	})
+	p.server = httptest.NewServer(mux)
+
+	return p
+}
+
+func (p *testProvider) idToken(nonce string, expiresAt time.Time) string {
+	claims := map[string]interface{}{
+		"iss": p.server.URL,
+		"sub": "user-123",
+		"aud": []string{"kowl"},
+		"exp": expiresAt.Unix(),
+	}
+	if nonce != "" {
+		claims["nonce"] = nonce
+	}
+	for name, value := range p.claims {
+		claims[name] = value
+	}
+
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: p.key},
+		(&jose.SignerOptions{}).WithHeader("kid", "key-1"))
+	require.NoError(p.t, err)
+	payload, _ := json.Marshal(claims)
+	signed, err := signer.Sign(payload)
+	require.NoError(p.t, err)
+	token, err := signed.CompactSerialize()
+	require.NoError(p.t, err)
+
+	return token
+}
+
+func newTestService(t *testing.T, issuerURL string) *Service {
+	cfg := Config{}
+	cfg.SetDefaults()
+	cfg.Enabled = true
+	cfg.IssuerURL = issuerURL
+	cfg.ClientID = "kowl"
+	cfg.ClientSecret = "client-secret"
+	cfg.RedirectURL = "https://kowl.example.com/auth/callback"
+	cfg.SessionSecret = strings.Repeat("s", 32)
+	require.NoError(t, cfg.Validate())
+
+	svc, err := NewService(cfg, "", zap.NewNop())
+	require.NoError(t, err)
+	return svc
+}
+
+// withCookies returns a request which carries all cookies set on the recorder
+func withCookies(r *http.Request, w *httptest.ResponseRecorder) *http.Request {
+	for _, cookie := range w.Result().Cookies() {
+		if cookie.MaxAge >= 0 {
+			r.AddCookie(cookie)
+		}
+	}
+	return r
+}
+
+func TestService_LoginFlow(t *testing.T) {
+	p := newTestProvider(t)
+	defer p.server.Close()
+	p.claims["email"] = "alice@example.com"
+	p.claims["groups"] = []string{"platform", "support"}
+	svc := newTestService(t, p.server.URL)
+
+	// Start the login
+	loginRecorder := httptest.NewRecorder()
+	authURL, err := svc.StartLogin(loginRecorder, httptest.NewRequest(http.MethodGet, "/auth/login", nil), "/topics")
+	require.NoError(t, err)
+	parsed, err := url.Parse(authURL)
+	require.NoError(t, err)
+	assert.Equal(t, p.server.URL+"/authorize", parsed.Scheme+"://"+parsed.Host+parsed.Path)
+	assert.Equal(t, "openid profile email", parsed.Query().Get("scope"))
+	p.nonce = parsed.Query().Get("nonce")
+	state := parsed.Query().Get("state")
+
+	// Callbacks with a wrong state are rejected
+	callback := httptest.NewRequest(http.MethodGet, "/auth/callback?code=auth-code&state=forged", nil)
+	_, _, err = svc.FinishLogin(httptest.NewRecorder(), withCookies(callback, loginRecorder))
+	assert.ErrorIs(t, err, ErrInvalidLoginState)
+
+	// Finish the login
+	callbackRecorder := httptest.NewRecorder()
+	callback = httptest.NewRequest(http.MethodGet, "/auth/callback?code=auth-code&state="+state, nil)
+	session, returnTo, err := svc.FinishLogin(callbackRecorder, withCookies(callback, loginRecorder))
+	require.NoError(t, err)
+	assert.Equal(t, "/topics", returnTo)
+	assert.Equal(t, "alice@example.com", session.Username)
+	assert.Equal(t, []string{"platform", "support"}, session.Groups)
+
+	// Authenticate requests with the session cookie
+	request := withCookies(httptest.NewRequest(http.MethodGet, "/api/topics", nil), callbackRecorder)
+	session, err = svc.Authenticate(httptest.NewRecorder(), request)
+	require.NoError(t, err)
+	assert.Equal(t, "alice@example.com", session.Username)
+	assert.Equal(t, 0, p.refreshCount)
+
+	// Expired ID tokens are refreshed and the updated claims are applied
+	p.mutex.Lock()
+	p.claims["groups"] = "platform"
+	p.clockOffset = 2 * time.Hour
+	p.mutex.Unlock()
+	svc.now = func() time.Time { return time.Now().Add(p.clockOffset) }
+	refreshRecorder := httptest.NewRecorder()
+	session, err = svc.Authenticate(refreshRecorder, request)
+	require.NoError(t, err)
+	assert.Equal(t, 1, p.refreshCount)
+	assert.Equal(t, []string{"platform"}, session.Groups)
+	assert.NotEmpty(t, refreshRecorder.Result().Cookies())
+
+	// Sessions end after the max age
+	svc.now = func() time.Time { return time.Now().Add(25 * time.Hour) }
+	_, err = svc.Authenticate(httptest.NewRecorder(), request)
+	assert.ErrorIs(t, err, ErrNotAuthenticated)
+
+	// Requests without a session
+	_, err = svc.Authenticate(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/topics", nil))
+	assert.ErrorIs(t, err, ErrNotAuthenticated)
+
+	assert.Equal(t, p.server.URL+"/logout?client_id=kowl", svc.Logout(httptest.NewRecorder(), request))
+}
+
+func TestService_EmailNotVerified(t *testing.T) {
+	p := newTestProvider(t)
+	defer p.server.Close()
+	p.claims["email"] = "alice@example.com"
+	p.claims["email_verified"] = false
+	svc := newTestService(t, p.server.URL)
+	svc.cookiePath = "/kowl/"
+
+	loginRecorder := httptest.NewRecorder()
+	authURL, err := svc.StartLogin(loginRecorder, httptest.NewRequest(http.MethodGet, "/auth/login", nil), "/")
+	require.NoError(t, err)
+	for _, cookie := range loginRecorder.Result().Cookies() {
+		assert.Equal(t, "/kowl/", cookie.Path)
+	}
+	parsed, err := url.Parse(authURL)
+	require.NoError(t, err)
+	p.nonce = parsed.Query().Get("nonce")
+
+	// The unverified email must not be used as username, as it may impersonate users who are bound to roles
+	callback := httptest.NewRequest(http.MethodGet, "/auth/callback?code=auth-code&state="+parsed.Query().Get("state"), nil)
+	_, _, err = svc.FinishLogin(httptest.NewRecorder(), withCookies(callback, loginRecorder))
+	assert.ErrorIs(t, err, ErrEmailNotVerified)
+}
+
+func TestService_ConcurrentRefresh(t *testing.T) {
+	p := newTestProvider(t)
+	defer p.server.Close()
+	svc := newTestService(t, p.server.URL)
+
+	loginRecorder := httptest.NewRecorder()
+	authURL, err := svc.StartLogin(loginRecorder, httptest.NewRequest(http.MethodGet, "/auth/login", nil), "/")
+	require.NoError(t, err)
+	parsed, err := url.Parse(authURL)
+	require.NoError(t, err)
+	p.nonce = parsed.Query().Get("nonce")
+	callbackRecorder := httptest.NewRecorder()
+	callback := httptest.NewRequest(http.MethodGet, "/auth/callback?code=auth-code&state="+parsed.Query().Get("state"), nil)
+	_, _, err = svc.FinishLogin(callbackRecorder, withCookies(callback, loginRecorder))
+	require.NoError(t, err)
+
+	// All requests which carry the expired session share a single refresh, including those which arrive afterwards
+	// with the previous cookie
+	p.mutex.Lock()
+	p.clockOffset = 2 * time.Hour
+	p.mutex.Unlock()
+	svc.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		request := withCookies(httptest.NewRequest(http.MethodGet, "/api/topics", nil), callbackRecorder)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := svc.Authenticate(httptest.NewRecorder(), request)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+	request := withCookies(httptest.NewRequest(http.MethodGet, "/api/topics", nil), callbackRecorder)
+	_, err = svc.Authenticate(httptest.NewRecorder(), request)
+	require.NoError(t, err)
+	assert.Equal(t, 1, p.refreshCount)
+}
+
+func TestProvider_VerifyIDToken(t *testing.T) {
+	p := newTestProvider(t)
+	defer p.server.Close()
+	svc := newTestService(t, p.server.URL)
+	ctx := httptest.NewRequest(http.MethodGet, "/", nil).Context()
+	provider, err := svc.getProvider(ctx)
+	require.NoError(t, err)
+	now := time.Now()
+
+	claims, err := provider.verifyIDToken(ctx, p.idToken("nonce", now.Add(time.Hour)), "nonce")
+	require.NoError(t, err)
+	assert.Equal(t, "user-123", claims.Subject)
+
+	_, err = provider.verifyIDToken(ctx, p.idToken("nonce", now.Add(time.Hour)), "other-nonce")
+	assert.Error(t, err, "nonce must match")
+	_, err = provider.verifyIDToken(ctx, p.idToken("nonce", now.Add(-time.Hour)), "nonce")
+	assert.Error(t, err, "token must not be expired")
+
+	p.claims["aud"] = "other-client"
+	_, err = provider.verifyIDToken(ctx, p.idToken("nonce", now.Add(time.Hour)), "nonce")
+	assert.Error(t, err, "audience must match")
+	delete(p.claims, "aud")
+
+	token := p.idToken("nonce", now.Add(time.Hour))
+	parts := strings.Split(token, ".")
+	forged, _ := json.Marshal(map[string]interface{}{"iss": p.server.URL, "sub": "admin", "aud": "kowl", "exp": now.Add(time.Hour).Unix()})
+	_, err = provider.verifyIDToken(ctx, parts[0]+"."+base64.RawURLEncoding.EncodeToString(forged)+"."+parts[2], "")
+	assert.Error(t, err, "signature must match the claims")
+}
+
+func TestSanitizeReturnTo(t *testing.T) {
+	assert.Equal(t, "/topics/orders", sanitizeReturnTo("/topics/orders"))
+	assert.Equal(t, "/", sanitizeReturnTo("https://evil.example.com"))
+	assert.Equal(t, "/", sanitizeReturnTo("//evil.example.com"))
+	assert.Equal(t, "/", sanitizeReturnTo(""))
+}
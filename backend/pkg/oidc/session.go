@@ -0,0 +1,100 @@
+package oidc
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Session is the authenticated session of a user, which is stored encrypted in the session cookie
+type Session struct {
+	// ID identifies the session across refreshes, so that concurrent requests of a session share a single refresh
+	ID       string   `json:"id"`
+	Username string   `json:"username"`
+	Groups   []string `json:"groups"`
+
+	// RefreshToken is used to extend the session once the ID token has expired, it is empty if the provider doesn't
+	// issue refresh tokens
+	RefreshToken string `json:"refreshToken,omitempty"`
+
+	// ExpiresAt is the expiry of the ID token, CreatedAt the time of the login
+	ExpiresAt time.Time `json:"expiresAt"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// loginState is stored in a short lived cookie between redirecting the user to the provider and the callback
+type loginState struct {
+	State     string    `json:"state"`
+	Nonce     string    `json:"nonce"`
+	ReturnTo  string    `json:"returnTo"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// cookieCodec encrypts and authenticates cookie values with AES-GCM, the key is derived from the session secret
+type cookieCodec struct {
+	aead cipher.AEAD
+}
+
+func newCookieCodec(secret string) (*cookieCodec, error) {
+	key := sha256.Sum256([]byte(secret))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &cookieCodec{aead: aead}, nil
+}
+
+// encode encrypts the JSON representation of the value. The cookie name is authenticated as well, so that values can
+// not be swapped between cookies.
+func (c *cookieCodec) encode(name string, value interface{}) (string, error) {
+	plaintext, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, c.aead.NonceSize())
+	_, err = io.ReadFull(rand.Reader, nonce)
+	if err != nil {
+		return "", err
+	}
+
+	sealed := c.aead.Seal(nonce, nonce, plaintext, []byte(name))
+	return base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+func (c *cookieCodec) decode(name string, encoded string, target interface{}) error {
+	sealed, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return err
+	}
+	nonceSize := c.aead.NonceSize()
+	if len(sealed) < nonceSize {
+		return fmt.Errorf("cookie value is too short")
+	}
+
+	plaintext, err := c.aead.Open(nil, sealed[:nonceSize], sealed[nonceSize:], []byte(name))
+	if err != nil {
+		return fmt.Errorf("failed to decrypt cookie: %w", err)
+	}
+	return json.Unmarshal(plaintext, target)
+}
+
+// randomString returns a random url-safe string, e.g. for the state and nonce parameters
+func randomString() (string, error) {
+	b := make([]byte, 32)
+	_, err := io.ReadFull(rand.Reader, b)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,56 @@
+package owl
+
+import (
+	"time"
+
+	"github.com/cloudhut/kowl/backend/pkg/kafka"
+)
+
+// APIVersions describes the API versions supported by the broker and the Kafka protocol version the client uses
+type APIVersions struct {
+	// ClientKafkaVersion is the Kafka protocol version sarama has been configured with. Sarama chooses the version for
+	// each request based on this version.
+	ClientKafkaVersion string `json:"clientKafkaVersion"`
+	BrokerID           int32  `json:"brokerId"`
+	FetchedAt          int64  `json:"fetchedAt"` // Unix timestamp in ms
+
+	APIs map[int16]APIVersion `json:"apis"`
+}
+
+// APIVersion is the supported version range of a single API key. NegotiatedVersion is the version Kowl sends for
+// this API, it is nil if Kowl doesn't use the API or the broker doesn't support the version Kowl sends.
+type APIVersion struct {
+	Name              string `json:"name"`
+	MinVersion        int16  `json:"minVersion"`
+	MaxVersion        int16  `json:"maxVersion"`
+	NegotiatedVersion *int16 `json:"negotiatedVersion"`
+}
+
+// GetAPIVersions returns the API versions which are supported by the brokers
+func (s *Service) GetAPIVersions(forceRefresh bool) (*APIVersions, error) {
+	res, err := s.kafkaSvc.DescribeAPIVersions(forceRefresh)
+	if err != nil {
+		return nil, err
+	}
+
+	clientVersion := s.kafkaSvc.Client.Config().Version
+	apis := make(map[int16]APIVersion, len(res.APIVersions))
+	for _, block := range res.APIVersions {
+		api := APIVersion{
+			Name:       kafka.APIKeyName(block.ApiKey),
+			MinVersion: block.MinVersion,
+			MaxVersion: block.MaxVersion,
+		}
+		if version, ok := kafka.NegotiatedAPIVersion(block.ApiKey, clientVersion, block); ok {
+			api.NegotiatedVersion = &version
+		}
+		apis[block.ApiKey] = api
+	}
+
+	return &APIVersions{
+		ClientKafkaVersion: clientVersion.String(),
+		BrokerID:           res.BrokerID,
+		FetchedAt:          res.FetchedAt.UnixNano() / int64(time.Millisecond),
+		APIs:               apis,
+	}, nil
+}
@@ -25,15 +25,18 @@ type BrokerConfig struct {
 }
 
 type BrokerConfigEntry struct {
-	Name      string `json:"name"`
-	Value     string `json:"value"`
-	IsDefault bool   `json:"isDefault"`
+	Name        string `json:"name"`
+	Value       string `json:"value"` // Sensitive values are redacted
+	IsDefault   bool   `json:"isDefault"`
+	Source      string `json:"source"`
+	IsReadOnly  bool   `json:"isReadOnly"`
+	IsSensitive bool   `json:"isSensitive"`
 }
 
 // GetClusterConfig tries to fetch all config resources for all brokers in the cluster. If at least one response from a
 // broker can be returned this function won't return an error. If all requests fail an error will be returned.
 func (s *Service) GetClusterConfig(ctx context.Context) (ClusterConfig, error) {
-	metadata, err := s.kafkaSvc.DescribeCluster()
+	metadata, err := s.kafkaSvc.DescribeCluster(ctx)
 	if err != nil {
 		return ClusterConfig{}, fmt.Errorf("failed to get broker ids: %w", err)
 	}
@@ -97,7 +100,7 @@ func (s *Service) GetBrokerConfig(ctx context.Context, brokerID int32) (*BrokerC
 	var configEntries []sarama.ConfigEntry
 	eg.Go(func() error {
 		var err error
-		configEntries, err = s.kafkaSvc.DescribeBrokerConfig(brokerID, []string{})
+		configEntries, err = s.kafkaSvc.DescribeBrokerConfig(ctx, brokerID, []string{})
 		if err != nil {
 			return err
 		}
@@ -115,9 +118,12 @@ func (s *Service) GetBrokerConfig(ctx context.Context, brokerID int32) (*BrokerC
 	formattedEntries := make([]*BrokerConfigEntry, len(configEntries))
 	for i, config := range configEntries {
 		formattedEntries[i] = &BrokerConfigEntry{
-			Name:      config.Name,
-			Value:     config.Value,
-			IsDefault: config.Default,
+			Name:        config.Name,
+			Value:       configEntryValue(&config),
+			IsDefault:   config.Default,
+			Source:      configSourceName(config.Source),
+			IsReadOnly:  config.ReadOnly,
+			IsSensitive: config.Sensitive,
 		}
 	}
 
@@ -0,0 +1,98 @@
+package owl
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Shopify/sarama"
+	"github.com/cloudhut/kowl/backend/pkg/kafka"
+	"go.uber.org/zap"
+)
+
+// ClientQuota describes the quotas which are set for a user, client id, the combination of both or an ip address.
+// Quotas which are not set are nil.
+type ClientQuota struct {
+	Entity                 []QuotaEntity `json:"entity"`
+	ProducerByteRate       *float64      `json:"producerByteRate"`
+	ConsumerByteRate       *float64      `json:"consumerByteRate"`
+	RequestPercentage      *float64      `json:"requestPercentage"`
+	ConnectionCreationRate *float64      `json:"connectionCreationRate"`
+}
+
+// QuotaEntity is a single component of a quota entity. The default entity of a type has no name.
+type QuotaEntity struct {
+	EntityType string `json:"entityType"`
+	Name       string `json:"name"`
+	IsDefault  bool   `json:"isDefault"`
+}
+
+// DescribeClientQuotas returns all quotas of the given entity type, optionally filtered by an entity name
+func (s *Service) DescribeClientQuotas(ctx context.Context, entityType string, name string) ([]ClientQuota, error) {
+	quotas, err := s.kafkaSvc.DescribeClientQuotas(ctx, sarama.QuotaEntityType(entityType), name)
+	if err != nil {
+		return nil, err
+	}
+
+	return convertClientQuotas(quotas), nil
+}
+
+// AlterClientQuotas sets or removes (nil values) the given quotas of an entity
+func (s *Service) AlterClientQuotas(ctx context.Context, entity []QuotaEntity, values map[string]*float64) error {
+	converted := make([]kafka.QuotaEntity, len(entity))
+	for i, e := range entity {
+		converted[i] = kafka.QuotaEntity{EntityType: sarama.QuotaEntityType(e.EntityType), Name: e.Name, Default: e.IsDefault}
+	}
+
+	err := s.kafkaSvc.AlterClientQuotas(ctx, converted, values)
+	if err != nil {
+		return err
+	}
+	s.logger.Info("altered client quotas", zap.String("entity", quotaEntityString(entity)), zap.Int("quotas", len(values)))
+
+	return nil
+}
+
+// convertClientQuotas converts the described quotas and sorts them by their entity, so that the results do not flap
+// between requests
+func convertClientQuotas(quotas []kafka.ClientQuota) []ClientQuota {
+	res := make([]ClientQuota, len(quotas))
+	for i, quota := range quotas {
+		entity := make([]QuotaEntity, len(quota.Entity))
+		for j, e := range quota.Entity {
+			entity[j] = QuotaEntity{EntityType: string(e.EntityType), Name: e.Name, IsDefault: e.Default}
+		}
+		res[i] = ClientQuota{
+			Entity:                 entity,
+			ProducerByteRate:       quotaValue(quota.Values, kafka.QuotaProducerByteRate),
+			ConsumerByteRate:       quotaValue(quota.Values, kafka.QuotaConsumerByteRate),
+			RequestPercentage:      quotaValue(quota.Values, kafka.QuotaRequestPercentage),
+			ConnectionCreationRate: quotaValue(quota.Values, kafka.QuotaConnectionCreationRate),
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return quotaEntityString(res[i].Entity) < quotaEntityString(res[j].Entity) })
+
+	return res
+}
+
+func quotaValue(values map[string]float64, key string) *float64 {
+	value, exists := values[key]
+	if !exists {
+		return nil
+	}
+	return &value
+}
+
+// quotaEntityString returns a readable representation of an entity, e. g. "user=alice,client-id=<default>"
+func quotaEntityString(entity []QuotaEntity) string {
+	components := make([]string, len(entity))
+	for i, e := range entity {
+		name := e.Name
+		if e.IsDefault {
+			name = "<default>"
+		}
+		components[i] = fmt.Sprintf("%v=%v", e.EntityType, name)
+	}
+	return strings.Join(components, ",")
+}
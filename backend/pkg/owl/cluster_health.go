@@ -0,0 +1,151 @@
+package owl
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/Shopify/sarama"
+)
+
+// ClusterHealth lists all partitions which are offline (no leader), under-replicated (less in sync replicas than
+// replicas) or led by a broker other than their preferred leader (the first replica). A cluster is healthy if the
+// metadata of all topics could be fetched and no partition is offline or under-replicated, a leader imbalance only
+// skews the load across the brokers.
+type ClusterHealth struct {
+	IsHealthy bool `json:"isHealthy"`
+
+	TopicCount     int `json:"topicCount"`
+	PartitionCount int `json:"partitionCount"`
+
+	OfflineCount         int `json:"offlineCount"`
+	UnderReplicatedCount int `json:"underReplicatedCount"`
+	LeaderImbalanceCount int `json:"leaderImbalanceCount"`
+
+	OfflinePartitions         []PartitionHealth `json:"offlinePartitions"`
+	UnderReplicatedPartitions []PartitionHealth `json:"underReplicatedPartitions"`
+	LeaderImbalancePartitions []PartitionHealth `json:"leaderImbalancePartitions"`
+
+	// TopicErrors contains the topics whose metadata could not be fetched, their partitions are not checked
+	TopicErrors []TopicHealthError `json:"topicErrors"`
+
+	// FetchedAt is the time at which the topic metadata has been fetched, IsStale is true if it is served from an
+	// expired cache entry while it is being refreshed
+	FetchedAt time.Time `json:"fetchedAt"`
+	IsStale   bool      `json:"isStale"`
+}
+
+// PartitionHealth is the replica assignment of a partition. Leader is -1 if the partition has no leader.
+type PartitionHealth struct {
+	TopicName       string  `json:"topicName"`
+	PartitionID     int32   `json:"partitionId"`
+	Leader          int32   `json:"leader"`
+	PreferredLeader int32   `json:"preferredLeader"`
+	Replicas        []int32 `json:"replicas"`
+	InSyncReplicas  []int32 `json:"inSyncReplicas"`
+	OfflineReplicas []int32 `json:"offlineReplicas"`
+}
+
+// TopicHealthError is a topic whose metadata contains an error
+type TopicHealthError struct {
+	TopicName string `json:"topicName"`
+	Error     string `json:"error"`
+}
+
+// GetClusterHealth checks the replication state of the partitions of all topics for which isTopicVisible returns
+// true, the counts only include these topics as well. It uses the cached topic metadata, so that the health can be
+// polled without fetching the metadata of all topics on every request. Errors of isTopicVisible are returned as is.
+func (s *Service) GetClusterHealth(ctx context.Context, isTopicVisible func(topicName string) (bool, error)) (*ClusterHealth, error) {
+	metadata, err := s.kafkaSvc.ListTopicsCached(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	topics := make([]*sarama.TopicMetadata, 0, len(metadata.Topics))
+	for _, topic := range metadata.Topics {
+		isVisible, err := isTopicVisible(topic.Name)
+		if err != nil {
+			return nil, err
+		}
+		if isVisible {
+			topics = append(topics, topic)
+		}
+	}
+
+	health := checkClusterHealth(topics)
+	health.FetchedAt = metadata.FetchedAt
+	health.IsStale = metadata.IsStale
+
+	return health, nil
+}
+
+// checkClusterHealth flags the offline, under-replicated and leader imbalanced partitions of the given topics. The
+// results are sorted by topic name and partition id.
+func checkClusterHealth(topics []*sarama.TopicMetadata) *ClusterHealth {
+	health := &ClusterHealth{
+		TopicCount:                len(topics),
+		OfflinePartitions:         make([]PartitionHealth, 0),
+		UnderReplicatedPartitions: make([]PartitionHealth, 0),
+		LeaderImbalancePartitions: make([]PartitionHealth, 0),
+		TopicErrors:               make([]TopicHealthError, 0),
+	}
+
+	for _, topic := range topics {
+		if topic.Err != sarama.ErrNoError {
+			health.TopicErrors = append(health.TopicErrors, TopicHealthError{TopicName: topic.Name, Error: topic.Err.Error()})
+			continue
+		}
+
+		for _, partition := range topic.Partitions {
+			health.PartitionCount++
+			p := PartitionHealth{
+				TopicName:       topic.Name,
+				PartitionID:     partition.ID,
+				Leader:          partition.Leader,
+				PreferredLeader: -1,
+				Replicas:        partition.Replicas,
+				InSyncReplicas:  partition.Isr,
+				OfflineReplicas: partition.OfflineReplicas,
+			}
+			if len(partition.Replicas) > 0 {
+				p.PreferredLeader = partition.Replicas[0]
+			}
+
+			// Brokers return the leader not available error for offline partitions along with leader -1
+			isOffline := partition.Leader < 0 || partition.Err == sarama.ErrLeaderNotAvailable
+			if isOffline {
+				health.OfflinePartitions = append(health.OfflinePartitions, p)
+			}
+			if len(partition.Isr) < len(partition.Replicas) {
+				health.UnderReplicatedPartitions = append(health.UnderReplicatedPartitions, p)
+			}
+			if !isOffline && p.PreferredLeader >= 0 && partition.Leader != p.PreferredLeader {
+				health.LeaderImbalancePartitions = append(health.LeaderImbalancePartitions, p)
+			}
+		}
+	}
+
+	for _, partitions := range [][]PartitionHealth{health.OfflinePartitions, health.UnderReplicatedPartitions,
+		health.LeaderImbalancePartitions} {
+		sortPartitionHealth(partitions)
+	}
+	sort.Slice(health.TopicErrors, func(i, j int) bool {
+		return health.TopicErrors[i].TopicName < health.TopicErrors[j].TopicName
+	})
+
+	health.OfflineCount = len(health.OfflinePartitions)
+	health.UnderReplicatedCount = len(health.UnderReplicatedPartitions)
+	health.LeaderImbalanceCount = len(health.LeaderImbalancePartitions)
+	health.IsHealthy = health.OfflineCount == 0 && health.UnderReplicatedCount == 0 && len(health.TopicErrors) == 0
+
+	return health
+}
+
+func sortPartitionHealth(partitions []PartitionHealth) {
+	sort.Slice(partitions, func(i, j int) bool {
+		if partitions[i].TopicName == partitions[j].TopicName {
+			return partitions[i].PartitionID < partitions[j].PartitionID
+		}
+		return partitions[i].TopicName < partitions[j].TopicName
+	})
+}
@@ -0,0 +1,53 @@
+package owl
+
+import (
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckClusterHealth(t *testing.T) {
+	partition := func(id int32, leader int32, replicas []int32, isr []int32) *sarama.PartitionMetadata {
+		return &sarama.PartitionMetadata{ID: id, Leader: leader, Replicas: replicas, Isr: isr}
+	}
+	topics := []*sarama.TopicMetadata{
+		{Name: "payments", Partitions: []*sarama.PartitionMetadata{
+			partition(1, -1, []int32{1, 2}, []int32{}),
+			partition(0, 2, []int32{1, 2, 3}, []int32{2, 3}),
+		}},
+		{Name: "orders", Partitions: []*sarama.PartitionMetadata{
+			partition(0, 1, []int32{1, 2, 3}, []int32{1, 2, 3}),
+			partition(1, 3, []int32{2, 3, 1}, []int32{2, 3, 1}),
+		}},
+		{Name: "deleted", Err: sarama.ErrUnknownTopicOrPartition},
+	}
+
+	health := checkClusterHealth(topics)
+	assert.False(t, health.IsHealthy)
+	assert.Equal(t, 3, health.TopicCount)
+	assert.Equal(t, 4, health.PartitionCount)
+
+	require.Equal(t, 1, health.OfflineCount)
+	assert.Equal(t, "payments", health.OfflinePartitions[0].TopicName)
+	assert.Equal(t, int32(1), health.OfflinePartitions[0].PartitionID)
+
+	require.Equal(t, 2, health.UnderReplicatedCount)
+	assert.Equal(t, int32(0), health.UnderReplicatedPartitions[0].PartitionID)
+	assert.Equal(t, int32(1), health.UnderReplicatedPartitions[1].PartitionID)
+
+	// Offline partitions are not reported as leader imbalanced
+	require.Equal(t, 2, health.LeaderImbalanceCount)
+	assert.Equal(t, "orders", health.LeaderImbalancePartitions[0].TopicName)
+	assert.Equal(t, int32(2), health.LeaderImbalancePartitions[0].PreferredLeader)
+	assert.Equal(t, "payments", health.LeaderImbalancePartitions[1].TopicName)
+
+	require.Len(t, health.TopicErrors, 1)
+	assert.Equal(t, "deleted", health.TopicErrors[0].TopicName)
+
+	// Leader imbalances alone do not make the cluster unhealthy
+	health = checkClusterHealth(topics[1:2])
+	assert.True(t, health.IsHealthy)
+	assert.Equal(t, 1, health.LeaderImbalanceCount)
+}
@@ -31,7 +31,7 @@ func (s *Service) GetClusterInfo(ctx context.Context) (*ClusterInfo, error) {
 
 	eg.Go(func() error {
 		var err error
-		sizeByBroker, err = s.logDirSizeByBroker()
+		sizeByBroker, err = s.logDirSizeByBroker(ctx)
 		if err != nil {
 			return err
 		}
@@ -40,7 +40,7 @@ func (s *Service) GetClusterInfo(ctx context.Context) (*ClusterInfo, error) {
 
 	eg.Go(func() error {
 		var err error
-		metadata, err = s.kafkaSvc.DescribeCluster()
+		metadata, err = s.kafkaSvc.DescribeCluster(ctx)
 		if err != nil {
 			return err
 		}
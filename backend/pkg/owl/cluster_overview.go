@@ -0,0 +1,135 @@
+package owl
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/Shopify/sarama"
+	"go.uber.org/zap"
+)
+
+// Names of the sub-stats of the cluster overview, which are reported if they could not be collected
+const (
+	clusterOverviewStatCluster        = "cluster"
+	clusterOverviewStatTopics         = "topics"
+	clusterOverviewStatConsumerGroups = "consumerGroups"
+)
+
+// ClusterOverview is an at-a-glance summary of the cluster. Each sub-stat is collected independently, so that a failing
+// request only affects its own stats: those are set to -1 (or empty), IsPartial is set and the failure is listed in
+// Errors. The topic and partition counts only include the topics which are allowed by the caller.
+type ClusterOverview struct {
+	ClusterID    string `json:"clusterId"`
+	ControllerID int32  `json:"controllerId"`
+	BrokerCount  int    `json:"brokerCount"`
+
+	TopicCount                    int `json:"topicCount"`
+	PartitionCount                int `json:"partitionCount"`
+	OfflinePartitionCount         int `json:"offlinePartitionCount"`
+	UnderReplicatedPartitionCount int `json:"underReplicatedPartitionCount"`
+
+	ConsumerGroupCount int `json:"consumerGroupCount"`
+
+	IsPartial bool                   `json:"partial"`
+	Errors    []ClusterOverviewError `json:"errors"`
+}
+
+// ClusterOverviewError is a sub-stat of the cluster overview which could not be collected (completely)
+type ClusterOverviewError struct {
+	Stat  string `json:"stat"`
+	Error string `json:"error"`
+}
+
+// GetClusterOverview collects the summary of the cluster from the cluster metadata, the cached topic metadata and the
+// list of consumer groups. Only the topics for which isTopicAllowed returns true are counted. An error is only returned
+// if none of the sub-stats could be collected.
+func (s *Service) GetClusterOverview(ctx context.Context, isTopicAllowed func(topicName string) bool) (*ClusterOverview, error) {
+	overview := &ClusterOverview{
+		ControllerID:                  -1,
+		BrokerCount:                   -1,
+		TopicCount:                    -1,
+		PartitionCount:                -1,
+		OfflinePartitionCount:         -1,
+		UnderReplicatedPartitionCount: -1,
+		ConsumerGroupCount:            -1,
+		Errors:                        make([]ClusterOverviewError, 0),
+	}
+	collectedStats := 0
+	mutex := sync.Mutex{}
+	addError := func(stat string, err error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		s.logger.Warn("failed to collect stat of the cluster overview", zap.String("stat", stat), zap.Error(err))
+		overview.Errors = append(overview.Errors, ClusterOverviewError{Stat: stat, Error: err.Error()})
+	}
+
+	wg := sync.WaitGroup{}
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		metadata, err := s.kafkaSvc.DescribeCluster(ctx)
+		if err != nil {
+			addError(clusterOverviewStatCluster, err)
+			return
+		}
+		mutex.Lock()
+		defer mutex.Unlock()
+		if metadata.ClusterID != nil {
+			overview.ClusterID = *metadata.ClusterID
+		}
+		overview.ControllerID = metadata.ControllerID
+		overview.BrokerCount = len(metadata.Brokers)
+		collectedStats++
+	}()
+	go func() {
+		defer wg.Done()
+		metadata, err := s.kafkaSvc.ListTopicsCached(ctx)
+		if err != nil {
+			addError(clusterOverviewStatTopics, err)
+			return
+		}
+		topics := make([]*sarama.TopicMetadata, 0, len(metadata.Topics))
+		for _, topic := range metadata.Topics {
+			if isTopicAllowed(topic.Name) {
+				topics = append(topics, topic)
+			}
+		}
+		health := checkClusterHealth(topics)
+		if len(health.TopicErrors) > 0 {
+			addError(clusterOverviewStatTopics, fmt.Errorf("the metadata of %d topics could not be fetched, their partitions are not counted: %v",
+				len(health.TopicErrors), health.TopicErrors[0].Error))
+		}
+		mutex.Lock()
+		defer mutex.Unlock()
+		overview.TopicCount = health.TopicCount
+		overview.PartitionCount = health.PartitionCount
+		overview.OfflinePartitionCount = health.OfflineCount
+		overview.UnderReplicatedPartitionCount = health.UnderReplicatedCount
+		collectedStats++
+	}()
+	go func() {
+		defer wg.Done()
+		groups, err := s.kafkaSvc.ListConsumerGroups(ctx)
+		if err != nil {
+			addError(clusterOverviewStatConsumerGroups, err)
+			return
+		}
+		// The groups of brokers which failed to list them are missing
+		for _, err := range groups.Errors {
+			addError(clusterOverviewStatConsumerGroups, err)
+		}
+		mutex.Lock()
+		defer mutex.Unlock()
+		overview.ConsumerGroupCount = len(groups.GroupIDs)
+		collectedStats++
+	}()
+	wg.Wait()
+
+	overview.IsPartial = len(overview.Errors) > 0
+	if collectedStats == 0 {
+		return nil, fmt.Errorf("failed to collect any stat of the cluster overview: %v", overview.Errors[0].Error)
+	}
+
+	return overview, nil
+}
@@ -0,0 +1,54 @@
+package owl
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/cloudhut/kowl/backend/pkg/kafka"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestGetClusterOverview_Partial(t *testing.T) {
+	broker := sarama.NewMockBroker(t, 1)
+	defer broker.Close()
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).
+			SetController(broker.BrokerID()).
+			SetBroker(broker.Addr(), broker.BrokerID()).
+			SetLeader("orders", 0, broker.BrokerID()).
+			SetLeader("orders", 1, -1),
+		"ListGroupsRequest": sarama.NewMockWrapper(&sarama.ListGroupsResponse{Err: sarama.ErrClusterAuthorizationFailed}),
+	})
+
+	client, err := sarama.NewClient([]string{broker.Addr()}, sarama.NewConfig())
+	require.NoError(t, err)
+	defer client.Close()
+	// Topics are listed from any connected broker, hence the connection must be established before the stats are
+	// collected concurrently
+	_, err = client.Controller()
+	require.NoError(t, err)
+	svc := &Service{kafkaSvc: &kafka.Service{Client: client, Logger: zap.NewNop()}, logger: zap.NewNop()}
+
+	overview, err := svc.GetClusterOverview(context.Background(), func(string) bool { return true })
+	require.NoError(t, err)
+	assert.True(t, overview.IsPartial)
+	require.Len(t, overview.Errors, 1)
+	assert.Equal(t, clusterOverviewStatConsumerGroups, overview.Errors[0].Stat)
+	assert.Equal(t, -1, overview.ConsumerGroupCount)
+
+	// The other stats are returned nevertheless
+	assert.Equal(t, broker.BrokerID(), overview.ControllerID)
+	assert.Equal(t, 1, overview.BrokerCount)
+	assert.Equal(t, 1, overview.TopicCount)
+	assert.Equal(t, 2, overview.PartitionCount)
+	assert.Equal(t, 1, overview.OfflinePartitionCount)
+
+	// Topics which are not allowed are not counted
+	overview, err = svc.GetClusterOverview(context.Background(), func(topicName string) bool { return topicName != "orders" })
+	require.NoError(t, err)
+	assert.Equal(t, 0, overview.TopicCount)
+	assert.Equal(t, 0, overview.PartitionCount)
+}
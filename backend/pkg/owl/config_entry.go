@@ -0,0 +1,32 @@
+package owl
+
+import "github.com/Shopify/sarama"
+
+// redactedConfigValue replaces the value of config entries which are marked as sensitive by Kafka
+const redactedConfigValue = "[REDACTED]"
+
+// configSourceName returns the Kafka naming of the config source, e.g. DYNAMIC_BROKER_CONFIG
+func configSourceName(source sarama.ConfigSource) string {
+	switch source {
+	case sarama.SourceTopic:
+		return "DYNAMIC_TOPIC_CONFIG"
+	case sarama.SourceDynamicBroker:
+		return "DYNAMIC_BROKER_CONFIG"
+	case sarama.SourceDynamicDefaultBroker:
+		return "DYNAMIC_DEFAULT_BROKER_CONFIG"
+	case sarama.SourceStaticBroker:
+		return "STATIC_BROKER_CONFIG"
+	case sarama.SourceDefault:
+		return "DEFAULT_CONFIG"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// configEntryValue returns the config value or a placeholder if the entry is sensitive
+func configEntryValue(entry *sarama.ConfigEntry) string {
+	if entry.Sensitive {
+		return redactedConfigValue
+	}
+	return entry.Value
+}
@@ -90,7 +90,7 @@ func (s *Service) getConsumerGroupLags(ctx context.Context, groups []string) (ma
 		topicPartitions[topic] = partitions
 	}
 
-	waterMarks, err := s.kafkaSvc.HighWaterMarks(topicPartitions)
+	waterMarks, err := s.kafkaSvc.HighWaterMarks(ctx, topicPartitions)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,176 @@
+package owl
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/Shopify/sarama"
+	"go.uber.org/zap"
+)
+
+// ConsumerGroupLagDetails describes the lag of a single consumer group, aggregated per topic and in total
+type ConsumerGroupLagDetails struct {
+	GroupID  string             `json:"groupId"`
+	State    string             `json:"state"`
+	TotalLag int64              `json:"totalLag"`
+	Topics   []*TopicLagDetails `json:"topics"`
+}
+
+// TopicLagDetails describes the lag of a consumer group on a single topic along with the lag of each partition
+type TopicLagDetails struct {
+	TopicName  string                `json:"topicName"`
+	SummedLag  int64                 `json:"summedLag"` // Partitions without a committed offset are not considered
+	Partitions []PartitionLagDetails `json:"partitions"`
+}
+
+// PartitionLagDetails describes the lag of a consumer group on a single partition. CommittedOffset is -1 if the group
+// has not committed an offset for this partition yet, in this case Lag is 0 and can not be determined.
+type PartitionLagDetails struct {
+	PartitionID     int32  `json:"partitionId"`
+	CommittedOffset int64  `json:"committedOffset"`
+	LogEndOffset    int64  `json:"logEndOffset"`
+	Lag             int64  `json:"lag"`
+	HasOffset       bool   `json:"hasOffset"`
+	MemberID        string `json:"memberId,omitempty"` // Empty if the partition is not assigned to any group member
+	ClientID        string `json:"clientId,omitempty"`
+	ClientHost      string `json:"clientHost,omitempty"`
+}
+
+// partitionOwner is the group member which currently has a partition assigned
+type partitionOwner struct {
+	MemberID   string
+	ClientID   string
+	ClientHost string
+}
+
+// GetConsumerGroupLagDetails returns the lag of a consumer group for all partitions, which either have a committed
+// offset or are assigned to one of the group's members.
+func (s *Service) GetConsumerGroupLagDetails(ctx context.Context, groupID string) (*ConsumerGroupLagDetails, error) {
+	// 1. Describe the group so that we know its members and their partition assignments
+	described, err := s.kafkaSvc.DescribeConsumerGroups(ctx, []string{groupID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe consumer group: %w", err)
+	}
+	var group *sarama.GroupDescription
+	for _, res := range described {
+		for _, g := range res.Groups {
+			if g.GroupId == groupID {
+				group = g
+			}
+		}
+	}
+	if group == nil {
+		return nil, ErrConsumerGroupNotFound
+	}
+	if group.Err != sarama.ErrNoError {
+		return nil, fmt.Errorf("failed to describe consumer group: %w", group.Err)
+	}
+
+	owners := make(map[string]map[int32]partitionOwner)
+	if group.ProtocolType == "consumer" {
+		for id, member := range group.Members {
+			assignments, err := member.GetMemberAssignment()
+			if err != nil {
+				s.logger.Warn("failed to decode member assignments", zap.String("client_id", member.ClientId), zap.Error(err))
+				continue
+			}
+			for topic, partitionIDs := range assignments.Topics {
+				if _, ok := owners[topic]; !ok {
+					owners[topic] = make(map[int32]partitionOwner)
+				}
+				for _, pID := range partitionIDs {
+					owners[topic][pID] = partitionOwner{MemberID: id, ClientID: member.ClientId, ClientHost: member.ClientHost}
+				}
+			}
+		}
+	}
+
+	// 2. Fetch committed offsets
+	offsets, err := s.kafkaSvc.ListConsumerGroupOffsets(ctx, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list consumer group offsets: %w", err)
+	}
+	committed := convertOffsets(offsets)
+
+	// Unknown groups are reported as dead groups without offsets
+	if group.State == "Dead" && len(group.Members) == 0 && len(committed) == 0 {
+		return nil, ErrConsumerGroupNotFound
+	}
+
+	// 3. Fetch the log end offsets of all partitions which are either committed or assigned
+	topicPartitions := make(map[string][]int32)
+	addPartition := func(topic string, pID int32) {
+		for _, id := range topicPartitions[topic] {
+			if id == pID {
+				return
+			}
+		}
+		topicPartitions[topic] = append(topicPartitions[topic], pID)
+	}
+	for topic, pOffsets := range committed {
+		for pID := range pOffsets {
+			addPartition(topic, pID)
+		}
+	}
+	for topic, pOwners := range owners {
+		for pID := range pOwners {
+			addPartition(topic, pID)
+		}
+	}
+
+	waterMarks, err := s.kafkaSvc.HighWaterMarks(ctx, topicPartitions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get high water marks: %w", err)
+	}
+
+	res := computeGroupLagDetails(topicPartitions, committed, waterMarks, owners)
+	res.GroupID = groupID
+	res.State = group.State
+
+	return res, nil
+}
+
+// computeGroupLagDetails calculates the lag for all given topic partitions. Partitions without a committed offset (-1)
+// are reported without lag and are not part of the summed lags.
+func computeGroupLagDetails(topicPartitions map[string][]int32, committed map[string]partitionOffsets, waterMarks map[string]map[int32]int64, owners map[string]map[int32]partitionOwner) *ConsumerGroupLagDetails {
+	res := &ConsumerGroupLagDetails{Topics: make([]*TopicLagDetails, 0, len(topicPartitions))}
+	for topic, partitionIDs := range topicPartitions {
+		t := &TopicLagDetails{
+			TopicName:  topic,
+			Partitions: make([]PartitionLagDetails, 0, len(partitionIDs)),
+		}
+		for _, pID := range partitionIDs {
+			p := PartitionLagDetails{
+				PartitionID:     pID,
+				CommittedOffset: -1,
+				LogEndOffset:    waterMarks[topic][pID],
+			}
+
+			if offset, exists := committed[topic][pID]; exists && offset >= 0 {
+				p.CommittedOffset = offset
+				p.HasOffset = true
+				p.Lag = p.LogEndOffset - offset
+				if p.Lag < 0 {
+					// The high water mark may have been fetched before the group committed its latest offset
+					p.Lag = 0
+				}
+			}
+			if owner, exists := owners[topic][pID]; exists {
+				p.MemberID = owner.MemberID
+				p.ClientID = owner.ClientID
+				p.ClientHost = owner.ClientHost
+			}
+
+			t.SummedLag += p.Lag
+			t.Partitions = append(t.Partitions, p)
+		}
+		sort.Slice(t.Partitions, func(i, j int) bool { return t.Partitions[i].PartitionID < t.Partitions[j].PartitionID })
+
+		res.TotalLag += t.SummedLag
+		res.Topics = append(res.Topics, t)
+	}
+	sort.Slice(res.Topics, func(i, j int) bool { return res.Topics[i].TopicName < res.Topics[j].TopicName })
+
+	return res
+}
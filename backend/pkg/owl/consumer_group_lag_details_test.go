@@ -0,0 +1,47 @@
+package owl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComputeGroupLagDetails(t *testing.T) {
+	topicPartitions := map[string][]int32{
+		"orders":   {2, 0, 1},
+		"payments": {0},
+	}
+	committed := map[string]partitionOffsets{
+		"orders":   {0: 90, 1: -1, 2: 120},
+		"payments": {0: 10},
+	}
+	waterMarks := map[string]map[int32]int64{
+		"orders":   {0: 100, 1: 50, 2: 110},
+		"payments": {0: 15},
+	}
+	owners := map[string]map[int32]partitionOwner{
+		"orders": {0: {MemberID: "member-1", ClientID: "client-1", ClientHost: "/10.0.0.1"}},
+	}
+
+	res := computeGroupLagDetails(topicPartitions, committed, waterMarks, owners)
+	require.Len(t, res.Topics, 2)
+	assert.Equal(t, int64(15), res.TotalLag)
+
+	orders := res.Topics[0]
+	assert.Equal(t, "orders", orders.TopicName)
+	assert.Equal(t, int64(10), orders.SummedLag)
+	require.Len(t, orders.Partitions, 3)
+
+	assert.Equal(t, PartitionLagDetails{
+		PartitionID: 0, CommittedOffset: 90, LogEndOffset: 100, Lag: 10, HasOffset: true,
+		MemberID: "member-1", ClientID: "client-1", ClientHost: "/10.0.0.1",
+	}, orders.Partitions[0])
+	// No committed offset
+	assert.Equal(t, PartitionLagDetails{PartitionID: 1, CommittedOffset: -1, LogEndOffset: 50}, orders.Partitions[1])
+	// Committed offset ahead of the fetched high water mark
+	assert.Equal(t, int64(0), orders.Partitions[2].Lag)
+
+	assert.Equal(t, "payments", res.Topics[1].TopicName)
+	assert.Equal(t, int64(5), res.Topics[1].SummedLag)
+}
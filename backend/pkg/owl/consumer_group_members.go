@@ -0,0 +1,131 @@
+package owl
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/Shopify/sarama"
+)
+
+// ConsumerGroupMembers describes the members of a single group along with their partition assignments. The generation
+// of the group is not part of the DescribeGroups response and can therefore not be reported.
+type ConsumerGroupMembers struct {
+	GroupID       string                 `json:"groupId"`
+	State         string                 `json:"state"`
+	ProtocolType  string                 `json:"protocolType"`
+	Protocol      string                 `json:"protocol"`
+	CoordinatorID int32                  `json:"coordinatorId"`
+	Members       []*ConsumerGroupMember `json:"members"`
+}
+
+// ConsumerGroupMember is a single member of a group. The metadata and assignment of members which use the "consumer"
+// protocol type are decoded, for all other protocol types (e. g. Kafka connect or the schema registry) or if decoding
+// fails, the raw bytes are returned instead.
+type ConsumerGroupMember struct {
+	ID         string `json:"id"`
+	ClientID   string `json:"clientId"`
+	ClientHost string `json:"clientHost"`
+
+	SubscribedTopics []string                 `json:"subscribedTopics"`
+	Assignments      []*GroupMemberAssignment `json:"assignments"`
+
+	RawMetadata   []byte `json:"rawMetadata,omitempty"`
+	RawAssignment []byte `json:"rawAssignment,omitempty"`
+	DecodeError   string `json:"decodeError,omitempty"`
+}
+
+// GetConsumerGroupMembers returns the members of a group along with the topic partitions which are assigned to them
+func (s *Service) GetConsumerGroupMembers(ctx context.Context, groupID string) (*ConsumerGroupMembers, error) {
+	described, err := s.kafkaSvc.DescribeConsumerGroups(ctx, []string{groupID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe consumer group: %w", err)
+	}
+
+	var group *sarama.GroupDescription
+	var coordinatorID int32
+	for id, res := range described {
+		for _, g := range res.Groups {
+			if g.GroupId == groupID {
+				group = g
+				coordinatorID = id
+			}
+		}
+	}
+	if group == nil {
+		return nil, ErrConsumerGroupNotFound
+	}
+	if group.Err != sarama.ErrNoError {
+		return nil, fmt.Errorf("failed to describe consumer group: %w", group.Err)
+	}
+	// Unknown groups are reported as dead groups without members
+	if group.State == "Dead" && len(group.Members) == 0 {
+		return nil, ErrConsumerGroupNotFound
+	}
+
+	return &ConsumerGroupMembers{
+		GroupID:       group.GroupId,
+		State:         group.State,
+		ProtocolType:  group.ProtocolType,
+		Protocol:      group.Protocol,
+		CoordinatorID: coordinatorID,
+		Members:       convertGroupMemberDetails(group.Members, group.ProtocolType),
+	}, nil
+}
+
+// convertGroupMemberDetails decodes the metadata and assignments of all group members, sorted by member id. Only
+// members of groups with the protocol type "consumer" follow the consumer protocol schema, see convertGroupMembers.
+func convertGroupMemberDetails(members map[string]*sarama.GroupMemberDescription, protocolType string) []*ConsumerGroupMember {
+	res := make([]*ConsumerGroupMember, 0, len(members))
+	for id, m := range members {
+		member := &ConsumerGroupMember{
+			ID:               id,
+			ClientID:         m.ClientId,
+			ClientHost:       m.ClientHost,
+			SubscribedTopics: make([]string, 0),
+			Assignments:      make([]*GroupMemberAssignment, 0),
+		}
+		res = append(res, member)
+
+		if protocolType != "consumer" {
+			member.RawMetadata = m.MemberMetadata
+			member.RawAssignment = m.MemberAssignment
+			continue
+		}
+
+		if len(m.MemberMetadata) > 0 {
+			metadata, err := m.GetMemberMetadata()
+			if err != nil {
+				member.RawMetadata = m.MemberMetadata
+				member.DecodeError = fmt.Sprintf("failed to decode member metadata: %v", err)
+			} else {
+				member.SubscribedTopics = append(member.SubscribedTopics, metadata.Topics...)
+				sort.Strings(member.SubscribedTopics)
+			}
+		}
+
+		// Members which are joining the group during a rebalance do not have an assignment yet
+		if len(m.MemberAssignment) == 0 {
+			continue
+		}
+		assignment, err := m.GetMemberAssignment()
+		if err != nil {
+			member.RawAssignment = m.MemberAssignment
+			member.DecodeError = fmt.Sprintf("failed to decode member assignment: %v", err)
+			continue
+		}
+		for topic, partitionIDs := range assignment.Topics {
+			sort.Slice(partitionIDs, func(i, j int) bool { return partitionIDs[i] < partitionIDs[j] })
+			member.Assignments = append(member.Assignments, &GroupMemberAssignment{
+				TopicName:    topic,
+				PartitionIDs: partitionIDs,
+			})
+		}
+		sort.Slice(member.Assignments, func(i, j int) bool {
+			return member.Assignments[i].TopicName < member.Assignments[j].TopicName
+		})
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].ID < res[j].ID })
+
+	return res
+}
@@ -0,0 +1,68 @@
+package owl
+
+import (
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertGroupMemberDetails(t *testing.T) {
+	joinReq := &sarama.JoinGroupRequest{}
+	err := joinReq.AddGroupProtocolMetadata("range", &sarama.ConsumerGroupMemberMetadata{Topics: []string{"payments", "orders"}})
+	require.NoError(t, err)
+	metadata := joinReq.OrderedGroupProtocols[0].Metadata
+	syncReq := &sarama.SyncGroupRequest{}
+	err = syncReq.AddGroupAssignmentMember("member-1", &sarama.ConsumerGroupMemberAssignment{
+		Topics: map[string][]int32{"payments": {0}, "orders": {2, 0}},
+	})
+	require.NoError(t, err)
+
+	members := map[string]*sarama.GroupMemberDescription{
+		"member-2": {ClientId: "client-2", ClientHost: "/10.0.0.2", MemberMetadata: metadata},
+		"member-1": {
+			ClientId:         "client-1",
+			ClientHost:       "/10.0.0.1",
+			MemberMetadata:   metadata,
+			MemberAssignment: syncReq.GroupAssignments["member-1"],
+		},
+		"member-3": {ClientId: "client-3", MemberAssignment: []byte{0x00}},
+	}
+
+	res := convertGroupMemberDetails(members, "consumer")
+	require.Len(t, res, 3)
+
+	assert.Equal(t, "member-1", res[0].ID)
+	assert.Equal(t, "client-1", res[0].ClientID)
+	assert.Equal(t, "/10.0.0.1", res[0].ClientHost)
+	assert.Equal(t, []string{"orders", "payments"}, res[0].SubscribedTopics)
+	assert.Equal(t, []*GroupMemberAssignment{
+		{TopicName: "orders", PartitionIDs: []int32{0, 2}},
+		{TopicName: "payments", PartitionIDs: []int32{0}},
+	}, res[0].Assignments)
+	assert.Nil(t, res[0].RawAssignment)
+	assert.Empty(t, res[0].DecodeError)
+
+	// Joining member without an assignment
+	assert.Equal(t, []string{"orders", "payments"}, res[1].SubscribedTopics)
+	assert.Empty(t, res[1].Assignments)
+	assert.Empty(t, res[1].DecodeError)
+
+	// Undecodable assignments are returned raw
+	assert.Equal(t, []byte{0x00}, res[2].RawAssignment)
+	assert.NotEmpty(t, res[2].DecodeError)
+}
+
+func TestConvertGroupMemberDetailsNonConsumerProtocol(t *testing.T) {
+	members := map[string]*sarama.GroupMemberDescription{
+		"worker-1": {ClientId: "connect-1", MemberMetadata: []byte("metadata"), MemberAssignment: []byte("assignment")},
+	}
+
+	res := convertGroupMemberDetails(members, "connect")
+	require.Len(t, res, 1)
+	assert.Equal(t, []byte("metadata"), res[0].RawMetadata)
+	assert.Equal(t, []byte("assignment"), res[0].RawAssignment)
+	assert.Empty(t, res[0].Assignments)
+	assert.Empty(t, res[0].DecodeError)
+}
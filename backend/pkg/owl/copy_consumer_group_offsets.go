@@ -0,0 +1,42 @@
+package owl
+
+import (
+	"context"
+
+	"github.com/cloudhut/kowl/backend/pkg/kafka"
+	"go.uber.org/zap"
+)
+
+// CopiedConsumerGroupOffset is the offset which has been committed for the destination group of an offset copy
+type CopiedConsumerGroupOffset struct {
+	TopicName    string `json:"topicName"`
+	PartitionID  int32  `json:"partitionId"`
+	SourceOffset int64  `json:"sourceOffset"`
+	Offset       int64  `json:"offset"`
+}
+
+// CopyConsumerGroupOffsets commits the committed offsets of the source group for the destination group and returns
+// the written offsets.
+func (s *Service) CopyConsumerGroupOffsets(ctx context.Context, req kafka.CopyConsumerGroupOffsetsRequest) ([]CopiedConsumerGroupOffset, error) {
+	copied, err := s.kafkaSvc.CopyConsumerGroupOffsets(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	s.logger.Info("copied consumer group offsets",
+		zap.String("source_group", req.SourceGroupID),
+		zap.String("destination_group", req.DestinationGroupID),
+		zap.Int64("offset_delta", req.OffsetDelta),
+		zap.Int("partitions", len(copied)))
+
+	res := make([]CopiedConsumerGroupOffset, len(copied))
+	for i, offset := range copied {
+		res[i] = CopiedConsumerGroupOffset{
+			TopicName:    offset.TopicName,
+			PartitionID:  offset.PartitionID,
+			SourceOffset: offset.SourceOffset,
+			Offset:       offset.Offset,
+		}
+	}
+
+	return res, nil
+}
@@ -0,0 +1,52 @@
+package owl
+
+import (
+	"context"
+	"sort"
+
+	"github.com/cloudhut/kowl/backend/pkg/kafka"
+	"go.uber.org/zap"
+)
+
+// CreateTopicResponse is the metadata of a topic which has been created
+type CreateTopicResponse struct {
+	TopicName         string                  `json:"topicName"`
+	PartitionCount    int                     `json:"partitionCount"`
+	ReplicationFactor int                     `json:"replicationFactor"`
+	Partitions        []CreatedTopicPartition `json:"partitions"`
+}
+
+// CreatedTopicPartition is the leader and the replicas of a partition of a topic which has been created
+type CreatedTopicPartition struct {
+	ID       int32   `json:"id"`
+	Leader   int32   `json:"leader"`
+	Replicas []int32 `json:"replicas"`
+}
+
+// CreateTopic creates a new topic and returns its metadata
+func (s *Service) CreateTopic(ctx context.Context, topicName string, spec kafka.TopicSpec) (*CreateTopicResponse, error) {
+	metadata, err := s.kafkaSvc.CreateTopic(ctx, topicName, spec)
+	if err != nil {
+		return nil, err
+	}
+	s.logger.Info("created topic", zap.String("topic_name", topicName), zap.Int("partition_count", len(metadata.Partitions)))
+
+	res := &CreateTopicResponse{
+		TopicName:      metadata.Name,
+		PartitionCount: len(metadata.Partitions),
+		Partitions:     make([]CreatedTopicPartition, len(metadata.Partitions)),
+	}
+	for i, partition := range metadata.Partitions {
+		res.Partitions[i] = CreatedTopicPartition{
+			ID:       partition.ID,
+			Leader:   partition.Leader,
+			Replicas: partition.Replicas,
+		}
+	}
+	sort.Slice(res.Partitions, func(i, j int) bool { return res.Partitions[i].ID < res.Partitions[j].ID })
+	if len(res.Partitions) > 0 {
+		res.ReplicationFactor = len(res.Partitions[0].Replicas)
+	}
+
+	return res, nil
+}
@@ -0,0 +1,47 @@
+package owl
+
+import (
+	"context"
+	"sort"
+
+	"go.uber.org/zap"
+)
+
+// DeletedConsumerGroup is the result of deleting a single consumer group. Error is empty if the group has been deleted.
+type DeletedConsumerGroup struct {
+	GroupID string `json:"groupId"`
+	Error   string `json:"error,omitempty"`
+}
+
+// DeleteConsumerGroup deletes a single consumer group, which must not have any active members
+func (s *Service) DeleteConsumerGroup(ctx context.Context, groupID string) error {
+	err := s.kafkaSvc.DeleteConsumerGroup(ctx, groupID)
+	if err != nil {
+		return err
+	}
+	s.logger.Info("deleted consumer group", zap.String("group", groupID))
+
+	return nil
+}
+
+// DeleteConsumerGroups deletes multiple consumer groups and returns the result of each group, sorted by group id
+func (s *Service) DeleteConsumerGroups(ctx context.Context, groupIDs []string) ([]DeletedConsumerGroup, error) {
+	errs, err := s.kafkaSvc.DeleteConsumerGroups(ctx, groupIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]DeletedConsumerGroup, 0, len(groupIDs))
+	for _, groupID := range groupIDs {
+		deleted := DeletedConsumerGroup{GroupID: groupID}
+		if err, exists := errs[groupID]; exists {
+			deleted.Error = err.Error()
+		} else {
+			s.logger.Info("deleted consumer group", zap.String("group", groupID))
+		}
+		res = append(res, deleted)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].GroupID < res[j].GroupID })
+
+	return res, nil
+}
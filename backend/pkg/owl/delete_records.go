@@ -0,0 +1,28 @@
+package owl
+
+import (
+	"context"
+	"sort"
+)
+
+// DeletedRecordsPartition is the new low water mark of a partition after records have been deleted
+type DeletedRecordsPartition struct {
+	PartitionID  int32 `json:"partitionId"`
+	LowWaterMark int64 `json:"lowWaterMark"`
+}
+
+// DeleteRecords deletes all records before the given offset for each partition and returns the new low water marks
+func (s *Service) DeleteRecords(ctx context.Context, topicName string, partitionOffsets map[int32]int64) ([]DeletedRecordsPartition, error) {
+	lowWaterMarks, err := s.kafkaSvc.DeleteRecords(ctx, topicName, partitionOffsets)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]DeletedRecordsPartition, 0, len(lowWaterMarks))
+	for partitionID, lowWaterMark := range lowWaterMarks {
+		res = append(res, DeletedRecordsPartition{PartitionID: partitionID, LowWaterMark: lowWaterMark})
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].PartitionID < res[j].PartitionID })
+
+	return res, nil
+}
@@ -4,4 +4,8 @@ import "errors"
 
 var (
 	ErrSchemaRegistryNotConfigured = errors.New("no schema registry configured")
+	ErrConsumerGroupNotFound       = errors.New("consumer group does not exist")
+	ErrInvalidPayload              = errors.New("invalid payload")
+	ErrInvalidSchemaVersion        = errors.New("invalid schema version")
+	ErrInvalidCompatibilityLevel   = errors.New("invalid compatibility level")
 )
@@ -0,0 +1,12 @@
+package owl
+
+import (
+	"context"
+
+	"github.com/cloudhut/kowl/backend/pkg/kafka"
+)
+
+// ExportMessages streams the messages of the requested range to onMessage and returns the number of exported messages
+func (s *Service) ExportMessages(ctx context.Context, req kafka.ExportMessagesRequest, onMessage func(m *kafka.ExportedMessage) error) (int, error) {
+	return s.kafkaSvc.ExportMessages(ctx, req, onMessage)
+}
@@ -0,0 +1,256 @@
+package owl
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/cloudhut/kowl/backend/pkg/kafka"
+	"go.uber.org/zap"
+)
+
+// ImportEncoding describes how the keys, values and header values of a message export are encoded
+type ImportEncoding string
+
+const (
+	// ImportEncodingBase64 expects payloads as base64 strings, as they are written by raw exports. It replays the
+	// original bytes of each message.
+	ImportEncodingBase64 ImportEncoding = "base64"
+	// ImportEncodingDecoded expects payloads as they are written by decoded exports. Strings are produced as text and
+	// all other JSON values (e.g. objects of JSON or Avro messages) are produced as JSON.
+	ImportEncodingDecoded ImportEncoding = "decoded"
+)
+
+// ImportMessagesRequest describes a message export in NDJSON format, which shall be produced to a topic
+type ImportMessagesRequest struct {
+	TopicName string
+
+	// Records contains one exported message per line, empty lines are skipped
+	Records  io.Reader
+	Encoding ImportEncoding
+
+	// PreserveKeys and PreserveHeaders produce the records with their exported keys and headers. Otherwise the
+	// records are produced without key and headers.
+	PreserveKeys    bool
+	PreserveHeaders bool
+
+	// DryRun validates all records without producing them
+	DryRun bool
+}
+
+// ImportMessagesResponse reports the outcome of every record of an import along with the total throughput
+type ImportMessagesResponse struct {
+	TopicName     string           `json:"topicName"`
+	IsDryRun      bool             `json:"isDryRun"`
+	Records       []ImportedRecord `json:"records"`
+	ProducedCount int              `json:"producedCount"`
+	FailedCount   int              `json:"failedCount"`
+
+	// ElapsedMs, MessagesPerSecond and BytesPerSecond only cover producing the records (not parsing them)
+	ElapsedMs         int64   `json:"elapsedMs"`
+	MessagesPerSecond float64 `json:"messagesPerSecond"`
+	BytesPerSecond    float64 `json:"bytesPerSecond"`
+}
+
+// ImportedRecord is the outcome of a single record. PartitionID and Offset are set if the record has been produced,
+// Error is set if it could not be parsed or produced.
+type ImportedRecord struct {
+	Line        int    `json:"line"`
+	PartitionID *int32 `json:"partitionId,omitempty"`
+	Offset      *int64 `json:"offset,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// exportedRecord is a single line of an NDJSON export. Columns which are not needed for producing are ignored.
+type exportedRecord struct {
+	Key     json.RawMessage `json:"key"`
+	Value   json.RawMessage `json:"value"`
+	Headers []struct {
+		Key   string          `json:"key"`
+		Value json.RawMessage `json:"value"`
+	} `json:"headers"`
+}
+
+// ImportMessages parses the exported records and produces all valid records to the given topic. Records which can't
+// be parsed or produced are reported in the response, but don't abort the import.
+func (s *Service) ImportMessages(ctx context.Context, req ImportMessagesRequest) (*ImportMessagesResponse, error) {
+	exists, err := s.kafkaSvc.TopicExists(ctx, req.TopicName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check whether the topic exists: %w", err)
+	}
+	if !exists {
+		return nil, kafka.ErrTopicNotFound
+	}
+
+	records, lines, err := parseExportedRecords(req)
+	if err != nil {
+		return nil, err
+	}
+	res := &ImportMessagesResponse{
+		TopicName: req.TopicName,
+		IsDryRun:  req.DryRun,
+		Records:   make([]ImportedRecord, len(lines)),
+	}
+
+	// Records which could be parsed are produced, their index in the response is kept in recordIndexes
+	produceReqs := make([]kafka.ProduceMessageRequest, 0, len(records))
+	recordIndexes := make([]int, 0, len(records))
+	for i, line := range lines {
+		res.Records[i].Line = line
+		if records[i].err != nil {
+			res.Records[i].Error = records[i].err.Error()
+			res.FailedCount++
+			continue
+		}
+		produceReqs = append(produceReqs, records[i].req)
+		recordIndexes = append(recordIndexes, i)
+	}
+
+	if req.DryRun || len(produceReqs) == 0 {
+		return res, nil
+	}
+
+	start := time.Now()
+	results, err := s.kafkaSvc.ProduceMessages(ctx, produceReqs)
+	if err != nil {
+		return nil, err
+	}
+	elapsed := time.Since(start)
+
+	producedBytes := 0
+	for i, result := range results {
+		record := &res.Records[recordIndexes[i]]
+		if result.Err != nil {
+			record.Error = result.Err.Error()
+			res.FailedCount++
+			continue
+		}
+		partitionID, offset := result.PartitionID, result.Offset
+		record.PartitionID = &partitionID
+		record.Offset = &offset
+		res.ProducedCount++
+		producedBytes += len(produceReqs[i].Key) + len(produceReqs[i].Value)
+	}
+
+	res.ElapsedMs = elapsed.Milliseconds()
+	if seconds := elapsed.Seconds(); seconds > 0 {
+		res.MessagesPerSecond = float64(res.ProducedCount) / seconds
+		res.BytesPerSecond = float64(producedBytes) / seconds
+	}
+	s.logger.Info("imported messages",
+		zap.String("topic_name", req.TopicName),
+		zap.Int("produced_count", res.ProducedCount),
+		zap.Int("failed_count", res.FailedCount),
+		zap.Duration("elapsed", elapsed))
+
+	return res, nil
+}
+
+// parsedRecord is either the produce request of a record or the reason why the record is invalid
+type parsedRecord struct {
+	req kafka.ProduceMessageRequest
+	err error
+}
+
+// parseExportedRecords parses every non-empty line of the export and returns the parsed records along with their line
+// numbers. An error is only returned if the export can't be read.
+func parseExportedRecords(req ImportMessagesRequest) ([]parsedRecord, []int, error) {
+	switch req.Encoding {
+	case "", ImportEncodingBase64, ImportEncodingDecoded:
+	default:
+		return nil, nil, fmt.Errorf("%w: unknown encoding '%v'", ErrInvalidPayload, req.Encoding)
+	}
+
+	reader := bufio.NewReader(req.Records)
+	var records []parsedRecord
+	var lines []int
+	for line := 1; ; line++ {
+		content, err := reader.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return nil, nil, fmt.Errorf("failed to read records: %w", err)
+		}
+		if content = bytes.TrimSpace(content); len(content) > 0 {
+			produceReq, parseErr := parseExportedRecord(content, req)
+			records = append(records, parsedRecord{req: produceReq, err: parseErr})
+			lines = append(lines, line)
+		}
+		if err == io.EOF {
+			return records, lines, nil
+		}
+	}
+}
+
+// parseExportedRecord converts a single exported record into a produce request for the target topic
+func parseExportedRecord(line []byte, req ImportMessagesRequest) (kafka.ProduceMessageRequest, error) {
+	produceReq := kafka.ProduceMessageRequest{TopicName: req.TopicName}
+
+	var record exportedRecord
+	err := json.Unmarshal(line, &record)
+	if err != nil {
+		return produceReq, fmt.Errorf("%w: record is not a valid JSON object: %v", ErrInvalidPayload, err)
+	}
+	if record.Value == nil {
+		return produceReq, fmt.Errorf("%w: record has no value column", ErrInvalidPayload)
+	}
+
+	produceReq.Value, err = decodeExportedPayload(record.Value, req.Encoding)
+	if err != nil {
+		return produceReq, fmt.Errorf("failed to decode value: %w", err)
+	}
+	if req.PreserveKeys {
+		produceReq.Key, err = decodeExportedPayload(record.Key, req.Encoding)
+		if err != nil {
+			return produceReq, fmt.Errorf("failed to decode key: %w", err)
+		}
+	}
+	if req.PreserveHeaders {
+		produceReq.Headers = make([]sarama.RecordHeader, len(record.Headers))
+		for i, header := range record.Headers {
+			value, err := decodeExportedPayload(header.Value, req.Encoding)
+			if err != nil {
+				return produceReq, fmt.Errorf("failed to decode value of header '%v': %w", header.Key, err)
+			}
+			produceReq.Headers[i] = sarama.RecordHeader{Key: []byte(header.Key), Value: value}
+		}
+	}
+
+	return produceReq, nil
+}
+
+// decodeExportedPayload returns the bytes of an exported key, value or header value. Missing and null payloads are
+// returned as nil (e.g. tombstones).
+func decodeExportedPayload(payload json.RawMessage, encoding ImportEncoding) ([]byte, error) {
+	if payload == nil || bytes.Equal(payload, []byte("null")) {
+		return nil, nil
+	}
+
+	var str string
+	isString := json.Unmarshal(payload, &str) == nil
+	switch encoding {
+	case "", ImportEncodingBase64:
+		if !isString {
+			return nil, fmt.Errorf("%w: payload must be a base64 string", ErrInvalidPayload)
+		}
+		decoded, err := base64.StdEncoding.DecodeString(str)
+		if err != nil {
+			return nil, fmt.Errorf("%w: payload is not valid base64: %v", ErrInvalidPayload, err)
+		}
+		return decoded, nil
+	default:
+		if isString {
+			return []byte(str), nil
+		}
+		var compacted bytes.Buffer
+		err := json.Compact(&compacted, payload)
+		if err != nil {
+			return nil, fmt.Errorf("%w: payload is not valid JSON: %v", ErrInvalidPayload, err)
+		}
+		return compacted.Bytes(), nil
+	}
+}
@@ -0,0 +1,74 @@
+package owl
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseExportedRecords(t *testing.T) {
+	export := `{"partition":0,"offset":0,"key":"a2V5","value":"AAEC/w==","headers":[{"key":"trace-id","value":"MTIz"},{"key":"empty","value":null}]}
+
+{"partition":0,"offset":1,"key":null,"value":null,"headers":[]}
+{"partition":0,"offset":2,"value":"not base64!"}
+{"partition":0,"offset":3,"key":"a2V5"}
+not json
+`
+	req := ImportMessagesRequest{
+		TopicName:       "orders",
+		Records:         strings.NewReader(export),
+		PreserveKeys:    true,
+		PreserveHeaders: true,
+	}
+	records, lines, err := parseExportedRecords(req)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 3, 4, 5, 6}, lines)
+	require.Len(t, records, 5)
+
+	require.NoError(t, records[0].err)
+	assert.Equal(t, "orders", records[0].req.TopicName)
+	assert.Equal(t, []byte("key"), records[0].req.Key)
+	assert.Equal(t, []byte{0x00, 0x01, 0x02, 0xff}, records[0].req.Value)
+	assert.Equal(t, []sarama.RecordHeader{
+		{Key: []byte("trace-id"), Value: []byte("123")},
+		{Key: []byte("empty"), Value: nil},
+	}, records[0].req.Headers)
+
+	// Tombstones are replayed without key and value
+	require.NoError(t, records[1].err)
+	assert.Nil(t, records[1].req.Key)
+	assert.Nil(t, records[1].req.Value)
+
+	assert.Error(t, records[2].err)
+	assert.EqualError(t, records[3].err, "invalid payload: record has no value column")
+	assert.Error(t, records[4].err)
+
+	// Without preserving keys and headers only the value is replayed
+	req.Records = strings.NewReader(export)
+	req.PreserveKeys = false
+	req.PreserveHeaders = false
+	records, _, err = parseExportedRecords(req)
+	require.NoError(t, err)
+	assert.Nil(t, records[0].req.Key)
+	assert.Nil(t, records[0].req.Headers)
+}
+
+func TestDecodeExportedPayload(t *testing.T) {
+	decoded, err := decodeExportedPayload([]byte(`"hello"`), ImportEncodingDecoded)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("hello"), decoded)
+
+	decoded, err = decodeExportedPayload([]byte(`{"id": 1, "tags": ["a"]}`), ImportEncodingDecoded)
+	require.NoError(t, err)
+	assert.Equal(t, []byte(`{"id":1,"tags":["a"]}`), decoded)
+
+	decoded, err = decodeExportedPayload([]byte(`null`), ImportEncodingDecoded)
+	require.NoError(t, err)
+	assert.Nil(t, decoded)
+
+	_, err = decodeExportedPayload([]byte(`{"id": 1}`), ImportEncodingBase64)
+	assert.Error(t, err)
+}
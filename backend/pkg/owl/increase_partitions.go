@@ -0,0 +1,35 @@
+package owl
+
+import (
+	"context"
+	"go.uber.org/zap"
+)
+
+// partitionIncreaseWarning is returned along with every partition increase, because the new partitions change the
+// partition which is chosen for a given key by the default partitioners
+const partitionIncreaseWarning = "Increasing the partition count changes the key to partition mapping. Messages " +
+	"with the same key may be written to a different partition than before, which breaks the ordering guarantees " +
+	"for keyed topics."
+
+// IncreasePartitionsResponse is the resulting partition count of a topic after the partitions have been increased
+type IncreasePartitionsResponse struct {
+	TopicName      string `json:"topicName"`
+	PartitionCount int32  `json:"partitionCount"`
+	Warning        string `json:"warning"`
+}
+
+// IncreasePartitions increases the partition count of a topic, optionally with a replica assignment for each of
+// the new partitions
+func (s *Service) IncreasePartitions(ctx context.Context, topicName string, newCount int32, assignment [][]int32) (*IncreasePartitionsResponse, error) {
+	count, err := s.kafkaSvc.IncreasePartitions(ctx, topicName, newCount, assignment)
+	if err != nil {
+		return nil, err
+	}
+	s.logger.Info("increased topic partitions", zap.String("topic_name", topicName), zap.Int32("partition_count", count))
+
+	return &IncreasePartitionsResponse{
+		TopicName:      topicName,
+		PartitionCount: count,
+		Warning:        partitionIncreaseWarning,
+	}, nil
+}
@@ -1,7 +1,10 @@
 package owl
 
 import (
+	"context"
 	"fmt"
+	"sort"
+
 	"github.com/Shopify/sarama"
 )
 
@@ -21,18 +24,24 @@ type AclRule struct {
 }
 
 // ListAllACLs returns a list of all stored ACLs.
-func (s *Service) ListAllACLs(req sarama.AclFilter) ([]*AclResource, error) {
-	aclResponses, err := s.kafkaSvc.ListACLs(req)
+func (s *Service) ListAllACLs(ctx context.Context, req sarama.AclFilter) ([]*AclResource, error) {
+	aclResponses, err := s.kafkaSvc.ListACLs(ctx, req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get ACLs from Kafka: %w", err)
 	}
 
+	return convertACLResources(aclResponses), nil
+}
+
+// convertACLResources converts the sarama ACL responses into resources with human readable enum names. Resources and
+// ACLs are sorted so that the results do not flap between requests.
+func convertACLResources(aclResponses []sarama.ResourceAcls) []*AclResource {
 	res := make([]*AclResource, len(aclResponses))
 	for i, aclResponse := range aclResponses {
 		overview := &AclResource{
 			ResourceType:        aclResourceTypeToDisplayname(aclResponse.ResourceType),
 			ResourceName:        aclResponse.ResourceName,
-			ResourcePatternType: "",
+			ResourcePatternType: aclResourcePatternTypeToDisplayname(aclResponse.ResourcePatternType),
 			ACLs:                nil,
 		}
 
@@ -45,12 +54,33 @@ func (s *Service) ListAllACLs(req sarama.AclFilter) ([]*AclResource, error) {
 				PermissionType: aclPermissionToDisplayname(acl.PermissionType),
 			}
 		}
+		sort.Slice(acls, func(i, j int) bool {
+			if acls[i].Principal != acls[j].Principal {
+				return acls[i].Principal < acls[j].Principal
+			}
+			if acls[i].Host != acls[j].Host {
+				return acls[i].Host < acls[j].Host
+			}
+			if acls[i].Operation != acls[j].Operation {
+				return acls[i].Operation < acls[j].Operation
+			}
+			return acls[i].PermissionType < acls[j].PermissionType
+		})
 		overview.ACLs = acls
 		res[i] = overview
 	}
-	// TODO: Maybe add sorting to ensure consistently sorted results / no flapping?
 
-	return res, nil
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].ResourceType != res[j].ResourceType {
+			return res[i].ResourceType < res[j].ResourceType
+		}
+		if res[i].ResourceName != res[j].ResourceName {
+			return res[i].ResourceName < res[j].ResourceName
+		}
+		return res[i].ResourcePatternType < res[j].ResourcePatternType
+	})
+
+	return res
 }
 
 func aclResourceTypeToDisplayname(resourceType sarama.AclResourceType) string {
@@ -72,6 +102,23 @@ func aclResourceTypeToDisplayname(resourceType sarama.AclResourceType) string {
 	}
 }
 
+func aclResourcePatternTypeToDisplayname(patternType sarama.AclResourcePatternType) string {
+	switch patternType {
+	case sarama.AclPatternUnknown:
+		return "UNKNOWN"
+	case sarama.AclPatternAny:
+		return "ANY"
+	case sarama.AclPatternMatch:
+		return "MATCH"
+	case sarama.AclPatternLiteral:
+		return "LITERAL"
+	case sarama.AclPatternPrefixed:
+		return "PREFIXED"
+	default:
+		return "NOT_IDENTIFIED_IN_KOWL"
+	}
+}
+
 func aclOperationToDisplayName(operation sarama.AclOperation) string {
 	switch operation {
 	case sarama.AclOperationUnknown:
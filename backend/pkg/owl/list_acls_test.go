@@ -0,0 +1,40 @@
+package owl
+
+import (
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertACLResources(t *testing.T) {
+	responses := []sarama.ResourceAcls{
+		{
+			Resource: sarama.Resource{ResourceType: sarama.AclResourceTopic, ResourceName: "orders-", ResourcePatternType: sarama.AclPatternPrefixed},
+			Acls: []*sarama.Acl{
+				{Principal: "User:bob", Host: "*", Operation: sarama.AclOperationWrite, PermissionType: sarama.AclPermissionAllow},
+				{Principal: "User:alice", Host: "*", Operation: sarama.AclOperationRead, PermissionType: sarama.AclPermissionDeny},
+			},
+		},
+		{
+			Resource: sarama.Resource{ResourceType: sarama.AclResourceGroup, ResourceName: "billing", ResourcePatternType: sarama.AclPatternLiteral},
+			Acls: []*sarama.Acl{
+				{Principal: "User:alice", Host: "10.0.0.1", Operation: sarama.AclOperationDescribe, PermissionType: sarama.AclPermissionAllow},
+			},
+		},
+	}
+
+	res := convertACLResources(responses)
+	require.Len(t, res, 2)
+
+	assert.Equal(t, "GROUP", res[0].ResourceType)
+	assert.Equal(t, "LITERAL", res[0].ResourcePatternType)
+
+	assert.Equal(t, "TOPIC", res[1].ResourceType)
+	assert.Equal(t, "orders-", res[1].ResourceName)
+	assert.Equal(t, "PREFIXED", res[1].ResourcePatternType)
+	require.Len(t, res[1].ACLs, 2)
+	assert.Equal(t, &AclRule{Principal: "User:alice", Host: "*", Operation: "READ", PermissionType: "DENY"}, res[1].ACLs[0])
+	assert.Equal(t, "User:bob", res[1].ACLs[1].Principal)
+}
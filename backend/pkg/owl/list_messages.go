@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/cloudhut/kowl/backend/pkg/kafka"
 	"math"
+	"sync/atomic"
 	"time"
 
 	"github.com/Shopify/sarama"
@@ -27,10 +28,21 @@ const (
 // ListMessageRequest carries all filter, sort and cancellation options for fetching messages from Kafka
 type ListMessageRequest struct {
 	TopicName             string
-	PartitionID           int32 // -1 for all partitions
-	StartOffset           int64 // -1 for recent (high - n), -2 for oldest offset, -3 for newest offset
+	PartitionID           int32   // -1 for all partitions
+	PartitionIDs          []int32 // Takes precedence over PartitionID if set
+	StartOffset           int64   // -1 for recent (high - n), -2 for oldest offset, -3 for newest offset
 	MessageCount          uint16
 	FilterInterpreterCode string
+
+	// HeaderFilter only returns messages which have a matching header
+	HeaderFilter kafka.HeaderFilter
+
+	// DeserializeHeaders decodes the header values with the topic's decoder chain, otherwise they are returned as binary
+	DeserializeHeaders bool
+
+	// MaxDuration bounds the total consumption time. Once it has been exceeded, consuming stops and the request is
+	// completed with the messages which have been found so far (partial result). Zero means no limit.
+	MaxDuration time.Duration
 }
 
 // ListMessageResponse returns the requested kafka messages along with some metadata about the operation
@@ -48,11 +60,21 @@ func (s *Service) ListMessages(ctx context.Context, listReq ListMessageRequest,
 	start := time.Now()
 	logger := s.logger.With(zap.String("topic", listReq.TopicName))
 
+	ctx, done, err := s.kafkaSvc.TrackOperation(ctx)
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	if err := s.kafkaSvc.CheckHeaderFilter(listReq.TopicName, listReq.HeaderFilter); err != nil {
+		return err
+	}
+
 	progress.OnPhase("Create Topic Consumer")
 	// We must create a new Consumer for every request,
 	// because each consumer can only consume every topic+partition once at the same time
 	// which means that concurrent requests will not work with one shared Consumer
-	consumer, err := sarama.NewConsumerFromClient(s.kafkaSvc.Client)
+	consumer, err := s.kafkaSvc.NewConsumer(ctx)
 	if err != nil {
 		return fmt.Errorf("couldn't create consumer: %w", err)
 	}
@@ -64,26 +86,18 @@ func (s *Service) ListMessages(ctx context.Context, listReq ListMessageRequest,
 	}()
 
 	progress.OnPhase("Get Partitions")
-	// Create array of partitionIDs which shall be consumed (always do that to ensure the topic exists at all)
-	partitions, err := s.kafkaSvc.ListPartitions(listReq.TopicName)
+	// Check that the requested partitions exist (always do that to ensure the topic exists at all)
+	requestedPartitionIDs := listReq.PartitionIDs
+	if len(requestedPartitionIDs) == 0 && listReq.PartitionID != partitionsAll {
+		requestedPartitionIDs = []int32{listReq.PartitionID}
+	}
+	partitionIDs, err := s.kafkaSvc.SelectPartitions(listReq.TopicName, requestedPartitionIDs)
 	if err != nil {
 		return fmt.Errorf("failed to get partitions: %w", err)
 	}
 
-	// Check if requested partitionID exists
-	if listReq.PartitionID > int32(len(partitions)) {
-		return fmt.Errorf("requested partitionID (%v) is greater than number of partitions (%v)", listReq.PartitionID, len(partitions))
-	}
-
-	partitionIDs := make([]int32, 0, len(partitions))
-	if listReq.PartitionID == partitionsAll {
-		partitionIDs = partitions
-	} else {
-		partitionIDs = append(partitionIDs, listReq.PartitionID)
-	}
-
 	progress.OnPhase("Get Watermarks")
-	marks, err := s.kafkaSvc.WaterMarks(listReq.TopicName, partitionIDs)
+	marks, err := s.kafkaSvc.WaterMarks(ctx, listReq.TopicName, partitionIDs)
 	if err != nil {
 		return fmt.Errorf("failed to get watermarks: %w", err)
 	}
@@ -91,15 +105,27 @@ func (s *Service) ListMessages(ctx context.Context, listReq ListMessageRequest,
 	progress.OnPhase("Setup consumer agents")
 
 	// Start a partition consumer for all requested partitions
-	doneCh := make(chan struct{}, len(partitions)) // shared channel where completed workers notify us that they're done
+	doneCh := make(chan struct{}, len(partitionIDs)) // shared channel where completed workers notify us that they're done
 	messageCh := make(chan *kafka.TopicMessage)
 	startedWorkers := 0
 
 	// Get partition consume request by calculating start and end offsets for each partition
 	consumeRequests := calculateConsumeRequests(&listReq, marks)
-	childCtx, cancel := context.WithCancel(ctx)
+	budgetCtx := ctx
+	if listReq.MaxDuration > 0 {
+		var cancelBudget context.CancelFunc
+		budgetCtx, cancelBudget = context.WithTimeout(ctx, listReq.MaxDuration)
+		defer cancelBudget()
+	}
+	childCtx, cancel := context.WithCancel(budgetCtx)
 	defer cancel()
+	offsetsReached := make(map[int32]*int64, len(consumeRequests))
 	for _, req := range consumeRequests {
+		offsetReached := req.StartOffset
+		if offsetReached == sarama.OffsetNewest {
+			offsetReached = req.HighWaterMark
+		}
+		offsetsReached[req.PartitionID] = &offsetReached
 		pConsumer := kafka.PartitionConsumer{
 			Logger: logger.With(zap.Int32("partition_id", req.PartitionID)),
 
@@ -111,8 +137,11 @@ func (s *Service) ListMessages(ctx context.Context, listReq ListMessageRequest,
 			TopicName:             listReq.TopicName,
 			Req:                   req,
 			FilterInterpreterCode: listReq.FilterInterpreterCode,
+			HeaderFilter:          listReq.HeaderFilter,
 
-			Deserializer: &s.kafkaSvc.Deserializer,
+			Deserializer:       &s.kafkaSvc.Deserializer,
+			DeserializeHeaders: listReq.DeserializeHeaders,
+			OffsetReached:      offsetsReached[req.PartitionID],
 		}
 		startedWorkers++
 		go pConsumer.Run(childCtx)
@@ -121,10 +150,15 @@ func (s *Service) ListMessages(ctx context.Context, listReq ListMessageRequest,
 	completedWorkers := 0
 	allWorkersDone := false
 	requestCancelled := false
+	budgetExhausted := false
 
 	progress.OnPhase("Consuming messages")
 
+	// The forwarder and the workers must have stopped before the request is completed, so that no messages are reported
+	// afterwards and the reached offsets include all messages which have been sent
+	forwarderDone := make(chan struct{})
 	go func(ch <-chan *kafka.TopicMessage, req ListMessageRequest) {
+		defer close(forwarderDone)
 		messagesToFetch := req.MessageCount
 		for {
 			select {
@@ -137,7 +171,7 @@ func (s *Service) ListMessages(ctx context.Context, listReq ListMessageRequest,
 					cancel()
 					return
 				}
-			case <-ctx.Done():
+			case <-childCtx.Done():
 				return
 			}
 		}
@@ -163,7 +197,17 @@ Loop:
 			break Loop
 		}
 
-		// 3. Count completed workers
+		// 3. Time budget exhausted? The workers are stopped by the cancelled context, the messages which have been
+		// found so far have been sent already.
+		select {
+		case <-budgetCtx.Done():
+			budgetExhausted = true
+			logger.Debug("ListMessages: max duration exceeded", zap.Duration("max_duration", listReq.MaxDuration))
+			break Loop
+		default:
+		}
+
+		// 4. Count completed workers
 		keepCounting := true
 		for keepCounting {
 			select {
@@ -182,7 +226,21 @@ Loop:
 		<-time.After(50 * time.Millisecond)
 	}
 
-	progress.OnComplete(time.Since(start).Milliseconds(), requestCancelled)
+	cancel()
+	for completedWorkers < startedWorkers {
+		<-doneCh
+		completedWorkers++
+	}
+	<-forwarderDone
+
+	var reached map[int32]int64
+	if budgetExhausted {
+		reached = make(map[int32]int64, len(offsetsReached))
+		for partitionID, offset := range offsetsReached {
+			reached[partitionID] = atomic.LoadInt64(offset)
+		}
+	}
+	progress.OnComplete(time.Since(start).Milliseconds(), requestCancelled, budgetExhausted, reached)
 
 	if requestCancelled {
 		return fmt.Errorf("request was cancelled while waiting for messages from workers (probably timeout) completedWorkers=%v startedWorksers=%v", completedWorkers, startedWorkers)
@@ -197,7 +255,7 @@ Loop:
 func calculateConsumeRequests(listReq *ListMessageRequest, marks map[int32]*kafka.WaterMark) map[int32]*kafka.PartitionConsumeRequest {
 	requests := make(map[int32]*kafka.PartitionConsumeRequest, len(marks))
 
-	predictableResults := listReq.StartOffset != StartOffsetNewest && listReq.FilterInterpreterCode == ""
+	predictableResults := listReq.StartOffset != StartOffsetNewest && listReq.FilterInterpreterCode == "" && listReq.HeaderFilter.IsEmpty()
 	// Init result map
 	notInitialized := int64(-1)
 	for _, mark := range marks {
@@ -315,3 +373,149 @@ func calculateConsumeRequests(listReq *ListMessageRequest, marks map[int32]*kafk
 
 	return filteredRequests
 }
+
+// ListNewestMessagesResponse contains the newest messages of a topic across all partitions. PartitionErrors contains the
+// error of each partition which could not be consumed completely.
+type ListNewestMessagesResponse struct {
+	ElapsedMs       int64                 `json:"elapsedMs"`
+	ConsumedBytes   int64                 `json:"consumedBytes"`
+	Messages        []*kafka.TopicMessage `json:"messages"`
+	PartitionErrors map[int32]string      `json:"partitionErrors,omitempty"`
+}
+
+// ListNewestMessages returns the newest count messages across the given partitions (all if empty) of the topic, sorted
+// by timestamp.
+func (s *Service) ListNewestMessages(ctx context.Context, topicName string, partitionIDs []int32, count int64) (*ListNewestMessagesResponse, error) {
+	start := time.Now()
+
+	res, err := s.kafkaSvc.ConsumeNewestMessages(ctx, topicName, partitionIDs, count)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ListNewestMessagesResponse{
+		ElapsedMs:       time.Since(start).Milliseconds(),
+		ConsumedBytes:   res.ConsumedBytes,
+		Messages:        res.Messages,
+		PartitionErrors: partitionErrorMessages(res.PartitionErrors),
+	}, nil
+}
+
+// partitionErrorMessages returns the messages of the partition errors, it returns nil if no partition has failed
+func partitionErrorMessages(partitionErrors map[int32]error) map[int32]string {
+	if len(partitionErrors) == 0 {
+		return nil
+	}
+
+	messages := make(map[int32]string, len(partitionErrors))
+	for partitionID, err := range partitionErrors {
+		messages[partitionID] = err.Error()
+	}
+	return messages
+}
+
+// ListCompactLatestMessagesResponse contains the latest message per key within the newest messages of a topic
+type ListCompactLatestMessagesResponse struct {
+	ElapsedMs int64 `json:"elapsedMs"`
+	*kafka.CompactLatestMessages
+}
+
+// ListCompactLatestMessages consumes the newest window messages of each of the given partitions (all if empty) and
+// returns the latest message per key, as they would be retained by log compaction.
+func (s *Service) ListCompactLatestMessages(ctx context.Context, topicName string, partitionIDs []int32, window int64) (*ListCompactLatestMessagesResponse, error) {
+	start := time.Now()
+
+	res, err := s.kafkaSvc.ConsumeCompactLatest(ctx, topicName, partitionIDs, window)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ListCompactLatestMessagesResponse{
+		ElapsedMs:             time.Since(start).Milliseconds(),
+		CompactLatestMessages: res,
+	}, nil
+}
+
+// ListMessagesFromTimestampResponse contains the messages which have been produced at or after the requested
+// timestamp, along with the start offset which has been resolved for each partition. PartitionErrors contains the
+// error of each partition which could not be consumed completely.
+type ListMessagesFromTimestampResponse struct {
+	ElapsedMs       int64                 `json:"elapsedMs"`
+	ConsumedBytes   int64                 `json:"consumedBytes"`
+	ResolvedOffsets map[int32]int64       `json:"resolvedOffsets"`
+	Messages        []*kafka.TopicMessage `json:"messages"`
+	PartitionErrors map[int32]string      `json:"partitionErrors,omitempty"`
+}
+
+// ListMessagesFromTimestamp returns up to count messages across the given partitions (all if empty) which have been
+// produced at or after the given timestamp, sorted by timestamp.
+func (s *Service) ListMessagesFromTimestamp(ctx context.Context, topicName string, partitionIDs []int32, timestamp time.Time, count int64) (*ListMessagesFromTimestampResponse, error) {
+	start := time.Now()
+
+	res, err := s.kafkaSvc.ConsumeFromTimestamp(ctx, topicName, partitionIDs, timestamp, count)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ListMessagesFromTimestampResponse{
+		ElapsedMs:       time.Since(start).Milliseconds(),
+		ConsumedBytes:   res.ConsumedBytes,
+		ResolvedOffsets: res.ResolvedOffsets,
+		Messages:        res.Messages,
+		PartitionErrors: partitionErrorMessages(res.PartitionErrors),
+	}, nil
+}
+
+// ListOffsetRangeResponse contains the messages of the requested offset range of each partition, sorted by partition
+// and offset. End offsets are exclusive, clamped ranges had an end offset beyond the high watermark.
+type ListOffsetRangeResponse struct {
+	ElapsedMs     int64                 `json:"elapsedMs"`
+	ConsumedBytes int64                 `json:"consumedBytes"`
+	Ranges        []ConsumedOffsetRange `json:"ranges"`
+	Messages      []*kafka.TopicMessage `json:"messages"`
+}
+
+// ConsumedOffsetRange is the offset range which has been consumed for a partition. If Error is set, the range has not
+// been consumed completely and only the messages before the error are returned.
+type ConsumedOffsetRange struct {
+	PartitionID   int32  `json:"partitionId"`
+	StartOffset   int64  `json:"startOffset"`
+	EndOffset     int64  `json:"endOffset"`
+	LowWaterMark  int64  `json:"lowWaterMark"`
+	HighWaterMark int64  `json:"highWaterMark"`
+	IsClamped     bool   `json:"isClamped"`
+	Error         string `json:"error,omitempty"`
+}
+
+// ListMessagesInOffsetRange returns all messages within the given offset ranges along with the attributes of their
+// record batches. Control records are only returned if includeControlRecords is set.
+func (s *Service) ListMessagesInOffsetRange(ctx context.Context, topicName string, ranges []kafka.OffsetRange, includeControlRecords bool) (*ListOffsetRangeResponse, error) {
+	start := time.Now()
+
+	res, err := s.kafkaSvc.ConsumeOffsetRanges(ctx, topicName, ranges, includeControlRecords)
+	if err != nil {
+		return nil, err
+	}
+
+	consumedRanges := make([]ConsumedOffsetRange, len(res.Ranges))
+	for i, r := range res.Ranges {
+		consumedRanges[i] = ConsumedOffsetRange{
+			PartitionID:   r.PartitionID,
+			StartOffset:   r.StartOffset,
+			EndOffset:     r.EndOffset,
+			LowWaterMark:  r.LowWaterMark,
+			HighWaterMark: r.HighWaterMark,
+			IsClamped:     r.IsClamped,
+		}
+		if r.Err != nil {
+			consumedRanges[i].Error = r.Err.Error()
+		}
+	}
+
+	return &ListOffsetRangeResponse{
+		ElapsedMs:     time.Since(start).Milliseconds(),
+		ConsumedBytes: res.ConsumedBytes,
+		Ranges:        consumedRanges,
+		Messages:      res.Messages,
+	}, nil
+}
@@ -1,15 +1,18 @@
 package owl
 
 import (
+	"context"
 	"fmt"
+	"sync"
+	"time"
 
 	"github.com/Shopify/sarama"
 )
 
 // LogDirSizeByBroker returns a map where the BrokerID is the key and the summed bytes of all log dirs of
 // the respective broker is the value.
-func (s *Service) logDirSizeByBroker() (map[int32]int64, error) {
-	responses := s.kafkaSvc.DescribeLogDirs()
+func (s *Service) logDirSizeByBroker(ctx context.Context) (map[int32]int64, error) {
+	responses := s.kafkaSvc.DescribeLogDirs(ctx)
 	errCount := 0 // todo: return and show in ui
 
 	sizeByBroker := make(map[int32]int64)
@@ -35,10 +38,48 @@ func (s *Service) logDirSizeByBroker() (map[int32]int64, error) {
 	return sizeByBroker, nil
 }
 
+// logDirSizeCache caches the log dir sizes of all topics, because describing the log dirs of all brokers is expensive
+// and the sizes are listed on every page load of the topic list
+type logDirSizeCache struct {
+	mutex       sync.Mutex
+	sizeByTopic map[string]int64
+	fetchedAt   time.Time
+}
+
+// cachedLogDirSizeByTopic returns the log dir sizes of all topics, which are cached as long as the topic metadata
+// (see kafka.Config.TopicMetadataCacheTTL)
+func (s *Service) cachedLogDirSizeByTopic(ctx context.Context) (map[string]int64, error) {
+	ttl := s.kafkaSvc.Config.TopicMetadataCacheTTL
+	if ttl <= 0 {
+		return s.logDirSizeByTopic(ctx)
+	}
+
+	c := &s.logDirSizes
+	c.mutex.Lock()
+	if c.sizeByTopic != nil && time.Since(c.fetchedAt) < ttl {
+		sizeByTopic := c.sizeByTopic
+		c.mutex.Unlock()
+		return sizeByTopic, nil
+	}
+	c.mutex.Unlock()
+
+	sizeByTopic, err := s.logDirSizeByTopic(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mutex.Lock()
+	c.sizeByTopic = sizeByTopic
+	c.fetchedAt = time.Now()
+	c.mutex.Unlock()
+
+	return sizeByTopic, nil
+}
+
 // LogDirSizeByTopic returns a map where the Topicname is the key and the summed bytes of all log dirs of
 // the respective topic is the value.
-func (s *Service) logDirSizeByTopic() (map[string]int64, error) {
-	responses := s.kafkaSvc.DescribeLogDirs()
+func (s *Service) logDirSizeByTopic(ctx context.Context) (map[string]int64, error) {
+	responses := s.kafkaSvc.DescribeLogDirs(ctx)
 	errCount := 0 // todo: return and show in ui
 
 	sizeByTopic := make(map[string]int64)
@@ -0,0 +1,60 @@
+package owl
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/cloudhut/kowl/backend/pkg/kafka"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestCachedLogDirSizeByTopic(t *testing.T) {
+	broker := sarama.NewMockBroker(t, 1)
+	defer broker.Close()
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).
+			SetController(broker.BrokerID()).
+			SetBroker(broker.Addr(), broker.BrokerID()).
+			SetLeader("orders", 0, broker.BrokerID()),
+		"DescribeLogDirsRequest": sarama.NewMockDescribeLogDirsResponse(t).
+			SetLogDirs("/data", map[string]int{"orders": 1}),
+	})
+
+	cfg := sarama.NewConfig()
+	cfg.Version = sarama.V1_0_0_0
+	client, err := sarama.NewClient([]string{broker.Addr()}, cfg)
+	require.NoError(t, err)
+	defer client.Close()
+	_, err = client.Controller()
+	require.NoError(t, err)
+
+	describeCount := func() int {
+		count := 0
+		for _, entry := range broker.History() {
+			if _, ok := entry.Request.(*sarama.DescribeLogDirsRequest); ok {
+				count++
+			}
+		}
+		return count
+	}
+
+	// The log dirs are only described once within the TTL of the topic metadata
+	kafkaSvc := &kafka.Service{Client: client, Logger: zap.NewNop(), Config: kafka.Config{TopicMetadataCacheTTL: time.Minute}}
+	svc := &Service{kafkaSvc: kafkaSvc, logger: zap.NewNop()}
+	for i := 0; i < 2; i++ {
+		sizeByTopic, err := svc.cachedLogDirSizeByTopic(context.Background())
+		require.NoError(t, err)
+		assert.Contains(t, sizeByTopic, "orders")
+	}
+	assert.Equal(t, 1, describeCount())
+
+	// A TTL of 0 disables the cache
+	kafkaSvc.Config.TopicMetadataCacheTTL = 0
+	_, err = svc.cachedLogDirSizeByTopic(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, describeCount())
+}
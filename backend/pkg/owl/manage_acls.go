@@ -0,0 +1,217 @@
+package owl
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Shopify/sarama"
+)
+
+// AclBinding is a single ACL for a resource, all enums are given by their display names (e.g. "TOPIC", "READ")
+type AclBinding struct {
+	ResourceType        string `json:"resourceType"`
+	ResourceName        string `json:"resourceName"`
+	ResourcePatternType string `json:"resourcePatternType"` // LITERAL (default) or PREFIXED
+	Principal           string `json:"principal"`
+	Host                string `json:"host"` // Defaults to "*"
+	Operation           string `json:"operation"`
+	PermissionType      string `json:"permissionType"`
+}
+
+// ToSarama validates the binding and converts it into the sarama resource and ACL
+func (a *AclBinding) ToSarama() (sarama.Resource, sarama.Acl, error) {
+	resourceType, err := parseAclResourceType(a.ResourceType)
+	if err != nil {
+		return sarama.Resource{}, sarama.Acl{}, err
+	}
+	if resourceType == sarama.AclResourceAny {
+		return sarama.Resource{}, sarama.Acl{}, fmt.Errorf("resource type must be a specific resource type")
+	}
+	if a.ResourceName == "" {
+		return sarama.Resource{}, sarama.Acl{}, fmt.Errorf("resource name must be set")
+	}
+
+	patternType := sarama.AclPatternLiteral
+	if a.ResourcePatternType != "" {
+		patternType, err = parseAclResourcePatternType(a.ResourcePatternType)
+		if err != nil {
+			return sarama.Resource{}, sarama.Acl{}, err
+		}
+	}
+	if patternType != sarama.AclPatternLiteral && patternType != sarama.AclPatternPrefixed {
+		return sarama.Resource{}, sarama.Acl{}, fmt.Errorf("resource pattern type must be LITERAL or PREFIXED")
+	}
+
+	if err := validatePrincipal(a.Principal); err != nil {
+		return sarama.Resource{}, sarama.Acl{}, err
+	}
+	host := a.Host
+	if host == "" {
+		host = "*"
+	}
+
+	operation, err := parseAclOperation(a.Operation)
+	if err != nil {
+		return sarama.Resource{}, sarama.Acl{}, err
+	}
+	if operation == sarama.AclOperationAny {
+		return sarama.Resource{}, sarama.Acl{}, fmt.Errorf("operation must be a specific operation")
+	}
+
+	permission, err := parseAclPermissionType(a.PermissionType)
+	if err != nil {
+		return sarama.Resource{}, sarama.Acl{}, err
+	}
+	if permission == sarama.AclPermissionAny {
+		return sarama.Resource{}, sarama.Acl{}, fmt.Errorf("permission type must be ALLOW or DENY")
+	}
+
+	resource := sarama.Resource{
+		ResourceType:        resourceType,
+		ResourceName:        a.ResourceName,
+		ResourcePatternType: patternType,
+	}
+	acl := sarama.Acl{
+		Principal:      a.Principal,
+		Host:           host,
+		Operation:      operation,
+		PermissionType: permission,
+	}
+
+	return resource, acl, nil
+}
+
+// AclBindingFilter matches ACL bindings for deletion. Enums which are not set match any value, nil strings match any
+// resource name, principal or host. A filter must either set the resource name or the principal, or it must confirm
+// that it may delete the ACLs of all resources and principals.
+type AclBindingFilter struct {
+	ResourceType        string  `json:"resourceType"`
+	ResourceName        *string `json:"resourceName"`
+	ResourcePatternType string  `json:"resourcePatternType"`
+	Principal           *string `json:"principal"`
+	Host                *string `json:"host"`
+	Operation           string  `json:"operation"`
+	PermissionType      string  `json:"permissionType"`
+	ConfirmDeleteAll    bool    `json:"confirmDeleteAll"`
+}
+
+// ToSarama validates the filter and converts it into a sarama ACL filter
+func (a *AclBindingFilter) ToSarama() (sarama.AclFilter, error) {
+	if a.ResourceName == nil && a.Principal == nil && !a.ConfirmDeleteAll {
+		return sarama.AclFilter{}, fmt.Errorf("either resource name or principal must be set, " +
+			"set confirmDeleteAll to delete the ACLs of all resources and principals")
+	}
+	filter := sarama.AclFilter{
+		ResourceType:              sarama.AclResourceAny,
+		ResourceName:              a.ResourceName,
+		ResourcePatternTypeFilter: sarama.AclPatternAny,
+		Principal:                 a.Principal,
+		Host:                      a.Host,
+		Operation:                 sarama.AclOperationAny,
+		PermissionType:            sarama.AclPermissionAny,
+	}
+
+	var err error
+	if a.ResourceType != "" {
+		if filter.ResourceType, err = parseAclResourceType(a.ResourceType); err != nil {
+			return sarama.AclFilter{}, err
+		}
+	}
+	if a.ResourcePatternType != "" {
+		if filter.ResourcePatternTypeFilter, err = parseAclResourcePatternType(a.ResourcePatternType); err != nil {
+			return sarama.AclFilter{}, err
+		}
+	}
+	if a.Principal != nil {
+		if err := validatePrincipal(*a.Principal); err != nil {
+			return sarama.AclFilter{}, err
+		}
+	}
+	if a.Operation != "" {
+		if filter.Operation, err = parseAclOperation(a.Operation); err != nil {
+			return sarama.AclFilter{}, err
+		}
+	}
+	if a.PermissionType != "" {
+		if filter.PermissionType, err = parseAclPermissionType(a.PermissionType); err != nil {
+			return sarama.AclFilter{}, err
+		}
+	}
+
+	return filter, nil
+}
+
+// CreateACL creates the given ACL binding
+func (s *Service) CreateACL(ctx context.Context, binding AclBinding) error {
+	resource, acl, err := binding.ToSarama()
+	if err != nil {
+		return err
+	}
+
+	return s.kafkaSvc.CreateACL(ctx, resource, acl)
+}
+
+// DeleteACLs deletes all ACL bindings which match the filter and returns the number of deleted bindings
+func (s *Service) DeleteACLs(ctx context.Context, bindingFilter AclBindingFilter) (int, error) {
+	filter, err := bindingFilter.ToSarama()
+	if err != nil {
+		return 0, err
+	}
+
+	deleted, err := s.kafkaSvc.DeleteACLs(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+
+	return len(deleted), nil
+}
+
+// validatePrincipal checks that the principal is given in the form "<type>:<name>", e.g. "User:alice"
+func validatePrincipal(principal string) error {
+	if principal == "" {
+		return fmt.Errorf("principal must be set")
+	}
+	parts := strings.SplitN(principal, ":", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return fmt.Errorf("principal '%v' must be given in the form '<type>:<name>', e.g. 'User:alice'", principal)
+	}
+
+	return nil
+}
+
+func parseAclResourceType(name string) (sarama.AclResourceType, error) {
+	for t := sarama.AclResourceAny; t <= sarama.AclResourceTransactionalID; t++ {
+		if aclResourceTypeToDisplayname(t) == strings.ToUpper(name) {
+			return t, nil
+		}
+	}
+	return sarama.AclResourceUnknown, fmt.Errorf("unknown resource type '%v'", name)
+}
+
+func parseAclResourcePatternType(name string) (sarama.AclResourcePatternType, error) {
+	for t := sarama.AclPatternAny; t <= sarama.AclPatternPrefixed; t++ {
+		if aclResourcePatternTypeToDisplayname(t) == strings.ToUpper(name) {
+			return t, nil
+		}
+	}
+	return sarama.AclPatternUnknown, fmt.Errorf("unknown resource pattern type '%v'", name)
+}
+
+func parseAclOperation(name string) (sarama.AclOperation, error) {
+	for o := sarama.AclOperationAny; o <= sarama.AclOperationIdempotentWrite; o++ {
+		if aclOperationToDisplayName(o) == strings.ToUpper(name) {
+			return o, nil
+		}
+	}
+	return sarama.AclOperationUnknown, fmt.Errorf("unknown operation '%v'", name)
+}
+
+func parseAclPermissionType(name string) (sarama.AclPermissionType, error) {
+	for p := sarama.AclPermissionAny; p <= sarama.AclPermissionAllow; p++ {
+		if aclPermissionToDisplayname(p) == strings.ToUpper(name) {
+			return p, nil
+		}
+	}
+	return sarama.AclPermissionUnknown, fmt.Errorf("unknown permission type '%v'", name)
+}
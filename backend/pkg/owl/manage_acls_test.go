@@ -0,0 +1,71 @@
+package owl
+
+import (
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAclBinding_ToSarama(t *testing.T) {
+	binding := AclBinding{
+		ResourceType:   "TOPIC",
+		ResourceName:   "orders",
+		Principal:      "User:alice",
+		Operation:      "read",
+		PermissionType: "ALLOW",
+	}
+	resource, acl, err := binding.ToSarama()
+	require.NoError(t, err)
+	assert.Equal(t, sarama.Resource{ResourceType: sarama.AclResourceTopic, ResourceName: "orders", ResourcePatternType: sarama.AclPatternLiteral}, resource)
+	assert.Equal(t, sarama.Acl{Principal: "User:alice", Host: "*", Operation: sarama.AclOperationRead, PermissionType: sarama.AclPermissionAllow}, acl)
+
+	invalid := binding
+	invalid.Principal = ""
+	_, _, err = invalid.ToSarama()
+	assert.Error(t, err)
+
+	invalid = binding
+	invalid.Principal = "alice"
+	_, _, err = invalid.ToSarama()
+	assert.Error(t, err)
+
+	invalid = binding
+	invalid.Operation = "READ_WRITE"
+	_, _, err = invalid.ToSarama()
+	assert.Error(t, err)
+
+	invalid = binding
+	invalid.Operation = "ANY"
+	_, _, err = invalid.ToSarama()
+	assert.Error(t, err)
+}
+
+func TestAclBindingFilter_ToSarama(t *testing.T) {
+	principal := "User:bob"
+	filter, err := (&AclBindingFilter{Principal: &principal, Operation: "WRITE"}).ToSarama()
+	require.NoError(t, err)
+	assert.Equal(t, sarama.AclResourceAny, filter.ResourceType)
+	assert.Equal(t, sarama.AclPatternAny, filter.ResourcePatternTypeFilter)
+	assert.Equal(t, sarama.AclOperationWrite, filter.Operation)
+	assert.Equal(t, sarama.AclPermissionAny, filter.PermissionType)
+	assert.Equal(t, &principal, filter.Principal)
+
+	empty := ""
+	_, err = (&AclBindingFilter{Principal: &empty}).ToSarama()
+	assert.Error(t, err)
+
+	_, err = (&AclBindingFilter{Principal: &principal, Operation: "FLY"}).ToSarama()
+	assert.Error(t, err)
+
+	// Filters which would match the ACLs of all resources and principals must be confirmed
+	_, err = (&AclBindingFilter{}).ToSarama()
+	assert.Error(t, err)
+	_, err = (&AclBindingFilter{ResourceType: "ANY", Operation: "ANY", PermissionType: "ANY"}).ToSarama()
+	assert.Error(t, err)
+	filter, err = (&AclBindingFilter{ConfirmDeleteAll: true}).ToSarama()
+	require.NoError(t, err)
+	assert.Nil(t, filter.ResourceName)
+	assert.Nil(t, filter.Principal)
+}
@@ -0,0 +1,36 @@
+package owl
+
+import (
+	"context"
+	"time"
+
+	"github.com/cloudhut/kowl/backend/pkg/kafka"
+)
+
+// MessageSizeDistribution is the distribution of the key and value sizes of the newest messages of a topic
+type MessageSizeDistribution struct {
+	TopicName string `json:"topicName"`
+
+	// SampleSize is the max number of newest messages which have been sampled per partition
+	SampleSize int64 `json:"sampleSize"`
+	ElapsedMs  int64 `json:"elapsedMs"`
+	*kafka.MessageSizeDistribution
+}
+
+// GetMessageSizeDistribution samples the newest sampleSize messages of each partition of a topic and returns the
+// percentiles of their key and value sizes
+func (s *Service) GetMessageSizeDistribution(ctx context.Context, topicName string, sampleSize int64) (*MessageSizeDistribution, error) {
+	start := time.Now()
+
+	res, err := s.kafkaSvc.GetMessageSizeDistribution(ctx, topicName, sampleSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MessageSizeDistribution{
+		TopicName:               topicName,
+		SampleSize:              sampleSize,
+		ElapsedMs:               time.Since(start).Milliseconds(),
+		MessageSizeDistribution: res,
+	}, nil
+}
@@ -0,0 +1,72 @@
+package owl
+
+import (
+	"context"
+	"github.com/cloudhut/kowl/backend/pkg/kafka"
+)
+
+// PartitionReassignments is the progress of all ongoing partition reassignments of the requested topics
+type PartitionReassignments struct {
+	// RemainingPartitions is the number of partitions whose replicas are still being moved
+	RemainingPartitions int                           `json:"remainingPartitions"`
+	Partitions          []PartitionReassignmentStatus `json:"partitions"`
+}
+
+// PartitionReassignmentStatus is the state of an ongoing reassignment of a single partition
+type PartitionReassignmentStatus struct {
+	TopicName        string  `json:"topicName"`
+	PartitionID      int32   `json:"partitionId"`
+	Replicas         []int32 `json:"replicas"`
+	AddingReplicas   []int32 `json:"addingReplicas"`
+	RemovingReplicas []int32 `json:"removingReplicas"`
+}
+
+// ReassignPartitions submits the partition reassignments and returns the initial progress of the affected topics
+func (s *Service) ReassignPartitions(ctx context.Context, reassignments map[string][]kafka.PartitionReassignment) (*PartitionReassignments, error) {
+	err := s.kafkaSvc.ReassignPartitions(ctx, reassignments)
+	if err != nil {
+		return nil, err
+	}
+
+	topicNames := make([]string, 0, len(reassignments))
+	for topicName := range reassignments {
+		topicNames = append(topicNames, topicName)
+	}
+
+	return s.ListPartitionReassignments(ctx, topicNames)
+}
+
+// ListPartitionReassignments returns the ongoing partition reassignments of the given topics. If no topics are given
+// the reassignments of all topics are returned.
+func (s *Service) ListPartitionReassignments(ctx context.Context, topicNames []string) (*PartitionReassignments, error) {
+	if len(topicNames) == 0 {
+		topics, err := s.kafkaSvc.ListTopics(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, topic := range topics {
+			topicNames = append(topicNames, topic.Name)
+		}
+	}
+
+	statuses, err := s.kafkaSvc.ListPartitionReassignments(ctx, topicNames)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &PartitionReassignments{
+		RemainingPartitions: len(statuses),
+		Partitions:          make([]PartitionReassignmentStatus, len(statuses)),
+	}
+	for i, status := range statuses {
+		res.Partitions[i] = PartitionReassignmentStatus{
+			TopicName:        status.TopicName,
+			PartitionID:      status.PartitionID,
+			Replicas:         status.Replicas,
+			AddingReplicas:   status.AddingReplicas,
+			RemovingReplicas: status.RemovingReplicas,
+		}
+	}
+
+	return res, nil
+}
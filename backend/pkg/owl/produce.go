@@ -0,0 +1,106 @@
+package owl
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Shopify/sarama"
+	"github.com/cloudhut/kowl/backend/pkg/kafka"
+	"go.uber.org/zap"
+)
+
+// PayloadEncoding describes how the key, value and headers of a message which shall be produced are encoded in the
+// request
+type PayloadEncoding string
+
+const (
+	// PayloadEncodingRaw sends the bytes of the given string as is
+	PayloadEncodingRaw PayloadEncoding = "raw"
+	// PayloadEncodingJSON validates that the given string is valid JSON
+	PayloadEncodingJSON PayloadEncoding = "json"
+	// PayloadEncodingBase64 decodes the given string as base64, so that arbitrary bytes can be produced
+	PayloadEncodingBase64 PayloadEncoding = "base64"
+)
+
+// ProduceMessageRequest is a single message which shall be produced
+type ProduceMessageRequest struct {
+	TopicName   string
+	PartitionID *int32
+	Key         *string
+	KeyEncoding PayloadEncoding
+	Headers     map[string]string // Header values are always sent as raw strings
+	Value       string
+	Encoding    PayloadEncoding
+	Compression string // Empty uses the configured default compression
+}
+
+// ProduceMessageResponse is the partition and offset the message has been written to
+type ProduceMessageResponse struct {
+	TopicName   string `json:"topicName"`
+	PartitionID int32  `json:"partitionId"`
+	Offset      int64  `json:"offset"`
+}
+
+// ProduceMessage decodes the message's key and value and produces it to the given topic
+func (s *Service) ProduceMessage(ctx context.Context, req ProduceMessageRequest) (*ProduceMessageResponse, error) {
+	value, err := decodePayload(req.Value, req.Encoding)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode value: %w", err)
+	}
+	var key []byte
+	if req.Key != nil {
+		key, err = decodePayload(*req.Key, req.KeyEncoding)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode key: %w", err)
+		}
+	}
+	headers := make([]sarama.RecordHeader, 0, len(req.Headers))
+	for k, v := range req.Headers {
+		headers = append(headers, sarama.RecordHeader{Key: []byte(k), Value: []byte(v)})
+	}
+
+	res, err := s.kafkaSvc.ProduceMessage(ctx, kafka.ProduceMessageRequest{
+		TopicName:   req.TopicName,
+		PartitionID: req.PartitionID,
+		Key:         key,
+		Headers:     headers,
+		Value:       value,
+		Compression: req.Compression,
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.logger.Info("produced message",
+		zap.String("topic_name", req.TopicName),
+		zap.Int32("partition_id", res.PartitionID),
+		zap.Int64("offset", res.Offset))
+
+	return &ProduceMessageResponse{
+		TopicName:   req.TopicName,
+		PartitionID: res.PartitionID,
+		Offset:      res.Offset,
+	}, nil
+}
+
+// decodePayload returns the bytes of the payload in the given encoding. An empty encoding defaults to raw.
+func decodePayload(payload string, encoding PayloadEncoding) ([]byte, error) {
+	switch encoding {
+	case "", PayloadEncodingRaw:
+		return []byte(payload), nil
+	case PayloadEncodingJSON:
+		if !json.Valid([]byte(payload)) {
+			return nil, fmt.Errorf("%w: payload is not valid JSON", ErrInvalidPayload)
+		}
+		return []byte(payload), nil
+	case PayloadEncodingBase64:
+		decoded, err := base64.StdEncoding.DecodeString(payload)
+		if err != nil {
+			return nil, fmt.Errorf("%w: payload is not valid base64: %v", ErrInvalidPayload, err)
+		}
+		return decoded, nil
+	default:
+		return nil, fmt.Errorf("%w: unknown encoding '%v'", ErrInvalidPayload, encoding)
+	}
+}
@@ -0,0 +1,31 @@
+package owl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodePayload(t *testing.T) {
+	decoded, err := decodePayload("hello", "")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("hello"), decoded)
+
+	decoded, err = decodePayload(`{"id": 1}`, PayloadEncodingJSON)
+	require.NoError(t, err)
+	assert.Equal(t, []byte(`{"id": 1}`), decoded)
+
+	_, err = decodePayload(`{"id": `, PayloadEncodingJSON)
+	assert.Error(t, err)
+
+	decoded, err = decodePayload("AAEC/w==", PayloadEncodingBase64)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x00, 0x01, 0x02, 0xff}, decoded)
+
+	_, err = decodePayload("not base64!", PayloadEncodingBase64)
+	assert.Error(t, err)
+
+	_, err = decodePayload("hello", "avro")
+	assert.Error(t, err)
+}
@@ -0,0 +1,41 @@
+package owl
+
+import (
+	"context"
+
+	"github.com/cloudhut/kowl/backend/pkg/kafka"
+	"go.uber.org/zap"
+)
+
+// ConsumerGroupOffsetChange is the committed offset of a partition before and after an offset reset
+type ConsumerGroupOffsetChange struct {
+	TopicName   string `json:"topicName"`
+	PartitionID int32  `json:"partitionId"`
+	Before      int64  `json:"before"` // -1 if no offset had been committed
+	After       int64  `json:"after"`
+}
+
+// ResetConsumerGroupOffsets resets the committed offsets of a consumer group and returns the offsets of all affected
+// partitions before and after the reset.
+func (s *Service) ResetConsumerGroupOffsets(ctx context.Context, req kafka.ResetConsumerGroupOffsetsRequest) ([]ConsumerGroupOffsetChange, error) {
+	changes, err := s.kafkaSvc.ResetConsumerGroupOffsets(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	s.logger.Info("reset consumer group offsets",
+		zap.String("group", req.GroupID),
+		zap.String("strategy", string(req.Strategy)),
+		zap.Int("partitions", len(changes)))
+
+	res := make([]ConsumerGroupOffsetChange, len(changes))
+	for i, change := range changes {
+		res[i] = ConsumerGroupOffsetChange{
+			TopicName:   change.TopicName,
+			PartitionID: change.PartitionID,
+			Before:      change.Before,
+			After:       change.After,
+		}
+	}
+
+	return res, nil
+}
@@ -19,12 +19,12 @@ func (s *Service) GetSchemaDetails(_ context.Context, subject string, version st
 		return nil, ErrSchemaRegistryNotConfigured
 	}
 
-	versions, err := s.kafkaSvc.SchemaService.GetSubjectVersions(subject)
+	versions, err := s.kafkaSvc.SchemaService.GetSubjectVersions(subject, false)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get versions for given subject: %w", err)
 	}
 
-	versionedSchema, err := s.kafkaSvc.SchemaService.GetSchemaBySubject(subject, version)
+	versionedSchema, err := s.kafkaSvc.SchemaService.GetSchemaBySubject(subject, version, false)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get versioned schema for given subject: %w", err)
 	}
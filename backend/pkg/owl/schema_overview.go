@@ -79,7 +79,7 @@ func (s *Service) GetSchemaOverview(ctx context.Context) (*SchemaOverview, error
 	})
 
 	g.Go(func() error {
-		res, err := s.kafkaSvc.SchemaService.GetSubjects()
+		res, err := s.kafkaSvc.SchemaService.GetSubjects(false)
 		if err != nil {
 			return fmt.Errorf("failed to get subjects: %w", err)
 		}
@@ -154,7 +154,7 @@ func (s *Service) getSchemaSubjects(subjects []string) []SchemaSubject {
 		wg.Add(1)
 		go func(subject string) {
 			defer wg.Done()
-			subRes, err := s.kafkaSvc.SchemaService.GetSubjectVersions(subject)
+			subRes, err := s.kafkaSvc.SchemaService.GetSubjectVersions(subject, false)
 			mutex.Lock()
 			defer mutex.Unlock()
 
@@ -0,0 +1,109 @@
+package owl
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cloudhut/kowl/backend/pkg/schema"
+)
+
+// compatibilityDefault is returned by the schema registry if a subject has no compatibility level of its own. It can
+// be set in order to reset the subject to the global compatibility level.
+const compatibilityDefault = "DEFAULT"
+
+// compatibilityLevels are the compatibility levels which can be set for a subject
+var compatibilityLevels = map[string]bool{
+	"BACKWARD":            true,
+	"BACKWARD_TRANSITIVE": true,
+	"FORWARD":             true,
+	"FORWARD_TRANSITIVE":  true,
+	"FULL":                true,
+	"FULL_TRANSITIVE":     true,
+	"NONE":                true,
+	compatibilityDefault:  true,
+}
+
+// SchemaCompatibility is the result of checking a candidate schema against the latest version of a subject
+type SchemaCompatibility struct {
+	IsCompatible bool     `json:"isCompatible"`
+	Messages     []string `json:"messages"`
+
+	// IsNewSubject is true if the subject has no versions yet, any schema is compatible in that case
+	IsNewSubject bool `json:"isNewSubject"`
+}
+
+// SubjectCompatibility is the compatibility level which applies to a subject. IsSubjectLevel is false if the subject
+// has no compatibility level of its own and the global compatibility level applies.
+type SubjectCompatibility struct {
+	Subject            string `json:"subject"`
+	CompatibilityLevel string `json:"compatibilityLevel"`
+	IsSubjectLevel     bool   `json:"isSubjectLevel"`
+}
+
+// CheckSchemaCompatibility checks whether the candidate schema could be registered as new version of the subject
+func (s *Service) CheckSchemaCompatibility(_ context.Context, subject string, req schema.CompatibilityCheckRequest) (*SchemaCompatibility, error) {
+	if s.kafkaSvc.SchemaService == nil {
+		return nil, ErrSchemaRegistryNotConfigured
+	}
+
+	res, err := s.kafkaSvc.SchemaService.CheckCompatibility(subject, "latest", req)
+	if err != nil {
+		// The registry returns 404 for subjects without versions, the first version is compatible by definition
+		if schema.IsSubjectNotFound(err) || schema.IsVersionNotFound(err) {
+			return &SchemaCompatibility{IsCompatible: true, Messages: []string{}, IsNewSubject: true}, nil
+		}
+		return nil, fmt.Errorf("failed to check schema compatibility: %w", err)
+	}
+
+	messages := res.Messages
+	if messages == nil {
+		messages = []string{}
+	}
+	return &SchemaCompatibility{IsCompatible: res.IsCompatible, Messages: messages}, nil
+}
+
+// GetSubjectCompatibility returns the compatibility level which applies to the subject
+func (s *Service) GetSubjectCompatibility(_ context.Context, subject string) (*SubjectCompatibility, error) {
+	if s.kafkaSvc.SchemaService == nil {
+		return nil, ErrSchemaRegistryNotConfigured
+	}
+
+	subjectCfg, err := s.kafkaSvc.SchemaService.GetSubjectConfig(subject)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get subject compatibility level: %w", err)
+	}
+	if subjectCfg.Compatibility != compatibilityDefault {
+		return &SubjectCompatibility{Subject: subject, CompatibilityLevel: subjectCfg.Compatibility, IsSubjectLevel: true}, nil
+	}
+
+	globalCfg, err := s.kafkaSvc.SchemaService.GetConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get global compatibility level: %w", err)
+	}
+	return &SubjectCompatibility{Subject: subject, CompatibilityLevel: globalCfg.Compatibility, IsSubjectLevel: false}, nil
+}
+
+// SetSubjectCompatibility sets the compatibility level of the subject. DEFAULT deletes the compatibility level of the
+// subject, so that the global compatibility level applies.
+func (s *Service) SetSubjectCompatibility(ctx context.Context, subject string, compatibilityLevel string) (*SubjectCompatibility, error) {
+	if s.kafkaSvc.SchemaService == nil {
+		return nil, ErrSchemaRegistryNotConfigured
+	}
+	if !compatibilityLevels[compatibilityLevel] {
+		return nil, fmt.Errorf("%w: '%v' is unknown", ErrInvalidCompatibilityLevel, compatibilityLevel)
+	}
+
+	if compatibilityLevel == compatibilityDefault {
+		err := s.kafkaSvc.SchemaService.DeleteSubjectConfig(subject)
+		if err != nil && !schema.IsSubjectNotFound(err) {
+			return nil, fmt.Errorf("failed to delete subject compatibility level: %w", err)
+		}
+		return s.GetSubjectCompatibility(ctx, subject)
+	}
+
+	res, err := s.kafkaSvc.SchemaService.PutSubjectConfig(subject, compatibilityLevel)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set subject compatibility level: %w", err)
+	}
+	return &SubjectCompatibility{Subject: subject, CompatibilityLevel: res.Compatibility, IsSubjectLevel: true}, nil
+}
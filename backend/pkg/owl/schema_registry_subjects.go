@@ -0,0 +1,134 @@
+package owl
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/cloudhut/kowl/backend/pkg/schema"
+)
+
+// SchemaRegistrySubject is a subject of the schema registry. IsSoftDeleted can only be true if soft deleted subjects
+// have been requested.
+type SchemaRegistrySubject struct {
+	Name          string `json:"name"`
+	IsSoftDeleted bool   `json:"isSoftDeleted"`
+}
+
+// SchemaRegistrySubjectVersion is a registered version of a subject
+type SchemaRegistrySubjectVersion struct {
+	Version       int  `json:"version"`
+	IsSoftDeleted bool `json:"isSoftDeleted"`
+}
+
+// SchemaRegistrySchema is a single version of a subject along with its schema
+type SchemaRegistrySchema struct {
+	Subject    string                   `json:"subject"`
+	Version    int                      `json:"version"`
+	SchemaID   int                      `json:"schemaId"`
+	Type       string                   `json:"type"` // AVRO, PROTOBUF or JSON
+	Schema     string                   `json:"schema"`
+	References []schema.SchemaReference `json:"references"`
+}
+
+// ListSchemaRegistrySubjects returns all subjects of the schema registry, including the soft deleted subjects if
+// deleted is true
+func (s *Service) ListSchemaRegistrySubjects(_ context.Context, deleted bool) ([]SchemaRegistrySubject, error) {
+	if s.kafkaSvc.SchemaService == nil {
+		return nil, ErrSchemaRegistryNotConfigured
+	}
+
+	subjects, err := s.kafkaSvc.SchemaService.GetSubjects(false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get subjects: %w", err)
+	}
+	allSubjects := subjects
+	if deleted {
+		// The registry doesn't flag soft deleted subjects, they are the difference between both lists
+		allSubjects, err = s.kafkaSvc.SchemaService.GetSubjects(true)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get subjects including soft deleted ones: %w", err)
+		}
+	}
+
+	isActive := make(map[string]bool, len(subjects.Subjects))
+	for _, subject := range subjects.Subjects {
+		isActive[subject] = true
+	}
+	res := make([]SchemaRegistrySubject, len(allSubjects.Subjects))
+	for i, subject := range allSubjects.Subjects {
+		res[i] = SchemaRegistrySubject{Name: subject, IsSoftDeleted: !isActive[subject]}
+	}
+
+	return res, nil
+}
+
+// ListSchemaRegistrySubjectVersions returns the registered versions of a subject, including the soft deleted versions
+// if deleted is true
+func (s *Service) ListSchemaRegistrySubjectVersions(_ context.Context, subject string, deleted bool) ([]SchemaRegistrySubjectVersion, error) {
+	if s.kafkaSvc.SchemaService == nil {
+		return nil, ErrSchemaRegistryNotConfigured
+	}
+
+	var activeVersions []int
+	versions, err := s.kafkaSvc.SchemaService.GetSubjectVersions(subject, false)
+	switch {
+	case err == nil:
+		activeVersions = versions.Versions
+	case deleted && schema.IsSubjectNotFound(err):
+		// All versions of a soft deleted subject are soft deleted
+	default:
+		return nil, fmt.Errorf("failed to get subject versions: %w", err)
+	}
+
+	allVersions := activeVersions
+	if deleted {
+		versions, err = s.kafkaSvc.SchemaService.GetSubjectVersions(subject, true)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get subject versions including soft deleted ones: %w", err)
+		}
+		allVersions = versions.Versions
+	}
+
+	isActive := make(map[int]bool, len(activeVersions))
+	for _, version := range activeVersions {
+		isActive[version] = true
+	}
+	res := make([]SchemaRegistrySubjectVersion, len(allVersions))
+	for i, version := range allVersions {
+		res[i] = SchemaRegistrySubjectVersion{Version: version, IsSoftDeleted: !isActive[version]}
+	}
+
+	return res, nil
+}
+
+// GetSchemaRegistrySchema returns a single version of a subject. Version is either a version number or "latest". Soft
+// deleted versions can only be returned if deleted is true.
+func (s *Service) GetSchemaRegistrySchema(_ context.Context, subject string, version string, deleted bool) (*SchemaRegistrySchema, error) {
+	if s.kafkaSvc.SchemaService == nil {
+		return nil, ErrSchemaRegistryNotConfigured
+	}
+	if version != "latest" {
+		if v, err := strconv.Atoi(version); err != nil || v <= 0 {
+			return nil, fmt.Errorf("%w: version must be a positive number or 'latest'", ErrInvalidSchemaVersion)
+		}
+	}
+
+	res, err := s.kafkaSvc.SchemaService.GetSchemaBySubject(subject, version, deleted)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get schema: %w", err)
+	}
+
+	references := res.References
+	if references == nil {
+		references = []schema.SchemaReference{}
+	}
+	return &SchemaRegistrySchema{
+		Subject:    res.Subject,
+		Version:    res.Version,
+		SchemaID:   res.SchemaID,
+		Type:       res.Type(),
+		Schema:     res.Schema,
+		References: references,
+	}, nil
+}
@@ -0,0 +1,118 @@
+package owl
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/cloudhut/kowl/backend/pkg/kafka"
+)
+
+// SearchMessagesRequest describes a paginated message search in a single partition. If ContinuationToken is set, the
+// search continues where the previous page has stopped and the offsets of the request are ignored.
+type SearchMessagesRequest struct {
+	TopicName          string
+	PartitionID        int32
+	StartOffset        int64
+	EndOffset          int64 // -1 for the latest message
+	Filter             kafka.MessageFilter
+	Limit              int
+	TimeBudget         time.Duration
+	ContinuationToken  string
+	DeserializeHeaders bool
+}
+
+// SearchMessagesResponse contains a page of matching messages. ContinuationToken is empty if the whole offset range
+// has been searched.
+type SearchMessagesResponse struct {
+	ElapsedMs         int64                 `json:"elapsedMs"`
+	ConsumedMessages  int64                 `json:"consumedMessages"`
+	ConsumedBytes     int64                 `json:"consumedBytes"`
+	IsTimedOut        bool                  `json:"isTimedOut"`
+	ContinuationToken string                `json:"continuationToken,omitempty"`
+	Messages          []*kafka.TopicMessage `json:"messages"`
+}
+
+// searchContinuationToken is the decoded continuation token which is passed to the frontend as base64 encoded JSON
+type searchContinuationToken struct {
+	TopicName   string `json:"t"`
+	PartitionID int32  `json:"p"`
+	NextOffset  int64  `json:"n"`
+	EndOffset   int64  `json:"e"`
+}
+
+func (t *searchContinuationToken) encode() string {
+	encoded, _ := json.Marshal(t)
+	return base64.RawURLEncoding.EncodeToString(encoded)
+}
+
+func decodeSearchContinuationToken(token string) (*searchContinuationToken, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode continuation token: %w", err)
+	}
+	var t searchContinuationToken
+	err = json.Unmarshal(decoded, &t)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode continuation token: %w", err)
+	}
+
+	return &t, nil
+}
+
+// SearchMessages searches a single partition for messages which pass the given filter and returns up to Limit matching
+// messages along with a continuation token for the next page.
+func (s *Service) SearchMessages(ctx context.Context, req SearchMessagesRequest) (*SearchMessagesResponse, error) {
+	start := time.Now()
+
+	searchReq := kafka.SearchMessagesRequest{
+		TopicName:   req.TopicName,
+		PartitionID: req.PartitionID,
+		StartOffset: req.StartOffset,
+		EndOffset:   req.EndOffset,
+		Filter:      req.Filter,
+		Limit:       req.Limit,
+		TimeBudget:  req.TimeBudget,
+
+		DeserializeHeaders: req.DeserializeHeaders,
+	}
+	if req.ContinuationToken != "" {
+		token, err := decodeSearchContinuationToken(req.ContinuationToken)
+		if err != nil {
+			return nil, err
+		}
+		if token.TopicName != req.TopicName {
+			return nil, fmt.Errorf("continuation token has been issued for a different topic")
+		}
+		searchReq.PartitionID = token.PartitionID
+		searchReq.StartOffset = token.NextOffset
+		searchReq.EndOffset = token.EndOffset
+	}
+
+	res, err := s.kafkaSvc.SearchMessages(ctx, searchReq)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &SearchMessagesResponse{
+		ElapsedMs:        time.Since(start).Milliseconds(),
+		ConsumedMessages: res.ConsumedMessages,
+		ConsumedBytes:    res.ConsumedBytes,
+		IsTimedOut:       res.IsTimedOut,
+		Messages:         res.Messages,
+	}
+	if !res.IsExhausted {
+		// The end offset is pinned, so that all pages of a search cover the same offset range
+		token := searchContinuationToken{
+			TopicName:   req.TopicName,
+			PartitionID: searchReq.PartitionID,
+			NextOffset:  res.NextOffset,
+			EndOffset:   res.EndOffset,
+		}
+		response.ContinuationToken = token.encode()
+	}
+
+	return response, nil
+}
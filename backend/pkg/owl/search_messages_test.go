@@ -0,0 +1,19 @@
+package owl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchContinuationToken(t *testing.T) {
+	token := searchContinuationToken{TopicName: "orders", PartitionID: 3, NextOffset: 1500, EndOffset: 9999}
+
+	decoded, err := decodeSearchContinuationToken(token.encode())
+	require.NoError(t, err)
+	assert.Equal(t, token, *decoded)
+
+	_, err = decodeSearchContinuationToken("not a token!")
+	assert.Error(t, err)
+}
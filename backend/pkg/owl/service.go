@@ -14,6 +14,8 @@ type Service struct {
 	kafkaSvc *kafka.Service
 	gitSvc   *git.Service // Git service can be nil if not configured
 	logger   *zap.Logger
+
+	logDirSizes logDirSizeCache
 }
 
 // NewService for the Owl package
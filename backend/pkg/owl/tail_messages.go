@@ -0,0 +1,12 @@
+package owl
+
+import (
+	"context"
+
+	"github.com/cloudhut/kowl/backend/pkg/kafka"
+)
+
+// TailMessages streams new messages of the requested partitions to the progress until the context is done
+func (s *Service) TailMessages(ctx context.Context, req kafka.TailMessagesRequest, progress kafka.ITailMessagesProgress) error {
+	return s.kafkaSvc.TailMessages(ctx, req, progress)
+}
@@ -1,6 +1,11 @@
 package owl
 
-import "go.uber.org/zap"
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+)
 
 // TopicConfigs is a TopicName along with all it's config entries
 type TopicConfigs struct {
@@ -10,9 +15,12 @@ type TopicConfigs struct {
 
 // TopicConfigEntry is a key value pair of a config property with it's value
 type TopicConfigEntry struct {
-	Name      string `json:"name"`
-	Value     string `json:"value"`
-	IsDefault bool   `json:"isDefault"`
+	Name        string `json:"name"`
+	Value       string `json:"value"` // Sensitive values are redacted
+	IsDefault   bool   `json:"isDefault"`
+	Source      string `json:"source"`
+	IsReadOnly  bool   `json:"isReadOnly"`
+	IsSensitive bool   `json:"isSensitive"`
 }
 
 // GetConfigEntryByName returns the TopicConfigEntry for a given config name (e. g. "cleanup.policy") or nil if
@@ -28,8 +36,8 @@ func (t *TopicConfigs) GetConfigEntryByName(configName string) *TopicConfigEntry
 }
 
 // GetTopicConfigs calls GetTopicsConfigs for a single Topic and returns a single response
-func (s *Service) GetTopicConfigs(topicName string, configNames []string) (*TopicConfigs, error) {
-	response, err := s.GetTopicsConfigs([]string{topicName}, configNames)
+func (s *Service) GetTopicConfigs(ctx context.Context, topicName string, configNames []string) (*TopicConfigs, error) {
+	response, err := s.GetTopicsConfigs(ctx, []string{topicName}, configNames)
 	if err != nil {
 		return nil, err
 	}
@@ -39,8 +47,8 @@ func (s *Service) GetTopicConfigs(topicName string, configNames []string) (*Topi
 
 // GetTopicsConfigs fetches all topic config options for the given set of topic names and config names and converts
 // that information so that it is handy to use. Provide an empty array for configNames to describe all config entries.
-func (s *Service) GetTopicsConfigs(topicNames []string, configNames []string) (map[string]*TopicConfigs, error) {
-	response, err := s.kafkaSvc.DescribeTopicsConfigs(topicNames, configNames)
+func (s *Service) GetTopicsConfigs(ctx context.Context, topicNames []string, configNames []string) (map[string]*TopicConfigs, error) {
+	response, err := s.kafkaSvc.DescribeTopicsConfigs(ctx, topicNames, configNames)
 	if err != nil {
 		return nil, err
 	}
@@ -49,6 +57,7 @@ func (s *Service) GetTopicsConfigs(topicNames []string, configNames []string) (m
 	converted := make(map[string]*TopicConfigs, len(topicNames))
 	for _, res := range response.Resources {
 		if res.ErrorMsg != "" {
+			err := fmt.Errorf("failed to describe config for resource '%v': %v", res.Name, res.ErrorMsg)
 			s.logger.Error("config response resource has an error", zap.String("resource_name", res.Name), zap.Error(err))
 			return nil, err
 		}
@@ -56,9 +65,12 @@ func (s *Service) GetTopicsConfigs(topicNames []string, configNames []string) (m
 		entries := make([]*TopicConfigEntry, len(res.Configs))
 		for j, cfg := range res.Configs {
 			entries[j] = &TopicConfigEntry{
-				Name:      cfg.Name,
-				Value:     cfg.Value,
-				IsDefault: cfg.Default,
+				Name:        cfg.Name,
+				Value:       configEntryValue(cfg),
+				IsDefault:   cfg.Default,
+				Source:      configSourceName(cfg.Source),
+				IsReadOnly:  cfg.ReadOnly,
+				IsSensitive: cfg.Sensitive,
 			}
 		}
 
@@ -70,3 +82,14 @@ func (s *Service) GetTopicsConfigs(topicNames []string, configNames []string) (m
 
 	return converted, nil
 }
+
+// AlterTopicConfig changes the given config entries of a topic and returns the topic's effective config afterwards.
+// Config entries with a nil value are reset to their default value.
+func (s *Service) AlterTopicConfig(ctx context.Context, topicName string, entries map[string]*string) (*TopicConfigs, error) {
+	err := s.kafkaSvc.AlterTopicConfig(ctx, topicName, entries)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetTopicConfigs(ctx, topicName, nil)
+}
@@ -0,0 +1,117 @@
+package owl
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/Shopify/sarama"
+	"github.com/cloudhut/kowl/backend/pkg/kafka"
+)
+
+// TopicLogDirSummary is the disk usage of a topic across all brokers. Sizes include all replicas of a partition.
+type TopicLogDirSummary struct {
+	TopicName      string               `json:"topicName"`
+	TotalSizeBytes int64                `json:"totalSizeBytes"`
+	Partitions     []PartitionSize      `json:"partitions"`
+	Brokers        []BrokerTopicLogDirs `json:"brokers"`
+}
+
+// PartitionSize is the summed size of all replicas of a partition
+type PartitionSize struct {
+	PartitionID    int32 `json:"partitionId"`
+	TotalSizeBytes int64 `json:"totalSizeBytes"`
+}
+
+// BrokerTopicLogDirs is the disk usage of a topic on a single broker. If the broker did not respond, IsAvailable is
+// false and Error contains the reason.
+type BrokerTopicLogDirs struct {
+	BrokerID       int32             `json:"brokerId"`
+	IsAvailable    bool              `json:"isAvailable"`
+	Error          string            `json:"error,omitempty"`
+	TotalSizeBytes int64             `json:"totalSizeBytes"`
+	Replicas       []PartitionLogDir `json:"replicas"`
+}
+
+// PartitionLogDir is a single replica of a partition on a broker. IsFuture is true for replicas which are being
+// moved to this log dir by a reassignment.
+type PartitionLogDir struct {
+	PartitionID int32  `json:"partitionId"`
+	LogDir      string `json:"logDir"`
+	SizeBytes   int64  `json:"sizeBytes"`
+	OffsetLag   int64  `json:"offsetLag"`
+	IsFuture    bool   `json:"isFuture"`
+}
+
+// GetTopicLogDirs returns the disk usage of each partition replica of a topic along with the topic's totals
+func (s *Service) GetTopicLogDirs(ctx context.Context, topicName string) (*TopicLogDirSummary, error) {
+	partitionIDs, err := s.kafkaSvc.ListPartitions(topicName)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := s.kafkaSvc.DescribeTopicLogDirs(ctx, topicName, partitionIDs)
+	return aggregateTopicLogDirs(topicName, responses), nil
+}
+
+// aggregateTopicLogDirs converts the log dir responses of all brokers into the topic summary
+func aggregateTopicLogDirs(topicName string, responses map[int32]*kafka.LogDirResponse) *TopicLogDirSummary {
+	res := &TopicLogDirSummary{
+		TopicName:  topicName,
+		Partitions: make([]PartitionSize, 0),
+		Brokers:    make([]BrokerTopicLogDirs, 0, len(responses)),
+	}
+
+	sizeByPartition := make(map[int32]int64)
+	for brokerID, response := range responses {
+		broker := BrokerTopicLogDirs{
+			BrokerID:    brokerID,
+			IsAvailable: true,
+			Replicas:    make([]PartitionLogDir, 0),
+		}
+		if response.Err != nil {
+			broker.IsAvailable = false
+			broker.Error = response.Err.Error()
+			res.Brokers = append(res.Brokers, broker)
+			continue
+		}
+
+		for _, dir := range response.LogDirs {
+			if dir.ErrorCode != sarama.ErrNoError {
+				// A single offline log dir should not hide the replicas in the other log dirs of the broker
+				broker.Error = fmt.Sprintf("log dir '%v' has failed with error: %v", dir.Path, dir.ErrorCode.Error())
+				continue
+			}
+			for _, topic := range dir.Topics {
+				if topic.Topic != topicName {
+					continue
+				}
+				for _, partition := range topic.Partitions {
+					broker.Replicas = append(broker.Replicas, PartitionLogDir{
+						PartitionID: partition.PartitionID,
+						LogDir:      dir.Path,
+						SizeBytes:   partition.Size,
+						OffsetLag:   partition.OffsetLag,
+						IsFuture:    partition.IsTemporary,
+					})
+					broker.TotalSizeBytes += partition.Size
+					sizeByPartition[partition.PartitionID] += partition.Size
+				}
+			}
+		}
+		sort.SliceStable(broker.Replicas, func(i, j int) bool {
+			return broker.Replicas[i].PartitionID < broker.Replicas[j].PartitionID
+		})
+
+		res.TotalSizeBytes += broker.TotalSizeBytes
+		res.Brokers = append(res.Brokers, broker)
+	}
+	sort.Slice(res.Brokers, func(i, j int) bool { return res.Brokers[i].BrokerID < res.Brokers[j].BrokerID })
+
+	for partitionID, size := range sizeByPartition {
+		res.Partitions = append(res.Partitions, PartitionSize{PartitionID: partitionID, TotalSizeBytes: size})
+	}
+	sort.Slice(res.Partitions, func(i, j int) bool { return res.Partitions[i].PartitionID < res.Partitions[j].PartitionID })
+
+	return res
+}
@@ -0,0 +1,48 @@
+package owl
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/cloudhut/kowl/backend/pkg/kafka"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAggregateTopicLogDirs(t *testing.T) {
+	logDirs := func(dirs ...sarama.DescribeLogDirsResponseDirMetadata) *kafka.LogDirResponse {
+		return &kafka.LogDirResponse{DescribeLogDirsResponse: &sarama.DescribeLogDirsResponse{LogDirs: dirs}}
+	}
+	topic := func(partitions ...sarama.DescribeLogDirsResponsePartition) []sarama.DescribeLogDirsResponseTopic {
+		return []sarama.DescribeLogDirsResponseTopic{{Topic: "orders", Partitions: partitions}}
+	}
+
+	responses := map[int32]*kafka.LogDirResponse{
+		1: logDirs(sarama.DescribeLogDirsResponseDirMetadata{Path: "/data/1", Topics: topic(
+			sarama.DescribeLogDirsResponsePartition{PartitionID: 1, Size: 300},
+			sarama.DescribeLogDirsResponsePartition{PartitionID: 0, Size: 100},
+		)}),
+		2: logDirs(
+			sarama.DescribeLogDirsResponseDirMetadata{Path: "/data/1", Topics: topic(
+				sarama.DescribeLogDirsResponsePartition{PartitionID: 0, Size: 100},
+			)},
+			sarama.DescribeLogDirsResponseDirMetadata{Path: "/data/2", Topics: topic(
+				sarama.DescribeLogDirsResponsePartition{PartitionID: 0, Size: 40, IsTemporary: true},
+			)},
+		),
+		3: {Err: fmt.Errorf("connection refused")},
+	}
+
+	res := aggregateTopicLogDirs("orders", responses)
+	assert.Equal(t, int64(540), res.TotalSizeBytes)
+	assert.Equal(t, []PartitionSize{{PartitionID: 0, TotalSizeBytes: 240}, {PartitionID: 1, TotalSizeBytes: 300}}, res.Partitions)
+
+	require.Len(t, res.Brokers, 3)
+	assert.Equal(t, int64(400), res.Brokers[0].TotalSizeBytes)
+	assert.Equal(t, int32(0), res.Brokers[0].Replicas[0].PartitionID)
+	assert.True(t, res.Brokers[1].Replicas[1].IsFuture)
+	assert.Equal(t, "/data/2", res.Brokers[1].Replicas[1].LogDir)
+	assert.False(t, res.Brokers[2].IsAvailable)
+	assert.Equal(t, "connection refused", res.Brokers[2].Error)
+}
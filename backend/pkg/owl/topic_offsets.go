@@ -0,0 +1,85 @@
+package owl
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/cloudhut/kowl/backend/pkg/kafka"
+	"go.uber.org/zap"
+)
+
+// TopicOffsets are the watermarks and message counts of all partitions of a topic. MessageCount is the sum of the
+// message counts of all available partitions.
+type TopicOffsets struct {
+	TopicName    string             `json:"topicName"`
+	MessageCount int64              `json:"messageCount"`
+	Partitions   []PartitionOffsets `json:"partitions"`
+}
+
+// PartitionOffsets are the watermarks of a partition. MessageCount is the difference of the high and low watermark,
+// which is an upper bound for compacted partitions or partitions with transaction markers. LastMessageTimestamp is
+// nil if the last message could not be consumed. If the partition is leaderless or its leader did not respond,
+// IsAvailable is false and Error contains the reason.
+type PartitionOffsets struct {
+	PartitionID          int32      `json:"partitionId"`
+	IsAvailable          bool       `json:"isAvailable"`
+	Error                string     `json:"error,omitempty"`
+	WaterMarkLow         int64      `json:"waterMarkLow"`
+	WaterMarkHigh        int64      `json:"waterMarkHigh"`
+	MessageCount         int64      `json:"messageCount"`
+	LastMessageTimestamp *time.Time `json:"lastMessageTimestamp"`
+}
+
+// GetTopicOffsets returns the earliest and latest offsets of all partitions of a topic along with the timestamp of
+// each partition's last message
+func (s *Service) GetTopicOffsets(ctx context.Context, topicName string) (*TopicOffsets, error) {
+	partitionIDs, err := s.kafkaSvc.ListPartitions(topicName)
+	if err != nil {
+		return nil, err
+	}
+
+	offsets, err := s.kafkaSvc.ListPartitionOffsets(ctx, topicName, partitionIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	timestamps, err := s.kafkaSvc.LastMessageTimestamps(ctx, topicName, offsets)
+	if err != nil {
+		// The watermarks are still useful without timestamps
+		s.logger.Warn("failed to get last message timestamps of topic", zap.String("topic", topicName), zap.Error(err))
+	}
+
+	return newTopicOffsets(topicName, offsets, timestamps), nil
+}
+
+// newTopicOffsets converts the partition offsets into the topic's offsets, sorted by partition id
+func newTopicOffsets(topicName string, offsets map[int32]*kafka.PartitionOffsets, timestamps map[int32]time.Time) *TopicOffsets {
+	res := &TopicOffsets{
+		TopicName:  topicName,
+		Partitions: make([]PartitionOffsets, 0, len(offsets)),
+	}
+
+	for _, mark := range offsets {
+		partition := PartitionOffsets{PartitionID: mark.PartitionID, IsAvailable: mark.Err == nil}
+		if mark.Err != nil {
+			partition.Error = mark.Err.Error()
+			res.Partitions = append(res.Partitions, partition)
+			continue
+		}
+
+		partition.WaterMarkLow = mark.Low
+		partition.WaterMarkHigh = mark.High
+		partition.MessageCount = mark.High - mark.Low
+		if timestamp, exists := timestamps[mark.PartitionID]; exists {
+			partition.LastMessageTimestamp = &timestamp
+		}
+		res.MessageCount += partition.MessageCount
+		res.Partitions = append(res.Partitions, partition)
+	}
+	sort.Slice(res.Partitions, func(i, j int) bool {
+		return res.Partitions[i].PartitionID < res.Partitions[j].PartitionID
+	})
+
+	return res
+}
@@ -0,0 +1,37 @@
+package owl
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/cloudhut/kowl/backend/pkg/kafka"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTopicOffsets(t *testing.T) {
+	lastTimestamp := time.Unix(1605882720, 0)
+	offsets := map[int32]*kafka.PartitionOffsets{
+		2: {PartitionID: 2, Err: sarama.ErrLeaderNotAvailable},
+		0: {PartitionID: 0, Low: 100, High: 150},
+		1: {PartitionID: 1, Low: 20, High: 20},
+	}
+
+	res := newTopicOffsets("orders", offsets, map[int32]time.Time{0: lastTimestamp})
+	assert.Equal(t, "orders", res.TopicName)
+	assert.Equal(t, int64(50), res.MessageCount)
+	require.Len(t, res.Partitions, 3)
+
+	assert.True(t, res.Partitions[0].IsAvailable)
+	assert.Equal(t, int64(50), res.Partitions[0].MessageCount)
+	require.NotNil(t, res.Partitions[0].LastMessageTimestamp)
+	assert.Equal(t, lastTimestamp, *res.Partitions[0].LastMessageTimestamp)
+
+	assert.True(t, res.Partitions[1].IsAvailable)
+	assert.Equal(t, int64(0), res.Partitions[1].MessageCount)
+	assert.Nil(t, res.Partitions[1].LastMessageTimestamp)
+
+	assert.False(t, res.Partitions[2].IsAvailable)
+	assert.NotEmpty(t, res.Partitions[2].Error)
+}
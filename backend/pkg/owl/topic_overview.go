@@ -1,6 +1,7 @@
 package owl
 
 import (
+	"context"
 	"sort"
 
 	"github.com/Shopify/sarama"
@@ -20,22 +21,61 @@ type TopicOverview struct {
 	AllowedActions []string `json:"allowedActions"`
 }
 
-// GetTopicsOverview returns a TopicOverview for all Kafka Topics
-func (s *Service) GetTopicsOverview() ([]*TopicOverview, error) {
-	topics, err := s.kafkaSvc.ListTopics()
+// TopicsOverview is a page of the listed topics. TotalCount is the number of topics which match the filter across all
+// pages. IsStale is true if the topic metadata is served from an expired cache entry while it is being refreshed.
+type TopicsOverview struct {
+	Topics     []*TopicOverview
+	TotalCount int
+	IsStale    bool
+}
+
+// Fields by which the topics overview can be sorted
+const (
+	TopicsSortByName           = "name"
+	TopicsSortByPartitionCount = "partitionCount"
+	TopicsSortBySize           = "size"
+)
+
+// TopicsOverviewRequest filters, sorts and paginates the listed topics. The zero value lists all topics sorted by
+// name.
+type TopicsOverviewRequest struct {
+	// Filter returns whether a topic shall be listed. It is applied to the cached topic metadata, hence the configs of
+	// topics which are filtered out or not on the requested page are not described.
+	Filter func(topicName string) (bool, error)
+
+	SortBy     string
+	Descending bool
+
+	// Offset is the number of matching topics which are skipped, Limit is the max number of returned topics (0 for all)
+	Offset int
+	Limit  int
+}
+
+// GetTopicsOverview returns a TopicOverview for the Kafka topics which match the request
+func (s *Service) GetTopicsOverview(ctx context.Context, req TopicsOverviewRequest) (*TopicsOverview, error) {
+	metadata, err := s.kafkaSvc.ListTopicsCached(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	// 3. Get log dir sizes for each topic
-	sizeByTopic, err := s.logDirSizeByTopic()
+	// 1. Get log dir sizes for each topic
+	sizeByTopic, err := s.cachedLogDirSizeByTopic(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	// 3. Create config resources request objects for all topics
-	topicNames := make([]string, len(topics))
-	for i, topic := range topics {
+	// 2. Filter the topics and construct the TopicOverview objects from the metadata
+	res := make([]*TopicOverview, 0, len(metadata.Topics))
+	for _, topic := range metadata.Topics {
+		if req.Filter != nil {
+			isIncluded, err := req.Filter(topic.Name)
+			if err != nil {
+				return nil, err
+			}
+			if !isIncluded {
+				continue
+			}
+		}
 		if topic.Err != sarama.ErrNoError {
 			s.logger.Error("failed to get topic metadata while listing topics",
 				zap.String("topic_name", topic.Name),
@@ -43,44 +83,80 @@ func (s *Service) GetTopicsOverview() ([]*TopicOverview, error) {
 			return nil, topic.Err
 		}
 
-		topicNames[i] = topic.Name
-	}
-
-	configs, err := s.GetTopicsConfigs(topicNames, []string{"cleanup.policy"})
-	if err != nil {
-		return nil, err
-	}
-
-	// x. Merge information from all requests and construct the TopicOverview object
-	res := make([]*TopicOverview, len(topicNames))
-	for i, topic := range topics {
 		size := int64(-1)
 		if value, ok := sizeByTopic[topic.Name]; ok {
 			size = value
 		}
-
-		policy := "unknown"
-		if val, ok := configs[topic.Name]; ok {
-			entry := val.GetConfigEntryByName("cleanup.policy")
-			if entry != nil {
-				policy = entry.Value
-			}
-		}
-
-		res[i] = &TopicOverview{
+		res = append(res, &TopicOverview{
 			TopicName:         topic.Name,
 			IsInternal:        topic.IsInternal,
 			PartitionCount:    len(topic.Partitions),
 			ReplicationFactor: len(topic.Partitions[0].Replicas),
-			CleanupPolicy:     policy,
+			CleanupPolicy:     "unknown",
 			LogDirSize:        size,
+		})
+	}
+	totalCount := len(res)
+
+	// 3. Sort and paginate, ties are sorted by topic name
+	sortTopicsOverview(res, req.SortBy, req.Descending)
+	res = paginateTopicsOverview(res, req.Offset, req.Limit)
+
+	// 4. Describe the cleanup policy of the topics on the requested page
+	if len(res) == 0 {
+		return &TopicsOverview{Topics: res, TotalCount: totalCount, IsStale: metadata.IsStale}, nil
+	}
+	topicNames := make([]string, len(res))
+	for i, topic := range res {
+		topicNames[i] = topic.TopicName
+	}
+	configs, err := s.GetTopicsConfigs(ctx, topicNames, []string{"cleanup.policy"})
+	if err != nil {
+		return nil, err
+	}
+	for _, topic := range res {
+		if val, ok := configs[topic.TopicName]; ok {
+			entry := val.GetConfigEntryByName("cleanup.policy")
+			if entry != nil {
+				topic.CleanupPolicy = entry.Value
+			}
 		}
 	}
 
-	// 5. Return map as array which is sorted by topic name
-	sort.Slice(res, func(i, j int) bool {
-		return res[i].TopicName < res[j].TopicName
+	return &TopicsOverview{Topics: res, TotalCount: totalCount, IsStale: metadata.IsStale}, nil
+}
+
+// sortTopicsOverview sorts the topics by the given field, an unknown field sorts by name
+func sortTopicsOverview(topics []*TopicOverview, sortBy string, descending bool) {
+	less := func(a, b *TopicOverview) bool {
+		switch sortBy {
+		case TopicsSortByPartitionCount:
+			if a.PartitionCount != b.PartitionCount {
+				return a.PartitionCount < b.PartitionCount
+			}
+		case TopicsSortBySize:
+			if a.LogDirSize != b.LogDirSize {
+				return a.LogDirSize < b.LogDirSize
+			}
+		}
+		return a.TopicName < b.TopicName
+	}
+	sort.Slice(topics, func(i, j int) bool {
+		if descending {
+			return less(topics[j], topics[i])
+		}
+		return less(topics[i], topics[j])
 	})
+}
 
-	return res, nil
+// paginateTopicsOverview returns the topics of the page at the given offset, a limit of 0 returns all remaining topics
+func paginateTopicsOverview(topics []*TopicOverview, offset int, limit int) []*TopicOverview {
+	if offset >= len(topics) {
+		return []*TopicOverview{}
+	}
+	topics = topics[offset:]
+	if limit > 0 && limit < len(topics) {
+		topics = topics[:limit]
+	}
+	return topics
 }
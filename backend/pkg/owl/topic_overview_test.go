@@ -0,0 +1,48 @@
+package owl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSortAndPaginateTopicsOverview(t *testing.T) {
+	newTopics := func() []*TopicOverview {
+		return []*TopicOverview{
+			{TopicName: "payments", PartitionCount: 12, LogDirSize: 100},
+			{TopicName: "orders", PartitionCount: 6, LogDirSize: 300},
+			{TopicName: "audit", PartitionCount: 6, LogDirSize: -1},
+			{TopicName: "customers", PartitionCount: 1, LogDirSize: 200},
+		}
+	}
+	names := func(topics []*TopicOverview) []string {
+		res := make([]string, len(topics))
+		for i, topic := range topics {
+			res[i] = topic.TopicName
+		}
+		return res
+	}
+
+	tt := []struct {
+		sortBy     string
+		descending bool
+		expected   []string
+	}{
+		{"", false, []string{"audit", "customers", "orders", "payments"}},
+		{TopicsSortByName, true, []string{"payments", "orders", "customers", "audit"}},
+		{TopicsSortByPartitionCount, false, []string{"customers", "audit", "orders", "payments"}},
+		{TopicsSortBySize, true, []string{"orders", "customers", "payments", "audit"}},
+	}
+	for _, table := range tt {
+		topics := newTopics()
+		sortTopicsOverview(topics, table.sortBy, table.descending)
+		assert.Equal(t, table.expected, names(topics), table.sortBy)
+	}
+
+	topics := newTopics()
+	sortTopicsOverview(topics, TopicsSortByName, false)
+	assert.Equal(t, []string{"customers", "orders"}, names(paginateTopicsOverview(topics, 1, 2)))
+	assert.Equal(t, []string{"payments"}, names(paginateTopicsOverview(topics, 3, 2)))
+	assert.Len(t, paginateTopicsOverview(topics, 0, 0), 4, "limit 0 returns all topics")
+	assert.Empty(t, paginateTopicsOverview(topics, 10, 2))
+}
@@ -1,6 +1,9 @@
 package owl
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+)
 
 // TopicPartition consists of some (not all) information about a partition of a topic.
 // Only data relevant to the 'partition table' in the frontend is included.
@@ -11,14 +14,14 @@ type TopicPartition struct {
 }
 
 // ListTopicPartitions returns the partition in the topic along with their watermarks
-func (s *Service) ListTopicPartitions(topicName string) ([]TopicPartition, error) {
+func (s *Service) ListTopicPartitions(ctx context.Context, topicName string) ([]TopicPartition, error) {
 	partitions, err := s.kafkaSvc.ListPartitions(topicName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get partitions for topic '%v': %v", topicName, err)
 	}
 
 	// Get watermarks
-	waterMarks, err := s.kafkaSvc.WaterMarks(topicName, partitions)
+	waterMarks, err := s.kafkaSvc.WaterMarks(ctx, topicName, partitions)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,56 @@
+package proto
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Config for deserializing protobuf encoded messages
+type Config struct {
+	Enabled bool `yaml:"enabled"`
+
+	// FileDescriptorSetPath is the path to a binary encoded FileDescriptorSet (e.g. created via
+	// `protoc --include_imports --descriptor_set_out`). ProtoPaths are directories which are searched for .proto files.
+	// Both can be used at the same time.
+	FileDescriptorSetPath string   `yaml:"fileDescriptorSetPath"`
+	ProtoPaths            []string `yaml:"protoPaths"`
+
+	// Mappings define which message types shall be used for the keys and values of the matching topics. Messages which
+	// have been serialized with the Confluent serializer are decoded using the schema registry and do not require a
+	// mapping.
+	Mappings []ConfigTopicMapping `yaml:"mappings"`
+}
+
+// ConfigTopicMapping maps all topics whose name match the TopicName regex to the full names of the protobuf message
+// types, e.g. "mycompany.orders.v1.Order".
+type ConfigTopicMapping struct {
+	TopicName      string `yaml:"topicName"`
+	KeyProtoType   string `yaml:"keyProtoType"`
+	ValueProtoType string `yaml:"valueProtoType"`
+}
+
+// Validate protobuf config input
+func (c *Config) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+
+	if len(c.Mappings) > 0 && c.FileDescriptorSetPath == "" && len(c.ProtoPaths) == 0 {
+		return fmt.Errorf("protobuf topic mappings require a fileDescriptorSetPath or protoPaths to load the message types from")
+	}
+
+	for i, mapping := range c.Mappings {
+		if mapping.TopicName == "" {
+			return fmt.Errorf("protobuf mapping at index %d must specify a topicName", i)
+		}
+		if mapping.KeyProtoType == "" && mapping.ValueProtoType == "" {
+			return fmt.Errorf("protobuf mapping for topic '%v' must specify a keyProtoType or a valueProtoType", mapping.TopicName)
+		}
+		_, err := regexp.Compile(mapping.TopicName)
+		if err != nil {
+			return fmt.Errorf("protobuf mapping topicName '%v' is not a valid regex: %w", mapping.TopicName, err)
+		}
+	}
+
+	return nil
+}
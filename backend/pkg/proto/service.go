@@ -0,0 +1,321 @@
+package proto
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/cloudhut/kowl/backend/pkg/schema"
+	"github.com/golang/protobuf/proto"
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/desc/protoparse"
+	"github.com/jhump/protoreflect/dynamic"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// RecordType is either the key or the value of a Kafka record
+type RecordType string
+
+const (
+	RecordKey   RecordType = "key"
+	RecordValue RecordType = "value"
+)
+
+// Service resolves protobuf message types and decodes protobuf encoded payloads into JSON. Message types are resolved
+// either via the configured topic mappings or via the schema registry for payloads with a Confluent wire format header.
+type Service struct {
+	cfg       Config
+	logger    *zap.Logger
+	schemaSvc *schema.Service
+
+	mappings           []topicMapping
+	descriptorsByName  map[string]*desc.MessageDescriptor
+	registryFilesMutex sync.RWMutex
+	registryFilesByID  map[uint32]*desc.FileDescriptor
+}
+
+type topicMapping struct {
+	topicName *regexp.Regexp
+	key       *desc.MessageDescriptor
+	value     *desc.MessageDescriptor
+}
+
+// NewService loads all configured proto files and resolves the message types of the topic mappings. schemaSvc is
+// optional and only required to decode payloads which have been serialized with the Confluent serializer.
+func NewService(cfg Config, schemaSvc *schema.Service, logger *zap.Logger) (*Service, error) {
+	files, err := loadFileDescriptors(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	descriptorsByName := make(map[string]*desc.MessageDescriptor)
+	for _, file := range files {
+		for _, md := range file.GetMessageTypes() {
+			addMessageDescriptors(descriptorsByName, md)
+		}
+	}
+	logger.Info("loaded protobuf message types", zap.Int("files", len(files)), zap.Int("message_types", len(descriptorsByName)))
+
+	mappings := make([]topicMapping, len(cfg.Mappings))
+	for i, mapping := range cfg.Mappings {
+		mappings[i].topicName = regexp.MustCompile(mapping.TopicName) // Has been validated already
+		if mapping.KeyProtoType != "" {
+			md, exists := descriptorsByName[mapping.KeyProtoType]
+			if !exists {
+				return nil, fmt.Errorf("protobuf type '%v' for the keys of topic '%v' could not be found", mapping.KeyProtoType, mapping.TopicName)
+			}
+			mappings[i].key = md
+		}
+		if mapping.ValueProtoType != "" {
+			md, exists := descriptorsByName[mapping.ValueProtoType]
+			if !exists {
+				return nil, fmt.Errorf("protobuf type '%v' for the values of topic '%v' could not be found", mapping.ValueProtoType, mapping.TopicName)
+			}
+			mappings[i].value = md
+		}
+	}
+
+	return &Service{
+		cfg:               cfg,
+		logger:            logger,
+		schemaSvc:         schemaSvc,
+		mappings:          mappings,
+		descriptorsByName: descriptorsByName,
+		registryFilesByID: make(map[uint32]*desc.FileDescriptor),
+	}, nil
+}
+
+// HasMapping returns true if a message type is mapped for the given topic and record type
+func (s *Service) HasMapping(topicName string, recordType RecordType) bool {
+	return s.mappedDescriptor(topicName, recordType) != nil
+}
+
+// UnmarshalMappedPayload decodes the payload using the message type which is mapped to the given topic and returns it
+// as JSON. A Confluent wire format header is stripped if the payload can not be decoded as is.
+func (s *Service) UnmarshalMappedPayload(payload []byte, topicName string, recordType RecordType) ([]byte, error) {
+	md := s.mappedDescriptor(topicName, recordType)
+	if md == nil {
+		return nil, fmt.Errorf("no protobuf type is mapped for the %v of topic '%v'", recordType, topicName)
+	}
+
+	jsonPayload, err := unmarshalToJSON(md, payload)
+	if err == nil {
+		return jsonPayload, nil
+	}
+	_, _, message, headerErr := decodeConfluentHeader(payload)
+	if headerErr != nil {
+		return nil, err
+	}
+
+	return unmarshalToJSON(md, message)
+}
+
+// UnmarshalConfluentPayload decodes a payload with a Confluent wire format header (magic byte, schema id and message
+// indexes) using the protobuf schema from the schema registry and returns it as JSON.
+func (s *Service) UnmarshalConfluentPayload(payload []byte) ([]byte, error) {
+	if s.schemaSvc == nil {
+		return nil, fmt.Errorf("schema registry is not configured")
+	}
+
+	schemaID, indexes, message, err := decodeConfluentHeader(payload)
+	if err != nil {
+		return nil, err
+	}
+	file, err := s.getRegistryFile(schemaID)
+	if err != nil {
+		return nil, err
+	}
+	md, err := resolveMessageByIndexes(file, indexes)
+	if err != nil {
+		return nil, err
+	}
+
+	return unmarshalToJSON(md, message)
+}
+
+func (s *Service) mappedDescriptor(topicName string, recordType RecordType) *desc.MessageDescriptor {
+	for _, mapping := range s.mappings {
+		if !mapping.topicName.MatchString(topicName) {
+			continue
+		}
+		if recordType == RecordKey && mapping.key != nil {
+			return mapping.key
+		}
+		if recordType == RecordValue && mapping.value != nil {
+			return mapping.value
+		}
+	}
+
+	return nil
+}
+
+// getRegistryFile returns the compiled protobuf schema for the given schema id
+func (s *Service) getRegistryFile(schemaID uint32) (*desc.FileDescriptor, error) {
+	s.registryFilesMutex.RLock()
+	file, exists := s.registryFilesByID[schemaID]
+	s.registryFilesMutex.RUnlock()
+	if exists {
+		return file, nil
+	}
+
+	schemaRes, err := s.schemaSvc.GetSchemaByID(schemaID)
+	if err != nil {
+		return nil, err
+	}
+	if schemaRes.Type() != schema.SchemaTypeProtobuf {
+		return nil, fmt.Errorf("schema with id %d is not a protobuf schema but %v", schemaID, schemaRes.Type())
+	}
+
+	// Referenced schemas (imports) must be fetched from the registry as well
+	filename := fmt.Sprintf("schema-%d.proto", schemaID)
+	contents := map[string]string{filename: schemaRes.Schema}
+	for _, ref := range schemaRes.References {
+		refRes, err := s.schemaSvc.GetSchemaBySubject(ref.Subject, strconv.Itoa(ref.Version), false)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get referenced schema '%v': %w", ref.Name, err)
+		}
+		contents[ref.Name] = refRes.Schema
+	}
+
+	parser := protoparse.Parser{Accessor: protoparse.FileContentsFromMap(contents)}
+	files, err := parser.ParseFiles(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse protobuf schema with id %d: %w", schemaID, err)
+	}
+
+	s.registryFilesMutex.Lock()
+	s.registryFilesByID[schemaID] = files[0]
+	s.registryFilesMutex.Unlock()
+
+	return files[0], nil
+}
+
+// decodeConfluentHeader splits a payload which has been serialized with the Confluent protobuf serializer into the
+// schema id, the message indexes and the actual protobuf message. The message indexes describe the path to the message
+// type within the schema, a single zero is encoded as empty array.
+// See: https://docs.confluent.io/platform/current/schema-registry/serdes-develop/index.html#wire-format
+func decodeConfluentHeader(payload []byte) (uint32, []int, []byte, error) {
+	if len(payload) < 6 || payload[0] != byte(0) {
+		return 0, nil, nil, fmt.Errorf("payload does not start with the confluent wire format header")
+	}
+	schemaID := binary.BigEndian.Uint32(payload[1:5])
+
+	remaining := payload[5:]
+	count, n := binary.Varint(remaining)
+	if n <= 0 || count < 0 || count > int64(len(remaining)) {
+		return 0, nil, nil, fmt.Errorf("failed to decode message indexes")
+	}
+	remaining = remaining[n:]
+	if count == 0 {
+		return schemaID, []int{0}, remaining, nil
+	}
+
+	indexes := make([]int, count)
+	for i := range indexes {
+		index, n := binary.Varint(remaining)
+		if n <= 0 || index < 0 {
+			return 0, nil, nil, fmt.Errorf("failed to decode message indexes")
+		}
+		indexes[i] = int(index)
+		remaining = remaining[n:]
+	}
+
+	return schemaID, indexes, remaining, nil
+}
+
+// resolveMessageByIndexes returns the message type at the given path of message indexes, e.g. [1, 0] is the first
+// nested message type of the second message type in the file.
+func resolveMessageByIndexes(file *desc.FileDescriptor, indexes []int) (*desc.MessageDescriptor, error) {
+	candidates := file.GetMessageTypes()
+	var md *desc.MessageDescriptor
+	for _, index := range indexes {
+		if index >= len(candidates) {
+			return nil, fmt.Errorf("message index %d is out of range in file '%v'", index, file.GetName())
+		}
+		md = candidates[index]
+		candidates = md.GetNestedMessageTypes()
+	}
+	if md == nil {
+		return nil, fmt.Errorf("no message indexes given")
+	}
+
+	return md, nil
+}
+
+func unmarshalToJSON(md *desc.MessageDescriptor, payload []byte) ([]byte, error) {
+	msg := dynamic.NewMessage(md)
+	err := msg.Unmarshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal payload as '%v': %w", md.GetFullyQualifiedName(), err)
+	}
+
+	return msg.MarshalJSON()
+}
+
+func addMessageDescriptors(descriptorsByName map[string]*desc.MessageDescriptor, md *desc.MessageDescriptor) {
+	descriptorsByName[md.GetFullyQualifiedName()] = md
+	for _, nested := range md.GetNestedMessageTypes() {
+		addMessageDescriptors(descriptorsByName, nested)
+	}
+}
+
+// loadFileDescriptors loads the configured FileDescriptorSet and parses all .proto files in the configured proto paths
+func loadFileDescriptors(cfg Config) ([]*desc.FileDescriptor, error) {
+	files := make([]*desc.FileDescriptor, 0)
+
+	if cfg.FileDescriptorSetPath != "" {
+		raw, err := ioutil.ReadFile(cfg.FileDescriptorSetPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file descriptor set: %w", err)
+		}
+		var set descriptorpb.FileDescriptorSet
+		err = proto.Unmarshal(raw, &set)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal file descriptor set: %w", err)
+		}
+		filesByName, err := desc.CreateFileDescriptorsFromSet(&set)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create file descriptors from set: %w", err)
+		}
+		for _, file := range filesByName {
+			files = append(files, file)
+		}
+	}
+
+	for _, protoPath := range cfg.ProtoPaths {
+		filenames := make([]string, 0)
+		err := filepath.Walk(protoPath, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() || !strings.HasSuffix(info.Name(), ".proto") {
+				return nil
+			}
+			relPath, err := filepath.Rel(protoPath, path)
+			if err != nil {
+				return err
+			}
+			filenames = append(filenames, filepath.ToSlash(relPath))
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to find proto files in '%v': %w", protoPath, err)
+		}
+
+		parser := protoparse.Parser{ImportPaths: []string{protoPath}}
+		parsed, err := parser.ParseFiles(filenames...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse proto files in '%v': %w", protoPath, err)
+		}
+		files = append(files, parsed...)
+	}
+
+	return files, nil
+}
@@ -0,0 +1,101 @@
+package proto
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jhump/protoreflect/dynamic"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+const testProtoFile = `syntax = "proto3";
+package shop.v1;
+
+message Customer {
+  string name = 1;
+}
+
+message Order {
+  message Item {
+    string sku = 1;
+  }
+  string id = 1;
+  repeated Item items = 2;
+}
+`
+
+func newTestService(t *testing.T) *Service {
+	dir, err := ioutil.TempDir("", "kowl-proto")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "shop.proto"), []byte(testProtoFile), 0600))
+
+	cfg := Config{
+		Enabled:    true,
+		ProtoPaths: []string{dir},
+		Mappings:   []ConfigTopicMapping{{TopicName: "^orders-.*", ValueProtoType: "shop.v1.Order"}},
+	}
+	require.NoError(t, cfg.Validate())
+	svc, err := NewService(cfg, nil, zap.NewNop())
+	require.NoError(t, err)
+
+	return svc
+}
+
+func TestService_UnmarshalMappedPayload(t *testing.T) {
+	svc := newTestService(t)
+	assert.True(t, svc.HasMapping("orders-eu", RecordValue))
+	assert.False(t, svc.HasMapping("orders-eu", RecordKey))
+	assert.False(t, svc.HasMapping("customers", RecordValue))
+
+	order := dynamic.NewMessage(svc.descriptorsByName["shop.v1.Order"])
+	order.SetFieldByName("id", "order-1")
+	payload, err := order.Marshal()
+	require.NoError(t, err)
+
+	jsonPayload, err := svc.UnmarshalMappedPayload(payload, "orders-eu", RecordValue)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"id":"order-1"}`, string(jsonPayload))
+
+	// Payloads serialized with the Confluent serializer are accepted as well
+	header := []byte{0, 0, 0, 0, 42, 0}
+	jsonPayload, err = svc.UnmarshalMappedPayload(append(header, payload...), "orders-eu", RecordValue)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"id":"order-1"}`, string(jsonPayload))
+}
+
+func TestDecodeConfluentHeader(t *testing.T) {
+	svc := newTestService(t)
+	file := svc.descriptorsByName["shop.v1.Order"].GetFile()
+
+	// Message indexes [1, 0] point to shop.v1.Order.Item
+	payload := []byte{0, 0, 0, 0, 7}
+	for _, v := range []int64{2, 1, 0} {
+		buf := make([]byte, binary.MaxVarintLen64)
+		payload = append(payload, buf[:binary.PutVarint(buf, v)]...)
+	}
+	payload = append(payload, 0x0a, 0x01, 'x')
+
+	schemaID, indexes, message, err := decodeConfluentHeader(payload)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(7), schemaID)
+	assert.Equal(t, []int{1, 0}, indexes)
+	assert.Equal(t, []byte{0x0a, 0x01, 'x'}, message)
+
+	md, err := resolveMessageByIndexes(file, indexes)
+	require.NoError(t, err)
+	assert.Equal(t, "shop.v1.Order.Item", md.GetFullyQualifiedName())
+
+	// A single zero byte is a shortcut for the first message type
+	_, indexes, _, err = decodeConfluentHeader([]byte{0, 0, 0, 0, 7, 0, 0x0a})
+	require.NoError(t, err)
+	assert.Equal(t, []int{0}, indexes)
+
+	_, err = resolveMessageByIndexes(file, []int{5})
+	assert.Error(t, err)
+}
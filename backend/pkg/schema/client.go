@@ -5,6 +5,7 @@ import (
 	"crypto/x509"
 	"fmt"
 	"io/ioutil"
+	neturl "net/url"
 	"time"
 
 	"github.com/go-resty/resty/v2"
@@ -44,7 +45,8 @@ func newClient(cfg Config) (*Client, error) {
 		client = client.SetAuthToken(cfg.BearerToken)
 	}
 
-	// Use custom root ca if desired
+	// Use custom root ca and client certificate if desired
+	tlsCfg := &tls.Config{InsecureSkipVerify: cfg.TLS.InsecureSkipTLSVerify}
 	if cfg.TLS.CaFilepath != "" {
 		ca, err := ioutil.ReadFile(cfg.TLS.CaFilepath)
 		if err != nil {
@@ -52,9 +54,16 @@ func newClient(cfg Config) (*Client, error) {
 		}
 		pool := x509.NewCertPool()
 		pool.AppendCertsFromPEM(ca)
-
-		client.SetTLSClientConfig(&tls.Config{RootCAs: pool})
+		tlsCfg.RootCAs = pool
 	}
+	if cfg.TLS.CertFilepath != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.TLS.CertFilepath, cfg.TLS.KeyFilepath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate for schema registry client: %w", err)
+		}
+		tlsCfg.Certificates = []tls.Certificate{cert}
+	}
+	client.SetTLSClientConfig(tlsCfg)
 
 	return &Client{
 		cfg:    cfg,
@@ -64,6 +73,31 @@ func newClient(cfg Config) (*Client, error) {
 
 type SchemaResponse struct {
 	Schema string `json:"schema"`
+
+	// SchemaType is either AVRO, PROTOBUF or JSON. The schema registry omits the schema type for Avro schemas.
+	SchemaType string            `json:"schemaType,omitempty"`
+	References []SchemaReference `json:"references,omitempty"`
+}
+
+// SchemaReference is a reference to another schema, e.g. an imported protobuf file
+type SchemaReference struct {
+	Name    string `json:"name"`
+	Subject string `json:"subject"`
+	Version int    `json:"version"`
+}
+
+const (
+	SchemaTypeAvro     = "AVRO"
+	SchemaTypeProtobuf = "PROTOBUF"
+	SchemaTypeJSON     = "JSON"
+)
+
+// Type returns the schema type, Avro is returned if the registry did not specify a schema type.
+func (s *SchemaResponse) Type() string {
+	if s.SchemaType == "" {
+		return SchemaTypeAvro
+	}
+	return s.SchemaType
 }
 
 // GetSchemaByID returns the schema string identified by the input ID.
@@ -96,6 +130,18 @@ type SchemaVersionedResponse struct {
 	SchemaID int    `json:"id"`
 	Version  int    `json:"version"`
 	Schema   string `json:"schema"`
+
+	// SchemaType is either AVRO, PROTOBUF or JSON. The schema registry omits the schema type for Avro schemas.
+	SchemaType string            `json:"schemaType,omitempty"`
+	References []SchemaReference `json:"references,omitempty"`
+}
+
+// Type returns the schema type, Avro is returned if the registry did not specify a schema type.
+func (s *SchemaVersionedResponse) Type() string {
+	if s.SchemaType == "" {
+		return SchemaTypeAvro
+	}
+	return s.SchemaType
 }
 
 // GetSchemaByID returns the schema for the specified version of this subject. The unescaped schema only is returned.
@@ -103,9 +149,10 @@ type SchemaVersionedResponse struct {
 // version (versionId) – Version of the schema to be returned. Valid values for versionId are between [1,2^31-1] or
 // 		the string “latest”, which returns the last registered schema under the specified subject.
 //		Note that there may be a new latest schema that gets registered right after this request is served.
-func (c *Client) GetSchemaBySubject(subject string, version string) (*SchemaVersionedResponse, error) {
-	url := fmt.Sprintf("/subjects/%s/versions/%s", subject, version)
-	res, err := c.client.R().SetResult(&SchemaVersionedResponse{}).Get(url)
+// deleted (bool) - Whether soft deleted versions can be returned as well.
+func (c *Client) GetSchemaBySubject(subject string, version string, deleted bool) (*SchemaVersionedResponse, error) {
+	url := fmt.Sprintf("/subjects/%s/versions/%s", neturl.PathEscape(subject), neturl.PathEscape(version))
+	res, err := c.client.R().SetResult(&SchemaVersionedResponse{}).SetQueryParams(deletedParam(deleted)).Get(url)
 	if err != nil {
 		return nil, fmt.Errorf("get schema by subject request failed: %w", err)
 	}
@@ -130,9 +177,9 @@ type SubjectsResponse struct {
 	Subjects []string // Subject names
 }
 
-// GetSubjects returns a list of registered subjects.
-func (c *Client) GetSubjects() (*SubjectsResponse, error) {
-	res, err := c.client.R().SetResult([]string{}).Get("/subjects")
+// GetSubjects returns a list of registered subjects. Soft deleted subjects are included if deleted is true.
+func (c *Client) GetSubjects(deleted bool) (*SubjectsResponse, error) {
+	res, err := c.client.R().SetResult([]string{}).SetQueryParams(deletedParam(deleted)).Get("/subjects")
 	if err != nil {
 		return nil, fmt.Errorf("get subjects request failed: %w", err)
 	}
@@ -160,9 +207,11 @@ type SubjectVersionsResponse struct {
 	Versions []int
 }
 
-func (c *Client) GetSubjectVersions(subject string) (*SubjectVersionsResponse, error) {
-	url := fmt.Sprintf("/subjects/%s/versions", subject)
-	res, err := c.client.R().SetResult([]int{}).Get(url)
+// GetSubjectVersions returns the registered versions of a subject. Soft deleted versions are included if deleted is
+// true.
+func (c *Client) GetSubjectVersions(subject string, deleted bool) (*SubjectVersionsResponse, error) {
+	url := fmt.Sprintf("/subjects/%s/versions", neturl.PathEscape(subject))
+	res, err := c.client.R().SetResult([]int{}).SetQueryParams(deletedParam(deleted)).Get(url)
 	if err != nil {
 		return nil, fmt.Errorf("get subject versions request failed: %w", err)
 	}
@@ -185,6 +234,14 @@ func (c *Client) GetSubjectVersions(subject string) (*SubjectVersionsResponse, e
 	}, nil
 }
 
+// deletedParam returns the query params which include soft deleted subjects or versions if deleted is true
+func deletedParam(deleted bool) map[string]string {
+	if !deleted {
+		return nil
+	}
+	return map[string]string{"deleted": "true"}
+}
+
 type ModeResponse struct {
 	// Possible values are: IMPORT, READONLY, READWRITE
 	Mode string `json:"mode"`
@@ -260,7 +317,8 @@ func (c *Client) GetSubjectConfig(subject string) (*ConfigResponse, error) {
 			return nil, fmt.Errorf("get config for subject failed: Status code %d", res.StatusCode())
 		}
 
-		if restErr.ErrorCode == codeSubjectNotFound {
+		// Newer schema registries return a dedicated error code if the subject exists but has no compatibility level
+		if restErr.ErrorCode == codeSubjectNotFound || restErr.ErrorCode == codeSubjectCompatibilityNotConfigured {
 			return &ConfigResponse{
 				Compatibility: "DEFAULT",
 			}, nil
@@ -291,3 +349,100 @@ func (c *Client) CheckConnectivity() error {
 
 	return nil
 }
+
+// CompatibilityCheckRequest is a candidate schema which shall be checked against a subject
+type CompatibilityCheckRequest struct {
+	Schema     string            `json:"schema"`
+	SchemaType string            `json:"schemaType,omitempty"` // Defaults to AVRO if empty
+	References []SchemaReference `json:"references,omitempty"`
+}
+
+type CompatibilityCheckResponse struct {
+	IsCompatible bool `json:"is_compatible"`
+
+	// Messages describe the incompatibilities, they are only returned by schema registries which support the verbose
+	// parameter (Confluent Platform 6.1+)
+	Messages []string `json:"messages"`
+}
+
+// CheckCompatibility tests the candidate schema against the given version of the subject ("latest" to test against
+// the latest version).
+func (c *Client) CheckCompatibility(subject string, version string, req CompatibilityCheckRequest) (*CompatibilityCheckResponse, error) {
+	url := fmt.Sprintf("/compatibility/subjects/%s/versions/%s", neturl.PathEscape(subject), neturl.PathEscape(version))
+	res, err := c.client.R().
+		SetHeader("Content-Type", "application/vnd.schemaregistry.v1+json").
+		SetQueryParam("verbose", "true").
+		SetBody(req).
+		SetResult(&CompatibilityCheckResponse{}).
+		Post(url)
+	if err != nil {
+		return nil, fmt.Errorf("check compatibility request failed: %w", err)
+	}
+
+	if res.IsError() {
+		restErr, ok := res.Error().(*RestError)
+		if !ok {
+			return nil, fmt.Errorf("check compatibility request failed: Status code %d", res.StatusCode())
+		}
+		return nil, restErr
+	}
+
+	parsed, ok := res.Result().(*CompatibilityCheckResponse)
+	if !ok {
+		return nil, fmt.Errorf("failed to parse check compatibility response")
+	}
+
+	return parsed, nil
+}
+
+type PutConfigResponse struct {
+	Compatibility string `json:"compatibility"`
+}
+
+// PutSubjectConfig sets the compatibility level of the given subject
+func (c *Client) PutSubjectConfig(subject string, compatibility string) (*PutConfigResponse, error) {
+	url := fmt.Sprintf("/config/%s", neturl.PathEscape(subject))
+	res, err := c.client.R().
+		SetHeader("Content-Type", "application/vnd.schemaregistry.v1+json").
+		SetBody(map[string]string{"compatibility": compatibility}).
+		SetResult(&PutConfigResponse{}).
+		Put(url)
+	if err != nil {
+		return nil, fmt.Errorf("put config for subject failed: %w", err)
+	}
+
+	if res.IsError() {
+		restErr, ok := res.Error().(*RestError)
+		if !ok {
+			return nil, fmt.Errorf("put config for subject failed: Status code %d", res.StatusCode())
+		}
+		return nil, restErr
+	}
+
+	parsed, ok := res.Result().(*PutConfigResponse)
+	if !ok {
+		return nil, fmt.Errorf("failed to parse put config for subject response")
+	}
+
+	return parsed, nil
+}
+
+// DeleteSubjectConfig deletes the compatibility level of the given subject, so that the global compatibility level
+// applies again
+func (c *Client) DeleteSubjectConfig(subject string) error {
+	url := fmt.Sprintf("/config/%s", neturl.PathEscape(subject))
+	res, err := c.client.R().Delete(url)
+	if err != nil {
+		return fmt.Errorf("delete config for subject failed: %w", err)
+	}
+
+	if res.IsError() {
+		restErr, ok := res.Error().(*RestError)
+		if !ok {
+			return fmt.Errorf("delete config for subject failed: Status code %d", res.StatusCode())
+		}
+		return restErr
+	}
+
+	return nil
+}
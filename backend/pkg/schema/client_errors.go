@@ -1,9 +1,16 @@
 package schema
 
+import "errors"
+
 const (
 	codeSubjectNotFound       = 40401
+	codeVersionNotFound       = 40402
 	codeSchemaNotFound        = 40403
 	codeBackendDatastoreError = 50001
+
+	codeSubjectCompatibilityNotConfigured = 40408
+	codeInvalidSchema                     = 42201
+	codeInvalidCompatibilityLevel         = 42203
 )
 
 func IsSchemaNotFound(err error) bool {
@@ -17,3 +24,39 @@ func IsSchemaNotFound(err error) bool {
 
 	return false
 }
+
+// IsSubjectNotFound returns true if the error is returned because the requested subject does not exist
+func IsSubjectNotFound(err error) bool {
+	var restErr *RestError
+	return errors.As(err, &restErr) && restErr.ErrorCode == codeSubjectNotFound
+}
+
+// IsVersionNotFound returns true if the error is returned because the requested version of a subject does not exist
+func IsVersionNotFound(err error) bool {
+	var restErr *RestError
+	return errors.As(err, &restErr) && restErr.ErrorCode == codeVersionNotFound
+}
+
+// IsNotFound returns true if the requested subject, version or schema does not exist
+func IsNotFound(err error) bool {
+	var restErr *RestError
+	if !errors.As(err, &restErr) {
+		return false
+	}
+	switch restErr.ErrorCode {
+	case codeSubjectNotFound, codeVersionNotFound, codeSchemaNotFound:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsInvalidInput returns true if the schema registry rejected the request, because the given schema or compatibility
+// level is invalid
+func IsInvalidInput(err error) bool {
+	var restErr *RestError
+	if !errors.As(err, &restErr) {
+		return false
+	}
+	return restErr.ErrorCode == codeInvalidSchema || restErr.ErrorCode == codeInvalidCompatibilityLevel
+}
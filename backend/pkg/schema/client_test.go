@@ -3,16 +3,18 @@ package schema
 import (
 	"github.com/jarcoal/httpmock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"net/http"
 	"testing"
 )
 
 func TestClient_GetSchemaByID(t *testing.T) {
 	baseURL := "https://schema-registry.company.com"
-	c := newClient(Config{
+	c, err := newClient(Config{
 		Enabled: true,
 		URLs:    []string{baseURL},
 	})
+	require.NoError(t, err)
 
 	httpClient := c.client.GetClient()
 	httpmock.ActivateNonDefault(httpClient)
@@ -34,10 +36,11 @@ func TestClient_GetSchemaByID(t *testing.T) {
 
 func TestClient_GetSubjects(t *testing.T) {
 	baseURL := "https://schema-registry.company.com"
-	c := newClient(Config{
+	c, err := newClient(Config{
 		Enabled: true,
 		URLs:    []string{baseURL},
 	})
+	require.NoError(t, err)
 
 	httpClient := c.client.GetClient()
 	httpmock.ActivateNonDefault(httpClient)
@@ -50,17 +53,18 @@ func TestClient_GetSubjects(t *testing.T) {
 		})
 
 	expected := &SubjectsResponse{Subjects: subjects}
-	actual, err := c.GetSubjects()
+	actual, err := c.GetSubjects(false)
 	assert.NoError(t, err, "expected no error when fetching subjects")
 	assert.Equal(t, expected, actual)
 }
 
 func TestClient_GetSubjectVersions(t *testing.T) {
 	baseURL := "https://schema-registry.company.com"
-	c := newClient(Config{
+	c, err := newClient(Config{
 		Enabled: true,
 		URLs:    []string{baseURL},
 	})
+	require.NoError(t, err)
 	httpClient := c.client.GetClient()
 	httpmock.ActivateNonDefault(httpClient)
 	defer httpmock.DeactivateAndReset()
@@ -72,7 +76,81 @@ func TestClient_GetSubjectVersions(t *testing.T) {
 		})
 
 	expected := &SubjectVersionsResponse{Versions: versions}
-	actual, err := c.GetSubjectVersions("orders")
+	actual, err := c.GetSubjectVersions("orders", false)
 	assert.NoError(t, err, "expected no error when fetching subject versions")
 	assert.Equal(t, expected, actual)
 }
+
+func TestClient_GetSchemaBySubject(t *testing.T) {
+	baseURL := "https://schema-registry.company.com"
+	c, err := newClient(Config{
+		Enabled: true,
+		URLs:    []string{baseURL},
+	})
+	require.NoError(t, err)
+	httpClient := c.client.GetClient()
+	httpmock.ActivateNonDefault(httpClient)
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", baseURL+"/subjects/orders-value/versions/2",
+		func(req *http.Request) (*http.Response, error) {
+			assert.Equal(t, "true", req.URL.Query().Get("deleted"))
+			return httpmock.NewJsonResponse(http.StatusOK, map[string]interface{}{
+				"subject":    "orders-value",
+				"id":         12,
+				"version":    2,
+				"schemaType": "PROTOBUF",
+				"schema":     `syntax = "proto3"; import "customer.proto";`,
+				"references": []map[string]interface{}{{"name": "customer.proto", "subject": "customer", "version": 1}},
+			})
+		})
+	httpmock.RegisterResponder("GET", baseURL+"/subjects/unknown/versions/latest",
+		httpmock.NewJsonResponderOrPanic(http.StatusNotFound, map[string]interface{}{
+			"error_code": 40401,
+			"message":    "Subject 'unknown' not found.",
+		}))
+
+	actual, err := c.GetSchemaBySubject("orders-value", "2", true)
+	require.NoError(t, err)
+	assert.Equal(t, SchemaTypeProtobuf, actual.Type())
+	assert.Equal(t, []SchemaReference{{Name: "customer.proto", Subject: "customer", Version: 1}}, actual.References)
+
+	_, err = c.GetSchemaBySubject("unknown", "latest", false)
+	assert.True(t, IsSubjectNotFound(err))
+	assert.True(t, IsNotFound(err))
+}
+
+func TestClient_CheckCompatibility(t *testing.T) {
+	baseURL := "https://schema-registry.company.com"
+	c, err := newClient(Config{
+		Enabled: true,
+		URLs:    []string{baseURL},
+	})
+	require.NoError(t, err)
+	httpClient := c.client.GetClient()
+	httpmock.ActivateNonDefault(httpClient)
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("POST", baseURL+"/compatibility/subjects/orders-value/versions/latest",
+		func(req *http.Request) (*http.Response, error) {
+			assert.Equal(t, "true", req.URL.Query().Get("verbose"))
+			return httpmock.NewJsonResponse(http.StatusOK, map[string]interface{}{
+				"is_compatible": false,
+				"messages":      []string{"READER_FIELD_MISSING_DEFAULT_VALUE, location: /fields/1"},
+			})
+		})
+	httpmock.RegisterResponder("POST", baseURL+"/compatibility/subjects/new-subject/versions/latest",
+		httpmock.NewJsonResponderOrPanic(http.StatusNotFound, map[string]interface{}{
+			"error_code": 40401,
+			"message":    "Subject 'new-subject' not found.",
+		}))
+
+	req := CompatibilityCheckRequest{Schema: `{"type": "record", "name": "order", "fields": []}`}
+	actual, err := c.CheckCompatibility("orders-value", "latest", req)
+	require.NoError(t, err)
+	assert.False(t, actual.IsCompatible)
+	assert.Len(t, actual.Messages, 1)
+
+	_, err = c.CheckCompatibility("new-subject", "latest", req)
+	assert.True(t, IsSubjectNotFound(err))
+}
@@ -3,6 +3,7 @@ package schema
 import (
 	"flag"
 	"fmt"
+	"time"
 )
 
 // Config for using a (Confluent) Schema Registry
@@ -17,6 +18,18 @@ type Config struct {
 
 	// TLS / Custom CA
 	TLS TLSConfig `yaml:"tls"`
+
+	// CacheSize is the maximum number of schemas which are cached, the least recently used schemas are evicted first
+	CacheSize int `yaml:"cacheSize"`
+
+	// SubjectsCacheTTL is the duration for which the list of subjects is cached, 0 disables the cache
+	SubjectsCacheTTL time.Duration `yaml:"subjectsCacheTtl"`
+}
+
+// SetDefaults for the schema registry config
+func (c *Config) SetDefaults() {
+	c.CacheSize = 1000
+	c.SubjectsCacheTTL = 10 * time.Second
 }
 
 // RegisterFlags registers all nested config flags.
@@ -34,5 +47,18 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("schema registry is enabled but no URL is configured")
 	}
 
+	if c.CacheSize <= 0 {
+		return fmt.Errorf("schema registry cacheSize must be greater than 0")
+	}
+
+	if c.SubjectsCacheTTL < 0 {
+		return fmt.Errorf("schema registry subjectsCacheTtl must not be negative")
+	}
+
+	err := c.TLS.Validate()
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
@@ -1,6 +1,20 @@
 package schema
 
-// TLSConfig to connect to Kafka via TLS
+import "fmt"
+
+// TLSConfig to connect to the schema registry via TLS
 type TLSConfig struct {
-	CaFilepath string `yaml:"caFilepath"`
+	CaFilepath            string `yaml:"caFilepath"`
+	CertFilepath          string `yaml:"certFilepath"`
+	KeyFilepath           string `yaml:"keyFilepath"`
+	InsecureSkipTLSVerify bool   `yaml:"insecureSkipTlsVerify"`
+}
+
+// Validate the schema registry TLS config
+func (c *TLSConfig) Validate() error {
+	if (c.CertFilepath == "") != (c.KeyFilepath == "") {
+		return fmt.Errorf("schema registry tls certFilepath and keyFilepath must be supplied as a pair")
+	}
+
+	return nil
 }
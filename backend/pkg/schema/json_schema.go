@@ -0,0 +1,595 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxJSONSchemaValidationErrors limits the number of validation errors which are reported for a single payload
+const maxJSONSchemaValidationErrors = 10
+
+// JSONSchema is a compiled JSON Schema which payloads can be validated against. It supports the validation keywords
+// which are common to the drafts 4 to 2020-12 (types, enums, properties, items, numeric and string bounds, patterns
+// and the allOf, anyOf, oneOf and not combinators). References within the schema document ($ref with a JSON pointer
+// into the document such as #/definitions/address) are resolved, references to other documents (e.g. schema
+// references of the registry) are not resolved and accept any value. Format annotations are ignored.
+type JSONSchema struct {
+	root *jsonSchemaNode
+}
+
+type jsonSchemaNode struct {
+	// always is set for the boolean schemas true (accepts anything) and false (accepts nothing)
+	always *bool
+
+	types     []string
+	enum      []interface{}
+	hasConst  bool
+	constant  interface{}
+	ref       *jsonSchemaNode
+	allOf     []*jsonSchemaNode
+	anyOf     []*jsonSchemaNode
+	oneOf     []*jsonSchemaNode
+	not       *jsonSchemaNode
+	minimum   *float64
+	maximum   *float64
+	exclMin   *float64
+	exclMax   *float64
+	multiple  *float64
+	minLength *int
+	maxLength *int
+	pattern   *regexp.Regexp
+
+	properties           map[string]*jsonSchemaNode
+	patternProperties    []jsonSchemaPatternProperty
+	additionalProperties *jsonSchemaNode
+	required             []string
+	minProperties        *int
+	maxProperties        *int
+
+	items       *jsonSchemaNode
+	tupleItems  []*jsonSchemaNode
+	minItems    *int
+	maxItems    *int
+	uniqueItems bool
+}
+
+type jsonSchemaPatternProperty struct {
+	pattern *regexp.Regexp
+	schema  *jsonSchemaNode
+}
+
+// jsonSchemaCompiler compiles a schema document. Referenced subschemas are compiled once, so that recursive schemas
+// don't recurse endlessly.
+type jsonSchemaCompiler struct {
+	document interface{}
+	refs     map[string]*jsonSchemaNode
+}
+
+// CompileJSONSchema compiles the given JSON Schema document
+func CompileJSONSchema(schemaStr string) (*JSONSchema, error) {
+	var document interface{}
+	err := json.Unmarshal([]byte(schemaStr), &document)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse json schema: %w", err)
+	}
+
+	c := &jsonSchemaCompiler{document: document, refs: make(map[string]*jsonSchemaNode)}
+	root, err := c.compile(document, "#")
+	if err != nil {
+		return nil, err
+	}
+	if err := checkRefCycles(root, make(map[*jsonSchemaNode]int)); err != nil {
+		return nil, err
+	}
+
+	return &JSONSchema{root: root}, nil
+}
+
+// States of a node while the schema is searched for reference cycles
+const (
+	refCycleUnvisited = iota
+	refCycleInProgress
+	refCycleDone
+)
+
+// checkRefCycles rejects schemas whose references form a cycle which doesn't consume any data, such as {"$ref":"#"}
+// or two definitions which reference each other via allOf. Validating a value against such a schema would recurse
+// endlessly. Cycles through properties or items are fine, since each step descends into the value.
+func checkRefCycles(node *jsonSchemaNode, states map[*jsonSchemaNode]int) error {
+	switch states[node] {
+	case refCycleInProgress:
+		return fmt.Errorf("the schema contains a $ref cycle which doesn't consume any data")
+	case refCycleDone:
+		return nil
+	}
+
+	states[node] = refCycleInProgress
+	next := make([]*jsonSchemaNode, 0, 2+len(node.allOf)+len(node.anyOf)+len(node.oneOf))
+	next = append(next, node.ref, node.not)
+	next = append(next, node.allOf...)
+	next = append(next, node.anyOf...)
+	next = append(next, node.oneOf...)
+	for _, n := range next {
+		if n == nil {
+			continue
+		}
+		if err := checkRefCycles(n, states); err != nil {
+			return err
+		}
+	}
+	states[node] = refCycleDone
+
+	return nil
+}
+
+func (c *jsonSchemaCompiler) compile(raw interface{}, location string) (*jsonSchemaNode, error) {
+	node := &jsonSchemaNode{}
+	return node, c.compileInto(node, raw, location)
+}
+
+func (c *jsonSchemaCompiler) compileInto(node *jsonSchemaNode, raw interface{}, location string) error {
+	if always, ok := raw.(bool); ok {
+		node.always = &always
+		return nil
+	}
+	obj, ok := raw.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("schema at '%v' must be an object or a boolean", location)
+	}
+
+	var err error
+	if ref, ok := obj["$ref"].(string); ok {
+		node.ref, err = c.resolveRef(ref)
+		if err != nil {
+			return err
+		}
+	}
+
+	switch t := obj["type"].(type) {
+	case string:
+		node.types = []string{t}
+	case []interface{}:
+		for _, item := range t {
+			if s, ok := item.(string); ok {
+				node.types = append(node.types, s)
+			}
+		}
+	}
+	if enum, ok := obj["enum"].([]interface{}); ok {
+		node.enum = enum
+	}
+	if constant, ok := obj["const"]; ok {
+		node.hasConst = true
+		node.constant = constant
+	}
+
+	for _, combinator := range []struct {
+		keyword string
+		target  *[]*jsonSchemaNode
+	}{{"allOf", &node.allOf}, {"anyOf", &node.anyOf}, {"oneOf", &node.oneOf}} {
+		subschemas, ok := obj[combinator.keyword].([]interface{})
+		if !ok {
+			continue
+		}
+		for i, subschema := range subschemas {
+			compiled, err := c.compile(subschema, fmt.Sprintf("%v/%v/%d", location, combinator.keyword, i))
+			if err != nil {
+				return err
+			}
+			*combinator.target = append(*combinator.target, compiled)
+		}
+	}
+	if not, ok := obj["not"]; ok {
+		if node.not, err = c.compile(not, location+"/not"); err != nil {
+			return err
+		}
+	}
+
+	// Numeric bounds. Draft 4 defines exclusiveMinimum and exclusiveMaximum as booleans which modify the bounds.
+	node.minimum = numberKeyword(obj, "minimum")
+	node.maximum = numberKeyword(obj, "maximum")
+	node.exclMin = numberKeyword(obj, "exclusiveMinimum")
+	node.exclMax = numberKeyword(obj, "exclusiveMaximum")
+	if exclusive, _ := obj["exclusiveMinimum"].(bool); exclusive {
+		node.exclMin, node.minimum = node.minimum, nil
+	}
+	if exclusive, _ := obj["exclusiveMaximum"].(bool); exclusive {
+		node.exclMax, node.maximum = node.maximum, nil
+	}
+	node.multiple = numberKeyword(obj, "multipleOf")
+
+	node.minLength = intKeyword(obj, "minLength")
+	node.maxLength = intKeyword(obj, "maxLength")
+	if pattern, ok := obj["pattern"].(string); ok {
+		if node.pattern, err = regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("pattern at '%v' is invalid: %w", location, err)
+		}
+	}
+
+	if properties, ok := obj["properties"].(map[string]interface{}); ok {
+		node.properties = make(map[string]*jsonSchemaNode, len(properties))
+		for name, property := range properties {
+			if node.properties[name], err = c.compile(property, location+"/properties/"+name); err != nil {
+				return err
+			}
+		}
+	}
+	if patternProperties, ok := obj["patternProperties"].(map[string]interface{}); ok {
+		for pattern, property := range patternProperties {
+			regex, err := regexp.Compile(pattern)
+			if err != nil {
+				return fmt.Errorf("patternProperties at '%v' are invalid: %w", location, err)
+			}
+			compiled, err := c.compile(property, location+"/patternProperties/"+pattern)
+			if err != nil {
+				return err
+			}
+			node.patternProperties = append(node.patternProperties, jsonSchemaPatternProperty{regex, compiled})
+		}
+	}
+	if additional, ok := obj["additionalProperties"]; ok {
+		if node.additionalProperties, err = c.compile(additional, location+"/additionalProperties"); err != nil {
+			return err
+		}
+	}
+	if required, ok := obj["required"].([]interface{}); ok {
+		for _, name := range required {
+			if s, ok := name.(string); ok {
+				node.required = append(node.required, s)
+			}
+		}
+	}
+	node.minProperties = intKeyword(obj, "minProperties")
+	node.maxProperties = intKeyword(obj, "maxProperties")
+
+	// Tuples are defined by an items array (up to draft 2019-09) or by prefixItems (draft 2020-12)
+	items := obj["items"]
+	if prefixItems, ok := obj["prefixItems"].([]interface{}); ok {
+		items = prefixItems
+		if node.items, err = c.compileOptional(obj["items"], location+"/items"); err != nil {
+			return err
+		}
+	} else if node.items, err = c.compileOptional(obj["additionalItems"], location+"/additionalItems"); err != nil {
+		return err
+	}
+	switch v := items.(type) {
+	case []interface{}:
+		for i, item := range v {
+			compiled, err := c.compile(item, fmt.Sprintf("%v/items/%d", location, i))
+			if err != nil {
+				return err
+			}
+			node.tupleItems = append(node.tupleItems, compiled)
+		}
+	case nil:
+	default:
+		if node.items, err = c.compile(v, location+"/items"); err != nil {
+			return err
+		}
+	}
+	node.minItems = intKeyword(obj, "minItems")
+	node.maxItems = intKeyword(obj, "maxItems")
+	node.uniqueItems, _ = obj["uniqueItems"].(bool)
+
+	return nil
+}
+
+func (c *jsonSchemaCompiler) compileOptional(raw interface{}, location string) (*jsonSchemaNode, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	return c.compile(raw, location)
+}
+
+// resolveRef returns the compiled subschema the reference points to. References to other documents are not resolved,
+// nil is returned for them.
+func (c *jsonSchemaCompiler) resolveRef(ref string) (*jsonSchemaNode, error) {
+	if !strings.HasPrefix(ref, "#") {
+		return nil, nil
+	}
+	if node, exists := c.refs[ref]; exists {
+		return node, nil
+	}
+
+	target, err := resolveJSONPointer(c.document, strings.TrimPrefix(ref, "#"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve $ref '%v': %w", ref, err)
+	}
+	// The node is registered before it's compiled, so that recursive references resolve to the same node
+	node := &jsonSchemaNode{}
+	c.refs[ref] = node
+	return node, c.compileInto(node, target, ref)
+}
+
+// resolveJSONPointer resolves a JSON pointer (RFC 6901) such as /definitions/address within the document
+func resolveJSONPointer(document interface{}, pointer string) (interface{}, error) {
+	if pointer == "" {
+		return document, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("only JSON pointers are supported")
+	}
+
+	current := document
+	for _, token := range strings.Split(pointer[1:], "/") {
+		token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+		switch v := current.(type) {
+		case map[string]interface{}:
+			next, exists := v[token]
+			if !exists {
+				return nil, fmt.Errorf("'%v' does not exist", token)
+			}
+			current = next
+		case []interface{}:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, fmt.Errorf("index '%v' does not exist", token)
+			}
+			current = v[i]
+		default:
+			return nil, fmt.Errorf("'%v' does not exist", token)
+		}
+	}
+
+	return current, nil
+}
+
+func numberKeyword(obj map[string]interface{}, keyword string) *float64 {
+	if v, ok := obj[keyword].(float64); ok {
+		return &v
+	}
+	return nil
+}
+
+func intKeyword(obj map[string]interface{}, keyword string) *int {
+	if v, ok := obj[keyword].(float64); ok {
+		i := int(v)
+		return &i
+	}
+	return nil
+}
+
+// Validate validates the payload, which must have been parsed with encoding/json, against the schema. It returns the
+// validation errors, an empty slice means the payload is valid.
+func (s *JSONSchema) Validate(payload interface{}) []string {
+	errs := make([]string, 0)
+	s.root.validate(payload, "$", &errs)
+	if len(errs) > maxJSONSchemaValidationErrors {
+		errs = append(errs[:maxJSONSchemaValidationErrors], fmt.Sprintf("%d more errors omitted", len(errs)-maxJSONSchemaValidationErrors))
+	}
+	return errs
+}
+
+// isValid returns whether the value is valid without collecting the validation errors
+func (n *jsonSchemaNode) isValid(value interface{}) bool {
+	errs := make([]string, 0)
+	n.validate(value, "", &errs)
+	return len(errs) == 0
+}
+
+func (n *jsonSchemaNode) validate(value interface{}, path string, errs *[]string) {
+	addError := func(format string, args ...interface{}) {
+		*errs = append(*errs, path+": "+fmt.Sprintf(format, args...))
+	}
+
+	if n.always != nil {
+		if !*n.always {
+			addError("no value is allowed")
+		}
+		return
+	}
+	if n.ref != nil {
+		n.ref.validate(value, path, errs)
+	}
+
+	if len(n.types) > 0 {
+		matches := false
+		for _, t := range n.types {
+			if jsonTypeMatches(t, value) {
+				matches = true
+				break
+			}
+		}
+		if !matches {
+			addError("expected type %v but got %v", strings.Join(n.types, " or "), jsonTypeName(value))
+			return
+		}
+	}
+	if n.enum != nil {
+		matches := false
+		for _, allowed := range n.enum {
+			if reflect.DeepEqual(allowed, value) {
+				matches = true
+				break
+			}
+		}
+		if !matches {
+			addError("value must be one of the enum values")
+		}
+	}
+	if n.hasConst && !reflect.DeepEqual(n.constant, value) {
+		addError("value must be equal to the const value")
+	}
+
+	for _, subschema := range n.allOf {
+		subschema.validate(value, path, errs)
+	}
+	if len(n.anyOf) > 0 {
+		matches := false
+		for _, subschema := range n.anyOf {
+			if subschema.isValid(value) {
+				matches = true
+				break
+			}
+		}
+		if !matches {
+			addError("value must match at least one schema of anyOf")
+		}
+	}
+	if len(n.oneOf) > 0 {
+		matches := 0
+		for _, subschema := range n.oneOf {
+			if subschema.isValid(value) {
+				matches++
+			}
+		}
+		if matches != 1 {
+			addError("value must match exactly one schema of oneOf, but matches %d", matches)
+		}
+	}
+	if n.not != nil && n.not.isValid(value) {
+		addError("value must not match the schema of not")
+	}
+
+	switch v := value.(type) {
+	case float64:
+		n.validateNumber(v, addError)
+	case string:
+		n.validateString(v, addError)
+	case map[string]interface{}:
+		n.validateObject(v, path, errs, addError)
+	case []interface{}:
+		n.validateArray(v, path, errs, addError)
+	}
+}
+
+func (n *jsonSchemaNode) validateNumber(v float64, addError func(string, ...interface{})) {
+	if n.minimum != nil && v < *n.minimum {
+		addError("%v is less than the minimum of %v", v, *n.minimum)
+	}
+	if n.maximum != nil && v > *n.maximum {
+		addError("%v is greater than the maximum of %v", v, *n.maximum)
+	}
+	if n.exclMin != nil && v <= *n.exclMin {
+		addError("%v must be greater than %v", v, *n.exclMin)
+	}
+	if n.exclMax != nil && v >= *n.exclMax {
+		addError("%v must be less than %v", v, *n.exclMax)
+	}
+	if n.multiple != nil && *n.multiple > 0 {
+		quotient := v / *n.multiple
+		if math.Abs(quotient-math.Round(quotient)) > 1e-9 {
+			addError("%v is not a multiple of %v", v, *n.multiple)
+		}
+	}
+}
+
+func (n *jsonSchemaNode) validateString(v string, addError func(string, ...interface{})) {
+	length := utf8.RuneCountInString(v)
+	if n.minLength != nil && length < *n.minLength {
+		addError("length %d is less than the minLength of %d", length, *n.minLength)
+	}
+	if n.maxLength != nil && length > *n.maxLength {
+		addError("length %d is greater than the maxLength of %d", length, *n.maxLength)
+	}
+	if n.pattern != nil && !n.pattern.MatchString(v) {
+		addError("value does not match the pattern '%v'", n.pattern.String())
+	}
+}
+
+func (n *jsonSchemaNode) validateObject(v map[string]interface{}, path string, errs *[]string, addError func(string, ...interface{})) {
+	for _, name := range n.required {
+		if _, exists := v[name]; !exists {
+			addError("required property '%v' is missing", name)
+		}
+	}
+	if n.minProperties != nil && len(v) < *n.minProperties {
+		addError("object has less than %d properties", *n.minProperties)
+	}
+	if n.maxProperties != nil && len(v) > *n.maxProperties {
+		addError("object has more than %d properties", *n.maxProperties)
+	}
+
+	// Properties are validated in order, so that the validation errors are deterministic
+	names := make([]string, 0, len(v))
+	for name := range v {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		propertyPath := path + "." + name
+		isDefined := false
+		if property, exists := n.properties[name]; exists {
+			isDefined = true
+			property.validate(v[name], propertyPath, errs)
+		}
+		for _, patternProperty := range n.patternProperties {
+			if patternProperty.pattern.MatchString(name) {
+				isDefined = true
+				patternProperty.schema.validate(v[name], propertyPath, errs)
+			}
+		}
+		if !isDefined && n.additionalProperties != nil {
+			n.additionalProperties.validate(v[name], propertyPath, errs)
+		}
+	}
+}
+
+func (n *jsonSchemaNode) validateArray(v []interface{}, path string, errs *[]string, addError func(string, ...interface{})) {
+	if n.minItems != nil && len(v) < *n.minItems {
+		addError("array has less than %d items", *n.minItems)
+	}
+	if n.maxItems != nil && len(v) > *n.maxItems {
+		addError("array has more than %d items", *n.maxItems)
+	}
+	if n.uniqueItems {
+	unique:
+		for i := range v {
+			for j := i + 1; j < len(v); j++ {
+				if reflect.DeepEqual(v[i], v[j]) {
+					addError("array items must be unique, items %d and %d are equal", i, j)
+					break unique
+				}
+			}
+		}
+	}
+
+	for i, item := range v {
+		itemPath := fmt.Sprintf("%v[%d]", path, i)
+		switch {
+		case i < len(n.tupleItems):
+			n.tupleItems[i].validate(item, itemPath, errs)
+		case n.items != nil:
+			n.items.validate(item, itemPath, errs)
+		}
+	}
+}
+
+func jsonTypeMatches(t string, value interface{}) bool {
+	switch t {
+	case "integer":
+		v, ok := value.(float64)
+		return ok && v == math.Trunc(v)
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	default:
+		return jsonTypeName(value) == t
+	}
+}
+
+func jsonTypeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
@@ -0,0 +1,103 @@
+package schema
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONSchema_Validate(t *testing.T) {
+	jsonSchema, err := CompileJSONSchema(`{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "type": "object",
+  "required": ["id", "customer"],
+  "additionalProperties": false,
+  "properties": {
+    "id": {"type": "integer", "minimum": 1},
+    "status": {"enum": ["OPEN", "SHIPPED"]},
+    "customer": {"$ref": "#/definitions/customer"},
+    "items": {"type": "array", "minItems": 1, "items": {"type": "string", "pattern": "^sku-"}}
+  },
+  "definitions": {
+    "customer": {
+      "type": "object",
+      "required": ["email"],
+      "properties": {
+        "email": {"type": "string", "minLength": 3},
+        "referredBy": {"$ref": "#/definitions/customer"}
+      }
+    }
+  }
+}`)
+	require.NoError(t, err)
+
+	tt := []struct {
+		name     string
+		payload  string
+		expected []string
+	}{
+		{"valid", `{"id": 1, "status": "OPEN", "customer": {"email": "a@b.c", "referredBy": {"email": "d@e.f"}}, "items": ["sku-1"]}`, []string{}},
+		{"wrong types", `{"id": 1.5, "customer": {"email": 42}}`, []string{
+			"$.customer.email: expected type string but got number",
+			"$.id: expected type integer but got number",
+		}},
+		{"missing and additional properties", `{"id": 0, "note": "fragile"}`, []string{
+			"$: required property 'customer' is missing",
+			"$.id: 0 is less than the minimum of 1",
+			"$.note: no value is allowed",
+		}},
+		{"recursive reference", `{"id": 1, "customer": {"email": "a@b.c", "referredBy": {}}}`, []string{
+			"$.customer.referredBy: required property 'email' is missing",
+		}},
+		{"array items", `{"id": 1, "customer": {"email": "a@b.c"}, "status": "LOST", "items": ["sku-1", "item-2"]}`, []string{
+			"$.items[1]: value does not match the pattern '^sku-'",
+			"$.status: value must be one of the enum values",
+		}},
+	}
+	for _, table := range tt {
+		var payload interface{}
+		require.NoError(t, json.Unmarshal([]byte(table.payload), &payload), table.name)
+		assert.Equal(t, table.expected, jsonSchema.Validate(payload), table.name)
+	}
+}
+
+func TestJSONSchema_Combinators(t *testing.T) {
+	jsonSchema, err := CompileJSONSchema(`{
+  "oneOf": [{"type": "string"}, {"type": "number", "exclusiveMinimum": 0}],
+  "not": {"const": "forbidden"}
+}`)
+	require.NoError(t, err)
+
+	assert.Empty(t, jsonSchema.Validate("order"))
+	assert.Empty(t, jsonSchema.Validate(float64(3)))
+	assert.Len(t, jsonSchema.Validate(float64(0)), 1)
+	assert.Len(t, jsonSchema.Validate("forbidden"), 1)
+
+	_, err = CompileJSONSchema(`{"$ref": "#/definitions/unknown"}`)
+	assert.Error(t, err)
+
+	// References to other documents are not resolved and accept any value
+	jsonSchema, err = CompileJSONSchema(`{"properties": {"customer": {"$ref": "customer.json"}}}`)
+	require.NoError(t, err)
+	assert.Empty(t, jsonSchema.Validate(map[string]interface{}{"customer": 42.0}))
+}
+
+func TestCompileJSONSchema_RefCycles(t *testing.T) {
+	// Cycles which don't consume any data would recurse endlessly during validation
+	cycles := []string{
+		`{"$ref": "#"}`,
+		`{"definitions": {"a": {"$ref": "#/definitions/b"}, "b": {"allOf": [{"$ref": "#/definitions/a"}]}}, "$ref": "#/definitions/a"}`,
+		`{"anyOf": [{"not": {"$ref": "#"}}]}`,
+	}
+	for _, schema := range cycles {
+		_, err := CompileJSONSchema(schema)
+		assert.Error(t, err, schema)
+	}
+
+	// Recursion through properties and items descends into the value
+	jsonSchema, err := CompileJSONSchema(`{"properties": {"children": {"items": {"$ref": "#"}}}}`)
+	require.NoError(t, err)
+	assert.Empty(t, jsonSchema.Validate(map[string]interface{}{"children": []interface{}{map[string]interface{}{}}}))
+}
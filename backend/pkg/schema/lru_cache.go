@@ -0,0 +1,70 @@
+package schema
+
+import (
+	"container/list"
+	"sync"
+)
+
+// lruCache is a LRU cache for schemas (e.g. compiled Avro codecs) by schema id, bounded by the number of cached
+// schemas. It is safe for concurrent access.
+type lruCache struct {
+	maxSize int
+
+	mutex   sync.Mutex
+	entries map[uint32]*list.Element
+	order   *list.List // Most recently used entries are at the front
+}
+
+type lruCacheEntry struct {
+	schemaID uint32
+	value    interface{}
+}
+
+func newLRUCache(maxSize int) *lruCache {
+	return &lruCache{
+		maxSize: maxSize,
+		entries: make(map[uint32]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// Get returns the cached value for the given schema id and marks it as most recently used
+func (c *lruCache) Get(schemaID uint32) (interface{}, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	elem, exists := c.entries[schemaID]
+	if !exists {
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+
+	return elem.Value.(*lruCacheEntry).value, true
+}
+
+// Add caches the value for the given schema id and evicts the least recently used value if the cache is full
+func (c *lruCache) Add(schemaID uint32, value interface{}) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if elem, exists := c.entries[schemaID]; exists {
+		elem.Value.(*lruCacheEntry).value = value
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.entries[schemaID] = c.order.PushFront(&lruCacheEntry{schemaID: schemaID, value: value})
+	if c.order.Len() > c.maxSize {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*lruCacheEntry).schemaID)
+	}
+}
+
+// Len returns the number of cached values
+func (c *lruCache) Len() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.order.Len()
+}
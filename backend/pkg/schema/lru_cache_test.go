@@ -0,0 +1,31 @@
+package schema
+
+import (
+	"testing"
+
+	"github.com/linkedin/goavro/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLRUCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	codec, err := goavro.NewCodec(`{"type": "string"}`)
+	require.NoError(t, err)
+
+	cache := newLRUCache(2)
+	cache.Add(1, codec)
+	cache.Add(2, codec)
+
+	// Access schema 1 so that schema 2 becomes the least recently used one
+	_, exists := cache.Get(1)
+	assert.True(t, exists)
+	cache.Add(3, codec)
+
+	assert.Equal(t, 2, cache.Len())
+	_, exists = cache.Get(2)
+	assert.False(t, exists)
+	_, exists = cache.Get(1)
+	assert.True(t, exists)
+	_, exists = cache.Get(3)
+	assert.True(t, exists)
+}
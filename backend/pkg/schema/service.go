@@ -2,6 +2,9 @@ package schema
 
 import (
 	"fmt"
+	"sync"
+	"time"
+
 	"github.com/linkedin/goavro/v2"
 	"golang.org/x/sync/singleflight"
 )
@@ -14,8 +17,23 @@ type Service struct {
 
 	registryClient *Client
 
-	// Schema Cache by schema id
-	cacheByID map[uint32]*goavro.Codec
+	// Schema Cache by schema id. codecsByID and jsonSchemasByID cache the compiled avro codecs and JSON schemas
+	cacheByID       *lruCache
+	codecsByID      *lruCache
+	jsonSchemasByID *lruCache
+
+	// subjectsCache caches the subject lists with and without soft deleted subjects for cfg.SubjectsCacheTTL
+	subjectsCache subjectsCache
+}
+
+type subjectsCache struct {
+	mutex     sync.Mutex
+	byDeleted map[bool]cachedSubjects // Keyed by whether soft deleted subjects are included
+}
+
+type cachedSubjects struct {
+	subjects  *SubjectsResponse
+	fetchedAt time.Time
 }
 
 // NewService to access schema registry. Returns an error if connection can't be established.
@@ -26,10 +44,13 @@ func NewSevice(cfg Config) (*Service, error) {
 	}
 
 	return &Service{
-		cfg:            cfg,
-		requestGroup:   singleflight.Group{},
-		registryClient: client,
-		cacheByID:      make(map[uint32]*goavro.Codec),
+		cfg:             cfg,
+		requestGroup:    singleflight.Group{},
+		registryClient:  client,
+		cacheByID:       newLRUCache(cfg.CacheSize),
+		codecsByID:      newLRUCache(cfg.CacheSize),
+		jsonSchemasByID: newLRUCache(cfg.CacheSize),
+		subjectsCache:   subjectsCache{byDeleted: make(map[bool]cachedSubjects)},
 	}, nil
 }
 
@@ -38,13 +59,14 @@ func (s *Service) CheckConnectivity() error {
 	return s.registryClient.CheckConnectivity()
 }
 
-func (s *Service) GetAvroSchemaByID(schemaID uint32) (*goavro.Codec, error) {
+// GetSchemaByID returns the (cached) schema for the given schema id, regardless of the schema type
+func (s *Service) GetSchemaByID(schemaID uint32) (*SchemaResponse, error) {
 	// Singleflight makes sure to not run the function body if there are concurrent requests. We use this to avoid
 	// duplicate requests against the schema registry
-	key := fmt.Sprintf("get-avro-schema-%d", schemaID)
+	key := fmt.Sprintf("get-schema-%d", schemaID)
 	v, err, _ := s.requestGroup.Do(key, func() (interface{}, error) {
-		if codec, exists := s.cacheByID[schemaID]; exists {
-			return codec, nil
+		if schemaRes, exists := s.cacheByID.Get(schemaID); exists {
+			return schemaRes, nil
 		}
 
 		schemaRes, err := s.registryClient.GetSchemaByID(schemaID)
@@ -54,38 +76,100 @@ func (s *Service) GetAvroSchemaByID(schemaID uint32) (*goavro.Codec, error) {
 			s.requestGroup.Forget(key)
 			return nil, fmt.Errorf("failed to get schema from registry: %w", err)
 		}
+		s.cacheByID.Add(schemaID, schemaRes)
 
-		codec, err := goavro.NewCodec(schemaRes.Schema)
-		if err != nil {
-			// If codec compilation returns an error we want to retry it next time (maybe the schema has changed or response
-			// was corrupted), so let's forget the key
-			s.requestGroup.Forget(key)
-			return nil, fmt.Errorf("failed to create codec from schema string: %w", err)
-		}
+		return schemaRes, nil
+	})
+	if err != nil {
+		return nil, err
+	}
 
-		s.cacheByID[schemaID] = codec
+	return v.(*SchemaResponse), nil
+}
 
-		return codec, nil
-	})
+func (s *Service) GetAvroSchemaByID(schemaID uint32) (*goavro.Codec, error) {
+	if codec, exists := s.codecsByID.Get(schemaID); exists {
+		return codec.(*goavro.Codec), nil
+	}
+
+	schemaRes, err := s.GetSchemaByID(schemaID)
 	if err != nil {
 		return nil, err
 	}
+	if schemaRes.Type() != SchemaTypeAvro {
+		return nil, fmt.Errorf("schema with id %d is not an avro schema but %v", schemaID, schemaRes.Type())
+	}
 
-	codec := v.(*goavro.Codec)
+	codec, err := goavro.NewCodec(schemaRes.Schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create codec from schema string: %w", err)
+	}
+	s.codecsByID.Add(schemaID, codec)
 
 	return codec, nil
 }
 
-func (s *Service) GetSubjects() (*SubjectsResponse, error) {
-	return s.registryClient.GetSubjects()
+// GetJSONSchemaByID returns the (cached) compiled JSON schema for the given schema id
+func (s *Service) GetJSONSchemaByID(schemaID uint32) (*JSONSchema, error) {
+	if jsonSchema, exists := s.jsonSchemasByID.Get(schemaID); exists {
+		return jsonSchema.(*JSONSchema), nil
+	}
+
+	schemaRes, err := s.GetSchemaByID(schemaID)
+	if err != nil {
+		return nil, err
+	}
+	if schemaRes.Type() != SchemaTypeJSON {
+		return nil, fmt.Errorf("schema with id %d is not a json schema but %v", schemaID, schemaRes.Type())
+	}
+
+	jsonSchema, err := CompileJSONSchema(schemaRes.Schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile json schema: %w", err)
+	}
+	s.jsonSchemasByID.Add(schemaID, jsonSchema)
+
+	return jsonSchema, nil
+}
+
+// GetSubjects returns the (cached) list of subjects. Soft deleted subjects are included if deleted is true.
+func (s *Service) GetSubjects(deleted bool) (*SubjectsResponse, error) {
+	if s.cfg.SubjectsCacheTTL == 0 {
+		return s.registryClient.GetSubjects(deleted)
+	}
+
+	s.subjectsCache.mutex.Lock()
+	cached, exists := s.subjectsCache.byDeleted[deleted]
+	s.subjectsCache.mutex.Unlock()
+	if exists && time.Since(cached.fetchedAt) < s.cfg.SubjectsCacheTTL {
+		return cached.subjects, nil
+	}
+
+	key := fmt.Sprintf("get-subjects-%v", deleted)
+	v, err, _ := s.requestGroup.Do(key, func() (interface{}, error) {
+		subjects, err := s.registryClient.GetSubjects(deleted)
+		if err != nil {
+			return nil, err
+		}
+
+		s.subjectsCache.mutex.Lock()
+		s.subjectsCache.byDeleted[deleted] = cachedSubjects{subjects: subjects, fetchedAt: time.Now()}
+		s.subjectsCache.mutex.Unlock()
+		return subjects, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return v.(*SubjectsResponse), nil
 }
 
-func (s *Service) GetSubjectVersions(subject string) (*SubjectVersionsResponse, error) {
-	return s.registryClient.GetSubjectVersions(subject)
+func (s *Service) GetSubjectVersions(subject string, deleted bool) (*SubjectVersionsResponse, error) {
+	return s.registryClient.GetSubjectVersions(subject, deleted)
 }
 
-func (s *Service) GetSchemaBySubject(subject string, version string) (*SchemaVersionedResponse, error) {
-	return s.registryClient.GetSchemaBySubject(subject, version)
+func (s *Service) GetSchemaBySubject(subject string, version string, deleted bool) (*SchemaVersionedResponse, error) {
+	return s.registryClient.GetSchemaBySubject(subject, version, deleted)
 }
 
 func (s *Service) GetMode() (*ModeResponse, error) {
@@ -99,3 +183,15 @@ func (s *Service) GetConfig() (*ConfigResponse, error) {
 func (s *Service) GetSubjectConfig(subject string) (*ConfigResponse, error) {
 	return s.registryClient.GetSubjectConfig(subject)
 }
+
+func (s *Service) CheckCompatibility(subject string, version string, req CompatibilityCheckRequest) (*CompatibilityCheckResponse, error) {
+	return s.registryClient.CheckCompatibility(subject, version, req)
+}
+
+func (s *Service) PutSubjectConfig(subject string, compatibility string) (*PutConfigResponse, error) {
+	return s.registryClient.PutSubjectConfig(subject, compatibility)
+}
+
+func (s *Service) DeleteSubjectConfig(subject string) error {
+	return s.registryClient.DeleteSubjectConfig(subject)
+}
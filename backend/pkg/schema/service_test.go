@@ -0,0 +1,89 @@
+package schema
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_GetAvroSchemaByID_Cached(t *testing.T) {
+	baseURL := "https://schema-registry.company.com"
+	cfg := Config{Enabled: true, URLs: []string{baseURL}}
+	cfg.SetDefaults()
+	svc, err := NewSevice(cfg)
+	require.NoError(t, err)
+
+	httpmock.ActivateNonDefault(svc.registryClient.client.GetClient())
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", baseURL+"/schemas/ids/1000",
+		httpmock.NewJsonResponderOrPanic(http.StatusOK, map[string]string{"schema": `{"type": "string"}`}))
+
+	for i := 0; i < 2; i++ {
+		codec, err := svc.GetAvroSchemaByID(1000)
+		require.NoError(t, err)
+		assert.Equal(t, `{"type": "string"}`, codec.Schema())
+	}
+	assert.Equal(t, 1, httpmock.GetTotalCallCount())
+}
+
+func TestService_GetJSONSchemaByID_Cached(t *testing.T) {
+	baseURL := "https://schema-registry.company.com"
+	cfg := Config{Enabled: true, URLs: []string{baseURL}}
+	cfg.SetDefaults()
+	svc, err := NewSevice(cfg)
+	require.NoError(t, err)
+
+	httpmock.ActivateNonDefault(svc.registryClient.client.GetClient())
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", baseURL+"/schemas/ids/1001",
+		httpmock.NewJsonResponderOrPanic(http.StatusOK, map[string]string{"schemaType": "JSON", "schema": `{"type": "object"}`}))
+	httpmock.RegisterResponder("GET", baseURL+"/schemas/ids/1000",
+		httpmock.NewJsonResponderOrPanic(http.StatusOK, map[string]string{"schema": `{"type": "string"}`}))
+
+	for i := 0; i < 2; i++ {
+		jsonSchema, err := svc.GetJSONSchemaByID(1001)
+		require.NoError(t, err)
+		assert.Len(t, jsonSchema.Validate("not an object"), 1)
+	}
+	assert.Equal(t, 1, httpmock.GetTotalCallCount())
+
+	_, err = svc.GetJSONSchemaByID(1000)
+	assert.Error(t, err, "avro schemas are no json schemas")
+}
+
+func TestService_GetSubjects_Cached(t *testing.T) {
+	baseURL := "https://schema-registry.company.com"
+	cfg := Config{Enabled: true, URLs: []string{baseURL}}
+	cfg.SetDefaults()
+	svc, err := NewSevice(cfg)
+	require.NoError(t, err)
+
+	httpmock.ActivateNonDefault(svc.registryClient.client.GetClient())
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", baseURL+"/subjects",
+		func(req *http.Request) (*http.Response, error) {
+			if req.URL.Query().Get("deleted") == "true" {
+				return httpmock.NewJsonResponse(http.StatusOK, []string{"orders-value", "payments-value"})
+			}
+			return httpmock.NewJsonResponse(http.StatusOK, []string{"orders-value"})
+		})
+
+	for i := 0; i < 2; i++ {
+		subjects, err := svc.GetSubjects(false)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"orders-value"}, subjects.Subjects)
+	}
+	assert.Equal(t, 1, httpmock.GetTotalCallCount())
+
+	// Soft deleted subjects are cached separately
+	subjects, err := svc.GetSubjects(true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"orders-value", "payments-value"}, subjects.Subjects)
+	assert.Equal(t, 2, httpmock.GetTotalCallCount())
+}